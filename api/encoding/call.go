@@ -91,6 +91,25 @@ func (c *Call) Caller() string {
 	return c.ic.req.Caller
 }
 
+// CallerIdentity returns the identity of the caller as verified by the
+// inbound transport, or an empty string if the transport did not
+// authenticate the caller.
+func (c *Call) CallerIdentity() string {
+	if c == nil {
+		return ""
+	}
+	return c.ic.req.CallerIdentity
+}
+
+// RemoteAddr returns the network address of the peer that sent this
+// request, if the inbound transport knows it.
+func (c *Call) RemoteAddr() string {
+	if c == nil {
+		return ""
+	}
+	return c.ic.req.RemoteAddr
+}
+
 // Service returns the name of the service being called.
 func (c *Call) Service() string {
 	if c == nil {
@@ -152,6 +171,35 @@ func (c *Call) HeaderNames() []string {
 	return names
 }
 
+// TransportHeader returns the value of the given header as the inbound
+// transport received it.
+func (c *Call) TransportHeader(k string) string {
+	if c == nil {
+		return ""
+	}
+
+	if v, ok := c.ic.req.TransportHeaders.Get(k); ok {
+		return v
+	}
+
+	return ""
+}
+
+// TransportHeaderNames returns a sorted list of the names of the headers
+// the inbound transport received with this request.
+func (c *Call) TransportHeaderNames() []string {
+	if c == nil {
+		return nil
+	}
+
+	var names []string
+	for k := range c.ic.req.TransportHeaders.Items() {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // ShardKey returns the shard key for this request.
 func (c *Call) ShardKey() string {
 	if c == nil {
@@ -55,3 +55,8 @@ func WithRoutingKey(rk string) CallOption {
 func WithRoutingDelegate(rd string) CallOption {
 	return CallOption{func(o *OutboundCall) { o.routingDelegate = &rd }}
 }
+
+// WithPeer pins the request to the peer with the given identifier.
+func WithPeer(id string) CallOption {
+	return CallOption{func(o *OutboundCall) { o.pinnedPeer = &id }}
+}
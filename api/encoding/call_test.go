@@ -88,6 +88,24 @@ func TestReadFromRequest(t *testing.T) {
 	assert.Equal(t, icall.resHeaders[0].v, "bar2")
 }
 
+func TestReadTransportInfoFromRequest(t *testing.T) {
+	ctx, icall := NewInboundCall(context.Background())
+	icall.ReadFromRequest(&transport.Request{
+		Caller:           "caller",
+		CallerIdentity:   "spiffe://example.com/caller",
+		RemoteAddr:       "10.0.0.1:53412",
+		TransportHeaders: transport.NewHeaders().With("User-Agent", "curl").With("Rpc-Caller", "caller"),
+	})
+	call := CallFromContext(ctx)
+	require.NotNil(t, call)
+
+	assert.Equal(t, "spiffe://example.com/caller", call.CallerIdentity())
+	assert.Equal(t, "10.0.0.1:53412", call.RemoteAddr())
+	assert.Equal(t, "curl", call.TransportHeader("user-agent"))
+	assert.Equal(t, "", call.TransportHeader("rpc-service"))
+	assert.Equal(t, []string{"rpc-caller", "user-agent"}, call.TransportHeaderNames())
+}
+
 func TestReadFromRequestMeta(t *testing.T) {
 	ctx, icall := NewInboundCall(context.Background())
 	icall.ReadFromRequestMeta(&transport.RequestMeta{
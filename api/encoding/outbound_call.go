@@ -23,6 +23,7 @@ package encoding
 import (
 	"context"
 
+	"go.uber.org/yarpc/api/peer"
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/yarpcerrors"
 )
@@ -39,6 +40,9 @@ type OutboundCall struct {
 	routingKey      *string
 	routingDelegate *string
 
+	// If non-nil, the request is pinned to this peer.
+	pinnedPeer *string
+
 	// If non-nil, response headers should be written here.
 	responseHeaders *map[string]string
 }
@@ -80,9 +84,12 @@ func (c *OutboundCall) WriteToRequest(ctx context.Context, req *transport.Reques
 	if c.routingDelegate != nil {
 		req.RoutingDelegate = *c.routingDelegate
 	}
+	if c.pinnedPeer != nil {
+		ctx = peer.ContextWithPinnedPeer(ctx, *c.pinnedPeer)
+	}
 
-	// NB(abg): context and error are unused for now but we want to leave room
-	// for CallOptions which can fail or modify the context.
+	// NB(abg): error is unused for now but we want to leave room for
+	// CallOptions which can fail.
 	return ctx, nil
 }
 
@@ -104,9 +111,12 @@ func (c *OutboundCall) WriteToRequestMeta(ctx context.Context, reqMeta *transpor
 	if c.routingDelegate != nil {
 		reqMeta.RoutingDelegate = *c.routingDelegate
 	}
+	if c.pinnedPeer != nil {
+		ctx = peer.ContextWithPinnedPeer(ctx, *c.pinnedPeer)
+	}
 
-	// NB(abg): context and error are unused for now but we want to leave room
-	// for CallOptions which can fail or modify the context.
+	// NB(abg): error is unused for now but we want to leave room for
+	// CallOptions which can fail.
 	return ctx, nil
 }
 
@@ -26,6 +26,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/peer"
 	"go.uber.org/yarpc/api/transport"
 )
 
@@ -186,3 +187,24 @@ func TestStreamOutboundCallCannotReadFromResponse(t *testing.T) {
 	assert.Contains(t, err.Error(), "response headers are not supported for streams")
 	assert.Nil(t, call)
 }
+
+func TestOutboundCallWithPeer(t *testing.T) {
+	call := NewOutboundCall(WithPeer("127.0.0.1:8080"))
+
+	ctx, err := call.WriteToRequest(context.Background(), &transport.Request{})
+	require.NoError(t, err)
+	id, ok := peer.PinnedPeer(ctx)
+	assert.True(t, ok, "request must be pinned")
+	assert.Equal(t, "127.0.0.1:8080", id)
+
+	ctx, err = call.WriteToRequestMeta(context.Background(), &transport.RequestMeta{})
+	require.NoError(t, err)
+	id, ok = peer.PinnedPeer(ctx)
+	assert.True(t, ok, "request must be pinned")
+	assert.Equal(t, "127.0.0.1:8080", id)
+
+	ctx, err = NewOutboundCall().WriteToRequest(context.Background(), &transport.Request{})
+	require.NoError(t, err)
+	_, ok = peer.PinnedPeer(ctx)
+	assert.False(t, ok, "request must not be pinned without WithPeer")
+}
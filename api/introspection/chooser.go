@@ -0,0 +1,50 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package introspection
+
+// IntrospectableChooser extends the Chooser interfaces.
+type IntrospectableChooser interface {
+	Introspect() ChooserStatus
+}
+
+// ChooserStatus is a collection of basic chooser info.
+type ChooserStatus struct {
+	Name  string       `json:"name"`
+	State string       `json:"state"`
+	Peers []PeerStatus `json:"peers"`
+}
+
+// PeerStatus is a collection of basic peers info.
+//
+// State summarizes the peer for display. Peer lists also report the
+// structured fields, which are left empty by choosers that do not track
+// them.
+type PeerStatus struct {
+	Identifier       string `json:"identifier"`
+	State            string `json:"state"`
+	ConnectionStatus string `json:"connectionStatus,omitempty"`
+	PendingRequests  int    `json:"pendingRequests"`
+	// LastError is the most recent error a request to the peer finished
+	// with, if any.
+	LastError string `json:"lastError,omitempty"`
+	// Weight is the weight of the peer, for lists that weigh their peers.
+	Weight int `json:"weight,omitempty"`
+}
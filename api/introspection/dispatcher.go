@@ -0,0 +1,72 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package introspection
+
+// DispatcherStatus represent detailed introspection information about a
+// dispatcher.
+type DispatcherStatus struct {
+	Name            string            `json:"name"`
+	ID              string            `json:"id"`
+	Procedures      []Procedure       `json:"procedures"`
+	Inbounds        []InboundStatus   `json:"inbounds"`
+	Outbounds       []OutboundStatus  `json:"outbounds"`
+	Transports      []TransportStatus `json:"transports"`
+	Middleware      MiddlewareStatus  `json:"middleware"`
+	PackageVersions []PackageVersion  `json:"packageVersions"`
+	Metrics         *MetricsStatus    `json:"metrics,omitempty"`
+}
+
+// Procedure represent a registered procedure on a dispatcher.
+type Procedure struct {
+	Service   string `json:"service"`
+	Name      string `json:"name"`
+	Encoding  string `json:"encoding"`
+	Signature string `json:"signature"`
+	RPCType   string `json:"rpcType"`
+}
+
+// TransportStatus is a collection of basic info about a transport used by
+// the inbounds and outbounds of a dispatcher.
+type TransportStatus struct {
+	// Type is the Go type of the transport, like "*http.Transport".
+	Type  string `json:"type"`
+	State string `json:"state"`
+}
+
+// MiddlewareStatus lists the middleware of a dispatcher.
+type MiddlewareStatus struct {
+	Inbound  MiddlewareChain `json:"inbound"`
+	Outbound MiddlewareChain `json:"outbound"`
+}
+
+// MiddlewareChain lists middleware by RPC type, in the order they see
+// requests. Middleware are named by their Go types.
+type MiddlewareChain struct {
+	Unary  []string `json:"unary"`
+	Oneway []string `json:"oneway"`
+	Stream []string `json:"stream"`
+}
+
+// PackageVersion represent a package and its version.
+type PackageVersion struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
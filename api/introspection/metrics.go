@@ -0,0 +1,55 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package introspection
+
+// MetricsStatus is a summary of the metrics a dispatcher records in memory.
+type MetricsStatus struct {
+	Counters  []CounterStatus   `json:"counters"`
+	Latencies []LatencyStatus   `json:"latencies"`
+	Peers     []PeerCountStatus `json:"peers"`
+}
+
+// CounterStatus is the current value of a single counter.
+type CounterStatus struct {
+	Name  string            `json:"name"`
+	Tags  map[string]string `json:"tags"`
+	Value int64             `json:"value"`
+}
+
+// LatencyStatus summarizes the observations of a single latency histogram.
+// Quantiles are reported as bucket upper bounds in the histogram's unit.
+type LatencyStatus struct {
+	Name  string            `json:"name"`
+	Tags  map[string]string `json:"tags"`
+	Unit  string            `json:"unit"`
+	Count int               `json:"count"`
+	P50   int64             `json:"p50"`
+	P90   int64             `json:"p90"`
+	P99   int64             `json:"p99"`
+	Max   int64             `json:"max"`
+}
+
+// PeerCountStatus is the number of peers retained by an outbound's chooser.
+type PeerCountStatus struct {
+	OutboundKey string `json:"outboundKey"`
+	RPCType     string `json:"rpcType"`
+	Peers       int    `json:"peers"`
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package middleware
+
+import (
+	"context"
+
+	"go.uber.org/yarpc/api/transport"
+)
+
+// StreamInterceptor intercepts the messages sent and received over a stream.
+//
+// StreamInbound and StreamOutbound middleware use it to observe or change
+// the individual messages of the streams they wrap, in addition to the
+// establishment of the streams.
+//
+// 	func (m *Middleware) HandleStream(s *transport.ServerStream, h transport.StreamHandler) error {
+// 		return h.HandleStream(middleware.InterceptServerStream(s, middleware.StreamInterceptor{
+// 			ReceiveMessage: func(ctx context.Context, receive middleware.ReceiveMessageFunc) (*transport.StreamMessage, error) {
+// 				msg, err := receive(ctx)
+// 				m.received.Inc()
+// 				return msg, err
+// 			},
+// 		}))
+// 	}
+type StreamInterceptor struct {
+	// SendMessage, if set, is called in place of the SendMessage method of
+	// the stream. send sends the message over the stream.
+	SendMessage func(ctx context.Context, msg *transport.StreamMessage, send SendMessageFunc) error
+
+	// ReceiveMessage, if set, is called in place of the ReceiveMessage method
+	// of the stream. receive receives a message from the stream.
+	ReceiveMessage func(ctx context.Context, receive ReceiveMessageFunc) (*transport.StreamMessage, error)
+}
+
+// SendMessageFunc sends a message over a stream.
+type SendMessageFunc func(context.Context, *transport.StreamMessage) error
+
+// ReceiveMessageFunc receives a message from a stream.
+type ReceiveMessageFunc func(context.Context) (*transport.StreamMessage, error)
+
+// InterceptServerStream returns a ServerStream whose messages pass through
+// the given StreamInterceptor before reaching the given ServerStream.
+func InterceptServerStream(s *transport.ServerStream, i StreamInterceptor) *transport.ServerStream {
+	if s == nil || (i.SendMessage == nil && i.ReceiveMessage == nil) {
+		return s
+	}
+	// NewServerStream only fails for nil streams.
+	ss, _ := transport.NewServerStream(interceptedStream{Stream: s, i: i})
+	return ss
+}
+
+// InterceptClientStream returns a ClientStream whose messages pass through
+// the given StreamInterceptor before reaching the given ClientStream.
+func InterceptClientStream(s *transport.ClientStream, i StreamInterceptor) *transport.ClientStream {
+	if s == nil || (i.SendMessage == nil && i.ReceiveMessage == nil) {
+		return s
+	}
+	// NewClientStream only fails for nil streams.
+	cs, _ := transport.NewClientStream(interceptedStreamCloser{
+		interceptedStream: interceptedStream{Stream: s, i: i},
+		closer:            s,
+	})
+	return cs
+}
+
+type interceptedStream struct {
+	transport.Stream
+
+	i StreamInterceptor
+}
+
+func (s interceptedStream) SendMessage(ctx context.Context, msg *transport.StreamMessage) error {
+	if s.i.SendMessage == nil {
+		return s.Stream.SendMessage(ctx, msg)
+	}
+	return s.i.SendMessage(ctx, msg, s.Stream.SendMessage)
+}
+
+func (s interceptedStream) ReceiveMessage(ctx context.Context) (*transport.StreamMessage, error) {
+	if s.i.ReceiveMessage == nil {
+		return s.Stream.ReceiveMessage(ctx)
+	}
+	return s.i.ReceiveMessage(ctx, s.Stream.ReceiveMessage)
+}
+
+type interceptedStreamCloser struct {
+	interceptedStream
+
+	closer *transport.ClientStream
+}
+
+func (s interceptedStreamCloser) Close(ctx context.Context) error {
+	return s.closer.Close(ctx)
+}
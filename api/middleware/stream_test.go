@@ -0,0 +1,133 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package middleware_test
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+)
+
+// countingInterceptor counts the messages sent and received over streams.
+type countingInterceptor struct {
+	sent     int
+	received int
+}
+
+func (c *countingInterceptor) interceptor() middleware.StreamInterceptor {
+	return middleware.StreamInterceptor{
+		SendMessage: func(ctx context.Context, msg *transport.StreamMessage, send middleware.SendMessageFunc) error {
+			c.sent++
+			return send(ctx, msg)
+		},
+		ReceiveMessage: func(ctx context.Context, receive middleware.ReceiveMessageFunc) (*transport.StreamMessage, error) {
+			c.received++
+			return receive(ctx)
+		},
+	}
+}
+
+func TestInterceptServerStream(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	ctx := context.Background()
+	req := &transport.StreamRequest{Meta: &transport.RequestMeta{Procedure: "hello"}}
+	msg := &transport.StreamMessage{Body: ioutil.NopCloser(bytes.NewReader([]byte("hi")))}
+
+	stream := transporttest.NewMockStream(mockCtrl)
+	stream.EXPECT().Context().Return(ctx)
+	stream.EXPECT().Request().Return(req)
+	stream.EXPECT().SendMessage(ctx, msg).Return(nil)
+	stream.EXPECT().ReceiveMessage(ctx).Return(msg, nil)
+
+	s, err := transport.NewServerStream(stream)
+	require.NoError(t, err)
+
+	var c countingInterceptor
+	s = middleware.InterceptServerStream(s, c.interceptor())
+
+	assert.Equal(t, ctx, s.Context())
+	assert.Equal(t, req, s.Request())
+	require.NoError(t, s.SendMessage(ctx, msg))
+	got, err := s.ReceiveMessage(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, msg, got)
+	assert.Equal(t, 1, c.sent)
+	assert.Equal(t, 1, c.received)
+}
+
+func TestInterceptClientStream(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	ctx := context.Background()
+	msg := &transport.StreamMessage{Body: ioutil.NopCloser(bytes.NewReader([]byte("hi")))}
+
+	stream := transporttest.NewMockStreamCloser(mockCtrl)
+	stream.EXPECT().SendMessage(ctx, msg).Return(nil).Times(2)
+	stream.EXPECT().ReceiveMessage(ctx).Return(msg, nil)
+	stream.EXPECT().Close(ctx).Return(nil)
+
+	s, err := transport.NewClientStream(stream)
+	require.NoError(t, err)
+
+	var c countingInterceptor
+	s = middleware.InterceptClientStream(s, c.interceptor())
+	s = middleware.InterceptClientStream(s, middleware.StreamInterceptor{
+		SendMessage: func(ctx context.Context, msg *transport.StreamMessage, send middleware.SendMessageFunc) error {
+			c.sent += 10
+			return send(ctx, msg)
+		},
+	})
+
+	require.NoError(t, s.SendMessage(ctx, msg))
+	require.NoError(t, s.SendMessage(ctx, msg))
+	_, err = s.ReceiveMessage(ctx)
+	require.NoError(t, err)
+	require.NoError(t, s.Close(ctx))
+	assert.Equal(t, 22, c.sent, "interceptors must compose")
+	assert.Equal(t, 1, c.received)
+}
+
+func TestInterceptStreamWithoutInterceptor(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	ss, err := transport.NewServerStream(transporttest.NewMockStream(mockCtrl))
+	require.NoError(t, err)
+	assert.True(t, ss == middleware.InterceptServerStream(ss, middleware.StreamInterceptor{}))
+
+	cs, err := transport.NewClientStream(transporttest.NewMockStreamCloser(mockCtrl))
+	require.NoError(t, err)
+	assert.True(t, cs == middleware.InterceptClientStream(cs, middleware.StreamInterceptor{}))
+
+	assert.Nil(t, middleware.InterceptServerStream(nil, middleware.StreamInterceptor{}))
+	assert.Nil(t, middleware.InterceptClientStream(nil, middleware.StreamInterceptor{}))
+}
@@ -0,0 +1,128 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package peer
+
+import "strconv"
+
+// Well-known attribute names. Peer list updaters should use these names for
+// the attributes they know, so that choosers and middleware from different
+// packages agree on them.
+const (
+	// ZoneAttribute is the zone, datacenter or region of the peer.
+	ZoneAttribute = "zone"
+
+	// WeightAttribute is the relative weight of the peer, as a decimal
+	// integer.
+	WeightAttribute = "weight"
+
+	// ShardAttribute is the shard or range of shards the peer serves.
+	ShardAttribute = "shard"
+)
+
+// Attributes are metadata about a peer, like its zone, weight or labels,
+// that peer list updaters attach to its identifier for choosers and
+// middleware to consume.
+type Attributes map[string]string
+
+// Zone returns the zone attribute, or an empty string if there is none.
+func (a Attributes) Zone() string {
+	return a[ZoneAttribute]
+}
+
+// Weight returns the weight attribute, and whether it is present and a valid
+// integer.
+func (a Attributes) Weight() (int, bool) {
+	s, ok := a[WeightAttribute]
+	if !ok {
+		return 0, false
+	}
+	w, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, false
+	}
+	return w, true
+}
+
+// AttributedIdentifier is a peer identifier that carries attributes.
+type AttributedIdentifier interface {
+	Identifier
+
+	Attributes() Attributes
+}
+
+// WithAttributes attaches attributes to a peer identifier. Attributes the
+// identifier already carries are kept unless the given attributes replace
+// them.
+func WithAttributes(id Identifier, attrs Attributes) AttributedIdentifier {
+	merged := AttributesOf(id)
+	if merged == nil {
+		merged = make(Attributes, len(attrs))
+	}
+	for k, v := range attrs {
+		merged[k] = v
+	}
+	return attributedIdentifier{id: id, attrs: merged}
+}
+
+type attributedIdentifier struct {
+	id    Identifier
+	attrs Attributes
+}
+
+func (i attributedIdentifier) Identifier() string     { return i.id.Identifier() }
+func (i attributedIdentifier) Attributes() Attributes { return i.attrs }
+
+// AttributesOf returns a copy of the attributes of a peer identifier or peer,
+// or nil if it has none.
+//
+// Besides the attributes of an AttributedIdentifier, the zone and weight of
+// identifiers with Zone and Weight methods, like those of the zone-aware and
+// weighted peer lists, are returned as the zone and weight attributes.
+func AttributesOf(v interface{}) Attributes {
+	var attrs Attributes
+	set := func(k, val string) {
+		if attrs == nil {
+			attrs = make(Attributes)
+		}
+		if _, ok := attrs[k]; !ok {
+			attrs[k] = val
+		}
+	}
+
+	if a, ok := v.(interface {
+		Attributes() Attributes
+	}); ok {
+		for k, val := range a.Attributes() {
+			set(k, val)
+		}
+	}
+	if z, ok := v.(interface {
+		Zone() string
+	}); ok && z.Zone() != "" {
+		set(ZoneAttribute, z.Zone())
+	}
+	if w, ok := v.(interface {
+		Weight() int
+	}); ok {
+		set(WeightAttribute, strconv.Itoa(w.Weight()))
+	}
+	return attrs
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package peer_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/peer/hostport"
+)
+
+// zonedWeighted is an identifier with Zone and Weight methods, like those of
+// the zone-aware and weighted peer lists.
+type zonedWeighted struct {
+	hostport.PeerIdentifier
+}
+
+func (zonedWeighted) Zone() string { return "us-east-1a" }
+func (zonedWeighted) Weight() int  { return 3 }
+
+func TestAttributesOf(t *testing.T) {
+	id := hostport.PeerIdentifier("127.0.0.1:8080")
+
+	assert.Nil(t, peer.AttributesOf(id), "plain identifiers must have no attributes")
+
+	attrs := peer.AttributesOf(zonedWeighted{id})
+	assert.Equal(t, peer.Attributes{"zone": "us-east-1a", "weight": "3"}, attrs)
+	w, ok := attrs.Weight()
+	assert.True(t, ok)
+	assert.Equal(t, 3, w)
+
+	attributed := peer.WithAttributes(zonedWeighted{id}, peer.Attributes{
+		peer.ShardAttribute:  "0-255",
+		peer.WeightAttribute: "5",
+	})
+	assert.Equal(t, "127.0.0.1:8080", attributed.Identifier())
+	assert.Equal(t, peer.Attributes{"zone": "us-east-1a", "weight": "5", "shard": "0-255"},
+		peer.AttributesOf(attributed), "given attributes must replace those of the identifier")
+
+	attrs = peer.AttributesOf(attributed)
+	attrs["zone"] = "eu-west-1a"
+	assert.Equal(t, "us-east-1a", attributed.Attributes().Zone(), "attributes must be copied")
+
+	_, ok = peer.Attributes{peer.WeightAttribute: "heavy"}.Weight()
+	assert.False(t, ok, "weights must be integers")
+}
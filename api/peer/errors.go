@@ -107,6 +107,17 @@ func (e ErrPeerRemoveNotInList) Error() string {
 	return fmt.Sprintf("can't remove peer (%s) because it is not in peerlist", string(e))
 }
 
+// ErrStaleSnapshot is returned to peer list updaters when a snapshot is not
+// newer than the last snapshot applied to the peer list.
+type ErrStaleSnapshot struct {
+	Version uint64
+	Applied uint64
+}
+
+func (e ErrStaleSnapshot) Error() string {
+	return fmt.Sprintf("can't apply snapshot version %d because version %d was already applied", e.Version, e.Applied)
+}
+
 // ErrChooseContextHasNoDeadline is returned when a context is sent to a peerlist with no deadline
 // DEPRECATED use yarpcerrors api instead.
 type ErrChooseContextHasNoDeadline string
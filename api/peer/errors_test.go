@@ -121,3 +121,10 @@ func TestErrChooseContextHasNoDeadline(t *testing.T) {
 	err := peer.ErrChooseContextHasNoDeadline(peerList)
 	assert.Equal(t, wantErr, err.Error())
 }
+
+func TestErrStaleSnapshot(t *testing.T) {
+	wantErr := "can't apply snapshot version 3 because version 5 was already applied"
+
+	err := peer.ErrStaleSnapshot{Version: 3, Applied: 5}
+	assert.Equal(t, wantErr, err.Error())
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package peer
+
+import (
+	"context"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/introspection"
+)
+
+// ChooserMiddleware defines middleware for peer Choosers, so that behaviors
+// like metrics, retrying failed choices, or filtering peers can be layered
+// onto any chooser.
+//
+// ChooserMiddleware MAY do zero or more of the following: change the
+// context, change the request, call the given chooser zero or more times,
+// wrap the returned onFinish callback, or return an error instead of a peer.
+//
+// ChooserMiddleware MUST return either a peer with a non-nil onFinish
+// callback or an error, and MUST be thread-safe.
+//
+// ChooserMiddleware is re-used across requests and MAY be called multiple
+// times on the same request.
+type ChooserMiddleware interface {
+	Choose(ctx context.Context, req *transport.Request, next Chooser) (peer Peer, onFinish func(error), err error)
+}
+
+// NopChooserMiddleware is a chooser middleware that does not do anything
+// special. It simply calls the underlying Chooser.
+var NopChooserMiddleware ChooserMiddleware = nopChooserMiddleware{}
+
+// ApplyChooserMiddleware applies the given ChooserMiddleware to the given
+// Chooser.
+//
+// The returned Chooser does not implement List. To keep updating a peer
+// list, apply middleware to the chooser returned by binding the list to an
+// updater.
+func ApplyChooserMiddleware(c Chooser, m ChooserMiddleware) Chooser {
+	if m == nil {
+		return c
+	}
+	return chooserWithMiddleware{c: c, m: m}
+}
+
+// ChooserMiddlewareFunc adapts a function into a ChooserMiddleware.
+type ChooserMiddlewareFunc func(context.Context, *transport.Request, Chooser) (Peer, func(error), error)
+
+// Choose for ChooserMiddlewareFunc.
+func (f ChooserMiddlewareFunc) Choose(ctx context.Context, req *transport.Request, next Chooser) (Peer, func(error), error) {
+	return f(ctx, req, next)
+}
+
+type chooserWithMiddleware struct {
+	c Chooser
+	m ChooserMiddleware
+}
+
+func (fc chooserWithMiddleware) Start() error {
+	return fc.c.Start()
+}
+
+func (fc chooserWithMiddleware) Stop() error {
+	return fc.c.Stop()
+}
+
+func (fc chooserWithMiddleware) IsRunning() bool {
+	return fc.c.IsRunning()
+}
+
+func (fc chooserWithMiddleware) Introspect() introspection.ChooserStatus {
+	if ic, ok := fc.c.(introspection.IntrospectableChooser); ok {
+		return ic.Introspect()
+	}
+	return introspection.ChooserStatus{}
+}
+
+func (fc chooserWithMiddleware) Choose(ctx context.Context, req *transport.Request) (Peer, func(error), error) {
+	return fc.m.Choose(ctx, req, fc.c)
+}
+
+type nopChooserMiddleware struct{}
+
+func (nopChooserMiddleware) Choose(ctx context.Context, req *transport.Request, next Chooser) (Peer, func(error), error) {
+	return next.Choose(ctx, req)
+}
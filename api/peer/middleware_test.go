@@ -0,0 +1,99 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package peer_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/introspection"
+	"go.uber.org/yarpc/peer/hostport"
+	"go.uber.org/yarpc/yarpctest"
+)
+
+// fixedChooser always chooses the same peer.
+type fixedChooser struct {
+	peer.Peer
+
+	running bool
+}
+
+func (c *fixedChooser) Start() error    { c.running = true; return nil }
+func (c *fixedChooser) Stop() error     { c.running = false; return nil }
+func (c *fixedChooser) IsRunning() bool { return c.running }
+
+func (c *fixedChooser) Choose(context.Context, *transport.Request) (peer.Peer, func(error), error) {
+	return c.Peer, func(error) {}, nil
+}
+
+func newFixedChooser(t *testing.T) *fixedChooser {
+	p, err := yarpctest.NewFakeTransport().RetainPeer(hostport.PeerIdentifier("a:1"), nil)
+	require.NoError(t, err)
+	return &fixedChooser{Peer: p}
+}
+
+func TestApplyChooserMiddleware(t *testing.T) {
+	c := newFixedChooser(t)
+	assert.Equal(t, c, peer.ApplyChooserMiddleware(c, nil), "nil middleware must not wrap the chooser")
+
+	var finished error
+	mw := peer.ChooserMiddlewareFunc(func(ctx context.Context, req *transport.Request, next peer.Chooser) (peer.Peer, func(error), error) {
+		if req.Procedure == "forbidden" {
+			return nil, nil, errors.New("forbidden")
+		}
+		p, onFinish, err := next.Choose(ctx, req)
+		return p, func(err error) {
+			finished = err
+			onFinish(err)
+		}, err
+	})
+	chooser := peer.ApplyChooserMiddleware(c, mw)
+
+	require.NoError(t, chooser.Start())
+	assert.True(t, c.IsRunning(), "Start must start the underlying chooser")
+	assert.True(t, chooser.IsRunning())
+
+	p, onFinish, err := chooser.Choose(context.Background(), &transport.Request{})
+	require.NoError(t, err)
+	assert.Equal(t, "a:1", p.Identifier())
+	onFinish(errors.New("great sadness"))
+	assert.EqualError(t, finished, "great sadness", "middleware must see the request finish")
+
+	_, _, err = chooser.Choose(context.Background(), &transport.Request{Procedure: "forbidden"})
+	assert.EqualError(t, err, "forbidden")
+
+	assert.Equal(t, introspection.ChooserStatus{}, chooser.(introspection.IntrospectableChooser).Introspect())
+
+	require.NoError(t, chooser.Stop())
+	assert.False(t, c.IsRunning(), "Stop must stop the underlying chooser")
+}
+
+func TestNopChooserMiddleware(t *testing.T) {
+	c := newFixedChooser(t)
+	p, _, err := peer.ApplyChooserMiddleware(c, peer.NopChooserMiddleware).Choose(context.Background(), &transport.Request{})
+	require.NoError(t, err)
+	assert.Equal(t, "a:1", p.Identifier())
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package peertest
+
+import (
+	"sort"
+	"sync"
+
+	"go.uber.org/yarpc/api/peer"
+)
+
+// FakeList is a peer.List that records the peers it holds, for testing peer
+// list updaters.
+type FakeList struct {
+	mu      sync.Mutex
+	peers   map[string]peer.Identifier
+	updates int
+}
+
+// NewFakeList returns a FakeList without peers.
+func NewFakeList() *FakeList {
+	return &FakeList{peers: make(map[string]peer.Identifier)}
+}
+
+// Update adds and removes the given peers.
+func (l *FakeList) Update(updates peer.ListUpdates) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.updates++
+	for _, pid := range updates.Removals {
+		delete(l.peers, pid.Identifier())
+	}
+	for _, pid := range updates.Additions {
+		l.peers[pid.Identifier()] = pid
+	}
+	return nil
+}
+
+// IDs returns the sorted identifiers of the peers in the list.
+func (l *FakeList) IDs() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	ids := make([]string, 0, len(l.peers))
+	for id := range l.peers {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Peer returns the peer.Identifier the given peer was added with, or nil if
+// the peer is not in the list.
+func (l *FakeList) Peer(id string) peer.Identifier {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.peers[id]
+}
+
+// Updates returns how many times Update has been called.
+func (l *FakeList) Updates() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.updates
+}
@@ -0,0 +1,41 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package peer
+
+// Snapshot is the full membership of a peer list as seen by a peer list
+// updater. Updaters that learn the whole set of peers at once, like when
+// they poll a registry or reconnect to it, may send snapshots instead of
+// computing additions and removals themselves.
+//
+// See go.uber.org/yarpc/peer.SnapshotList for applying snapshots to a List.
+type Snapshot struct {
+	// Version orders the snapshots of an updater. A snapshot whose version
+	// is not greater than the version of the last applied snapshot is stale
+	// and is rejected, so that a slow update cannot undo a newer one.
+	//
+	// Version zero is unversioned. Unversioned snapshots are always applied,
+	// and the next versioned snapshot is accepted regardless of its version,
+	// which lets updaters whose sources restart their versions start over.
+	Version uint64
+
+	// Peers are all the peers that should be in the list.
+	Peers []Identifier
+}
@@ -51,6 +51,30 @@ type Request struct {
 	// Name of the service making the request.
 	Caller string
 
+	// CallerIdentity is the identity of the caller as verified by the
+	// inbound transport, like the SPIFFE ID of the certificate the caller
+	// presented over mutually authenticated TLS. Unlike Caller, the caller
+	// cannot claim any identity it likes.
+	//
+	// It is set by inbound transports and is empty if the transport did not
+	// authenticate the caller.
+	CallerIdentity string
+
+	// RemoteAddr is the network address of the peer that sent the request,
+	// like "10.0.0.1:53412".
+	//
+	// It is set by inbound transports and is empty if the transport does not
+	// know it.
+	RemoteAddr string
+
+	// TransportHeaders are the headers of the request as the inbound
+	// transport received them, including those that YARPC reserves for
+	// itself, like the HTTP headers which carry the caller and the TTL.
+	//
+	// They are set by inbound transports which have headers of their own,
+	// like HTTP and gRPC.
+	TransportHeaders Headers
+
 	// Name of the service to which the request is being made.
 	// The service refers to the canonical traffic group for the service.
 	Service string
@@ -20,7 +20,11 @@
 
 package transport
 
-import "io"
+import (
+	"io"
+
+	"go.uber.org/yarpc/yarpcerrors"
+)
 
 // Response is the low level response representation.
 type Response struct {
@@ -48,6 +52,45 @@ type Response struct {
 	Headers          Headers
 	Body             io.ReadCloser
 	ApplicationError bool
+
+	// ApplicationErrorMeta describes the application error of the response,
+	// if the transport carried one. It is nil unless ApplicationError is
+	// set, and may be nil even then.
+	ApplicationErrorMeta *ApplicationErrorMeta
+}
+
+// ApplicationErrorMeta describes an application error, like a declared
+// Thrift exception, so that middleware can observe and classify it without
+// decoding the response body.
+type ApplicationErrorMeta struct {
+	// Name identifies the kind of error, like the name of the type of a
+	// Thrift exception.
+	Name string
+
+	// Message describes the error.
+	Message string
+
+	// Details are an encoding-specific representation of the error, like a
+	// Thrift exception encoded with the Thrift binary protocol.
+	Details []byte
+}
+
+// Status returns the application error as a YARPC error with code
+// CodeUnknown which carries its details, or nil if there is none.
+func (m *ApplicationErrorMeta) Status() *yarpcerrors.Status {
+	if m == nil {
+		return nil
+	}
+	return yarpcerrors.Newf(yarpcerrors.CodeUnknown, "%s", m.Message).WithDetails(m.Details)
+}
+
+// ApplicationErrorMetaSetter is implemented by ResponseWriters of
+// transports which can carry ApplicationErrorMeta with responses.
+type ApplicationErrorMetaSetter interface {
+	// SetApplicationErrorMeta describes the application error of the
+	// response. If called, this MUST be called before any invocation of
+	// Write().
+	SetApplicationErrorMeta(*ApplicationErrorMeta)
 }
 
 // ResponseWriter allows Handlers to write responses in a streaming fashion.
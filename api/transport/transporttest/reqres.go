@@ -244,9 +244,10 @@ func (m ResponseMatcher) Matches(got interface{}) bool {
 // FakeResponseWriter is a ResponseWriter that records the headers and the body
 // written to it.
 type FakeResponseWriter struct {
-	IsApplicationError bool
-	Headers            transport.Headers
-	Body               bytes.Buffer
+	IsApplicationError   bool
+	ApplicationErrorMeta *transport.ApplicationErrorMeta
+	Headers              transport.Headers
+	Body                 bytes.Buffer
 }
 
 // SetApplicationError for FakeResponseWriter.
@@ -254,6 +255,11 @@ func (fw *FakeResponseWriter) SetApplicationError() {
 	fw.IsApplicationError = true
 }
 
+// SetApplicationErrorMeta for FakeResponseWriter.
+func (fw *FakeResponseWriter) SetApplicationErrorMeta(meta *transport.ApplicationErrorMeta) {
+	fw.ApplicationErrorMeta = meta
+}
+
 // AddHeaders for FakeResponseWriter.
 func (fw *FakeResponseWriter) AddHeaders(h transport.Headers) {
 	for k, v := range h.OriginalItems() {
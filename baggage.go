@@ -0,0 +1,59 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpc
+
+import (
+	"context"
+
+	"go.uber.org/yarpc/internal/baggage"
+)
+
+// WithBaggage returns a copy of the context whose baggage has the key set to
+// the value. Baggage is request-scoped information, like the tenant of a
+// request or the experiments it is part of, that every service downstream
+// needs.
+//
+// Baggage reaches the services a request calls when the baggage middleware
+// of go.uber.org/yarpc/x/middleware/baggage is used as outbound middleware,
+// and is read from requests a service handles when it is used as inbound
+// middleware. Keys are case-insensitive.
+//
+// 	ctx = yarpc.WithBaggage(ctx, "tenant", "acme")
+// 	res, err := client.GetUser(ctx, req)
+func WithBaggage(ctx context.Context, key, value string) context.Context {
+	return baggage.With(ctx, key, value)
+}
+
+// Baggage returns a copy of the baggage of the context, keyed by canonical
+// keys, or nil if it has none.
+//
+// 	tenant := yarpc.Baggage(ctx)["tenant"]
+func Baggage(ctx context.Context) map[string]string {
+	items := baggage.FromContext(ctx)
+	if len(items) == 0 {
+		return nil
+	}
+	copied := make(map[string]string, len(items))
+	for k, v := range items {
+		copied[k] = v
+	}
+	return copied
+}
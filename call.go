@@ -71,16 +71,34 @@ func WithShardKey(sk string) CallOption {
 	return CallOption(encoding.WithShardKey(sk))
 }
 
-// WithRoutingKey sets the routing key for the request.
+// WithRoutingKey sets the routing key for the request, which mesh routers
+// may use instead of the service name to route it.
 func WithRoutingKey(rk string) CallOption {
 	return CallOption(encoding.WithRoutingKey(rk))
 }
 
-// WithRoutingDelegate sets the routing delegate for the request.
+// WithRoutingDelegate sets the routing delegate for the request, a service
+// that mesh routers may send it through instead of the destined service.
 func WithRoutingDelegate(rd string) CallOption {
 	return CallOption(encoding.WithRoutingDelegate(rd))
 }
 
+// WithPeer pins the request to the peer with the given identifier, like
+// "127.0.0.1:8080", instead of letting the peer chooser of the outbound pick
+// one. Use it to address a specific instance, like for scatter-gather or
+// administrative calls.
+//
+// 	for _, id := range instances {
+// 		res, err := client.Flush(ctx, req, yarpc.WithPeer(id))
+// 		...
+// 	}
+//
+// The call fails if the peer chooser does not retain the peer. The peer lists
+// in the go.uber.org/yarpc/peer package tree support pinning.
+func WithPeer(id string) CallOption {
+	return CallOption(encoding.WithPeer(id))
+}
+
 // Call provides information about the current request inside handlers. An
 // instance of Call for the current request can be obtained by calling
 // CallFromContext on the request context.
@@ -129,6 +147,19 @@ func (c *Call) Caller() string {
 	return (*encoding.Call)(c).Caller()
 }
 
+// CallerIdentity returns the identity of the caller as verified by the
+// inbound transport, like the SPIFFE ID of its TLS certificate, or an empty
+// string if the transport did not authenticate the caller.
+func (c *Call) CallerIdentity() string {
+	return (*encoding.Call)(c).CallerIdentity()
+}
+
+// RemoteAddr returns the network address of the peer that sent this
+// request, like "10.0.0.1:53412", if the inbound transport knows it.
+func (c *Call) RemoteAddr() string {
+	return (*encoding.Call)(c).RemoteAddr()
+}
+
 // Service returns the name of the service being called.
 func (c *Call) Service() string {
 	return (*encoding.Call)(c).Service()
@@ -161,6 +192,22 @@ func (c *Call) HeaderNames() []string {
 	return (*encoding.Call)(c).HeaderNames()
 }
 
+// TransportHeader returns the value of the given header as the inbound
+// transport received it, including headers YARPC reserves for itself and
+// does not pass to handlers through Header. Only transports with headers of
+// their own, like HTTP and gRPC, provide them.
+//
+// 	userAgent := yarpc.CallFromContext(ctx).TransportHeader("User-Agent")
+func (c *Call) TransportHeader(k string) string {
+	return (*encoding.Call)(c).TransportHeader(k)
+}
+
+// TransportHeaderNames returns a sorted list of the names of the headers
+// the inbound transport received with this request.
+func (c *Call) TransportHeaderNames() []string {
+	return (*encoding.Call)(c).TransportHeaderNames()
+}
+
 // ShardKey returns the shard key for this request.
 func (c *Call) ShardKey() string {
 	return (*encoding.Call)(c).ShardKey()
@@ -20,7 +20,17 @@
 
 package yarpc
 
-import "go.uber.org/yarpc/api/transport"
+import (
+	"context"
+	"time"
+
+	"go.uber.org/yarpc/api/encoding"
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/clientconfig"
+	"go.uber.org/yarpc/internal/onclose"
+)
 
 // ClientConfig builds transport.ClientConfigs which specify the means of
 // making a request from this service to another service by name.
@@ -29,3 +39,114 @@ type ClientConfig interface {
 }
 
 var _ ClientConfig = (*Dispatcher)(nil)
+
+// ClientDefaults are defaults for every call made through a client.
+type ClientDefaults struct {
+	// CallOptions apply to every call as though they were passed at the call
+	// site, like WithHeader, WithShardKey, or WithPeer. Options passed at the call site
+	// take precedence over them. ResponseHeaders cannot be a default.
+	CallOptions []CallOption
+
+	// Timeout is the deadline of unary and oneway calls made with contexts
+	// that have none. It does not apply to streams.
+	Timeout time.Duration
+}
+
+// WithClientDefaults returns a ClientConfig which makes all calls through the
+// given ClientConfig with the given defaults. Use it to build clients whose
+// calls share headers, shard keys, or timeouts, instead of repeating them at
+// each call site.
+//
+// 	cc := yarpc.WithClientDefaults(dispatcher.ClientConfig("users"), yarpc.ClientDefaults{
+// 		CallOptions: []yarpc.CallOption{yarpc.WithHeader("tenant", "acme")},
+// 		Timeout:     time.Second,
+// 	})
+// 	client := json.New(cc)
+func WithClientDefaults(cc transport.ClientConfig, defaults ClientDefaults) transport.ClientConfig {
+	mw := newClientDefaults(defaults)
+	return clientconfig.ApplyMiddleware(cc, mw, mw, mw)
+}
+
+var (
+	_ middleware.UnaryOutbound  = (*clientDefaults)(nil)
+	_ middleware.OnewayOutbound = (*clientDefaults)(nil)
+	_ middleware.StreamOutbound = (*clientDefaults)(nil)
+)
+
+// clientDefaults is outbound middleware which fills in the parts of requests
+// that their callers left out.
+type clientDefaults struct {
+	meta    transport.RequestMeta
+	peer    string
+	timeout time.Duration
+}
+
+func newClientDefaults(defaults ClientDefaults) *clientDefaults {
+	opts := make([]encoding.CallOption, len(defaults.CallOptions))
+	for i, o := range defaults.CallOptions {
+		opts[i] = encoding.CallOption(o)
+	}
+
+	c := &clientDefaults{timeout: defaults.Timeout}
+	// Writing the options to a request is how we find out what they set.
+	ctx, _ := encoding.NewOutboundCall(opts...).WriteToRequestMeta(context.Background(), &c.meta)
+	c.peer, _ = peer.PinnedPeer(ctx)
+	return c
+}
+
+func (c *clientDefaults) fill(headers *transport.Headers, shardKey, routingKey, routingDelegate *string) {
+	for k, v := range c.meta.Headers.OriginalItems() {
+		if _, ok := headers.Get(k); !ok {
+			*headers = headers.With(k, v)
+		}
+	}
+	if *shardKey == "" {
+		*shardKey = c.meta.ShardKey
+	}
+	if *routingKey == "" {
+		*routingKey = c.meta.RoutingKey
+	}
+	if *routingDelegate == "" {
+		*routingDelegate = c.meta.RoutingDelegate
+	}
+}
+
+// withPeer pins requests to the default peer, unless the caller pinned them
+// to another.
+func (c *clientDefaults) withPeer(ctx context.Context) context.Context {
+	if _, ok := peer.PinnedPeer(ctx); ok || c.peer == "" {
+		return ctx
+	}
+	return peer.ContextWithPinnedPeer(ctx, c.peer)
+}
+
+func (c *clientDefaults) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok || c.timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, c.timeout)
+}
+
+func (c *clientDefaults) Call(ctx context.Context, req *transport.Request, out transport.UnaryOutbound) (*transport.Response, error) {
+	ctx, cancel := c.withTimeout(c.withPeer(ctx))
+	c.fill(&req.Headers, &req.ShardKey, &req.RoutingKey, &req.RoutingDelegate)
+	res, err := out.Call(ctx, req)
+	// The context is canceled when the response body is closed rather than
+	// when the call returns, since the body may still be read with it.
+	onclose.Response(res, cancel)
+	return res, err
+}
+
+func (c *clientDefaults) CallOneway(ctx context.Context, req *transport.Request, out transport.OnewayOutbound) (transport.Ack, error) {
+	// The context is not canceled when the call returns, since the outbound
+	// may still use it to deliver the request.
+	ctx, _ = c.withTimeout(c.withPeer(ctx))
+	c.fill(&req.Headers, &req.ShardKey, &req.RoutingKey, &req.RoutingDelegate)
+	return out.CallOneway(ctx, req)
+}
+
+func (c *clientDefaults) CallStream(ctx context.Context, req *transport.StreamRequest, out transport.StreamOutbound) (*transport.ClientStream, error) {
+	meta := req.Meta
+	c.fill(&meta.Headers, &meta.ShardKey, &meta.RoutingKey, &meta.RoutingDelegate)
+	return out.CallStream(c.withPeer(ctx), req)
+}
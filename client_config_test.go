@@ -0,0 +1,196 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpc
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+)
+
+// recordingOutbound records the last request it was called with, and
+// whether its context had a deadline. Its responses have a body which reports
+// the error of the context of the call when it is read.
+type recordingOutbound struct {
+	transport.Outbound
+
+	req         *transport.Request
+	streamReq   *transport.StreamRequest
+	hasDeadline bool
+	pinnedPeer  string
+}
+
+func (o *recordingOutbound) Call(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	o.req = req
+	_, o.hasDeadline = ctx.Deadline()
+	o.pinnedPeer, _ = peer.PinnedPeer(ctx)
+	return &transport.Response{Body: ctxBody{ctx}}, nil
+}
+
+func (o *recordingOutbound) CallOneway(ctx context.Context, req *transport.Request) (transport.Ack, error) {
+	o.req = req
+	_, o.hasDeadline = ctx.Deadline()
+	return nil, nil
+}
+
+func (o *recordingOutbound) CallStream(ctx context.Context, req *transport.StreamRequest) (*transport.ClientStream, error) {
+	o.streamReq = req
+	return nil, nil
+}
+
+func TestWithClientDefaults(t *testing.T) {
+	out := &recordingOutbound{}
+	cc := WithClientDefaults(&transport.OutboundConfig{
+		CallerName: "frontend",
+		Outbounds: transport.Outbounds{
+			ServiceName: "users",
+			Unary:       out,
+			Oneway:      out,
+			Stream:      out,
+		},
+	}, ClientDefaults{
+		CallOptions: []CallOption{
+			WithHeader("Tenant", "acme"),
+			WithHeader("region", "us-east"),
+			WithShardKey("shard01"),
+		},
+		Timeout: time.Second,
+	})
+
+	oc, ok := cc.(*transport.OutboundConfig)
+	require.True(t, ok, "defaults must keep the OutboundConfig")
+	assert.Equal(t, "frontend", oc.CallerName)
+	assert.Equal(t, "users", oc.Outbounds.ServiceName)
+
+	t.Run("unary", func(t *testing.T) {
+		_, err := cc.GetUnaryOutbound().Call(context.Background(), &transport.Request{
+			Headers: transport.NewHeaders().With("region", "eu-west"),
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, map[string]string{"tenant": "acme", "region": "eu-west"}, out.req.Headers.Items(),
+			"headers passed at the call site must take precedence")
+		assert.Equal(t, "Tenant", headerCase(out.req.Headers, "tenant"), "defaults must keep the case of their headers")
+		assert.Equal(t, "shard01", out.req.ShardKey)
+		assert.True(t, out.hasDeadline, "calls without a deadline must get the default timeout")
+	})
+
+	t.Run("oneway", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		_, err := cc.GetOnewayOutbound().CallOneway(ctx, &transport.Request{ShardKey: "shard02"})
+		require.NoError(t, err)
+
+		assert.Equal(t, "shard02", out.req.ShardKey, "shard keys passed at the call site must take precedence")
+		assert.Equal(t, "acme", headerValue(out.req.Headers, "tenant"))
+		assert.True(t, out.hasDeadline)
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		_, err := oc.Outbounds.Stream.CallStream(context.Background(), &transport.StreamRequest{
+			Meta: &transport.RequestMeta{RoutingKey: "canary"},
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, "acme", headerValue(out.streamReq.Meta.Headers, "tenant"))
+		assert.Equal(t, "shard01", out.streamReq.Meta.ShardKey)
+		assert.Equal(t, "canary", out.streamReq.Meta.RoutingKey)
+	})
+}
+
+func TestWithClientDefaultsResponseBody(t *testing.T) {
+	out := &recordingOutbound{}
+	cc := WithClientDefaults(clientConfig{out}, ClientDefaults{Timeout: time.Minute})
+
+	res, err := cc.GetUnaryOutbound().Call(context.Background(), &transport.Request{})
+	require.NoError(t, err)
+	require.True(t, out.hasDeadline)
+	_, err = ioutil.ReadAll(res.Body)
+	assert.NoError(t, err, "the body must be readable after the call returns")
+
+	require.NoError(t, res.Body.Close())
+	_, err = ioutil.ReadAll(res.Body)
+	assert.Equal(t, context.Canceled, err, "the context must be canceled once the body is closed")
+}
+
+func TestWithClientDefaultsWithoutTimeout(t *testing.T) {
+	out := &recordingOutbound{}
+	cc := WithClientDefaults(clientConfig{out}, ClientDefaults{
+		CallOptions: []CallOption{WithRoutingDelegate("proxy")},
+	})
+	_, isOutboundConfig := cc.(*transport.OutboundConfig)
+	assert.False(t, isOutboundConfig)
+	assert.Equal(t, "users", cc.Service())
+
+	_, err := cc.GetUnaryOutbound().Call(context.Background(), &transport.Request{})
+	require.NoError(t, err)
+	assert.Equal(t, "proxy", out.req.RoutingDelegate)
+	assert.False(t, out.hasDeadline, "calls must not get a deadline without a default timeout")
+
+	_, err = cc.GetOnewayOutbound().CallOneway(context.Background(), &transport.Request{RoutingDelegate: "other"})
+	require.NoError(t, err)
+	assert.Equal(t, "other", out.req.RoutingDelegate)
+}
+
+func TestWithClientDefaultsPeer(t *testing.T) {
+	out := &recordingOutbound{}
+	cc := WithClientDefaults(clientConfig{out}, ClientDefaults{
+		CallOptions: []CallOption{WithPeer("127.0.0.1:8080")},
+	})
+
+	_, err := cc.GetUnaryOutbound().Call(context.Background(), &transport.Request{})
+	require.NoError(t, err)
+	assert.Equal(t, "127.0.0.1:8080", out.pinnedPeer)
+
+	ctx := peer.ContextWithPinnedPeer(context.Background(), "127.0.0.1:9090")
+	_, err = cc.GetUnaryOutbound().Call(ctx, &transport.Request{})
+	require.NoError(t, err)
+	assert.Equal(t, "127.0.0.1:9090", out.pinnedPeer, "peers pinned at the call site must take precedence")
+}
+
+// clientConfig is a ClientConfig which is not a transport.OutboundConfig.
+type clientConfig struct{ out *recordingOutbound }
+
+func (clientConfig) Caller() string                                { return "frontend" }
+func (clientConfig) Service() string                               { return "users" }
+func (c clientConfig) GetUnaryOutbound() transport.UnaryOutbound   { return c.out }
+func (c clientConfig) GetOnewayOutbound() transport.OnewayOutbound { return c.out }
+
+func headerValue(h transport.Headers, k string) string {
+	v, _ := h.Get(k)
+	return v
+}
+
+// headerCase returns the name the given header was set with.
+func headerCase(h transport.Headers, k string) string {
+	for name := range h.OriginalItems() {
+		if transport.CanonicalizeHeaderKey(name) == k {
+			return name
+		}
+	}
+	return ""
+}
@@ -29,6 +29,7 @@ import (
 	"go.uber.org/net/metrics"
 	"go.uber.org/net/metrics/tallypush"
 	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/internal/observability"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -86,12 +87,67 @@ type MetricsConfig struct {
 	// default, metrics are collected in memory but not pushed.
 	// TODO deprecate this option for metrics configuration.
 	Tally tally.Scope
+	// Expvar publishes the metrics of the dispatcher through expvar under
+	// the "yarpc" variable, and renders them on the debug page. If neither
+	// Metrics nor Tally is supplied, metrics are recorded in memory for this
+	// purpose.
+	Expvar bool
+	// Tags are additional tags on the metrics of the observability
+	// middleware, whose values are extracted from each request. Since every
+	// distinct combination of tags is a separate time series, tag values
+	// should come from a small set.
+	Tags []MetricsTag
+	// RenameTags renames default tags on the metrics of the observability
+	// middleware, like "source" and "dest", keyed by their default names.
+	RenameTags map[string]string
+	// DropTags are the names of default tags to leave out of the metrics of
+	// the observability middleware. Requests that differ only by dropped tags
+	// share metrics.
+	DropTags []string
 }
 
-func (c MetricsConfig) scope(name string, logger *zap.Logger) (*metrics.Scope, context.CancelFunc) {
-	// Neither: no-op metrics, not pushed
+// MetricsTag is an additional tag on the metrics of the observability
+// middleware.
+type MetricsTag struct {
+	// Name of the tag.
+	Name string
+	// Value returns the value of the tag for a request.
+	Value func(context.Context, *transport.Request) string
+}
+
+// HeaderMetricsTag returns a MetricsTag whose value is the value of the given
+// request header, or an empty string if the request does not have it.
+func HeaderMetricsTag(name, header string) MetricsTag {
+	return MetricsTag{
+		Name: name,
+		Value: func(_ context.Context, req *transport.Request) string {
+			v, _ := req.Headers.Get(header)
+			return v
+		},
+	}
+}
+
+func (c MetricsConfig) tags() observability.TagConfig {
+	cfg := observability.TagConfig{
+		Rename: c.RenameTags,
+		Drop:   c.DropTags,
+	}
+	for _, tag := range c.Tags {
+		cfg.Extra = append(cfg.Extra, observability.Tag{Name: tag.Name, Value: tag.Value})
+	}
+	return cfg
+}
+
+// scope returns the scope used to record metrics, along with the metrics
+// root if the dispatcher owns it.
+func (c MetricsConfig) scope(name string, logger *zap.Logger) (*metrics.Scope, *metrics.Root, context.CancelFunc) {
+	// Neither: no-op metrics, or in-memory metrics published through expvar
 	if c.Metrics == nil && c.Tally == nil {
-		return nil, func() {}
+		if !c.Expvar {
+			return nil, nil, func() {}
+		}
+		root := metrics.New()
+		return root.Scope().Tagged(dispatcherTags(name)), root, func() {}
 	}
 
 	// Both: ignore Tally and warn.
@@ -114,23 +170,27 @@ func (c MetricsConfig) scope(name string, logger *zap.Logger) (*metrics.Scope, c
 		parent = root.Scope()
 	}
 
-	meter := parent.Tagged(metrics.Tags{
-		"component":  _packageName,
-		"dispatcher": name,
-	})
+	meter := parent.Tagged(dispatcherTags(name))
 
 	// When we have c.Metrics, we do not push
 	if root == nil {
-		return meter, func() {}
+		return meter, nil, func() {}
 	}
 
 	// When we have c.Tally, we measure *and* push
 	stopMeter, err := root.Push(tallypush.New(c.Tally), _tallyPushInterval)
 	if err != nil {
 		logger.Error("Failed to start pushing metrics to Tally.", zap.Error(err))
-		return meter, func() {}
+		return meter, root, func() {}
+	}
+	return meter, root, stopMeter
+}
+
+func dispatcherTags(name string) metrics.Tags {
+	return metrics.Tags{
+		"component":  _packageName,
+		"dispatcher": name,
 	}
-	return meter, stopMeter
 }
 
 // Config specifies the parameters of a new Dispatcher constructed via
@@ -140,6 +200,11 @@ type Config struct {
 	// making requests to this service.
 	Name string
 
+	// Aliases are other names of this service, which it answers to as well,
+	// like the names of services it replaces. Requests to an alias are routed
+	// to the procedures registered under Name.
+	Aliases []string
+
 	// Inbounds define how this service receives incoming requests from other
 	// services.
 	//
@@ -179,4 +244,23 @@ type Config struct {
 	// observability middleware is being inserted in the Inbound/Outbound
 	// Middleware.
 	DisableAutoObservabilityMiddleware bool
+
+	// DrainTimeout is how long Stop waits for requests in flight to finish
+	// before it stops inbounds, outbounds, and transports. While draining,
+	// new inbound requests are rejected with an Unavailable error. Requests
+	// still in flight after the timeout are canceled, and Stop reports them
+	// in a DrainError.
+	//
+	// By default, Stop does not wait for requests in flight.
+	DrainTimeout time.Duration
+
+	// Startup configures the readiness gates and timeouts of the phases of
+	// starting the Dispatcher.
+	Startup StartupConfig
+
+	// HandlerTimeout configures how the Dispatcher enforces the deadlines of
+	// unary requests on their handlers.
+	//
+	// By default, handlers are trusted to stop when their context is done.
+	HandlerTimeout HandlerTimeoutConfig
 }
@@ -23,6 +23,8 @@ package yarpc
 import (
 	"context"
 	"fmt"
+	"sync"
+	"time"
 
 	"go.uber.org/multierr"
 	"go.uber.org/net/metrics"
@@ -74,33 +76,87 @@ func NewDispatcher(cfg Config) *Dispatcher {
 	if err := internal.ValidateServiceName(cfg.Name); err != nil {
 		panic("yarpc.NewDispatcher expects a valid service name: " + err.Error())
 	}
+	for _, alias := range cfg.Aliases {
+		if err := internal.ValidateServiceName(alias); err != nil {
+			panic("yarpc.NewDispatcher expects valid service aliases: " + err.Error())
+		}
+	}
 
 	logger := cfg.Logging.logger(cfg.Name)
 	extractor := cfg.Logging.extractor()
 
-	meter, stopMeter := cfg.Metrics.scope(cfg.Name, logger)
-	cfg = addObservingMiddleware(cfg, meter, logger, extractor)
+	tags := cfg.Metrics.tags()
+	if err := tags.Validate(); err != nil {
+		panic("yarpc.NewDispatcher expects valid metrics tags: " + err.Error())
+	}
+
+	meter, metricsRoot, stopMeter := cfg.Metrics.scope(cfg.Name, logger)
+	if cfg.HandlerTimeout.Enforce {
+		cfg = addHandlerTimeoutMiddleware(cfg, logger)
+	}
+	var inflight *inflightTracker
+	if cfg.DrainTimeout > 0 {
+		inflight = newInflightTracker()
+		cfg = addInflightMiddleware(cfg, inflight)
+	}
+	cfg = addObservingMiddleware(cfg, meter, logger, extractor, tags)
 
+	router := NewMapRouter(cfg.Name)
+	for _, alias := range cfg.Aliases {
+		router.RegisterAlias(alias, cfg.Name)
+	}
+	outbounds, reloadable := newReloadableOutbounds(convertOutbounds(cfg.Outbounds, cfg.OutboundMiddleware))
 	return &Dispatcher{
-		name:              cfg.Name,
-		table:             middleware.ApplyRouteTable(NewMapRouter(cfg.Name), cfg.RouterMiddleware),
-		inbounds:          cfg.Inbounds,
-		outbounds:         convertOutbounds(cfg.Outbounds, cfg.OutboundMiddleware),
-		transports:        collectTransports(cfg.Inbounds, cfg.Outbounds),
-		inboundMiddleware: cfg.InboundMiddleware,
-		log:               logger,
-		meter:             meter,
-		stopMeter:         stopMeter,
-		once:              lifecycle.NewOnce(),
+		name:               cfg.Name,
+		router:             router,
+		table:              middleware.ApplyRouteTable(router, cfg.RouterMiddleware),
+		inbounds:           cfg.Inbounds,
+		outbounds:          outbounds,
+		outboundsConfig:    copyOutbounds(cfg.Outbounds),
+		reloadable:         reloadable,
+		transports:         collectTransports(cfg.Inbounds, cfg.Outbounds),
+		inboundMiddleware:  cfg.InboundMiddleware,
+		outboundMiddleware: cfg.OutboundMiddleware,
+		log:                logger,
+		meter:              meter,
+		metricsRoot:        metricsRoot,
+		exposeExpvar:       cfg.Metrics.Expvar,
+		stopMeter:          stopMeter,
+		inflight:           inflight,
+		drainTimeout:       cfg.DrainTimeout,
+		startup:            cfg.Startup,
+		once:               lifecycle.NewOnce(),
 	}
 }
 
-func addObservingMiddleware(cfg Config, meter *metrics.Scope, logger *zap.Logger, extractor observability.ContextExtractor) Config {
+// addHandlerTimeoutMiddleware enforces deadlines outside of the configured
+// middleware, so that slow middleware is held to them as well.
+func addHandlerTimeoutMiddleware(cfg Config, logger *zap.Logger) Config {
+	timeout := newHandlerTimeout(cfg.HandlerTimeout, logger)
+	cfg.InboundMiddleware.Unary = inboundmiddleware.UnaryChain(timeout, cfg.InboundMiddleware.Unary)
+	return cfg
+}
+
+// addInflightMiddleware tracks requests in flight outside of the configured
+// middleware, but inside of the observability middleware so that requests
+// rejected while draining are observed.
+func addInflightMiddleware(cfg Config, inflight *inflightTracker) Config {
+	cfg.InboundMiddleware.Unary = inboundmiddleware.UnaryChain(inflight, cfg.InboundMiddleware.Unary)
+	cfg.InboundMiddleware.Oneway = inboundmiddleware.OnewayChain(inflight, cfg.InboundMiddleware.Oneway)
+	cfg.InboundMiddleware.Stream = inboundmiddleware.StreamChain(inflight, cfg.InboundMiddleware.Stream)
+
+	cfg.OutboundMiddleware.Unary = outboundmiddleware.UnaryChain(inflight, cfg.OutboundMiddleware.Unary)
+	cfg.OutboundMiddleware.Oneway = outboundmiddleware.OnewayChain(inflight, cfg.OutboundMiddleware.Oneway)
+
+	return cfg
+}
+
+func addObservingMiddleware(cfg Config, meter *metrics.Scope, logger *zap.Logger, extractor observability.ContextExtractor, tags observability.TagConfig) Config {
 	if cfg.DisableAutoObservabilityMiddleware {
 		return cfg
 	}
 
-	observer := observability.NewMiddleware(logger, meter, extractor)
+	observer := observability.NewMiddlewareWithTags(logger, meter, extractor, tags)
 
 	cfg.InboundMiddleware.Unary = inboundmiddleware.UnaryChain(observer, cfg.InboundMiddleware.Unary)
 	cfg.InboundMiddleware.Oneway = inboundmiddleware.OnewayChain(observer, cfg.InboundMiddleware.Oneway)
@@ -199,17 +255,33 @@ func collectTransports(inbounds Inbounds, outbounds Outbounds) []transport.Trans
 // Dispatcher encapsulates a YARPC application. It acts as the entry point to
 // send and receive YARPC requests in a transport and encoding agnostic way.
 type Dispatcher struct {
-	table      transport.RouteTable
-	name       string
-	inbounds   Inbounds
-	outbounds  Outbounds
-	transports []transport.Transport
-
-	inboundMiddleware InboundMiddleware
-
-	log       *zap.Logger
-	meter     *metrics.Scope
-	stopMeter context.CancelFunc
+	router   MapRouter
+	table    transport.RouteTable
+	name     string
+	inbounds Inbounds
+
+	// outboundsMu guards the outbounds and transports, which Reload replaces.
+	outboundsMu     sync.RWMutex
+	outbounds       Outbounds
+	outboundsConfig Outbounds
+	reloadable      map[string]*reloadableOutbound
+	transports      []transport.Transport
+	reloadMu        sync.Mutex
+
+	inboundMiddleware  InboundMiddleware
+	outboundMiddleware OutboundMiddleware
+
+	log          *zap.Logger
+	meter        *metrics.Scope
+	metricsRoot  *metrics.Root
+	exposeExpvar bool
+	stopMeter    context.CancelFunc
+
+	inflight     *inflightTracker
+	drainTimeout time.Duration
+	startup      StartupConfig
+
+	hooks lifecycleHooks
 
 	once *lifecycle.Once
 }
@@ -226,11 +298,24 @@ func (d *Dispatcher) Inbounds() Inbounds {
 
 // Outbounds returns a copy of the list of outbounds for this RPC object.
 func (d *Dispatcher) Outbounds() Outbounds {
-	outbounds := make(Outbounds, len(d.outbounds))
-	for k, v := range d.outbounds {
-		outbounds[k] = v
-	}
-	return outbounds
+	return copyOutbounds(d.currentOutbounds())
+}
+
+// currentOutbounds returns a snapshot of the outbounds of the Dispatcher.
+// Reload replaces the map rather than changing it, so callers must not modify
+// the snapshot.
+func (d *Dispatcher) currentOutbounds() Outbounds {
+	d.outboundsMu.RLock()
+	defer d.outboundsMu.RUnlock()
+	return d.outbounds
+}
+
+// currentTransports returns a snapshot of the transports of the Dispatcher.
+// Like the outbounds, Reload replaces the slice, which must not be modified.
+func (d *Dispatcher) currentTransports() []transport.Transport {
+	d.outboundsMu.RLock()
+	defer d.outboundsMu.RUnlock()
+	return d.transports
 }
 
 // ClientConfig provides the configuration needed to talk to the given
@@ -268,7 +353,7 @@ func (d *Dispatcher) MustOutboundConfig(outboundKey string) *transport.OutboundC
 //  }
 // 	keyvalueClient := json.New(outboundConfig)
 func (d *Dispatcher) OutboundConfig(outboundKey string) (oc *transport.OutboundConfig, ok bool) {
-	if out, ok := d.outbounds[outboundKey]; ok {
+	if out, ok := d.currentOutbounds()[outboundKey]; ok {
 		return &transport.OutboundConfig{
 			CallerName: d.name,
 			Outbounds:  out,
@@ -287,6 +372,10 @@ func (d *Dispatcher) InboundMiddleware() InboundMiddleware {
 // Register registers zero or more procedures with this dispatcher. Incoming
 // requests to these procedures will be routed to the handlers specified in
 // the given Procedures.
+//
+// Procedures may be registered before or after the dispatcher starts. Note
+// that TChannel inbounds only serve the services that had procedures when
+// they started.
 func (d *Dispatcher) Register(rs []transport.Procedure) {
 	procedures := make([]transport.Procedure, 0, len(rs))
 
@@ -316,6 +405,35 @@ func (d *Dispatcher) Register(rs []transport.Procedure) {
 	d.table.Register(procedures)
 }
 
+// RegisterAlias makes this dispatcher answer to requests for the alias with
+// the procedures of the given service, including those registered later.
+// This lets one dispatcher serve several services under all of their names,
+// like while consolidating them.
+//
+// Aliases should be registered before the dispatcher starts, since TChannel
+// inbounds only serve the services that had procedures when they started.
+// RegisterAlias panics if the alias already has procedures of its own.
+func (d *Dispatcher) RegisterAlias(alias, service string) {
+	d.router.RegisterAlias(alias, service)
+	d.log.Info("Registered service alias.", zap.String("alias", alias), zap.String("service", service))
+}
+
+// Unregister removes procedures registered with this dispatcher, matching
+// them by service, name, and encoding. Incoming requests to these procedures
+// will fail as unrecognized, while requests already being handled are not
+// affected. Procedures that are not registered are ignored.
+//
+// Procedures may be unregistered while the dispatcher is running.
+func (d *Dispatcher) Unregister(rs []transport.Procedure) {
+	d.router.Unregister(rs)
+	for _, r := range rs {
+		d.log.Info("Unregistered procedure.",
+			zap.String("service", r.Service),
+			zap.String("procedure", r.Name),
+			zap.String("encoding", string(r.Encoding)))
+	}
+}
+
 // Start starts the Dispatcher, allowing it to accept and process new incoming
 // requests. This starts all inbounds and outbounds configured on this
 // Dispatcher.
@@ -381,6 +499,10 @@ func (d *Dispatcher) PhasedStart() (*PhasedStarter, error) {
 // Stop stops the Dispatcher, shutting down all inbounds, outbounds, and
 // transports. This function returns after everything has been stopped.
 //
+// If the Dispatcher has a DrainTimeout, Stop first rejects new inbound
+// requests and waits up to the timeout for requests in flight to finish. It
+// returns a DrainError describing the requests it had to cancel, if any.
+//
 // Stop and PhasedStop are mutually exclusive. See the PhasedStop
 // documentation for details.
 func (d *Dispatcher) Stop() error {
@@ -391,6 +513,7 @@ func (d *Dispatcher) Stop() error {
 	return d.once.Stop(func() error {
 		d.log.Info("shutting down dispatcher")
 		return multierr.Combine(
+			stopper.DrainRequests(),
 			stopper.StopInbounds(),
 			stopper.StopOutbounds(),
 			stopper.StopTransports(),
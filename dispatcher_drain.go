@@ -0,0 +1,258 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/onclose"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// InflightRequest describes a request that was in flight when the dispatcher
+// stopped.
+type InflightRequest struct {
+	Caller    string
+	Service   string
+	Procedure string
+
+	// Started is when the request started.
+	Started time.Time
+}
+
+// DrainError is returned when stopping a dispatcher if requests were still
+// in flight when the drain timeout expired. The contexts of these requests
+// were canceled before the dispatcher stopped its inbounds.
+type DrainError struct {
+	// Inbound are the requests that were being handled.
+	Inbound []InflightRequest
+
+	// Outbound are the calls that were being made.
+	Outbound []InflightRequest
+}
+
+func (e *DrainError) Error() string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "canceled %d inbound requests and %d outbound calls still in flight after the drain timeout",
+		len(e.Inbound), len(e.Outbound))
+	for _, r := range e.Inbound {
+		fmt.Fprintf(&buf, "; inbound %q from %q", r.Procedure, r.Caller)
+	}
+	for _, r := range e.Outbound {
+		fmt.Fprintf(&buf, "; outbound %q to %q", r.Procedure, r.Service)
+	}
+	return buf.String()
+}
+
+var (
+	_ middleware.UnaryInbound   = (*inflightTracker)(nil)
+	_ middleware.OnewayInbound  = (*inflightTracker)(nil)
+	_ middleware.StreamInbound  = (*inflightTracker)(nil)
+	_ middleware.UnaryOutbound  = (*inflightTracker)(nil)
+	_ middleware.OnewayOutbound = (*inflightTracker)(nil)
+)
+
+// inflightTracker is middleware which tracks the requests a dispatcher is
+// handling and the calls it is making, so that stopping the dispatcher can
+// wait for them to finish. Once draining, it rejects new inbound requests.
+//
+// Outbound streams are not tracked, since they are only finished when their
+// callers close them.
+type inflightTracker struct {
+	mu       sync.Mutex
+	draining bool
+	nextID   uint64
+	inbound  map[uint64]*inflightEntry
+	outbound map[uint64]*inflightEntry
+
+	// changed is closed and replaced whenever a request finishes.
+	changed chan struct{}
+}
+
+type inflightEntry struct {
+	req    InflightRequest
+	cancel context.CancelFunc
+}
+
+func newInflightTracker() *inflightTracker {
+	return &inflightTracker{
+		inbound:  make(map[uint64]*inflightEntry),
+		outbound: make(map[uint64]*inflightEntry),
+		changed:  make(chan struct{}),
+	}
+}
+
+// begin tracks a request until the returned function is called. The request
+// must use the returned context, which is canceled if the request is still
+// in flight after the drain timeout.
+func (t *inflightTracker) begin(ctx context.Context, meta *transport.RequestMeta, inbound bool) (context.Context, func(), error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if inbound && t.draining {
+		return ctx, nil, yarpcerrors.Newf(yarpcerrors.CodeUnavailable,
+			"service %q is shutting down and cannot handle procedure %q", meta.Service, meta.Procedure)
+	}
+
+	entries := t.outbound
+	if inbound {
+		entries = t.inbound
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	id := t.nextID
+	t.nextID++
+	entries[id] = &inflightEntry{
+		req: InflightRequest{
+			Caller:    meta.Caller,
+			Service:   meta.Service,
+			Procedure: meta.Procedure,
+			Started:   time.Now(),
+		},
+		cancel: cancel,
+	}
+
+	return ctx, func() {
+		cancel()
+		t.mu.Lock()
+		delete(entries, id)
+		close(t.changed)
+		t.changed = make(chan struct{})
+		t.mu.Unlock()
+	}, nil
+}
+
+// drain rejects new inbound requests and waits for those in flight and for
+// calls to finish. If the context is done first, the remaining requests are
+// canceled and reported in a DrainError.
+func (t *inflightTracker) drain(ctx context.Context) error {
+	t.mu.Lock()
+	t.draining = true
+	for len(t.inbound)+len(t.outbound) > 0 {
+		changed := t.changed
+		t.mu.Unlock()
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return t.cancel()
+		}
+		t.mu.Lock()
+	}
+	t.mu.Unlock()
+	return nil
+}
+
+func (t *inflightTracker) cancel() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	err := &DrainError{
+		Inbound:  cancelEntries(t.inbound),
+		Outbound: cancelEntries(t.outbound),
+	}
+	if len(err.Inbound)+len(err.Outbound) == 0 {
+		return nil
+	}
+	return err
+}
+
+// cancelEntries cancels the given requests, and returns them in the order
+// they started.
+func cancelEntries(entries map[uint64]*inflightEntry) []InflightRequest {
+	if len(entries) == 0 {
+		return nil
+	}
+	ids := make([]uint64, 0, len(entries))
+	for id := range entries {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	reqs := make([]InflightRequest, len(ids))
+	for i, id := range ids {
+		entries[id].cancel()
+		reqs[i] = entries[id].req
+	}
+	return reqs
+}
+
+func (t *inflightTracker) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter, h transport.UnaryHandler) error {
+	ctx, done, err := t.begin(ctx, req.ToRequestMeta(), true)
+	if err != nil {
+		return err
+	}
+	defer done()
+	return h.Handle(ctx, req, resw)
+}
+
+func (t *inflightTracker) HandleOneway(ctx context.Context, req *transport.Request, h transport.OnewayHandler) error {
+	ctx, done, err := t.begin(ctx, req.ToRequestMeta(), true)
+	if err != nil {
+		return err
+	}
+	defer done()
+	return h.HandleOneway(ctx, req)
+}
+
+func (t *inflightTracker) HandleStream(s *transport.ServerStream, h transport.StreamHandler) error {
+	ctx, done, err := t.begin(s.Context(), s.Request().Meta, true)
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	s, err = transport.NewServerStream(contextStream{Stream: s, ctx: ctx})
+	if err != nil {
+		return err
+	}
+	return h.HandleStream(s)
+}
+
+func (t *inflightTracker) Call(ctx context.Context, req *transport.Request, out transport.UnaryOutbound) (*transport.Response, error) {
+	ctx, done, _ := t.begin(ctx, req.ToRequestMeta(), false)
+	res, err := out.Call(ctx, req)
+	// The call stays in flight until the response body is closed, since the
+	// body may still be read with the context of the call.
+	onclose.Response(res, done)
+	return res, err
+}
+
+func (t *inflightTracker) CallOneway(ctx context.Context, req *transport.Request, out transport.OnewayOutbound) (transport.Ack, error) {
+	ctx, done, _ := t.begin(ctx, req.ToRequestMeta(), false)
+	defer done()
+	return out.CallOneway(ctx, req)
+}
+
+// contextStream is a stream with a different context.
+type contextStream struct {
+	transport.Stream
+
+	ctx context.Context
+}
+
+func (s contextStream) Context() context.Context { return s.ctx }
@@ -0,0 +1,210 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpc
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/internal/testtime"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// blockingHandler blocks requests until they are released or their
+// contexts are canceled.
+type blockingHandler struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func newBlockingHandler() *blockingHandler {
+	return &blockingHandler{
+		started: make(chan struct{}, 10),
+		release: make(chan struct{}),
+	}
+}
+
+func (h *blockingHandler) Handle(ctx context.Context, _ *transport.Request, _ transport.ResponseWriter) error {
+	h.started <- struct{}{}
+	select {
+	case <-h.release:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestInflightTrackerDrain(t *testing.T) {
+	tracker := newInflightTracker()
+	h := newBlockingHandler()
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- tracker.Handle(context.Background(), &transport.Request{Procedure: "get"}, &transporttest.FakeResponseWriter{}, h)
+	}()
+	<-h.started
+
+	drained := make(chan error, 1)
+	go func() { drained <- tracker.drain(context.Background()) }()
+
+	testtime.WaitFor(t, "the tracker must start draining", func() bool {
+		tracker.mu.Lock()
+		defer tracker.mu.Unlock()
+		return tracker.draining
+	})
+
+	err := tracker.Handle(context.Background(), &transport.Request{Service: "users", Procedure: "get"}, &transporttest.FakeResponseWriter{}, h)
+	assert.Equal(t, yarpcerrors.CodeUnavailable, yarpcerrors.FromError(err).Code(), "new requests must be rejected while draining")
+
+	select {
+	case <-drained:
+		t.Fatal("drain must wait for requests in flight")
+	default:
+	}
+
+	close(h.release)
+	assert.NoError(t, <-errs)
+	assert.NoError(t, <-drained)
+}
+
+func TestInflightTrackerDrainTimeout(t *testing.T) {
+	tracker := newInflightTracker()
+	h := newBlockingHandler()
+
+	errs := make(chan error, 2)
+	go func() {
+		errs <- tracker.Handle(context.Background(), &transport.Request{Caller: "frontend", Procedure: "get"}, &transporttest.FakeResponseWriter{}, h)
+	}()
+	<-h.started
+	go func() {
+		_, err := tracker.Call(context.Background(), &transport.Request{Service: "storage", Procedure: "read"}, blockingOutbound{h})
+		errs <- err
+	}()
+	<-h.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := tracker.drain(ctx)
+	require.Error(t, err)
+
+	drainErr, ok := err.(*DrainError)
+	require.True(t, ok, "expected a DrainError, got %v", err)
+	require.Len(t, drainErr.Inbound, 1)
+	assert.Equal(t, "frontend", drainErr.Inbound[0].Caller)
+	assert.Equal(t, "get", drainErr.Inbound[0].Procedure)
+	require.Len(t, drainErr.Outbound, 1)
+	assert.Equal(t, "storage", drainErr.Outbound[0].Service)
+	assert.Equal(t, "read", drainErr.Outbound[0].Procedure)
+	assert.Contains(t, err.Error(), "canceled 1 inbound requests and 1 outbound calls")
+
+	assert.Equal(t, context.Canceled, <-errs, "requests in flight must be canceled")
+	assert.Equal(t, context.Canceled, <-errs, "requests in flight must be canceled")
+}
+
+// blockingOutbound blocks calls with a blockingHandler.
+type blockingOutbound struct {
+	h *blockingHandler
+}
+
+func (o blockingOutbound) Call(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	return nil, o.h.Handle(ctx, req, nil)
+}
+
+func (blockingOutbound) Transports() []transport.Transport { return nil }
+func (blockingOutbound) Start() error                      { return nil }
+func (blockingOutbound) Stop() error                       { return nil }
+func (blockingOutbound) IsRunning() bool                   { return false }
+
+// bodyOutbound returns a response whose body reports the error of the
+// context of the call when it is read.
+type bodyOutbound struct{ blockingOutbound }
+
+func (bodyOutbound) Call(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	return &transport.Response{Body: ctxBody{ctx}}, nil
+}
+
+type ctxBody struct{ ctx context.Context }
+
+func (b ctxBody) Read([]byte) (int, error) {
+	if err := b.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return 0, io.EOF
+}
+
+func (ctxBody) Close() error { return nil }
+
+func TestInflightTrackerResponseBody(t *testing.T) {
+	tracker := newInflightTracker()
+	numOutbound := func() int {
+		tracker.mu.Lock()
+		defer tracker.mu.Unlock()
+		return len(tracker.outbound)
+	}
+
+	res, err := tracker.Call(context.Background(), &transport.Request{Service: "storage", Procedure: "read"}, bodyOutbound{})
+	require.NoError(t, err)
+	_, err = ioutil.ReadAll(res.Body)
+	assert.NoError(t, err, "the body must be readable after the call returns")
+	assert.Equal(t, 1, numOutbound(), "the call must be in flight until its body is closed")
+
+	require.NoError(t, res.Body.Close())
+	assert.Equal(t, 0, numOutbound())
+	_, err = ioutil.ReadAll(res.Body)
+	assert.Equal(t, context.Canceled, err, "the context must be canceled once the body is closed")
+}
+
+func TestDispatcherDrainTimeout(t *testing.T) {
+	h := newBlockingHandler()
+	d := NewDispatcher(Config{
+		Name:         "test",
+		DrainTimeout: 10 * time.Millisecond,
+	})
+	d.Register([]transport.Procedure{{
+		Name:        "get",
+		HandlerSpec: transport.NewUnaryHandlerSpec(h),
+	}})
+	require.NoError(t, d.Start())
+
+	spec, err := d.Router().Choose(context.Background(), &transport.Request{Service: "test", Procedure: "get"})
+	require.NoError(t, err)
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- spec.Unary().Handle(context.Background(),
+			&transport.Request{Caller: "frontend", Service: "test", Procedure: "get"},
+			&transporttest.FakeResponseWriter{})
+	}()
+	<-h.started
+
+	err = d.Stop()
+	require.Error(t, err)
+	_, ok := err.(*DrainError)
+	assert.True(t, ok, "expected a DrainError, got %v", err)
+	assert.Error(t, <-errs)
+}
@@ -0,0 +1,115 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpc
+
+import (
+	"sync"
+
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+)
+
+// LifecycleHook is a function that runs at a point in the lifecycle of a
+// Dispatcher, like warming a cache after the Dispatcher starts or
+// unregistering from service discovery before it stops.
+type LifecycleHook func() error
+
+type lifecycleHooks struct {
+	mu          sync.Mutex
+	beforeStart []LifecycleHook
+	onStart     []LifecycleHook
+	beforeStop  []LifecycleHook
+	onStop      []LifecycleHook
+}
+
+// BeforeStart registers a hook that runs when the Dispatcher starts, before
+// it starts any transports, outbounds, or inbounds. If the hook fails,
+// starting the Dispatcher fails with its error and nothing is started.
+//
+// Hooks must be registered before the Dispatcher starts. Start hooks run in
+// the order they were registered.
+func (d *Dispatcher) BeforeStart(h LifecycleHook) {
+	d.hooks.mu.Lock()
+	d.hooks.beforeStart = append(d.hooks.beforeStart, h)
+	d.hooks.mu.Unlock()
+}
+
+// OnStart registers a hook that runs after the Dispatcher has started all of
+// its transports, outbounds, and inbounds, and is ready to make and handle
+// requests. If the hook fails, starting the Dispatcher fails with its error
+// and everything that was started is stopped.
+func (d *Dispatcher) OnStart(h LifecycleHook) {
+	d.hooks.mu.Lock()
+	d.hooks.onStart = append(d.hooks.onStart, h)
+	d.hooks.mu.Unlock()
+}
+
+// BeforeStop registers a hook that runs when the Dispatcher stops, before it
+// drains requests or stops anything, so that it can still make and handle
+// requests. If the hook fails, the Dispatcher stops anyway, and stopping it
+// returns the error.
+//
+// Stop hooks run in the reverse of the order they were registered.
+func (d *Dispatcher) BeforeStop(h LifecycleHook) {
+	d.hooks.mu.Lock()
+	d.hooks.beforeStop = append(d.hooks.beforeStop, h)
+	d.hooks.mu.Unlock()
+}
+
+// OnStop registers a hook that runs after the Dispatcher has stopped all of
+// its inbounds, outbounds, and transports. Errors from the hook are returned
+// when stopping the Dispatcher.
+func (d *Dispatcher) OnStop(h LifecycleHook) {
+	d.hooks.mu.Lock()
+	d.hooks.onStop = append(d.hooks.onStop, h)
+	d.hooks.mu.Unlock()
+}
+
+// runStart runs start hooks in order, stopping at the first failure.
+func (h *lifecycleHooks) runStart(log *zap.Logger, hooks *[]LifecycleHook) error {
+	h.mu.Lock()
+	run := append([]LifecycleHook(nil), (*hooks)...)
+	h.mu.Unlock()
+
+	for _, hook := range run {
+		if err := hook(); err != nil {
+			log.Error("lifecycle hook failed", zap.Error(err))
+			return err
+		}
+	}
+	return nil
+}
+
+// runStop runs all stop hooks in reverse order, combining their errors.
+func (h *lifecycleHooks) runStop(log *zap.Logger, hooks *[]LifecycleHook) error {
+	h.mu.Lock()
+	run := append([]LifecycleHook(nil), (*hooks)...)
+	h.mu.Unlock()
+
+	var errs error
+	for i := len(run) - 1; i >= 0; i-- {
+		if err := run[i](); err != nil {
+			log.Error("lifecycle hook failed", zap.Error(err))
+			errs = multierr.Append(errs, err)
+		}
+	}
+	return errs
+}
@@ -0,0 +1,94 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpc
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLifecycleHooks(t *testing.T) {
+	var calls []string
+	hook := func(name string) LifecycleHook {
+		return func() error {
+			calls = append(calls, name)
+			return nil
+		}
+	}
+
+	d := NewDispatcher(Config{Name: "test"})
+	d.BeforeStart(hook("before start 1"))
+	d.BeforeStart(hook("before start 2"))
+	d.OnStart(hook("on start"))
+	d.BeforeStop(hook("before stop 1"))
+	d.BeforeStop(hook("before stop 2"))
+	d.OnStop(hook("on stop"))
+
+	require.NoError(t, d.Start())
+	assert.Equal(t, []string{"before start 1", "before start 2", "on start"}, calls)
+
+	calls = nil
+	require.NoError(t, d.Stop())
+	assert.Equal(t, []string{"before stop 2", "before stop 1", "on stop"}, calls,
+		"stop hooks must run in reverse order")
+}
+
+func TestLifecycleHookFailures(t *testing.T) {
+	t.Run("before start", func(t *testing.T) {
+		d := NewDispatcher(Config{Name: "test"})
+		d.BeforeStart(func() error { return errors.New("great sadness") })
+		d.OnStart(func() error {
+			t.Error("start hooks must not run after a failure")
+			return nil
+		})
+		assert.EqualError(t, d.Start(), "great sadness")
+	})
+
+	t.Run("on start", func(t *testing.T) {
+		d := NewDispatcher(Config{Name: "test"})
+		d.OnStart(func() error { return errors.New("great sadness") })
+		d.OnStop(func() error {
+			t.Error("stop hooks must not run if the dispatcher did not start")
+			return nil
+		})
+		assert.EqualError(t, d.Start(), "great sadness")
+		assert.Error(t, d.Stop())
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		var stopped bool
+		d := NewDispatcher(Config{Name: "test"})
+		d.BeforeStop(func() error { return errors.New("great sadness") })
+		d.OnStop(func() error {
+			stopped = true
+			return errors.New("more sadness")
+		})
+		require.NoError(t, d.Start())
+		err := d.Stop()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "great sadness")
+		assert.Contains(t, err.Error(), "more sadness")
+		assert.True(t, stopped, "the dispatcher must stop even if a hook fails")
+	})
+}
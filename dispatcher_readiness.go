@@ -0,0 +1,159 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpc
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/errorsync"
+	"go.uber.org/yarpc/internal/introspection"
+	"go.uber.org/zap"
+)
+
+// StartPhase is a phase of starting a Dispatcher. The phases run in order:
+// transports start before outbounds, which start before inbounds.
+type StartPhase int
+
+const (
+	// TransportsPhase starts the transports of the Dispatcher.
+	TransportsPhase StartPhase = iota + 1
+
+	// OutboundsPhase starts the outbounds of the Dispatcher.
+	OutboundsPhase
+
+	// InboundsPhase starts the inbounds of the Dispatcher, after which it
+	// serves requests.
+	InboundsPhase
+)
+
+func (p StartPhase) String() string {
+	switch p {
+	case TransportsPhase:
+		return "transports"
+	case OutboundsPhase:
+		return "outbounds"
+	case InboundsPhase:
+		return "inbounds"
+	default:
+		return fmt.Sprintf("StartPhase(%d)", int(p))
+	}
+}
+
+// StartupConfig configures how a Dispatcher starts.
+type StartupConfig struct {
+	// Gates must pass before the phases of startup they hold back begin,
+	// like waiting for outbounds to have peers before inbounds accept
+	// requests. The gates of a phase are waited for in order.
+	Gates []ReadinessGate
+
+	// Timeouts bound how long each phase of startup may take, including
+	// waiting for its gates. If a phase times out, starting the Dispatcher
+	// fails and everything that was started is stopped. Phases without a
+	// timeout take as long as they need.
+	Timeouts map[StartPhase]time.Duration
+}
+
+// ReadinessGate holds back a phase of startup until a component is ready.
+type ReadinessGate struct {
+	// Name identifies the gate in errors and logs.
+	Name string
+
+	// Before is the phase of startup that waits for the gate.
+	Before StartPhase
+
+	// Ready blocks until the component is ready, or returns an error if it
+	// cannot become ready or the context is done first.
+	Ready func(context.Context) error
+}
+
+// _peersAvailablePollInterval is how often PeersAvailable checks the peers
+// of an outbound.
+const _peersAvailablePollInterval = 50 * time.Millisecond
+
+// PeersAvailable returns the Ready function of a ReadinessGate that waits
+// until the outbound has at least one available peer. Use it with the
+// InboundsPhase to accept requests only once the outbound can make calls.
+//
+// Outbounds that cannot report the status of their peers are ready as soon
+// as they start.
+func PeersAvailable(o transport.Outbound) func(context.Context) error {
+	return func(ctx context.Context) error {
+		i, ok := o.(introspection.IntrospectableOutbound)
+		if !ok {
+			return nil
+		}
+
+		ticker := time.NewTicker(_peersAvailablePollInterval)
+		defer ticker.Stop()
+		for {
+			for _, p := range i.Introspect().Chooser.Peers {
+				if p.ConnectionStatus == peer.Available.String() {
+					return nil
+				}
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return fmt.Errorf("no peers available: %v", ctx.Err())
+			}
+		}
+	}
+}
+
+// phaseContext returns the context bounding the given phase of startup.
+func (s *PhasedStarter) phaseContext(phase StartPhase) (context.Context, context.CancelFunc) {
+	if timeout := s.dispatcher.startup.Timeouts[phase]; timeout > 0 {
+		return context.WithTimeout(context.Background(), timeout)
+	}
+	return context.WithCancel(context.Background())
+}
+
+// waitGates waits for the gates that hold back the given phase, in order.
+func (s *PhasedStarter) waitGates(ctx context.Context, phase StartPhase) error {
+	for _, gate := range s.dispatcher.startup.Gates {
+		if gate.Before != phase {
+			continue
+		}
+		s.log.Info("waiting for readiness gate", zap.String("gate", gate.Name), zap.Stringer("phase", phase))
+		if err := gate.Ready(ctx); err != nil {
+			return fmt.Errorf("readiness gate %q failed before starting %v: %v", gate.Name, phase, err)
+		}
+		s.log.Debug("passed readiness gate", zap.String("gate", gate.Name), zap.Stringer("phase", phase))
+	}
+	return nil
+}
+
+// wait waits for the components of the given phase to start, unless the
+// context is done first.
+func (s *PhasedStarter) wait(ctx context.Context, phase StartPhase, wait *errorsync.ErrorWaiter) []error {
+	done := make(chan []error, 1)
+	go func() { done <- wait.Wait() }()
+	select {
+	case errs := <-done:
+		return errs
+	case <-ctx.Done():
+		return []error{fmt.Errorf("timed out starting %v: %v", phase, ctx.Err())}
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/introspection"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+)
+
+func TestReadinessGates(t *testing.T) {
+	var gates []string
+	gate := func(name string, phase StartPhase) ReadinessGate {
+		return ReadinessGate{
+			Name:   name,
+			Before: phase,
+			Ready: func(context.Context) error {
+				gates = append(gates, name)
+				return nil
+			},
+		}
+	}
+
+	d := NewDispatcher(Config{
+		Name: "test",
+		Startup: StartupConfig{
+			Gates: []ReadinessGate{
+				gate("peers", InboundsPhase),
+				gate("config", TransportsPhase),
+				gate("cache", InboundsPhase),
+				gate("discovery", OutboundsPhase),
+			},
+		},
+	})
+	require.NoError(t, d.Start())
+	defer d.Stop()
+	assert.Equal(t, []string{"config", "discovery", "peers", "cache"}, gates,
+		"gates must be waited for by phase, and in order within a phase")
+}
+
+func TestReadinessGateFailures(t *testing.T) {
+	t.Run("failure", func(t *testing.T) {
+		d := NewDispatcher(Config{
+			Name: "test",
+			Startup: StartupConfig{
+				Gates: []ReadinessGate{{
+					Name:   "cache",
+					Before: InboundsPhase,
+					Ready:  func(context.Context) error { return errors.New("great sadness") },
+				}},
+			},
+		})
+		d.OnStart(func() error {
+			t.Error("the dispatcher must not start if a gate fails")
+			return nil
+		})
+		assert.EqualError(t, d.Start(), `readiness gate "cache" failed before starting inbounds: great sadness`)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		d := NewDispatcher(Config{
+			Name: "test",
+			Startup: StartupConfig{
+				Gates: []ReadinessGate{{
+					Name:   "peers",
+					Before: OutboundsPhase,
+					Ready: func(ctx context.Context) error {
+						<-ctx.Done()
+						return ctx.Err()
+					},
+				}},
+				Timeouts: map[StartPhase]time.Duration{OutboundsPhase: 10 * time.Millisecond},
+			},
+		})
+		err := d.Start()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "context deadline exceeded")
+	})
+}
+
+// introspectableOutbound reports peers with the given connection statuses,
+// one set per call, repeating the last.
+type introspectableOutbound struct {
+	transport.Outbound
+
+	statuses [][]string
+	calls    *int
+}
+
+func (o introspectableOutbound) Introspect() introspection.OutboundStatus {
+	i := *o.calls
+	if i >= len(o.statuses) {
+		i = len(o.statuses) - 1
+	}
+	*o.calls++
+
+	var peers []introspection.PeerStatus
+	for _, s := range o.statuses[i] {
+		peers = append(peers, introspection.PeerStatus{ConnectionStatus: s})
+	}
+	return introspection.OutboundStatus{Chooser: introspection.ChooserStatus{Peers: peers}}
+}
+
+func TestPeersAvailable(t *testing.T) {
+	o := introspectableOutbound{
+		statuses: [][]string{
+			{peer.Connecting.String()},
+			{peer.Unavailable.String(), peer.Available.String()},
+		},
+		calls: new(int),
+	}
+	assert.NoError(t, PeersAvailable(o)(context.Background()))
+	assert.Equal(t, 2, *o.calls, "peers must be polled until one is available")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	o = introspectableOutbound{statuses: [][]string{nil}, calls: new(int)}
+	assert.Error(t, PeersAvailable(o)(ctx), "outbounds without available peers must not be ready")
+
+	assert.NoError(t, PeersAvailable(nil)(context.Background()),
+		"outbounds that cannot report their peers must be ready")
+}
@@ -0,0 +1,464 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpc
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"go.uber.org/atomic"
+	"go.uber.org/multierr"
+	"go.uber.org/yarpc/api/introspection"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/errorsync"
+	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/zap"
+)
+
+// Reload applies the outbounds of the given configuration to the Dispatcher
+// without restarting it, so that long-lived processes can follow changes
+// from their configuration management. Outbounds with new keys are added,
+// outbounds whose keys are gone are removed, and outbounds that changed,
+// like ones with a new peer list, replace the old ones.
+//
+// Clients built before the reload use the new outbounds from then on, but
+// keep the service name they were built with. Calls through an outbound key
+// that was removed fail with an Unimplemented error.
+//
+// If the Dispatcher is running, Reload starts the new outbounds and their
+// transports before it switches to them, and stops the old ones afterwards.
+// If any of them fail to start, nothing changes and Reload returns the
+// error. Calls still in flight on the old outbounds when they stop may fail.
+//
+// The name, inbounds, and middleware of the Dispatcher cannot be reloaded and
+// the rest of the configuration is ignored. Middleware that supports it may
+// be adjusted through its own API instead, like the SetTargets method of the
+// split middleware.
+//
+// Reload must not be called while the Dispatcher is starting or stopping,
+// including during a phased start or stop. Calls to Reload are serialized.
+func (d *Dispatcher) Reload(cfg Config) error {
+	if cfg.Name != d.name {
+		return fmt.Errorf("cannot reload dispatcher %q with the configuration of service %q", d.name, cfg.Name)
+	}
+	for outboundKey, outs := range cfg.Outbounds {
+		if outs.Unary == nil && outs.Oneway == nil && outs.Stream == nil {
+			return fmt.Errorf("no outbound set for outbound key %q in dispatcher", outboundKey)
+		}
+	}
+
+	d.reloadMu.Lock()
+	defer d.reloadMu.Unlock()
+
+	running := d.once.IsRunning()
+	log := d.log.With(zap.Bool("running", running))
+
+	// Work out what changed against the configuration last applied.
+	d.outboundsMu.RLock()
+	oldConfig, oldTransports := d.outboundsConfig, d.transports
+	d.outboundsMu.RUnlock()
+
+	var (
+		added    = make(Outbounds)
+		replaced = make(Outbounds)
+		removed  []string
+	)
+	for outboundKey, outs := range cfg.Outbounds {
+		old, ok := oldConfig[outboundKey]
+		switch {
+		case !ok:
+			added[outboundKey] = outs
+		case !sameOutbounds(old, outs):
+			replaced[outboundKey] = outs
+		}
+	}
+	for outboundKey := range oldConfig {
+		if _, ok := cfg.Outbounds[outboundKey]; !ok {
+			removed = append(removed, outboundKey)
+		}
+	}
+	if len(added)+len(replaced)+len(removed) == 0 {
+		log.Debug("reloaded dispatcher without changes")
+		return nil
+	}
+
+	transports := collectTransports(d.inbounds, cfg.Outbounds)
+	startTransports := subtractTransports(transports, oldTransports)
+	stopTransports := subtractTransports(oldTransports, transports)
+
+	start := convertOutbounds(added, d.outboundMiddleware)
+	for outboundKey, outs := range convertOutbounds(replaced, d.outboundMiddleware) {
+		start[outboundKey] = outs
+	}
+
+	if running {
+		log.Info("starting reloaded transports and outbounds")
+		if err := startReloaded(startTransports, start); err != nil {
+			log.Error("failed to start reloaded outbounds", zap.Error(err))
+			return err
+		}
+	}
+
+	// Switch to the new outbounds.
+	d.outboundsMu.Lock()
+	var stop []transport.Outbounds
+	reloadable := make(map[string]*reloadableOutbound, len(cfg.Outbounds))
+	for outboundKey, r := range d.reloadable {
+		if _, ok := cfg.Outbounds[outboundKey]; !ok {
+			stop = append(stop, r.load())
+			r.remove()
+			continue
+		}
+		if outs, ok := start[outboundKey]; ok {
+			stop = append(stop, r.load())
+			r.store(outs)
+		}
+		reloadable[outboundKey] = r
+	}
+	for outboundKey := range added {
+		reloadable[outboundKey] = newReloadableOutbound(outboundKey, start[outboundKey])
+	}
+	outbounds := make(Outbounds, len(reloadable))
+	for outboundKey, r := range reloadable {
+		outbounds[outboundKey] = r.outbounds()
+	}
+	d.reloadable = reloadable
+	d.outbounds = outbounds
+	d.outboundsConfig = copyOutbounds(cfg.Outbounds)
+	d.transports = transports
+	d.outboundsMu.Unlock()
+
+	log.Info("reloaded dispatcher outbounds",
+		zap.Int("added", len(added)),
+		zap.Int("replaced", len(replaced)),
+		zap.Int("removed", len(removed)))
+
+	if !running {
+		return nil
+	}
+	log.Info("stopping outbounds and transports replaced by reload")
+	return stopReloaded(stop, stopTransports)
+}
+
+// startReloaded starts new transports, then new outbounds. If any of them
+// fail to start, all of them are stopped.
+func startReloaded(transports []transport.Transport, outbounds Outbounds) error {
+	wait := errorsync.ErrorWaiter{}
+	for _, t := range transports {
+		wait.Submit(t.Start)
+	}
+	if errs := wait.Wait(); len(errs) > 0 {
+		return multierr.Combine(append(errs, stopReloaded(nil, transports))...)
+	}
+
+	wait = errorsync.ErrorWaiter{}
+	for _, outs := range outbounds {
+		submitOutbounds(&wait, outs, transport.Lifecycle.Start)
+	}
+	if errs := wait.Wait(); len(errs) > 0 {
+		return multierr.Combine(append(errs, stopReloaded(outboundsList(outbounds), transports))...)
+	}
+	return nil
+}
+
+// stopReloaded stops outbounds, then transports.
+func stopReloaded(outbounds []transport.Outbounds, transports []transport.Transport) error {
+	wait := errorsync.ErrorWaiter{}
+	for _, outs := range outbounds {
+		submitOutbounds(&wait, outs, transport.Lifecycle.Stop)
+	}
+	errs := wait.Wait()
+
+	wait = errorsync.ErrorWaiter{}
+	for _, t := range transports {
+		wait.Submit(t.Stop)
+	}
+	return multierr.Combine(append(errs, wait.Wait()...)...)
+}
+
+func submitOutbounds(wait *errorsync.ErrorWaiter, outs transport.Outbounds, f func(transport.Lifecycle) error) {
+	if outs.Unary != nil {
+		wait.Submit(func() error { return f(outs.Unary) })
+	}
+	if outs.Oneway != nil {
+		wait.Submit(func() error { return f(outs.Oneway) })
+	}
+	if outs.Stream != nil {
+		wait.Submit(func() error { return f(outs.Stream) })
+	}
+}
+
+func outboundsList(outbounds Outbounds) []transport.Outbounds {
+	list := make([]transport.Outbounds, 0, len(outbounds))
+	for _, outs := range outbounds {
+		list = append(list, outs)
+	}
+	return list
+}
+
+func copyOutbounds(outbounds Outbounds) Outbounds {
+	c := make(Outbounds, len(outbounds))
+	for outboundKey, outs := range outbounds {
+		c[outboundKey] = outs
+	}
+	return c
+}
+
+// subtractTransports returns the transports in a which are not in b.
+func subtractTransports(a, b []transport.Transport) []transport.Transport {
+	in := make(map[transport.Transport]struct{}, len(b))
+	for _, t := range b {
+		in[t] = struct{}{}
+	}
+	var diff []transport.Transport
+	for _, t := range a {
+		if _, ok := in[t]; !ok {
+			diff = append(diff, t)
+		}
+	}
+	return diff
+}
+
+// sameOutbounds reports whether two configured outbounds are the same.
+func sameOutbounds(a, b transport.Outbounds) bool {
+	return a.ServiceName == b.ServiceName &&
+		sameOutbound(a.Unary, b.Unary) &&
+		sameOutbound(a.Oneway, b.Oneway) &&
+		sameOutbound(a.Stream, b.Stream)
+}
+
+// sameOutbound reports whether two outbounds are the same instance. Outbounds
+// that cannot be compared are considered different.
+func sameOutbound(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	if !reflect.TypeOf(a).Comparable() || !reflect.TypeOf(b).Comparable() {
+		return false
+	}
+	return a == b
+}
+
+// reloadableOutbound holds the outbounds of an outbound key, with middleware
+// applied, which Reload may replace while clients are using them. The
+// dispatcher hands out the unary, oneway, and stream outbounds below, which
+// forward to the current outbounds.
+type reloadableOutbound struct {
+	outboundKey string
+
+	// current holds the current transport.Outbounds.
+	current atomic.Value
+	removed atomic.Bool
+}
+
+func newReloadableOutbound(outboundKey string, outs transport.Outbounds) *reloadableOutbound {
+	r := &reloadableOutbound{outboundKey: outboundKey}
+	r.current.Store(outs)
+	return r
+}
+
+// newReloadableOutbounds wraps outbounds with middleware applied in
+// reloadable outbounds, returning the outbounds to hand out to clients.
+func newReloadableOutbounds(outbounds Outbounds) (Outbounds, map[string]*reloadableOutbound) {
+	reloadable := make(map[string]*reloadableOutbound, len(outbounds))
+	wrapped := make(Outbounds, len(outbounds))
+	for outboundKey, outs := range outbounds {
+		r := newReloadableOutbound(outboundKey, outs)
+		reloadable[outboundKey] = r
+		wrapped[outboundKey] = r.outbounds()
+	}
+	return wrapped, reloadable
+}
+
+func (r *reloadableOutbound) load() transport.Outbounds {
+	return r.current.Load().(transport.Outbounds)
+}
+
+func (r *reloadableOutbound) store(outs transport.Outbounds) {
+	r.current.Store(outs)
+}
+
+func (r *reloadableOutbound) remove() {
+	r.removed.Store(true)
+	r.current.Store(transport.Outbounds{})
+}
+
+// outbounds returns the outbounds to hand out to clients, for the RPC types
+// the current outbounds support.
+func (r *reloadableOutbound) outbounds() transport.Outbounds {
+	current := r.load()
+	outs := transport.Outbounds{ServiceName: current.ServiceName}
+	if current.Unary != nil {
+		outs.Unary = reloadableUnaryOutbound{r}
+	}
+	if current.Oneway != nil {
+		outs.Oneway = reloadableOnewayOutbound{r}
+	}
+	if current.Stream != nil {
+		outs.Stream = reloadableStreamOutbound{r}
+	}
+	return outs
+}
+
+func (r *reloadableOutbound) unsupported(rpcType string) error {
+	if r.removed.Load() {
+		return yarpcerrors.Newf(yarpcerrors.CodeUnimplemented,
+			"outbound key %q was removed from the dispatcher", r.outboundKey)
+	}
+	return yarpcerrors.Newf(yarpcerrors.CodeUnimplemented,
+		"outbound key %q no longer supports %s calls", r.outboundKey, rpcType)
+}
+
+type reloadableUnaryOutbound struct{ r *reloadableOutbound }
+
+func (o reloadableUnaryOutbound) Transports() []transport.Transport {
+	if out := o.r.load().Unary; out != nil {
+		return out.Transports()
+	}
+	return nil
+}
+
+func (o reloadableUnaryOutbound) Start() error {
+	if out := o.r.load().Unary; out != nil {
+		return out.Start()
+	}
+	return nil
+}
+
+func (o reloadableUnaryOutbound) Stop() error {
+	if out := o.r.load().Unary; out != nil {
+		return out.Stop()
+	}
+	return nil
+}
+
+func (o reloadableUnaryOutbound) IsRunning() bool {
+	if out := o.r.load().Unary; out != nil {
+		return out.IsRunning()
+	}
+	return false
+}
+
+func (o reloadableUnaryOutbound) Introspect() introspection.OutboundStatus {
+	if out, ok := o.r.load().Unary.(introspection.IntrospectableOutbound); ok {
+		return out.Introspect()
+	}
+	return introspection.OutboundStatusNotSupported
+}
+
+func (o reloadableUnaryOutbound) Call(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	out := o.r.load().Unary
+	if out == nil {
+		return nil, o.r.unsupported("unary")
+	}
+	return out.Call(ctx, req)
+}
+
+type reloadableOnewayOutbound struct{ r *reloadableOutbound }
+
+func (o reloadableOnewayOutbound) Transports() []transport.Transport {
+	if out := o.r.load().Oneway; out != nil {
+		return out.Transports()
+	}
+	return nil
+}
+
+func (o reloadableOnewayOutbound) Start() error {
+	if out := o.r.load().Oneway; out != nil {
+		return out.Start()
+	}
+	return nil
+}
+
+func (o reloadableOnewayOutbound) Stop() error {
+	if out := o.r.load().Oneway; out != nil {
+		return out.Stop()
+	}
+	return nil
+}
+
+func (o reloadableOnewayOutbound) IsRunning() bool {
+	if out := o.r.load().Oneway; out != nil {
+		return out.IsRunning()
+	}
+	return false
+}
+
+func (o reloadableOnewayOutbound) Introspect() introspection.OutboundStatus {
+	if out, ok := o.r.load().Oneway.(introspection.IntrospectableOutbound); ok {
+		return out.Introspect()
+	}
+	return introspection.OutboundStatusNotSupported
+}
+
+func (o reloadableOnewayOutbound) CallOneway(ctx context.Context, req *transport.Request) (transport.Ack, error) {
+	out := o.r.load().Oneway
+	if out == nil {
+		return nil, o.r.unsupported("oneway")
+	}
+	return out.CallOneway(ctx, req)
+}
+
+type reloadableStreamOutbound struct{ r *reloadableOutbound }
+
+func (o reloadableStreamOutbound) Transports() []transport.Transport {
+	if out := o.r.load().Stream; out != nil {
+		return out.Transports()
+	}
+	return nil
+}
+
+func (o reloadableStreamOutbound) Start() error {
+	if out := o.r.load().Stream; out != nil {
+		return out.Start()
+	}
+	return nil
+}
+
+func (o reloadableStreamOutbound) Stop() error {
+	if out := o.r.load().Stream; out != nil {
+		return out.Stop()
+	}
+	return nil
+}
+
+func (o reloadableStreamOutbound) IsRunning() bool {
+	if out := o.r.load().Stream; out != nil {
+		return out.IsRunning()
+	}
+	return false
+}
+
+func (o reloadableStreamOutbound) Introspect() introspection.OutboundStatus {
+	if out, ok := o.r.load().Stream.(introspection.IntrospectableOutbound); ok {
+		return out.Introspect()
+	}
+	return introspection.OutboundStatusNotSupported
+}
+
+func (o reloadableStreamOutbound) CallStream(ctx context.Context, req *transport.StreamRequest) (*transport.ClientStream, error) {
+	out := o.r.load().Stream
+	if out == nil {
+		return nil, o.r.unsupported("stream")
+	}
+	return out.CallStream(ctx, req)
+}
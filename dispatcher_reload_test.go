@@ -0,0 +1,220 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// fakeLifecycle records whether it is running, and fails to start with
+// startErr.
+type fakeLifecycle struct {
+	running  atomic.Bool
+	startErr error
+}
+
+func (l *fakeLifecycle) Start() error {
+	if l.startErr != nil {
+		return l.startErr
+	}
+	l.running.Store(true)
+	return nil
+}
+
+func (l *fakeLifecycle) Stop() error {
+	l.running.Store(false)
+	return nil
+}
+
+func (l *fakeLifecycle) IsRunning() bool { return l.running.Load() }
+
+type reloadTransport struct{ fakeLifecycle }
+
+// reloadOutbound answers calls with its name in a header.
+type reloadOutbound struct {
+	fakeLifecycle
+
+	name      string
+	transport *reloadTransport
+}
+
+func (o *reloadOutbound) Transports() []transport.Transport {
+	return []transport.Transport{o.transport}
+}
+
+func (o *reloadOutbound) Call(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	return &transport.Response{Headers: transport.NewHeaders().With("outbound", o.name)}, nil
+}
+
+func callOutbound(t *testing.T, cc transport.ClientConfig) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	res, err := cc.GetUnaryOutbound().Call(ctx, &transport.Request{
+		Caller:    cc.Caller(),
+		Service:   cc.Service(),
+		Encoding:  "raw",
+		Procedure: "get",
+	})
+	if err != nil {
+		return "", err
+	}
+	name, _ := res.Headers.Get("outbound")
+	return name, nil
+}
+
+func TestReloadOutbounds(t *testing.T) {
+	oldTransport, newTransport := &reloadTransport{}, &reloadTransport{}
+	users := &reloadOutbound{name: "users", transport: oldTransport}
+	storage := &reloadOutbound{name: "storage", transport: oldTransport}
+
+	d := NewDispatcher(Config{
+		Name: "test",
+		Outbounds: Outbounds{
+			"users":   {Unary: users},
+			"storage": {Unary: storage},
+		},
+	})
+	require.NoError(t, d.Start())
+	defer d.Stop()
+
+	usersClient := d.ClientConfig("users")
+	storageClient := d.ClientConfig("storage")
+	name, err := callOutbound(t, usersClient)
+	require.NoError(t, err)
+	assert.Equal(t, "users", name)
+
+	newUsers := &reloadOutbound{name: "new users", transport: newTransport}
+	billing := &reloadOutbound{name: "billing", transport: oldTransport}
+	require.NoError(t, d.Reload(Config{
+		Name: "test",
+		Outbounds: Outbounds{
+			"users":   {Unary: newUsers},
+			"billing": {Unary: billing},
+		},
+	}))
+
+	assert.False(t, users.IsRunning(), "replaced outbounds must be stopped")
+	assert.False(t, storage.IsRunning(), "removed outbounds must be stopped")
+	assert.True(t, newUsers.IsRunning(), "new outbounds must be started")
+	assert.True(t, billing.IsRunning(), "new outbounds must be started")
+	assert.True(t, newTransport.IsRunning(), "new transports must be started")
+	assert.True(t, oldTransport.IsRunning(), "transports still in use must keep running")
+
+	name, err = callOutbound(t, usersClient)
+	require.NoError(t, err)
+	assert.Equal(t, "new users", name, "existing clients must use the new outbound")
+
+	_, err = callOutbound(t, storageClient)
+	require.Error(t, err)
+	assert.Equal(t, yarpcerrors.CodeUnimplemented, yarpcerrors.FromError(err).Code())
+
+	name, err = callOutbound(t, d.ClientConfig("billing"))
+	require.NoError(t, err)
+	assert.Equal(t, "billing", name)
+
+	outbounds := d.Outbounds()
+	assert.Len(t, outbounds, 2)
+	assert.Contains(t, outbounds, "users")
+	assert.Contains(t, outbounds, "billing")
+
+	require.NoError(t, d.Reload(Config{
+		Name: "test",
+		Outbounds: Outbounds{
+			"users": {Unary: newUsers},
+		},
+	}))
+	assert.True(t, newUsers.IsRunning(), "unchanged outbounds must keep running")
+	assert.False(t, billing.IsRunning())
+	assert.False(t, oldTransport.IsRunning(), "transports no longer used must be stopped")
+}
+
+func TestReloadStartFailure(t *testing.T) {
+	oldTransport, newTransport := &reloadTransport{}, &reloadTransport{}
+	users := &reloadOutbound{name: "users", transport: oldTransport}
+	d := NewDispatcher(Config{
+		Name:      "test",
+		Outbounds: Outbounds{"users": {Unary: users}},
+	})
+	require.NoError(t, d.Start())
+	defer d.Stop()
+
+	broken := &reloadOutbound{name: "broken", transport: newTransport}
+	broken.startErr = errors.New("great sadness")
+	err := d.Reload(Config{
+		Name:      "test",
+		Outbounds: Outbounds{"users": {Unary: broken}},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "great sadness")
+
+	assert.True(t, users.IsRunning(), "outbounds must not change if the reload fails")
+	assert.False(t, newTransport.IsRunning(), "transports started by a failed reload must be stopped")
+	name, err := callOutbound(t, d.ClientConfig("users"))
+	require.NoError(t, err)
+	assert.Equal(t, "users", name)
+}
+
+func TestReloadBeforeStart(t *testing.T) {
+	tr := &reloadTransport{}
+	users := &reloadOutbound{name: "users", transport: tr}
+	d := NewDispatcher(Config{
+		Name:      "test",
+		Outbounds: Outbounds{"users": {Unary: users}},
+	})
+	usersClient := d.ClientConfig("users")
+
+	newUsers := &reloadOutbound{name: "new users", transport: tr}
+	require.NoError(t, d.Reload(Config{
+		Name:      "test",
+		Outbounds: Outbounds{"users": {Unary: newUsers}},
+	}))
+	assert.False(t, newUsers.IsRunning(), "reloading must not start a dispatcher that is not running")
+
+	require.NoError(t, d.Start())
+	defer d.Stop()
+	assert.False(t, users.IsRunning())
+	assert.True(t, newUsers.IsRunning())
+
+	name, err := callOutbound(t, usersClient)
+	require.NoError(t, err)
+	assert.Equal(t, "new users", name)
+}
+
+func TestReloadInvalidConfig(t *testing.T) {
+	d := NewDispatcher(Config{Name: "test"})
+
+	err := d.Reload(Config{Name: "other"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `cannot reload dispatcher "test"`)
+
+	err = d.Reload(Config{Name: "test", Outbounds: Outbounds{"users": {}}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `no outbound set for outbound key "users"`)
+}
@@ -21,6 +21,7 @@
 package yarpc
 
 import (
+	"context"
 	"errors"
 	"sync"
 
@@ -44,6 +45,7 @@ import (
 type PhasedStarter struct {
 	startedMu sync.Mutex
 	started   []transport.Lifecycle
+	aborted   bool
 
 	dispatcher *Dispatcher
 	log        *zap.Logger
@@ -64,12 +66,21 @@ func (s *PhasedStarter) StartTransports() error {
 		return errors.New("already began starting transports")
 	}
 	defer s.transportsStarted.Store(true)
+	hooks := &s.dispatcher.hooks
+	if err := hooks.runStart(s.log, &hooks.beforeStart); err != nil {
+		return err
+	}
+	ctx, cancel := s.phaseContext(TransportsPhase)
+	defer cancel()
+	if err := s.waitGates(ctx, TransportsPhase); err != nil {
+		return s.abort([]error{err})
+	}
 	s.log.Info("starting transports")
 	wait := errorsync.ErrorWaiter{}
-	for _, t := range s.dispatcher.transports {
+	for _, t := range s.dispatcher.currentTransports() {
 		wait.Submit(s.start(t))
 	}
-	if errs := wait.Wait(); len(errs) != 0 {
+	if errs := s.wait(ctx, TransportsPhase, &wait); len(errs) != 0 {
 		return s.abort(errs)
 	}
 	s.log.Debug("started transports")
@@ -88,14 +99,19 @@ func (s *PhasedStarter) StartOutbounds() error {
 		return errors.New("already began starting outbounds")
 	}
 	defer s.outboundsStarted.Store(true)
+	ctx, cancel := s.phaseContext(OutboundsPhase)
+	defer cancel()
+	if err := s.waitGates(ctx, OutboundsPhase); err != nil {
+		return s.abort([]error{err})
+	}
 	s.log.Info("starting outbounds")
 	wait := errorsync.ErrorWaiter{}
-	for _, o := range s.dispatcher.outbounds {
+	for _, o := range s.dispatcher.currentOutbounds() {
 		wait.Submit(s.start(o.Unary))
 		wait.Submit(s.start(o.Oneway))
 		wait.Submit(s.start(o.Stream))
 	}
-	if errs := wait.Wait(); len(errs) != 0 {
+	if errs := s.wait(ctx, OutboundsPhase, &wait); len(errs) != 0 {
 		return s.abort(errs)
 	}
 	s.log.Debug("started outbounds")
@@ -113,15 +129,25 @@ func (s *PhasedStarter) StartInbounds() error {
 	if s.inboundsStartInitiated.Swap(true) {
 		return errors.New("already began starting inbounds")
 	}
+	ctx, cancel := s.phaseContext(InboundsPhase)
+	defer cancel()
+	if err := s.waitGates(ctx, InboundsPhase); err != nil {
+		return s.abort([]error{err})
+	}
 	s.log.Info("starting inbounds")
 	wait := errorsync.ErrorWaiter{}
 	for _, i := range s.dispatcher.inbounds {
 		wait.Submit(s.start(i))
 	}
-	if errs := wait.Wait(); len(errs) != 0 {
+	if errs := s.wait(ctx, InboundsPhase, &wait); len(errs) != 0 {
 		return s.abort(errs)
 	}
 	s.log.Debug("started inbounds")
+	hooks := &s.dispatcher.hooks
+	if err := hooks.runStart(s.log, &hooks.onStart); err != nil {
+		return s.abort([]error{err})
+	}
+	publishExpvar(s.dispatcher)
 	return nil
 }
 
@@ -136,6 +162,11 @@ func (s *PhasedStarter) start(lc transport.Lifecycle) func() error {
 		}
 
 		s.startedMu.Lock()
+		if s.aborted {
+			// Startup failed, like by timing out, while this was starting.
+			s.startedMu.Unlock()
+			return lc.Stop()
+		}
 		s.started = append(s.started, lc)
 		s.startedMu.Unlock()
 
@@ -147,6 +178,7 @@ func (s *PhasedStarter) abort(errs []error) error {
 	// Failed to start so stop everything that was started.
 	wait := errorsync.ErrorWaiter{}
 	s.startedMu.Lock()
+	s.aborted = true
 	for _, lc := range s.started {
 		wait.Submit(lc.Stop)
 	}
@@ -175,12 +207,15 @@ func (s *PhasedStarter) setRouters() {
 // shutdown, see the documentation for the Dispatcher's PhasedStop method.
 //
 // The user of a PhasedStopper is responsible for correctly ordering shutdown:
-// inbounds MUST be stopped before outbounds, which MUST be stopped before
-// transports. Attempting shutdown in any other order will return an error.
+// requests MAY be drained first, then inbounds MUST be stopped before
+// outbounds, which MUST be stopped before transports. Attempting shutdown in
+// any other order will return an error.
 type PhasedStopper struct {
 	dispatcher *Dispatcher
 	log        *zap.Logger
 
+	beforeStopRan           atomic.Bool
+	drainInitiated          atomic.Bool
 	inboundsStopInitiated   atomic.Bool
 	inboundsStopped         atomic.Bool
 	outboundsStopInitiated  atomic.Bool
@@ -188,7 +223,44 @@ type PhasedStopper struct {
 	transportsStopInitiated atomic.Bool
 }
 
-// StopInbounds is the first step in shutdown. It stops all inbounds
+// DrainRequests is the optional first step in shutdown. If the dispatcher
+// has a DrainTimeout, it rejects new inbound requests and waits up to the
+// timeout for requests in flight to finish, canceling those that do not and
+// reporting them in a DrainError. Otherwise, it returns immediately. It's
+// safe to call concurrently, but all calls after the first return an error.
+func (s *PhasedStopper) DrainRequests() error {
+	if s.inboundsStopInitiated.Load() {
+		return errors.New("must drain requests before stopping inbounds")
+	}
+	if s.drainInitiated.Swap(true) {
+		return errors.New("already began draining requests")
+	}
+	hookErr := s.runBeforeStop()
+	if s.dispatcher.inflight == nil {
+		return hookErr
+	}
+	s.log.Debug("draining requests", zap.Duration("timeout", s.dispatcher.drainTimeout))
+	ctx, cancel := context.WithTimeout(context.Background(), s.dispatcher.drainTimeout)
+	defer cancel()
+	if err := s.dispatcher.inflight.drain(ctx); err != nil {
+		s.log.Warn("canceled requests in flight after the drain timeout", zap.Error(err))
+		return multierr.Append(hookErr, err)
+	}
+	s.log.Debug("drained requests")
+	return hookErr
+}
+
+// runBeforeStop runs the BeforeStop hooks of the dispatcher, unless they
+// already ran.
+func (s *PhasedStopper) runBeforeStop() error {
+	if s.beforeStopRan.Swap(true) {
+		return nil
+	}
+	hooks := &s.dispatcher.hooks
+	return hooks.runStop(s.log, &hooks.beforeStop)
+}
+
+// StopInbounds is the first required step in shutdown. It stops all inbounds
 // configured on the dispatcher, which stops routing RPCs to all registered
 // procedures. It's safe to call concurrently, but all calls after the first
 // return an error.
@@ -197,16 +269,18 @@ func (s *PhasedStopper) StopInbounds() error {
 		return errors.New("already began stopping inbounds")
 	}
 	defer s.inboundsStopped.Store(true)
+	hookErr := s.runBeforeStop()
+	unpublishExpvar(s.dispatcher)
 	s.log.Debug("stopping inbounds")
 	wait := errorsync.ErrorWaiter{}
 	for _, ib := range s.dispatcher.inbounds {
 		wait.Submit(ib.Stop)
 	}
 	if errs := wait.Wait(); len(errs) > 0 {
-		return multierr.Combine(errs...)
+		return multierr.Combine(append([]error{hookErr}, errs...)...)
 	}
 	s.log.Debug("stopped inbounds")
-	return nil
+	return hookErr
 }
 
 // StopOutbounds is the second step in shutdown. It stops all outbounds
@@ -223,7 +297,7 @@ func (s *PhasedStopper) StopOutbounds() error {
 	defer s.outboundsStopped.Store(true)
 	s.log.Debug("stopping outbounds")
 	wait := errorsync.ErrorWaiter{}
-	for _, o := range s.dispatcher.outbounds {
+	for _, o := range s.dispatcher.currentOutbounds() {
 		if o.Unary != nil {
 			wait.Submit(o.Unary.Stop)
 		}
@@ -253,11 +327,12 @@ func (s *PhasedStopper) StopTransports() error {
 	}
 	s.log.Debug("stopping transports")
 	wait := errorsync.ErrorWaiter{}
-	for _, t := range s.dispatcher.transports {
+	for _, t := range s.dispatcher.currentTransports() {
 		wait.Submit(t.Stop)
 	}
+	hooks := &s.dispatcher.hooks
 	if errs := wait.Wait(); len(errs) > 0 {
-		return multierr.Combine(errs...)
+		return multierr.Combine(append(errs, hooks.runStop(s.log, &hooks.onStop))...)
 	}
 	s.log.Debug("stopped transports")
 
@@ -265,5 +340,5 @@ func (s *PhasedStopper) StopTransports() error {
 	s.dispatcher.stopMeter()
 	s.log.Debug("stopped metrics push loop, if any")
 
-	return nil
+	return hooks.runStop(s.log, &hooks.onStop)
 }
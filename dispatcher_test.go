@@ -100,6 +100,15 @@ func TestDispatcherNamePanic(t *testing.T) {
 	}
 }
 
+func TestDispatcherInvalidMetricsTagsPanic(t *testing.T) {
+	require.Panics(t, func() {
+		NewDispatcher(Config{
+			Name:    "test",
+			Metrics: MetricsConfig{DropTags: []string{"tenant"}},
+		})
+	}, "expected dropping an unknown metrics tag to panic")
+}
+
 func TestDispatcherRegisterPanic(t *testing.T) {
 	d := basicDispatcher(t)
 
@@ -490,6 +499,7 @@ func TestPhasedStartStop(t *testing.T) {
 		assert.Error(t, stopper.StopTransports(), "succeeded stopping transports before inbounds")
 		assert.Error(t, stopper.StopOutbounds(), "succeeded stopping outbounds before inbounds")
 		require.NoError(t, stopper.StopInbounds(), "stopping inbunds failed")
+		assert.Error(t, stopper.DrainRequests(), "succeeded draining requests after stopping inbounds")
 
 		// Must stop outbounds second.
 		assert.Error(t, stopper.StopInbounds(), "succeeded stopping inbounds again")
@@ -872,3 +882,47 @@ func checkPackageVersion(t *testing.T, packageNameToVersion map[string]string, k
 	assert.NotEmpty(t, version)
 	assert.Equal(t, expectedVersion, version)
 }
+
+func TestRegisterAndUnregisterAfterStart(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	d := NewDispatcher(Config{Name: "test"})
+	require.NoError(t, d.Start())
+	defer d.Stop()
+
+	handler := transporttest.NewMockUnaryHandler(mockCtrl)
+	procedures := []transport.Procedure{{
+		Name:        "plugin",
+		HandlerSpec: transport.NewUnaryHandlerSpec(handler),
+	}}
+	req := &transport.Request{Service: "test", Procedure: "plugin"}
+
+	d.Register(procedures)
+	_, err := d.Router().Choose(context.Background(), req)
+	assert.NoError(t, err, "procedures registered after start must be routed")
+
+	d.Unregister(procedures)
+	_, err = d.Router().Choose(context.Background(), req)
+	assert.Error(t, err, "unregistered procedures must not be routed")
+	assert.Empty(t, d.Router().Procedures())
+}
+
+func TestDispatcherAliases(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	d := NewDispatcher(Config{Name: "test", Aliases: []string{"legacy-a"}})
+	d.RegisterAlias("legacy-b", "test")
+	d.Register([]transport.Procedure{{
+		Name:        "echo",
+		HandlerSpec: transport.NewUnaryHandlerSpec(transporttest.NewMockUnaryHandler(mockCtrl)),
+	}})
+
+	for _, service := range []string{"test", "legacy-a", "legacy-b"} {
+		_, err := d.Router().Choose(context.Background(), &transport.Request{Service: service, Procedure: "echo"})
+		assert.NoError(t, err, "requests to %q must be routed", service)
+	}
+
+	assert.Panics(t, func() { NewDispatcher(Config{Name: "test", Aliases: []string{"not valid"}}) })
+}
@@ -0,0 +1,176 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpc
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/zap"
+)
+
+// HandlerTimeoutConfig configures how a Dispatcher enforces the deadlines of
+// the unary requests it handles.
+//
+// Transports give handlers contexts with the deadlines their callers
+// propagated, but only handlers that stop when their context is done respect
+// them. With Enforce, the Dispatcher answers requests whose deadline passed
+// with a DeadlineExceeded error, whatever their handlers return.
+type HandlerTimeoutConfig struct {
+	// Enforce answers requests that outlive their deadline with a
+	// DeadlineExceeded error, and cancels the contexts of their handlers.
+	Enforce bool
+
+	// AbandonAfter is how long after the deadline of a request the
+	// Dispatcher waits for its handler to return. Handlers which take longer
+	// are abandoned: the request is answered at once while the handler keeps
+	// running, and whatever it writes to its response is dropped.
+	//
+	// By default, the Dispatcher waits for handlers to return, however long
+	// they take.
+	AbandonAfter time.Duration
+}
+
+var _ middleware.UnaryInbound = (*handlerTimeout)(nil)
+
+// handlerTimeout is inbound middleware which enforces the deadlines of
+// requests on their handlers.
+type handlerTimeout struct {
+	abandonAfter time.Duration
+	log          *zap.Logger
+}
+
+func newHandlerTimeout(cfg HandlerTimeoutConfig, log *zap.Logger) *handlerTimeout {
+	return &handlerTimeout{abandonAfter: cfg.AbandonAfter, log: log}
+}
+
+func (t *handlerTimeout) Handle(ctx context.Context, req *transport.Request, w transport.ResponseWriter, h transport.UnaryHandler) error {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return h.Handle(ctx, req, w)
+	}
+
+	start := time.Now()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if t.abandonAfter <= 0 {
+		return t.enforce(ctx, req, start, h.Handle(ctx, req, w))
+	}
+
+	aw := &abandonableResponseWriter{w: w}
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+		done <- h.Handle(ctx, req, aw)
+	}()
+
+	timer := time.NewTimer(deadline.Add(t.abandonAfter).Sub(start))
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return t.enforce(ctx, req, start, err)
+	case <-timer.C:
+		aw.abandon()
+		t.log.Warn("abandoned handler which did not return after the deadline of its request",
+			zap.String("service", req.Service),
+			zap.String("procedure", req.Procedure),
+			zap.String("caller", req.Caller),
+			zap.Duration("abandonAfter", t.abandonAfter))
+		return deadlineExceededError(req, deadline.Sub(start))
+	}
+}
+
+// enforce returns a DeadlineExceeded error in place of the result of
+// handlers which returned after the deadline of their request.
+func (t *handlerTimeout) enforce(ctx context.Context, req *transport.Request, start time.Time, err error) error {
+	if ctx.Err() != context.DeadlineExceeded {
+		return err
+	}
+	if yarpcerrors.IsDeadlineExceeded(err) {
+		return err
+	}
+	deadline, _ := ctx.Deadline()
+	return deadlineExceededError(req, deadline.Sub(start))
+}
+
+func deadlineExceededError(req *transport.Request, timeout time.Duration) error {
+	return yarpcerrors.Newf(
+		yarpcerrors.CodeDeadlineExceeded,
+		"call to procedure %q of service %q from caller %q timed out after %v",
+		req.Procedure, req.Service, req.Caller, timeout)
+}
+
+// abandonableResponseWriter drops what handlers write to their response
+// once they were abandoned.
+type abandonableResponseWriter struct {
+	mu        sync.Mutex
+	w         transport.ResponseWriter
+	abandoned bool
+}
+
+func (w *abandonableResponseWriter) abandon() {
+	w.mu.Lock()
+	w.abandoned = true
+	w.mu.Unlock()
+}
+
+func (w *abandonableResponseWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.abandoned {
+		return len(p), nil
+	}
+	return w.w.Write(p)
+}
+
+func (w *abandonableResponseWriter) AddHeaders(h transport.Headers) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.abandoned {
+		w.w.AddHeaders(h)
+	}
+}
+
+func (w *abandonableResponseWriter) SetApplicationError() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.abandoned {
+		w.w.SetApplicationError()
+	}
+}
+
+func (w *abandonableResponseWriter) SetApplicationErrorMeta(meta *transport.ApplicationErrorMeta) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if setter, ok := w.w.(transport.ApplicationErrorMetaSetter); ok && !w.abandoned {
+		setter.SetApplicationErrorMeta(meta)
+	}
+}
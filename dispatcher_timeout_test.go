@@ -0,0 +1,135 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// stubbornHandler writes its response after it is released, whether or not
+// its context is done.
+type stubbornHandler struct {
+	release chan struct{}
+	ctxErr  chan error
+}
+
+func newStubbornHandler() *stubbornHandler {
+	return &stubbornHandler{release: make(chan struct{}), ctxErr: make(chan error, 1)}
+}
+
+func (h *stubbornHandler) Handle(ctx context.Context, _ *transport.Request, w transport.ResponseWriter) error {
+	<-h.release
+	h.ctxErr <- ctx.Err()
+	w.AddHeaders(transport.NewHeaders().With("answered", "late"))
+	_, err := w.Write([]byte("too late"))
+	return err
+}
+
+type panickingHandler struct{}
+
+func (panickingHandler) Handle(context.Context, *transport.Request, transport.ResponseWriter) error {
+	panic("great sadness")
+}
+
+func TestHandlerTimeoutEnforce(t *testing.T) {
+	timeout := newHandlerTimeout(HandlerTimeoutConfig{Enforce: true}, zap.NewNop())
+	req := &transport.Request{Caller: "frontend", Service: "users", Procedure: "get"}
+
+	t.Run("within deadline", func(t *testing.T) {
+		h := newStubbornHandler()
+		close(h.release)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		w := &transporttest.FakeResponseWriter{}
+		require.NoError(t, timeout.Handle(ctx, req, w, h))
+		assert.NoError(t, <-h.ctxErr)
+		assert.Equal(t, "too late", w.Body.String())
+	})
+
+	t.Run("after deadline", func(t *testing.T) {
+		h := newStubbornHandler()
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		time.AfterFunc(50*time.Millisecond, func() { close(h.release) })
+
+		err := timeout.Handle(ctx, req, &transporttest.FakeResponseWriter{}, h)
+		require.Error(t, err)
+		assert.Equal(t, yarpcerrors.CodeDeadlineExceeded, yarpcerrors.FromError(err).Code())
+		assert.Contains(t, err.Error(), `call to procedure "get" of service "users" from caller "frontend" timed out`)
+		assert.Equal(t, context.DeadlineExceeded, <-h.ctxErr, "the context of the handler must be done")
+	})
+
+	t.Run("without deadline", func(t *testing.T) {
+		h := newStubbornHandler()
+		close(h.release)
+		assert.NoError(t, timeout.Handle(context.Background(), req, &transporttest.FakeResponseWriter{}, h))
+	})
+}
+
+func TestHandlerTimeoutAbandon(t *testing.T) {
+	core, logs := observer.New(zap.WarnLevel)
+	timeout := newHandlerTimeout(HandlerTimeoutConfig{Enforce: true, AbandonAfter: 10 * time.Millisecond}, zap.New(core))
+	req := &transport.Request{Caller: "frontend", Service: "users", Procedure: "get"}
+
+	h := newStubbornHandler()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	w := &transporttest.FakeResponseWriter{}
+	err := timeout.Handle(ctx, req, w, h)
+	require.Error(t, err)
+	assert.Equal(t, yarpcerrors.CodeDeadlineExceeded, yarpcerrors.FromError(err).Code())
+	if assert.Len(t, logs.All(), 1) {
+		assert.Contains(t, logs.All()[0].Message, "abandoned handler")
+	}
+
+	close(h.release)
+	assert.Error(t, <-h.ctxErr, "the context of an abandoned handler must be done")
+	assert.Empty(t, w.Body.String(), "writes of abandoned handlers must be dropped")
+	assert.Equal(t, 0, w.Headers.Len())
+
+	t.Run("panic", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		err := timeout.Handle(ctx, req, &transporttest.FakeResponseWriter{}, panickingHandler{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "great sadness")
+	})
+}
+
+func TestDispatcherHandlerTimeout(t *testing.T) {
+	d := NewDispatcher(Config{Name: "test", HandlerTimeout: HandlerTimeoutConfig{Enforce: true}})
+	assert.NotNil(t, d.InboundMiddleware().Unary, "enforcing deadlines must add inbound middleware")
+
+	d = NewDispatcher(Config{Name: "test", DisableAutoObservabilityMiddleware: true})
+	assert.Nil(t, d.InboundMiddleware().Unary)
+}
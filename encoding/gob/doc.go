@@ -0,0 +1,61 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package gob provides an encoding for YARPC based on the standard library's
+// encoding/gob.
+//
+// Gob trades cross-language interoperability for zero IDL maintenance: request
+// and response bodies are plain Go structs, and both sides of a call need only
+// agree on their shape. Use it only between services that are entirely written
+// in Go.
+//
+// To make outbound requests using this encoding,
+//
+// 	client := gob.New(clientConfig)
+// 	var resBody GetValueResponse
+// 	err := client.Call(ctx, "getValue", &GetValueRequest{...}, &resBody)
+//
+// To register a gob procedure, define functions in the format,
+//
+// 	f(ctx context.Context, body $reqBody) ($resBody, error)
+//
+// Where '$reqBody' and '$resBody' are pointers to structs representing your
+// request and response objects. As with encoding/gob, fields are matched by
+// name, only exported fields are transmitted, and concrete types sent in
+// interface-typed fields must be registered with gob.Register on both sides.
+//
+// Use the Procedure function to build procedures to register against a
+// Router.
+//
+// 	dispatcher.Register(gob.Procedure("getValue", GetValue))
+// 	dispatcher.Register(gob.Procedure("setValue", SetValue))
+//
+// Similarly, to register a oneway gob procedure, define functions in the
+// format,
+//
+// 	f(ctx context.Context, body $reqBody) error
+//
+// Where $reqBody is a pointer to a struct.
+//
+// Use the OnewayProcedure function to build procedures to register against a
+// Router.
+//
+// 	dispatcher.Register(gob.OnewayProcedure("runTask", RunTask))
+package gob
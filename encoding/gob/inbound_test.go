@@ -0,0 +1,143 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package gob
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+type simpleRequest struct {
+	Name   string
+	Values map[string][]int
+}
+
+type simpleResponse struct {
+	Success bool
+}
+
+func encode(t *testing.T, v interface{}) []byte {
+	var buff bytes.Buffer
+	require.NoError(t, gob.NewEncoder(&buff).Encode(v))
+	return buff.Bytes()
+}
+
+func TestHandleSuccess(t *testing.T) {
+	h := func(ctx context.Context, body *simpleRequest) (*simpleResponse, error) {
+		assert.Equal(t, "simpleCall", yarpc.CallFromContext(ctx).Procedure())
+		assert.Equal(t, "foo", body.Name)
+		assert.Equal(t, map[string][]int{"a": {1, 2}}, body.Values)
+		return &simpleResponse{Success: true}, nil
+	}
+
+	resw := new(transporttest.FakeResponseWriter)
+	err := Procedure("simpleCall", h)[0].HandlerSpec.Unary().Handle(context.Background(), &transport.Request{
+		Procedure: "simpleCall",
+		Encoding:  Encoding,
+		Body: bytes.NewReader(encode(t, &simpleRequest{
+			Name:   "foo",
+			Values: map[string][]int{"a": {1, 2}},
+		})),
+	}, resw)
+	require.NoError(t, err)
+
+	var res simpleResponse
+	require.NoError(t, gob.NewDecoder(&resw.Body).Decode(&res))
+	assert.True(t, res.Success)
+}
+
+func TestHandleApplicationError(t *testing.T) {
+	h := func(ctx context.Context, body *simpleRequest) (*simpleResponse, error) {
+		return nil, errors.New("great sadness")
+	}
+
+	resw := new(transporttest.FakeResponseWriter)
+	err := Procedure("simpleCall", h)[0].HandlerSpec.Unary().Handle(context.Background(), &transport.Request{
+		Procedure: "simpleCall",
+		Encoding:  Encoding,
+		Body:      bytes.NewReader(encode(t, &simpleRequest{Name: "foo"})),
+	}, resw)
+	assert.EqualError(t, err, "great sadness")
+	assert.True(t, resw.IsApplicationError)
+	assert.Empty(t, resw.Body.Bytes())
+}
+
+func TestHandleRequestErrors(t *testing.T) {
+	h := func(ctx context.Context, body *simpleRequest) (*simpleResponse, error) {
+		t.Fatal("handler must not be called")
+		return nil, nil
+	}
+	handler := Procedure("simpleCall", h)[0].HandlerSpec.Unary()
+
+	t.Run("encoding mismatch", func(t *testing.T) {
+		err := handler.Handle(context.Background(), &transport.Request{
+			Procedure: "simpleCall",
+			Encoding:  "json",
+			Body:      bytes.NewReader(encode(t, &simpleRequest{Name: "foo"})),
+		}, new(transporttest.FakeResponseWriter))
+		assert.Equal(t, yarpcerrors.CodeInvalidArgument, yarpcerrors.FromError(err).Code())
+	})
+
+	t.Run("invalid body", func(t *testing.T) {
+		err := handler.Handle(context.Background(), &transport.Request{
+			Procedure: "simpleCall",
+			Encoding:  Encoding,
+			Body:      bytes.NewReader([]byte("not gob")),
+		}, new(transporttest.FakeResponseWriter))
+		assert.Equal(t, yarpcerrors.CodeInvalidArgument, yarpcerrors.FromError(err).Code())
+	})
+
+	t.Run("mismatched type", func(t *testing.T) {
+		err := handler.Handle(context.Background(), &transport.Request{
+			Procedure: "simpleCall",
+			Encoding:  Encoding,
+			Body:      bytes.NewReader(encode(t, &simpleResponse{Success: true})),
+		}, new(transporttest.FakeResponseWriter))
+		assert.Equal(t, yarpcerrors.CodeInvalidArgument, yarpcerrors.FromError(err).Code())
+	})
+}
+
+func TestHandleOneway(t *testing.T) {
+	var got *simpleRequest
+	h := func(ctx context.Context, body *simpleRequest) error {
+		got = body
+		return nil
+	}
+
+	err := OnewayProcedure("simpleCall", h)[0].HandlerSpec.Oneway().HandleOneway(context.Background(), &transport.Request{
+		Procedure: "simpleCall",
+		Encoding:  Encoding,
+		Body:      bytes.NewReader(encode(t, &simpleRequest{Name: "foo"})),
+	})
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.Equal(t, "foo", got.Name)
+}
@@ -0,0 +1,173 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package json
+
+import (
+	"context"
+	"sync"
+
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// DecodePool is a bounded pool of goroutines which decode JSON response
+// bodies on behalf of AsyncClients. Sharing a pool between clients bounds the
+// CPU spent on deserialization while callers continue to overlap any number
+// of outstanding network requests.
+//
+// 	pool := json.NewDecodePool(runtime.NumCPU())
+// 	defer pool.Stop()
+//
+// 	client := json.NewAsync(clientConfig, pool)
+type DecodePool struct {
+	jobs     chan func()
+	stopped  chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewDecodePool starts a DecodePool with the given number of workers. At
+// least one worker is always started.
+func NewDecodePool(workers int) *DecodePool {
+	if workers < 1 {
+		workers = 1
+	}
+	p := &DecodePool{
+		jobs:    make(chan func()),
+		stopped: make(chan struct{}),
+	}
+	p.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go p.work()
+	}
+	return p
+}
+
+func (p *DecodePool) work() {
+	defer p.wg.Done()
+	for {
+		select {
+		case job := <-p.jobs:
+			job()
+		case <-p.stopped:
+			return
+		}
+	}
+}
+
+// submit hands the job to a worker, blocking until one is free. It returns
+// an error if the context finishes or the pool is stopped first.
+func (p *DecodePool) submit(ctx context.Context, job func()) error {
+	select {
+	case p.jobs <- job:
+		return nil
+	case <-p.stopped:
+		return yarpcerrors.Newf(yarpcerrors.CodeUnavailable, "json decode pool has been stopped")
+	case <-ctx.Done():
+		return yarpcerrors.Newf(yarpcerrors.CodeDeadlineExceeded, "timed out waiting for a json decode worker: %v", ctx.Err())
+	}
+}
+
+// Stop stops all workers in the pool, waiting for in-flight decodes to
+// finish. Calls which have not yet been handed to a worker fail with an
+// Unavailable error.
+func (p *DecodePool) Stop() {
+	p.stopOnce.Do(func() { close(p.stopped) })
+	p.wg.Wait()
+}
+
+// Future is a handle to the result of an asynchronous JSON call.
+type Future struct {
+	done chan struct{}
+	err  error
+}
+
+func newFuture() *Future {
+	return &Future{done: make(chan struct{})}
+}
+
+func (f *Future) resolve(err error) {
+	f.err = err
+	close(f.done)
+}
+
+// Done returns a channel that is closed once the call has completed and the
+// response body has been decoded into the value provided to CallAsync.
+func (f *Future) Done() <-chan struct{} {
+	return f.done
+}
+
+// Get blocks until the call completes or the given context finishes,
+// returning the error of the call, if any. The response body passed to
+// CallAsync must not be read until Get has returned a nil error.
+func (f *Future) Get(ctx context.Context) error {
+	select {
+	case <-f.done:
+		return f.err
+	case <-ctx.Done():
+		return yarpcerrors.Newf(yarpcerrors.CodeDeadlineExceeded, "timed out waiting for json call to complete: %v", ctx.Err())
+	}
+}
+
+// AsyncClient makes JSON requests to a single service without blocking the
+// calling goroutine, decoding responses on a DecodePool.
+type AsyncClient interface {
+	// CallAsync starts an outbound JSON request and returns immediately.
+	//
+	// resBodyOut is a pointer to a value that can be filled with
+	// json.Unmarshal. It is populated on a DecodePool worker and must not be
+	// accessed until the returned Future resolves.
+	CallAsync(ctx context.Context, procedure string, reqBody interface{}, resBodyOut interface{}, opts ...yarpc.CallOption) *Future
+}
+
+// NewAsync builds a new asynchronous JSON client which decodes responses on
+// the given pool.
+func NewAsync(c transport.ClientConfig, pool *DecodePool, opts ...ClientOption) AsyncClient {
+	return asyncClient{client: newClient(c, opts), pool: pool}
+}
+
+type asyncClient struct {
+	client jsonClient
+	pool   *DecodePool
+}
+
+func (c asyncClient) CallAsync(ctx context.Context, procedure string, reqBody interface{}, resBodyOut interface{}, opts ...yarpc.CallOption) *Future {
+	f := newFuture()
+	go func() {
+		call, treq, tres, err := c.client.send(ctx, procedure, reqBody, opts)
+		if tres == nil {
+			f.resolve(err)
+			return
+		}
+
+		job := func() {
+			f.resolve(c.client.decodeResponse(ctx, call, treq, tres, err, resBodyOut))
+		}
+		if submitErr := c.pool.submit(ctx, job); submitErr != nil {
+			if tres.Body != nil {
+				_ = tres.Body.Close()
+			}
+			f.resolve(submitErr)
+		}
+	}()
+	return f
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package json
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/internal/clientconfig"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+func TestCallAsync(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	pool := NewDecodePool(2)
+	defer pool.Stop()
+
+	outbound := transporttest.NewMockUnaryOutbound(mockCtrl)
+	client := NewAsync(clientconfig.MultiOutbound("caller", "service",
+		transport.Outbounds{Unary: outbound}), pool)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	var futures []*Future
+	results := make([]map[string]interface{}, 10)
+	for i := range results {
+		outbound.EXPECT().Call(gomock.Any(), gomock.Any()).Return(
+			&transport.Response{
+				Body: ioutil.NopCloser(bytes.NewReader([]byte(`{"success": true}`))),
+			}, nil)
+		futures = append(futures, client.CallAsync(ctx, "foo", map[string]interface{}{}, &results[i]))
+	}
+	for i, f := range futures {
+		require.NoError(t, f.Get(ctx))
+		assert.Equal(t, map[string]interface{}{"success": true}, results[i])
+	}
+}
+
+func TestCallAsyncErrors(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	t.Run("transport error", func(t *testing.T) {
+		pool := NewDecodePool(1)
+		defer pool.Stop()
+
+		outbound := transporttest.NewMockUnaryOutbound(mockCtrl)
+		client := NewAsync(clientconfig.MultiOutbound("caller", "service",
+			transport.Outbounds{Unary: outbound}), pool)
+		outbound.EXPECT().Call(gomock.Any(), gomock.Any()).Return(nil, errors.New("great sadness"))
+
+		var res map[string]interface{}
+		assert.EqualError(t, client.CallAsync(ctx, "foo", nil, &res).Get(ctx), "great sadness")
+	})
+
+	t.Run("decode error", func(t *testing.T) {
+		pool := NewDecodePool(1)
+		defer pool.Stop()
+
+		outbound := transporttest.NewMockUnaryOutbound(mockCtrl)
+		client := NewAsync(clientconfig.MultiOutbound("caller", "service",
+			transport.Outbounds{Unary: outbound}), pool)
+		outbound.EXPECT().Call(gomock.Any(), gomock.Any()).Return(
+			&transport.Response{Body: ioutil.NopCloser(bytes.NewReader([]byte(`invalid JSON`)))}, nil)
+
+		var res map[string]interface{}
+		err := client.CallAsync(ctx, "bar", nil, &res).Get(ctx)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `failed to decode "json" response body for procedure "bar" of service "service"`)
+	})
+
+	t.Run("stopped pool", func(t *testing.T) {
+		pool := NewDecodePool(1)
+		pool.Stop()
+
+		outbound := transporttest.NewMockUnaryOutbound(mockCtrl)
+		client := NewAsync(clientconfig.MultiOutbound("caller", "service",
+			transport.Outbounds{Unary: outbound}), pool)
+		outbound.EXPECT().Call(gomock.Any(), gomock.Any()).Return(
+			&transport.Response{Body: ioutil.NopCloser(bytes.NewReader([]byte(`{}`)))}, nil)
+
+		var res map[string]interface{}
+		err := client.CallAsync(ctx, "foo", nil, &res).Get(ctx)
+		assert.Equal(t, yarpcerrors.CodeUnavailable, yarpcerrors.FromError(err).Code())
+	})
+}
+
+func TestFutureGetTimeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := newFuture().Get(ctx)
+	assert.Equal(t, yarpcerrors.CodeDeadlineExceeded, yarpcerrors.FromError(err).Code())
+}
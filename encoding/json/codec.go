@@ -0,0 +1,84 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package json
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Codec is an implementation of JSON used to encode and decode request and
+// response bodies. It defaults to the standard library's encoding/json.
+//
+// Alternative implementations such as jsoniter may be plugged in with a small
+// adapter.
+//
+// 	type jsoniterCodec struct{ api jsoniter.API }
+//
+// 	func (c jsoniterCodec) Marshal(v interface{}) ([]byte, error) {
+// 		return c.api.Marshal(v)
+// 	}
+//
+// 	func (c jsoniterCodec) NewEncoder(w io.Writer) json.Encoder {
+// 		return c.api.NewEncoder(w)
+// 	}
+//
+// 	func (c jsoniterCodec) NewDecoder(r io.Reader) json.Decoder {
+// 		return c.api.NewDecoder(r)
+// 	}
+type Codec interface {
+	// Marshal returns the JSON encoding of v.
+	Marshal(v interface{}) ([]byte, error)
+
+	// NewEncoder returns an Encoder which writes to w.
+	NewEncoder(w io.Writer) Encoder
+
+	// NewDecoder returns a Decoder which reads from r.
+	NewDecoder(r io.Reader) Decoder
+}
+
+// Encoder writes JSON values to an output stream.
+type Encoder interface {
+	Encode(v interface{}) error
+}
+
+// Decoder reads JSON values from an input stream.
+//
+// Decoders which also implement DisallowUnknownFields() or UseNumber(), as
+// the standard library's does, honor the corresponding options.
+type Decoder interface {
+	Decode(v interface{}) error
+}
+
+// stdlibCodec is the default Codec, backed by encoding/json.
+type stdlibCodec struct{}
+
+func (stdlibCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (stdlibCodec) NewEncoder(w io.Writer) Encoder {
+	return json.NewEncoder(w)
+}
+
+func (stdlibCodec) NewDecoder(r io.Reader) Decoder {
+	return json.NewDecoder(r)
+}
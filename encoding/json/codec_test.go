@@ -0,0 +1,167 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package json
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/internal/clientconfig"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// recordingCodec wraps encoding/json, recording how it was used.
+type recordingCodec struct {
+	marshals int
+	encoders int
+	decoders []*recordingDecoder
+}
+
+func (c *recordingCodec) Marshal(v interface{}) ([]byte, error) {
+	c.marshals++
+	return json.Marshal(v)
+}
+
+func (c *recordingCodec) NewEncoder(w io.Writer) Encoder {
+	c.encoders++
+	return json.NewEncoder(w)
+}
+
+func (c *recordingCodec) NewDecoder(r io.Reader) Decoder {
+	d := &recordingDecoder{Decoder: json.NewDecoder(r)}
+	c.decoders = append(c.decoders, d)
+	return d
+}
+
+type recordingDecoder struct {
+	*json.Decoder
+
+	disallowUnknownFields bool
+}
+
+func (d *recordingDecoder) DisallowUnknownFields() {
+	d.disallowUnknownFields = true
+}
+
+func TestClientUseCodec(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	outbound := transporttest.NewMockUnaryOutbound(mockCtrl)
+	outbound.EXPECT().Call(gomock.Any(), gomock.Any()).Return(&transport.Response{
+		Body: ioutil.NopCloser(bytes.NewReader([]byte(`{"success": true}`))),
+	}, nil)
+
+	codec := &recordingCodec{}
+	client := NewWithOptions(
+		clientconfig.MultiOutbound("caller", "service", transport.Outbounds{Unary: outbound}),
+		UseCodec(codec),
+		DisallowUnknownFields,
+	)
+
+	var res simpleResponse
+	require.NoError(t, client.Call(context.Background(), "foo", &simpleRequest{Name: "foo"}, &res))
+	assert.True(t, res.Success)
+
+	assert.Equal(t, 1, codec.marshals)
+	require.Len(t, codec.decoders, 1)
+	assert.True(t, codec.decoders[0].disallowUnknownFields, "option was not applied to the decoder")
+}
+
+func TestProcedureUseCodec(t *testing.T) {
+	codec := &recordingCodec{}
+	procs := Procedure("simpleCall", func(ctx context.Context, body *simpleRequest) (*simpleResponse, error) {
+		return &simpleResponse{Success: body.Name == "foo"}, nil
+	}, UseCodec(codec))
+	require.Len(t, procs, 1)
+
+	resw := new(transporttest.FakeResponseWriter)
+	require.NoError(t, procs[0].HandlerSpec.Unary().Handle(context.Background(), &transport.Request{
+		Procedure: "simpleCall",
+		Encoding:  "json",
+		Body:      jsonBody(`{"name": "foo"}`),
+	}, resw))
+	assert.JSONEq(t, `{"Success": true}`, resw.Body.String())
+
+	assert.Len(t, codec.decoders, 1)
+	assert.Equal(t, 1, codec.encoders)
+}
+
+func TestProcedureDisallowUnknownFields(t *testing.T) {
+	var decoded bool
+	codec := &recordingCodec{}
+	procs := OnewayProcedure("simpleCall", func(ctx context.Context, body *simpleRequest) error {
+		decoded = true
+		return nil
+	}, UseCodec(codec), DisallowUnknownFields)
+	require.Len(t, procs, 1)
+
+	require.NoError(t, procs[0].HandlerSpec.Oneway().HandleOneway(context.Background(), &transport.Request{
+		Procedure: "simpleCall",
+		Encoding:  "json",
+		Body:      jsonBody(`{"name": "foo"}`),
+	}))
+	assert.True(t, decoded)
+	require.Len(t, codec.decoders, 1)
+	assert.True(t, codec.decoders[0].disallowUnknownFields, "option was not applied to the decoder")
+}
+
+func TestProcedureUseNumber(t *testing.T) {
+	procs := Procedure("echo", func(ctx context.Context, body map[string]interface{}) (map[string]interface{}, error) {
+		assert.Equal(t, json.Number("9007199254740993"), body["id"])
+		return body, nil
+	}, UseNumber)
+	require.Len(t, procs, 1)
+
+	resw := new(transporttest.FakeResponseWriter)
+	require.NoError(t, procs[0].HandlerSpec.Unary().Handle(context.Background(), &transport.Request{
+		Procedure: "echo",
+		Encoding:  "json",
+		Body:      jsonBody(`{"id": 9007199254740993}`),
+	}, resw))
+
+	// Large integers survive the round trip without losing precision.
+	assert.JSONEq(t, `{"id": 9007199254740993}`, resw.Body.String())
+}
+
+func TestProcedureDecodeErrorIsInvalidArgument(t *testing.T) {
+	procs := Procedure("simpleCall", func(ctx context.Context, body *simpleRequest) (*simpleResponse, error) {
+		t.Fatal("handler must not be called")
+		return nil, nil
+	})
+
+	err := procs[0].HandlerSpec.Unary().Handle(context.Background(), &transport.Request{
+		Procedure: "simpleCall",
+		Encoding:  "json",
+		Body:      jsonBody(`{"name":`),
+	}, new(transporttest.FakeResponseWriter))
+	require.Error(t, err)
+	assert.Equal(t, yarpcerrors.CodeInvalidArgument, yarpcerrors.FromError(err).Code())
+}
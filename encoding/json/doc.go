@@ -52,4 +52,13 @@
 //  dispatcher.Register(json.OnewayProcedure("setValue", SetValue))
 //  dispatcher.Register(json.OnewayProcedure("runTask", RunTask))
 //
+// Bodies are encoded with the standard library's encoding/json by default.
+// Use NewWithOptions and the options accepted by Procedure to plug in a
+// different implementation with UseCodec, or to tune decoding with
+// DisallowUnknownFields and UseNumber. Request and response bodies are
+// decoded directly from the transport's stream.
+//
+// 	client := json.NewWithOptions(clientConfig, json.UseCodec(myCodec))
+// 	dispatcher.Register(json.Procedure("getValue", GetValue, json.DisallowUnknownFields))
+//
 package json
@@ -22,7 +22,6 @@ package json
 
 import (
 	"context"
-	"encoding/json"
 	"reflect"
 
 	encodingapi "go.uber.org/yarpc/api/encoding"
@@ -39,6 +38,7 @@ import (
 type jsonHandler struct {
 	reader  requestReader
 	handler reflect.Value
+	config  config
 }
 
 func (h jsonHandler) Handle(ctx context.Context, treq *transport.Request, rw transport.ResponseWriter) error {
@@ -51,7 +51,7 @@ func (h jsonHandler) Handle(ctx context.Context, treq *transport.Request, rw tra
 		return err
 	}
 
-	reqBody, err := h.reader.Read(json.NewDecoder(treq.Body))
+	reqBody, err := h.reader.Read(h.config.newDecoder(treq.Body))
 	if err != nil {
 		return errors.RequestBodyDecodeError(treq, err)
 	}
@@ -66,7 +66,7 @@ func (h jsonHandler) Handle(ctx context.Context, treq *transport.Request, rw tra
 	// the previous behavior was so we deprioritize this error
 	var encodeErr error
 	if result := results[0].Interface(); result != nil {
-		if err := json.NewEncoder(rw).Encode(result); err != nil {
+		if err := h.config.codec().NewEncoder(rw).Encode(result); err != nil {
 			encodeErr = errors.ResponseBodyEncodeError(treq, err)
 		}
 	}
@@ -89,7 +89,7 @@ func (h jsonHandler) HandleOneway(ctx context.Context, treq *transport.Request)
 		return err
 	}
 
-	reqBody, err := h.reader.Read(json.NewDecoder(treq.Body))
+	reqBody, err := h.reader.Read(h.config.newDecoder(treq.Body))
 	if err != nil {
 		return errors.RequestBodyDecodeError(treq, err)
 	}
@@ -105,7 +105,7 @@ func (h jsonHandler) HandleOneway(ctx context.Context, treq *transport.Request)
 
 // requestReader is used to parse a JSON request argument from a JSON decoder.
 type requestReader interface {
-	Read(*json.Decoder) (reflect.Value, error)
+	Read(Decoder) (reflect.Value, error)
 }
 
 type structReader struct {
@@ -113,7 +113,7 @@ type structReader struct {
 	Type reflect.Type
 }
 
-func (r structReader) Read(d *json.Decoder) (reflect.Value, error) {
+func (r structReader) Read(d Decoder) (reflect.Value, error) {
 	value := reflect.New(r.Type)
 	err := d.Decode(value.Interface())
 	return value, err
@@ -123,7 +123,7 @@ type mapReader struct {
 	Type reflect.Type // Type of the map
 }
 
-func (r mapReader) Read(d *json.Decoder) (reflect.Value, error) {
+func (r mapReader) Read(d Decoder) (reflect.Value, error) {
 	value := reflect.New(r.Type)
 	err := d.Decode(value.Interface())
 	return value.Elem(), err
@@ -131,7 +131,7 @@ func (r mapReader) Read(d *json.Decoder) (reflect.Value, error) {
 
 type ifaceEmptyReader struct{}
 
-func (ifaceEmptyReader) Read(d *json.Decoder) (reflect.Value, error) {
+func (ifaceEmptyReader) Read(d Decoder) (reflect.Value, error) {
 	value := reflect.New(_interfaceEmptyType)
 	err := d.Decode(value.Interface())
 	return value.Elem(), err
@@ -0,0 +1,117 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package json
+
+import "io"
+
+type config struct {
+	Codec                 Codec
+	DisallowUnknownFields bool
+	UseNumber             bool
+}
+
+// codec returns the configured Codec, defaulting to encoding/json.
+func (c config) codec() Codec {
+	if c.Codec == nil {
+		return stdlibCodec{}
+	}
+	return c.Codec
+}
+
+// newDecoder builds a decoder which reads from r with the configured
+// options.
+func (c config) newDecoder(r io.Reader) Decoder {
+	d := c.codec().NewDecoder(r)
+	if c.DisallowUnknownFields {
+		if d, ok := d.(interface {
+			DisallowUnknownFields()
+		}); ok {
+			d.DisallowUnknownFields()
+		}
+	}
+	if c.UseNumber {
+		if d, ok := d.(interface {
+			UseNumber()
+		}); ok {
+			d.UseNumber()
+		}
+	}
+	return d
+}
+
+// ClientOption customizes the behavior of a JSON client.
+type ClientOption interface {
+	applyClientOption(*config)
+}
+
+// RegisterOption customizes the behavior of a JSON handler during
+// registration.
+type RegisterOption interface {
+	applyRegisterOption(*config)
+}
+
+// Option unifies options that apply to both, JSON clients and handlers.
+type Option interface {
+	ClientOption
+	RegisterOption
+}
+
+type optionFunc func(*config)
+
+func (f optionFunc) applyClientOption(c *config)   { f(c) }
+func (f optionFunc) applyRegisterOption(c *config) { f(c) }
+
+// UseCodec is an option that specifies the JSON implementation used to
+// encode and decode bodies. It may be specified on the client side when the
+// client is constructed,
+//
+// 	client := json.NewWithOptions(clientConfig, json.UseCodec(myCodec))
+//
+// It may be specified on the server side when the handler is registered.
+//
+// 	dispatcher.Register(json.Procedure("get", h.Get, json.UseCodec(myCodec)))
+//
+// It defaults to the standard library's encoding/json.
+func UseCodec(codec Codec) Option {
+	return optionFunc(func(c *config) {
+		c.Codec = codec
+	})
+}
+
+// DisallowUnknownFields is an option that causes bodies containing object
+// keys which do not match any exported field of the destination struct to
+// fail to decode.
+//
+// Handlers with this option reject such requests with an InvalidArgument
+// error. This option has no effect if the Codec's decoders do not support it;
+// the standard library's supports it starting with Go 1.10.
+var DisallowUnknownFields Option = optionFunc(func(c *config) {
+	c.DisallowUnknownFields = true
+})
+
+// UseNumber is an option that causes numbers decoded into an interface{} to
+// be decoded as an encoding/json Number instead of a float64, preserving the precision
+// of large integers.
+//
+// This option has no effect if the Codec's decoders do not support it.
+var UseNumber Option = optionFunc(func(c *config) {
+	c.UseNumber = true
+})
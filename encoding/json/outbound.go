@@ -23,7 +23,6 @@ package json
 import (
 	"bytes"
 	"context"
-	"encoding/json"
 
 	"go.uber.org/yarpc"
 	encodingapi "go.uber.org/yarpc/api/encoding"
@@ -49,15 +48,42 @@ func New(c transport.ClientConfig) Client {
 	return jsonClient{cc: c}
 }
 
+// NewWithOptions builds a new JSON client with the given options.
+//
+// 	client := json.NewWithOptions(clientConfig, json.DisallowUnknownFields)
+func NewWithOptions(c transport.ClientConfig, opts ...ClientOption) Client {
+	return newClient(c, opts)
+}
+
+func newClient(c transport.ClientConfig, opts []ClientOption) jsonClient {
+	client := jsonClient{cc: c}
+	for _, opt := range opts {
+		opt.applyClientOption(&client.config)
+	}
+	return client
+}
+
 func init() {
 	yarpc.RegisterClientBuilder(New)
 }
 
 type jsonClient struct {
-	cc transport.ClientConfig
+	cc     transport.ClientConfig
+	config config
 }
 
 func (c jsonClient) Call(ctx context.Context, procedure string, reqBody interface{}, resBodyOut interface{}, opts ...yarpc.CallOption) error {
+	call, treq, tres, err := c.send(ctx, procedure, reqBody, opts)
+	if tres == nil {
+		return err
+	}
+	return c.decodeResponse(ctx, call, treq, tres, err, resBodyOut)
+}
+
+// send encodes the request body and performs the transport-level call. If
+// the returned response is nil, the returned error is the result of the
+// call.
+func (c jsonClient) send(ctx context.Context, procedure string, reqBody interface{}, opts []yarpc.CallOption) (*encodingapi.OutboundCall, *transport.Request, *transport.Response, error) {
 	call := encodingapi.NewOutboundCall(encoding.FromOptions(opts)...)
 	treq := transport.Request{
 		Caller:    c.cc.Caller(),
@@ -68,29 +94,30 @@ func (c jsonClient) Call(ctx context.Context, procedure string, reqBody interfac
 
 	ctx, err := call.WriteToRequest(ctx, &treq)
 	if err != nil {
-		return err
+		return nil, nil, nil, err
 	}
 
-	encoded, err := json.Marshal(reqBody)
+	encoded, err := c.config.codec().Marshal(reqBody)
 	if err != nil {
-		return errors.RequestBodyEncodeError(&treq, err)
+		return nil, nil, nil, errors.RequestBodyEncodeError(&treq, err)
 	}
 
 	treq.Body = bytes.NewReader(encoded)
 	tres, appErr := c.cc.GetUnaryOutbound().Call(ctx, &treq)
-	if tres == nil {
-		return appErr
-	}
+	return call, &treq, tres, appErr
+}
 
+// decodeResponse reads the response headers and body into resBodyOut.
+func (c jsonClient) decodeResponse(ctx context.Context, call *encodingapi.OutboundCall, treq *transport.Request, tres *transport.Response, appErr error, resBodyOut interface{}) error {
 	// we want to return the appErr if it exists as this is what
 	// the previous behavior was so we deprioritize this error
 	var decodeErr error
-	if _, err = call.ReadFromResponse(ctx, tres); err != nil {
+	if _, err := call.ReadFromResponse(ctx, tres); err != nil {
 		decodeErr = err
 	}
 	if tres.Body != nil {
-		if err := json.NewDecoder(tres.Body).Decode(resBodyOut); err != nil && decodeErr == nil {
-			decodeErr = errors.ResponseBodyDecodeError(&treq, err)
+		if err := c.config.newDecoder(tres.Body).Decode(resBodyOut); err != nil && decodeErr == nil {
+			decodeErr = errors.ResponseBodyDecodeError(treq, err)
 		}
 		if err := tres.Body.Close(); err != nil && decodeErr == nil {
 			decodeErr = err
@@ -118,7 +145,7 @@ func (c jsonClient) CallOneway(ctx context.Context, procedure string, reqBody in
 	}
 
 	var buff bytes.Buffer
-	if err := json.NewEncoder(&buff).Encode(reqBody); err != nil {
+	if err := c.config.codec().NewEncoder(&buff).Encode(reqBody); err != nil {
 		return nil, errors.RequestBodyEncodeError(&treq, err)
 	}
 	treq.Body = &buff
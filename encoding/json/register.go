@@ -51,12 +51,12 @@ func Register(r transport.RouteTable, rs []transport.Procedure) {
 //
 // Where $reqBody and $resBody are a map[string]interface{} or pointers to
 // structs.
-func Procedure(name string, handler interface{}) []transport.Procedure {
+func Procedure(name string, handler interface{}, opts ...RegisterOption) []transport.Procedure {
 	return []transport.Procedure{
 		{
 			Name: name,
 			HandlerSpec: transport.NewUnaryHandlerSpec(
-				wrapUnaryHandler(name, handler),
+				wrapUnaryHandler(name, handler, newRegisterConfig(opts)),
 			),
 			Encoding: Encoding,
 		},
@@ -69,12 +69,12 @@ func Procedure(name string, handler interface{}) []transport.Procedure {
 // 	f(ctx context.Context, body $reqBody) error
 //
 // Where $reqBody is a map[string]interface{} or pointer to a struct.
-func OnewayProcedure(name string, handler interface{}) []transport.Procedure {
+func OnewayProcedure(name string, handler interface{}, opts ...RegisterOption) []transport.Procedure {
 	return []transport.Procedure{
 		{
 			Name: name,
 			HandlerSpec: transport.NewOnewayHandlerSpec(
-				wrapOnewayHandler(name, handler)),
+				wrapOnewayHandler(name, handler, newRegisterConfig(opts))),
 			Encoding: Encoding,
 		},
 	}
@@ -82,19 +82,27 @@ func OnewayProcedure(name string, handler interface{}) []transport.Procedure {
 
 // wrapUnaryHandler takes a valid JSON handler function and converts it into a
 // transport.UnaryHandler.
-func wrapUnaryHandler(name string, handler interface{}) transport.UnaryHandler {
+func wrapUnaryHandler(name string, handler interface{}, cfg config) transport.UnaryHandler {
 	reqBodyType := verifyUnarySignature(name, reflect.TypeOf(handler))
-	return newJSONHandler(reqBodyType, handler)
+	return newJSONHandler(reqBodyType, handler, cfg)
 }
 
 // wrapOnewayHandler takes a valid JSON handler function and converts it into a
 // transport.OnewayHandler.
-func wrapOnewayHandler(name string, handler interface{}) transport.OnewayHandler {
+func wrapOnewayHandler(name string, handler interface{}, cfg config) transport.OnewayHandler {
 	reqBodyType := verifyOnewaySignature(name, reflect.TypeOf(handler))
-	return newJSONHandler(reqBodyType, handler)
+	return newJSONHandler(reqBodyType, handler, cfg)
 }
 
-func newJSONHandler(reqBodyType reflect.Type, handler interface{}) jsonHandler {
+func newRegisterConfig(opts []RegisterOption) config {
+	var cfg config
+	for _, opt := range opts {
+		opt.applyRegisterOption(&cfg)
+	}
+	return cfg
+}
+
+func newJSONHandler(reqBodyType reflect.Type, handler interface{}, cfg config) jsonHandler {
 	var r requestReader
 	if reqBodyType == _interfaceEmptyType {
 		r = ifaceEmptyReader{}
@@ -108,6 +116,7 @@ func newJSONHandler(reqBodyType reflect.Type, handler interface{}) jsonHandler {
 	return jsonHandler{
 		reader:  r,
 		handler: reflect.ValueOf(handler),
+		config:  cfg,
 	}
 }
 
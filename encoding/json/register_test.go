@@ -86,7 +86,7 @@ func TestWrapUnaryHandlerInvalid(t *testing.T) {
 
 	for _, tt := range tests {
 		assert.Panics(t, assert.PanicTestFunc(func() {
-			wrapUnaryHandler(tt.Name, tt.Func)
+			wrapUnaryHandler(tt.Name, tt.Func, config{})
 		}), tt.Name)
 	}
 }
@@ -123,7 +123,7 @@ func TestWrapUnaryHandlerValid(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		wrapUnaryHandler(tt.Name, tt.Func)
+		wrapUnaryHandler(tt.Name, tt.Func, config{})
 	}
 }
 
@@ -180,7 +180,7 @@ func TestWrapOnewayHandlerInvalid(t *testing.T) {
 
 	for _, tt := range tests {
 		assert.Panics(t, assert.PanicTestFunc(func() {
-			wrapOnewayHandler(tt.Name, tt.Func)
+			wrapOnewayHandler(tt.Name, tt.Func, config{})
 		}))
 	}
 }
@@ -216,6 +216,6 @@ func TestWrapOnewayHandlerValid(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		wrapOnewayHandler(tt.Name, tt.Func)
+		wrapOnewayHandler(tt.Name, tt.Func, config{})
 	}
 }
@@ -98,6 +98,11 @@
 // Protobuf method being called in the form proto_package.proto_service::proto_method, and the data is the JSON
 // representation of the request.
 //
+// The proto+json encoding may be used in place of json. It carries the same
+// JSON representation, but names the body as a Protobuf message, so the call
+// reaches the Protobuf handler even if the service also registers plain JSON
+// procedures. Clients may send it with the UseProtoJSON option.
+//
 // If using Yab, one can also use:
 //
 //   yab -p http://0.0.0.0:8080 -e json -s hello -p foo.bar.Baz::Echo -r '{"value":"sample"}'
@@ -130,7 +135,18 @@
 //     Fire(context.Context, *FireRequest) error
 //   }
 //
-// Except for any ClientOptions (such as UseJSON), the types and functions
-// defined in this package should not be directly used in applications,
-// instead use the code generated from protoc-gen-yarpc-go.
+// Marshaling may be tuned with the Deterministic and DiscardUnknown options.
+// Pass them to generated clients as ClientOptions, and apply them to
+// generated procedures with WithMarshalOptions.
+//
+//   client := foo.NewBazYARPCClient(clientConfig, protobuf.Deterministic)
+//   dispatcher.Register(protobuf.WithMarshalOptions(
+//     foo.BuildBazYARPCProcedures(handler),
+//     protobuf.DiscardUnknown,
+//   ))
+//
+// Except for any ClientOptions (such as UseJSON), MarshalOptions, and
+// WithMarshalOptions, the types and functions defined in this package should
+// not be directly used in applications, instead use the code generated from
+// protoc-gen-yarpc-go.
 package protobuf
@@ -30,22 +30,29 @@ import (
 )
 
 type unaryHandler struct {
-	handle     func(context.Context, proto.Message) (proto.Message, error)
-	newRequest func() proto.Message
+	handle         func(context.Context, proto.Message) (proto.Message, error)
+	newRequest     func() proto.Message
+	marshalOptions marshalOptions
+	validate       bool
 }
 
 func newUnaryHandler(
 	handle func(context.Context, proto.Message) (proto.Message, error),
 	newRequest func() proto.Message,
 ) *unaryHandler {
-	return &unaryHandler{handle, newRequest}
+	return &unaryHandler{handle: handle, newRequest: newRequest}
 }
 
 func (u *unaryHandler) Handle(ctx context.Context, transportRequest *transport.Request, responseWriter transport.ResponseWriter) error {
-	ctx, call, request, err := getProtoRequest(ctx, transportRequest, u.newRequest)
+	ctx, call, request, err := getProtoRequest(ctx, transportRequest, u.newRequest, u.marshalOptions)
 	if err != nil {
 		return err
 	}
+	if u.validate {
+		if err := validateRequest(transportRequest, request); err != nil {
+			return err
+		}
+	}
 
 	response, appErr := u.handle(ctx, request)
 
@@ -55,7 +62,7 @@ func (u *unaryHandler) Handle(ctx context.Context, transportRequest *transport.R
 	var responseData []byte
 	var responseCleanup func()
 	if response != nil {
-		responseData, responseCleanup, err = marshal(transportRequest.Encoding, response)
+		responseData, responseCleanup, err = marshal(transportRequest.Encoding, response, u.marshalOptions)
 		if responseCleanup != nil {
 			defer responseCleanup()
 		}
@@ -74,22 +81,29 @@ func (u *unaryHandler) Handle(ctx context.Context, transportRequest *transport.R
 }
 
 type onewayHandler struct {
-	handleOneway func(context.Context, proto.Message) error
-	newRequest   func() proto.Message
+	handleOneway   func(context.Context, proto.Message) error
+	newRequest     func() proto.Message
+	marshalOptions marshalOptions
+	validate       bool
 }
 
 func newOnewayHandler(
 	handleOneway func(context.Context, proto.Message) error,
 	newRequest func() proto.Message,
 ) *onewayHandler {
-	return &onewayHandler{handleOneway, newRequest}
+	return &onewayHandler{handleOneway: handleOneway, newRequest: newRequest}
 }
 
 func (o *onewayHandler) HandleOneway(ctx context.Context, transportRequest *transport.Request) error {
-	ctx, _, request, err := getProtoRequest(ctx, transportRequest, o.newRequest)
+	ctx, _, request, err := getProtoRequest(ctx, transportRequest, o.newRequest, o.marshalOptions)
 	if err != nil {
 		return err
 	}
+	if o.validate {
+		if err := validateRequest(transportRequest, request); err != nil {
+			return err
+		}
+	}
 	return o.handleOneway(ctx, request)
 }
 
@@ -113,8 +127,8 @@ func (s *streamHandler) HandleStream(stream *transport.ServerStream) error {
 	return s.handle(protoStream)
 }
 
-func getProtoRequest(ctx context.Context, transportRequest *transport.Request, newRequest func() proto.Message) (context.Context, *apiencoding.InboundCall, proto.Message, error) {
-	if err := errors.ExpectEncodings(transportRequest, Encoding, JSONEncoding); err != nil {
+func getProtoRequest(ctx context.Context, transportRequest *transport.Request, newRequest func() proto.Message, opts marshalOptions) (context.Context, *apiencoding.InboundCall, proto.Message, error) {
+	if err := errors.ExpectEncodings(transportRequest, Encoding, JSONEncoding, ProtoJSONEncoding); err != nil {
 		return nil, nil, nil, err
 	}
 	ctx, call := apiencoding.NewInboundCall(ctx)
@@ -122,7 +136,7 @@ func getProtoRequest(ctx context.Context, transportRequest *transport.Request, n
 		return nil, nil, nil, err
 	}
 	request := newRequest()
-	if err := unmarshal(transportRequest.Encoding, transportRequest.Body, request); err != nil {
+	if err := unmarshal(transportRequest.Encoding, transportRequest.Body, request, opts); err != nil {
 		return nil, nil, nil, errors.RequestBodyDecodeError(transportRequest, err)
 	}
 	return ctx, call, request, nil
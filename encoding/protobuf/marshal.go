@@ -42,7 +42,18 @@ var (
 	}
 )
 
-func unmarshal(encoding transport.Encoding, reader io.Reader, message proto.Message) error {
+// marshalOptions customize how messages are marshaled and unmarshaled. The
+// zero value marshals and unmarshals messages as-is.
+type marshalOptions struct {
+	// Deterministic orders map entries when marshaling to the binary
+	// encoding so that equal messages produce equal bytes.
+	Deterministic bool
+
+	// DiscardUnknown drops unrecognized fields from unmarshaled messages.
+	DiscardUnknown bool
+}
+
+func unmarshal(encoding transport.Encoding, reader io.Reader, message proto.Message, opts marshalOptions) error {
 	buf := bufferpool.Get()
 	defer bufferpool.Put(buf)
 	if _, err := buf.ReadFrom(reader); err != nil {
@@ -52,14 +63,19 @@ func unmarshal(encoding transport.Encoding, reader io.Reader, message proto.Mess
 	if len(body) == 0 {
 		return nil
 	}
+	var err error
 	switch encoding {
 	case Encoding:
-		return unmarshalProto(body, message)
-	case JSONEncoding:
-		return unmarshalJSON(body, message)
+		err = unmarshalProto(body, message)
+	case JSONEncoding, ProtoJSONEncoding:
+		err = unmarshalJSON(body, message)
 	default:
 		return yarpcerrors.Newf(yarpcerrors.CodeInternal, "encoding.Expect should have handled encoding %q but did not", encoding)
 	}
+	if err == nil && opts.DiscardUnknown {
+		proto.DiscardUnknown(message)
+	}
+	return err
 }
 
 func unmarshalProto(body []byte, message proto.Message) error {
@@ -70,19 +86,20 @@ func unmarshalJSON(body []byte, message proto.Message) error {
 	return _jsonUnmarshaler.Unmarshal(bytes.NewReader(body), message)
 }
 
-func marshal(encoding transport.Encoding, message proto.Message) ([]byte, func(), error) {
+func marshal(encoding transport.Encoding, message proto.Message, opts marshalOptions) ([]byte, func(), error) {
 	switch encoding {
 	case Encoding:
-		return marshalProto(message)
-	case JSONEncoding:
+		return marshalProto(message, opts.Deterministic)
+	case JSONEncoding, ProtoJSONEncoding:
 		return marshalJSON(message)
 	default:
 		return nil, nil, yarpcerrors.Newf(yarpcerrors.CodeInternal, "encoding.Expect should have handled encoding %q but did not", encoding)
 	}
 }
 
-func marshalProto(message proto.Message) ([]byte, func(), error) {
+func marshalProto(message proto.Message, deterministic bool) ([]byte, func(), error) {
 	protoBuffer := getBuffer()
+	protoBuffer.SetDeterministic(deterministic)
 	cleanup := func() { putBuffer(protoBuffer) }
 	if err := protoBuffer.Marshal(message); err != nil {
 		cleanup()
@@ -24,13 +24,50 @@ import (
 	"bytes"
 	"testing"
 
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/yarpcerrors"
 )
 
 func TestUnhandledEncoding(t *testing.T) {
-	assert.Equal(t, yarpcerrors.CodeInternal, yarpcerrors.FromError(unmarshal(transport.Encoding("foo"), bytes.NewReader([]byte("foo")), nil)).Code())
-	_, _, err := marshal(transport.Encoding("foo"), nil)
+	assert.Equal(t, yarpcerrors.CodeInternal, yarpcerrors.FromError(unmarshal(transport.Encoding("foo"), bytes.NewReader([]byte("foo")), nil, marshalOptions{})).Code())
+	_, _, err := marshal(transport.Encoding("foo"), nil, marshalOptions{})
 	assert.Equal(t, yarpcerrors.CodeInternal, yarpcerrors.FromError(err).Code())
 }
+
+func TestProtoJSONRoundTrip(t *testing.T) {
+	body, cleanup, err := marshal(ProtoJSONEncoding, &types.StringValue{Value: "foo"}, marshalOptions{})
+	require.NoError(t, err)
+	defer cleanup()
+	assert.Equal(t, `"foo"`, string(body))
+
+	var value types.StringValue
+	require.NoError(t, unmarshal(ProtoJSONEncoding, bytes.NewReader(body), &value, marshalOptions{}))
+	assert.Equal(t, "foo", value.Value)
+}
+
+func TestDeterministicMarshal(t *testing.T) {
+	message := &types.Struct{Fields: make(map[string]*types.Value)}
+	for _, k := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		message.Fields[k] = &types.Value{Kind: &types.Value_StringValue{StringValue: k}}
+	}
+
+	want, cleanup, err := marshal(Encoding, message, marshalOptions{Deterministic: true})
+	require.NoError(t, err)
+	want = append([]byte(nil), want...)
+	cleanup()
+
+	for i := 0; i < 10; i++ {
+		got, cleanup, err := marshal(Encoding, message, marshalOptions{Deterministic: true})
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+		cleanup()
+	}
+
+	var decoded types.Struct
+	require.NoError(t, unmarshal(Encoding, bytes.NewReader(want), &decoded, marshalOptions{DiscardUnknown: true}))
+	assert.True(t, proto.Equal(message, &decoded))
+}
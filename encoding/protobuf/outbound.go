@@ -38,6 +38,7 @@ type client struct {
 	serviceName    string
 	outboundConfig *transport.OutboundConfig
 	encoding       transport.Encoding
+	marshalOptions marshalOptions
 }
 
 func newClient(serviceName string, clientConfig transport.ClientConfig, options ...ClientOption) *client {
@@ -103,7 +104,7 @@ func (c *client) Call(
 	var response proto.Message
 	if transportResponse.Body != nil {
 		response = newResponse()
-		if err := unmarshal(transportRequest.Encoding, transportResponse.Body, response); err != nil {
+		if err := unmarshal(transportRequest.Encoding, transportResponse.Body, response, c.marshalOptions); err != nil {
 			return nil, errors.ResponseBodyDecodeError(transportRequest, err)
 		}
 	}
@@ -142,11 +143,11 @@ func (c *client) buildTransportRequest(ctx context.Context, requestMethodName st
 	if err != nil {
 		return nil, nil, nil, nil, err
 	}
-	if transportRequest.Encoding != Encoding && transportRequest.Encoding != JSONEncoding {
-		return nil, nil, nil, nil, yarpcerrors.Newf(yarpcerrors.CodeInternal, "can only use encodings %q or %q, but %q was specified", Encoding, JSONEncoding, transportRequest.Encoding)
+	if !isSupportedEncoding(transportRequest.Encoding) {
+		return nil, nil, nil, nil, yarpcerrors.Newf(yarpcerrors.CodeInternal, "can only use encodings %q, %q or %q, but %q was specified", Encoding, JSONEncoding, ProtoJSONEncoding, transportRequest.Encoding)
 	}
 	if request != nil {
-		requestData, cleanup, err := marshal(transportRequest.Encoding, request)
+		requestData, cleanup, err := marshal(transportRequest.Encoding, request, c.marshalOptions)
 		if err != nil {
 			return nil, nil, nil, cleanup, errors.RequestBodyEncodeError(transportRequest, err)
 		}
@@ -179,8 +180,8 @@ func (c *client) CallStream(
 	if err != nil {
 		return nil, err
 	}
-	if streamRequest.Meta.Encoding != Encoding && streamRequest.Meta.Encoding != JSONEncoding {
-		return nil, yarpcerrors.InternalErrorf("can only use encodings %q or %q, but %q was specified", Encoding, JSONEncoding, streamRequest.Meta.Encoding)
+	if !isSupportedEncoding(streamRequest.Meta.Encoding) {
+		return nil, yarpcerrors.InternalErrorf("can only use encodings %q, %q or %q, but %q was specified", Encoding, JSONEncoding, ProtoJSONEncoding, streamRequest.Meta.Encoding)
 	}
 	streamOutbound := c.outboundConfig.Outbounds.Stream
 	if streamOutbound == nil {
@@ -38,12 +38,87 @@ const (
 
 	// JSONEncoding is the name of the JSON encoding.
 	//
-	// Protobuf handlers are able to handle both Encoding and JSONEncoding encodings.
+	// Protobuf handlers are able to handle Encoding, JSONEncoding and
+	// ProtoJSONEncoding encodings.
 	JSONEncoding transport.Encoding = "json"
+
+	// ProtoJSONEncoding is the name of the Protobuf JSON encoding.
+	//
+	// Requests and responses are the JSON mapping of Protobuf messages, as
+	// with JSONEncoding, but the encoding name says that the body is a
+	// Protobuf message. This lets callers such as curl or JavaScript clients
+	// reach Protobuf handlers even where plain JSON handlers are registered
+	// for the same procedure.
+	ProtoJSONEncoding transport.Encoding = "proto+json"
+)
+
+var (
+	// UseJSON says to use the json encoding for client/server communication.
+	UseJSON ClientOption = useJSON{}
+
+	// UseProtoJSON says to use the proto+json encoding for client/server
+	// communication.
+	UseProtoJSON ClientOption = useProtoJSON{}
+
+	// Deterministic says to marshal map fields in a stable order, so that
+	// equal messages encode to equal bytes. It only affects the binary
+	// encoding and has a performance cost.
+	Deterministic MarshalOption = deterministic{}
+
+	// DiscardUnknown says to drop fields that are not part of a message's
+	// definition when unmarshaling, rather than retaining them to be
+	// marshaled again.
+	DiscardUnknown MarshalOption = discardUnknown{}
 )
 
-// UseJSON says to use the json encoding for client/server communication.
-var UseJSON ClientOption = useJSON{}
+// MarshalOption customizes how messages are marshaled and unmarshaled.
+//
+// MarshalOptions may be passed to clients as ClientOptions, and applied to
+// handlers with WithMarshalOptions.
+type MarshalOption interface {
+	ClientOption
+
+	applyMarshalOption(*marshalOptions)
+}
+
+// WithMarshalOptions applies the given MarshalOptions to the unary and oneway
+// Protobuf handlers in procedures, as built by generated code.
+//
+// 	dispatcher.Register(protobuf.WithMarshalOptions(
+// 		examplepb.BuildKeyValueYARPCProcedures(handler),
+// 		protobuf.DiscardUnknown,
+// 	))
+//
+// Other procedures are returned unchanged.
+func WithMarshalOptions(procedures []transport.Procedure, options ...MarshalOption) []transport.Procedure {
+	var opts marshalOptions
+	for _, option := range options {
+		option.applyMarshalOption(&opts)
+	}
+
+	result := make([]transport.Procedure, len(procedures))
+	for i, p := range procedures {
+		switch p.HandlerSpec.Type() {
+		case transport.Unary:
+			if h, ok := p.HandlerSpec.Unary().(*unaryHandler); ok {
+				handler := *h
+				handler.marshalOptions = opts
+				p.HandlerSpec = transport.NewUnaryHandlerSpec(&handler)
+			}
+		case transport.Oneway:
+			if h, ok := p.HandlerSpec.Oneway().(*onewayHandler); ok {
+				handler := *h
+				handler.marshalOptions = opts
+				p.HandlerSpec = transport.NewOnewayHandlerSpec(&handler)
+			}
+		}
+		result[i] = p
+	}
+	return result
+}
+
+// _encodings are the encodings Protobuf handlers are registered for.
+var _encodings = []transport.Encoding{Encoding, JSONEncoding, ProtoJSONEncoding}
 
 // ***all below functions should only be called by generated code***
 
@@ -75,51 +150,33 @@ type BuildProceduresStreamHandlerParams struct {
 
 // BuildProcedures builds the transport.Procedures.
 func BuildProcedures(params BuildProceduresParams) []transport.Procedure {
-	procedures := make([]transport.Procedure, 0, 2*(len(params.UnaryHandlerParams)+len(params.OnewayHandlerParams)))
+	procedures := make([]transport.Procedure, 0, len(_encodings)*(len(params.UnaryHandlerParams)+len(params.OnewayHandlerParams)+len(params.StreamHandlerParams)))
 	for _, unaryHandlerParams := range params.UnaryHandlerParams {
-		procedures = append(
-			procedures,
-			transport.Procedure{
-				Name:        procedure.ToName(params.ServiceName, unaryHandlerParams.MethodName),
-				HandlerSpec: transport.NewUnaryHandlerSpec(unaryHandlerParams.Handler),
-				Encoding:    Encoding,
-			},
-			transport.Procedure{
+		for _, encoding := range _encodings {
+			procedures = append(procedures, transport.Procedure{
 				Name:        procedure.ToName(params.ServiceName, unaryHandlerParams.MethodName),
 				HandlerSpec: transport.NewUnaryHandlerSpec(unaryHandlerParams.Handler),
-				Encoding:    JSONEncoding,
-			},
-		)
+				Encoding:    encoding,
+			})
+		}
 	}
 	for _, onewayHandlerParams := range params.OnewayHandlerParams {
-		procedures = append(
-			procedures,
-			transport.Procedure{
+		for _, encoding := range _encodings {
+			procedures = append(procedures, transport.Procedure{
 				Name:        procedure.ToName(params.ServiceName, onewayHandlerParams.MethodName),
 				HandlerSpec: transport.NewOnewayHandlerSpec(onewayHandlerParams.Handler),
-				Encoding:    Encoding,
-			},
-			transport.Procedure{
-				Name:        procedure.ToName(params.ServiceName, onewayHandlerParams.MethodName),
-				HandlerSpec: transport.NewOnewayHandlerSpec(onewayHandlerParams.Handler),
-				Encoding:    JSONEncoding,
-			},
-		)
+				Encoding:    encoding,
+			})
+		}
 	}
 	for _, streamHandlerParams := range params.StreamHandlerParams {
-		procedures = append(
-			procedures,
-			transport.Procedure{
-				Name:        procedure.ToName(params.ServiceName, streamHandlerParams.MethodName),
-				HandlerSpec: transport.NewStreamHandlerSpec(streamHandlerParams.Handler),
-				Encoding:    Encoding,
-			},
-			transport.Procedure{
+		for _, encoding := range _encodings {
+			procedures = append(procedures, transport.Procedure{
 				Name:        procedure.ToName(params.ServiceName, streamHandlerParams.MethodName),
 				HandlerSpec: transport.NewStreamHandlerSpec(streamHandlerParams.Handler),
-				Encoding:    JSONEncoding,
-			},
-		)
+				Encoding:    encoding,
+			})
+		}
 	}
 	return procedures
 }
@@ -214,6 +271,8 @@ func ClientBuilderOptions(_ transport.ClientConfig, structField reflect.StructFi
 		switch opt {
 		case "json":
 			opts = append(opts, UseJSON)
+		case "proto+json":
+			opts = append(opts, UseProtoJSON)
 		}
 	}
 	return opts
@@ -230,6 +289,43 @@ func (useJSON) apply(client *client) {
 	client.encoding = JSONEncoding
 }
 
+type useProtoJSON struct{}
+
+func (useProtoJSON) apply(client *client) {
+	client.encoding = ProtoJSONEncoding
+}
+
+type deterministic struct{}
+
+func (d deterministic) apply(client *client) {
+	d.applyMarshalOption(&client.marshalOptions)
+}
+
+func (deterministic) applyMarshalOption(opts *marshalOptions) {
+	opts.Deterministic = true
+}
+
+type discardUnknown struct{}
+
+func (d discardUnknown) apply(client *client) {
+	d.applyMarshalOption(&client.marshalOptions)
+}
+
+func (discardUnknown) applyMarshalOption(opts *marshalOptions) {
+	opts.DiscardUnknown = true
+}
+
+// isSupportedEncoding returns whether Protobuf messages can be sent with the
+// given encoding.
+func isSupportedEncoding(encoding transport.Encoding) bool {
+	for _, e := range _encodings {
+		if e == encoding {
+			return true
+		}
+	}
+	return false
+}
+
 func uniqueLowercaseStrings(s []string) []string {
 	m := make(map[string]bool, len(s))
 	for _, e := range s {
@@ -26,6 +26,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/yarpcerrors"
 )
 
@@ -36,6 +38,52 @@ func TestCastError(t *testing.T) {
 func TestClientBuilderOptions(t *testing.T) {
 	assert.Nil(t, ClientBuilderOptions(nil, reflect.StructField{Tag: `service:"keyvalue"`}))
 	assert.Equal(t, []ClientOption{UseJSON}, ClientBuilderOptions(nil, reflect.StructField{Tag: `service:"keyvalue" proto:"json"`}))
+	assert.Equal(t, []ClientOption{UseProtoJSON}, ClientBuilderOptions(nil, reflect.StructField{Tag: `service:"keyvalue" proto:"proto+json"`}))
+}
+
+func TestBuildProceduresRegistersAllEncodings(t *testing.T) {
+	procedures := BuildProcedures(BuildProceduresParams{
+		ServiceName: "foo.Bar",
+		UnaryHandlerParams: []BuildProceduresUnaryHandlerParams{
+			{MethodName: "Baz"},
+		},
+	})
+	var encodings []transport.Encoding
+	for _, p := range procedures {
+		assert.Equal(t, "foo.Bar::Baz", p.Name)
+		encodings = append(encodings, p.Encoding)
+	}
+	assert.Equal(t, []transport.Encoding{Encoding, JSONEncoding, ProtoJSONEncoding}, encodings)
+}
+
+func TestMarshalOptions(t *testing.T) {
+	c := newClient("foo.Bar", &transport.OutboundConfig{}, Deterministic, DiscardUnknown)
+	assert.Equal(t, marshalOptions{Deterministic: true, DiscardUnknown: true}, c.marshalOptions)
+
+	procedures := BuildProcedures(BuildProceduresParams{
+		ServiceName: "foo.Bar",
+		UnaryHandlerParams: []BuildProceduresUnaryHandlerParams{
+			{MethodName: "Baz", Handler: NewUnaryHandler(UnaryHandlerParams{})},
+		},
+		OnewayHandlerParams: []BuildProceduresOnewayHandlerParams{
+			{MethodName: "Qux", Handler: NewOnewayHandler(OnewayHandlerParams{})},
+		},
+	})
+	withOptions := WithMarshalOptions(procedures, DiscardUnknown)
+	require.Len(t, withOptions, len(procedures))
+	for i, p := range withOptions {
+		assert.Equal(t, procedures[i].Name, p.Name)
+		assert.Equal(t, procedures[i].Encoding, p.Encoding)
+		switch p.HandlerSpec.Type() {
+		case transport.Unary:
+			assert.Equal(t, marshalOptions{DiscardUnknown: true}, p.HandlerSpec.Unary().(*unaryHandler).marshalOptions)
+		case transport.Oneway:
+			assert.Equal(t, marshalOptions{DiscardUnknown: true}, p.HandlerSpec.Oneway().(*onewayHandler).marshalOptions)
+		}
+	}
+
+	// The original procedures are left untouched.
+	assert.Equal(t, marshalOptions{}, procedures[0].HandlerSpec.Unary().(*unaryHandler).marshalOptions)
 }
 
 func TestUniqueLowercaseStrings(t *testing.T) {
@@ -40,7 +40,7 @@ func readFromStream(
 		return nil, err
 	}
 	message := newMessage()
-	if err := unmarshal(stream.Request().Meta.Encoding, streamMsg.Body, message); err != nil {
+	if err := unmarshal(stream.Request().Meta.Encoding, streamMsg.Body, message, marshalOptions{}); err != nil {
 		streamMsg.Body.Close()
 		return nil, err
 	}
@@ -52,7 +52,7 @@ func readFromStream(
 
 // writeToStream writes a proto.Message to a stream.
 func writeToStream(ctx context.Context, stream transport.Stream, message proto.Message) error {
-	messageData, cleanup, err := marshal(stream.Request().Meta.Encoding, message)
+	messageData, cleanup, err := marshal(stream.Request().Meta.Encoding, message, marshalOptions{})
 	if err != nil {
 		return err
 	}
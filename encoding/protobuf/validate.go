@@ -0,0 +1,128 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package protobuf
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gogo/protobuf/proto"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// validator is implemented by messages with validation methods generated by
+// protoc-gen-validate.
+type validator interface {
+	Validate() error
+}
+
+// allValidator is implemented by messages generated by versions of
+// protoc-gen-validate that report every violation rather than the first.
+type allValidator interface {
+	ValidateAll() error
+}
+
+// fieldError is implemented by the errors protoc-gen-validate generates for
+// violations of the rules of a field.
+type fieldError interface {
+	Field() string
+	Reason() string
+}
+
+// multiError is implemented by the errors ValidateAll returns.
+type multiError interface {
+	AllErrors() []error
+}
+
+// WithRequestValidation validates the requests of the unary and oneway
+// Protobuf handlers in procedures, as built by generated code, before the
+// handlers are called.
+//
+// 	dispatcher.Register(protobuf.WithRequestValidation(
+// 		examplepb.BuildKeyValueYARPCProcedures(handler),
+// 	))
+//
+// Requests are validated with the Validate or ValidateAll methods that
+// protoc-gen-validate generates for their messages. Invalid requests are
+// rejected with a CodeInvalidArgument error that lists the fields that
+// failed validation and why. Requests whose messages have no validation
+// methods are passed along unchanged.
+//
+// Other procedures are returned unchanged.
+func WithRequestValidation(procedures []transport.Procedure) []transport.Procedure {
+	result := make([]transport.Procedure, len(procedures))
+	for i, p := range procedures {
+		switch p.HandlerSpec.Type() {
+		case transport.Unary:
+			if h, ok := p.HandlerSpec.Unary().(*unaryHandler); ok {
+				handler := *h
+				handler.validate = true
+				p.HandlerSpec = transport.NewUnaryHandlerSpec(&handler)
+			}
+		case transport.Oneway:
+			if h, ok := p.HandlerSpec.Oneway().(*onewayHandler); ok {
+				handler := *h
+				handler.validate = true
+				p.HandlerSpec = transport.NewOnewayHandlerSpec(&handler)
+			}
+		}
+		result[i] = p
+	}
+	return result
+}
+
+// validateRequest validates a decoded request message, returning a
+// CodeInvalidArgument error if it is invalid.
+func validateRequest(req *transport.Request, message proto.Message) error {
+	var err error
+	switch m := message.(type) {
+	case allValidator:
+		err = m.ValidateAll()
+	case validator:
+		err = m.Validate()
+	}
+	if err == nil {
+		return nil
+	}
+	return yarpcerrors.Newf(yarpcerrors.CodeInvalidArgument,
+		"invalid request for procedure %q of service %q from caller %q: %s",
+		req.Procedure, req.Service, req.Caller, describeValidationError(err))
+}
+
+// describeValidationError lists the violations in a validation error, with
+// the fields they apply to.
+func describeValidationError(err error) string {
+	errs := []error{err}
+	if m, ok := err.(multiError); ok && len(m.AllErrors()) > 0 {
+		errs = m.AllErrors()
+	}
+
+	violations := make([]string, len(errs))
+	for i, err := range errs {
+		if f, ok := err.(fieldError); ok {
+			violations[i] = fmt.Sprintf("%s: %s", f.Field(), f.Reason())
+		} else {
+			violations[i] = err.Error()
+		}
+	}
+	return strings.Join(violations, "; ")
+}
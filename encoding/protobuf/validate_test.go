@@ -0,0 +1,159 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package protobuf
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// fieldViolation mimics the field errors generated by protoc-gen-validate.
+type fieldViolation struct {
+	field, reason string
+}
+
+func (v fieldViolation) Field() string  { return v.field }
+func (v fieldViolation) Reason() string { return v.reason }
+func (v fieldViolation) Error() string  { return "invalid " + v.field }
+
+// violations mimics the errors ValidateAll methods return.
+type violations []error
+
+func (v violations) AllErrors() []error { return v }
+
+func (v violations) Error() string {
+	msgs := make([]string, len(v))
+	for i, err := range v {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+type fakeMessage struct{}
+
+func (*fakeMessage) Reset()         {}
+func (*fakeMessage) String() string { return "fakeMessage" }
+func (*fakeMessage) ProtoMessage()  {}
+
+type validatedMessage struct {
+	fakeMessage
+
+	err error
+}
+
+func (m *validatedMessage) Validate() error { return m.err }
+
+type allValidatedMessage struct {
+	validatedMessage
+
+	allErr error
+}
+
+func (m *allValidatedMessage) ValidateAll() error { return m.allErr }
+
+func TestValidateRequest(t *testing.T) {
+	req := &transport.Request{Caller: "caller", Service: "service", Procedure: "foo.Bar::Baz"}
+
+	tests := []struct {
+		desc    string
+		message proto.Message
+		wantErr string
+	}{
+		{
+			desc:    "no validation",
+			message: &fakeMessage{},
+		},
+		{
+			desc:    "valid",
+			message: &validatedMessage{},
+		},
+		{
+			desc:    "field violation",
+			message: &validatedMessage{err: fieldViolation{"name", "value length must be at least 1 runes"}},
+			wantErr: "name: value length must be at least 1 runes",
+		},
+		{
+			desc:    "other error",
+			message: &validatedMessage{err: errors.New("great sadness")},
+			wantErr: "great sadness",
+		},
+		{
+			desc: "all violations",
+			message: &allValidatedMessage{
+				validatedMessage: validatedMessage{err: fieldViolation{"name", "must be set"}},
+				allErr: violations{
+					fieldViolation{"name", "must be set"},
+					fieldViolation{"age", "value must be greater than 0"},
+				},
+			},
+			wantErr: "name: must be set; age: value must be greater than 0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			err := validateRequest(req, tt.message)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Equal(t, yarpcerrors.CodeInvalidArgument, yarpcerrors.FromError(err).Code())
+			assert.Equal(t,
+				`invalid request for procedure "foo.Bar::Baz" of service "service" from caller "caller": `+tt.wantErr,
+				yarpcerrors.FromError(err).Message())
+		})
+	}
+}
+
+func TestWithRequestValidation(t *testing.T) {
+	procedures := BuildProcedures(BuildProceduresParams{
+		ServiceName: "foo.Bar",
+		UnaryHandlerParams: []BuildProceduresUnaryHandlerParams{
+			{MethodName: "Baz", Handler: NewUnaryHandler(UnaryHandlerParams{})},
+		},
+		OnewayHandlerParams: []BuildProceduresOnewayHandlerParams{
+			{MethodName: "Qux", Handler: NewOnewayHandler(OnewayHandlerParams{})},
+		},
+	})
+	validated := WithMarshalOptions(WithRequestValidation(procedures), DiscardUnknown)
+	require.Len(t, validated, len(procedures))
+	for _, p := range validated {
+		switch p.HandlerSpec.Type() {
+		case transport.Unary:
+			h := p.HandlerSpec.Unary().(*unaryHandler)
+			assert.True(t, h.validate)
+			assert.True(t, h.marshalOptions.DiscardUnknown, "marshal options must be kept")
+		case transport.Oneway:
+			assert.True(t, p.HandlerSpec.Oneway().(*onewayHandler).validate)
+		}
+	}
+
+	// The original procedures are left untouched.
+	assert.False(t, procedures[0].HandlerSpec.Unary().(*unaryHandler).validate)
+}
@@ -42,4 +42,24 @@
 // 	}
 //
 // 	dispatcher.Register(raw.OnewayProcedure("RunTask", RunTask))
+//
+// Streaming Bodies
+//
+// The functions above buffer entire request and response bodies in memory.
+// For large payloads, use NewReaderClient and ReaderProcedure instead, which
+// hand bodies between the transport and the application as streams.
+//
+// 	client := raw.NewReaderClient(clientConfig)
+// 	resBody, err := client.Call(ctx, "upload", file)
+// 	if resBody != nil {
+// 		defer resBody.Close()
+// 	}
+//
+// 	func Upload(ctx context.Context, reqBody io.Reader) (io.ReadCloser, error) {
+// 		// ...
+// 	}
+//
+// 	dispatcher.Register(raw.ReaderProcedure("upload", Upload))
+//
+// Note that some transports buffer bodies regardless.
 package raw
@@ -22,6 +22,7 @@ package raw
 
 import (
 	"context"
+	"io"
 	"io/ioutil"
 
 	encodingapi "go.uber.org/yarpc/api/encoding"
@@ -85,3 +86,54 @@ func (r rawOnewayHandler) HandleOneway(ctx context.Context, treq *transport.Requ
 
 	return r.OnewayHandler(ctx, reqBody)
 }
+
+// rawReaderHandler adapts a ReaderHandler into a transport.UnaryHandler
+type rawReaderHandler struct{ ReaderHandler }
+
+// rawReaderOnewayHandler adapts a ReaderOnewayHandler into a
+// transport.OnewayHandler
+type rawReaderOnewayHandler struct{ ReaderOnewayHandler }
+
+func (r rawReaderHandler) Handle(ctx context.Context, treq *transport.Request, rw transport.ResponseWriter) error {
+	if err := errors.ExpectEncodings(treq, Encoding); err != nil {
+		return err
+	}
+
+	ctx, call := encodingapi.NewInboundCall(ctx)
+	if err := call.ReadFromRequest(treq); err != nil {
+		return err
+	}
+
+	resBody, appErr := r.ReaderHandler(ctx, treq.Body)
+	if resBody != nil {
+		defer resBody.Close()
+	}
+	if err := call.WriteToResponse(rw); err != nil {
+		return err
+	}
+
+	// we want to return the appErr if it exists as this is what
+	// the previous behavior was so we deprioritize this error
+	var writeErr error
+	if resBody != nil {
+		_, writeErr = io.Copy(rw, resBody)
+	}
+	if appErr != nil {
+		rw.SetApplicationError()
+		return appErr
+	}
+	return writeErr
+}
+
+func (r rawReaderOnewayHandler) HandleOneway(ctx context.Context, treq *transport.Request) error {
+	if err := errors.ExpectEncodings(treq, Encoding); err != nil {
+		return err
+	}
+
+	ctx, call := encodingapi.NewInboundCall(ctx)
+	if err := call.ReadFromRequest(treq); err != nil {
+		return err
+	}
+
+	return r.ReaderOnewayHandler(ctx, treq.Body)
+}
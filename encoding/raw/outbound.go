@@ -23,6 +23,7 @@ package raw
 import (
 	"bytes"
 	"context"
+	"io"
 	"io/ioutil"
 
 	"go.uber.org/yarpc"
@@ -47,6 +48,7 @@ func New(c transport.ClientConfig) Client {
 
 func init() {
 	yarpc.RegisterClientBuilder(New)
+	yarpc.RegisterClientBuilder(NewReaderClient)
 }
 
 type rawClient struct {
@@ -110,3 +112,77 @@ func (c rawClient) CallOneway(ctx context.Context, procedure string, body []byte
 
 	return c.cc.GetOnewayOutbound().CallOneway(ctx, &treq)
 }
+
+// ReaderClient makes Raw requests to a single service without buffering
+// request or response bodies in the encoding layer. It is suited to large
+// payloads which should be streamed to and from the transport.
+type ReaderClient interface {
+	// Call performs a unary outbound Raw request.
+	//
+	// If the returned response body is non-nil, the caller must close it,
+	// even if an error is also returned. The body may stop being readable
+	// once the context finishes.
+	Call(ctx context.Context, procedure string, body io.Reader, opts ...yarpc.CallOption) (io.ReadCloser, error)
+
+	// CallOneway performs a oneway outbound Raw request.
+	CallOneway(ctx context.Context, procedure string, body io.Reader, opts ...yarpc.CallOption) (transport.Ack, error)
+}
+
+// NewReaderClient builds a new Raw client which reads and writes bodies as
+// streams.
+func NewReaderClient(c transport.ClientConfig) ReaderClient {
+	return rawReaderClient{cc: c}
+}
+
+type rawReaderClient struct {
+	cc transport.ClientConfig
+}
+
+func (c rawReaderClient) Call(ctx context.Context, procedure string, body io.Reader, opts ...yarpc.CallOption) (io.ReadCloser, error) {
+	call := encodingapi.NewOutboundCall(encoding.FromOptions(opts)...)
+	treq := transport.Request{
+		Caller:    c.cc.Caller(),
+		Service:   c.cc.Service(),
+		Procedure: procedure,
+		Encoding:  Encoding,
+		Body:      body,
+	}
+
+	ctx, err := call.WriteToRequest(ctx, &treq)
+	if err != nil {
+		return nil, err
+	}
+
+	tres, appErr := c.cc.GetUnaryOutbound().Call(ctx, &treq)
+	if tres == nil {
+		return nil, appErr
+	}
+
+	if _, err = call.ReadFromResponse(ctx, tres); err != nil {
+		if tres.Body != nil {
+			tres.Body.Close()
+		}
+		return nil, err
+	}
+
+	// As with Client, the response body is surfaced alongside the appErr.
+	return tres.Body, appErr
+}
+
+func (c rawReaderClient) CallOneway(ctx context.Context, procedure string, body io.Reader, opts ...yarpc.CallOption) (transport.Ack, error) {
+	call := encodingapi.NewOutboundCall(encoding.FromOptions(opts)...)
+	treq := transport.Request{
+		Caller:    c.cc.Caller(),
+		Service:   c.cc.Service(),
+		Procedure: procedure,
+		Encoding:  Encoding,
+		Body:      body,
+	}
+
+	ctx, err := call.WriteToRequest(ctx, &treq)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.cc.GetOnewayOutbound().CallOneway(ctx, &treq)
+}
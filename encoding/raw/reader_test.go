@@ -0,0 +1,182 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package raw
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/internal/clientconfig"
+)
+
+// trackingCloser records whether it was closed.
+type trackingCloser struct {
+	io.Reader
+
+	closed bool
+}
+
+func (c *trackingCloser) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestReaderClientCall(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	tests := []struct {
+		desc        string
+		responseErr error
+	}{
+		{desc: "success"},
+		{desc: "application error", responseErr: errors.New("great sadness")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			reqBody := strings.NewReader("hello")
+			resBody := &trackingCloser{Reader: strings.NewReader("world")}
+
+			outbound := transporttest.NewMockUnaryOutbound(mockCtrl)
+			outbound.EXPECT().Call(gomock.Any(), gomock.Any()).Do(
+				func(_ context.Context, req *transport.Request) {
+					// The request body is handed to the transport as-is.
+					assert.True(t, req.Body == reqBody, "request body was not passed through")
+					assert.Equal(t, Encoding, req.Encoding)
+				}).Return(&transport.Response{Body: resBody}, tt.responseErr)
+
+			client := NewReaderClient(clientconfig.MultiOutbound("caller", "service",
+				transport.Outbounds{Unary: outbound}))
+
+			body, err := client.Call(context.Background(), "foo", reqBody)
+			if tt.responseErr != nil {
+				assert.Equal(t, tt.responseErr, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			require.NotNil(t, body)
+
+			got, err := ioutil.ReadAll(body)
+			require.NoError(t, err)
+			assert.Equal(t, "world", string(got))
+
+			assert.False(t, resBody.closed, "response body must be left to the caller")
+			require.NoError(t, body.Close())
+			assert.True(t, resBody.closed)
+		})
+	}
+}
+
+func TestReaderClientCallOneway(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	reqBody := strings.NewReader("hello")
+	outbound := transporttest.NewMockOnewayOutbound(mockCtrl)
+	outbound.EXPECT().CallOneway(gomock.Any(), gomock.Any()).Do(
+		func(_ context.Context, req *transport.Request) {
+			assert.True(t, req.Body == reqBody, "request body was not passed through")
+		}).Return(&successAck{}, nil)
+
+	client := NewReaderClient(clientconfig.MultiOutbound("caller", "service",
+		transport.Outbounds{Oneway: outbound}))
+
+	ack, err := client.CallOneway(context.Background(), "foo", reqBody)
+	require.NoError(t, err)
+	assert.Equal(t, "success", ack.String())
+}
+
+func TestReaderHandler(t *testing.T) {
+	tests := []struct {
+		desc    string
+		appErr  error
+		resBody string
+	}{
+		{desc: "success", resBody: "world"},
+		{desc: "application error", appErr: errors.New("great sadness"), resBody: "sad"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			reqBody := bytes.NewReader([]byte("hello"))
+			resBody := &trackingCloser{Reader: strings.NewReader(tt.resBody)}
+
+			procs := ReaderProcedure("foo", func(ctx context.Context, body io.Reader) (io.ReadCloser, error) {
+				assert.True(t, body == reqBody, "request body was not passed through")
+				return resBody, tt.appErr
+			})
+			require.Len(t, procs, 1)
+
+			rw := new(transporttest.FakeResponseWriter)
+			err := procs[0].HandlerSpec.Unary().Handle(context.Background(), &transport.Request{
+				Procedure: "foo",
+				Encoding:  Encoding,
+				Body:      reqBody,
+			}, rw)
+			assert.Equal(t, tt.appErr, err)
+			assert.Equal(t, tt.appErr != nil, rw.IsApplicationError)
+			assert.Equal(t, tt.resBody, rw.Body.String())
+			assert.True(t, resBody.closed, "response body must be closed")
+		})
+	}
+}
+
+func TestReaderHandlerEncodingMismatch(t *testing.T) {
+	procs := ReaderProcedure("foo", func(context.Context, io.Reader) (io.ReadCloser, error) {
+		t.Fatal("handler must not be called")
+		return nil, nil
+	})
+
+	err := procs[0].HandlerSpec.Unary().Handle(context.Background(), &transport.Request{
+		Procedure: "foo",
+		Encoding:  "json",
+		Body:      strings.NewReader("hello"),
+	}, new(transporttest.FakeResponseWriter))
+	assert.Error(t, err)
+}
+
+func TestReaderOnewayHandler(t *testing.T) {
+	var got string
+	procs := ReaderOnewayProcedure("foo", func(ctx context.Context, body io.Reader) error {
+		b, err := ioutil.ReadAll(body)
+		got = string(b)
+		return err
+	})
+	require.Len(t, procs, 1)
+
+	require.NoError(t, procs[0].HandlerSpec.Oneway().HandleOneway(context.Background(), &transport.Request{
+		Procedure: "foo",
+		Encoding:  Encoding,
+		Body:      strings.NewReader("hello"),
+	}))
+	assert.Equal(t, "hello", got)
+}
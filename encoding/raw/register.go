@@ -22,6 +22,7 @@ package raw
 
 import (
 	"context"
+	"io"
 
 	"go.uber.org/yarpc/api/transport"
 )
@@ -61,3 +62,37 @@ func OnewayProcedure(name string, handler OnewayHandler) []transport.Procedure {
 		},
 	}
 }
+
+// ReaderHandler implements a single, unary procedure whose request and
+// response bodies are streamed rather than buffered.
+//
+// The request body must not be used after the handler returns. The returned
+// response body, if non-nil, is copied to the transport and closed.
+type ReaderHandler func(context.Context, io.Reader) (io.ReadCloser, error)
+
+// ReaderProcedure builds a Procedure from the given streaming raw handler.
+func ReaderProcedure(name string, handler ReaderHandler) []transport.Procedure {
+	return []transport.Procedure{
+		{
+			Name:        name,
+			HandlerSpec: transport.NewUnaryHandlerSpec(rawReaderHandler{handler}),
+		},
+	}
+}
+
+// ReaderOnewayHandler implements a single, oneway procedure whose request
+// body is streamed rather than buffered.
+//
+// The request body must not be used after the handler returns.
+type ReaderOnewayHandler func(context.Context, io.Reader) error
+
+// ReaderOnewayProcedure builds a Procedure from the given streaming raw
+// oneway handler.
+func ReaderOnewayProcedure(name string, handler ReaderOnewayHandler) []transport.Procedure {
+	return []transport.Procedure{
+		{
+			Name:        name,
+			HandlerSpec: transport.NewOnewayHandlerSpec(rawReaderOnewayHandler{handler}),
+		},
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package thrift
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/thriftrw/protocol"
+	"go.uber.org/thriftrw/wire"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/encoding/thrift/internal/compact"
+	"go.uber.org/yarpc/internal/clientconfig"
+	"go.uber.org/yarpc/internal/testtime"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// loopbackOutbound sends requests directly to a Thrift handler.
+type loopbackOutbound struct {
+	transport.UnaryOutbound
+
+	handler  transport.UnaryHandler
+	requests []*transport.Request
+	bodies   [][]byte
+}
+
+func (o *loopbackOutbound) Call(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	var body bytes.Buffer
+	if _, err := body.ReadFrom(req.Body); err != nil {
+		return nil, err
+	}
+	req.Body = bytes.NewReader(body.Bytes())
+	o.requests = append(o.requests, req)
+	o.bodies = append(o.bodies, body.Bytes())
+
+	rw := new(transporttest.FakeResponseWriter)
+	if err := o.handler.Handle(ctx, req, rw); err != nil {
+		return nil, err
+	}
+	return &transport.Response{Body: ioutil.NopCloser(&rw.Body)}, nil
+}
+
+func TestCompactRoundTrip(t *testing.T) {
+	reqBody := wire.NewValueStruct(wire.Struct{Fields: []wire.Field{
+		{ID: 1, Value: wire.NewValueI64(1 << 20)},
+	}})
+	resBody := wire.NewValueStruct(wire.Struct{Fields: []wire.Field{
+		{ID: 0, Value: wire.NewValueBinary([]byte("hello"))},
+	}})
+
+	for _, enveloped := range []bool{false, true} {
+		var (
+			clientOpts   = []ClientOption{Compact}
+			registerOpts = []RegisterOption{Compact}
+		)
+		if enveloped {
+			clientOpts = append(clientOpts, Enveloped)
+			registerOpts = append(registerOpts, Enveloped)
+		}
+
+		procs := BuildProcedures(Service{
+			Name: "MyService",
+			Methods: []Method{{
+				Name: "someMethod",
+				HandlerSpec: HandlerSpec{
+					Type: transport.Unary,
+					Unary: func(ctx context.Context, w wire.Value) (Response, error) {
+						assert.True(t, wire.ValuesAreEqual(reqBody, w), "request body did not match")
+						return Response{Body: valueEnveloper{wire.Reply, resBody}}, nil
+					},
+				},
+			}},
+		}, registerOpts...)
+		require.Len(t, procs, 2)
+		assert.Equal(t, Encoding, procs[0].Encoding)
+		assert.Equal(t, CompactEncoding, procs[1].Encoding)
+
+		out := &loopbackOutbound{handler: procs[1].HandlerSpec.Unary()}
+		client := New(Config{
+			Service:      "MyService",
+			ClientConfig: clientconfig.MultiOutbound("caller", "service", transport.Outbounds{Unary: out}),
+		}, clientOpts...)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testtime.Second)
+		defer cancel()
+
+		got, err := client.Call(ctx, valueEnveloper{wire.Call, reqBody})
+		require.NoError(t, err, "enveloped: %v", enveloped)
+		assert.True(t, wire.ValuesAreEqual(resBody, got), "response body did not match")
+
+		require.Len(t, out.requests, 1)
+		assert.Equal(t, CompactEncoding, out.requests[0].Encoding)
+
+		// The request was written with the compact protocol.
+		reader := bytes.NewReader(out.bodies[0])
+		if enveloped {
+			_, err = compact.Protocol.DecodeEnveloped(reader)
+		} else {
+			_, err = compact.Protocol.Decode(reader, wire.TStruct)
+		}
+		assert.NoError(t, err, "request was not compact encoded")
+	}
+}
+
+func TestCompactHandlerAcceptsBinary(t *testing.T) {
+	h := thriftUnaryHandler{
+		Protocol: protocol.Binary,
+		Compact:  true,
+		UnaryHandler: func(ctx context.Context, w wire.Value) (Response, error) {
+			return Response{Body: fakeEnveloper(wire.Reply)}, nil
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), testtime.Second)
+	defer cancel()
+
+	var body bytes.Buffer
+	require.NoError(t, protocol.Binary.Encode(wire.NewValueStruct(wire.Struct{}), &body))
+	req := request()
+	req.Body = &body
+
+	rw := new(transporttest.FakeResponseWriter)
+	require.NoError(t, h.Handle(ctx, req, rw))
+
+	// Binary requests get binary responses.
+	_, err := protocol.Binary.Decode(bytes.NewReader(rw.Body.Bytes()), wire.TStruct)
+	assert.NoError(t, err)
+}
+
+func TestCompactRequiresOptIn(t *testing.T) {
+	h := thriftUnaryHandler{Protocol: protocol.Binary}
+
+	ctx, cancel := context.WithTimeout(context.Background(), testtime.Second)
+	defer cancel()
+
+	req := request()
+	req.Encoding = CompactEncoding
+	err := h.Handle(ctx, req, new(transporttest.FakeResponseWriter))
+	require.Error(t, err)
+	assert.True(t, yarpcerrors.IsInvalidArgument(err), "expected InvalidArgument, got %v", err)
+	assert.Contains(t, err.Error(), `expected encoding "thrift" but got "thrift+compact"`)
+}
+
+type valueEnveloper struct {
+	typ wire.EnvelopeType
+	v   wire.Value
+}
+
+func (valueEnveloper) MethodName() string { return "someMethod" }
+
+func (e valueEnveloper) EnvelopeType() wire.EnvelopeType { return e.typ }
+
+func (e valueEnveloper) ToWire() (wire.Value, error) { return e.v, nil }
@@ -25,4 +25,8 @@ import "go.uber.org/yarpc/api/transport"
 // Encoding is the name of this encoding.
 const Encoding transport.Encoding = "thrift"
 
+// CompactEncoding is the name of this encoding when payloads use the Thrift
+// compact protocol. See the Compact option.
+const CompactEncoding transport.Encoding = "thrift+compact"
+
 const _defaultBufferSize = 1024 // 1k
@@ -93,6 +93,8 @@
 // 	             multiplexing enabled. Equivalent to passing
 // 	             thrift.Multiplexed. This option has no effect if enveloped
 // 	             was not set.
+// 	compact:     Requests and responses will use the Thrift compact
+// 	             protocol. Equivalent to passing thrift.Compact.
 //
 // For example,
 //
@@ -120,6 +122,33 @@
 // 	var h handler
 // 	yarpc.InjectClients(dispatcher, &h)
 //
+// Apache Thrift clients using TMultiplexedProtocol send requests for several
+// services to a single endpoint. Use Multiplex to serve them from one
+// procedure, dispatching on the service name in the envelope.
+//
+// 	dispatcher.Register(thrift.Multiplex("thrift",
+// 		thrift.MultiplexedService{
+// 			Name:       "MyService",
+// 			Procedures: myserviceserver.New(handler, thrift.Enveloped),
+// 		},
+// 	))
+//
+// Using the Compact Protocol
+//
+// Payloads are encoded with the Thrift binary protocol by default. The
+// thrift.Compact option switches to the compact protocol, which produces
+// considerably smaller payloads for large structs. Compact requests are sent
+// with the "thrift+compact" encoding, so servers must also opt in when the
+// handler is registered.
+//
+// 	dispatcher.Register(myserviceserver.New(handler, thrift.Compact))
+//
+// Servers registered with this option continue to accept binary requests,
+// answering each request with the protocol it was made with. Once the server
+// is deployed, clients may switch over independently.
+//
+// 	client := myserviceclient.New(dispatcher.ClientConfig("myservice"), thrift.Compact)
+//
 // Automatically Sanitizing TChannel Contexts
 //
 // Contexts created with `tchannel.ContextWithHeaders` are incompatible with YARPC clients generated from Thrift.
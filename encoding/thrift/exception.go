@@ -0,0 +1,97 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package thrift
+
+import (
+	"bytes"
+	"reflect"
+
+	"go.uber.org/thriftrw/envelope"
+	"go.uber.org/thriftrw/protocol"
+	"go.uber.org/thriftrw/wire"
+	"go.uber.org/yarpc/api/transport"
+)
+
+// exception is a declared Thrift exception, as generated by ThriftRW.
+type exception interface {
+	error
+
+	ToWire() (wire.Value, error)
+}
+
+// exceptionMeta returns the ApplicationErrorMeta of the declared exception
+// that the given result of a Thrift procedure carries, or nil if it carries
+// none.
+//
+// Generated results are structs with a pointer field for the successful
+// response and one for each declared exception, of which at most one is
+// set.
+func exceptionMeta(result envelope.Enveloper) *transport.ApplicationErrorMeta {
+	v := reflect.Indirect(reflect.ValueOf(result))
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Field(i)
+		if f.Kind() != reflect.Ptr || f.IsNil() || !f.CanInterface() {
+			continue
+		}
+		exc, ok := f.Interface().(exception)
+		if !ok {
+			continue
+		}
+
+		meta := &transport.ApplicationErrorMeta{
+			Name:    f.Elem().Type().Name(),
+			Message: exc.Error(),
+		}
+		// Exceptions which fail to encode are still named.
+		if value, err := exc.ToWire(); err == nil {
+			var buf bytes.Buffer
+			if err := protocol.Binary.Encode(value, &buf); err == nil {
+				meta.Details = buf.Bytes()
+			}
+		}
+		return meta
+	}
+	return nil
+}
+
+// DecodeExceptionDetails decodes the details of an application error, which
+// inbounds set for declared Thrift exceptions, into the given exception.
+// Middleware may use it to classify the exceptions of responses.
+//
+// 	if meta := res.ApplicationErrorMeta; meta != nil && meta.Name == "KeyDoesNotExist" {
+// 		var exc kv.KeyDoesNotExist
+// 		if err := thrift.DecodeExceptionDetails(meta.Details, &exc); err == nil {
+// 			...
+// 		}
+// 	}
+func DecodeExceptionDetails(details []byte, exc interface {
+	FromWire(wire.Value) error
+}) error {
+	value, err := protocol.Binary.Decode(bytes.NewReader(details), wire.TStruct)
+	if err != nil {
+		return err
+	}
+	return exc.FromWire(value)
+}
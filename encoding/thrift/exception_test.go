@@ -0,0 +1,88 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package thrift
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/thriftrw/protocol"
+	"go.uber.org/thriftrw/thrifttest"
+	"go.uber.org/thriftrw/wire"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/internal/examples/thrift-keyvalue/keyvalue/kv"
+	"go.uber.org/yarpc/internal/testtime"
+)
+
+func TestExceptionMeta(t *testing.T) {
+	message := "no such key"
+	meta := exceptionMeta(&kv.KeyValue_GetValue_Result{
+		DoesNotExist: &kv.ResourceDoesNotExist{Key: "foo", Message: &message},
+	})
+	require.NotNil(t, meta)
+	assert.Equal(t, "ResourceDoesNotExist", meta.Name)
+	assert.Contains(t, meta.Message, "foo")
+
+	var exc kv.ResourceDoesNotExist
+	require.NoError(t, DecodeExceptionDetails(meta.Details, &exc))
+	assert.Equal(t, "foo", exc.Key)
+	assert.Equal(t, "no such key", *exc.Message)
+
+	success := "bar"
+	assert.Nil(t, exceptionMeta(&kv.KeyValue_GetValue_Result{Success: &success}))
+	assert.Nil(t, exceptionMeta(fakeEnveloper(wire.Reply)))
+
+	assert.Error(t, DecodeExceptionDetails([]byte("not thrift"), &exc))
+}
+
+func TestHandleException(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	proto := thrifttest.NewMockEnvelopeAgnosticProtocol(mockCtrl)
+	proto.EXPECT().DecodeRequest(wire.Call, gomock.Any()).Return(
+		wire.NewValueStruct(wire.Struct{}), protocol.NoEnvelopeResponder, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testtime.Second)
+	defer cancel()
+
+	handler := func(ctx context.Context, w wire.Value) (Response, error) {
+		return Response{
+			Body: &kv.KeyValue_GetValue_Result{
+				DoesNotExist: &kv.ResourceDoesNotExist{Key: "foo"},
+			},
+			IsApplicationError: true,
+		}, nil
+	}
+	h := thriftUnaryHandler{Protocol: proto, UnaryHandler: handler}
+
+	rw := new(transporttest.FakeResponseWriter)
+	require.NoError(t, h.Handle(ctx, request(), rw))
+	assert.True(t, rw.IsApplicationError)
+	require.NotNil(t, rw.ApplicationErrorMeta, "declared exceptions must be described")
+	assert.Equal(t, "ResourceDoesNotExist", rw.ApplicationErrorMeta.Name)
+
+	status := rw.ApplicationErrorMeta.Status()
+	assert.NotEmpty(t, status.Details(), "exceptions must be carried as details")
+}
@@ -28,6 +28,7 @@ import (
 	"go.uber.org/thriftrw/wire"
 	encodingapi "go.uber.org/yarpc/api/encoding"
 	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/encoding/thrift/internal/compact"
 	"go.uber.org/yarpc/internal/bufferpool"
 	"go.uber.org/yarpc/pkg/errors"
 )
@@ -37,6 +38,7 @@ type thriftUnaryHandler struct {
 	UnaryHandler UnaryHandler
 	Protocol     protocol.Protocol
 	Enveloping   bool
+	Compact      bool
 }
 
 // thriftOnewayHandler wraps a Thrift Handler into a transport.OnewayHandler
@@ -44,6 +46,7 @@ type thriftOnewayHandler struct {
 	OnewayHandler OnewayHandler
 	Protocol      protocol.Protocol
 	Enveloping    bool
+	Compact       bool
 }
 
 func (t thriftUnaryHandler) Handle(ctx context.Context, treq *transport.Request, rw transport.ResponseWriter) error {
@@ -52,7 +55,7 @@ func (t thriftUnaryHandler) Handle(ctx context.Context, treq *transport.Request,
 
 	ctx, call := encodingapi.NewInboundCall(ctx)
 
-	reqValue, responder, err := decodeRequest(call, buf, treq, wire.Call, t.Protocol, t.Enveloping)
+	reqValue, responder, err := decodeRequest(call, buf, treq, wire.Call, t.Protocol, t.Enveloping, t.Compact)
 	if err != nil {
 		return err
 	}
@@ -74,6 +77,11 @@ func (t thriftUnaryHandler) Handle(ctx context.Context, treq *transport.Request,
 
 	if res.IsApplicationError {
 		rw.SetApplicationError()
+		if setter, ok := rw.(transport.ApplicationErrorMetaSetter); ok {
+			if meta := exceptionMeta(res.Body); meta != nil {
+				setter.SetApplicationErrorMeta(meta)
+			}
+		}
 	}
 
 	if err := call.WriteToResponse(rw); err != nil {
@@ -94,7 +102,7 @@ func (t thriftOnewayHandler) HandleOneway(ctx context.Context, treq *transport.R
 
 	ctx, call := encodingapi.NewInboundCall(ctx)
 
-	reqValue, _, err := decodeRequest(call, buf, treq, wire.OneWay, t.Protocol, t.Enveloping)
+	reqValue, _, err := decodeRequest(call, buf, treq, wire.OneWay, t.Protocol, t.Enveloping, t.Compact)
 	if err != nil {
 		return err
 	}
@@ -121,6 +129,9 @@ func decodeRequest(
 	// enveloping indicates that requests must be enveloped, used only if the
 	// protocol is not envelope agnostic.
 	enveloping bool,
+	// compact indicates that requests with the compact encoding are accepted
+	// in addition to the protocol above.
+	compact bool,
 ) (
 	// the wire representation of the decoded request.
 	// decodeRequest does not surface the envelope.
@@ -130,7 +141,11 @@ func decodeRequest(
 	protocol.Responder,
 	error,
 ) {
-	if err := errors.ExpectEncodings(treq, Encoding); err != nil {
+	encodings := []transport.Encoding{Encoding}
+	if compact {
+		encodings = append(encodings, CompactEncoding)
+	}
+	if err := errors.ExpectEncodings(treq, encodings...); err != nil {
 		return wire.Value{}, nil, err
 	}
 
@@ -145,6 +160,10 @@ func decodeRequest(
 
 	reader := bytes.NewReader(buf.Bytes())
 
+	if treq.Encoding == CompactEncoding {
+		return decodeCompactRequest(treq, reqEnvelopeType, reader, enveloping)
+	}
+
 	// Discover or choose the appropriate envelope
 	if agnosticProto, ok := proto.(protocol.EnvelopeAgnosticProtocol); ok {
 		return agnosticProto.DecodeRequest(reqEnvelopeType, reader)
@@ -186,3 +205,31 @@ func decodeUnenvelopedRequest(
 	responder := protocol.NoEnvelopeResponder
 	return reqValue, responder, err
 }
+
+// decodeCompactRequest decodes a request made with the compact protocol,
+// responding with the compact protocol as well.
+func decodeCompactRequest(
+	treq *transport.Request,
+	reqEnvelopeType wire.EnvelopeType,
+	reader *bytes.Reader,
+	enveloping bool,
+) (wire.Value, protocol.Responder, error) {
+	if !enveloping {
+		reqValue, err := compact.Protocol.Decode(reader, wire.TStruct)
+		if err != nil {
+			return wire.Value{}, nil, err
+		}
+		return reqValue, compact.NoEnvelopeResponder, nil
+	}
+
+	envelope, err := compact.Protocol.DecodeEnveloped(reader)
+	if err != nil {
+		return wire.Value{}, nil, err
+	}
+	if envelope.Type != reqEnvelopeType {
+		err := errors.RequestBodyDecodeError(treq, errUnexpectedEnvelopeType(envelope.Type))
+		return wire.Value{}, nil, err
+	}
+	responder := compact.EnvelopeResponder{Name: envelope.Name, SeqID: envelope.SeqID}
+	return envelope.Value, responder, nil
+}
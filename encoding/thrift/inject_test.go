@@ -75,6 +75,15 @@ func TestClientBuilderOptions(t *testing.T) {
 			},
 			want: clientConfig{Enveloping: true, Multiplexed: true},
 		},
+		{
+			desc: "compact",
+			give: reflect.StructField{
+				Name: "Client",
+				Type: _typeOfSomeInterface,
+				Tag:  `service:"keyvalue" thrift:"compact,enveloped"`,
+			},
+			want: clientConfig{Enveloping: true, Compact: true},
+		},
 		{
 			desc: "ignore unknown",
 			give: reflect.StructField{
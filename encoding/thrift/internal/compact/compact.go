@@ -0,0 +1,559 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package compact implements the Thrift compact protocol for thriftrw wire
+// values.
+//
+// See https://github.com/apache/thrift/blob/master/doc/specs/thrift-compact-protocol.md
+package compact
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	"go.uber.org/thriftrw/protocol"
+	"go.uber.org/thriftrw/wire"
+)
+
+// Protocol is the Thrift compact protocol.
+var Protocol protocol.Protocol = compactProtocol{}
+
+var _ protocol.Responder = EnvelopeResponder{}
+
+const (
+	_protocolID   = 0x82
+	_version      = 1
+	_versionMask  = 0x1f
+	_typeShift    = 5
+	_maxShortList = 14
+)
+
+// Compact types. Booleans in struct fields carry their value in the type.
+const (
+	_stop         byte = 0
+	_booleanTrue  byte = 1
+	_booleanFalse byte = 2
+	_byte         byte = 3
+	_i16          byte = 4
+	_i32          byte = 5
+	_i64          byte = 6
+	_double       byte = 7
+	_binary       byte = 8
+	_list         byte = 9
+	_set          byte = 10
+	_map          byte = 11
+	_struct       byte = 12
+)
+
+func toCompactType(t wire.Type) (byte, error) {
+	switch t {
+	case wire.TBool:
+		return _booleanTrue, nil
+	case wire.TI8:
+		return _byte, nil
+	case wire.TI16:
+		return _i16, nil
+	case wire.TI32:
+		return _i32, nil
+	case wire.TI64:
+		return _i64, nil
+	case wire.TDouble:
+		return _double, nil
+	case wire.TBinary:
+		return _binary, nil
+	case wire.TList:
+		return _list, nil
+	case wire.TSet:
+		return _set, nil
+	case wire.TMap:
+		return _map, nil
+	case wire.TStruct:
+		return _struct, nil
+	default:
+		return 0, fmt.Errorf("unknown thrift type %v", t)
+	}
+}
+
+func toWireType(t byte) (wire.Type, error) {
+	switch t {
+	case _booleanTrue, _booleanFalse:
+		return wire.TBool, nil
+	case _byte:
+		return wire.TI8, nil
+	case _i16:
+		return wire.TI16, nil
+	case _i32:
+		return wire.TI32, nil
+	case _i64:
+		return wire.TI64, nil
+	case _double:
+		return wire.TDouble, nil
+	case _binary:
+		return wire.TBinary, nil
+	case _list:
+		return wire.TList, nil
+	case _set:
+		return wire.TSet, nil
+	case _map:
+		return wire.TMap, nil
+	case _struct:
+		return wire.TStruct, nil
+	default:
+		return 0, fmt.Errorf("unknown compact type %d", t)
+	}
+}
+
+type compactProtocol struct{}
+
+func (compactProtocol) Encode(v wire.Value, w io.Writer) error {
+	e := newEncoder(w)
+	if err := e.writeValue(v); err != nil {
+		return err
+	}
+	return e.Flush()
+}
+
+func (compactProtocol) Decode(r io.ReaderAt, t wire.Type) (wire.Value, error) {
+	return newDecoder(r).readValue(t)
+}
+
+func (compactProtocol) EncodeEnveloped(env wire.Envelope, w io.Writer) error {
+	e := newEncoder(w)
+	e.writeByte(_protocolID)
+	e.writeByte(_version&_versionMask | byte(env.Type)<<_typeShift)
+	e.writeVarint(uint64(uint32(env.SeqID)))
+	e.writeBinary([]byte(env.Name))
+	if err := e.writeValue(env.Value); err != nil {
+		return err
+	}
+	return e.Flush()
+}
+
+func (compactProtocol) DecodeEnveloped(r io.ReaderAt) (wire.Envelope, error) {
+	d := newDecoder(r)
+	id, err := d.ReadByte()
+	if err != nil {
+		return wire.Envelope{}, err
+	}
+	if id != _protocolID {
+		return wire.Envelope{}, fmt.Errorf("expected compact protocol id %#x but got %#x", _protocolID, id)
+	}
+	versionAndType, err := d.ReadByte()
+	if err != nil {
+		return wire.Envelope{}, err
+	}
+	if version := versionAndType & _versionMask; version != _version {
+		return wire.Envelope{}, fmt.Errorf("unsupported compact protocol version %d", version)
+	}
+	seqID, err := d.readVarint()
+	if err != nil {
+		return wire.Envelope{}, err
+	}
+	name, err := d.readBinary()
+	if err != nil {
+		return wire.Envelope{}, err
+	}
+	value, err := d.readValue(wire.TStruct)
+	if err != nil {
+		return wire.Envelope{}, err
+	}
+	return wire.Envelope{
+		Name:  string(name),
+		Type:  wire.EnvelopeType(versionAndType >> _typeShift),
+		SeqID: int32(uint32(seqID)),
+		Value: value,
+	}, nil
+}
+
+// EnvelopeResponder encodes responses to enveloped requests with the compact
+// protocol.
+type EnvelopeResponder struct {
+	Name  string
+	SeqID int32
+}
+
+// EncodeResponse writes the response in an envelope matching the request.
+func (r EnvelopeResponder) EncodeResponse(v wire.Value, t wire.EnvelopeType, w io.Writer) error {
+	return Protocol.EncodeEnveloped(wire.Envelope{
+		Name:  r.Name,
+		Type:  t,
+		SeqID: r.SeqID,
+		Value: v,
+	}, w)
+}
+
+// NoEnvelopeResponder encodes responses to unenveloped requests with the
+// compact protocol.
+var NoEnvelopeResponder protocol.Responder = noEnvelopeResponder{}
+
+type noEnvelopeResponder struct{}
+
+func (noEnvelopeResponder) EncodeResponse(v wire.Value, _ wire.EnvelopeType, w io.Writer) error {
+	return Protocol.Encode(v, w)
+}
+
+type encoder struct {
+	*bufio.Writer
+
+	// err is the first error encountered while writing.
+	err error
+	buf [binary.MaxVarintLen64]byte
+}
+
+func newEncoder(w io.Writer) *encoder {
+	return &encoder{Writer: bufio.NewWriter(w)}
+}
+
+// Flush flushes buffered data and reports the first error encountered.
+func (e *encoder) Flush() error {
+	if e.err != nil {
+		return e.err
+	}
+	return e.Writer.Flush()
+}
+
+func (e *encoder) writeByte(b byte) {
+	if e.err == nil {
+		e.err = e.WriteByte(b)
+	}
+}
+
+func (e *encoder) write(b []byte) {
+	if e.err == nil {
+		_, e.err = e.Write(b)
+	}
+}
+
+func (e *encoder) writeVarint(v uint64) {
+	n := binary.PutUvarint(e.buf[:], v)
+	e.write(e.buf[:n])
+}
+
+func (e *encoder) writeZigZag(v int64) {
+	e.writeVarint(uint64((v << 1) ^ (v >> 63)))
+}
+
+func (e *encoder) writeBinary(b []byte) {
+	e.writeVarint(uint64(len(b)))
+	e.write(b)
+}
+
+func (e *encoder) writeValue(v wire.Value) error {
+	switch v.Type() {
+	case wire.TBool:
+		if v.GetBool() {
+			e.writeByte(_booleanTrue)
+		} else {
+			e.writeByte(_booleanFalse)
+		}
+	case wire.TI8:
+		e.writeByte(byte(v.GetI8()))
+	case wire.TI16:
+		e.writeZigZag(int64(v.GetI16()))
+	case wire.TI32:
+		e.writeZigZag(int64(v.GetI32()))
+	case wire.TI64:
+		e.writeZigZag(v.GetI64())
+	case wire.TDouble:
+		binary.LittleEndian.PutUint64(e.buf[:8], math.Float64bits(v.GetDouble()))
+		e.write(e.buf[:8])
+	case wire.TBinary:
+		e.writeBinary(v.GetBinary())
+	case wire.TStruct:
+		return e.writeStruct(v.GetStruct())
+	case wire.TList:
+		return e.writeList(v.GetList())
+	case wire.TSet:
+		return e.writeList(v.GetSet())
+	case wire.TMap:
+		return e.writeMap(v.GetMap())
+	default:
+		return fmt.Errorf("unknown thrift type %v", v.Type())
+	}
+	return nil
+}
+
+func (e *encoder) writeStruct(s wire.Struct) error {
+	var lastID int16
+	for _, f := range s.Fields {
+		t, err := toCompactType(f.Value.Type())
+		if err != nil {
+			return err
+		}
+		if f.Value.Type() == wire.TBool && !f.Value.GetBool() {
+			t = _booleanFalse
+		}
+
+		if delta := int(f.ID) - int(lastID); delta > 0 && delta <= 15 {
+			e.writeByte(byte(delta)<<4 | t)
+		} else {
+			e.writeByte(t)
+			e.writeZigZag(int64(f.ID))
+		}
+		lastID = f.ID
+
+		// Booleans are held entirely in the field header.
+		if f.Value.Type() == wire.TBool {
+			continue
+		}
+		if err := e.writeValue(f.Value); err != nil {
+			return err
+		}
+	}
+	e.writeByte(_stop)
+	return nil
+}
+
+func (e *encoder) writeList(l wire.ValueList) error {
+	t, err := toCompactType(l.ValueType())
+	if err != nil {
+		return err
+	}
+	if size := l.Size(); size <= _maxShortList {
+		e.writeByte(byte(size)<<4 | t)
+	} else {
+		e.writeByte(0xf0 | t)
+		e.writeVarint(uint64(size))
+	}
+	return l.ForEach(e.writeValue)
+}
+
+func (e *encoder) writeMap(m wire.MapItemList) error {
+	if m.Size() == 0 {
+		e.writeByte(0)
+		return nil
+	}
+	kt, err := toCompactType(m.KeyType())
+	if err != nil {
+		return err
+	}
+	vt, err := toCompactType(m.ValueType())
+	if err != nil {
+		return err
+	}
+	e.writeVarint(uint64(m.Size()))
+	e.writeByte(kt<<4 | vt)
+	return m.ForEach(func(item wire.MapItem) error {
+		if err := e.writeValue(item.Key); err != nil {
+			return err
+		}
+		return e.writeValue(item.Value)
+	})
+}
+
+type decoder struct {
+	*bufio.Reader
+}
+
+func newDecoder(r io.ReaderAt) decoder {
+	return decoder{bufio.NewReader(io.NewSectionReader(r, 0, math.MaxInt64))}
+}
+
+func (d decoder) readVarint() (uint64, error) {
+	v, err := binary.ReadUvarint(d)
+	return v, unexpectedEOF(err)
+}
+
+func (d decoder) readZigZag() (int64, error) {
+	v, err := d.readVarint()
+	return int64(v>>1) ^ -int64(v&1), err
+}
+
+// readBinary reads a length-prefixed byte string. Memory is allocated as
+// data arrives so that a corrupt length cannot force a large allocation.
+func (d decoder) readBinary() ([]byte, error) {
+	n, err := d.readVarint()
+	if err != nil {
+		return nil, err
+	}
+	if n > math.MaxInt32 {
+		return nil, fmt.Errorf("binary length %d is too large", n)
+	}
+	var buf bytes.Buffer
+	if _, err := io.CopyN(&buf, d, int64(n)); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (d decoder) readValue(t wire.Type) (wire.Value, error) {
+	switch t {
+	case wire.TBool:
+		b, err := d.ReadByte()
+		return wire.NewValueBool(b == _booleanTrue), unexpectedEOF(err)
+	case wire.TI8:
+		b, err := d.ReadByte()
+		return wire.NewValueI8(int8(b)), unexpectedEOF(err)
+	case wire.TI16:
+		v, err := d.readZigZag()
+		return wire.NewValueI16(int16(v)), err
+	case wire.TI32:
+		v, err := d.readZigZag()
+		return wire.NewValueI32(int32(v)), err
+	case wire.TI64:
+		v, err := d.readZigZag()
+		return wire.NewValueI64(v), err
+	case wire.TDouble:
+		var b [8]byte
+		if _, err := io.ReadFull(d, b[:]); err != nil {
+			return wire.Value{}, unexpectedEOF(err)
+		}
+		return wire.NewValueDouble(math.Float64frombits(binary.LittleEndian.Uint64(b[:]))), nil
+	case wire.TBinary:
+		b, err := d.readBinary()
+		return wire.NewValueBinary(b), err
+	case wire.TStruct:
+		s, err := d.readStruct()
+		return wire.NewValueStruct(s), err
+	case wire.TList:
+		l, err := d.readList()
+		return wire.NewValueList(l), err
+	case wire.TSet:
+		l, err := d.readList()
+		return wire.NewValueSet(l), err
+	case wire.TMap:
+		m, err := d.readMap()
+		return wire.NewValueMap(m), err
+	default:
+		return wire.Value{}, fmt.Errorf("unknown thrift type %v", t)
+	}
+}
+
+func (d decoder) readStruct() (wire.Struct, error) {
+	var (
+		fields []wire.Field
+		lastID int16
+	)
+	for {
+		header, err := d.ReadByte()
+		if err != nil {
+			return wire.Struct{}, unexpectedEOF(err)
+		}
+		if header == _stop {
+			return wire.Struct{Fields: fields}, nil
+		}
+
+		id := lastID + int16(header>>4)
+		if header>>4 == 0 {
+			v, err := d.readZigZag()
+			if err != nil {
+				return wire.Struct{}, err
+			}
+			id = int16(v)
+		}
+		lastID = id
+
+		var value wire.Value
+		switch ct := header & 0x0f; ct {
+		case _booleanTrue:
+			value = wire.NewValueBool(true)
+		case _booleanFalse:
+			value = wire.NewValueBool(false)
+		default:
+			t, err := toWireType(ct)
+			if err != nil {
+				return wire.Struct{}, err
+			}
+			if value, err = d.readValue(t); err != nil {
+				return wire.Struct{}, err
+			}
+		}
+		fields = append(fields, wire.Field{ID: id, Value: value})
+	}
+}
+
+func (d decoder) readList() (wire.ValueList, error) {
+	header, err := d.ReadByte()
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	t, err := toWireType(header & 0x0f)
+	if err != nil {
+		return nil, err
+	}
+	size := uint64(header >> 4)
+	if size == 0x0f {
+		if size, err = d.readVarint(); err != nil {
+			return nil, err
+		}
+	}
+
+	var values []wire.Value
+	for i := uint64(0); i < size; i++ {
+		v, err := d.readValue(t)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return wire.ValueListFromSlice(t, values), nil
+}
+
+// readMap reads a map. Empty maps do not carry their key and value types in
+// the compact protocol, so they are read as maps of binary to binary.
+func (d decoder) readMap() (wire.MapItemList, error) {
+	size, err := d.readVarint()
+	if err != nil {
+		return nil, err
+	}
+	if size == 0 {
+		return wire.MapItemListFromSlice(wire.TBinary, wire.TBinary, nil), nil
+	}
+
+	types, err := d.ReadByte()
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	kt, err := toWireType(types >> 4)
+	if err != nil {
+		return nil, err
+	}
+	vt, err := toWireType(types & 0x0f)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []wire.MapItem
+	for i := uint64(0); i < size; i++ {
+		k, err := d.readValue(kt)
+		if err != nil {
+			return nil, err
+		}
+		v, err := d.readValue(vt)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, wire.MapItem{Key: k, Value: v})
+	}
+	return wire.MapItemListFromSlice(kt, vt, items), nil
+}
+
+// unexpectedEOF reports running out of data in the middle of a value as
+// io.ErrUnexpectedEOF.
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
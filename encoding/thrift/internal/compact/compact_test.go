@@ -0,0 +1,176 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package compact
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/thriftrw/wire"
+)
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		desc string
+		give wire.Value
+	}{
+		{"bool", wire.NewValueBool(true)},
+		{"i8", wire.NewValueI8(-42)},
+		{"i16", wire.NewValueI16(math.MinInt16)},
+		{"i32", wire.NewValueI32(math.MaxInt32)},
+		{"i64", wire.NewValueI64(math.MinInt64)},
+		{"double", wire.NewValueDouble(3.14)},
+		{"binary", wire.NewValueBinary([]byte("hello"))},
+		{
+			"struct",
+			wire.NewValueStruct(wire.Struct{Fields: []wire.Field{
+				{ID: 1, Value: wire.NewValueBool(true)},
+				{ID: 2, Value: wire.NewValueBool(false)},
+				{ID: 3, Value: wire.NewValueI32(-1)},
+				// Field ID gaps larger than 15 and going backwards use the
+				// long form header.
+				{ID: 100, Value: wire.NewValueBinary([]byte("far"))},
+				{ID: 4, Value: wire.NewValueI64(1 << 40)},
+				{ID: -1, Value: wire.NewValueBool(true)},
+				{ID: 5, Value: wire.NewValueStruct(wire.Struct{Fields: []wire.Field{
+					{ID: 1, Value: wire.NewValueDouble(-1)},
+				}})},
+			}}),
+		},
+		{
+			"short list",
+			wire.NewValueList(wire.ValueListFromSlice(wire.TBool, []wire.Value{
+				wire.NewValueBool(true),
+				wire.NewValueBool(false),
+			})),
+		},
+		{
+			"long list",
+			wire.NewValueList(wire.ValueListFromSlice(wire.TI32, func() []wire.Value {
+				values := make([]wire.Value, 100)
+				for i := range values {
+					values[i] = wire.NewValueI32(int32(i))
+				}
+				return values
+			}())),
+		},
+		{
+			"set",
+			wire.NewValueSet(wire.ValueListFromSlice(wire.TBinary, []wire.Value{
+				wire.NewValueBinary([]byte("a")),
+				wire.NewValueBinary([]byte("b")),
+			})),
+		},
+		{
+			"map",
+			wire.NewValueMap(wire.MapItemListFromSlice(wire.TI16, wire.TList, []wire.MapItem{
+				{
+					Key:   wire.NewValueI16(1),
+					Value: wire.NewValueList(wire.ValueListFromSlice(wire.TI8, []wire.Value{wire.NewValueI8(1)})),
+				},
+			})),
+		},
+		{
+			"empty map",
+			wire.NewValueMap(wire.MapItemListFromSlice(wire.TBinary, wire.TBinary, nil)),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, Protocol.Encode(tt.give, &buf))
+
+			got, err := Protocol.Decode(bytes.NewReader(buf.Bytes()), tt.give.Type())
+			require.NoError(t, err)
+			assert.True(t, wire.ValuesAreEqual(tt.give, got), "expected %v, got %v", tt.give, got)
+		})
+	}
+}
+
+func TestEncodedBytes(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Protocol.Encode(wire.NewValueStruct(wire.Struct{Fields: []wire.Field{
+		{ID: 1, Value: wire.NewValueI32(1)},
+		{ID: 2, Value: wire.NewValueBool(true)},
+	}}), &buf))
+	assert.Equal(t, []byte{0x15, 0x02, 0x11, 0x00}, buf.Bytes())
+
+	buf.Reset()
+	require.NoError(t, Protocol.EncodeEnveloped(wire.Envelope{
+		Name:  "a",
+		Type:  wire.Call,
+		SeqID: 1,
+		Value: wire.NewValueStruct(wire.Struct{}),
+	}, &buf))
+	assert.Equal(t, []byte{0x82, 0x21, 0x01, 0x01, 'a', 0x00}, buf.Bytes())
+}
+
+func TestEnvelopeRoundTrip(t *testing.T) {
+	give := wire.Envelope{
+		Name:  "someMethod",
+		Type:  wire.Exception,
+		SeqID: -1,
+		Value: wire.NewValueStruct(wire.Struct{Fields: []wire.Field{
+			{ID: 1, Value: wire.NewValueBinary([]byte("great sadness"))},
+		}}),
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, EnvelopeResponder{Name: give.Name, SeqID: give.SeqID}.EncodeResponse(give.Value, give.Type, &buf))
+
+	got, err := Protocol.DecodeEnveloped(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, give.Name, got.Name)
+	assert.Equal(t, give.Type, got.Type)
+	assert.Equal(t, give.SeqID, got.SeqID)
+	assert.True(t, wire.ValuesAreEqual(give.Value, got.Value))
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		desc    string
+		give    []byte
+		wantErr string
+	}{
+		{"truncated struct", []byte{0x15}, io.ErrUnexpectedEOF.Error()},
+		{"truncated binary", []byte{0x18, 0x05, 'a'}, io.ErrUnexpectedEOF.Error()},
+		{"unknown type", []byte{0x1d}, "unknown compact type 13"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := Protocol.Decode(bytes.NewReader(tt.give), wire.TStruct)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+
+	t.Run("not compact envelope", func(t *testing.T) {
+		_, err := Protocol.DecodeEnveloped(bytes.NewReader([]byte{0x80, 0x01, 0x00, 0x01}))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "expected compact protocol id")
+	})
+}
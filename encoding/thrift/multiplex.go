@@ -21,11 +21,19 @@
 package thrift
 
 import (
+	"bytes"
+	"context"
+	"fmt"
 	"io"
 	"strings"
 
 	"go.uber.org/thriftrw/protocol"
 	"go.uber.org/thriftrw/wire"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/bufferpool"
+	"go.uber.org/yarpc/pkg/errors"
+	"go.uber.org/yarpc/pkg/procedure"
+	"go.uber.org/yarpc/yarpcerrors"
 )
 
 // multiplexedOutboundProtocol is a Protocol for outbound requests that adds
@@ -48,3 +56,147 @@ func (m multiplexedOutboundProtocol) DecodeEnveloped(r io.ReaderAt) (wire.Envelo
 	e.Name = strings.TrimPrefix(e.Name, m.Service+":")
 	return e, err
 }
+
+// MultiplexedService groups the procedures of a single Thrift service under
+// the name that Apache Thrift's TMultiplexedProtocol clients use for it.
+type MultiplexedService struct {
+	// Name of the service in multiplexed envelopes.
+	Name string
+
+	// Procedures of the service, as built by the generated server package.
+	// These must be registered with the Enveloped option.
+	Procedures []transport.Procedure
+}
+
+// Multiplex builds a procedure with the given name which dispatches
+// requests to multiple Thrift services. This allows a single inbound
+// endpoint to serve Apache Thrift clients which use TMultiplexedProtocol.
+//
+// Requests must be enveloped and the envelope name must be in the form
+// "Service:method", where Service is the name of a MultiplexedService. The
+// service name is stripped from the envelope before the request is handed
+// to the matching procedure, and the response carries the bare method name,
+// as TMultiplexedProcessor does.
+//
+// 	dispatcher.Register(thrift.Multiplex("thrift",
+// 		thrift.MultiplexedService{
+// 			Name:       "KeyValue",
+// 			Procedures: keyvalueserver.New(kvHandler, thrift.Enveloped),
+// 		},
+// 		thrift.MultiplexedService{
+// 			Name:       "Admin",
+// 			Procedures: adminserver.New(adminHandler, thrift.Enveloped),
+// 		},
+// 	))
+//
+// Envelopes are decoded with the binary protocol unless the Protocol option
+// is given. Multiplex panics if two procedures share a multiplexed name.
+func Multiplex(name string, services ...MultiplexedService) []transport.Procedure {
+	return MultiplexWithOptions(name, services)
+}
+
+// MultiplexWithOptions is Multiplex with RegisterOptions, such as Protocol,
+// controlling how the envelopes of multiplexed requests are read.
+func MultiplexWithOptions(name string, services []MultiplexedService, opts ...RegisterOption) []transport.Procedure {
+	var rc registerConfig
+	for _, opt := range opts {
+		opt.applyRegisterOption(&rc)
+	}
+
+	proto := protocol.Binary
+	if rc.Protocol != nil {
+		proto = rc.Protocol
+	}
+
+	h := multiplexedHandler{
+		proto:      proto,
+		procedures: make(map[string]multiplexedProcedure),
+	}
+	for _, s := range services {
+		for _, p := range s.Procedures {
+			if p.Encoding != Encoding {
+				continue
+			}
+
+			_, method := procedure.FromName(p.Name)
+			key := s.Name + ":" + method
+			if _, ok := h.procedures[key]; ok {
+				panic(fmt.Sprintf("multiple procedures found for multiplexed name %q", key))
+			}
+			h.procedures[key] = multiplexedProcedure{
+				Name:        p.Name,
+				Method:      method,
+				HandlerSpec: p.HandlerSpec,
+			}
+		}
+	}
+
+	return []transport.Procedure{
+		{
+			Name:        name,
+			HandlerSpec: transport.NewUnaryHandlerSpec(h),
+			Encoding:    Encoding,
+		},
+	}
+}
+
+type multiplexedProcedure struct {
+	// Name of the procedure requests are dispatched to.
+	Name string
+
+	// Method name to use in the envelope handed to the procedure.
+	Method string
+
+	HandlerSpec transport.HandlerSpec
+}
+
+// multiplexedHandler dispatches multiplexed requests to the procedure named
+// in their envelope.
+type multiplexedHandler struct {
+	proto      protocol.Protocol
+	procedures map[string]multiplexedProcedure
+}
+
+func (h multiplexedHandler) Handle(ctx context.Context, treq *transport.Request, rw transport.ResponseWriter) error {
+	if err := errors.ExpectEncodings(treq, Encoding); err != nil {
+		return err
+	}
+
+	buf := bufferpool.Get()
+	defer bufferpool.Put(buf)
+	if _, err := buf.ReadFrom(treq.Body); err != nil {
+		return err
+	}
+
+	envelope, err := h.proto.DecodeEnveloped(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		return errors.RequestBodyDecodeError(treq, err)
+	}
+
+	p, ok := h.procedures[envelope.Name]
+	if !ok {
+		return yarpcerrors.Newf(yarpcerrors.CodeUnimplemented,
+			"unrecognized multiplexed procedure %q for procedure %q of service %q",
+			envelope.Name, treq.Procedure, treq.Service)
+	}
+
+	envelope.Name = p.Method
+	var body bytes.Buffer
+	if err := h.proto.EncodeEnveloped(envelope, &body); err != nil {
+		return errors.RequestBodyDecodeError(treq, err)
+	}
+
+	req := *treq
+	req.Procedure = p.Name
+	req.Body = &body
+
+	switch p.HandlerSpec.Type() {
+	case transport.Unary:
+		return p.HandlerSpec.Unary().Handle(ctx, &req, rw)
+	case transport.Oneway:
+		return p.HandlerSpec.Oneway().HandleOneway(ctx, &req)
+	default:
+		return yarpcerrors.Newf(yarpcerrors.CodeUnimplemented,
+			"procedure %q does not support %v requests", p.Name, p.HandlerSpec.Type())
+	}
+}
@@ -22,12 +22,19 @@ package thrift
 
 import (
 	"bytes"
+	"context"
 	"testing"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/thriftrw/protocol"
 	"go.uber.org/thriftrw/thrifttest"
 	"go.uber.org/thriftrw/wire"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/internal/testtime"
+	"go.uber.org/yarpc/yarpcerrors"
 )
 
 func TestMultiplexedEncode(t *testing.T) {
@@ -115,3 +122,112 @@ func TestMultiplexedDecode(t *testing.T) {
 		}
 	}
 }
+
+func TestMultiplexInbound(t *testing.T) {
+	var (
+		unaryCalls  []string
+		onewayCalls []string
+	)
+	unary := func(name string) UnaryHandler {
+		return func(context.Context, wire.Value) (Response, error) {
+			unaryCalls = append(unaryCalls, name)
+			return Response{Body: fakeEnveloper(wire.Reply)}, nil
+		}
+	}
+
+	procs := Multiplex("thrift",
+		MultiplexedService{
+			Name: "KeyValue",
+			Procedures: BuildProcedures(Service{
+				Name: "KeyValue",
+				Methods: []Method{
+					{Name: "someMethod", HandlerSpec: HandlerSpec{Type: transport.Unary, Unary: unary("KeyValue")}},
+				},
+			}, Enveloped),
+		},
+		MultiplexedService{
+			// The multiplexed name need not match the Thrift service name.
+			Name: "Other",
+			Procedures: BuildProcedures(Service{
+				Name: "Admin",
+				Methods: []Method{
+					{Name: "someMethod", HandlerSpec: HandlerSpec{Type: transport.Unary, Unary: unary("Admin")}},
+					{
+						Name: "fire",
+						HandlerSpec: HandlerSpec{
+							Type: transport.Oneway,
+							Oneway: func(context.Context, wire.Value) error {
+								onewayCalls = append(onewayCalls, "fire")
+								return nil
+							},
+						},
+					},
+				},
+			}, Enveloped),
+		},
+	)
+	require.Len(t, procs, 1)
+	assert.Equal(t, "thrift", procs[0].Name)
+	assert.Equal(t, Encoding, procs[0].Encoding)
+	handler := procs[0].HandlerSpec.Unary()
+
+	call := func(name string, envelopeType wire.EnvelopeType) (*transporttest.FakeResponseWriter, error) {
+		var body bytes.Buffer
+		require.NoError(t, protocol.Binary.EncodeEnveloped(wire.Envelope{
+			Name:  name,
+			Type:  envelopeType,
+			SeqID: 42,
+			Value: wire.NewValueStruct(wire.Struct{}),
+		}, &body))
+
+		ctx, cancel := context.WithTimeout(context.Background(), testtime.Second)
+		defer cancel()
+
+		rw := new(transporttest.FakeResponseWriter)
+		err := handler.Handle(ctx, &transport.Request{
+			Caller:    "caller",
+			Service:   "service",
+			Encoding:  Encoding,
+			Procedure: "thrift",
+			Body:      &body,
+		}, rw)
+		return rw, err
+	}
+
+	t.Run("unary", func(t *testing.T) {
+		rw, err := call("Other:someMethod", wire.Call)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Admin"}, unaryCalls)
+
+		// The response envelope carries the bare method name.
+		envelope, err := protocol.Binary.DecodeEnveloped(bytes.NewReader(rw.Body.Bytes()))
+		require.NoError(t, err)
+		assert.Equal(t, "someMethod", envelope.Name)
+		assert.Equal(t, wire.Reply, envelope.Type)
+		assert.Equal(t, int32(42), envelope.SeqID)
+	})
+
+	t.Run("oneway", func(t *testing.T) {
+		_, err := call("Other:fire", wire.OneWay)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"fire"}, onewayCalls)
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		for _, name := range []string{"Admin:someMethod", "someMethod", "KeyValue:fire"} {
+			_, err := call(name, wire.Call)
+			assert.Equal(t, yarpcerrors.CodeUnimplemented, yarpcerrors.FromError(err).Code(), "name: %q", name)
+		}
+	})
+}
+
+func TestMultiplexDuplicateNames(t *testing.T) {
+	service := MultiplexedService{
+		Name: "KeyValue",
+		Procedures: BuildProcedures(Service{
+			Name:    "KeyValue",
+			Methods: []Method{{Name: "someMethod", HandlerSpec: HandlerSpec{Type: transport.Unary}}},
+		}),
+	}
+	assert.Panics(t, func() { Multiplex("thrift", service, service) })
+}
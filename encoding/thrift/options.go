@@ -26,6 +26,7 @@ type clientConfig struct {
 	Protocol    protocol.Protocol
 	Enveloping  bool
 	Multiplexed bool
+	Compact     bool
 }
 
 // ClientOption customizes the behavior of a Thrift client.
@@ -36,6 +37,7 @@ type ClientOption interface {
 type registerConfig struct {
 	Protocol   protocol.Protocol
 	Enveloping bool
+	Compact    bool
 }
 
 // RegisterOption customizes the behavior of a Thrift handler during
@@ -117,3 +119,37 @@ func (p protocolOption) applyRegisterOption(c *registerConfig) {
 func Protocol(p protocol.Protocol) Option {
 	return protocolOption{Protocol: p}
 }
+
+// Compact is an option that specifies that Thrift payloads should use the
+// compact protocol, which is considerably smaller than the binary protocol
+// for structs with many fields or integers.
+//
+// Requests made with the compact protocol use the "thrift+compact" encoding
+// rather than "thrift", so the server must opt in as well.
+//
+// It may be specified on the client side when the client is constructed.
+// Clients with this option send and expect compact payloads, and take
+// precedence over the Protocol option.
+//
+// 	client := myserviceclient.New(clientConfig, thrift.Compact)
+//
+// It may be specified on the server side when the handler is registered.
+// Handlers with this option accept both compact and binary requests and
+// respond using the protocol of the request, so clients can be migrated one
+// at a time.
+//
+// 	dispatcher.Register(myserviceserver.New(handler, thrift.Compact))
+//
+// Enveloping is supported with the compact protocol and is controlled by the
+// Enveloped option as usual.
+var Compact Option = compactOption{}
+
+type compactOption struct{}
+
+func (compactOption) applyClientOption(c *clientConfig) {
+	c.Compact = true
+}
+
+func (compactOption) applyRegisterOption(c *registerConfig) {
+	c.Compact = true
+}
@@ -32,6 +32,7 @@ import (
 	encodingapi "go.uber.org/yarpc/api/encoding"
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/encoding/thrift/internal"
+	"go.uber.org/yarpc/encoding/thrift/internal/compact"
 	"go.uber.org/yarpc/pkg/encoding"
 	"go.uber.org/yarpc/pkg/errors"
 	"go.uber.org/yarpc/pkg/procedure"
@@ -84,6 +85,12 @@ func New(c Config, opts ...ClientOption) Client {
 		p = cc.Protocol
 	}
 
+	enc := Encoding
+	if cc.Compact {
+		p = compact.Protocol
+		enc = CompactEncoding
+	}
+
 	if cc.Multiplexed {
 		p = multiplexedOutboundProtocol{
 			Protocol: p,
@@ -95,6 +102,7 @@ func New(c Config, opts ...ClientOption) Client {
 		p:             p,
 		cc:            c.ClientConfig,
 		thriftService: c.Service,
+		encoding:      enc,
 		Enveloping:    cc.Enveloping,
 	}
 }
@@ -105,6 +113,7 @@ type thriftClient struct {
 
 	// name of the Thrift service
 	thriftService string
+	encoding      transport.Encoding
 	Enveloping    bool
 }
 
@@ -204,7 +213,7 @@ func (c thriftClient) buildTransportRequest(reqBody envelope.Enveloper) (*transp
 	treq := transport.Request{
 		Caller:    c.cc.Caller(),
 		Service:   c.cc.Service(),
-		Encoding:  Encoding,
+		Encoding:  c.encoding,
 		Procedure: procedure.ToName(c.thriftService, reqBody.MethodName()),
 	}
 
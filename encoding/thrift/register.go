@@ -92,7 +92,12 @@ func BuildProcedures(s Service, opts ...RegisterOption) []transport.Procedure {
 		proto = rc.Protocol
 	}
 
-	rs := make([]transport.Procedure, 0, len(s.Methods))
+	encodings := []transport.Encoding{Encoding}
+	if rc.Compact {
+		encodings = append(encodings, CompactEncoding)
+	}
+
+	rs := make([]transport.Procedure, 0, len(s.Methods)*len(encodings))
 
 	for _, method := range s.Methods {
 		var spec transport.HandlerSpec
@@ -102,23 +107,27 @@ func BuildProcedures(s Service, opts ...RegisterOption) []transport.Procedure {
 				UnaryHandler: method.HandlerSpec.Unary,
 				Protocol:     proto,
 				Enveloping:   rc.Enveloping,
+				Compact:      rc.Compact,
 			})
 		case transport.Oneway:
 			spec = transport.NewOnewayHandlerSpec(thriftOnewayHandler{
 				OnewayHandler: method.HandlerSpec.Oneway,
 				Protocol:      proto,
 				Enveloping:    rc.Enveloping,
+				Compact:       rc.Compact,
 			})
 		default:
 			panic(fmt.Sprintf("Invalid handler type for %T", method))
 		}
 
-		rs = append(rs, transport.Procedure{
-			Name:        procedure.ToName(s.Name, method.Name),
-			HandlerSpec: spec,
-			Encoding:    Encoding,
-			Signature:   method.Signature,
-		})
+		for _, enc := range encodings {
+			rs = append(rs, transport.Procedure{
+				Name:        procedure.ToName(s.Name, method.Name),
+				HandlerSpec: spec,
+				Encoding:    enc,
+				Signature:   method.Signature,
+			})
+		}
 	}
 	return rs
 }
@@ -0,0 +1,96 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package avro provides the Avro encoding for YARPC.
+//
+// Request and response bodies are Avro binary data. The ID of the schema a
+// body was written with is sent in the avro-schema-id header, and the schema
+// itself is resolved through a schema registry, so that readers can decode
+// bodies written with older or newer versions of a schema. This is the
+// model used by Avro-centric data platforms, such as those built around a
+// Confluent-style schema registry.
+//
+// Avro serialization and the schema registry are pluggable: a Codec encodes
+// and decodes values and a Registry stores schemas. Values are Go types
+// which report their own schema, as generated Avro types do.
+//
+// 	client := avro.New(dispatcher.ClientConfig("users"), codec, registry)
+// 	var user User
+// 	err := client.Call(ctx, "getUser", &GetUserRequest{ID: "1"}, &user)
+//
+// Handlers are registered much like JSON handlers,
+//
+// 	dispatcher.Register(avro.Procedure("getUser", codec, registry, getUser))
+//
+// Schemas are registered under the subjects "$procedure-request" and
+// "$procedure-response".
+//
+// This package is experimental. Breaking changes may be made between minor
+// releases.
+package avro
+
+import (
+	"context"
+
+	"go.uber.org/yarpc/api/transport"
+)
+
+const (
+	// Encoding is the name of this encoding.
+	Encoding transport.Encoding = "avro"
+
+	// SchemaIDHeader is the header holding the registry ID of the schema
+	// with which a request or response body was written.
+	SchemaIDHeader = "avro-schema-id"
+)
+
+// Value is a Go value which may be sent with the Avro encoding.
+type Value interface {
+	// Schema returns the Avro schema of this value as JSON.
+	Schema() string
+}
+
+// Codec converts values to and from Avro binary data.
+type Codec interface {
+	// Marshal encodes the value using its own schema.
+	Marshal(v Value) ([]byte, error)
+
+	// Unmarshal decodes data written with the given writer schema into v,
+	// resolving it against the schema of v.
+	Unmarshal(writerSchema string, data []byte, v Value) error
+}
+
+// Registry is a client for a schema registry.
+type Registry interface {
+	// Register returns the ID of the schema under the given subject,
+	// registering it if it is new.
+	Register(ctx context.Context, subject, schema string) (int, error)
+
+	// Schema returns the schema with the given ID.
+	Schema(ctx context.Context, id int) (string, error)
+}
+
+func requestSubject(procedure string) string {
+	return procedure + "-request"
+}
+
+func responseSubject(procedure string) string {
+	return procedure + "-response"
+}
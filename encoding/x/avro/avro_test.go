@@ -0,0 +1,286 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package avro
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/internal/clientconfig"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+const (
+	_userV1 = `{"type":"record","name":"User","fields":[{"name":"name","type":"string"}]}`
+	_userV2 = `{"type":"record","name":"User","fields":[{"name":"name","type":"string"},{"name":"email","type":"string","default":""}]}`
+)
+
+type userV1 struct {
+	Name string `json:"name"`
+}
+
+func (*userV1) Schema() string { return _userV1 }
+
+type userV2 struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+func (*userV2) Schema() string { return _userV2 }
+
+// jsonCodec stands in for an Avro library. It records the writer schemas it
+// was asked to resolve.
+type jsonCodec struct {
+	sync.Mutex
+	writerSchemas []string
+}
+
+func (c *jsonCodec) Marshal(v Value) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (c *jsonCodec) Unmarshal(writerSchema string, data []byte, v Value) error {
+	c.Lock()
+	c.writerSchemas = append(c.writerSchemas, writerSchema)
+	c.Unlock()
+	return json.Unmarshal(data, v)
+}
+
+type fakeRegistry struct {
+	sync.Mutex
+	subjects  map[string]string
+	schemas   []string
+	lookups   int
+	unhealthy bool
+}
+
+func newFakeRegistry() *fakeRegistry {
+	return &fakeRegistry{subjects: make(map[string]string)}
+}
+
+func (r *fakeRegistry) Register(_ context.Context, subject, schema string) (int, error) {
+	r.Lock()
+	defer r.Unlock()
+	if r.unhealthy {
+		return 0, errors.New("registry unavailable")
+	}
+	r.subjects[subject] = schema
+	for id, s := range r.schemas {
+		if s == schema {
+			return id, nil
+		}
+	}
+	r.schemas = append(r.schemas, schema)
+	return len(r.schemas) - 1, nil
+}
+
+func (r *fakeRegistry) Schema(_ context.Context, id int) (string, error) {
+	r.Lock()
+	defer r.Unlock()
+	r.lookups++
+	if id < 0 || id >= len(r.schemas) {
+		return "", errors.New("schema not found")
+	}
+	return r.schemas[id], nil
+}
+
+// loopbackOutbound calls handlers from a router directly.
+type loopbackOutbound struct {
+	router transport.Router
+}
+
+func (o loopbackOutbound) Transports() []transport.Transport { return nil }
+func (o loopbackOutbound) Start() error                      { return nil }
+func (o loopbackOutbound) Stop() error                       { return nil }
+func (o loopbackOutbound) IsRunning() bool                   { return true }
+
+func (o loopbackOutbound) Call(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	spec, err := o.router.Choose(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	resw := new(transporttest.FakeResponseWriter)
+	err = spec.Unary().Handle(ctx, req, resw)
+	return &transport.Response{
+		Headers:          resw.Headers,
+		Body:             ioutil.NopCloser(&resw.Body),
+		ApplicationError: resw.IsApplicationError,
+	}, err
+}
+
+func (o loopbackOutbound) CallOneway(ctx context.Context, req *transport.Request) (transport.Ack, error) {
+	spec, err := o.router.Choose(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return nil, spec.Oneway().HandleOneway(ctx, req)
+}
+
+func newClientConfig(procedures []transport.Procedure) transport.ClientConfig {
+	router := yarpc.NewMapRouter("service")
+	router.Register(procedures)
+	out := loopbackOutbound{router: router}
+	return clientconfig.MultiOutbound("caller", "service", transport.Outbounds{
+		ServiceName: "service",
+		Unary:       out,
+		Oneway:      out,
+	})
+}
+
+func TestRoundTripResolvesSchemas(t *testing.T) {
+	codec := &jsonCodec{}
+	registry := newFakeRegistry()
+
+	// The server reads and writes the newer schema; the client the older.
+	procedures := Procedure("echo", codec, registry, func(_ context.Context, u *userV2) (*userV2, error) {
+		assert.Equal(t, "", u.Email)
+		return &userV2{Name: u.Name, Email: u.Name + "@example.com"}, nil
+	})
+	client := New(newClientConfig(procedures), codec, registry)
+
+	for i := 0; i < 2; i++ {
+		var res userV1
+		require.NoError(t, client.Call(context.Background(), "echo", &userV1{Name: "alice"}, &res))
+		assert.Equal(t, "alice", res.Name)
+	}
+
+	assert.Equal(t, map[string]string{
+		"echo-request":  _userV1,
+		"echo-response": _userV2,
+	}, registry.subjects)
+	assert.Equal(t, []string{_userV1, _userV2, _userV1, _userV2}, codec.writerSchemas)
+	// The client and server each look up the schema written by the other
+	// once.
+	assert.Equal(t, 2, registry.lookups)
+}
+
+func TestRoundTripLooksUpUnknownSchemas(t *testing.T) {
+	codec := &jsonCodec{}
+	registry := newFakeRegistry()
+
+	procedures := Procedure("echo", codec, registry, func(_ context.Context, u *userV1) (*userV1, error) {
+		return u, nil
+	})
+	// Separate clients have separate caches, so the server must look up
+	// their schema once.
+	cc := newClientConfig(procedures)
+	for i := 0; i < 3; i++ {
+		var res userV1
+		require.NoError(t, New(cc, codec, registry).Call(context.Background(), "echo", &userV1{Name: "bob"}, &res))
+	}
+	assert.Equal(t, 1, registry.lookups)
+}
+
+func TestOneway(t *testing.T) {
+	codec := &jsonCodec{}
+	registry := newFakeRegistry()
+
+	var got string
+	procedures := OnewayProcedure("fire", codec, registry, func(_ context.Context, u *userV1) error {
+		got = u.Name
+		return nil
+	})
+	client := New(newClientConfig(procedures), codec, registry)
+
+	_, err := client.CallOneway(context.Background(), "fire", &userV1{Name: "carol"})
+	require.NoError(t, err)
+	assert.Equal(t, "carol", got)
+}
+
+func TestApplicationError(t *testing.T) {
+	codec := &jsonCodec{}
+	registry := newFakeRegistry()
+
+	procedures := Procedure("fail", codec, registry, func(context.Context, *userV1) (*userV1, error) {
+		return nil, errors.New("great sadness")
+	})
+	client := New(newClientConfig(procedures), codec, registry)
+
+	var res userV1
+	assert.EqualError(t, client.Call(context.Background(), "fail", &userV1{}, &res), "great sadness")
+}
+
+func TestRegistryUnavailable(t *testing.T) {
+	codec := &jsonCodec{}
+	registry := newFakeRegistry()
+	registry.unhealthy = true
+
+	client := New(newClientConfig(nil), codec, registry)
+	var res userV1
+	err := client.Call(context.Background(), "echo", &userV1{}, &res)
+	assert.Equal(t, yarpcerrors.CodeUnavailable, yarpcerrors.FromError(err).Code())
+}
+
+func TestMissingSchemaID(t *testing.T) {
+	codec := &jsonCodec{}
+	procedures := Procedure("echo", codec, newFakeRegistry(), func(_ context.Context, u *userV1) (*userV1, error) {
+		return u, nil
+	})
+
+	err := procedures[0].HandlerSpec.Unary().Handle(context.Background(), &transport.Request{
+		Caller:    "caller",
+		Service:   "service",
+		Procedure: "echo",
+		Encoding:  Encoding,
+		Body:      ioutil.NopCloser(nil),
+	}, new(transporttest.FakeResponseWriter))
+	assert.True(t, yarpcerrors.IsInvalidArgument(err))
+	assert.Contains(t, err.Error(), SchemaIDHeader)
+}
+
+func TestInvalidHandlerSignatures(t *testing.T) {
+	codec := &jsonCodec{}
+	registry := newFakeRegistry()
+
+	tests := []struct {
+		desc    string
+		handler interface{}
+		oneway  bool
+	}{
+		{desc: "not a function", handler: 42},
+		{desc: "too few arguments", handler: func(context.Context) (*userV1, error) { return nil, nil }},
+		{desc: "no context", handler: func(string, *userV1) (*userV1, error) { return nil, nil }},
+		{desc: "not a value", handler: func(context.Context, *struct{}) (*userV1, error) { return nil, nil }},
+		{desc: "bad result", handler: func(context.Context, *userV1) (string, error) { return "", nil }},
+		{desc: "no error", handler: func(context.Context, *userV1) (*userV1, string) { return nil, "" }},
+		{desc: "oneway result", handler: func(context.Context, *userV1) (*userV1, error) { return nil, nil }, oneway: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert.Panics(t, func() {
+				if tt.oneway {
+					OnewayProcedure("foo", codec, registry, tt.handler)
+				} else {
+					Procedure("foo", codec, registry, tt.handler)
+				}
+			})
+		})
+	}
+}
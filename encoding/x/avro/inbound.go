@@ -0,0 +1,145 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package avro
+
+import (
+	"context"
+	"io/ioutil"
+	"reflect"
+	"strconv"
+
+	encodingapi "go.uber.org/yarpc/api/encoding"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/pkg/errors"
+)
+
+// avroHandler adapts a user-provided high-level handler into a
+// transport-level Handler.
+//
+// The wrapped function must already be in the correct format:
+//
+// 	f(ctx context.Context, body $reqBody) ($resBody, error)
+type avroHandler struct {
+	procedure   string
+	codec       Codec
+	schemas     *schemaCache
+	reqBodyType reflect.Type
+	handler     reflect.Value
+}
+
+func newAvroHandler(procedure string, codec Codec, registry Registry, reqBodyType reflect.Type, handler interface{}) avroHandler {
+	return avroHandler{
+		procedure:   procedure,
+		codec:       codec,
+		schemas:     newSchemaCache(registry),
+		reqBodyType: reqBodyType,
+		handler:     reflect.ValueOf(handler),
+	}
+}
+
+func (h avroHandler) Handle(ctx context.Context, treq *transport.Request, rw transport.ResponseWriter) error {
+	ctx, call, reqBody, err := h.readRequest(ctx, treq)
+	if err != nil {
+		return err
+	}
+
+	results := h.handler.Call([]reflect.Value{reflect.ValueOf(ctx), reqBody})
+
+	if err := call.WriteToResponse(rw); err != nil {
+		return err
+	}
+
+	// we want to return the appErr if it exists as this is what the other
+	// encodings do, so we deprioritize this error
+	var encodeErr error
+	if !results[0].IsNil() {
+		encodeErr = h.writeResponse(ctx, treq, rw, results[0].Interface().(Value))
+	}
+
+	if appErr, _ := results[1].Interface().(error); appErr != nil {
+		rw.SetApplicationError()
+		return appErr
+	}
+
+	return encodeErr
+}
+
+func (h avroHandler) HandleOneway(ctx context.Context, treq *transport.Request) error {
+	ctx, _, reqBody, err := h.readRequest(ctx, treq)
+	if err != nil {
+		return err
+	}
+
+	results := h.handler.Call([]reflect.Value{reflect.ValueOf(ctx), reqBody})
+
+	if err := results[0].Interface(); err != nil {
+		return err.(error)
+	}
+	return nil
+}
+
+// readRequest decodes the request body, resolving the schema it was
+// written with through the registry.
+func (h avroHandler) readRequest(ctx context.Context, treq *transport.Request) (context.Context, *encodingapi.InboundCall, reflect.Value, error) {
+	if err := errors.ExpectEncodings(treq, Encoding); err != nil {
+		return nil, nil, reflect.Value{}, err
+	}
+
+	ctx, call := encodingapi.NewInboundCall(ctx)
+	if err := call.ReadFromRequest(treq); err != nil {
+		return nil, nil, reflect.Value{}, err
+	}
+
+	id, err := schemaID(treq.Headers)
+	if err != nil {
+		return nil, nil, reflect.Value{}, errors.RequestBodyDecodeError(treq, err)
+	}
+	writerSchema, err := h.schemas.schema(ctx, id)
+	if err != nil {
+		return nil, nil, reflect.Value{}, err
+	}
+	data, err := ioutil.ReadAll(treq.Body)
+	if err != nil {
+		return nil, nil, reflect.Value{}, errors.RequestBodyDecodeError(treq, err)
+	}
+
+	reqBody := reflect.New(h.reqBodyType.Elem())
+	if err := h.codec.Unmarshal(writerSchema, data, reqBody.Interface().(Value)); err != nil {
+		return nil, nil, reflect.Value{}, errors.RequestBodyDecodeError(treq, err)
+	}
+	return ctx, call, reqBody, nil
+}
+
+// writeResponse encodes the response body and writes it along with the ID
+// of its schema.
+func (h avroHandler) writeResponse(ctx context.Context, treq *transport.Request, rw transport.ResponseWriter, resBody Value) error {
+	id, err := h.schemas.id(ctx, responseSubject(h.procedure), resBody.Schema())
+	if err != nil {
+		return err
+	}
+	data, err := h.codec.Marshal(resBody)
+	if err != nil {
+		return errors.ResponseBodyEncodeError(treq, err)
+	}
+	rw.AddHeaders(transport.NewHeaders().With(SchemaIDHeader, strconv.Itoa(id)))
+	_, err = rw.Write(data)
+	return err
+}
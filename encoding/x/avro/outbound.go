@@ -0,0 +1,146 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package avro
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"strconv"
+
+	"go.uber.org/yarpc"
+	encodingapi "go.uber.org/yarpc/api/encoding"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/pkg/encoding"
+	"go.uber.org/yarpc/pkg/errors"
+)
+
+// Client makes Avro requests to a single service.
+type Client interface {
+	// Call performs an outbound Avro request, decoding the response into
+	// resBodyOut.
+	Call(ctx context.Context, procedure string, reqBody Value, resBodyOut Value, opts ...yarpc.CallOption) error
+
+	// CallOneway performs an outbound oneway Avro request.
+	CallOneway(ctx context.Context, procedure string, reqBody Value, opts ...yarpc.CallOption) (transport.Ack, error)
+}
+
+// New builds a new Avro client which encodes values with the given codec
+// and resolves schemas with the given registry.
+func New(c transport.ClientConfig, codec Codec, registry Registry) Client {
+	return avroClient{cc: c, codec: codec, schemas: newSchemaCache(registry)}
+}
+
+type avroClient struct {
+	cc      transport.ClientConfig
+	codec   Codec
+	schemas *schemaCache
+}
+
+func (c avroClient) Call(ctx context.Context, procedure string, reqBody Value, resBodyOut Value, opts ...yarpc.CallOption) error {
+	call := encodingapi.NewOutboundCall(encoding.FromOptions(opts)...)
+	ctx, treq, err := c.buildRequest(ctx, call, procedure, reqBody)
+	if err != nil {
+		return err
+	}
+
+	tres, appErr := c.cc.GetUnaryOutbound().Call(ctx, treq)
+	if tres == nil {
+		return appErr
+	}
+
+	// we want to return the appErr if it exists as this is what the other
+	// encodings do, so we deprioritize this error
+	var decodeErr error
+	if _, err := call.ReadFromResponse(ctx, tres); err != nil {
+		decodeErr = err
+	}
+	if tres.Body != nil {
+		if err := c.decodeResponse(ctx, treq, tres, resBodyOut); err != nil && decodeErr == nil {
+			decodeErr = err
+		}
+		if err := tres.Body.Close(); err != nil && decodeErr == nil {
+			decodeErr = err
+		}
+	}
+
+	if appErr != nil {
+		return appErr
+	}
+	return decodeErr
+}
+
+func (c avroClient) CallOneway(ctx context.Context, procedure string, reqBody Value, opts ...yarpc.CallOption) (transport.Ack, error) {
+	call := encodingapi.NewOutboundCall(encoding.FromOptions(opts)...)
+	ctx, treq, err := c.buildRequest(ctx, call, procedure, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	return c.cc.GetOnewayOutbound().CallOneway(ctx, treq)
+}
+
+func (c avroClient) buildRequest(ctx context.Context, call *encodingapi.OutboundCall, procedure string, reqBody Value) (context.Context, *transport.Request, error) {
+	treq := &transport.Request{
+		Caller:    c.cc.Caller(),
+		Service:   c.cc.Service(),
+		Procedure: procedure,
+		Encoding:  Encoding,
+	}
+
+	ctx, err := call.WriteToRequest(ctx, treq)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	id, err := c.schemas.id(ctx, requestSubject(procedure), reqBody.Schema())
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := c.codec.Marshal(reqBody)
+	if err != nil {
+		return nil, nil, errors.RequestBodyEncodeError(treq, err)
+	}
+	treq.Headers = treq.Headers.With(SchemaIDHeader, strconv.Itoa(id))
+	treq.Body = bytes.NewReader(data)
+	return ctx, treq, nil
+}
+
+func (c avroClient) decodeResponse(ctx context.Context, treq *transport.Request, tres *transport.Response, resBodyOut Value) error {
+	data, err := ioutil.ReadAll(tres.Body)
+	if err != nil {
+		return errors.ResponseBodyDecodeError(treq, err)
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	id, err := schemaID(tres.Headers)
+	if err != nil {
+		return errors.ResponseBodyDecodeError(treq, err)
+	}
+	writerSchema, err := c.schemas.schema(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := c.codec.Unmarshal(writerSchema, data, resBodyOut); err != nil {
+		return errors.ResponseBodyDecodeError(treq, err)
+	}
+	return nil
+}
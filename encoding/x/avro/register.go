@@ -0,0 +1,133 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package avro
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"go.uber.org/yarpc/api/transport"
+)
+
+var (
+	_ctxType   = reflect.TypeOf((*context.Context)(nil)).Elem()
+	_errorType = reflect.TypeOf((*error)(nil)).Elem()
+	_valueType = reflect.TypeOf((*Value)(nil)).Elem()
+)
+
+// Procedure builds a Procedure from the given Avro handler. handler must be
+// a function with a signature similar to,
+//
+// 	f(ctx context.Context, body $reqBody) ($resBody, error)
+//
+// Where $reqBody and $resBody are pointers to structs which implement Value.
+func Procedure(name string, codec Codec, registry Registry, handler interface{}) []transport.Procedure {
+	reqBodyType := verifyUnarySignature(name, reflect.TypeOf(handler))
+	return []transport.Procedure{
+		{
+			Name:        name,
+			HandlerSpec: transport.NewUnaryHandlerSpec(newAvroHandler(name, codec, registry, reqBodyType, handler)),
+			Encoding:    Encoding,
+		},
+	}
+}
+
+// OnewayProcedure builds a Procedure from the given Avro handler. handler
+// must be a function with a signature similar to,
+//
+// 	f(ctx context.Context, body $reqBody) error
+//
+// Where $reqBody is a pointer to a struct which implements Value.
+func OnewayProcedure(name string, codec Codec, registry Registry, handler interface{}) []transport.Procedure {
+	reqBodyType := verifyOnewaySignature(name, reflect.TypeOf(handler))
+	return []transport.Procedure{
+		{
+			Name:        name,
+			HandlerSpec: transport.NewOnewayHandlerSpec(newAvroHandler(name, codec, registry, reqBodyType, handler)),
+			Encoding:    Encoding,
+		},
+	}
+}
+
+// verifyUnarySignature verifies that the given type matches what we expect
+// from Avro unary handlers and returns the request type.
+func verifyUnarySignature(n string, t reflect.Type) reflect.Type {
+	reqBodyType := verifyInputSignature(n, t)
+
+	if t.NumOut() != 2 {
+		panic(fmt.Sprintf("expected handler for %q to have 2 results but it had %v", n, t.NumOut()))
+	}
+	if t.Out(1) != _errorType {
+		panic(fmt.Sprintf("handler for %q must return error as its second result, not %v", n, t.Out(1)))
+	}
+	if !isValidValueType(t.Out(0)) {
+		panic(fmt.Sprintf(
+			"the first result of the handler for %q must be a struct pointer which implements avro.Value, and not: %v",
+			n, t.Out(0),
+		))
+	}
+	return reqBodyType
+}
+
+// verifyOnewaySignature verifies that the given type matches what we expect
+// from oneway Avro handlers and returns the request type.
+func verifyOnewaySignature(n string, t reflect.Type) reflect.Type {
+	reqBodyType := verifyInputSignature(n, t)
+
+	if t.NumOut() != 1 {
+		panic(fmt.Sprintf("expected handler for %q to have 1 result but it had %v", n, t.NumOut()))
+	}
+	if t.Out(0) != _errorType {
+		panic(fmt.Sprintf("the result of the handler for %q must be of type error, and not: %v", n, t.Out(0)))
+	}
+	return reqBodyType
+}
+
+// verifyInputSignature verifies that the given input argument types match
+// what we expect from Avro handlers and returns the request body type.
+func verifyInputSignature(n string, t reflect.Type) reflect.Type {
+	if t.Kind() != reflect.Func {
+		panic(fmt.Sprintf("handler for %q is not a function but a %v", n, t.Kind()))
+	}
+	if t.NumIn() != 2 {
+		panic(fmt.Sprintf("expected handler for %q to have 2 arguments but it had %v", n, t.NumIn()))
+	}
+	if t.In(0) != _ctxType {
+		panic(fmt.Sprintf(
+			"the first argument of the handler for %q must be of type context.Context, and not: %v",
+			n, t.In(0),
+		))
+	}
+
+	reqBodyType := t.In(1)
+	if !isValidValueType(reqBodyType) {
+		panic(fmt.Sprintf(
+			"the second argument of the handler for %q must be a struct pointer which implements avro.Value, and not: %v",
+			n, reqBodyType,
+		))
+	}
+	return reqBodyType
+}
+
+func isValidValueType(t reflect.Type) bool {
+	return t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct && t.Implements(_valueType)
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package avro
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// schemaCache caches the results of a Registry. Schemas are immutable once
+// registered, so entries never expire.
+type schemaCache struct {
+	registry Registry
+
+	mu      sync.RWMutex
+	ids     map[subjectSchema]int
+	schemas map[int]string
+}
+
+type subjectSchema struct {
+	subject string
+	schema  string
+}
+
+func newSchemaCache(registry Registry) *schemaCache {
+	return &schemaCache{
+		registry: registry,
+		ids:      make(map[subjectSchema]int),
+		schemas:  make(map[int]string),
+	}
+}
+
+// id returns the ID of the schema under the subject.
+func (c *schemaCache) id(ctx context.Context, subject, schema string) (int, error) {
+	key := subjectSchema{subject: subject, schema: schema}
+	c.mu.RLock()
+	id, ok := c.ids[key]
+	c.mu.RUnlock()
+	if ok {
+		return id, nil
+	}
+
+	id, err := c.registry.Register(ctx, subject, schema)
+	if err != nil {
+		return 0, yarpcerrors.Newf(yarpcerrors.CodeUnavailable, "failed to register avro schema for subject %q: %v", subject, err)
+	}
+
+	c.mu.Lock()
+	c.ids[key] = id
+	c.schemas[id] = schema
+	c.mu.Unlock()
+	return id, nil
+}
+
+// schema returns the schema with the given ID.
+func (c *schemaCache) schema(ctx context.Context, id int) (string, error) {
+	c.mu.RLock()
+	schema, ok := c.schemas[id]
+	c.mu.RUnlock()
+	if ok {
+		return schema, nil
+	}
+
+	schema, err := c.registry.Schema(ctx, id)
+	if err != nil {
+		return "", yarpcerrors.Newf(yarpcerrors.CodeUnavailable, "failed to look up avro schema %d: %v", id, err)
+	}
+
+	c.mu.Lock()
+	c.schemas[id] = schema
+	c.mu.Unlock()
+	return schema, nil
+}
+
+// schemaID returns the schema ID held in the SchemaIDHeader of the given
+// headers.
+func schemaID(headers transport.Headers) (int, error) {
+	value, ok := headers.Get(SchemaIDHeader)
+	if !ok {
+		return 0, fmt.Errorf("missing %q header", SchemaIDHeader)
+	}
+	id, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %q header %q: %v", SchemaIDHeader, value, err)
+	}
+	return id, nil
+}
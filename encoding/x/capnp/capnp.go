@@ -0,0 +1,138 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package capnp provides the Cap'n Proto encoding for YARPC.
+//
+// Request and response bodies are Cap'n Proto messages in the standard
+// stream framing: a segment table followed by the segments. This package
+// deals only in framed segments, so it may be used with any Cap'n Proto
+// library. For example, with a library whose messages marshal to the stream
+// framing,
+//
+// 	b, err := msg.Marshal()
+// 	req, err := capnp.FromBytes(b)
+// 	res, err := client.Call(ctx, "Users::get", req)
+// 	b, err = res.Bytes()
+//
+// Handlers are registered with Procedure and OnewayProcedure,
+//
+// 	dispatcher.Register(capnp.Procedure("Users::get", getUser))
+//
+// Decoded messages are checked against segment limits before they reach
+// handlers or callers. See MaxSegments and MaxMessageSize.
+//
+// This package is experimental. Breaking changes may be made between minor
+// releases.
+package capnp
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"go.uber.org/yarpc/api/transport"
+)
+
+// Encoding is the name of this encoding.
+const Encoding transport.Encoding = "capnp"
+
+const _wordSize = 8
+
+// Message is a Cap'n Proto message made of one or more segments. The size
+// of each segment is a multiple of eight bytes.
+type Message struct {
+	Segments [][]byte
+}
+
+// Bytes returns the message in the standard stream framing.
+func (m *Message) Bytes() ([]byte, error) {
+	if len(m.Segments) == 0 {
+		return nil, fmt.Errorf("cap'n proto messages must have at least one segment")
+	}
+
+	size := headerSize(len(m.Segments))
+	for i, seg := range m.Segments {
+		if len(seg)%_wordSize != 0 {
+			return nil, fmt.Errorf("segment %d has %d bytes, which is not a whole number of words", i, len(seg))
+		}
+		size += len(seg)
+	}
+
+	b := make([]byte, headerSize(len(m.Segments)), size)
+	binary.LittleEndian.PutUint32(b, uint32(len(m.Segments)-1))
+	for i, seg := range m.Segments {
+		binary.LittleEndian.PutUint32(b[4*(i+1):], uint32(len(seg)/_wordSize))
+	}
+	for _, seg := range m.Segments {
+		b = append(b, seg...)
+	}
+	return b, nil
+}
+
+// FromBytes parses a message in the standard stream framing, enforcing the
+// given limits. The segments of the returned message refer to b.
+func FromBytes(b []byte, opts ...Option) (*Message, error) {
+	return fromBytes(b, newOptions(opts))
+}
+
+func fromBytes(b []byte, o options) (*Message, error) {
+	if len(b) < 4 {
+		return nil, fmt.Errorf("message of %d bytes is too short for a segment table", len(b))
+	}
+	count := uint64(binary.LittleEndian.Uint32(b)) + 1
+	if count > uint64(o.maxSegments) {
+		return nil, fmt.Errorf("message has %d segments, more than the limit of %d", count, o.maxSegments)
+	}
+
+	offset := headerSize(int(count))
+	if len(b) < offset {
+		return nil, fmt.Errorf("message of %d bytes is too short for a table of %d segments", len(b), count)
+	}
+
+	var total uint64
+	sizes := make([]uint64, count)
+	for i := range sizes {
+		sizes[i] = uint64(binary.LittleEndian.Uint32(b[4*(i+1):])) * _wordSize
+		total += sizes[i]
+	}
+	if total > uint64(o.maxMessageSize) {
+		return nil, fmt.Errorf("message has %d bytes of segments, more than the limit of %d", total, o.maxMessageSize)
+	}
+	if uint64(len(b)-offset) != total {
+		return nil, fmt.Errorf("segment table describes %d bytes but the message has %d", total, len(b)-offset)
+	}
+
+	segments := make([][]byte, count)
+	for i, size := range sizes {
+		end := offset + int(size)
+		segments[i] = b[offset:end:end]
+		offset = end
+	}
+	return &Message{Segments: segments}, nil
+}
+
+// headerSize returns the size of the segment table for the given number of
+// segments, padded to a whole number of words.
+func headerSize(segments int) int {
+	size := 4 * (segments + 1)
+	if rem := size % _wordSize; rem != 0 {
+		size += _wordSize - rem
+	}
+	return size
+}
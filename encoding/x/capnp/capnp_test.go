@@ -0,0 +1,106 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package capnp
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func word(b byte) []byte {
+	return bytes.Repeat([]byte{b}, _wordSize)
+}
+
+func TestMessageRoundTrip(t *testing.T) {
+	tests := []struct {
+		desc     string
+		segments [][]byte
+		size     int
+	}{
+		{
+			desc:     "single segment",
+			segments: [][]byte{word(1)},
+			size:     8 + 8,
+		},
+		{
+			desc:     "two segments",
+			segments: [][]byte{word(1), append(word(2), word(3)...)},
+			size:     16 + 24,
+		},
+		{
+			desc:     "empty segment",
+			segments: [][]byte{{}},
+			size:     8,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			b, err := (&Message{Segments: tt.segments}).Bytes()
+			require.NoError(t, err)
+			assert.Len(t, b, tt.size)
+
+			m, err := FromBytes(b)
+			require.NoError(t, err)
+			assert.Equal(t, tt.segments, m.Segments)
+		})
+	}
+}
+
+func TestMessageBytesErrors(t *testing.T) {
+	_, err := (&Message{}).Bytes()
+	assert.Error(t, err)
+
+	_, err = (&Message{Segments: [][]byte{[]byte("short")}}).Bytes()
+	assert.Error(t, err)
+}
+
+func TestFromBytesErrors(t *testing.T) {
+	valid, err := (&Message{Segments: [][]byte{word(1), word(2), word(3)}}).Bytes()
+	require.NoError(t, err)
+
+	tests := []struct {
+		desc string
+		give []byte
+		opts []Option
+	}{
+		{desc: "too short", give: []byte{0, 0}},
+		{desc: "truncated table", give: valid[:8]},
+		{desc: "truncated segments", give: valid[:len(valid)-1]},
+		{desc: "trailing data", give: append(append([]byte(nil), valid...), word(4)...)},
+		{desc: "too many segments", give: valid, opts: []Option{MaxSegments(2)}},
+		{desc: "too large", give: valid, opts: []Option{MaxMessageSize(16)}},
+		{desc: "huge segment count", give: []byte{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := FromBytes(tt.give, tt.opts...)
+			assert.Error(t, err)
+		})
+	}
+
+	_, err = FromBytes(valid, MaxSegments(3), MaxMessageSize(24))
+	assert.NoError(t, err, "limits are inclusive")
+}
@@ -0,0 +1,95 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package capnp
+
+import (
+	"context"
+
+	encodingapi "go.uber.org/yarpc/api/encoding"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/pkg/errors"
+)
+
+// capnpUnaryHandler adapts a UnaryHandler into a transport.UnaryHandler.
+type capnpUnaryHandler struct {
+	handler UnaryHandler
+	opts    options
+}
+
+// capnpOnewayHandler adapts a OnewayHandler into a transport.OnewayHandler.
+type capnpOnewayHandler struct {
+	handler OnewayHandler
+	opts    options
+}
+
+func (h capnpUnaryHandler) Handle(ctx context.Context, treq *transport.Request, rw transport.ResponseWriter) error {
+	ctx, call, req, err := readRequest(ctx, treq, h.opts)
+	if err != nil {
+		return err
+	}
+
+	res, appErr := h.handler(ctx, req)
+	if err := call.WriteToResponse(rw); err != nil {
+		return err
+	}
+
+	// we want to return the appErr if it exists as this is what
+	// the other encodings do so we deprioritize this error
+	var writeErr error
+	if res != nil {
+		b, err := res.Bytes()
+		if err != nil {
+			writeErr = errors.ResponseBodyEncodeError(treq, err)
+		} else {
+			_, writeErr = rw.Write(b)
+		}
+	}
+	if appErr != nil {
+		rw.SetApplicationError()
+		return appErr
+	}
+	return writeErr
+}
+
+func (h capnpOnewayHandler) HandleOneway(ctx context.Context, treq *transport.Request) error {
+	ctx, _, req, err := readRequest(ctx, treq, h.opts)
+	if err != nil {
+		return err
+	}
+	return h.handler(ctx, req)
+}
+
+func readRequest(ctx context.Context, treq *transport.Request, opts options) (context.Context, *encodingapi.InboundCall, *Message, error) {
+	if err := errors.ExpectEncodings(treq, Encoding); err != nil {
+		return nil, nil, nil, err
+	}
+
+	ctx, call := encodingapi.NewInboundCall(ctx)
+	if err := call.ReadFromRequest(treq); err != nil {
+		return nil, nil, nil, err
+	}
+
+	req, err := readMessage(treq.Body, opts)
+	if err != nil {
+		return nil, nil, nil, errors.RequestBodyDecodeError(treq, err)
+	}
+	return ctx, call, req, nil
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package capnp
+
+// Option customizes the limits applied to Cap'n Proto messages by clients
+// and handlers.
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(opts *options) { f(opts) }
+
+const (
+	_defaultMaxSegments    = 512
+	_defaultMaxMessageSize = 64 * 1024 * 1024
+)
+
+type options struct {
+	maxSegments    int
+	maxMessageSize int
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		maxSegments:    _defaultMaxSegments,
+		maxMessageSize: _defaultMaxMessageSize,
+	}
+	for _, opt := range opts {
+		opt.apply(&o)
+	}
+	return o
+}
+
+// maxFrameSize is the largest framed message allowed by the limits.
+func (o options) maxFrameSize() int {
+	return headerSize(o.maxSegments) + o.maxMessageSize
+}
+
+// MaxSegments limits the number of segments in a message.
+//
+// Defaults to 512.
+func MaxSegments(n int) Option {
+	return optionFunc(func(o *options) {
+		o.maxSegments = n
+	})
+}
+
+// MaxMessageSize limits the total size of the segments of a message, in
+// bytes.
+//
+// Defaults to 64 MiB.
+func MaxMessageSize(n int) Option {
+	return optionFunc(func(o *options) {
+		o.maxMessageSize = n
+	})
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package capnp
+
+import (
+	"bytes"
+	"context"
+
+	"go.uber.org/yarpc"
+	encodingapi "go.uber.org/yarpc/api/encoding"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/pkg/encoding"
+	"go.uber.org/yarpc/pkg/errors"
+)
+
+// Client makes Cap'n Proto requests to a single service.
+type Client interface {
+	// Call performs a unary outbound Cap'n Proto request. The response is
+	// nil if the handler returned no message.
+	Call(ctx context.Context, procedure string, req *Message, opts ...yarpc.CallOption) (*Message, error)
+
+	// CallOneway performs a oneway outbound Cap'n Proto request.
+	CallOneway(ctx context.Context, procedure string, req *Message, opts ...yarpc.CallOption) (transport.Ack, error)
+}
+
+// New builds a new Cap'n Proto client. Responses which exceed the given
+// limits fail to decode.
+func New(c transport.ClientConfig, opts ...Option) Client {
+	return capnpClient{cc: c, opts: newOptions(opts)}
+}
+
+type capnpClient struct {
+	cc   transport.ClientConfig
+	opts options
+}
+
+func (c capnpClient) Call(ctx context.Context, procedure string, req *Message, opts ...yarpc.CallOption) (*Message, error) {
+	call := encodingapi.NewOutboundCall(encoding.FromOptions(opts)...)
+	ctx, treq, err := c.buildRequest(ctx, call, procedure, req)
+	if err != nil {
+		return nil, err
+	}
+
+	tres, appErr := c.cc.GetUnaryOutbound().Call(ctx, treq)
+	if tres == nil {
+		return nil, appErr
+	}
+	if tres.Body != nil {
+		defer tres.Body.Close()
+	}
+
+	if _, err := call.ReadFromResponse(ctx, tres); err != nil {
+		return nil, err
+	}
+
+	// we want to return the appErr if it exists as this is what
+	// the other encodings do so we deprioritize this error
+	var res *Message
+	var decodeErr error
+	if tres.Body != nil {
+		if res, err = readMessage(tres.Body, c.opts); err != nil {
+			decodeErr = errors.ResponseBodyDecodeError(treq, err)
+		}
+	}
+	if appErr != nil {
+		return res, appErr
+	}
+	return res, decodeErr
+}
+
+func (c capnpClient) CallOneway(ctx context.Context, procedure string, req *Message, opts ...yarpc.CallOption) (transport.Ack, error) {
+	call := encodingapi.NewOutboundCall(encoding.FromOptions(opts)...)
+	ctx, treq, err := c.buildRequest(ctx, call, procedure, req)
+	if err != nil {
+		return nil, err
+	}
+	return c.cc.GetOnewayOutbound().CallOneway(ctx, treq)
+}
+
+func (c capnpClient) buildRequest(ctx context.Context, call *encodingapi.OutboundCall, procedure string, req *Message) (context.Context, *transport.Request, error) {
+	treq := &transport.Request{
+		Caller:    c.cc.Caller(),
+		Service:   c.cc.Service(),
+		Procedure: procedure,
+		Encoding:  Encoding,
+	}
+
+	ctx, err := call.WriteToRequest(ctx, treq)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	b, err := req.Bytes()
+	if err != nil {
+		return nil, nil, errors.RequestBodyEncodeError(treq, err)
+	}
+	treq.Body = bytes.NewReader(b)
+	return ctx, treq, nil
+}
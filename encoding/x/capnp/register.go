@@ -0,0 +1,58 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package capnp
+
+import (
+	"context"
+
+	"go.uber.org/yarpc/api/transport"
+)
+
+// UnaryHandler implements a single, unary procedure.
+type UnaryHandler func(context.Context, *Message) (*Message, error)
+
+// Procedure builds a Procedure from the given Cap'n Proto handler. Requests
+// which exceed the given limits are rejected before reaching the handler.
+func Procedure(name string, handler UnaryHandler, opts ...Option) []transport.Procedure {
+	return []transport.Procedure{
+		{
+			Name:        name,
+			HandlerSpec: transport.NewUnaryHandlerSpec(capnpUnaryHandler{handler: handler, opts: newOptions(opts)}),
+			Encoding:    Encoding,
+		},
+	}
+}
+
+// OnewayHandler implements a single, oneway procedure.
+type OnewayHandler func(context.Context, *Message) error
+
+// OnewayProcedure builds a Procedure from the given Cap'n Proto handler.
+// Requests which exceed the given limits are rejected before reaching the
+// handler.
+func OnewayProcedure(name string, handler OnewayHandler, opts ...Option) []transport.Procedure {
+	return []transport.Procedure{
+		{
+			Name:        name,
+			HandlerSpec: transport.NewOnewayHandlerSpec(capnpOnewayHandler{handler: handler, opts: newOptions(opts)}),
+			Encoding:    Encoding,
+		},
+	}
+}
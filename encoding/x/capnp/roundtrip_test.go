@@ -0,0 +1,154 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package capnp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/internal/clientconfig"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// loopbackOutbound calls handlers from a router directly.
+type loopbackOutbound struct {
+	router transport.Router
+}
+
+func (o loopbackOutbound) Transports() []transport.Transport { return nil }
+func (o loopbackOutbound) Start() error                      { return nil }
+func (o loopbackOutbound) Stop() error                       { return nil }
+func (o loopbackOutbound) IsRunning() bool                   { return true }
+
+func (o loopbackOutbound) Call(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	spec, err := o.router.Choose(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	resw := new(transporttest.FakeResponseWriter)
+	err = spec.Unary().Handle(ctx, req, resw)
+	return &transport.Response{
+		Headers:          resw.Headers,
+		Body:             ioutil.NopCloser(&resw.Body),
+		ApplicationError: resw.IsApplicationError,
+	}, err
+}
+
+func (o loopbackOutbound) CallOneway(ctx context.Context, req *transport.Request) (transport.Ack, error) {
+	spec, err := o.router.Choose(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return nil, spec.Oneway().HandleOneway(ctx, req)
+}
+
+func newClientConfig(procedures []transport.Procedure) transport.ClientConfig {
+	router := yarpc.NewMapRouter("service")
+	router.Register(procedures)
+	out := loopbackOutbound{router: router}
+	return clientconfig.MultiOutbound("caller", "service", transport.Outbounds{
+		ServiceName: "service",
+		Unary:       out,
+		Oneway:      out,
+	})
+}
+
+// reverse returns the message with its segments in reverse order.
+func reverse(_ context.Context, m *Message) (*Message, error) {
+	segments := make([][]byte, len(m.Segments))
+	for i, seg := range m.Segments {
+		segments[len(segments)-1-i] = seg
+	}
+	return &Message{Segments: segments}, nil
+}
+
+func TestCall(t *testing.T) {
+	client := New(newClientConfig(Procedure("reverse", reverse)))
+
+	res, err := client.Call(context.Background(), "reverse", &Message{Segments: [][]byte{word(1), word(2)}})
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{word(2), word(1)}, res.Segments)
+}
+
+func TestCallApplicationError(t *testing.T) {
+	client := New(newClientConfig(Procedure("fail", func(context.Context, *Message) (*Message, error) {
+		return nil, errors.New("great sadness")
+	})))
+
+	res, err := client.Call(context.Background(), "fail", &Message{Segments: [][]byte{word(1)}})
+	assert.EqualError(t, err, "great sadness")
+	assert.Nil(t, res)
+}
+
+func TestCallOneway(t *testing.T) {
+	var got *Message
+	client := New(newClientConfig(OnewayProcedure("fire", func(_ context.Context, m *Message) error {
+		got = m
+		return nil
+	})))
+
+	_, err := client.CallOneway(context.Background(), "fire", &Message{Segments: [][]byte{word(7)}})
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{word(7)}, got.Segments)
+}
+
+func TestLimits(t *testing.T) {
+	big := &Message{Segments: [][]byte{word(1), word(2), word(3)}}
+
+	t.Run("handler rejects large requests", func(t *testing.T) {
+		client := New(newClientConfig(Procedure("reverse", reverse, MaxSegments(2))))
+		_, err := client.Call(context.Background(), "reverse", big)
+		assert.True(t, yarpcerrors.IsInvalidArgument(err), "unexpected error: %v", err)
+	})
+
+	t.Run("client rejects large responses", func(t *testing.T) {
+		client := New(newClientConfig(Procedure("reverse", reverse)), MaxMessageSize(16))
+		_, err := client.Call(context.Background(), "reverse", big)
+		assert.True(t, yarpcerrors.IsInvalidArgument(err), "unexpected error: %v", err)
+	})
+
+	t.Run("oversized bodies are not read in full", func(t *testing.T) {
+		b, err := big.Bytes()
+		require.NoError(t, err)
+		_, err = readMessage(bytes.NewReader(append(b, make([]byte, 1024)...)), newOptions([]Option{MaxMessageSize(24)}))
+		assert.Error(t, err)
+	})
+}
+
+func TestInvalidRequestEncoding(t *testing.T) {
+	procedures := Procedure("reverse", reverse)
+	err := procedures[0].HandlerSpec.Unary().Handle(context.Background(), &transport.Request{
+		Caller:    "caller",
+		Service:   "service",
+		Procedure: "reverse",
+		Encoding:  "raw",
+		Body:      bytes.NewReader(nil),
+	}, new(transporttest.FakeResponseWriter))
+	assert.True(t, yarpcerrors.IsInvalidArgument(err))
+}
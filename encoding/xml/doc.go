@@ -0,0 +1,54 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package xml provides the XML encoding for YARPC.
+//
+// To make outbound requests using this encoding,
+//
+// 	client := xml.New(clientConfig)
+// 	var resBody GetValueResponse
+// 	err := client.Call(ctx, "getValue", &GetValueRequest{...}, &resBody)
+//
+// To register an XML procedure, define functions in the format,
+//
+// 	f(ctx context.Context, body $reqBody) ($resBody, error)
+//
+// Where '$reqBody' and '$resBody' are pointers to structs representing your
+// request and response objects. These are encoded with the standard
+// library's encoding/xml, so struct tags such as `xml:"name,attr"` apply.
+//
+// Use the Procedure function to build procedures to register against a
+// Router.
+//
+// 	dispatcher.Register(xml.Procedure("getValue", GetValue))
+// 	dispatcher.Register(xml.Procedure("setValue", SetValue))
+//
+// Similarly, to register a oneway XML procedure, define functions in the
+// format,
+//
+// 	f(ctx context.Context, body $reqBody) error
+//
+// Where $reqBody is a pointer to a struct.
+//
+// Use the OnewayProcedure function to build procedures to register against a
+// Router.
+//
+// 	dispatcher.Register(xml.OnewayProcedure("runTask", RunTask))
+package xml
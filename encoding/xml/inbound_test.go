@@ -0,0 +1,144 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package xml
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+type simpleRequest struct {
+	XMLName xml.Name `xml:"request"`
+	ID      int      `xml:"id,attr"`
+	Name    string   `xml:"name"`
+}
+
+type simpleResponse struct {
+	XMLName xml.Name `xml:"response"`
+	Success bool     `xml:"success"`
+}
+
+func xmlBody(s string) *bytes.Reader {
+	return bytes.NewReader([]byte(s))
+}
+
+func TestHandleSuccess(t *testing.T) {
+	h := func(ctx context.Context, body *simpleRequest) (*simpleResponse, error) {
+		assert.Equal(t, "simpleCall", yarpc.CallFromContext(ctx).Procedure())
+		assert.Equal(t, 42, body.ID)
+		assert.Equal(t, "foo", body.Name)
+		return &simpleResponse{Success: true}, nil
+	}
+
+	resw := new(transporttest.FakeResponseWriter)
+	err := Procedure("simpleCall", h)[0].HandlerSpec.Unary().Handle(context.Background(), &transport.Request{
+		Procedure: "simpleCall",
+		Encoding:  Encoding,
+		Body:      xmlBody(`<request id="42"><name>foo</name></request>`),
+	}, resw)
+	require.NoError(t, err)
+
+	assert.Equal(t, `<response><success>true</success></response>`, resw.Body.String())
+}
+
+func TestHandleApplicationError(t *testing.T) {
+	h := func(ctx context.Context, body *simpleRequest) (*simpleResponse, error) {
+		return &simpleResponse{}, errors.New("great sadness")
+	}
+
+	resw := new(transporttest.FakeResponseWriter)
+	err := Procedure("simpleCall", h)[0].HandlerSpec.Unary().Handle(context.Background(), &transport.Request{
+		Procedure: "simpleCall",
+		Encoding:  Encoding,
+		Body:      xmlBody(`<request></request>`),
+	}, resw)
+	assert.EqualError(t, err, "great sadness")
+	assert.True(t, resw.IsApplicationError)
+	assert.Equal(t, `<response><success>false</success></response>`, resw.Body.String())
+}
+
+func TestHandleNilResponse(t *testing.T) {
+	h := func(ctx context.Context, body *simpleRequest) (*simpleResponse, error) {
+		return nil, nil
+	}
+
+	resw := new(transporttest.FakeResponseWriter)
+	err := Procedure("simpleCall", h)[0].HandlerSpec.Unary().Handle(context.Background(), &transport.Request{
+		Procedure: "simpleCall",
+		Encoding:  Encoding,
+		Body:      xmlBody(`<request></request>`),
+	}, resw)
+	require.NoError(t, err)
+	assert.Empty(t, resw.Body.String())
+}
+
+func TestHandleRequestErrors(t *testing.T) {
+	h := func(ctx context.Context, body *simpleRequest) (*simpleResponse, error) {
+		t.Fatal("handler must not be called")
+		return nil, nil
+	}
+	handler := Procedure("simpleCall", h)[0].HandlerSpec.Unary()
+
+	t.Run("encoding mismatch", func(t *testing.T) {
+		err := handler.Handle(context.Background(), &transport.Request{
+			Procedure: "simpleCall",
+			Encoding:  "json",
+			Body:      xmlBody(`<request></request>`),
+		}, new(transporttest.FakeResponseWriter))
+		assert.Equal(t, yarpcerrors.CodeInvalidArgument, yarpcerrors.FromError(err).Code())
+	})
+
+	t.Run("invalid body", func(t *testing.T) {
+		err := handler.Handle(context.Background(), &transport.Request{
+			Procedure: "simpleCall",
+			Encoding:  Encoding,
+			Body:      xmlBody(`<request><name>`),
+		}, new(transporttest.FakeResponseWriter))
+		assert.Equal(t, yarpcerrors.CodeInvalidArgument, yarpcerrors.FromError(err).Code())
+	})
+}
+
+func TestHandleOneway(t *testing.T) {
+	var got *simpleRequest
+	h := func(ctx context.Context, body *simpleRequest) error {
+		got = body
+		return nil
+	}
+
+	err := OnewayProcedure("simpleCall", h)[0].HandlerSpec.Oneway().HandleOneway(context.Background(), &transport.Request{
+		Procedure: "simpleCall",
+		Encoding:  Encoding,
+		Body:      xmlBody(`<request id="1"><name>foo</name></request>`),
+	})
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.Equal(t, "foo", got.Name)
+}
@@ -0,0 +1,137 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package xml
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/internal/clientconfig"
+)
+
+func TestCall(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	tests := []struct {
+		desc            string
+		encodedResponse string
+		responseErr     error
+
+		want    *simpleResponse
+		wantErr string
+	}{
+		{
+			desc:            "success",
+			encodedResponse: `<response><success>true</success></response>`,
+			want:            &simpleResponse{Success: true},
+		},
+		{
+			desc:            "application error",
+			encodedResponse: `<response><success>false</success></response>`,
+			responseErr:     errors.New("great sadness"),
+			wantErr:         "great sadness",
+		},
+		{
+			desc:            "invalid response",
+			encodedResponse: `<response>`,
+			wantErr:         `failed to decode "xml" response body for procedure "foo" of service "service"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			outbound := transporttest.NewMockUnaryOutbound(mockCtrl)
+			client := New(clientconfig.MultiOutbound("caller", "service",
+				transport.Outbounds{Unary: outbound}))
+
+			outbound.EXPECT().Call(gomock.Any(),
+				transporttest.NewRequestMatcher(t, &transport.Request{
+					Caller:    "caller",
+					Service:   "service",
+					Procedure: "foo",
+					Encoding:  Encoding,
+					Body:      bytes.NewReader([]byte(`<request id="42"><name>foo</name></request>`)),
+				}),
+			).Return(&transport.Response{
+				Body: ioutil.NopCloser(bytes.NewReader([]byte(tt.encodedResponse))),
+			}, tt.responseErr)
+
+			var res simpleResponse
+			err := client.Call(context.Background(), "foo", &simpleRequest{ID: 42, Name: "foo"}, &res)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want.Success, res.Success)
+		})
+	}
+}
+
+func TestCallEncodeError(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	client := New(clientconfig.MultiOutbound("caller", "service",
+		transport.Outbounds{Unary: transporttest.NewMockUnaryOutbound(mockCtrl)}))
+
+	// Channels cannot be marshaled to XML.
+	err := client.Call(context.Background(), "foo", make(chan int), &simpleResponse{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `failed to encode "xml" request body for procedure "foo" of service "service"`)
+}
+
+type successAck struct{}
+
+func (successAck) String() string { return "success" }
+
+func TestCallOneway(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	outbound := transporttest.NewMockOnewayOutbound(mockCtrl)
+	client := New(clientconfig.MultiOutbound("caller", "service",
+		transport.Outbounds{Oneway: outbound}))
+
+	outbound.EXPECT().CallOneway(gomock.Any(),
+		transporttest.NewRequestMatcher(t, &transport.Request{
+			Caller:    "caller",
+			Service:   "service",
+			Procedure: "foo",
+			Encoding:  Encoding,
+			Body:      bytes.NewReader([]byte(`<request id="1"><name>bar</name></request>`)),
+		}),
+	).Return(successAck{}, nil)
+
+	ack, err := client.CallOneway(context.Background(), "foo", &simpleRequest{ID: 1, Name: "bar"})
+	require.NoError(t, err)
+	assert.Equal(t, "success", ack.String())
+}
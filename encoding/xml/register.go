@@ -0,0 +1,59 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package xml
+
+import (
+	"encoding/xml"
+	"io"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/reflectencoding"
+)
+
+var _codec = reflectencoding.Codec{
+	Encoding: Encoding,
+	Encode: func(w io.Writer, v interface{}) error {
+		return xml.NewEncoder(w).Encode(v)
+	},
+	Decode: func(r io.Reader, v interface{}) error {
+		return xml.NewDecoder(r).Decode(v)
+	},
+}
+
+// Procedure builds a Procedure from the given XML handler. handler must be
+// a function with a signature similar to,
+//
+// 	f(ctx context.Context, body $reqBody) ($resBody, error)
+//
+// Where $reqBody and $resBody are pointers to structs.
+func Procedure(name string, handler interface{}) []transport.Procedure {
+	return _codec.Procedure(name, handler)
+}
+
+// OnewayProcedure builds a Procedure from the given XML handler. handler must
+// be a function with a signature similar to,
+//
+// 	f(ctx context.Context, body $reqBody) error
+//
+// Where $reqBody is a pointer to a struct.
+func OnewayProcedure(name string, handler interface{}) []transport.Procedure {
+	return _codec.OnewayProcedure(name, handler)
+}
@@ -0,0 +1,101 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpc
+
+import (
+	"expvar"
+	"sync"
+
+	"go.uber.org/yarpc/internal/introspection"
+)
+
+// _expvarName is the name of the expvar variable under which the in-memory
+// metrics of all running dispatchers are published.
+const _expvarName = "yarpc"
+
+var (
+	_expvarOnce      sync.Once
+	_expvarPublished bool
+
+	_expvarMu          sync.RWMutex
+	_expvarDispatchers = make(map[*Dispatcher]struct{})
+)
+
+// publishExpvar makes the metrics of the dispatcher available through
+// expvar. It does nothing unless the dispatcher is configured to publish
+// metrics that it owns, and logs a warning if another package already
+// published a variable with the same name.
+func publishExpvar(d *Dispatcher) {
+	if !d.exposeExpvar || d.metricsRoot == nil {
+		return
+	}
+
+	_expvarOnce.Do(func() {
+		_expvarPublished = tryPublishExpvar(_expvarName, expvar.Func(expvarSnapshot))
+	})
+	if !_expvarPublished {
+		d.log.Warn("Could not publish metrics through expvar: " +
+			"the \"" + _expvarName + "\" variable is already in use.")
+		return
+	}
+
+	_expvarMu.Lock()
+	_expvarDispatchers[d] = struct{}{}
+	_expvarMu.Unlock()
+}
+
+// tryPublishExpvar publishes the variable under the given name, unless the
+// name is already in use. Unlike expvar.Publish, it does not panic on
+// duplicate names, since another copy of YARPC vendored into the same binary
+// may have published the name already.
+func tryPublishExpvar(name string, v expvar.Var) bool {
+	if expvar.Get(name) != nil {
+		return false
+	}
+	expvar.Publish(name, v)
+	return true
+}
+
+// unpublishExpvar removes the dispatcher from the expvar variable.
+func unpublishExpvar(d *Dispatcher) {
+	_expvarMu.Lock()
+	delete(_expvarDispatchers, d)
+	_expvarMu.Unlock()
+}
+
+// expvarSnapshot returns the metrics of all published dispatchers, keyed by
+// dispatcher name. Dispatchers sharing a name are further qualified with
+// their ID.
+func expvarSnapshot() interface{} {
+	_expvarMu.RLock()
+	defer _expvarMu.RUnlock()
+
+	snap := make(map[string]*introspection.MetricsStatus, len(_expvarDispatchers))
+	for d := range _expvarDispatchers {
+		status := d.Introspect()
+		key := status.Name
+		if _, ok := snap[key]; ok {
+			key = status.Name + "@" + status.ID
+		}
+		snap[key] = status.Metrics
+	}
+	return snap
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package yarpc
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+)
+
+func TestExpvarPublishing(t *testing.T) {
+	d := NewDispatcher(Config{Name: "expvar-test", Metrics: MetricsConfig{Expvar: true}})
+	require.NoError(t, d.Start())
+
+	published := func() map[string]interface{} {
+		v := expvar.Get("yarpc")
+		require.NotNil(t, v, "yarpc expvar must be published")
+		var snap map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(v.String()), &snap))
+		return snap
+	}
+
+	assert.Contains(t, published(), "expvar-test")
+	require.NoError(t, d.Stop())
+	assert.NotContains(t, published(), "expvar-test")
+}
+
+func TestInMemoryMetrics(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	req := &transport.Request{
+		Service:   "test",
+		Caller:    "test",
+		Procedure: "test",
+		Encoding:  transport.Encoding("test"),
+	}
+
+	newDispatcher := func(cfg MetricsConfig) *Dispatcher {
+		out := transporttest.NewMockUnaryOutbound(mockCtrl)
+		out.EXPECT().Transports().AnyTimes()
+		out.EXPECT().Call(gomock.Any(), req).Return(&transport.Response{}, nil)
+		d := NewDispatcher(Config{
+			Name:      "test",
+			Outbounds: Outbounds{"test": {Unary: out}},
+			Metrics:   cfg,
+		})
+		_, err := d.MustOutboundConfig("test").Outbounds.Unary.Call(ctx, req)
+		require.NoError(t, err)
+		return d
+	}
+
+	t.Run("enabled", func(t *testing.T) {
+		status := newDispatcher(MetricsConfig{Expvar: true}).Introspect().Metrics
+		require.NotNil(t, status, "metrics must be recorded in memory")
+
+		counters := make(map[string]int64)
+		for _, c := range status.Counters {
+			counters[c.Name] += c.Value
+		}
+		assert.Equal(t, int64(1), counters["calls"])
+		assert.Equal(t, int64(1), counters["successes"])
+
+		var successes int
+		for _, l := range status.Latencies {
+			if l.Name == "success_latency_ms" {
+				successes += l.Count
+			}
+		}
+		assert.Equal(t, 1, successes)
+		require.Len(t, status.Peers, 1)
+		assert.Equal(t, "test", status.Peers[0].OutboundKey)
+	})
+
+	t.Run("default", func(t *testing.T) {
+		assert.Nil(t, newDispatcher(MetricsConfig{}).Introspect().Metrics)
+	})
+}
+
+func TestTryPublishExpvar(t *testing.T) {
+	assert.True(t, tryPublishExpvar("yarpc-try-publish-test", expvar.Func(func() interface{} { return 1 })))
+	assert.False(t, tryPublishExpvar("yarpc-try-publish-test", expvar.Func(func() interface{} { return 2 })),
+		"must not publish over an existing variable")
+	assert.Equal(t, "1", expvar.Get("yarpc-try-publish-test").String())
+}
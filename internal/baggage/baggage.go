@@ -0,0 +1,57 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package baggage carries request-scoped key-value pairs on contexts, for the
+// yarpc.WithBaggage API and the middleware that propagates them.
+package baggage
+
+import (
+	"context"
+
+	"go.uber.org/yarpc/api/transport"
+)
+
+type baggageKey struct{}
+
+// With returns a copy of the context whose baggage has the key set to the
+// value. Keys are case-insensitive, like the headers that carry them.
+func With(ctx context.Context, key, value string) context.Context {
+	key = transport.CanonicalizeHeaderKey(key)
+	items := FromContext(ctx)
+	copied := make(map[string]string, len(items)+1)
+	for k, v := range items {
+		copied[k] = v
+	}
+	copied[key] = value
+	return context.WithValue(ctx, baggageKey{}, copied)
+}
+
+// WithItems returns a copy of the context whose baggage is the given items,
+// whose keys must be canonical. The items must not be modified afterwards.
+func WithItems(ctx context.Context, items map[string]string) context.Context {
+	return context.WithValue(ctx, baggageKey{}, items)
+}
+
+// FromContext returns the baggage of the context, or nil. The baggage must
+// not be modified.
+func FromContext(ctx context.Context) map[string]string {
+	items, _ := ctx.Value(baggageKey{}).(map[string]string)
+	return items
+}
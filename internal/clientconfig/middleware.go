@@ -0,0 +1,76 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package clientconfig
+
+import (
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+)
+
+// ApplyMiddleware returns a ClientConfig whose outbounds apply the given
+// outbound middleware to the calls made through cc. Middleware which is nil
+// is not applied.
+//
+// Streaming clients require an OutboundConfig, so one is returned if cc is
+// an OutboundConfig.
+func ApplyMiddleware(
+	cc transport.ClientConfig,
+	unary middleware.UnaryOutbound,
+	oneway middleware.OnewayOutbound,
+	stream middleware.StreamOutbound,
+) transport.ClientConfig {
+	oc, ok := cc.(*transport.OutboundConfig)
+	if !ok {
+		return clientConfigWithMiddleware{ClientConfig: cc, unary: unary, oneway: oneway}
+	}
+
+	outbounds := oc.Outbounds
+	if outbounds.Unary != nil {
+		outbounds.Unary = middleware.ApplyUnaryOutbound(outbounds.Unary, unary)
+	}
+	if outbounds.Oneway != nil {
+		outbounds.Oneway = middleware.ApplyOnewayOutbound(outbounds.Oneway, oneway)
+	}
+	if outbounds.Stream != nil {
+		outbounds.Stream = middleware.ApplyStreamOutbound(outbounds.Stream, stream)
+	}
+	return &transport.OutboundConfig{
+		CallerName: oc.CallerName,
+		Outbounds:  outbounds,
+	}
+}
+
+// clientConfigWithMiddleware applies middleware to the outbounds of a
+// ClientConfig which is not a transport.OutboundConfig.
+type clientConfigWithMiddleware struct {
+	transport.ClientConfig
+
+	unary  middleware.UnaryOutbound
+	oneway middleware.OnewayOutbound
+}
+
+func (c clientConfigWithMiddleware) GetUnaryOutbound() transport.UnaryOutbound {
+	return middleware.ApplyUnaryOutbound(c.ClientConfig.GetUnaryOutbound(), c.unary)
+}
+
+func (c clientConfigWithMiddleware) GetOnewayOutbound() transport.OnewayOutbound {
+	return middleware.ApplyOnewayOutbound(c.ClientConfig.GetOnewayOutbound(), c.oneway)
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package clientconfig
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+)
+
+// nopOutbound records the procedures of the requests it is called with.
+type nopOutbound struct {
+	transport.Outbound
+
+	procedures []string
+}
+
+func (o *nopOutbound) Call(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	o.procedures = append(o.procedures, req.Procedure)
+	return &transport.Response{}, nil
+}
+
+func (o *nopOutbound) CallOneway(ctx context.Context, req *transport.Request) (transport.Ack, error) {
+	o.procedures = append(o.procedures, req.Procedure)
+	return nil, nil
+}
+
+func (o *nopOutbound) CallStream(ctx context.Context, req *transport.StreamRequest) (*transport.ClientStream, error) {
+	o.procedures = append(o.procedures, req.Meta.Procedure)
+	return nil, nil
+}
+
+func TestApplyMiddleware(t *testing.T) {
+	unary := middleware.UnaryOutboundFunc(func(ctx context.Context, req *transport.Request, o transport.UnaryOutbound) (*transport.Response, error) {
+		req.Procedure = "unary"
+		return o.Call(ctx, req)
+	})
+	oneway := middleware.OnewayOutboundFunc(func(ctx context.Context, req *transport.Request, o transport.OnewayOutbound) (transport.Ack, error) {
+		req.Procedure = "oneway"
+		return o.CallOneway(ctx, req)
+	})
+	stream := middleware.StreamOutboundFunc(func(ctx context.Context, req *transport.StreamRequest, o transport.StreamOutbound) (*transport.ClientStream, error) {
+		req.Meta.Procedure = "stream"
+		return o.CallStream(ctx, req)
+	})
+
+	out := &nopOutbound{}
+	cc := &transport.OutboundConfig{
+		CallerName: caller,
+		Outbounds: transport.Outbounds{
+			ServiceName: service,
+			Unary:       out,
+			Oneway:      out,
+			Stream:      out,
+		},
+	}
+
+	tests := []struct {
+		desc string
+		give transport.ClientConfig
+	}{
+		{desc: "outbound config", give: cc},
+		{desc: "client config", give: struct{ transport.ClientConfig }{cc}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			out.procedures = nil
+			got := ApplyMiddleware(tt.give, unary, oneway, stream)
+			assert.Equal(t, caller, got.Caller())
+			assert.Equal(t, service, got.Service())
+
+			_, err := got.GetUnaryOutbound().Call(context.Background(), &transport.Request{})
+			require.NoError(t, err)
+			_, err = got.GetOnewayOutbound().CallOneway(context.Background(), &transport.Request{})
+			require.NoError(t, err)
+			assert.Equal(t, []string{"unary", "oneway"}, out.procedures)
+		})
+	}
+
+	t.Run("stream", func(t *testing.T) {
+		out.procedures = nil
+		oc, ok := ApplyMiddleware(cc, unary, oneway, stream).(*transport.OutboundConfig)
+		require.True(t, ok, "OutboundConfigs must be kept for streaming clients")
+		_, err := oc.Outbounds.Stream.CallStream(context.Background(), &transport.StreamRequest{
+			Meta: &transport.RequestMeta{},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"stream"}, out.procedures)
+	})
+
+	t.Run("nil middleware", func(t *testing.T) {
+		oc, ok := ApplyMiddleware(cc, nil, nil, nil).(*transport.OutboundConfig)
+		require.True(t, ok)
+		assert.Equal(t, out, oc.Outbounds.Unary)
+		assert.Equal(t, out, oc.Outbounds.Oneway)
+		assert.Equal(t, out, oc.Outbounds.Stream)
+	})
+}
@@ -0,0 +1,204 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package encodingbench
+
+import (
+	"context"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"testing"
+
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/encoding/json"
+	"go.uber.org/yarpc/encoding/protobuf"
+	"go.uber.org/yarpc/encoding/raw"
+	"go.uber.org/yarpc/internal/clientconfig"
+	"go.uber.org/yarpc/internal/examples/protobuf/examplepb"
+	"go.uber.org/yarpc/internal/examples/thrift-keyvalue/keyvalue/kv/keyvalueclient"
+	"go.uber.org/yarpc/internal/examples/thrift-keyvalue/keyvalue/kv/keyvalueserver"
+)
+
+const (
+	_caller  = "caller"
+	_service = "service"
+)
+
+// _payloadSizes are the sizes, in bytes, of the string sent in each request
+// and returned in each response.
+var _payloadSizes = []int{16, 1024, 64 * 1024}
+
+// loopbackOutbound is a UnaryOutbound which calls handlers from a router
+// directly.
+type loopbackOutbound struct {
+	router transport.Router
+}
+
+func (o loopbackOutbound) Transports() []transport.Transport { return nil }
+func (o loopbackOutbound) Start() error                      { return nil }
+func (o loopbackOutbound) Stop() error                       { return nil }
+func (o loopbackOutbound) IsRunning() bool                   { return true }
+
+func (o loopbackOutbound) Call(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	spec, err := o.router.Choose(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	resw := new(transporttest.FakeResponseWriter)
+	if err := spec.Unary().Handle(ctx, req, resw); err != nil {
+		return nil, err
+	}
+	return &transport.Response{
+		Headers:          resw.Headers,
+		Body:             ioutil.NopCloser(&resw.Body),
+		ApplicationError: resw.IsApplicationError,
+	}, nil
+}
+
+// newClientConfig registers the procedures with a router and returns a
+// ClientConfig whose outbound calls them directly.
+func newClientConfig(procedures []transport.Procedure) transport.ClientConfig {
+	router := yarpc.NewMapRouter(_service)
+	router.Register(procedures)
+	return clientconfig.MultiOutbound(_caller, _service, transport.Outbounds{
+		ServiceName: _service,
+		Unary:       loopbackOutbound{router: router},
+	})
+}
+
+// runPayloadSizes runs f once for each payload size with a payload of that
+// size.
+func runPayloadSizes(b *testing.B, f func(*testing.B, string)) {
+	for _, size := range _payloadSizes {
+		payload := strings.Repeat("a", size)
+		b.Run(strconv.Itoa(size), func(b *testing.B) {
+			b.SetBytes(int64(size))
+			b.ReportAllocs()
+			f(b, payload)
+		})
+	}
+}
+
+func BenchmarkRaw(b *testing.B) {
+	client := raw.New(newClientConfig(raw.Procedure("echo", func(_ context.Context, body []byte) ([]byte, error) {
+		return body, nil
+	})))
+
+	runPayloadSizes(b, func(b *testing.B, payload string) {
+		body := []byte(payload)
+		ctx := context.Background()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := client.Call(ctx, "echo", body); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+type jsonValue struct {
+	Value string `json:"value"`
+}
+
+func BenchmarkJSON(b *testing.B) {
+	client := json.New(newClientConfig(json.Procedure("echo", func(_ context.Context, v *jsonValue) (*jsonValue, error) {
+		return v, nil
+	})))
+
+	runPayloadSizes(b, func(b *testing.B, payload string) {
+		req := &jsonValue{Value: payload}
+		ctx := context.Background()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			var res jsonValue
+			if err := client.Call(ctx, "echo", req, &res); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// thriftEcho implements the KeyValue Thrift service by returning the key
+// as the value.
+type thriftEcho struct{}
+
+func (thriftEcho) GetValue(_ context.Context, key *string) (string, error) {
+	return *key, nil
+}
+
+func (thriftEcho) SetValue(context.Context, *string, *string) error {
+	return nil
+}
+
+func BenchmarkThrift(b *testing.B) {
+	client := keyvalueclient.New(newClientConfig(keyvalueserver.New(thriftEcho{})))
+
+	runPayloadSizes(b, func(b *testing.B, payload string) {
+		ctx := context.Background()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := client.GetValue(ctx, &payload); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// protobufEcho implements the KeyValue Protobuf service by returning the
+// key as the value.
+type protobufEcho struct{}
+
+func (protobufEcho) GetValue(_ context.Context, req *examplepb.GetValueRequest) (*examplepb.GetValueResponse, error) {
+	return &examplepb.GetValueResponse{Value: req.Key}, nil
+}
+
+func (protobufEcho) SetValue(context.Context, *examplepb.SetValueRequest) (*examplepb.SetValueResponse, error) {
+	return &examplepb.SetValueResponse{}, nil
+}
+
+func BenchmarkProtobuf(b *testing.B) {
+	benchmarkProtobuf(b)
+}
+
+func BenchmarkProtobufJSON(b *testing.B) {
+	benchmarkProtobuf(b, protobuf.UseJSON)
+}
+
+func benchmarkProtobuf(b *testing.B, opts ...protobuf.ClientOption) {
+	client := examplepb.NewKeyValueYARPCClient(
+		newClientConfig(examplepb.BuildKeyValueYARPCProcedures(protobufEcho{})),
+		opts...,
+	)
+
+	runPayloadSizes(b, func(b *testing.B, payload string) {
+		req := &examplepb.GetValueRequest{Key: payload}
+		ctx := context.Background()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := client.GetValue(ctx, req); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,211 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package framing implements the length-prefixed wire format shared by
+// YARPC's stream-oriented experimental transports.
+//
+// A request frame carries the time-to-live of the call followed by the
+// request serialized with the serialize package. A response frame carries
+// the YARPC error (if any), the application error flag, headers, and body.
+package framing
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// DefaultMaxFrameSize is the maximum frame size used by transports that do
+// not configure one.
+const DefaultMaxFrameSize = 4 * 1024 * 1024
+
+// WriteFrame writes the given bytes to the writer prefixed with their
+// length.
+func WriteFrame(w io.Writer, b []byte) error {
+	var size [4]byte
+	binary.BigEndian.PutUint32(size[:], uint32(len(b)))
+	if _, err := w.Write(size[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(b)
+	return err
+}
+
+// ReadFrame reads a single length-prefixed frame from the reader, failing
+// if the frame is larger than max bytes.
+func ReadFrame(r io.Reader, max int) ([]byte, error) {
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if int64(n) > int64(max) {
+		return nil, fmt.Errorf("frame of %d bytes exceeds maximum frame size of %d bytes", n, max)
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// EncodeRequest prefixes a serialized request with its time-to-live.
+func EncodeRequest(ttl time.Duration, serialized []byte) []byte {
+	var e encoder
+	e.uvarint(uint64(ttl / time.Millisecond))
+	e.buf.Write(serialized)
+	return e.buf.Bytes()
+}
+
+// DecodeRequest splits a request frame into its time-to-live and serialized
+// request.
+func DecodeRequest(b []byte) (time.Duration, []byte, error) {
+	d := decoder{b: b}
+	ttl := d.uvarint()
+	if d.err != nil {
+		return 0, nil, d.err
+	}
+	return time.Duration(ttl) * time.Millisecond, d.b, nil
+}
+
+// Response is the wire representation of a unary response or failure.
+type Response struct {
+	// Err is the YARPC error the call failed with, if any.
+	Err *yarpcerrors.Status
+
+	ApplicationError bool
+	Headers          transport.Headers
+	Body             []byte
+}
+
+// EncodeResponse serializes the given response.
+func EncodeResponse(res Response) []byte {
+	var e encoder
+	if res.Err == nil {
+		e.uvarint(uint64(yarpcerrors.CodeOK))
+	} else {
+		e.uvarint(uint64(res.Err.Code()))
+		e.string(res.Err.Name())
+		e.string(res.Err.Message())
+	}
+	if res.ApplicationError {
+		e.uvarint(1)
+	} else {
+		e.uvarint(0)
+	}
+	items := res.Headers.OriginalItems()
+	e.uvarint(uint64(len(items)))
+	for k, v := range items {
+		e.string(k)
+		e.string(v)
+	}
+	e.bytes(res.Body)
+	return e.buf.Bytes()
+}
+
+// DecodeResponse deserializes a response produced by EncodeResponse.
+func DecodeResponse(b []byte) (Response, error) {
+	var res Response
+	d := decoder{b: b}
+
+	if code := yarpcerrors.Code(d.uvarint()); code != yarpcerrors.CodeOK {
+		name, message := d.string(), d.string()
+		res.Err = yarpcerrors.Newf(code, "%s", message)
+		if name != "" {
+			res.Err = res.Err.WithName(name)
+		}
+	}
+	res.ApplicationError = d.uvarint() == 1
+	n := d.uvarint()
+	if d.err == nil && n > uint64(len(d.b)) {
+		d.err = errors.New("malformed response frame: too many headers")
+	}
+	if n > 0 && d.err == nil {
+		res.Headers = transport.NewHeadersWithCapacity(int(n))
+		for i := uint64(0); i < n && d.err == nil; i++ {
+			k, v := d.string(), d.string()
+			res.Headers = res.Headers.With(k, v)
+		}
+	}
+	res.Body = d.bytes()
+	return res, d.err
+}
+
+type encoder struct {
+	buf     bytes.Buffer
+	scratch [binary.MaxVarintLen64]byte
+}
+
+func (e *encoder) uvarint(v uint64) {
+	n := binary.PutUvarint(e.scratch[:], v)
+	e.buf.Write(e.scratch[:n])
+}
+
+func (e *encoder) bytes(b []byte) {
+	e.uvarint(uint64(len(b)))
+	e.buf.Write(b)
+}
+
+func (e *encoder) string(s string) {
+	e.uvarint(uint64(len(s)))
+	e.buf.WriteString(s)
+}
+
+type decoder struct {
+	b   []byte
+	err error
+}
+
+func (d *decoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(d.b)
+	if n <= 0 {
+		d.err = errors.New("malformed frame: invalid varint")
+		return 0
+	}
+	d.b = d.b[n:]
+	return v
+}
+
+func (d *decoder) bytes() []byte {
+	n := d.uvarint()
+	if d.err != nil {
+		return nil
+	}
+	if n > uint64(len(d.b)) {
+		d.err = errors.New("malformed frame: truncated field")
+		return nil
+	}
+	b := d.b[:n]
+	d.b = d.b[n:]
+	return b
+}
+
+func (d *decoder) string() string {
+	return string(d.bytes())
+}
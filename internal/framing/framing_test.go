@@ -0,0 +1,108 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package framing
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+func TestFrames(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteFrame(&buf, []byte("hello")))
+	require.NoError(t, WriteFrame(&buf, nil))
+
+	b, err := ReadFrame(&buf, 10)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(b))
+
+	b, err = ReadFrame(&buf, 10)
+	require.NoError(t, err)
+	assert.Empty(t, b)
+
+	require.NoError(t, WriteFrame(&buf, []byte("too large")))
+	_, err = ReadFrame(&buf, 4)
+	assert.Error(t, err)
+}
+
+func TestRequest(t *testing.T) {
+	ttl, serialized, err := DecodeRequest(EncodeRequest(1500*time.Millisecond, []byte("request")))
+	require.NoError(t, err)
+	assert.Equal(t, 1500*time.Millisecond, ttl)
+	assert.Equal(t, "request", string(serialized))
+
+	_, _, err = DecodeRequest(nil)
+	assert.Error(t, err)
+}
+
+func TestResponse(t *testing.T) {
+	tests := []struct {
+		msg string
+		res Response
+	}{
+		{msg: "empty"},
+		{
+			msg: "success",
+			res: Response{
+				Headers: transport.NewHeaders().With("foo", "bar"),
+				Body:    []byte("body"),
+			},
+		},
+		{
+			msg: "application error",
+			res: Response{
+				ApplicationError: true,
+				Body:             []byte("exception"),
+			},
+		},
+		{
+			msg: "error",
+			res: Response{Err: yarpcerrors.Newf(yarpcerrors.CodeNotFound, "not found")},
+		},
+		{
+			msg: "named error",
+			res: Response{Err: yarpcerrors.Newf(yarpcerrors.CodeInternal, "sad").WithName("great-sadness")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.msg, func(t *testing.T) {
+			res, err := DecodeResponse(EncodeResponse(tt.res))
+			require.NoError(t, err)
+			assert.Equal(t, tt.res.Err, res.Err)
+			assert.Equal(t, tt.res.ApplicationError, res.ApplicationError)
+			assert.Equal(t, tt.res.Headers.OriginalItems(), res.Headers.OriginalItems())
+			assert.Equal(t, string(tt.res.Body), string(res.Body))
+		})
+	}
+}
+
+func TestMalformedResponse(t *testing.T) {
+	encoded := EncodeResponse(Response{Body: []byte("body")})
+	_, err := DecodeResponse(encoded[:len(encoded)-1])
+	assert.Error(t, err)
+}
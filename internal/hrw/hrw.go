@@ -0,0 +1,148 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package hrw implements rendezvous, or highest random weight, hashing for
+// the peer lists which pin requests to peers by a key.
+//
+// Each peer gets a score for the key of a request, and the peer with the
+// highest score is chosen. When a peer is removed, only its keys move, each
+// to the peer with its next highest score.
+package hrw
+
+import (
+	"context"
+	"hash/fnv"
+
+	"go.uber.org/atomic"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/splitmix"
+	"go.uber.org/yarpc/peer/peerlist"
+)
+
+// KeyFunc returns the key of a request, or an empty string if the request
+// has none.
+type KeyFunc func(*transport.Request) string
+
+// NewList returns a peer list which chooses peers by rendezvous hashing of
+// the keys of requests. Requests without a key are spread over the peers in
+// round-robin order.
+func NewList(name string, t peer.Transport, key KeyFunc, opts ...peerlist.ListOption) *peerlist.List {
+	return peerlist.New(name, t, &hrw{key: key}, opts...)
+}
+
+// Score returns the score of the peer with the given identifier for the
+// given key.
+func Score(key, id string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	h.Write([]byte{0})
+	h.Write([]byte(id))
+	return splitmix.Mix(h.Sum64())
+}
+
+type subscriber struct {
+	peer peer.StatusPeer
+}
+
+func (s *subscriber) NotifyStatusChanged(pid peer.Identifier) {}
+
+// hrw chooses the available peer with the highest score for the key of a
+// request.
+//
+// hrw is NOT thread-safe. The peer list calls Add and Remove with its write
+// lock held and Choose with its read lock held, so Choose must not modify
+// the peers.
+type hrw struct {
+	key KeyFunc
+
+	subs []*subscriber
+	next atomic.Uint32
+}
+
+// Add adds the peer.
+func (r *hrw) Add(p peer.StatusPeer) peer.Subscriber {
+	sub := &subscriber{peer: p}
+	r.subs = append(r.subs, sub)
+	return sub
+}
+
+// Remove removes the peer. Its keys move to the peers with the next highest
+// scores.
+func (r *hrw) Remove(p peer.StatusPeer, s peer.Subscriber) {
+	sub, ok := s.(*subscriber)
+	if !ok {
+		// Don't panic.
+		return
+	}
+
+	for i, candidate := range r.subs {
+		if candidate == sub {
+			r.subs = append(r.subs[:i], r.subs[i+1:]...)
+			return
+		}
+	}
+}
+
+// Choose returns the peer with the highest score for the key of the
+// request, or the next peer in round-robin order if the request has no key.
+// Returns nil if there are no peers.
+func (r *hrw) Choose(_ context.Context, req *transport.Request) peer.StatusPeer {
+	if len(r.subs) == 0 {
+		return nil
+	}
+
+	var key string
+	if req != nil {
+		key = r.key(req)
+	}
+	if key == "" {
+		i := int(r.next.Inc()-1) % len(r.subs)
+		return r.subs[i].peer
+	}
+
+	var (
+		best      *subscriber
+		bestID    string
+		bestScore uint64
+	)
+	for _, sub := range r.subs {
+		id := sub.peer.Identifier()
+		s := Score(key, id)
+		// Break ties by identifier so that the choice does not depend on the
+		// order in which peers were added.
+		if best == nil || s > bestScore || (s == bestScore && id < bestID) {
+			best, bestID, bestScore = sub, id, s
+		}
+	}
+	return best.peer
+}
+
+func (r *hrw) Start() error {
+	return nil
+}
+
+func (r *hrw) Stop() error {
+	return nil
+}
+
+func (r *hrw) IsRunning() bool {
+	return true
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package hrw
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/peer/hostport"
+	"go.uber.org/yarpc/yarpctest"
+)
+
+func TestNewList(t *testing.T) {
+	pl := NewList("test", yarpctest.NewFakeTransport(), func(req *transport.Request) string {
+		return req.Procedure
+	})
+	require.NoError(t, pl.Start())
+	defer pl.Stop()
+
+	ids := []string{"1.1.1.1:1", "2.2.2.2:2", "3.3.3.3:3"}
+	var pids []peer.Identifier
+	for _, id := range ids {
+		pids = append(pids, hostport.PeerIdentifier(id))
+	}
+	require.NoError(t, pl.Update(peer.ListUpdates{Additions: pids}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	choose := func(req *transport.Request) string {
+		p, onFinish, err := pl.Choose(ctx, req)
+		require.NoError(t, err)
+		onFinish(nil)
+		return p.Identifier()
+	}
+
+	// The peer with the highest score for the key is chosen.
+	best := ids[0]
+	for _, id := range ids[1:] {
+		if Score("get", id) > Score("get", best) {
+			best = id
+		}
+	}
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, best, choose(&transport.Request{Procedure: "get"}))
+	}
+
+	// Requests without a key are spread over every peer.
+	seen := make(map[string]bool)
+	for range ids {
+		seen[choose(&transport.Request{})] = true
+	}
+	assert.Len(t, seen, len(ids))
+}
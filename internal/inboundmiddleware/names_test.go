@@ -0,0 +1,39 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package inboundmiddleware
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/middleware/middlewaretest"
+)
+
+func TestNames(t *testing.T) {
+	mw := &middlewaretest.MockUnaryInbound{}
+	chain := UnaryChain(mw, UnaryChain(middleware.NopUnaryInbound, mw))
+
+	assert.Equal(t, []string{"*middlewaretest.MockUnaryInbound", "*middlewaretest.MockUnaryInbound"}, Names(chain))
+	assert.Equal(t, []string{"*middlewaretest.MockUnaryInbound"}, Names(mw))
+	assert.Nil(t, Names(middleware.NopOnewayInbound))
+	assert.Nil(t, Names(nil))
+}
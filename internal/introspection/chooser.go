@@ -17,23 +17,15 @@
 // LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
 // OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
 // THE SOFTWARE.
-
 package introspection
 
+import "go.uber.org/yarpc/api/introspection"
+
 // IntrospectableChooser extends the Chooser interfaces.
-type IntrospectableChooser interface {
-	Introspect() ChooserStatus
-}
+type IntrospectableChooser = introspection.IntrospectableChooser
 
 // ChooserStatus is a collection of basic chooser info.
-type ChooserStatus struct {
-	Name  string       `json:"name"`
-	State string       `json:"state"`
-	Peers []PeerStatus `json:"peers"`
-}
+type ChooserStatus = introspection.ChooserStatus
 
 // PeerStatus is a collection of basic peers info.
-type PeerStatus struct {
-	Identifier string `json:"identifier"`
-	State      string `json:"state"`
-}
+type PeerStatus = introspection.PeerStatus
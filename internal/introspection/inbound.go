@@ -17,17 +17,12 @@
 // LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
 // OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
 // THE SOFTWARE.
-
 package introspection
 
+import "go.uber.org/yarpc/api/introspection"
+
 // IntrospectableInbound extends the Inbound interface.
-type IntrospectableInbound interface {
-	Introspect() InboundStatus
-}
+type IntrospectableInbound = introspection.IntrospectableInbound
 
 // InboundStatus is a collection of basics info about an Inbound.
-type InboundStatus struct {
-	Transport string `json:"transport"`
-	Endpoint  string `json:"endpoint"`
-	State     string `json:"state"`
-}
+type InboundStatus = introspection.InboundStatus
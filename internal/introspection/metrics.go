@@ -0,0 +1,113 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package introspection
+
+import (
+	"sort"
+	"time"
+
+	"go.uber.org/net/metrics"
+	"go.uber.org/yarpc/api/introspection"
+)
+
+// MetricsStatus is a summary of the metrics a dispatcher records in memory.
+type MetricsStatus = introspection.MetricsStatus
+
+// CounterStatus is the current value of a single counter.
+type CounterStatus = introspection.CounterStatus
+
+// LatencyStatus summarizes the observations of a single latency histogram.
+type LatencyStatus = introspection.LatencyStatus
+
+// PeerCountStatus is the number of peers retained by an outbound's chooser.
+type PeerCountStatus = introspection.PeerCountStatus
+
+// NewMetricsStatus summarizes the given snapshot of in-memory metrics and
+// outbound statuses.
+func NewMetricsStatus(snap *metrics.RootSnapshot, outbounds []OutboundStatus) MetricsStatus {
+	var status MetricsStatus
+	for _, c := range snap.Counters {
+		status.Counters = append(status.Counters, CounterStatus{
+			Name:  c.Name,
+			Tags:  c.Tags,
+			Value: c.Value,
+		})
+	}
+	for _, h := range snap.Histograms {
+		status.Latencies = append(status.Latencies, newLatencyStatus(h))
+	}
+	for _, o := range outbounds {
+		status.Peers = append(status.Peers, PeerCountStatus{
+			OutboundKey: o.OutboundKey,
+			RPCType:     o.RPCType,
+			Peers:       len(o.Chooser.Peers),
+		})
+	}
+	return status
+}
+
+func newLatencyStatus(h metrics.HistogramSnapshot) LatencyStatus {
+	values := make([]int64, len(h.Values))
+	copy(values, h.Values)
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+
+	return LatencyStatus{
+		Name:  h.Name,
+		Tags:  h.Tags,
+		Unit:  unitName(h.Unit),
+		Count: len(values),
+		P50:   quantile(values, 0.5),
+		P90:   quantile(values, 0.9),
+		P99:   quantile(values, 0.99),
+		Max:   quantile(values, 1),
+	}
+}
+
+// quantile returns the q-th quantile of the sorted values, or zero if there
+// are no values.
+func quantile(sorted []int64, q float64) int64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(q*float64(len(sorted))+0.5) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(sorted) {
+		i = len(sorted) - 1
+	}
+	return sorted[i]
+}
+
+func unitName(unit time.Duration) string {
+	switch unit {
+	case time.Nanosecond:
+		return "ns"
+	case time.Microsecond:
+		return "us"
+	case time.Millisecond:
+		return "ms"
+	case time.Second:
+		return "s"
+	default:
+		return unit.String()
+	}
+}
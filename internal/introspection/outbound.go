@@ -17,25 +17,16 @@
 // LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
 // OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
 // THE SOFTWARE.
-
 package introspection
 
+import "go.uber.org/yarpc/api/introspection"
+
 // IntrospectableOutbound extends the Outbound interface.
-type IntrospectableOutbound interface {
-	Introspect() OutboundStatus
-}
+type IntrospectableOutbound = introspection.IntrospectableOutbound
 
 // OutboundStatus is a collection of basics info about an Outbound.
-type OutboundStatus struct {
-	Transport   string        `json:"transport"`
-	RPCType     string        `json:"rpctype"`
-	Endpoint    string        `json:"endpoint"`
-	State       string        `json:"state"`
-	Chooser     ChooserStatus `json:"chooser"`
-	Service     string        `json:"service"`
-	OutboundKey string        `json:"outboundkey"`
-}
+type OutboundStatus = introspection.OutboundStatus
 
 // OutboundStatusNotSupported is returned when not valid OutboundStatus can be
 // produced.
-var OutboundStatusNotSupported = OutboundStatus{}
+var OutboundStatusNotSupported = introspection.OutboundStatusNotSupported
@@ -17,11 +17,9 @@
 // LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
 // OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
 // THE SOFTWARE.
-
 package introspection
 
+import "go.uber.org/yarpc/api/introspection"
+
 // PackageVersion represent a package and its version.
-type PackageVersion struct {
-	Name    string `json:"name"`
-	Version string `json:"version"`
-}
+type PackageVersion = introspection.PackageVersion
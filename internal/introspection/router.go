@@ -21,16 +21,12 @@
 package introspection
 
 import (
+	"go.uber.org/yarpc/api/introspection"
 	"go.uber.org/yarpc/api/transport"
 )
 
 // Procedure represent a registered procedure on a dispatcher.
-type Procedure struct {
-	Name      string `json:"name"`
-	Encoding  string `json:"encoding"`
-	Signature string `json:"signature"`
-	RPCType   string `json:"rpcType"`
-}
+type Procedure = introspection.Procedure
 
 // IntrospectProcedures is a convenience function that translate a slice of
 // transport.Procedure to a slice of introspection.Procedure. This output is
@@ -39,6 +35,7 @@ func IntrospectProcedures(routerProcs []transport.Procedure) []Procedure {
 	procedures := make([]Procedure, 0, len(routerProcs))
 	for _, p := range routerProcs {
 		procedures = append(procedures, Procedure{
+			Service:   p.Service,
 			Name:      p.Name,
 			Encoding:  string(p.Encoding),
 			Signature: p.Signature,
@@ -53,17 +53,23 @@ type graph struct {
 	meter   *metrics.Scope
 	logger  *zap.Logger
 	extract ContextExtractor
+	tags    TagConfig
 
 	edgesMu sync.RWMutex
 	edges   map[string]*edge
+	// Edges whose metrics have the same tags, because tags were dropped,
+	// share their metrics.
+	edgeMetrics map[string]*edgeMetrics
 }
 
-func newGraph(meter *metrics.Scope, logger *zap.Logger, extract ContextExtractor) graph {
+func newGraph(meter *metrics.Scope, logger *zap.Logger, extract ContextExtractor, tags TagConfig) graph {
 	return graph{
-		edges:   make(map[string]*edge, _defaultGraphSize),
-		meter:   meter,
-		logger:  logger,
-		extract: extract,
+		edges:       make(map[string]*edge, _defaultGraphSize),
+		edgeMetrics: make(map[string]*edgeMetrics, _defaultGraphSize),
+		meter:       meter,
+		logger:      logger,
+		extract:     extract,
+		tags:        tags,
 	}
 }
 
@@ -80,7 +86,11 @@ func (g *graph) begin(ctx context.Context, rpcType transport.Type, direction dir
 	d.Add(req.RoutingKey)
 	d.Add(req.RoutingDelegate)
 	d.Add(string(direction))
-	e := g.getOrCreateEdge(d.Digest(), req, string(direction))
+	extraValues := g.tags.extraValues(ctx, req)
+	for _, v := range extraValues {
+		d.Add(v)
+	}
+	e := g.getOrCreateEdge(d.Digest(), req, string(direction), extraValues)
 	d.Free()
 
 	return call{
@@ -94,11 +104,11 @@ func (g *graph) begin(ctx context.Context, rpcType transport.Type, direction dir
 	}
 }
 
-func (g *graph) getOrCreateEdge(key []byte, req *transport.Request, direction string) *edge {
+func (g *graph) getOrCreateEdge(key []byte, req *transport.Request, direction string, extraValues []string) *edge {
 	if e := g.getEdge(key); e != nil {
 		return e
 	}
-	return g.createEdge(key, req, direction)
+	return g.createEdge(key, req, direction, extraValues)
 }
 
 func (g *graph) getEdge(key []byte) *edge {
@@ -108,7 +118,7 @@ func (g *graph) getEdge(key []byte) *edge {
 	return e
 }
 
-func (g *graph) createEdge(key []byte, req *transport.Request, direction string) *edge {
+func (g *graph) createEdge(key []byte, req *transport.Request, direction string, extraValues []string) *edge {
 	g.edgesMu.Lock()
 	// Since we'll rarely hit this code path, the overhead of defer is acceptable.
 	defer g.edgesMu.Unlock()
@@ -118,7 +128,19 @@ func (g *graph) createEdge(key []byte, req *transport.Request, direction string)
 		return e
 	}
 
-	e := newEdge(g.logger, g.meter, req, direction)
+	tags := g.tags.apply(defaultTags(req, direction), extraValues)
+	tagsKey := tagsKey(tags)
+	m, ok := g.edgeMetrics[tagsKey]
+	if !ok {
+		m = newEdgeMetrics(g.logger, g.meter, tags)
+		g.edgeMetrics[tagsKey] = m
+	}
+
+	logger := edgeLogger(g.logger, req, direction)
+	for i, tag := range g.tags.Extra {
+		logger = logger.With(zap.String(tag.Name, extraValues[i]))
+	}
+	e := &edge{logger: logger, edgeMetrics: m}
 	g.edges[string(key)] = e
 	return e
 }
@@ -128,6 +150,11 @@ func (g *graph) createEdge(key []byte, req *transport.Request, direction string)
 type edge struct {
 	logger *zap.Logger
 
+	*edgeMetrics
+}
+
+// edgeMetrics are the metrics of an edge.
+type edgeMetrics struct {
 	calls          *metrics.Counter
 	successes      *metrics.Counter
 	callerFailures *metrics.CounterVector
@@ -138,10 +165,18 @@ type edge struct {
 	serverErrLatencies *metrics.Histogram
 }
 
-// newEdge constructs a new edge. Since Registries enforce metric uniqueness,
-// edges should be cached and re-used for each RPC.
+// newEdge constructs a new edge with the default tags. Since Registries
+// enforce metric uniqueness, edges should be cached and re-used for each RPC.
 func newEdge(logger *zap.Logger, meter *metrics.Scope, req *transport.Request, direction string) *edge {
-	tags := metrics.Tags{
+	return &edge{
+		logger:      edgeLogger(logger, req, direction),
+		edgeMetrics: newEdgeMetrics(logger, meter, defaultTags(req, direction)),
+	}
+}
+
+// defaultTags returns the default tags of the metrics of an edge.
+func defaultTags(req *transport.Request, direction string) metrics.Tags {
+	return metrics.Tags{
 		"source":           req.Caller,
 		"dest":             req.Service,
 		"transport":        unknownIfEmpty(req.Transport),
@@ -151,6 +186,26 @@ func newEdge(logger *zap.Logger, meter *metrics.Scope, req *transport.Request, d
 		"routing_delegate": req.RoutingDelegate,
 		"direction":        direction,
 	}
+}
+
+// edgeLogger returns the logger of an edge.
+func edgeLogger(logger *zap.Logger, req *transport.Request, direction string) *zap.Logger {
+	return logger.With(
+		zap.String("source", req.Caller),
+		zap.String("dest", req.Service),
+		zap.String("transport", unknownIfEmpty(req.Transport)),
+		zap.String("procedure", req.Procedure),
+		zap.String("encoding", string(req.Encoding)),
+		zap.String("routingKey", req.RoutingKey),
+		zap.String("routingDelegate", req.RoutingDelegate),
+		zap.String("direction", direction),
+	)
+}
+
+// newEdgeMetrics constructs the metrics of an edge with the given tags.
+// Since Registries enforce metric uniqueness, they should be cached and
+// re-used for each edge with the same tags.
+func newEdgeMetrics(logger *zap.Logger, meter *metrics.Scope, tags metrics.Tags) *edgeMetrics {
 	calls, err := meter.Counter(metrics.Spec{
 		Name:      "calls",
 		Help:      "Total number of RPCs.",
@@ -221,18 +276,7 @@ func newEdge(logger *zap.Logger, meter *metrics.Scope, req *transport.Request, d
 	if err != nil {
 		logger.Error("Failed to create server failure latency distribution.", zap.Error(err))
 	}
-	logger = logger.With(
-		zap.String("source", req.Caller),
-		zap.String("dest", req.Service),
-		zap.String("transport", unknownIfEmpty(req.Transport)),
-		zap.String("procedure", req.Procedure),
-		zap.String("encoding", string(req.Encoding)),
-		zap.String("routingKey", req.RoutingKey),
-		zap.String("routingDelegate", req.RoutingDelegate),
-		zap.String("direction", direction),
-	)
-	return &edge{
-		logger:             logger,
+	return &edgeMetrics{
 		calls:              calls,
 		successes:          successes,
 		callerFailures:     callerFailures,
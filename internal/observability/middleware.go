@@ -53,6 +53,12 @@ func (w *writer) SetApplicationError() {
 	w.ResponseWriter.SetApplicationError()
 }
 
+func (w *writer) SetApplicationErrorMeta(meta *transport.ApplicationErrorMeta) {
+	if setter, ok := w.ResponseWriter.(transport.ApplicationErrorMetaSetter); ok {
+		setter.SetApplicationErrorMeta(meta)
+	}
+}
+
 func (w *writer) free() {
 	_writerPool.Put(w)
 }
@@ -64,7 +70,13 @@ type Middleware struct {
 
 // NewMiddleware constructs a Middleware.
 func NewMiddleware(logger *zap.Logger, scope *metrics.Scope, extract ContextExtractor) *Middleware {
-	return &Middleware{newGraph(scope, logger, extract)}
+	return NewMiddlewareWithTags(logger, scope, extract, TagConfig{})
+}
+
+// NewMiddlewareWithTags constructs a Middleware whose metrics have the tags
+// of the given configuration, which must be valid.
+func NewMiddlewareWithTags(logger *zap.Logger, scope *metrics.Scope, extract ContextExtractor, tags TagConfig) *Middleware {
+	return &Middleware{newGraph(scope, logger, extract, tags)}
 }
 
 // Handle implements middleware.UnaryInbound.
@@ -0,0 +1,172 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package observability
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"go.uber.org/net/metrics"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/digester"
+)
+
+// _defaultTags are the names of the tags on the metrics of every edge.
+var _defaultTags = []string{
+	"source",
+	"dest",
+	"transport",
+	"procedure",
+	"encoding",
+	"routing_key",
+	"routing_delegate",
+	"direction",
+}
+
+// Tag is an additional tag on the metrics of every edge, whose value is
+// extracted from each request.
+type Tag struct {
+	Name  string
+	Value func(context.Context, *transport.Request) string
+}
+
+// TagConfig customizes the tags on the metrics of edges. Default tags are
+// dropped, then renamed, then the extra tags are added.
+type TagConfig struct {
+	// Extra are tags added to the default tags.
+	Extra []Tag
+
+	// Rename maps the names of default tags to their new names.
+	Rename map[string]string
+
+	// Drop are the names of default tags to leave out.
+	Drop []string
+}
+
+// Validate returns an error if the configuration renames or drops tags that
+// are not default tags, or if two tags end up with the same name.
+func (c TagConfig) Validate() error {
+	isDefault := make(map[string]bool, len(_defaultTags))
+	for _, name := range _defaultTags {
+		isDefault[name] = true
+	}
+	dropped := make(map[string]bool, len(c.Drop))
+	for _, name := range c.Drop {
+		if !isDefault[name] {
+			return fmt.Errorf("cannot drop metrics tag %q: not a default tag", name)
+		}
+		dropped[name] = true
+	}
+	for from := range c.Rename {
+		if !isDefault[from] {
+			return fmt.Errorf("cannot rename metrics tag %q: not a default tag", from)
+		}
+	}
+
+	// The error tag is always added to failure metrics.
+	names := map[string]bool{_error: true}
+	add := func(name string) error {
+		if name == "" {
+			return errors.New("metrics tags must have a name")
+		}
+		if names[name] {
+			return fmt.Errorf("metrics tag %q is used more than once", name)
+		}
+		names[name] = true
+		return nil
+	}
+	for _, name := range _defaultTags {
+		if dropped[name] {
+			continue
+		}
+		if to, ok := c.Rename[name]; ok {
+			name = to
+		}
+		if err := add(name); err != nil {
+			return err
+		}
+	}
+	for _, tag := range c.Extra {
+		if err := add(tag.Name); err != nil {
+			return err
+		}
+		if tag.Value == nil {
+			return fmt.Errorf("metrics tag %q has no value function", tag.Name)
+		}
+	}
+	return nil
+}
+
+// extraValues returns the values of the extra tags for a request.
+func (c TagConfig) extraValues(ctx context.Context, req *transport.Request) []string {
+	if len(c.Extra) == 0 {
+		return nil
+	}
+	values := make([]string, len(c.Extra))
+	for i, tag := range c.Extra {
+		values[i] = tag.Value(ctx, req)
+	}
+	return values
+}
+
+// apply returns the tags for the metrics of an edge with the given default
+// tags and values of the extra tags.
+func (c TagConfig) apply(defaults metrics.Tags, extraValues []string) metrics.Tags {
+	if len(c.Extra) == 0 && len(c.Rename) == 0 && len(c.Drop) == 0 {
+		return defaults
+	}
+	tags := make(metrics.Tags, len(defaults)+len(c.Extra))
+	for name, value := range defaults {
+		tags[name] = value
+	}
+	for _, name := range c.Drop {
+		delete(tags, name)
+	}
+	for from, to := range c.Rename {
+		if value, ok := tags[from]; ok {
+			delete(tags, from)
+			tags[to] = value
+		}
+	}
+	for i, tag := range c.Extra {
+		tags[tag.Name] = extraValues[i]
+	}
+	return tags
+}
+
+// tagsKey returns a key that identifies a set of tags.
+func tagsKey(tags metrics.Tags) string {
+	names := make([]string, 0, len(tags))
+	for name := range tags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	d := digester.New()
+	defer d.Free()
+	for _, name := range names {
+		d.Add(name)
+		d.Add(tags[name])
+	}
+	return string(d.Digest())
+}
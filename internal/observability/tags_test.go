@@ -0,0 +1,183 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package observability
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/net/metrics"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/zap"
+)
+
+func headerTag(name, header string) Tag {
+	return Tag{
+		Name: name,
+		Value: func(_ context.Context, req *transport.Request) string {
+			v, _ := req.Headers.Get(header)
+			return v
+		},
+	}
+}
+
+func TestTagConfigValidate(t *testing.T) {
+	tests := []struct {
+		desc    string
+		cfg     TagConfig
+		wantErr string
+	}{
+		{desc: "empty"},
+		{
+			desc: "valid",
+			cfg: TagConfig{
+				Extra:  []Tag{headerTag("tenant", "x-tenant")},
+				Rename: map[string]string{"source": "caller", "dest": "callee"},
+				Drop:   []string{"routing_key", "routing_delegate"},
+			},
+		},
+		{
+			desc: "rename to dropped name",
+			cfg: TagConfig{
+				Rename: map[string]string{"source": "routing_key"},
+				Drop:   []string{"routing_key"},
+			},
+		},
+		{
+			desc:    "drop unknown tag",
+			cfg:     TagConfig{Drop: []string{"tenant"}},
+			wantErr: `cannot drop metrics tag "tenant": not a default tag`,
+		},
+		{
+			desc:    "rename unknown tag",
+			cfg:     TagConfig{Rename: map[string]string{"tenant": "customer"}},
+			wantErr: `cannot rename metrics tag "tenant": not a default tag`,
+		},
+		{
+			desc:    "rename to default tag",
+			cfg:     TagConfig{Rename: map[string]string{"source": "dest"}},
+			wantErr: `metrics tag "dest" is used more than once`,
+		},
+		{
+			desc:    "rename to empty name",
+			cfg:     TagConfig{Rename: map[string]string{"source": ""}},
+			wantErr: "metrics tags must have a name",
+		},
+		{
+			desc:    "extra default tag",
+			cfg:     TagConfig{Extra: []Tag{headerTag("procedure", "x-procedure")}},
+			wantErr: `metrics tag "procedure" is used more than once`,
+		},
+		{
+			desc:    "extra error tag",
+			cfg:     TagConfig{Extra: []Tag{headerTag("error", "x-error")}},
+			wantErr: `metrics tag "error" is used more than once`,
+		},
+		{
+			desc:    "extra tag without value",
+			cfg:     TagConfig{Extra: []Tag{{Name: "tenant"}}},
+			wantErr: `metrics tag "tenant" has no value function`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Equal(t, tt.wantErr, err.Error())
+		})
+	}
+}
+
+func TestMiddlewareTagsSnapshot(t *testing.T) {
+	defer stubTime()()
+	root := metrics.New()
+	meter := root.Scope()
+	mw := NewMiddlewareWithTags(zap.NewNop(), meter, NewNopContextExtractor(), TagConfig{
+		Extra:  []Tag{headerTag("tenant", "x-tenant")},
+		Rename: map[string]string{"source": "caller"},
+		Drop:   []string{"routing_key", "routing_delegate", "transport"},
+	})
+
+	// The requests differ only by dropped tags, so they share metrics.
+	for _, rk := range []string{"rk1", "rk2"} {
+		err := mw.Handle(
+			context.Background(),
+			&transport.Request{
+				Caller:     "caller",
+				Service:    "service",
+				Transport:  "http",
+				Encoding:   "raw",
+				Procedure:  "procedure",
+				Headers:    transport.NewHeaders().With("x-tenant", "acme"),
+				RoutingKey: rk,
+				Body:       strings.NewReader("body"),
+			},
+			&transporttest.FakeResponseWriter{},
+			fakeHandler{nil, false},
+		)
+		assert.NoError(t, err, "Unexpected transport error.")
+	}
+
+	snap := root.Snapshot()
+	tags := metrics.Tags{
+		"caller":    "caller",
+		"dest":      "service",
+		"direction": "inbound",
+		"encoding":  "raw",
+		"procedure": "procedure",
+		"tenant":    "acme",
+	}
+	want := &metrics.RootSnapshot{
+		Counters: []metrics.Snapshot{
+			{Name: "calls", Tags: tags, Value: 2},
+			{Name: "successes", Tags: tags, Value: 2},
+		},
+		Histograms: []metrics.HistogramSnapshot{
+			{
+				Name: "caller_failure_latency_ms",
+				Tags: tags,
+				Unit: time.Millisecond,
+			},
+			{
+				Name: "server_failure_latency_ms",
+				Tags: tags,
+				Unit: time.Millisecond,
+			},
+			{
+				Name:   "success_latency_ms",
+				Tags:   tags,
+				Unit:   time.Millisecond,
+				Values: []int64{1, 1},
+			},
+		},
+	}
+	assert.Equal(t, want, snap, "Unexpected snapshot of metrics.")
+}
@@ -0,0 +1,54 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package onclose runs functions when the bodies of responses are closed.
+package onclose
+
+import (
+	"io"
+	"sync"
+
+	"go.uber.org/yarpc/api/transport"
+)
+
+// Response arranges for f to run once the body of the response is closed.
+// Middleware uses it to release the context of a call, since the body may
+// still be read with that context after the call returns. f runs at once if
+// there is no body.
+func Response(res *transport.Response, f func()) {
+	if res == nil || res.Body == nil {
+		f()
+		return
+	}
+	res.Body = &body{ReadCloser: res.Body, f: f}
+}
+
+type body struct {
+	io.ReadCloser
+
+	once sync.Once
+	f    func()
+}
+
+func (b *body) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.f)
+	return err
+}
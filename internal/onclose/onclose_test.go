@@ -0,0 +1,60 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package onclose
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+)
+
+func TestResponse(t *testing.T) {
+	t.Run("body", func(t *testing.T) {
+		var calls int
+		res := &transport.Response{Body: ioutil.NopCloser(bytes.NewReader([]byte("hello")))}
+		Response(res, func() { calls++ })
+		assert.Equal(t, 0, calls, "must not run before the body is closed")
+
+		b, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "hello", string(b))
+
+		require.NoError(t, res.Body.Close())
+		require.NoError(t, res.Body.Close())
+		assert.Equal(t, 1, calls, "must run once when the body is closed")
+	})
+
+	t.Run("no body", func(t *testing.T) {
+		var calls int
+		Response(&transport.Response{}, func() { calls++ })
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("no response", func(t *testing.T) {
+		var calls int
+		Response(nil, func() { calls++ })
+		assert.Equal(t, 1, calls)
+	})
+}
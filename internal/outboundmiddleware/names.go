@@ -0,0 +1,61 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package outboundmiddleware
+
+import (
+	"fmt"
+
+	"go.uber.org/yarpc/api/middleware"
+)
+
+// Names returns the names of the middleware in the given middleware, which
+// may be a chain, in the order they see requests. Middleware are named by
+// their Go types, and no-op middleware are left out.
+func Names(mw interface{}) []string {
+	switch mw := mw.(type) {
+	case nil:
+		return nil
+	case unaryChain:
+		var names []string
+		for _, m := range mw {
+			names = append(names, Names(m)...)
+		}
+		return names
+	case onewayChain:
+		var names []string
+		for _, m := range mw {
+			names = append(names, Names(m)...)
+		}
+		return names
+	case streamChain:
+		var names []string
+		for _, m := range mw {
+			names = append(names, Names(m)...)
+		}
+		return names
+	}
+
+	switch mw {
+	case middleware.NopUnaryOutbound, middleware.NopOnewayOutbound, middleware.NopStreamOutbound:
+		return nil
+	}
+	return []string{fmt.Sprintf("%T", mw)}
+}
@@ -0,0 +1,44 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package reflectencoding implements procedures and clients for encodings
+// whose request and response bodies are Go structs marshaled by a standard
+// library codec, such as the XML and gob encodings. Handlers are plain
+// functions which are called through reflection.
+package reflectencoding
+
+import (
+	"io"
+
+	"go.uber.org/yarpc/api/transport"
+)
+
+// Codec describes how an encoding marshals bodies.
+type Codec struct {
+	// Encoding is the name of the encoding. Inbound requests must use this
+	// encoding, and outbound requests are sent with it.
+	Encoding transport.Encoding
+
+	// Encode writes v to w.
+	Encode func(w io.Writer, v interface{}) error
+
+	// Decode reads from r into v, which is a pointer.
+	Decode func(r io.Reader, v interface{}) error
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package reflectencoding
+
+import (
+	"context"
+	"reflect"
+
+	encodingapi "go.uber.org/yarpc/api/encoding"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/pkg/errors"
+)
+
+// reflectHandler adapts a user-provided high-level handler into a
+// transport-level Handler.
+//
+// The wrapped function must already be in the correct format:
+//
+// 	f(ctx context.Context, body $reqBody) ($resBody, error)
+type reflectHandler struct {
+	codec Codec
+
+	// Type of the request struct (not a pointer to the struct)
+	reqType reflect.Type
+	handler reflect.Value
+}
+
+func (h reflectHandler) Handle(ctx context.Context, treq *transport.Request, rw transport.ResponseWriter) error {
+	if err := errors.ExpectEncodings(treq, h.codec.Encoding); err != nil {
+		return err
+	}
+
+	ctx, call := encodingapi.NewInboundCall(ctx)
+	if err := call.ReadFromRequest(treq); err != nil {
+		return err
+	}
+
+	reqBody, err := h.readRequest(treq)
+	if err != nil {
+		return errors.RequestBodyDecodeError(treq, err)
+	}
+
+	results := h.handler.Call([]reflect.Value{reflect.ValueOf(ctx), reqBody})
+
+	if err := call.WriteToResponse(rw); err != nil {
+		return err
+	}
+
+	// we want to return the appErr if it exists as this is what
+	// the previous behavior was so we deprioritize this error
+	var encodeErr error
+	if !results[0].IsNil() {
+		if err := h.codec.Encode(rw, results[0].Interface()); err != nil {
+			encodeErr = errors.ResponseBodyEncodeError(treq, err)
+		}
+	}
+
+	if appErr, _ := results[1].Interface().(error); appErr != nil {
+		rw.SetApplicationError()
+		return appErr
+	}
+
+	return encodeErr
+}
+
+func (h reflectHandler) HandleOneway(ctx context.Context, treq *transport.Request) error {
+	if err := errors.ExpectEncodings(treq, h.codec.Encoding); err != nil {
+		return err
+	}
+
+	ctx, call := encodingapi.NewInboundCall(ctx)
+	if err := call.ReadFromRequest(treq); err != nil {
+		return err
+	}
+
+	reqBody, err := h.readRequest(treq)
+	if err != nil {
+		return errors.RequestBodyDecodeError(treq, err)
+	}
+
+	results := h.handler.Call([]reflect.Value{reflect.ValueOf(ctx), reqBody})
+
+	if err := results[0].Interface(); err != nil {
+		return err.(error)
+	}
+
+	return nil
+}
+
+// readRequest decodes the request body into a new instance of the request
+// struct.
+func (h reflectHandler) readRequest(treq *transport.Request) (reflect.Value, error) {
+	value := reflect.New(h.reqType)
+	err := h.codec.Decode(treq.Body, value.Interface())
+	return value, err
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package reflectencoding
+
+import (
+	"bytes"
+	"context"
+
+	"go.uber.org/yarpc"
+	encodingapi "go.uber.org/yarpc/api/encoding"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/pkg/encoding"
+	"go.uber.org/yarpc/pkg/errors"
+)
+
+// Call performs an outbound request to the service of the client config.
+// resBodyOut is a pointer to a value that can be filled by Decode.
+func (c Codec) Call(ctx context.Context, cc transport.ClientConfig, procedure string, reqBody interface{}, resBodyOut interface{}, opts []yarpc.CallOption) error {
+	call := encodingapi.NewOutboundCall(encoding.FromOptions(opts)...)
+	treq := transport.Request{
+		Caller:    cc.Caller(),
+		Service:   cc.Service(),
+		Procedure: procedure,
+		Encoding:  c.Encoding,
+	}
+
+	ctx, err := call.WriteToRequest(ctx, &treq)
+	if err != nil {
+		return err
+	}
+
+	var buff bytes.Buffer
+	if err := c.Encode(&buff, reqBody); err != nil {
+		return errors.RequestBodyEncodeError(&treq, err)
+	}
+
+	treq.Body = &buff
+	tres, appErr := cc.GetUnaryOutbound().Call(ctx, &treq)
+	if tres == nil {
+		return appErr
+	}
+
+	// we want to return the appErr if it exists as this is what
+	// the previous behavior was so we deprioritize this error
+	var decodeErr error
+	if _, err = call.ReadFromResponse(ctx, tres); err != nil {
+		decodeErr = err
+	}
+	if tres.Body != nil {
+		if err := c.Decode(tres.Body, resBodyOut); err != nil && decodeErr == nil {
+			decodeErr = errors.ResponseBodyDecodeError(&treq, err)
+		}
+		if err := tres.Body.Close(); err != nil && decodeErr == nil {
+			decodeErr = err
+		}
+	}
+
+	if appErr != nil {
+		return appErr
+	}
+	return decodeErr
+}
+
+// CallOneway performs an outbound oneway request to the service of the
+// client config.
+func (c Codec) CallOneway(ctx context.Context, cc transport.ClientConfig, procedure string, reqBody interface{}, opts []yarpc.CallOption) (transport.Ack, error) {
+	call := encodingapi.NewOutboundCall(encoding.FromOptions(opts)...)
+	treq := transport.Request{
+		Caller:    cc.Caller(),
+		Service:   cc.Service(),
+		Procedure: procedure,
+		Encoding:  c.Encoding,
+	}
+
+	ctx, err := call.WriteToRequest(ctx, &treq)
+	if err != nil {
+		return nil, err
+	}
+
+	var buff bytes.Buffer
+	if err := c.Encode(&buff, reqBody); err != nil {
+		return nil, errors.RequestBodyEncodeError(&treq, err)
+	}
+	treq.Body = &buff
+
+	return cc.GetOnewayOutbound().CallOneway(ctx, &treq)
+}
@@ -0,0 +1,136 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package reflectencoding
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var _testCodec = Codec{
+	Encoding: "test",
+	Encode: func(w io.Writer, v interface{}) error {
+		return json.NewEncoder(w).Encode(v)
+	},
+	Decode: func(r io.Reader, v interface{}) error {
+		return json.NewDecoder(r).Decode(v)
+	},
+}
+
+func TestWrapUnaryHandlerInvalid(t *testing.T) {
+	tests := []struct {
+		Name string
+		Func interface{}
+	}{
+		{"empty", func() {}},
+		{"not-a-function", 0},
+		{
+			"wrong-args-in",
+			func(context.Context) (*struct{}, error) {
+				return nil, nil
+			},
+		},
+		{
+			"wrong-ctx",
+			func(string, *struct{}) (*struct{}, error) {
+				return nil, nil
+			},
+		},
+		{
+			"map-req",
+			func(context.Context, map[string]interface{}) (*struct{}, error) {
+				return nil, nil
+			},
+		},
+		{
+			"non-pointer-req",
+			func(context.Context, struct{}) (*struct{}, error) {
+				return nil, nil
+			},
+		},
+		{
+			"non-pointer-res",
+			func(context.Context, *struct{}) (struct{}, error) {
+				return struct{}{}, nil
+			},
+		},
+		{
+			"second-return-value-not-error",
+			func(context.Context, *struct{}) (*struct{}, *struct{}) {
+				return nil, nil
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		assert.Panics(t, assert.PanicTestFunc(func() {
+			_testCodec.wrapUnaryHandler(tt.Name, tt.Func)
+		}), tt.Name)
+	}
+}
+
+func TestWrapOnewayHandlerInvalid(t *testing.T) {
+	tests := []struct {
+		Name string
+		Func interface{}
+	}{
+		{"empty", func() {}},
+		{
+			"interface-req",
+			func(context.Context, interface{}) error {
+				return nil
+			},
+		},
+		{
+			"return-not-error",
+			func(context.Context, *struct{}) *struct{} {
+				return nil
+			},
+		},
+		{
+			"too-many-results",
+			func(context.Context, *struct{}) (*struct{}, error) {
+				return nil, nil
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		assert.Panics(t, assert.PanicTestFunc(func() {
+			_testCodec.wrapOnewayHandler(tt.Name, tt.Func)
+		}), tt.Name)
+	}
+}
+
+func TestWrapHandlersValid(t *testing.T) {
+	assert.NotPanics(t, func() {
+		_testCodec.wrapUnaryHandler("foo", func(context.Context, *struct{}) (*struct{}, error) {
+			return nil, nil
+		})
+		_testCodec.wrapOnewayHandler("bar", func(context.Context, *struct{}) error {
+			return nil
+		})
+	})
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tlsidentity
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromConnectionState(t *testing.T) {
+	spiffeID, err := url.Parse("spiffe://example.com/frontend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	verified := func(cert *x509.Certificate) *tls.ConnectionState {
+		return &tls.ConnectionState{
+			PeerCertificates: []*x509.Certificate{cert},
+			VerifiedChains:   [][]*x509.Certificate{{cert}},
+		}
+	}
+	cert := &x509.Certificate{
+		Subject:  pkix.Name{CommonName: "frontend"},
+		DNSNames: []string{"frontend.example.com"},
+		URIs:     []*url.URL{spiffeID},
+	}
+
+	tests := []struct {
+		desc  string
+		state *tls.ConnectionState
+		want  string
+	}{
+		{desc: "no TLS"},
+		{
+			desc:  "unverified certificate",
+			state: &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}},
+		},
+		{
+			desc:  "URI",
+			state: verified(cert),
+			want:  "spiffe://example.com/frontend",
+		},
+		{
+			desc: "DNS name",
+			state: verified(&x509.Certificate{
+				Subject:  pkix.Name{CommonName: "frontend"},
+				DNSNames: []string{"frontend.example.com"},
+			}),
+			want: "frontend.example.com",
+		},
+		{
+			desc:  "common name",
+			state: verified(&x509.Certificate{Subject: pkix.Name{CommonName: "frontend"}}),
+			want:  "frontend",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert.Equal(t, tt.want, FromConnectionState(tt.state))
+		})
+	}
+}
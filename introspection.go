@@ -23,16 +23,20 @@ package yarpc
 import (
 	"fmt"
 	"runtime"
+	"sort"
 
 	tchannel "github.com/uber/tchannel-go"
 	thriftrw "go.uber.org/thriftrw/version"
-	"go.uber.org/yarpc/internal/introspection"
+	"go.uber.org/yarpc/api/introspection"
+	"go.uber.org/yarpc/internal/inboundmiddleware"
+	internalintrospection "go.uber.org/yarpc/internal/introspection"
+	"go.uber.org/yarpc/internal/outboundmiddleware"
 )
 
-// Introspect returns detailed information about the dispatcher. This function
-// acquires a lots of locks throughout and should only be called with some
-// reserve. This method is public merely for use by the package yarpcmeta. The
-// result of this function is internal to yarpc anyway.
+// Introspect returns detailed information about the dispatcher: the
+// procedures it serves, its inbounds, outbounds and their peers, transports,
+// middleware, and metrics. This function acquires a lots of locks throughout
+// and should only be called with some reserve, like for a debug page.
 func (d *Dispatcher) Introspect() introspection.DispatcherStatus {
 	var inbounds []introspection.InboundStatus
 	for _, i := range d.inbounds {
@@ -47,7 +51,7 @@ func (d *Dispatcher) Introspect() introspection.DispatcherStatus {
 		inbounds = append(inbounds, status)
 	}
 	var outbounds []introspection.OutboundStatus
-	for outboundKey, o := range d.outbounds {
+	for outboundKey, o := range d.currentOutbounds() {
 		if o.Unary != nil {
 			var status introspection.OutboundStatus
 			if o, ok := o.Unary.(introspection.IntrospectableOutbound); ok {
@@ -72,15 +76,65 @@ func (d *Dispatcher) Introspect() introspection.DispatcherStatus {
 			status.OutboundKey = outboundKey
 			outbounds = append(outbounds, status)
 		}
+		if o.Stream != nil {
+			var status introspection.OutboundStatus
+			if o, ok := o.Stream.(introspection.IntrospectableOutbound); ok {
+				status = o.Introspect()
+			} else {
+				status.Transport = "Introspection not supported"
+			}
+			status.RPCType = "stream"
+			status.Service = o.ServiceName
+			status.OutboundKey = outboundKey
+			outbounds = append(outbounds, status)
+		}
+	}
+	sort.Slice(outbounds, func(i, j int) bool {
+		if outbounds[i].OutboundKey != outbounds[j].OutboundKey {
+			return outbounds[i].OutboundKey < outbounds[j].OutboundKey
+		}
+		return outbounds[i].RPCType < outbounds[j].RPCType
+	})
+	var transports []introspection.TransportStatus
+	for _, t := range d.currentTransports() {
+		state := "Stopped"
+		if t.IsRunning() {
+			state = "Running"
+		}
+		transports = append(transports, introspection.TransportStatus{
+			Type:  fmt.Sprintf("%T", t),
+			State: state,
+		})
+	}
+	sort.Slice(transports, func(i, j int) bool { return transports[i].Type < transports[j].Type })
+	middleware := introspection.MiddlewareStatus{
+		Inbound: introspection.MiddlewareChain{
+			Unary:  inboundmiddleware.Names(d.inboundMiddleware.Unary),
+			Oneway: inboundmiddleware.Names(d.inboundMiddleware.Oneway),
+			Stream: inboundmiddleware.Names(d.inboundMiddleware.Stream),
+		},
+		Outbound: introspection.MiddlewareChain{
+			Unary:  outboundmiddleware.Names(d.outboundMiddleware.Unary),
+			Oneway: outboundmiddleware.Names(d.outboundMiddleware.Oneway),
+			Stream: outboundmiddleware.Names(d.outboundMiddleware.Stream),
+		},
+	}
+	procedures := internalintrospection.IntrospectProcedures(d.table.Procedures())
+	var metricsStatus *introspection.MetricsStatus
+	if d.metricsRoot != nil {
+		s := internalintrospection.NewMetricsStatus(d.metricsRoot.Snapshot(), outbounds)
+		metricsStatus = &s
 	}
-	procedures := introspection.IntrospectProcedures(d.table.Procedures())
 	return introspection.DispatcherStatus{
 		Name:            d.name,
 		ID:              fmt.Sprintf("%p", d),
 		Procedures:      procedures,
 		Inbounds:        inbounds,
 		Outbounds:       outbounds,
+		Transports:      transports,
+		Middleware:      middleware,
 		PackageVersions: PackageVersions,
+		Metrics:         metricsStatus,
 	}
 }
 
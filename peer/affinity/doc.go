@@ -0,0 +1,36 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package affinity provides an implementation of a peer list that pins
+// requests to peers by the value of an application header, such as a user
+// or session ID, so that stateful backend sessions keep reaching the same
+// instance.
+//
+// Peers are chosen by rendezvous hashing: every available peer is scored
+// against the header value, and the peer with the highest score is chosen.
+// If the pinned peer is removed or becomes unavailable, its requests go to
+// the peer with the next highest score, and return to the pinned peer once
+// it is available again. Requests for other header values do not move.
+//
+// 	list := affinity.New(transport, "x-user-id")
+//
+// Requests without the header are spread across the available peers in
+// round-robin order.
+package affinity
@@ -0,0 +1,81 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package affinity
+
+import (
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/hrw"
+	"go.uber.org/yarpc/peer/peerlist"
+)
+
+type listConfig struct {
+	capacity int
+}
+
+var defaultListConfig = listConfig{
+	capacity: 10,
+}
+
+// ListOption customizes the behavior of a session affinity peer list.
+type ListOption func(*listConfig)
+
+// Capacity specifies the default capacity of the underlying
+// data structures for this list.
+//
+// Defaults to 10.
+func Capacity(capacity int) ListOption {
+	return func(c *listConfig) {
+		c.capacity = capacity
+	}
+}
+
+// New creates a new session affinity peer list, which pins requests to peers
+// by the value of the named application header.
+func New(transport peer.Transport, header string, opts ...ListOption) *List {
+	cfg := defaultListConfig
+	for _, o := range opts {
+		o(&cfg)
+	}
+
+	return &List{
+		List: hrw.NewList(
+			"session-affinity",
+			transport,
+			headerKey(header),
+			peerlist.Capacity(cfg.capacity),
+		),
+	}
+}
+
+// headerKey returns the value of the given header as the key of requests.
+func headerKey(header string) hrw.KeyFunc {
+	return func(req *transport.Request) string {
+		v, _ := req.Headers.Get(header)
+		return v
+	}
+}
+
+// List is a PeerList which pins requests to peers by the value of an
+// application header.
+type List struct {
+	*peerlist.List
+}
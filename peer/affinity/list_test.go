@@ -0,0 +1,136 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package affinity
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/peerlisttest"
+	"go.uber.org/yarpc/peer/hostport"
+	"go.uber.org/yarpc/yarpctest"
+)
+
+const _header = "x-user-id"
+
+func newStartedList(t *testing.T, ids ...string) *List {
+	pl := New(yarpctest.NewFakeTransport(), _header)
+	require.NoError(t, pl.Start())
+	require.NoError(t, pl.Update(peer.ListUpdates{Additions: identifiers(ids...)}))
+	return pl
+}
+
+func identifiers(ids ...string) []peer.Identifier {
+	pids := make([]peer.Identifier, len(ids))
+	for i, id := range ids {
+		pids[i] = hostport.PeerIdentifier(id)
+	}
+	return pids
+}
+
+// route returns the identifier of the peer chosen for each of n users.
+func route(t *testing.T, pl *List, n int) map[string]string {
+	return peerlisttest.RouteRequests(t, pl, n, func(user string) *transport.Request {
+		return &transport.Request{Headers: transport.NewHeaders().With(_header, user)}
+	})
+}
+
+func TestAffinityPinsSessions(t *testing.T) {
+	const numUsers = 1000
+
+	pl := newStartedList(t, "1.1.1.1:1", "2.2.2.2:2", "3.3.3.3:3", "4.4.4.4:4")
+	defer pl.Stop()
+
+	before := route(t, pl, numUsers)
+	assert.Equal(t, before, route(t, pl, numUsers), "sessions must stay pinned")
+
+	counts := make(map[string]int)
+	for _, id := range before {
+		counts[id]++
+	}
+	require.Len(t, counts, 4, "every peer must receive sessions")
+	for id, n := range counts {
+		assert.InDelta(t, numUsers/4, n, numUsers/10, "sessions must spread evenly, got %d for %v", n, id)
+	}
+
+	t.Run("sessions fall back while their peer is gone", func(t *testing.T) {
+		require.NoError(t, pl.Update(peer.ListUpdates{Removals: identifiers("2.2.2.2:2")}))
+		after := route(t, pl, numUsers)
+		for user, id := range before {
+			if id == "2.2.2.2:2" {
+				assert.NotEqual(t, "2.2.2.2:2", after[user])
+			} else {
+				assert.Equal(t, id, after[user], "session %q must not move", user)
+			}
+		}
+
+		require.NoError(t, pl.Update(peer.ListUpdates{Additions: identifiers("2.2.2.2:2")}))
+		assert.Equal(t, before, route(t, pl, numUsers), "sessions must return to their peer")
+	})
+}
+
+func TestAffinityIndependentOfInsertionOrder(t *testing.T) {
+	a := newStartedList(t, "1.1.1.1:1", "2.2.2.2:2", "3.3.3.3:3")
+	defer a.Stop()
+	b := newStartedList(t, "3.3.3.3:3", "1.1.1.1:1", "2.2.2.2:2")
+	defer b.Stop()
+
+	assert.Equal(t, route(t, a, 100), route(t, b, 100))
+}
+
+func TestAffinityWithoutHeader(t *testing.T) {
+	pl := newStartedList(t, "1.1.1.1:1", "2.2.2.2:2", "3.3.3.3:3")
+	defer pl.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	counts := make(map[string]int)
+	for i := 0; i < 30; i++ {
+		p, onFinish, err := pl.Choose(ctx, &transport.Request{})
+		require.NoError(t, err)
+		onFinish(nil)
+		counts[p.Identifier()]++
+	}
+	assert.Equal(t, map[string]int{
+		"1.1.1.1:1": 10,
+		"2.2.2.2:2": 10,
+		"3.3.3.3:3": 10,
+	}, counts)
+}
+
+func TestAffinityEmpty(t *testing.T) {
+	pl := newStartedList(t)
+	defer pl.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, _, err := pl.Choose(ctx, &transport.Request{
+		Headers: transport.NewHeaders().With(_header, "user-1"),
+	})
+	assert.Error(t, err)
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package peer
+
+import (
+	"context"
+
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+)
+
+// Chain combines a series of ChooserMiddleware into a single
+// ChooserMiddleware. The first middleware is the outermost: it is called
+// first, and its next chooser calls the second middleware, and so on, until
+// the last middleware calls the chooser the chain is applied to.
+//
+// Apply the chain with ApplyChooserMiddleware from
+// go.uber.org/yarpc/api/peer:
+//
+// 	chooser := apipeer.ApplyChooserMiddleware(list, peer.Chain(metrics, filter))
+func Chain(mw ...peer.ChooserMiddleware) peer.ChooserMiddleware {
+	unchained := make([]peer.ChooserMiddleware, 0, len(mw))
+	for _, m := range mw {
+		if m == nil {
+			continue
+		}
+		if c, ok := m.(chooserChain); ok {
+			unchained = append(unchained, c...)
+			continue
+		}
+		unchained = append(unchained, m)
+	}
+
+	switch len(unchained) {
+	case 0:
+		return peer.NopChooserMiddleware
+	case 1:
+		return unchained[0]
+	default:
+		return chooserChain(unchained)
+	}
+}
+
+type chooserChain []peer.ChooserMiddleware
+
+func (c chooserChain) Choose(ctx context.Context, req *transport.Request, next peer.Chooser) (peer.Peer, func(error), error) {
+	return chooserChainExec{
+		Chain: []peer.ChooserMiddleware(c),
+		Final: next,
+	}.Choose(ctx, req)
+}
+
+// chooserChainExec adapts a series of ChooserMiddleware into a Chooser. It
+// is scoped to a single call of a Chooser and is not thread-safe.
+type chooserChainExec struct {
+	Chain []peer.ChooserMiddleware
+	Final peer.Chooser
+}
+
+func (x chooserChainExec) Start() error {
+	return x.Final.Start()
+}
+
+func (x chooserChainExec) Stop() error {
+	return x.Final.Stop()
+}
+
+func (x chooserChainExec) IsRunning() bool {
+	return x.Final.IsRunning()
+}
+
+func (x chooserChainExec) Choose(ctx context.Context, req *transport.Request) (peer.Peer, func(error), error) {
+	if len(x.Chain) == 0 {
+		return x.Final.Choose(ctx, req)
+	}
+	next := x.Chain[0]
+	x.Chain = x.Chain[1:]
+	return next.Choose(ctx, req, x)
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package peer_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	. "go.uber.org/yarpc/peer"
+	"go.uber.org/yarpc/peer/hostport"
+	"go.uber.org/yarpc/yarpctest"
+)
+
+func TestChain(t *testing.T) {
+	var calls []string
+	record := func(name string) peer.ChooserMiddleware {
+		return peer.ChooserMiddlewareFunc(func(ctx context.Context, req *transport.Request, next peer.Chooser) (peer.Peer, func(error), error) {
+			calls = append(calls, name)
+			return next.Choose(ctx, req)
+		})
+	}
+
+	trans := yarpctest.NewFakeTransport()
+	chooser := peer.ApplyChooserMiddleware(
+		NewSingle(hostport.PeerIdentifier("a:1"), trans),
+		Chain(record("a"), nil, Chain(record("b"), record("c")), record("d")),
+	)
+	require.NoError(t, chooser.Start())
+	defer chooser.Stop()
+
+	p, onFinish, err := chooser.Choose(context.Background(), &transport.Request{})
+	require.NoError(t, err)
+	onFinish(nil)
+	assert.Equal(t, "a:1", p.Identifier())
+	assert.Equal(t, []string{"a", "b", "c", "d"}, calls, "middleware must be called in order")
+
+	calls = nil
+	_, onFinish, err = chooser.Choose(context.Background(), &transport.Request{})
+	require.NoError(t, err)
+	onFinish(nil)
+	assert.Equal(t, []string{"a", "b", "c", "d"}, calls, "chains must be reusable")
+}
+
+func TestChainDegenerate(t *testing.T) {
+	assert.Equal(t, peer.NopChooserMiddleware, Chain())
+	assert.Equal(t, peer.NopChooserMiddleware, Chain(nil, nil))
+
+	mw := peer.ChooserMiddlewareFunc(func(ctx context.Context, req *transport.Request, next peer.Chooser) (peer.Peer, func(error), error) {
+		return next.Choose(ctx, req)
+	})
+	_, ok := Chain(nil, mw).(peer.ChooserMiddlewareFunc)
+	assert.True(t, ok, "a single middleware must not be wrapped")
+}
@@ -0,0 +1,206 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package circuitbreaker
+
+import (
+	"fmt"
+	"sync"
+
+	"go.uber.org/net/metrics"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/internal/clock"
+)
+
+// State is the state of a circuit breaker.
+type State int
+
+const (
+	// Closed breakers let every request through.
+	Closed State = iota
+	// Open breakers let no request through.
+	Open
+	// HalfOpen breakers let a single probe request through.
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("State(%d)", int(s))
+	}
+}
+
+var _ peer.Peer = (*breakerPeer)(nil)
+
+// breakerPeer wraps a peer with a circuit breaker. Its status is the status
+// of the wrapped peer, except that it is unavailable while the breaker does
+// not let requests through.
+type breakerPeer struct {
+	peer.Peer
+
+	opts *options
+
+	mu          sync.Mutex
+	subscribers map[peer.Subscriber]struct{}
+	state       State
+	failures    int
+	probing     bool
+	timer       clock.Timer
+
+	stateGauge *metrics.Gauge
+	trips      *metrics.Counter
+}
+
+// Status returns the status of the wrapped peer, reporting it unavailable
+// while the breaker is open, or half-open with a probe in flight.
+func (p *breakerPeer) Status() peer.Status {
+	status := p.Peer.Status()
+
+	p.mu.Lock()
+	blocked := p.state == Open || (p.state == HalfOpen && p.probing)
+	p.mu.Unlock()
+
+	if blocked {
+		status.ConnectionStatus = peer.Unavailable
+	}
+	return status
+}
+
+// State returns the state of the peer's circuit breaker.
+func (p *breakerPeer) State() State {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.state
+}
+
+// StartRequest marks the start of a request. In the half-open state, the
+// request is the probe, and the peer is unavailable until it finishes.
+func (p *breakerPeer) StartRequest() {
+	p.Peer.StartRequest()
+
+	p.mu.Lock()
+	notify := p.state == HalfOpen && !p.probing
+	if notify {
+		p.probing = true
+	}
+	p.mu.Unlock()
+
+	if notify {
+		p.notify()
+	}
+}
+
+// observe records the result of a request to the peer.
+func (p *breakerPeer) observe(err error) {
+	failed := p.opts.isFailure(err)
+
+	p.mu.Lock()
+	var notify bool
+	switch {
+	case p.state == HalfOpen && p.probing:
+		p.probing = false
+		if failed {
+			p.open()
+		} else {
+			p.setState(Closed)
+			p.failures = 0
+		}
+		notify = true
+	case p.state == Closed && failed:
+		p.failures++
+		if p.failures >= p.opts.failureThreshold {
+			p.open()
+			notify = true
+		}
+	case p.state == Closed:
+		p.failures = 0
+	}
+	p.mu.Unlock()
+
+	if notify {
+		p.notify()
+	}
+}
+
+// open opens the breaker and schedules it to become half-open.
+//
+// Must be called with the lock held.
+func (p *breakerPeer) open() {
+	p.setState(Open)
+	p.failures = 0
+	if p.trips != nil {
+		p.trips.Inc()
+	}
+	p.timer = p.opts.clock.AfterFunc(p.opts.openTimeout, p.halfOpen)
+}
+
+func (p *breakerPeer) halfOpen() {
+	p.mu.Lock()
+	changed := p.state == Open
+	if changed {
+		p.setState(HalfOpen)
+		p.timer = nil
+	}
+	p.mu.Unlock()
+
+	if changed {
+		p.notify()
+	}
+}
+
+// Must be called with the lock held.
+func (p *breakerPeer) setState(s State) {
+	p.state = s
+	if p.stateGauge != nil {
+		p.stateGauge.Store(int64(s))
+	}
+}
+
+// stop cancels any pending transition of the breaker.
+func (p *breakerPeer) stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.timer != nil {
+		p.timer.Stop()
+		p.timer = nil
+	}
+}
+
+// notify tells every subscriber that the peer's status may have changed.
+// Must be called without the lock held, since subscribers read the status.
+func (p *breakerPeer) notify() {
+	p.mu.Lock()
+	subscribers := make([]peer.Subscriber, 0, len(p.subscribers))
+	for s := range p.subscribers {
+		subscribers = append(subscribers, s)
+	}
+	p.mu.Unlock()
+
+	for _, s := range subscribers {
+		s.NotifyStatusChanged(p)
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/net/metrics"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/internal/testtime"
+	"go.uber.org/yarpc/peer/hostport"
+	"go.uber.org/yarpc/peer/roundrobin"
+	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/yarpc/yarpctest"
+)
+
+var errUnavailable = yarpcerrors.UnavailableErrorf("unavailable")
+
+func withClock(c clock.Clock) Option {
+	return optionFunc(func(o *options) {
+		o.clock = c
+	})
+}
+
+// callPeer chooses peers until the given peer is chosen, and finishes that
+// request with err. Requests to other peers succeed.
+func callPeer(t *testing.T, l *List, id string, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	for i := 0; i < 10; i++ {
+		p, onFinish, chooseErr := l.Choose(ctx, &transport.Request{})
+		require.NoError(t, chooseErr)
+		if p.Identifier() == id {
+			onFinish(err)
+			return
+		}
+		onFinish(nil)
+	}
+	t.Fatalf("peer %q was not chosen", id)
+}
+
+// waitForHalfOpen waits until the breaker of the given peer is half-open and
+// the list has made the peer available again. The list's introspection
+// summary is read under its lock, since breakers change peer status from
+// timer goroutines.
+func waitForHalfOpen(t *testing.T, breakers *Transport, l *roundrobin.List, pid peer.Identifier) {
+	testtime.WaitFor(t, "breaker must become half-open", func() bool {
+		return breakers.State(pid) == HalfOpen && l.Introspect().State == "Running (2/2 available)"
+	})
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	fake := clock.NewFake()
+	root := metrics.New()
+	breakers := NewTransport(yarpctest.NewFakeTransport(),
+		FailureThreshold(2),
+		OpenTimeout(time.Minute),
+		Meter(root.Scope()),
+		withClock(fake),
+	)
+	inner := roundrobin.New(breakers)
+	l := NewList(inner)
+	require.NoError(t, l.Start())
+	defer l.Stop()
+
+	a, b := hostport.PeerIdentifier("a:1"), hostport.PeerIdentifier("b:1")
+	require.NoError(t, l.Update(peer.ListUpdates{Additions: []peer.Identifier{a, b}}))
+
+	// Failures that are not the server's fault do not count.
+	callPeer(t, l, "b:1", yarpcerrors.InvalidArgumentErrorf("bad request"))
+	callPeer(t, l, "b:1", errUnavailable)
+	assert.Equal(t, Closed, breakers.State(b))
+	callPeer(t, l, "b:1", errUnavailable)
+	assert.Equal(t, Open, breakers.State(b))
+	assert.Equal(t, "Running (1/2 available)", inner.Introspect().State, "open peers must be unavailable")
+
+	// The failed probe opens the breaker again.
+	fake.Add(time.Minute)
+	waitForHalfOpen(t, breakers, inner, b)
+	callPeer(t, l, "b:1", errors.New("great sadness"))
+	assert.Equal(t, Open, breakers.State(b))
+	assert.Equal(t, "Running (1/2 available)", inner.Introspect().State)
+
+	// The successful probe closes the breaker.
+	fake.Add(time.Minute)
+	waitForHalfOpen(t, breakers, inner, b)
+	callPeer(t, l, "b:1", nil)
+	assert.Equal(t, Closed, breakers.State(b))
+	assert.Equal(t, "Running (2/2 available)", inner.Introspect().State)
+	assert.Equal(t, Closed, breakers.State(a))
+
+	var trips int64
+	for _, c := range root.Snapshot().Counters {
+		if c.Name == "circuit_breaker_trips" {
+			trips += c.Value
+		}
+	}
+	assert.Equal(t, int64(2), trips, "only b:1 must have tripped, twice")
+}
+
+func TestCircuitBreakerHalfOpenAllowsOneProbe(t *testing.T) {
+	fake := clock.NewFake()
+	breakers := NewTransport(yarpctest.NewFakeTransport(),
+		FailureThreshold(1),
+		withClock(fake),
+	)
+	p, err := breakers.RetainPeer(hostport.PeerIdentifier("a:1"), nopSubscriber{})
+	require.NoError(t, err)
+	bp := p.(*breakerPeer)
+
+	bp.observe(errUnavailable)
+	assert.Equal(t, peer.Unavailable, bp.Status().ConnectionStatus)
+
+	fake.Add(time.Hour)
+	testtime.WaitFor(t, "breaker must become half-open", func() bool { return bp.State() == HalfOpen })
+	assert.Equal(t, peer.Available, bp.Status().ConnectionStatus)
+
+	bp.StartRequest()
+	assert.Equal(t, peer.Unavailable, bp.Status().ConnectionStatus, "only one probe may be in flight")
+	bp.EndRequest()
+	bp.observe(nil)
+	assert.Equal(t, peer.Available, bp.Status().ConnectionStatus)
+
+	require.NoError(t, breakers.ReleasePeer(hostport.PeerIdentifier("a:1"), nopSubscriber{}))
+	assert.Equal(t, Closed, breakers.State(hostport.PeerIdentifier("a:1")))
+}
+
+type nopSubscriber struct{}
+
+func (nopSubscriber) NotifyStatusChanged(peer.Identifier) {}
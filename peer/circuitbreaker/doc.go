@@ -0,0 +1,43 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package circuitbreaker provides per-peer circuit breakers that every peer
+// list honors.
+//
+// A circuit breaker is kept for each peer. After a number of consecutive
+// failed requests, the peer's breaker opens and the peer reports itself as
+// unavailable through its peer.Status, so peer lists stop choosing it. Once
+// the open timeout has passed, the breaker is half-open: the peer becomes
+// available again for a single probe request. If the probe succeeds, the
+// breaker closes; if it fails, the breaker opens again.
+//
+// Breakers are installed by wrapping the transport that peer lists retain
+// peers from, and fed by wrapping the peer list that observes request
+// results.
+//
+// 	breakers := circuitbreaker.NewTransport(httpTransport,
+// 		circuitbreaker.FailureThreshold(5),
+// 		circuitbreaker.OpenTimeout(30*time.Second),
+// 	)
+// 	list := circuitbreaker.NewList(roundrobin.New(breakers))
+// 	outbound := httpTransport.NewOutbound(list)
+//
+// The state of each peer's breaker may be recorded with the Meter option.
+package circuitbreaker
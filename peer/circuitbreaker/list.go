@@ -0,0 +1,73 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package circuitbreaker
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/introspection"
+)
+
+var _ peer.ChooserList = (*List)(nil)
+
+// List wraps a peer list whose peers were retained through a circuit breaker
+// Transport, and feeds the result of every request it chooses a peer for to
+// that peer's circuit breaker.
+type List struct {
+	peer.ChooserList
+}
+
+// NewList wraps the given peer list.
+func NewList(list peer.ChooserList) *List {
+	return &List{ChooserList: list}
+}
+
+// Choose chooses a peer from the wrapped list and records the result of the
+// request with the peer's circuit breaker.
+func (l *List) Choose(ctx context.Context, req *transport.Request) (peer.Peer, func(error), error) {
+	p, onFinish, err := l.ChooserList.Choose(ctx, req)
+	if err != nil {
+		return p, onFinish, err
+	}
+
+	bp, ok := p.(*breakerPeer)
+	if !ok {
+		return p, onFinish, nil
+	}
+	return p, func(err error) {
+		onFinish(err)
+		bp.observe(err)
+	}, nil
+}
+
+// Introspect returns the status of the wrapped list. Peers whose circuit is
+// open are reported as unavailable.
+func (l *List) Introspect() introspection.ChooserStatus {
+	var status introspection.ChooserStatus
+	if ic, ok := l.ChooserList.(introspection.IntrospectableChooser); ok {
+		status = ic.Introspect()
+	}
+	status.Name = fmt.Sprintf("circuit-breaker(%s)", status.Name)
+	return status
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package circuitbreaker
+
+import (
+	"time"
+
+	"go.uber.org/net/metrics"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// Option customizes the behavior of circuit breakers.
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(opts *options) { f(opts) }
+
+type options struct {
+	failureThreshold int
+	openTimeout      time.Duration
+	isFailure        func(error) bool
+	meter            *metrics.Scope
+	clock            clock.Clock
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		failureThreshold: 5,
+		openTimeout:      30 * time.Second,
+		isFailure:        isServerFailure,
+		clock:            clock.NewReal(),
+	}
+	for _, opt := range opts {
+		opt.apply(&o)
+	}
+	return o
+}
+
+// FailureThreshold specifies the number of consecutive failed requests to a
+// peer that open its circuit breaker.
+//
+// Defaults to 5.
+func FailureThreshold(n int) Option {
+	return optionFunc(func(o *options) {
+		o.failureThreshold = n
+	})
+}
+
+// OpenTimeout specifies how long a circuit breaker stays open before it lets
+// a probe request through.
+//
+// Defaults to 30 seconds.
+func OpenTimeout(d time.Duration) Option {
+	return optionFunc(func(o *options) {
+		o.openTimeout = d
+	})
+}
+
+// IsFailure specifies which request errors count as failures of the peer.
+//
+// Defaults to errors with the codes Unknown, DeadlineExceeded, Internal,
+// Unavailable and DataLoss, which indicate a fault of the server rather than
+// of the request.
+func IsFailure(f func(error) bool) Option {
+	return optionFunc(func(o *options) {
+		o.isFailure = f
+	})
+}
+
+// Meter records the state of each peer's circuit breaker, and the number of
+// times it opened, in the given scope.
+func Meter(meter *metrics.Scope) Option {
+	return optionFunc(func(o *options) {
+		o.meter = meter
+	})
+}
+
+func isServerFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	switch yarpcerrors.FromError(err).Code() {
+	case yarpcerrors.CodeUnknown,
+		yarpcerrors.CodeDeadlineExceeded,
+		yarpcerrors.CodeInternal,
+		yarpcerrors.CodeUnavailable,
+		yarpcerrors.CodeDataLoss:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package circuitbreaker
+
+import (
+	"sync"
+
+	"go.uber.org/net/metrics"
+	"go.uber.org/yarpc/api/peer"
+)
+
+const _peerTag = "peer"
+
+var _ peer.Transport = (*Transport)(nil)
+
+// Transport wraps a peer transport, installing a circuit breaker on every
+// peer retained through it. Peers are shared by every subscriber that
+// retains the same identifier, and so are their breakers.
+type Transport struct {
+	transport peer.Transport
+	opts      options
+
+	mu    sync.Mutex
+	peers map[string]*breakerPeer
+
+	states *metrics.GaugeVector
+	trips  *metrics.CounterVector
+}
+
+// NewTransport wraps the given transport with per-peer circuit breakers.
+func NewTransport(transport peer.Transport, opts ...Option) *Transport {
+	o := newOptions(opts)
+	t := &Transport{
+		transport: transport,
+		opts:      o,
+		peers:     make(map[string]*breakerPeer),
+	}
+	if o.meter != nil {
+		// Errors are ignored because these metrics are unique to this
+		// package; at worst, they are not recorded.
+		t.states, _ = o.meter.GaugeVector(metrics.Spec{
+			Name:    "circuit_breaker_state",
+			Help:    "State of each peer's circuit breaker: 0 closed, 1 open, 2 half-open.",
+			VarTags: []string{_peerTag},
+		})
+		t.trips, _ = o.meter.CounterVector(metrics.Spec{
+			Name:    "circuit_breaker_trips",
+			Help:    "Number of times each peer's circuit breaker opened.",
+			VarTags: []string{_peerTag},
+		})
+	}
+	return t
+}
+
+// RetainPeer retains the peer from the wrapped transport and returns it with
+// its circuit breaker.
+func (t *Transport) RetainPeer(pid peer.Identifier, s peer.Subscriber) (peer.Peer, error) {
+	p, err := t.transport.RetainPeer(pid, s)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	bp, ok := t.peers[pid.Identifier()]
+	if !ok {
+		bp = &breakerPeer{
+			Peer:        p,
+			opts:        &t.opts,
+			subscribers: make(map[peer.Subscriber]struct{}),
+		}
+		if t.states != nil {
+			bp.stateGauge, _ = t.states.Get(_peerTag, pid.Identifier())
+			bp.trips, _ = t.trips.Get(_peerTag, pid.Identifier())
+		}
+		t.peers[pid.Identifier()] = bp
+	}
+	bp.mu.Lock()
+	bp.subscribers[s] = struct{}{}
+	bp.mu.Unlock()
+	return bp, nil
+}
+
+// ReleasePeer releases the peer from the wrapped transport, discarding its
+// circuit breaker once no subscriber retains it.
+func (t *Transport) ReleasePeer(pid peer.Identifier, s peer.Subscriber) error {
+	t.mu.Lock()
+	if bp, ok := t.peers[pid.Identifier()]; ok {
+		bp.mu.Lock()
+		delete(bp.subscribers, s)
+		unused := len(bp.subscribers) == 0
+		bp.mu.Unlock()
+		if unused {
+			bp.stop()
+			delete(t.peers, pid.Identifier())
+		}
+	}
+	t.mu.Unlock()
+
+	return t.transport.ReleasePeer(pid, s)
+}
+
+// State returns the state of the circuit breaker of the peer with the given
+// identifier. Peers that are not retained are reported as Closed.
+func (t *Transport) State(pid peer.Identifier) State {
+	t.mu.Lock()
+	bp, ok := t.peers[pid.Identifier()]
+	t.mu.Unlock()
+
+	if !ok {
+		return Closed
+	}
+	return bp.State()
+}
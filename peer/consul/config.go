@@ -0,0 +1,94 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package consul
+
+import (
+	"time"
+
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/yarpcconfig"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// Configuration describes how to build a Consul peer list updater.
+type Configuration struct {
+	Service    string        `config:"service"`
+	Tag        string        `config:"tag"`
+	Datacenter string        `config:"datacenter"`
+	Address    string        `config:"address,interpolate"`
+	Token      string        `config:"token,interpolate"`
+	WaitTime   time.Duration `config:"wait-time"`
+}
+
+// Spec returns a configuration specification for the Consul peer list
+// updater, making it possible to track the passing instances of a Consul
+// service with transports that use outbound peer list configuration (like
+// HTTP).
+//
+//  cfg := yarpcconfig.New()
+//  cfg.MustRegisterPeerListUpdater(consul.Spec())
+//
+// This enables the consul peer list updater:
+//
+//  outbounds:
+//    otherservice:
+//      unary:
+//        http:
+//          url: http://host:port/rpc
+//          round-robin:
+//            consul:
+//              service: otherservice
+//              tag: primary
+//              address: ${CONSUL_HTTP_ADDR:127.0.0.1:8500}
+func Spec() yarpcconfig.PeerListUpdaterSpec {
+	return yarpcconfig.PeerListUpdaterSpec{
+		Name: "consul",
+		BuildPeerListUpdater: func(cfg Configuration, k *yarpcconfig.Kit) (peer.Binder, error) {
+			if cfg.Service == "" {
+				return nil, yarpcerrors.InvalidArgumentErrorf("Service is required.")
+			}
+
+			var opts []Option
+			if cfg.Tag != "" {
+				opts = append(opts, Tag(cfg.Tag))
+			}
+			if cfg.Datacenter != "" {
+				opts = append(opts, Datacenter(cfg.Datacenter))
+			}
+			if cfg.Address != "" {
+				opts = append(opts, Address(cfg.Address))
+			}
+			if cfg.Token != "" {
+				opts = append(opts, Token(cfg.Token))
+			}
+
+			if cfg.WaitTime < 0 {
+				return nil, yarpcerrors.InvalidArgumentErrorf(
+					"WaitTime must not be negative. Got: %v.", cfg.WaitTime)
+			}
+			if cfg.WaitTime > 0 {
+				opts = append(opts, WaitTime(cfg.WaitTime))
+			}
+
+			return Binder(cfg.Service, opts...), nil
+		},
+	}
+}
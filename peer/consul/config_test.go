@@ -0,0 +1,74 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package consul
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/yarpcconfig"
+)
+
+func TestConsulConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Configuration
+		wantErr bool
+	}{
+		{
+			name:    "no service",
+			wantErr: true,
+		},
+		{
+			name:    "negative wait time",
+			cfg:     Configuration{Service: "myservice", WaitTime: -time.Second},
+			wantErr: true,
+		},
+		{
+			name: "valid configuration",
+			cfg: Configuration{
+				Service:    "myservice",
+				Tag:        "primary",
+				Datacenter: "dc1",
+				Address:    "127.0.0.1:8500",
+				Token:      "secret",
+				WaitTime:   time.Minute,
+			},
+		},
+	}
+
+	s := Spec()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			build := s.BuildPeerListUpdater.(func(Configuration, *yarpcconfig.Kit) (peer.Binder, error))
+			binder, err := build(tt.cfg, nil)
+
+			if tt.wantErr {
+				require.Error(t, err, "must not construct a peer list updater")
+			} else {
+				require.NoError(t, err)
+				require.NotNil(t, binder(&fakeList{}))
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package consul provides a peer list updater that tracks the healthy
+// instances of a service registered in Consul.
+//
+// The updater issues blocking health queries to a Consul agent for the
+// instances of a service, optionally with a tag, whose health checks are
+// passing. Consul answers as soon as the set of passing instances changes,
+// and the updater sends the instances that were added or removed to the
+// peer list.
+//
+// 	list := zoneaware.New("dc1", roundrobin.New(transport), roundrobin.New(transport))
+// 	chooser := peer.Bind(list, consul.Binder("myservice", consul.Tag("primary")))
+//
+// Instances are added as Instance identifiers, which carry the Consul
+// metadata of the instance. Their zone is the datacenter of the instance, so
+// zone-aware lists prefer instances in the local datacenter, and their
+// weight is the Consul weight of a passing instance, so weighted lists
+// honor it.
+//
+// The agent is reached at the address in the CONSUL_HTTP_ADDR environment
+// variable, or at 127.0.0.1:8500, with the token in CONSUL_HTTP_TOKEN, unless
+// the Address and Token options say otherwise.
+package consul
@@ -0,0 +1,88 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package consul
+
+import (
+	"go.uber.org/yarpc/peer/weightedroundrobin"
+	"go.uber.org/yarpc/peer/zoneaware"
+)
+
+var (
+	_ zoneaware.ZonedIdentifier             = (*Instance)(nil)
+	_ weightedroundrobin.WeightedIdentifier = (*Instance)(nil)
+)
+
+// Instance is a peer identifier for an instance of a Consul service. Its
+// identifier is the host:port address of the instance.
+type Instance struct {
+	addr       string
+	serviceID  string
+	node       string
+	datacenter string
+	tags       []string
+	meta       map[string]string
+	weight     int
+}
+
+// Identifier returns the host:port address of the instance.
+func (i *Instance) Identifier() string { return i.addr }
+
+// ServiceID returns the ID the instance is registered with.
+func (i *Instance) ServiceID() string { return i.serviceID }
+
+// Node returns the name of the Consul node the instance runs on.
+func (i *Instance) Node() string { return i.node }
+
+// Datacenter returns the Consul datacenter of the instance.
+func (i *Instance) Datacenter() string { return i.datacenter }
+
+// Tags returns the tags of the instance.
+func (i *Instance) Tags() []string { return i.tags }
+
+// Meta returns the service metadata of the instance.
+func (i *Instance) Meta() map[string]string { return i.meta }
+
+// Zone returns the datacenter of the instance, for zone-aware peer lists.
+func (i *Instance) Zone() string { return i.datacenter }
+
+// Weight returns the weight of the instance while its checks are passing,
+// for weighted peer lists.
+func (i *Instance) Weight() int { return i.weight }
+
+// equal returns whether the instances have the same address and metadata.
+func (i *Instance) equal(o *Instance) bool {
+	if i.addr != o.addr || i.serviceID != o.serviceID || i.node != o.node ||
+		i.datacenter != o.datacenter || i.weight != o.weight ||
+		len(i.tags) != len(o.tags) || len(i.meta) != len(o.meta) {
+		return false
+	}
+	for n, tag := range i.tags {
+		if o.tags[n] != tag {
+			return false
+		}
+	}
+	for k, v := range i.meta {
+		if ov, ok := o.meta[k]; !ok || ov != v {
+			return false
+		}
+	}
+	return true
+}
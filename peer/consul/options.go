@@ -0,0 +1,132 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package consul
+
+import (
+	"net/http"
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Option customizes the behavior of a Consul peer list updater.
+type Option func(*options)
+
+type options struct {
+	tag           string
+	datacenter    string
+	address       string
+	token         string
+	waitTime      time.Duration
+	retryInterval time.Duration
+	httpClient    *http.Client
+	logger        *zap.Logger
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		address:       os.Getenv("CONSUL_HTTP_ADDR"),
+		token:         os.Getenv("CONSUL_HTTP_TOKEN"),
+		waitTime:      time.Minute,
+		retryInterval: time.Second,
+		httpClient:    http.DefaultClient,
+		logger:        zap.NewNop(),
+	}
+	if o.address == "" {
+		o.address = "127.0.0.1:8500"
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Tag specifies a tag that instances must have.
+func Tag(tag string) Option {
+	return func(o *options) {
+		o.tag = tag
+	}
+}
+
+// Datacenter specifies the datacenter to query.
+//
+// Defaults to the datacenter of the agent.
+func Datacenter(dc string) Option {
+	return func(o *options) {
+		o.datacenter = dc
+	}
+}
+
+// Address specifies the address of the Consul agent, either as host:port or
+// as a URL such as https://consul.example.com:8501.
+//
+// Defaults to the CONSUL_HTTP_ADDR environment variable, or 127.0.0.1:8500.
+func Address(addr string) Option {
+	return func(o *options) {
+		o.address = addr
+	}
+}
+
+// Token specifies the ACL token sent to the Consul agent.
+//
+// Defaults to the CONSUL_HTTP_TOKEN environment variable.
+func Token(token string) Option {
+	return func(o *options) {
+		o.token = token
+	}
+}
+
+// WaitTime specifies how long Consul may hold a blocking query open before
+// answering without changes.
+//
+// Defaults to 1 minute.
+func WaitTime(d time.Duration) Option {
+	return func(o *options) {
+		o.waitTime = d
+	}
+}
+
+// RetryInterval specifies how long to wait before querying again after a
+// failed query.
+//
+// Defaults to 1 second.
+func RetryInterval(d time.Duration) Option {
+	return func(o *options) {
+		o.retryInterval = d
+	}
+}
+
+// HTTPClient specifies the HTTP client used to reach the Consul agent.
+//
+// Defaults to http.DefaultClient.
+func HTTPClient(client *http.Client) Option {
+	return func(o *options) {
+		o.httpClient = client
+	}
+}
+
+// Logger specifies a logger for failed queries.
+func Logger(logger *zap.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
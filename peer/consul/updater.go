@@ -0,0 +1,273 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package consul
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/pkg/lifecycle"
+	"go.uber.org/zap"
+)
+
+var _ transport.Lifecycle = (*Updater)(nil)
+
+// serviceEntry decodes the parts of an entry of a Consul health query that
+// the updater needs.
+type serviceEntry struct {
+	Node struct {
+		Node       string
+		Address    string
+		Datacenter string
+	}
+	Service struct {
+		ID      string
+		Address string
+		Port    int
+		Tags    []string
+		Meta    map[string]string
+		Weights struct {
+			Passing int
+		}
+	}
+}
+
+// instance returns the peer identifier of the entry.
+func (e *serviceEntry) instance() *Instance {
+	host := e.Service.Address
+	if host == "" {
+		host = e.Node.Address
+	}
+	weight := e.Service.Weights.Passing
+	if weight <= 0 {
+		weight = 1
+	}
+	return &Instance{
+		addr:       net.JoinHostPort(host, strconv.Itoa(e.Service.Port)),
+		serviceID:  e.Service.ID,
+		node:       e.Node.Node,
+		datacenter: e.Node.Datacenter,
+		tags:       e.Service.Tags,
+		meta:       e.Service.Meta,
+		weight:     weight,
+	}
+}
+
+// Updater is a peer list updater that keeps a peer list in sync with the
+// passing instances of a Consul service.
+type Updater struct {
+	list    peer.List
+	service string
+	opts    options
+	once    *lifecycle.Once
+
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	// instances holds the instances sent to the list, by address. It is only
+	// accessed by the query goroutine.
+	instances map[string]*Instance
+}
+
+// New creates an updater that sends the passing instances of the named
+// Consul service to the peer list once started.
+func New(list peer.List, service string, opts ...Option) *Updater {
+	return &Updater{
+		list:      list,
+		service:   service,
+		opts:      newOptions(opts),
+		once:      lifecycle.NewOnce(),
+		done:      make(chan struct{}),
+		instances: make(map[string]*Instance),
+	}
+}
+
+// Binder returns a peer.Binder that binds peer lists to an updater for the
+// named Consul service.
+func Binder(service string, opts ...Option) peer.Binder {
+	return func(list peer.List) transport.Lifecycle {
+		return New(list, service, opts...)
+	}
+}
+
+// Start starts querying the instances of the service. Failed queries are
+// logged and retried, so that Start succeeds while Consul is unavailable.
+func (u *Updater) Start() error {
+	return u.once.Start(func() error {
+		ctx, cancel := context.WithCancel(context.Background())
+		u.cancel = cancel
+		go u.run(ctx)
+		return nil
+	})
+}
+
+// Stop stops querying the instances. Peers already sent to the list remain.
+func (u *Updater) Stop() error {
+	return u.once.Stop(func() error {
+		if u.cancel == nil {
+			// Never started querying.
+			return nil
+		}
+		u.cancel()
+		<-u.done
+		return nil
+	})
+}
+
+// IsRunning returns whether the updater is running.
+func (u *Updater) IsRunning() bool {
+	return u.once.IsRunning()
+}
+
+// run issues blocking queries until the context is canceled, each waiting
+// for a change since the index of the previous answer.
+func (u *Updater) run(ctx context.Context) {
+	defer close(u.done)
+
+	var index uint64
+	for {
+		entries, next, err := u.query(ctx, index)
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			u.sync(entries)
+			// Consul may reset its index, in which case blocking queries
+			// must start over.
+			if next < index {
+				next = 0
+			}
+			index = next
+			if index > 0 {
+				continue
+			}
+			// Without an index, the next query would not block.
+		} else {
+			u.opts.logger.Warn("failed to query Consul, retrying",
+				zap.String("service", u.service), zap.Error(err))
+			index = 0
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(u.opts.retryInterval):
+		}
+	}
+}
+
+// query returns the passing instances of the service once they changed since
+// the given index, along with the index of the answer.
+func (u *Updater) query(ctx context.Context, index uint64) ([]serviceEntry, uint64, error) {
+	query := url.Values{"passing": {"true"}}
+	if u.opts.tag != "" {
+		query.Set("tag", u.opts.tag)
+	}
+	if u.opts.datacenter != "" {
+		query.Set("dc", u.opts.datacenter)
+	}
+	if index > 0 {
+		query.Set("index", strconv.FormatUint(index, 10))
+		query.Set("wait", fmt.Sprintf("%dms", u.opts.waitTime/time.Millisecond))
+	}
+
+	base := u.opts.address
+	if !strings.Contains(base, "://") {
+		base = "http://" + base
+	}
+	target := fmt.Sprintf("%s/v1/health/service/%s?%s", base, url.PathEscape(u.service), query.Encode())
+
+	req, err := http.NewRequest("GET", target, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	req = req.WithContext(ctx)
+	if u.opts.token != "" {
+		req.Header.Set("X-Consul-Token", u.opts.token)
+	}
+
+	res, err := u.opts.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("unexpected response from Consul: %s", res.Status)
+	}
+
+	var entries []serviceEntry
+	if err := json.NewDecoder(res.Body).Decode(&entries); err != nil {
+		return nil, 0, err
+	}
+	next, _ := strconv.ParseUint(res.Header.Get("X-Consul-Index"), 10, 64)
+	return entries, next, nil
+}
+
+// sync sends the instances that were added, removed or changed since the
+// last sync to the list. Changed instances are removed and added again with
+// their new metadata.
+func (u *Updater) sync(entries []serviceEntry) {
+	instances := make(map[string]*Instance, len(entries))
+	for i := range entries {
+		inst := entries[i].instance()
+		instances[inst.addr] = inst
+	}
+
+	var removals, additions []string
+	for addr, inst := range u.instances {
+		if ni, ok := instances[addr]; !ok || !ni.equal(inst) {
+			removals = append(removals, addr)
+		}
+	}
+	for addr, inst := range instances {
+		if oi, ok := u.instances[addr]; !ok || !oi.equal(inst) {
+			additions = append(additions, addr)
+		}
+	}
+	if len(removals) == 0 && len(additions) == 0 {
+		return
+	}
+	sort.Strings(removals)
+	sort.Strings(additions)
+
+	var updates peer.ListUpdates
+	for _, addr := range removals {
+		updates.Removals = append(updates.Removals, u.instances[addr])
+	}
+	for _, addr := range additions {
+		updates.Additions = append(updates.Additions, instances[addr])
+	}
+	if err := u.list.Update(updates); err != nil {
+		u.opts.logger.Warn("failed to update peer list",
+			zap.String("service", u.service), zap.Error(err))
+	}
+	u.instances = instances
+}
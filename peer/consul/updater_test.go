@@ -0,0 +1,135 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package consul
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/peer/peertest"
+	"go.uber.org/yarpc/internal/testtime"
+	"go.uber.org/yarpc/peer/zoneaware"
+)
+
+// fakeConsul answers the first health query at once, and every later query
+// with the next entries sent to it.
+type fakeConsul struct {
+	t       *testing.T
+	entries chan []serviceEntry
+
+	mu      sync.Mutex
+	index   int
+	current []serviceEntry
+}
+
+func (c *fakeConsul) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	assert.Equal(c.t, "/v1/health/service/myservice", r.URL.Path)
+	assert.Equal(c.t, "true", r.URL.Query().Get("passing"))
+	assert.Equal(c.t, "primary", r.URL.Query().Get("tag"))
+	assert.Equal(c.t, "secret", r.Header.Get("X-Consul-Token"))
+
+	c.mu.Lock()
+	index := c.index
+	c.mu.Unlock()
+	if r.URL.Query().Get("index") == strconv.Itoa(index) {
+		select {
+		case <-r.Context().Done():
+			return
+		case entries := <-c.entries:
+			c.mu.Lock()
+			c.index++
+			c.current = entries
+			c.mu.Unlock()
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	w.Header().Set("X-Consul-Index", strconv.Itoa(c.index))
+	assert.NoError(c.t, json.NewEncoder(w).Encode(c.current))
+}
+
+func entry(id, addr string, port int, dc string, tags ...string) serviceEntry {
+	var e serviceEntry
+	e.Node.Node = "node-" + id
+	e.Node.Address = "10.0.0.100"
+	e.Node.Datacenter = dc
+	e.Service.ID = id
+	e.Service.Address = addr
+	e.Service.Port = port
+	e.Service.Tags = tags
+	e.Service.Weights.Passing = 3
+	return e
+}
+
+func TestUpdater(t *testing.T) {
+	consul := &fakeConsul{
+		t:       t,
+		entries: make(chan []serviceEntry),
+		index:   10,
+		current: []serviceEntry{
+			entry("a", "10.0.0.1", 8080, "dc1", "primary"),
+			entry("b", "", 8080, "dc2", "primary"),
+		},
+	}
+	server := httptest.NewServer(consul)
+	defer server.Close()
+
+	list := peertest.NewFakeList()
+	u := New(list, "myservice", Address(server.URL), Tag("primary"), Token("secret"))
+	require.NoError(t, u.Start())
+	defer u.Stop()
+
+	testtime.WaitFor(t, "passing instances must be added", func() bool {
+		return fmt.Sprint(list.IDs()) == "[10.0.0.100:8080 10.0.0.1:8080]"
+	})
+
+	inst, ok := list.Peer("10.0.0.100:8080").(*Instance)
+	require.True(t, ok, "peers must be added as instances")
+	assert.Equal(t, "b", inst.ServiceID())
+	assert.Equal(t, "node-b", inst.Node())
+	assert.Equal(t, "dc2", inst.Datacenter())
+	assert.Equal(t, []string{"primary"}, inst.Tags())
+	assert.Equal(t, 3, inst.Weight())
+	assert.Equal(t, "dc2", list.Peer("10.0.0.100:8080").(zoneaware.ZonedIdentifier).Zone())
+
+	consul.entries <- []serviceEntry{
+		entry("a", "10.0.0.1", 8080, "dc1", "primary", "canary"),
+		entry("c", "10.0.0.3", 8080, "dc1", "primary"),
+	}
+	testtime.WaitFor(t, "instances must follow Consul", func() bool {
+		return fmt.Sprint(list.IDs()) == "[10.0.0.1:8080 10.0.0.3:8080]"
+	})
+	testtime.WaitFor(t, "changed instances must be added again", func() bool {
+		inst, ok := list.Peer("10.0.0.1:8080").(*Instance)
+		return ok && len(inst.Tags()) == 2
+	})
+
+	require.NoError(t, u.Stop())
+	assert.False(t, u.IsRunning())
+}
@@ -0,0 +1,41 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package debounce provides a wrapper for peer list updaters that coalesces
+// bursts of membership changes into batched, rate-limited updates of the
+// peer list.
+//
+// Registries that flap, and redeploys that replace many instances at once,
+// can send a peer list a storm of small updates, each of which may open or
+// close connections. Wrapping the updater's binder holds changes back until
+// the membership has been quiet for a moment, and sends only their net
+// effect, so a peer that is removed and added again in a burst is left alone.
+//
+// 	binder := debounce.Binder(dns.Binder("_http._tcp.myservice.example.com"),
+// 		debounce.Delay(time.Second),
+// 		debounce.MinInterval(5*time.Second),
+// 	)
+// 	chooser := peer.Bind(list, binder)
+//
+// The first update is sent at once, so that the peer list has peers as soon
+// as the updater starts. Later updates wait for the Delay after the most
+// recent change, but no longer than the MaxDelay after the first change they
+// hold back, and are sent no more often than the MinInterval.
+package debounce
@@ -0,0 +1,90 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package debounce
+
+import (
+	"time"
+
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/zap"
+)
+
+// Option customizes the behavior of a debounced peer list updater.
+type Option func(*options)
+
+type options struct {
+	delay       time.Duration
+	maxDelay    time.Duration
+	minInterval time.Duration
+	logger      *zap.Logger
+	clock       clock.Clock
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		delay:       500 * time.Millisecond,
+		maxDelay:    5 * time.Second,
+		minInterval: time.Second,
+		logger:      zap.NewNop(),
+		clock:       clock.NewReal(),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Delay specifies how long the membership must be quiet before held back
+// changes are sent to the peer list.
+//
+// Defaults to 500 milliseconds.
+func Delay(d time.Duration) Option {
+	return func(o *options) {
+		o.delay = d
+	}
+}
+
+// MaxDelay specifies the longest time a change is held back while the
+// membership keeps changing.
+//
+// Defaults to 5 seconds.
+func MaxDelay(d time.Duration) Option {
+	return func(o *options) {
+		o.maxDelay = d
+	}
+}
+
+// MinInterval specifies the shortest time between two updates of the peer
+// list.
+//
+// Defaults to 1 second.
+func MinInterval(d time.Duration) Option {
+	return func(o *options) {
+		o.minInterval = d
+	}
+}
+
+// Logger specifies a logger for failed updates of the peer list.
+func Logger(logger *zap.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
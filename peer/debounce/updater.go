@@ -0,0 +1,229 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package debounce
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/multierr"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/pkg/lifecycle"
+	"go.uber.org/zap"
+)
+
+// Binder returns a peer list binder that binds a peer list to the updater of
+// the given binder, coalescing the updater's changes into batched,
+// rate-limited updates of the peer list.
+func Binder(binder peer.Binder, opts ...Option) peer.Binder {
+	return func(pl peer.List) transport.Lifecycle {
+		return New(pl, binder, opts...)
+	}
+}
+
+// Updater holds back the changes an inner peer list updater makes, and sends
+// their net effect to a peer list in batches.
+type Updater struct {
+	once    *lifecycle.Once
+	updater transport.Lifecycle
+	opts    options
+
+	mu sync.Mutex
+	pl peer.List
+	// applied maps the identifiers of the peers in the peer list to the
+	// identifiers the list holds for them.
+	applied map[string]peer.Identifier
+	// desired maps the identifiers of the peers the inner updater provides
+	// to its identifiers for them.
+	desired map[string]peer.Identifier
+	// dirty holds the identifiers of the peers changed since the last
+	// update of the peer list.
+	dirty map[string]struct{}
+	// flushed is when the peer list was last updated, and is zero until the
+	// first update.
+	flushed time.Time
+	// pending is when the oldest held back change was made, and deadline is
+	// when the held back changes are due, while a flush is scheduled.
+	pending   time.Time
+	deadline  time.Time
+	scheduled bool
+	stopped   bool
+}
+
+var _ transport.Lifecycle = (*Updater)(nil)
+
+// New returns an updater that binds the given peer list to the updater of
+// the given binder, coalescing its changes.
+func New(pl peer.List, binder peer.Binder, opts ...Option) *Updater {
+	u := &Updater{
+		once:    lifecycle.NewOnce(),
+		opts:    newOptions(opts),
+		pl:      pl,
+		applied: make(map[string]peer.Identifier),
+		desired: make(map[string]peer.Identifier),
+		dirty:   make(map[string]struct{}),
+	}
+	u.updater = binder(debouncedList{u})
+	return u
+}
+
+// Start starts the inner updater.
+func (u *Updater) Start() error {
+	return u.once.Start(u.start)
+}
+
+func (u *Updater) start() error {
+	return u.updater.Start()
+}
+
+// Stop stops the inner updater and sends the changes still held back to the
+// peer list, including the peers the inner updater removed as it stopped.
+func (u *Updater) Stop() error {
+	return u.once.Stop(u.stop)
+}
+
+func (u *Updater) stop() error {
+	err := u.updater.Stop()
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.stopped = true
+	return multierr.Append(err, u.flush())
+}
+
+// IsRunning returns whether the inner updater is running.
+func (u *Updater) IsRunning() bool {
+	return u.once.IsRunning()
+}
+
+// update records the changes of the inner updater, and sends them to the
+// peer list at once if the peer list has never been updated, or schedules
+// them otherwise.
+func (u *Updater) update(updates peer.ListUpdates) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	for _, pid := range updates.Removals {
+		delete(u.desired, pid.Identifier())
+		u.dirty[pid.Identifier()] = struct{}{}
+	}
+	for _, pid := range updates.Additions {
+		u.desired[pid.Identifier()] = pid
+		u.dirty[pid.Identifier()] = struct{}{}
+	}
+
+	if u.flushed.IsZero() || u.stopped {
+		return u.flush()
+	}
+
+	now := u.opts.clock.Now()
+	if u.pending.IsZero() {
+		u.pending = now
+	}
+	deadline := now.Add(u.opts.delay)
+	if latest := u.pending.Add(u.opts.maxDelay); deadline.After(latest) {
+		deadline = latest
+	}
+	if earliest := u.flushed.Add(u.opts.minInterval); deadline.Before(earliest) {
+		deadline = earliest
+	}
+	u.deadline = deadline
+
+	if !u.scheduled {
+		u.scheduled = true
+		u.opts.clock.AfterFunc(deadline.Sub(now), u.fire)
+	}
+	return nil
+}
+
+// fire sends the held back changes to the peer list if they are due, or
+// schedules itself again for when they are, since the deadline moves later
+// as changes keep coming.
+func (u *Updater) fire() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if !u.scheduled {
+		return
+	}
+	now := u.opts.clock.Now()
+	if now.Before(u.deadline) {
+		u.opts.clock.AfterFunc(u.deadline.Sub(now), u.fire)
+		return
+	}
+
+	if err := u.flush(); err != nil {
+		u.opts.logger.Error("failed to update peer list", zap.Error(err))
+	}
+}
+
+// flush sends the net effect of the held back changes to the peer list. A
+// peer that was removed and added again with an equal identifier is left
+// alone, and a peer whose identifier changed is removed and added again.
+//
+// Must be called with the lock held.
+func (u *Updater) flush() error {
+	u.scheduled = false
+	u.pending = time.Time{}
+	u.flushed = u.opts.clock.Now()
+	if len(u.dirty) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(u.dirty))
+	for id := range u.dirty {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	u.dirty = make(map[string]struct{})
+
+	var updates peer.ListUpdates
+	for _, id := range ids {
+		old, held := u.applied[id]
+		pid, wanted := u.desired[id]
+		if held && wanted && reflect.DeepEqual(old, pid) {
+			continue
+		}
+		if held {
+			updates.Removals = append(updates.Removals, old)
+			delete(u.applied, id)
+		}
+		if wanted {
+			updates.Additions = append(updates.Additions, pid)
+			u.applied[id] = pid
+		}
+	}
+
+	if len(updates.Removals) == 0 && len(updates.Additions) == 0 {
+		return nil
+	}
+	return u.pl.Update(updates)
+}
+
+// debouncedList is the peer list the inner updater is bound to.
+type debouncedList struct{ u *Updater }
+
+func (l debouncedList) Update(updates peer.ListUpdates) error {
+	return l.u.update(updates)
+}
@@ -0,0 +1,185 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package debounce
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/peer/peertest"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/internal/testtime"
+	"go.uber.org/yarpc/peer/hostport"
+	"go.uber.org/yarpc/pkg/lifecycle"
+)
+
+// state describes the peers in the list and how many updates it received.
+func state(l *peertest.FakeList) string {
+	return fmt.Sprintf("%v after %d update(s)", l.IDs(), l.Updates())
+}
+
+// fakeUpdater adds its initial peers when it starts, removes its peers when
+// it stops, and otherwise changes its peers when the test tells it to.
+type fakeUpdater struct {
+	once  *lifecycle.Once
+	pl    peer.List
+	peers []peer.Identifier
+}
+
+func (u *fakeUpdater) bind(pl peer.List) transport.Lifecycle {
+	u.pl = pl
+	return u
+}
+
+func (u *fakeUpdater) Start() error {
+	return u.once.Start(func() error {
+		return u.pl.Update(peer.ListUpdates{Additions: u.peers})
+	})
+}
+
+func (u *fakeUpdater) Stop() error {
+	return u.once.Stop(func() error {
+		return u.pl.Update(peer.ListUpdates{Removals: u.peers})
+	})
+}
+
+func (u *fakeUpdater) IsRunning() bool {
+	return u.once.IsRunning()
+}
+
+func (u *fakeUpdater) add(t *testing.T, id string) {
+	u.peers = append(u.peers, hostport.PeerIdentifier(id))
+	require.NoError(t, u.pl.Update(peer.ListUpdates{Additions: []peer.Identifier{hostport.PeerIdentifier(id)}}))
+}
+
+func (u *fakeUpdater) remove(t *testing.T, id string) {
+	for i, pid := range u.peers {
+		if pid.Identifier() == id {
+			u.peers = append(u.peers[:i], u.peers[i+1:]...)
+			break
+		}
+	}
+	require.NoError(t, u.pl.Update(peer.ListUpdates{Removals: []peer.Identifier{hostport.PeerIdentifier(id)}}))
+}
+
+func newTestUpdater(list *peertest.FakeList, opts ...Option) (*Updater, *fakeUpdater, *clock.FakeClock) {
+	fake := clock.NewFake()
+	inner := &fakeUpdater{
+		once:  lifecycle.NewOnce(),
+		peers: []peer.Identifier{hostport.PeerIdentifier("a:1"), hostport.PeerIdentifier("b:1")},
+	}
+	opts = append([]Option{
+		Delay(time.Second),
+		MaxDelay(5 * time.Second),
+		MinInterval(2 * time.Second),
+		func(o *options) { o.clock = fake },
+	}, opts...)
+	return New(list, inner.bind, opts...), inner, fake
+}
+
+func TestDebounce(t *testing.T) {
+	list := peertest.NewFakeList()
+	u, inner, fake := newTestUpdater(list)
+
+	require.NoError(t, u.Start())
+	assert.Equal(t, "[a:1 b:1] after 1 update(s)", state(list), "the first update must be sent at once")
+
+	fake.Add(3 * time.Second)
+	inner.remove(t, "b:1")
+	inner.add(t, "c:1")
+	inner.add(t, "b:1")
+	assert.Equal(t, "[a:1 b:1] after 1 update(s)", state(list), "changes must be held back")
+
+	fake.Add(500 * time.Millisecond)
+	inner.add(t, "d:1")
+	fake.Add(900 * time.Millisecond)
+	assert.Equal(t, "[a:1 b:1] after 1 update(s)", state(list), "changes must wait for the membership to be quiet")
+
+	fake.Add(100 * time.Millisecond)
+	testtime.WaitFor(t, "held back changes must be sent in one update", func() bool {
+		return state(list) == "[a:1 b:1 c:1 d:1] after 2 update(s)"
+	})
+
+	inner.remove(t, "c:1")
+	require.NoError(t, u.Stop())
+	assert.Equal(t, "[] after 3 update(s)", state(list), "held back changes must be sent when stopping")
+	assert.False(t, u.IsRunning())
+}
+
+func TestDebounceMaxDelay(t *testing.T) {
+	list := peertest.NewFakeList()
+	u, inner, fake := newTestUpdater(list)
+	require.NoError(t, u.Start())
+	defer u.Stop()
+
+	fake.Add(3 * time.Second)
+	for i := 0; i < 5; i++ {
+		inner.add(t, fmt.Sprintf("c:%d", i))
+		fake.Add(900 * time.Millisecond)
+	}
+	assert.Equal(t, "[a:1 b:1] after 1 update(s)", state(list))
+
+	fake.Add(500 * time.Millisecond)
+	testtime.WaitFor(t, "changes must not be held back longer than the maximum delay", func() bool {
+		return state(list) == "[a:1 b:1 c:0 c:1 c:2 c:3 c:4] after 2 update(s)"
+	})
+}
+
+func TestDebounceMinInterval(t *testing.T) {
+	list := peertest.NewFakeList()
+	u, inner, fake := newTestUpdater(list, MinInterval(10*time.Second))
+	require.NoError(t, u.Start())
+	defer u.Stop()
+
+	inner.add(t, "c:1")
+	fake.Add(5 * time.Second)
+	assert.Equal(t, "[a:1 b:1] after 1 update(s)", state(list),
+		"the peer list must not be updated more often than the minimum interval")
+
+	fake.Add(5 * time.Second)
+	testtime.WaitFor(t, "changes must be sent after the minimum interval", func() bool {
+		return state(list) == "[a:1 b:1 c:1] after 2 update(s)"
+	})
+}
+
+func TestDebounceFlap(t *testing.T) {
+	list := peertest.NewFakeList()
+	u, inner, fake := newTestUpdater(list)
+	require.NoError(t, u.Start())
+	defer u.Stop()
+
+	fake.Add(3 * time.Second)
+	inner.remove(t, "a:1")
+	inner.add(t, "a:1")
+	fake.Add(time.Second)
+
+	// Nothing is sent for the flap, so the next change is the second update.
+	inner.add(t, "c:1")
+	fake.Add(2 * time.Second)
+	testtime.WaitFor(t, "flapping peers must be left alone", func() bool {
+		return state(list) == "[a:1 b:1 c:1] after 2 update(s)"
+	})
+}
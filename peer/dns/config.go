@@ -0,0 +1,140 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dns
+
+import (
+	"net"
+	"time"
+
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/yarpcconfig"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// Configuration describes how to build a DNS SRV peer list updater.
+type Configuration struct {
+	Name     string        `config:"name"`
+	Interval time.Duration `config:"interval"`
+	Timeout  time.Duration `config:"timeout"`
+}
+
+// Spec returns a configuration specification for the DNS SRV peer list
+// updater, making it possible to discover peers from SRV records with
+// transports that use outbound peer list configuration (like HTTP).
+//
+//  cfg := yarpcconfig.New()
+//  cfg.MustRegisterPeerListUpdater(dns.Spec())
+//
+// This enables the dns-srv peer list updater:
+//
+//  outbounds:
+//    otherservice:
+//      unary:
+//        http:
+//          url: https://host:port/rpc
+//          weighted-round-robin:
+//            dns-srv:
+//              name: _http._tcp.otherservice.example.com
+//              interval: 10s
+func Spec() yarpcconfig.PeerListUpdaterSpec {
+	return yarpcconfig.PeerListUpdaterSpec{
+		Name: "dns-srv",
+		BuildPeerListUpdater: func(cfg Configuration, k *yarpcconfig.Kit) (peer.Binder, error) {
+			if cfg.Name == "" {
+				return nil, yarpcerrors.InvalidArgumentErrorf("Name is required.")
+			}
+
+			opts, err := durationOptions(cfg.Interval, cfg.Timeout)
+			if err != nil {
+				return nil, err
+			}
+			return Binder(cfg.Name, opts...), nil
+		},
+	}
+}
+
+// HostConfiguration describes how to build a DNS host peer list updater.
+type HostConfiguration struct {
+	Address  string        `config:"address"`
+	Interval time.Duration `config:"interval"`
+	Timeout  time.Duration `config:"timeout"`
+}
+
+// HostSpec returns a configuration specification for the DNS host peer list
+// updater, making it possible to discover peers from the A and AAAA records
+// of a host with transports that use outbound peer list configuration (like
+// HTTP).
+//
+//  cfg := yarpcconfig.New()
+//  cfg.MustRegisterPeerListUpdater(dns.HostSpec())
+//
+// This enables the dns peer list updater:
+//
+//  outbounds:
+//    otherservice:
+//      unary:
+//        http:
+//          url: https://host:port/rpc
+//          round-robin:
+//            dns:
+//              address: otherservice.default.svc.cluster.local:8080
+//              interval: 10s
+func HostSpec() yarpcconfig.PeerListUpdaterSpec {
+	return yarpcconfig.PeerListUpdaterSpec{
+		Name: "dns",
+		BuildPeerListUpdater: func(cfg HostConfiguration, k *yarpcconfig.Kit) (peer.Binder, error) {
+			if _, _, err := net.SplitHostPort(cfg.Address); err != nil {
+				return nil, yarpcerrors.InvalidArgumentErrorf(
+					"Address must be a host:port address. Got: %q.", cfg.Address)
+			}
+
+			opts, err := durationOptions(cfg.Interval, cfg.Timeout)
+			if err != nil {
+				return nil, err
+			}
+			return HostBinder(cfg.Address, opts...), nil
+		},
+	}
+}
+
+// durationOptions validates the refresh interval and resolution timeout of a
+// configuration, returning the options for those that are set.
+func durationOptions(interval, timeout time.Duration) ([]Option, error) {
+	var opts []Option
+
+	if interval < 0 {
+		return nil, yarpcerrors.InvalidArgumentErrorf(
+			"Interval must not be negative. Got: %v.", interval)
+	}
+	if interval > 0 {
+		opts = append(opts, Interval(interval))
+	}
+
+	if timeout < 0 {
+		return nil, yarpcerrors.InvalidArgumentErrorf(
+			"Timeout must not be negative. Got: %v.", timeout)
+	}
+	if timeout > 0 {
+		opts = append(opts, Timeout(timeout))
+	}
+
+	return opts, nil
+}
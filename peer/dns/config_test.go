@@ -0,0 +1,122 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dns
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/yarpcconfig"
+)
+
+func TestDNSConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Configuration
+		wantErr bool
+	}{
+		{
+			name:    "no name",
+			wantErr: true,
+		},
+		{
+			name:    "negative interval",
+			cfg:     Configuration{Name: "_http._tcp.example.com", Interval: -time.Second},
+			wantErr: true,
+		},
+		{
+			name:    "negative timeout",
+			cfg:     Configuration{Name: "_http._tcp.example.com", Timeout: -time.Second},
+			wantErr: true,
+		},
+		{
+			name: "valid configuration",
+			cfg: Configuration{
+				Name:     "_http._tcp.example.com",
+				Interval: time.Minute,
+				Timeout:  time.Second,
+			},
+		},
+	}
+
+	s := Spec()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			build := s.BuildPeerListUpdater.(func(Configuration, *yarpcconfig.Kit) (peer.Binder, error))
+			binder, err := build(tt.cfg, nil)
+
+			if tt.wantErr {
+				require.Error(t, err, "must not construct a peer list updater")
+			} else {
+				require.NoError(t, err)
+				require.NotNil(t, binder(&recordingList{}))
+			}
+		})
+	}
+}
+
+func TestDNSHostConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     HostConfiguration
+		wantErr bool
+	}{
+		{
+			name:    "no address",
+			wantErr: true,
+		},
+		{
+			name:    "no port",
+			cfg:     HostConfiguration{Address: "myservice.example.com"},
+			wantErr: true,
+		},
+		{
+			name:    "negative interval",
+			cfg:     HostConfiguration{Address: "myservice.example.com:80", Interval: -time.Second},
+			wantErr: true,
+		},
+		{
+			name: "valid configuration",
+			cfg: HostConfiguration{
+				Address:  "myservice.example.com:80",
+				Interval: time.Minute,
+				Timeout:  time.Second,
+			},
+		},
+	}
+
+	s := HostSpec()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			build := s.BuildPeerListUpdater.(func(HostConfiguration, *yarpcconfig.Kit) (peer.Binder, error))
+			binder, err := build(tt.cfg, nil)
+
+			if tt.wantErr {
+				require.Error(t, err, "must not construct a peer list updater")
+			} else {
+				require.NoError(t, err)
+				require.NotNil(t, binder(&recordingList{}))
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package dns provides peer list updaters that discover peers from DNS
+// records.
+//
+// The SRV updater resolves an SRV name, sends the targets to a peer list as
+// host:port identifiers, and resolves the name again when the records
+// expire, sending only the peers that were added or removed since.
+//
+// 	list := weightedroundrobin.New(transport)
+// 	chooser := peer.Bind(list, dns.Binder("_http._tcp.myservice.example.com"))
+//
+// Only the targets with the most preferred (lowest) priority are used, as
+// SRV clients do. The weight of each target is attached to its identifier
+// with weightedroundrobin.Weighted, so weighted peer lists send traffic in
+// proportion to it and other peer lists ignore it.
+//
+// The host updater resolves the A and AAAA records of a host instead, and
+// sends its addresses to a peer list with a fixed port. It tracks Kubernetes
+// headless services and round-robin DNS backends as their addresses change,
+// without restarting the dispatcher.
+//
+// 	chooser := peer.Bind(list, dns.HostBinder("myservice.default.svc.cluster.local:8080"))
+//
+// Each refresh is brought forward by a random jitter, so that many processes
+// tracking the same name do not resolve it at the same time.
+//
+// The resolver of the standard library does not report the TTL of records,
+// so by default names are resolved again at a fixed interval. Resolvers that
+// know the TTL may be provided with the WithResolver option.
+package dns
@@ -0,0 +1,102 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dns
+
+import (
+	"net"
+	"time"
+
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/zap"
+)
+
+// Option customizes the behavior of a DNS peer list updater.
+type Option func(*options)
+
+type options struct {
+	interval time.Duration
+	timeout  time.Duration
+	jitter   float64
+	resolver Resolver
+	logger   *zap.Logger
+	clock    clock.Clock
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		interval: 30 * time.Second,
+		timeout:  5 * time.Second,
+		jitter:   0.1,
+		resolver: netResolver{net.DefaultResolver},
+		logger:   zap.NewNop(),
+		clock:    clock.NewReal(),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Interval specifies how often the name is resolved if the resolver does not
+// report the TTL of the records.
+//
+// Defaults to 30 seconds.
+func Interval(d time.Duration) Option {
+	return func(o *options) {
+		o.interval = d
+	}
+}
+
+// Jitter specifies the largest share of the refresh interval, between 0 and
+// 1, by which each refresh is randomly brought forward, so that many
+// processes tracking the same name do not resolve it at the same time.
+//
+// Defaults to 0.1.
+func Jitter(fraction float64) Option {
+	return func(o *options) {
+		o.jitter = fraction
+	}
+}
+
+// Timeout specifies how long a resolution may take.
+//
+// Defaults to 5 seconds.
+func Timeout(d time.Duration) Option {
+	return func(o *options) {
+		o.timeout = d
+	}
+}
+
+// WithResolver specifies the resolver of SRV records.
+//
+// Defaults to the resolver of the standard library.
+func WithResolver(r Resolver) Option {
+	return func(o *options) {
+		o.resolver = r
+	}
+}
+
+// Logger specifies a logger for failed resolutions.
+func Logger(logger *zap.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
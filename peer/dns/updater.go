@@ -0,0 +1,272 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dns
+
+import (
+	"context"
+	"math/rand"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/peer/hostport"
+	"go.uber.org/yarpc/peer/weightedroundrobin"
+	"go.uber.org/yarpc/pkg/lifecycle"
+	"go.uber.org/zap"
+)
+
+// _minRefreshInterval bounds how often a name is resolved, however short the
+// TTL of its records.
+const _minRefreshInterval = time.Second
+
+var _ transport.Lifecycle = (*Updater)(nil)
+
+// Updater is a peer list updater that keeps a peer list in sync with the DNS
+// records of a name.
+type Updater struct {
+	list peer.List
+	name string
+	opts options
+	once *lifecycle.Once
+
+	// resolve returns the weight of every target of the name, by address.
+	resolve func(context.Context) (map[string]int, time.Duration, error)
+	// identify returns the identifier of the target with the given address
+	// and weight.
+	identify func(addr string, weight int) peer.Identifier
+
+	mu sync.Mutex
+	// weights holds the weight of every peer sent to the list, by address.
+	weights map[string]int
+	timer   clock.Timer
+	stopped bool
+}
+
+func newUpdater(list peer.List, name string, opts []Option) *Updater {
+	return &Updater{
+		list:    list,
+		name:    name,
+		opts:    newOptions(opts),
+		once:    lifecycle.NewOnce(),
+		weights: make(map[string]int),
+	}
+}
+
+// New creates an updater that sends the targets of the SRV records of the
+// given name to the peer list once started.
+func New(list peer.List, name string, opts ...Option) *Updater {
+	u := newUpdater(list, name, opts)
+	u.resolve = u.resolveSRV
+	u.identify = func(addr string, weight int) peer.Identifier {
+		return weightedroundrobin.Weighted(hostport.PeerIdentifier(addr), weight)
+	}
+	return u
+}
+
+// Binder returns a peer.Binder that binds peer lists to an updater for the
+// SRV records of the given name.
+func Binder(name string, opts ...Option) peer.Binder {
+	return func(list peer.List) transport.Lifecycle {
+		return New(list, name, opts...)
+	}
+}
+
+// NewHost creates an updater that sends the addresses of the A and AAAA
+// records of the host in the given host:port address to the peer list once
+// started, each with the port of the address.
+func NewHost(list peer.List, addr string, opts ...Option) *Updater {
+	u := newUpdater(list, addr, opts)
+	u.resolve = u.resolveHost
+	u.identify = func(addr string, _ int) peer.Identifier {
+		return hostport.PeerIdentifier(addr)
+	}
+	return u
+}
+
+// HostBinder returns a peer.Binder that binds peer lists to an updater for
+// the A and AAAA records of the host in the given host:port address.
+func HostBinder(addr string, opts ...Option) peer.Binder {
+	return func(list peer.List) transport.Lifecycle {
+		return NewHost(list, addr, opts...)
+	}
+}
+
+// Start resolves the name for the first time and keeps resolving it until
+// the updater is stopped. Failed resolutions are logged and retried, so that
+// Start succeeds while DNS is unavailable.
+func (u *Updater) Start() error {
+	return u.once.Start(func() error {
+		u.refresh()
+		return nil
+	})
+}
+
+// Stop stops resolving the name. Peers already sent to the list remain.
+func (u *Updater) Stop() error {
+	return u.once.Stop(func() error {
+		u.mu.Lock()
+		defer u.mu.Unlock()
+
+		u.stopped = true
+		if u.timer != nil {
+			u.timer.Stop()
+			u.timer = nil
+		}
+		return nil
+	})
+}
+
+// IsRunning returns whether the updater is running.
+func (u *Updater) IsRunning() bool {
+	return u.once.IsRunning()
+}
+
+// refresh resolves the name, schedules the next resolution, and updates the
+// list with the peers that changed.
+func (u *Updater) refresh() {
+	ctx, cancel := context.WithTimeout(context.Background(), u.opts.timeout)
+	weights, ttl, err := u.resolve(ctx)
+	cancel()
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.stopped {
+		return
+	}
+	u.timer = u.opts.clock.AfterFunc(u.refreshInterval(ttl), u.refresh)
+
+	if err != nil {
+		u.opts.logger.Warn("failed to resolve DNS records, keeping current peers",
+			zap.String("name", u.name), zap.Error(err))
+		return
+	}
+
+	updates := u.diff(weights)
+	if len(updates.Additions) == 0 && len(updates.Removals) == 0 {
+		return
+	}
+	if err := u.list.Update(updates); err != nil {
+		u.opts.logger.Warn("failed to update peer list",
+			zap.String("name", u.name), zap.Error(err))
+	}
+}
+
+// refreshInterval returns how long to wait before resolving the name again.
+// The interval is shortened by a random share of up to the jitter, so that
+// many updaters for the same name do not resolve it at the same time, and
+// still resolve it before its records expire.
+func (u *Updater) refreshInterval(ttl time.Duration) time.Duration {
+	d := u.opts.interval
+	if ttl > 0 {
+		d = ttl
+	}
+	if u.opts.jitter > 0 {
+		d -= time.Duration(rand.Float64() * u.opts.jitter * float64(d))
+	}
+	if d < _minRefreshInterval {
+		d = _minRefreshInterval
+	}
+	return d
+}
+
+// resolveSRV returns the targets of the SRV records of the name with the
+// most preferred (lowest) priority.
+func (u *Updater) resolveSRV(ctx context.Context) (map[string]int, time.Duration, error) {
+	records, ttl, err := u.opts.resolver.LookupSRV(ctx, u.name)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var priority uint16
+	for i, r := range records {
+		if i == 0 || r.Priority < priority {
+			priority = r.Priority
+		}
+	}
+
+	weights := make(map[string]int, len(records))
+	for _, r := range records {
+		if r.Priority != priority {
+			continue
+		}
+		addr := net.JoinHostPort(strings.TrimSuffix(r.Target, "."), strconv.Itoa(int(r.Port)))
+		weights[addr] = int(r.Weight)
+	}
+	return weights, ttl, nil
+}
+
+// resolveHost returns the addresses of the A and AAAA records of the host,
+// with the port of the name.
+func (u *Updater) resolveHost(ctx context.Context) (map[string]int, time.Duration, error) {
+	host, port, err := net.SplitHostPort(u.name)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ips, ttl, err := u.opts.resolver.LookupIP(ctx, host)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	weights := make(map[string]int, len(ips))
+	for _, ip := range ips {
+		weights[net.JoinHostPort(ip.String(), port)] = 0
+	}
+	return weights, ttl, nil
+}
+
+// diff returns the updates that bring the list from the current peers to the
+// given targets, and records them as the current peers. Peers whose weight
+// changed are removed and added again with the new weight.
+//
+// Must be called with the lock held.
+func (u *Updater) diff(weights map[string]int) peer.ListUpdates {
+	var removals, additions []string
+	for addr, w := range u.weights {
+		if nw, ok := weights[addr]; !ok || nw != w {
+			removals = append(removals, addr)
+		}
+	}
+	for addr, w := range weights {
+		if ow, ok := u.weights[addr]; !ok || ow != w {
+			additions = append(additions, addr)
+		}
+	}
+	sort.Strings(removals)
+	sort.Strings(additions)
+
+	var updates peer.ListUpdates
+	for _, addr := range removals {
+		updates.Removals = append(updates.Removals, hostport.PeerIdentifier(addr))
+	}
+	for _, addr := range additions {
+		updates.Additions = append(updates.Additions, u.identify(addr, weights[addr]))
+	}
+	u.weights = weights
+	return updates
+}
@@ -0,0 +1,220 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dns
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/internal/testtime"
+	"go.uber.org/yarpc/peer/weightedroundrobin"
+)
+
+func withClock(c clock.Clock) Option {
+	return func(o *options) {
+		o.clock = c
+	}
+}
+
+// advance moves the clock forward and waits for the updater to resolve the
+// name and schedule the next resolution.
+func advance(t *testing.T, u *Updater, fake *clock.FakeClock, d time.Duration) {
+	u.mu.Lock()
+	prev := u.timer
+	u.mu.Unlock()
+
+	fake.Add(d)
+	testtime.WaitFor(t, "name must be resolved again", func() bool {
+		u.mu.Lock()
+		defer u.mu.Unlock()
+		return u.timer != prev
+	})
+}
+
+type fakeResolver struct {
+	mu      sync.Mutex
+	ips     []net.IP
+	records []*net.SRV
+	ttl     time.Duration
+	err     error
+	lookups int
+}
+
+func (r *fakeResolver) set(ttl time.Duration, err error, records ...*net.SRV) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records, r.ttl, r.err = records, ttl, err
+}
+
+func (r *fakeResolver) setIPs(ips ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ips = nil
+	for _, ip := range ips {
+		r.ips = append(r.ips, net.ParseIP(ip))
+	}
+}
+
+func (r *fakeResolver) numLookups() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.lookups
+}
+
+func (r *fakeResolver) LookupSRV(ctx context.Context, name string) ([]*net.SRV, time.Duration, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lookups++
+	return r.records, r.ttl, r.err
+}
+
+func (r *fakeResolver) LookupIP(ctx context.Context, host string) ([]net.IP, time.Duration, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lookups++
+	return r.ips, r.ttl, r.err
+}
+
+// update is a peer list update as identifiers and weights.
+type update struct {
+	removals  []string
+	additions map[string]int
+}
+
+// recordingList records the updates it receives.
+type recordingList struct {
+	mu      sync.Mutex
+	updates []update
+}
+
+func (l *recordingList) Update(updates peer.ListUpdates) error {
+	u := update{additions: make(map[string]int)}
+	for _, pid := range updates.Removals {
+		u.removals = append(u.removals, pid.Identifier())
+	}
+	for _, pid := range updates.Additions {
+		var weight int
+		if w, ok := pid.(weightedroundrobin.WeightedIdentifier); ok {
+			weight = w.Weight()
+		}
+		u.additions[pid.Identifier()] = weight
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.updates = append(l.updates, u)
+	return nil
+}
+
+func (l *recordingList) received() []update {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]update(nil), l.updates...)
+}
+
+func TestUpdater(t *testing.T) {
+	fake := clock.NewFake()
+	resolver := &fakeResolver{}
+	resolver.set(0, nil,
+		&net.SRV{Target: "a.example.com.", Port: 80, Priority: 10, Weight: 5},
+		&net.SRV{Target: "b.example.com.", Port: 81, Priority: 10, Weight: 1},
+		&net.SRV{Target: "backup.example.com.", Port: 80, Priority: 20, Weight: 1},
+	)
+	list := &recordingList{}
+	u := New(list, "_http._tcp.example.com", WithResolver(resolver), Interval(time.Minute), Jitter(0), withClock(fake))
+	require.NoError(t, u.Start())
+	defer u.Stop()
+	assert.True(t, u.IsRunning())
+
+	assert.Equal(t, []update{{
+		additions: map[string]int{"a.example.com:80": 5, "b.example.com:81": 1},
+	}}, list.received(), "must add the targets of the most preferred priority")
+
+	// Unchanged records do not update the list.
+	advance(t, u, fake, time.Minute)
+	assert.Equal(t, 2, resolver.numLookups())
+	assert.Len(t, list.received(), 1)
+
+	// Changed weights and targets are diffed, with a TTL this time.
+	resolver.set(10*time.Second, nil,
+		&net.SRV{Target: "a.example.com.", Port: 80, Priority: 10, Weight: 2},
+		&net.SRV{Target: "c.example.com.", Port: 80, Priority: 10, Weight: 1},
+	)
+	advance(t, u, fake, time.Minute)
+	require.Len(t, list.received(), 2)
+	assert.Equal(t, update{
+		removals:  []string{"a.example.com:80", "b.example.com:81"},
+		additions: map[string]int{"a.example.com:80": 2, "c.example.com:80": 1},
+	}, list.received()[1])
+
+	// Failed resolutions keep the current peers, and the TTL applies.
+	resolver.set(0, errors.New("great sadness"))
+	advance(t, u, fake, 10*time.Second)
+	assert.Equal(t, 4, resolver.numLookups(), "name must be resolved after the TTL")
+	assert.Len(t, list.received(), 2)
+
+	require.NoError(t, u.Stop())
+	fake.Add(time.Hour)
+	assert.Equal(t, 4, resolver.numLookups(), "must not resolve the name once stopped")
+}
+
+func TestHostUpdater(t *testing.T) {
+	fake := clock.NewFake()
+	resolver := &fakeResolver{}
+	resolver.setIPs("10.0.0.1", "10.0.0.2", "fd00::1")
+	list := &recordingList{}
+	u := NewHost(list, "myservice.default.svc.cluster.local:8080", WithResolver(resolver), Interval(time.Minute), Jitter(0), withClock(fake))
+	require.NoError(t, u.Start())
+	defer u.Stop()
+
+	assert.Equal(t, []update{{
+		additions: map[string]int{"10.0.0.1:8080": 0, "10.0.0.2:8080": 0, "[fd00::1]:8080": 0},
+	}}, list.received())
+
+	resolver.setIPs("10.0.0.2", "10.0.0.3")
+	advance(t, u, fake, time.Minute)
+	require.Len(t, list.received(), 2)
+	assert.Equal(t, update{
+		removals:  []string{"10.0.0.1:8080", "[fd00::1]:8080"},
+		additions: map[string]int{"10.0.0.3:8080": 0},
+	}, list.received()[1])
+}
+
+func TestRefreshInterval(t *testing.T) {
+	u := New(&recordingList{}, "foo", Interval(time.Minute), Jitter(0))
+	assert.Equal(t, time.Minute, u.refreshInterval(0))
+	assert.Equal(t, 5*time.Second, u.refreshInterval(5*time.Second))
+	assert.Equal(t, time.Second, u.refreshInterval(time.Millisecond))
+
+	u = New(&recordingList{}, "foo", Interval(time.Minute), Jitter(0.5))
+	for i := 0; i < 100; i++ {
+		d := u.refreshInterval(0)
+		assert.True(t, d > 30*time.Second && d <= time.Minute, "interval %v must be jittered by up to half", d)
+	}
+}
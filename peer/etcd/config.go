@@ -0,0 +1,70 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package etcd
+
+import (
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/yarpcconfig"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// Configuration describes how to build an etcd peer list updater.
+type Configuration struct {
+	Prefix    string   `config:"prefix"`
+	Endpoints []string `config:"endpoints"`
+}
+
+// Spec returns a configuration specification for the etcd peer list updater,
+// making it possible to track the instances registered under a prefix in etcd
+// with transports that use outbound peer list configuration (like HTTP).
+//
+//  cfg := yarpcconfig.New()
+//  cfg.MustRegisterPeerListUpdater(etcd.Spec())
+//
+// This enables the etcd peer list updater:
+//
+//  outbounds:
+//    otherservice:
+//      unary:
+//        http:
+//          url: http://host:port/rpc
+//          round-robin:
+//            etcd:
+//              prefix: /services/otherservice/
+//              endpoints:
+//                - http://etcd-1:2379
+//                - http://etcd-2:2379
+func Spec() yarpcconfig.PeerListUpdaterSpec {
+	return yarpcconfig.PeerListUpdaterSpec{
+		Name: "etcd",
+		BuildPeerListUpdater: func(cfg Configuration, k *yarpcconfig.Kit) (peer.Binder, error) {
+			if cfg.Prefix == "" {
+				return nil, yarpcerrors.InvalidArgumentErrorf("Prefix is required.")
+			}
+
+			var opts []Option
+			if len(cfg.Endpoints) > 0 {
+				opts = append(opts, Endpoints(cfg.Endpoints...))
+			}
+			return Binder(cfg.Prefix, opts...), nil
+		},
+	}
+}
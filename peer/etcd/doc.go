@@ -0,0 +1,42 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package etcd provides a peer list updater that tracks instances registered
+// under a prefix in etcd.
+//
+// Instances register themselves by putting a key under the prefix, typically
+// attached to a lease that they keep alive, with their host:port address as
+// the value:
+//
+// 	etcdctl put --lease=$LEASE /services/myservice/10.0.0.1:8080 10.0.0.1:8080
+//
+// The updater reads every key under the prefix and then watches the prefix
+// from the revision it read, reading it again whenever the watch ends. Keys
+// that are put add their address to the peer list, and keys that are deleted,
+// including keys whose lease expired, remove it.
+//
+// 	list := roundrobin.New(transport)
+// 	chooser := peer.Bind(list, etcd.Binder("/services/myservice/",
+// 		etcd.Endpoints("http://etcd-1:2379", "http://etcd-2:2379"),
+// 	))
+//
+// The updater speaks to etcd over the JSON gateway of its v3 API, which is
+// served on the client port of etcd 3.4 and later.
+package etcd
@@ -0,0 +1,91 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package etcd
+
+import (
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Option customizes the behavior of an etcd peer list updater.
+type Option func(*options)
+
+type options struct {
+	endpoints     []string
+	httpClient    *http.Client
+	retryInterval time.Duration
+	logger        *zap.Logger
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		endpoints:     []string{"http://127.0.0.1:2379"},
+		httpClient:    http.DefaultClient,
+		retryInterval: time.Second,
+		logger:        zap.NewNop(),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Endpoints specifies the client URLs of the etcd cluster, such as
+// http://etcd-1:2379. The updater moves on to the next endpoint whenever a
+// request fails.
+//
+// Defaults to http://127.0.0.1:2379.
+func Endpoints(urls ...string) Option {
+	return func(o *options) {
+		if len(urls) > 0 {
+			o.endpoints = urls
+		}
+	}
+}
+
+// HTTPClient specifies the HTTP client used to reach etcd, for example to
+// present client certificates.
+//
+// Defaults to http.DefaultClient.
+func HTTPClient(client *http.Client) Option {
+	return func(o *options) {
+		o.httpClient = client
+	}
+}
+
+// RetryInterval specifies how long to wait before reading the prefix again
+// after a failed read or watch.
+//
+// Defaults to 1 second.
+func RetryInterval(d time.Duration) Option {
+	return func(o *options) {
+		o.retryInterval = d
+	}
+}
+
+// Logger specifies a logger for failed reads and watches.
+func Logger(logger *zap.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
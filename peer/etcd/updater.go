@@ -0,0 +1,298 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package etcd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/peer/hostport"
+	"go.uber.org/yarpc/pkg/lifecycle"
+	"go.uber.org/zap"
+)
+
+var _ transport.Lifecycle = (*Updater)(nil)
+
+// Updater is a peer list updater that keeps a peer list in sync with the
+// instances registered under a prefix in etcd.
+type Updater struct {
+	list   peer.List
+	prefix string
+	opts   options
+	once   *lifecycle.Once
+
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	// The following are only accessed by the watch goroutine.
+
+	// endpoint is the index of the endpoint requests are sent to.
+	endpoint int
+	// keys holds the address registered under every key.
+	keys map[string]string
+	// peers holds the addresses sent to the list.
+	peers map[string]struct{}
+}
+
+// New creates an updater that sends the addresses registered under the
+// prefix to the peer list once started.
+func New(list peer.List, prefix string, opts ...Option) *Updater {
+	return &Updater{
+		list:   list,
+		prefix: prefix,
+		opts:   newOptions(opts),
+		once:   lifecycle.NewOnce(),
+		done:   make(chan struct{}),
+		keys:   make(map[string]string),
+		peers:  make(map[string]struct{}),
+	}
+}
+
+// Binder returns a peer.Binder that binds peer lists to an updater for the
+// prefix.
+func Binder(prefix string, opts ...Option) peer.Binder {
+	return func(list peer.List) transport.Lifecycle {
+		return New(list, prefix, opts...)
+	}
+}
+
+// Start starts watching the prefix. Failed reads and watches are logged and
+// retried.
+func (u *Updater) Start() error {
+	return u.once.Start(func() error {
+		ctx, cancel := context.WithCancel(context.Background())
+		u.cancel = cancel
+		go u.run(ctx)
+		return nil
+	})
+}
+
+// Stop stops watching the prefix. Peers already sent to the list remain.
+func (u *Updater) Stop() error {
+	return u.once.Stop(func() error {
+		if u.cancel == nil {
+			// Never started watching.
+			return nil
+		}
+		u.cancel()
+		<-u.done
+		return nil
+	})
+}
+
+// IsRunning returns whether the updater is running.
+func (u *Updater) IsRunning() bool {
+	return u.once.IsRunning()
+}
+
+// run reads and watches the prefix until the context is canceled, reading it
+// again whenever a watch ends.
+func (u *Updater) run(ctx context.Context) {
+	defer close(u.done)
+
+	for {
+		revision, err := u.readPrefix(ctx)
+		if err == nil {
+			err = u.watchPrefix(ctx, revision)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			// etcd ended the watch.
+			continue
+		}
+
+		endpoint := u.opts.endpoints[u.endpoint]
+		u.endpoint = (u.endpoint + 1) % len(u.opts.endpoints)
+		u.opts.logger.Warn("failed to watch etcd prefix, retrying",
+			zap.String("prefix", u.prefix),
+			zap.String("endpoint", endpoint),
+			zap.Error(err))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(u.opts.retryInterval):
+		}
+	}
+}
+
+// readPrefix replaces the known keys with those under the prefix, returning
+// the revision they were read at.
+func (u *Updater) readPrefix(ctx context.Context) (int64, error) {
+	res, err := u.post(ctx, "/v3/kv/range", rangeRequest{
+		Key:      []byte(u.prefix),
+		RangeEnd: prefixEnd([]byte(u.prefix)),
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	var r rangeResponse
+	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
+		return 0, err
+	}
+
+	u.keys = make(map[string]string, len(r.Kvs))
+	for _, kv := range r.Kvs {
+		u.put(kv)
+	}
+	u.sync()
+	return r.Header.Revision, nil
+}
+
+// watchPrefix applies changes to the keys under the prefix after the given
+// revision until the watch ends.
+func (u *Updater) watchPrefix(ctx context.Context, revision int64) error {
+	res, err := u.post(ctx, "/v3/watch", watchRequest{CreateRequest: watchCreateRequest{
+		Key:           []byte(u.prefix),
+		RangeEnd:      prefixEnd([]byte(u.prefix)),
+		StartRevision: revision + 1,
+	}})
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	dec := json.NewDecoder(res.Body)
+	for {
+		var msg watchMessage
+		if err := dec.Decode(&msg); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if msg.Error != nil {
+			return errors.New(msg.Error.Message)
+		}
+		if msg.Result == nil {
+			continue
+		}
+
+		r := msg.Result
+		if r.CompactRevision > 0 {
+			// The revision to watch from was compacted away, which a new read
+			// resolves.
+			return fmt.Errorf("watch revision %d was compacted at %d", revision+1, r.CompactRevision)
+		}
+		if r.Canceled {
+			return fmt.Errorf("watch canceled: %s", r.CancelReason)
+		}
+		if len(r.Events) == 0 {
+			continue
+		}
+		for _, e := range r.Events {
+			if e.Type == "DELETE" {
+				delete(u.keys, string(e.Kv.Key))
+			} else {
+				u.put(e.Kv)
+			}
+		}
+		u.sync()
+	}
+}
+
+// put records the address registered under the key, ignoring empty values.
+func (u *Updater) put(kv keyValue) {
+	addr := strings.TrimSpace(string(kv.Value))
+	if addr == "" {
+		delete(u.keys, string(kv.Key))
+		return
+	}
+	u.keys[string(kv.Key)] = addr
+}
+
+// post sends a request to the current etcd endpoint.
+func (u *Updater) post(ctx context.Context, path string, body interface{}) (*http.Response, error) {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := strings.TrimSuffix(u.opts.endpoints[u.endpoint], "/")
+	req, err := http.NewRequest("POST", endpoint+path, bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := u.opts.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		return nil, fmt.Errorf("unexpected response from etcd: %s", res.Status)
+	}
+	return res, nil
+}
+
+// sync sends the addresses that were registered or deregistered since the
+// last sync to the list.
+func (u *Updater) sync() {
+	addrs := make(map[string]struct{}, len(u.keys))
+	for _, addr := range u.keys {
+		addrs[addr] = struct{}{}
+	}
+
+	var removals, additions []string
+	for addr := range u.peers {
+		if _, ok := addrs[addr]; !ok {
+			removals = append(removals, addr)
+		}
+	}
+	for addr := range addrs {
+		if _, ok := u.peers[addr]; !ok {
+			additions = append(additions, addr)
+		}
+	}
+	if len(removals) == 0 && len(additions) == 0 {
+		return
+	}
+	sort.Strings(removals)
+	sort.Strings(additions)
+
+	var updates peer.ListUpdates
+	for _, addr := range removals {
+		updates.Removals = append(updates.Removals, hostport.PeerIdentifier(addr))
+	}
+	for _, addr := range additions {
+		updates.Additions = append(updates.Additions, hostport.PeerIdentifier(addr))
+	}
+	if err := u.list.Update(updates); err != nil {
+		u.opts.logger.Warn("failed to update peer list",
+			zap.String("prefix", u.prefix), zap.Error(err))
+	}
+	u.peers = addrs
+}
@@ -0,0 +1,158 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package etcd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/peer/peertest"
+	"go.uber.org/yarpc/internal/testtime"
+)
+
+// fakeEtcd serves the keys it holds and then the watch responses sent to it.
+type fakeEtcd struct {
+	t       *testing.T
+	results chan watchResponse
+
+	mu    sync.Mutex
+	kvs   []keyValue
+	reads int
+}
+
+func (e *fakeEtcd) setKVs(kvs ...keyValue) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.kvs = kvs
+}
+
+func (e *fakeEtcd) readCount() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.reads
+}
+
+func (e *fakeEtcd) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/v3/kv/range":
+		var req rangeRequest
+		assert.NoError(e.t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(e.t, "/services/myservice/", string(req.Key))
+		assert.Equal(e.t, "/services/myservice0", string(req.RangeEnd))
+
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		e.reads++
+		assert.NoError(e.t, json.NewEncoder(w).Encode(rangeResponse{
+			Header: responseHeader{Revision: 5},
+			Kvs:    e.kvs,
+		}))
+
+	case "/v3/watch":
+		var req watchRequest
+		assert.NoError(e.t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(e.t, "/services/myservice/", string(req.CreateRequest.Key))
+		assert.Equal(e.t, int64(6), req.CreateRequest.StartRevision)
+
+		enc := json.NewEncoder(w)
+		assert.NoError(e.t, enc.Encode(watchMessage{Result: &watchResponse{Created: true}}))
+		w.(http.Flusher).Flush()
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case result := <-e.results:
+				assert.NoError(e.t, enc.Encode(watchMessage{Result: &result}))
+				w.(http.Flusher).Flush()
+			}
+		}
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func kv(key, value string) keyValue {
+	return keyValue{Key: []byte("/services/myservice/" + key), Value: []byte(value)}
+}
+
+func TestUpdater(t *testing.T) {
+	etcd := &fakeEtcd{t: t, results: make(chan watchResponse)}
+	etcd.setKVs(kv("a", "10.0.0.1:8080"), kv("b", "10.0.0.2:8080"))
+	server := httptest.NewServer(etcd)
+	defer server.Close()
+
+	list := peertest.NewFakeList()
+	u := New(list, "/services/myservice/",
+		// The first endpoint refuses connections, so the updater must move
+		// on to the second.
+		Endpoints("http://127.0.0.1:1", server.URL),
+		RetryInterval(time.Millisecond),
+	)
+	require.NoError(t, u.Start())
+	defer u.Stop()
+
+	testtime.WaitFor(t, "registered instances must be read", func() bool {
+		return fmt.Sprint(list.IDs()) == "[10.0.0.1:8080 10.0.0.2:8080]"
+	})
+
+	etcd.results <- watchResponse{Events: []event{
+		{Kv: kv("c", "10.0.0.3:8080")},
+		{Type: "DELETE", Kv: kv("a", "")},
+	}}
+	testtime.WaitFor(t, "puts and deletes must be applied", func() bool {
+		return fmt.Sprint(list.IDs()) == "[10.0.0.2:8080 10.0.0.3:8080]"
+	})
+
+	// A compacted watch must be followed by a new read.
+	etcd.setKVs(kv("d", "10.0.0.4:8080"))
+	etcd.results <- watchResponse{CompactRevision: 10}
+	testtime.WaitFor(t, "compacted watches must read the prefix again", func() bool {
+		return fmt.Sprint(list.IDs()) == "[10.0.0.4:8080]"
+	})
+	assert.Equal(t, 2, etcd.readCount())
+
+	require.NoError(t, u.Stop())
+	assert.False(t, u.IsRunning())
+}
+
+func TestPrefixEnd(t *testing.T) {
+	tests := []struct {
+		prefix string
+		want   string
+	}{
+		{"/services/", "/services0"},
+		{"a", "b"},
+		{"a\xff", "b"},
+		{"\xff\xff", "\x00"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, []byte(tt.want), prefixEnd([]byte(tt.prefix)), "prefix %q", tt.prefix)
+	}
+}
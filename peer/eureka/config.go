@@ -0,0 +1,85 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package eureka
+
+import (
+	"time"
+
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/yarpcconfig"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// Configuration describes how to build a Eureka peer list updater.
+type Configuration struct {
+	App         string        `config:"app"`
+	ServiceURLs []string      `config:"service-urls"`
+	Interval    time.Duration `config:"interval"`
+	SecurePort  bool          `config:"secure-port"`
+}
+
+// Spec returns a configuration specification for the Eureka peer list
+// updater, making it possible to track the instances of an application
+// registered with Eureka with transports that use outbound peer list
+// configuration (like HTTP).
+//
+//  cfg := yarpcconfig.New()
+//  cfg.MustRegisterPeerListUpdater(eureka.Spec())
+//
+// This enables the eureka peer list updater:
+//
+//  outbounds:
+//    otherservice:
+//      unary:
+//        http:
+//          url: http://host:port/rpc
+//          round-robin:
+//            eureka:
+//              app: OTHERSERVICE
+//              service-urls:
+//                - http://eureka-1:8761/eureka
+//                - http://eureka-2:8761/eureka
+func Spec() yarpcconfig.PeerListUpdaterSpec {
+	return yarpcconfig.PeerListUpdaterSpec{
+		Name: "eureka",
+		BuildPeerListUpdater: func(cfg Configuration, k *yarpcconfig.Kit) (peer.Binder, error) {
+			if cfg.App == "" {
+				return nil, yarpcerrors.InvalidArgumentErrorf("App is required.")
+			}
+
+			var opts []Option
+			if len(cfg.ServiceURLs) > 0 {
+				opts = append(opts, ServiceURLs(cfg.ServiceURLs...))
+			}
+			if cfg.Interval < 0 {
+				return nil, yarpcerrors.InvalidArgumentErrorf(
+					"Interval must not be negative. Got: %v.", cfg.Interval)
+			}
+			if cfg.Interval > 0 {
+				opts = append(opts, Interval(cfg.Interval))
+			}
+			if cfg.SecurePort {
+				opts = append(opts, SecurePort())
+			}
+			return Binder(cfg.App, opts...), nil
+		},
+	}
+}
@@ -0,0 +1,39 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package eureka provides a peer list updater that tracks the instances of
+// an application registered with Netflix Eureka, for services migrating from
+// Ribbon-based stacks.
+//
+// The updater fetches the instances of the application when it starts, and
+// then polls the registry's delta API at an interval, like the Eureka client
+// does. Instances with the UP status are sent to the peer list as host:port
+// identifiers, using their IP address and port, and are removed once they
+// change status or are cancelled.
+//
+// 	list := roundrobin.New(transport)
+// 	chooser := peer.Bind(list, eureka.Binder("MYSERVICE",
+// 		eureka.ServiceURLs("http://eureka-1:8761/eureka", "http://eureka-2:8761/eureka"),
+// 	))
+//
+// Since the delta API only describes recent changes, the updater fetches the
+// application in full after a failed poll and every tenth poll, so that
+// missed changes are eventually corrected.
+package eureka
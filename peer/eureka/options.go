@@ -0,0 +1,98 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package eureka
+
+import (
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Option customizes the behavior of a Eureka peer list updater.
+type Option func(*options)
+
+type options struct {
+	serviceURLs []string
+	interval    time.Duration
+	securePort  bool
+	httpClient  *http.Client
+	logger      *zap.Logger
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		serviceURLs: []string{"http://localhost:8761/eureka"},
+		interval:    30 * time.Second,
+		httpClient:  http.DefaultClient,
+		logger:      zap.NewNop(),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// ServiceURLs specifies the URLs of the Eureka servers, such as
+// http://eureka-1:8761/eureka. The updater moves on to the next server
+// whenever a request fails.
+//
+// Defaults to http://localhost:8761/eureka.
+func ServiceURLs(urls ...string) Option {
+	return func(o *options) {
+		if len(urls) > 0 {
+			o.serviceURLs = urls
+		}
+	}
+}
+
+// Interval specifies how often the registry is polled for changes.
+//
+// Defaults to 30 seconds, like the Eureka client.
+func Interval(d time.Duration) Option {
+	return func(o *options) {
+		o.interval = d
+	}
+}
+
+// SecurePort sends requests to the secure port of instances rather than
+// their port.
+func SecurePort() Option {
+	return func(o *options) {
+		o.securePort = true
+	}
+}
+
+// HTTPClient specifies the HTTP client used to reach the Eureka servers.
+//
+// Defaults to http.DefaultClient.
+func HTTPClient(client *http.Client) Option {
+	return func(o *options) {
+		o.httpClient = client
+	}
+}
+
+// Logger specifies a logger for failed polls.
+func Logger(logger *zap.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
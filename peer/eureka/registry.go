@@ -0,0 +1,132 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package eureka
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// The following types decode the parts of the JSON responses of the Eureka
+// REST API the updater needs.
+
+type applicationResponse struct {
+	Application application `json:"application"`
+}
+
+type deltaResponse struct {
+	Applications struct {
+		Application applicationSlice `json:"application"`
+	} `json:"applications"`
+}
+
+type application struct {
+	Name     string        `json:"name"`
+	Instance instanceSlice `json:"instance"`
+}
+
+type instance struct {
+	InstanceID string `json:"instanceId"`
+	HostName   string `json:"hostName"`
+	IPAddr     string `json:"ipAddr"`
+	Status     string `json:"status"`
+	Port       port   `json:"port"`
+	SecurePort port   `json:"securePort"`
+	ActionType string `json:"actionType"`
+}
+
+type port struct {
+	Port    int    `json:"$"`
+	Enabled string `json:"@enabled"`
+}
+
+// id returns the identifier of the instance, which older servers only
+// provide as its host name.
+func (i *instance) id() string {
+	if i.InstanceID != "" {
+		return i.InstanceID
+	}
+	return i.HostName
+}
+
+// address returns the host:port address of the instance, or an empty string
+// if the instance should not receive requests.
+func (i *instance) address(secure bool) string {
+	if i.Status != "UP" {
+		return ""
+	}
+	p := i.Port
+	if secure {
+		p = i.SecurePort
+	}
+	if p.Port == 0 || strings.EqualFold(p.Enabled, "false") {
+		return ""
+	}
+	host := i.IPAddr
+	if host == "" {
+		host = i.HostName
+	}
+	if host == "" {
+		return ""
+	}
+	return net.JoinHostPort(host, strconv.Itoa(p.Port))
+}
+
+// Eureka encodes lists with a single element as the element alone, so the
+// following slices decode either form.
+
+type instanceSlice []instance
+
+func (s *instanceSlice) UnmarshalJSON(b []byte) error {
+	return unmarshalSlice(b, (*[]instance)(s), func() error {
+		var i instance
+		err := json.Unmarshal(b, &i)
+		*s = instanceSlice{i}
+		return err
+	})
+}
+
+type applicationSlice []application
+
+func (s *applicationSlice) UnmarshalJSON(b []byte) error {
+	return unmarshalSlice(b, (*[]application)(s), func() error {
+		var a application
+		err := json.Unmarshal(b, &a)
+		*s = applicationSlice{a}
+		return err
+	})
+}
+
+// unmarshalSlice decodes a JSON array into the slice, or calls single for
+// any other JSON value.
+func unmarshalSlice(b []byte, slice interface{}, single func() error) error {
+	b = bytes.TrimSpace(b)
+	if len(b) > 0 && b[0] == '[' {
+		return json.Unmarshal(b, slice)
+	}
+	if bytes.Equal(b, []byte("null")) {
+		return nil
+	}
+	return single()
+}
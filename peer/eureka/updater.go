@@ -0,0 +1,270 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package eureka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/peer/hostport"
+	"go.uber.org/yarpc/pkg/lifecycle"
+	"go.uber.org/zap"
+)
+
+// _fullFetchEvery is how many polls the updater makes between full fetches
+// of the application.
+const _fullFetchEvery = 10
+
+var _ transport.Lifecycle = (*Updater)(nil)
+
+// Updater is a peer list updater that keeps a peer list in sync with the UP
+// instances of an application registered with Eureka.
+type Updater struct {
+	list peer.List
+	app  string
+	opts options
+	once *lifecycle.Once
+
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	// The following are only accessed by the poll goroutine.
+
+	// server is the index of the server requests are sent to.
+	server int
+	// instances holds the address of every UP instance, by instance ID.
+	instances map[string]string
+	// peers holds the addresses sent to the list.
+	peers map[string]struct{}
+}
+
+// New creates an updater that sends the UP instances of the named
+// application to the peer list once started.
+func New(list peer.List, app string, opts ...Option) *Updater {
+	return &Updater{
+		list:      list,
+		app:       strings.ToUpper(app),
+		opts:      newOptions(opts),
+		once:      lifecycle.NewOnce(),
+		done:      make(chan struct{}),
+		instances: make(map[string]string),
+		peers:     make(map[string]struct{}),
+	}
+}
+
+// Binder returns a peer.Binder that binds peer lists to an updater for the
+// named application.
+func Binder(app string, opts ...Option) peer.Binder {
+	return func(list peer.List) transport.Lifecycle {
+		return New(list, app, opts...)
+	}
+}
+
+// Start starts polling the registry. Failed polls are logged and retried.
+func (u *Updater) Start() error {
+	return u.once.Start(func() error {
+		ctx, cancel := context.WithCancel(context.Background())
+		u.cancel = cancel
+		go u.run(ctx)
+		return nil
+	})
+}
+
+// Stop stops polling the registry. Peers already sent to the list remain.
+func (u *Updater) Stop() error {
+	return u.once.Stop(func() error {
+		u.cancel()
+		<-u.done
+		return nil
+	})
+}
+
+// IsRunning returns whether the updater is running.
+func (u *Updater) IsRunning() bool {
+	return u.once.IsRunning()
+}
+
+// run polls the registry at the interval until the context is canceled.
+func (u *Updater) run(ctx context.Context) {
+	defer close(u.done)
+
+	// polls counts the polls since the last full fetch, which is due when it
+	// is zero.
+	var polls int
+	for {
+		var err error
+		if polls == 0 {
+			err = u.fetchApplication(ctx)
+		} else {
+			err = u.fetchDelta(ctx)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		polls = (polls + 1) % _fullFetchEvery
+		if err != nil {
+			server := u.opts.serviceURLs[u.server]
+			u.server = (u.server + 1) % len(u.opts.serviceURLs)
+			u.opts.logger.Warn("failed to poll Eureka registry",
+				zap.String("app", u.app),
+				zap.String("server", server),
+				zap.Error(err))
+			polls = 0
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(u.opts.interval):
+		}
+	}
+}
+
+// fetchApplication replaces the known instances with those of the
+// application.
+func (u *Updater) fetchApplication(ctx context.Context) error {
+	var res applicationResponse
+	err := u.get(ctx, "/apps/"+url.PathEscape(u.app), &res)
+	if err != nil && err != errNotFound {
+		return err
+	}
+
+	// An application that is not found has no instances.
+	u.instances = make(map[string]string, len(res.Application.Instance))
+	for _, inst := range res.Application.Instance {
+		u.apply(inst)
+	}
+	u.sync()
+	return nil
+}
+
+// fetchDelta applies the recent changes to the instances of the
+// application.
+func (u *Updater) fetchDelta(ctx context.Context) error {
+	var res deltaResponse
+	if err := u.get(ctx, "/apps/delta", &res); err != nil {
+		return err
+	}
+
+	for _, app := range res.Applications.Application {
+		if !strings.EqualFold(app.Name, u.app) {
+			continue
+		}
+		for _, inst := range app.Instance {
+			if inst.ActionType == "DELETED" {
+				delete(u.instances, inst.id())
+			} else {
+				u.apply(inst)
+			}
+		}
+	}
+	u.sync()
+	return nil
+}
+
+// apply records the address of the instance if it is UP, and forgets it
+// otherwise.
+func (u *Updater) apply(inst instance) {
+	if addr := inst.address(u.opts.securePort); addr != "" {
+		u.instances[inst.id()] = addr
+	} else {
+		delete(u.instances, inst.id())
+	}
+}
+
+// errNotFound is returned by get if the server responds with 404 Not Found.
+var errNotFound = errors.New("not found")
+
+// get requests the path from the current Eureka server and decodes the JSON
+// response into v.
+func (u *Updater) get(ctx context.Context, path string, v interface{}) error {
+	server := strings.TrimSuffix(u.opts.serviceURLs[u.server], "/")
+	req, err := http.NewRequest("GET", server+path, nil)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "application/json")
+
+	res, err := u.opts.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		return json.NewDecoder(res.Body).Decode(v)
+	case http.StatusNotFound:
+		return errNotFound
+	default:
+		return fmt.Errorf("unexpected response from Eureka: %s", res.Status)
+	}
+}
+
+// sync sends the addresses that were added or removed since the last sync to
+// the list.
+func (u *Updater) sync() {
+	addrs := make(map[string]struct{}, len(u.instances))
+	for _, addr := range u.instances {
+		addrs[addr] = struct{}{}
+	}
+
+	var removals, additions []string
+	for addr := range u.peers {
+		if _, ok := addrs[addr]; !ok {
+			removals = append(removals, addr)
+		}
+	}
+	for addr := range addrs {
+		if _, ok := u.peers[addr]; !ok {
+			additions = append(additions, addr)
+		}
+	}
+	if len(removals) == 0 && len(additions) == 0 {
+		return
+	}
+	sort.Strings(removals)
+	sort.Strings(additions)
+
+	var updates peer.ListUpdates
+	for _, addr := range removals {
+		updates.Removals = append(updates.Removals, hostport.PeerIdentifier(addr))
+	}
+	for _, addr := range additions {
+		updates.Additions = append(updates.Additions, hostport.PeerIdentifier(addr))
+	}
+	if err := u.list.Update(updates); err != nil {
+		u.opts.logger.Warn("failed to update peer list",
+			zap.String("app", u.app), zap.Error(err))
+	}
+	u.peers = addrs
+}
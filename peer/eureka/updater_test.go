@@ -0,0 +1,174 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package eureka
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/peer/peertest"
+	"go.uber.org/yarpc/internal/testtime"
+)
+
+// fakeEureka serves fixed responses for the application and delta APIs.
+type fakeEureka struct {
+	t *testing.T
+
+	mu    sync.Mutex
+	app   string
+	delta string
+}
+
+func (e *fakeEureka) set(app, delta string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.app, e.delta = app, delta
+}
+
+func (e *fakeEureka) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	assert.Equal(e.t, "application/json", r.Header.Get("Accept"))
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	switch r.URL.Path {
+	case "/eureka/apps/MYSERVICE":
+		if e.app == "" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, e.app)
+	case "/eureka/apps/delta":
+		fmt.Fprint(w, e.delta)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+const _app = `{"application": {"name": "MYSERVICE", "instance": [
+	{"instanceId": "a", "ipAddr": "10.0.0.1", "status": "UP", "port": {"$": 8080, "@enabled": "true"}},
+	{"instanceId": "b", "ipAddr": "10.0.0.2", "status": "DOWN", "port": {"$": 8080, "@enabled": "true"}},
+	{"hostName": "c.example.com", "status": "UP", "port": {"$": 8080, "@enabled": "true"}}
+]}}`
+
+const _delta = `{"applications": {"versions__delta": "2", "application": [
+	{"name": "OTHERSERVICE", "instance": {"instanceId": "x", "ipAddr": "10.1.0.1", "status": "UP", "port": {"$": 8080, "@enabled": "true"}, "actionType": "ADDED"}},
+	{"name": "MYSERVICE", "instance": [
+		{"instanceId": "a", "ipAddr": "10.0.0.1", "status": "OUT_OF_SERVICE", "port": {"$": 8080, "@enabled": "true"}, "actionType": "MODIFIED"},
+		{"instanceId": "b", "ipAddr": "10.0.0.2", "status": "UP", "port": {"$": 8080, "@enabled": "true"}, "actionType": "MODIFIED"},
+		{"hostName": "c.example.com", "status": "UP", "port": {"$": 8080, "@enabled": "true"}, "actionType": "DELETED"},
+		{"instanceId": "d", "ipAddr": "10.0.0.4", "status": "UP", "port": {"$": 8080, "@enabled": "true"}, "actionType": "ADDED"}
+	]}
+]}}`
+
+func TestUpdaterFetch(t *testing.T) {
+	eureka := &fakeEureka{t: t}
+	eureka.set(_app, _delta)
+	server := httptest.NewServer(eureka)
+	defer server.Close()
+
+	list := peertest.NewFakeList()
+	u := New(list, "myservice", ServiceURLs(server.URL+"/eureka/"))
+	ctx := context.Background()
+
+	require.NoError(t, u.fetchApplication(ctx))
+	assert.Equal(t, "[10.0.0.1:8080 c.example.com:8080]", fmt.Sprint(list.IDs()), "UP instances must be added")
+
+	require.NoError(t, u.fetchDelta(ctx))
+	assert.Equal(t, "[10.0.0.2:8080 10.0.0.4:8080]", fmt.Sprint(list.IDs()), "changes must be applied")
+
+	require.NoError(t, u.fetchDelta(ctx))
+	assert.Equal(t, "[10.0.0.2:8080 10.0.0.4:8080]", fmt.Sprint(list.IDs()), "changes must be applied once")
+
+	eureka.set("", _delta)
+	require.NoError(t, u.fetchApplication(ctx))
+	assert.Equal(t, "[]", fmt.Sprint(list.IDs()), "unknown applications must have no instances")
+}
+
+func TestUpdater(t *testing.T) {
+	eureka := &fakeEureka{t: t}
+	eureka.set(_app, `{"applications": {}}`)
+	server := httptest.NewServer(eureka)
+	defer server.Close()
+
+	list := peertest.NewFakeList()
+	u := New(list, "MYSERVICE",
+		// The first server refuses connections, so the updater must move on
+		// to the second.
+		ServiceURLs("http://127.0.0.1:1/eureka", server.URL+"/eureka"),
+		Interval(time.Millisecond),
+	)
+	require.NoError(t, u.Start())
+	defer u.Stop()
+
+	testtime.WaitFor(t, "UP instances must be added", func() bool {
+		return fmt.Sprint(list.IDs()) == "[10.0.0.1:8080 c.example.com:8080]"
+	})
+
+	require.NoError(t, u.Stop())
+	assert.False(t, u.IsRunning())
+}
+
+func TestInstanceAddress(t *testing.T) {
+	tests := []struct {
+		msg    string
+		give   instance
+		secure bool
+		want   string
+	}{
+		{
+			msg:  "up",
+			give: instance{IPAddr: "10.0.0.1", Status: "UP", Port: port{Port: 8080, Enabled: "true"}},
+			want: "10.0.0.1:8080",
+		},
+		{
+			msg:  "starting",
+			give: instance{IPAddr: "10.0.0.1", Status: "STARTING", Port: port{Port: 8080, Enabled: "true"}},
+		},
+		{
+			msg:  "port disabled",
+			give: instance{IPAddr: "10.0.0.1", Status: "UP", Port: port{Port: 8080, Enabled: "false"}},
+		},
+		{
+			msg: "secure port",
+			give: instance{
+				IPAddr:     "10.0.0.1",
+				Status:     "UP",
+				Port:       port{Port: 8080, Enabled: "true"},
+				SecurePort: port{Port: 8443, Enabled: "true"},
+			},
+			secure: true,
+			want:   "10.0.0.1:8443",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.msg, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.give.address(tt.secure))
+		})
+	}
+}
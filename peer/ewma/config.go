@@ -0,0 +1,89 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ewma
+
+import (
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/yarpcconfig"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// Configuration describes how to build an EWMA peer list.
+type Configuration struct {
+	Capacity   *int     `config:"capacity"`
+	Smoothing  *float64 `config:"smoothing"`
+	ProbeRatio *float64 `config:"probe-ratio"`
+}
+
+// Spec returns a configuration specification for the latency-aware EWMA peer
+// list implementation, making it possible to prefer the fastest peers with
+// transports that use outbound peer list configuration (like HTTP).
+//
+//  cfg := yarpcconfig.New()
+//  cfg.MustRegisterPeerList(ewma.Spec())
+//
+// This enables the EWMA peer list:
+//
+//  outbounds:
+//    otherservice:
+//      unary:
+//        http:
+//          url: https://host:port/rpc
+//          ewma:
+//            smoothing: 0.2
+//            probe-ratio: 0.1
+//            peers:
+//              - 127.0.0.1:8080
+//              - 127.0.0.1:8081
+func Spec() yarpcconfig.PeerListSpec {
+	return yarpcconfig.PeerListSpec{
+		Name: "ewma",
+		BuildPeerList: func(cfg Configuration, t peer.Transport, k *yarpcconfig.Kit) (peer.ChooserList, error) {
+			var opts []ListOption
+
+			if cfg.Capacity != nil {
+				if *cfg.Capacity <= 0 {
+					return nil, yarpcerrors.InvalidArgumentErrorf(
+						"Capacity must be greater than 0. Got: %d.", *cfg.Capacity)
+				}
+				opts = append(opts, Capacity(*cfg.Capacity))
+			}
+
+			if cfg.Smoothing != nil {
+				if *cfg.Smoothing <= 0 || *cfg.Smoothing > 1 {
+					return nil, yarpcerrors.InvalidArgumentErrorf(
+						"Smoothing must be greater than 0 and at most 1. Got: %v.", *cfg.Smoothing)
+				}
+				opts = append(opts, Smoothing(*cfg.Smoothing))
+			}
+
+			if cfg.ProbeRatio != nil {
+				if *cfg.ProbeRatio < 0 || *cfg.ProbeRatio > 1 {
+					return nil, yarpcerrors.InvalidArgumentErrorf(
+						"ProbeRatio must be between 0 and 1. Got: %v.", *cfg.ProbeRatio)
+				}
+				opts = append(opts, ProbeRatio(*cfg.ProbeRatio))
+			}
+
+			return New(t, opts...), nil
+		},
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package ewma provides an implementation of a peer list that prefers the
+// peers that have recently responded fastest.
+//
+// The list tracks an exponentially weighted moving average (EWMA) of each
+// peer's request latency, fed by the completion of every request it chooses.
+// Each request goes to the peer with the lowest score, the average latency
+// multiplied by one more than its number of pending requests, so that a
+// burst of requests does not all pile onto the single fastest peer.
+//
+// Peers that have not yet completed a request score zero and are tried
+// first. A small fraction of requests probe a random peer instead, so that
+// a peer that was slow has a chance to show that it has recovered. This
+// reduces tail latency when part of a fleet is degraded.
+package ewma
@@ -0,0 +1,169 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ewma
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+)
+
+type subscriber struct {
+	peer peer.StatusPeer
+
+	// ewma is the moving average latency of the peer in nanoseconds, or zero
+	// if the peer has not yet completed a request.
+	ewma float64
+}
+
+func (s *subscriber) NotifyStatusChanged(pid peer.Identifier) {}
+
+// score returns the expected cost of sending one more request to the peer.
+//
+// Must be called with the lock held.
+func (s *subscriber) score() float64 {
+	return s.ewma * float64(s.peer.Status().PendingRequestCount+1)
+}
+
+// latencyList chooses among available peers by their moving average
+// latency.
+//
+// The peer list calls Choose with only its read lock held, and latencies are
+// observed without it, so latencyList guards its own state.
+type latencyList struct {
+	mu sync.Mutex
+
+	subs map[string]*subscriber
+
+	smoothing  float64
+	probeRatio float64
+	rand       *rand.Rand
+}
+
+func newLatencyList(smoothing, probeRatio float64, seed int64) *latencyList {
+	return &latencyList{
+		subs:       make(map[string]*subscriber),
+		smoothing:  smoothing,
+		probeRatio: probeRatio,
+		rand:       rand.New(rand.NewSource(seed)),
+	}
+}
+
+// Add adds the peer. It has no latency history.
+func (l *latencyList) Add(p peer.StatusPeer) peer.Subscriber {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	sub := &subscriber{peer: p}
+	l.subs[p.Identifier()] = sub
+	return sub
+}
+
+// Remove removes the peer and forgets its latency history.
+func (l *latencyList) Remove(p peer.StatusPeer, s peer.Subscriber) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if sub, ok := l.subs[p.Identifier()]; ok && sub == s {
+		delete(l.subs, p.Identifier())
+	}
+}
+
+// observe folds a latency sample into the moving average of the peer with
+// the given identifier. Samples for peers that have since been removed are
+// dropped.
+func (l *latencyList) observe(id string, d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	sub, ok := l.subs[id]
+	if !ok {
+		return
+	}
+	if sub.ewma == 0 {
+		sub.ewma = float64(d)
+		return
+	}
+	sub.ewma = l.smoothing*float64(d) + (1-l.smoothing)*sub.ewma
+}
+
+// Choose returns the peer with the lowest score, or a random peer for a
+// fraction of requests. Returns nil if there are no peers.
+func (l *latencyList) Choose(_ context.Context, _ *transport.Request) peer.StatusPeer {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.subs) == 0 {
+		return nil
+	}
+
+	probe := l.probeRatio > 0 && l.rand.Float64() < l.probeRatio
+	if probe {
+		n := l.rand.Intn(len(l.subs))
+		for _, sub := range l.subs {
+			if n == 0 {
+				return sub.peer
+			}
+			n--
+		}
+	}
+
+	var best *subscriber
+	for _, sub := range l.subs {
+		if best == nil || less(sub, best) {
+			best = sub
+		}
+	}
+	return best.peer
+}
+
+// less reports whether a is a better choice than b.
+//
+// Peers without latency history all score zero, so ties are broken by the
+// number of pending requests to spread requests among them, and then by
+// identifier so that choices do not depend on map iteration order.
+func less(a, b *subscriber) bool {
+	if sa, sb := a.score(), b.score(); sa != sb {
+		return sa < sb
+	}
+	pa := a.peer.Status().PendingRequestCount
+	pb := b.peer.Status().PendingRequestCount
+	if pa != pb {
+		return pa < pb
+	}
+	return a.peer.Identifier() < b.peer.Identifier()
+}
+
+func (l *latencyList) Start() error {
+	return nil
+}
+
+func (l *latencyList) Stop() error {
+	return nil
+}
+
+func (l *latencyList) IsRunning() bool {
+	return true
+}
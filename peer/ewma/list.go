@@ -0,0 +1,132 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ewma
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/peer/peerlist"
+)
+
+type listConfig struct {
+	capacity   int
+	smoothing  float64
+	probeRatio float64
+	seed       int64
+	clock      clock.Clock
+}
+
+// ListOption customizes the behavior of an EWMA peer list.
+type ListOption func(*listConfig)
+
+// Capacity specifies the default capacity of the underlying
+// data structures for this list.
+//
+// Defaults to 10.
+func Capacity(capacity int) ListOption {
+	return func(c *listConfig) {
+		c.capacity = capacity
+	}
+}
+
+// Smoothing specifies the weight, between 0 and 1, given to each new latency
+// sample in a peer's moving average. Higher values react faster to changes
+// in latency; lower values smooth out noise.
+//
+// Defaults to 0.3.
+func Smoothing(smoothing float64) ListOption {
+	return func(c *listConfig) {
+		c.smoothing = smoothing
+	}
+}
+
+// ProbeRatio specifies the fraction of requests, between 0 and 1, that are
+// sent to a random peer instead of the fastest one.
+//
+// Defaults to 0.05.
+func ProbeRatio(ratio float64) ListOption {
+	return func(c *listConfig) {
+		c.probeRatio = ratio
+	}
+}
+
+// Seed specifies the random seed used to choose when and where to probe.
+//
+// Defaults to approximately the process start time in nanoseconds.
+func Seed(seed int64) ListOption {
+	return func(c *listConfig) {
+		c.seed = seed
+	}
+}
+
+// New creates a new latency-aware EWMA peer list.
+func New(transport peer.Transport, opts ...ListOption) *List {
+	cfg := listConfig{
+		capacity:   10,
+		smoothing:  0.3,
+		probeRatio: 0.05,
+		seed:       time.Now().UnixNano(),
+		clock:      clock.NewReal(),
+	}
+	for _, o := range opts {
+		o(&cfg)
+	}
+
+	latencies := newLatencyList(cfg.smoothing, cfg.probeRatio, cfg.seed)
+	return &List{
+		List: peerlist.New(
+			"ewma",
+			transport,
+			latencies,
+			peerlist.Capacity(cfg.capacity),
+		),
+		latencies: latencies,
+		clock:     cfg.clock,
+	}
+}
+
+// List is a PeerList which prefers the peers with the lowest recent latency.
+type List struct {
+	*peerlist.List
+
+	latencies *latencyList
+	clock     clock.Clock
+}
+
+// Choose selects the available peer with the lowest latency score and
+// records the latency of the request when it finishes.
+func (l *List) Choose(ctx context.Context, req *transport.Request) (peer.Peer, func(error), error) {
+	p, onFinish, err := l.List.Choose(ctx, req)
+	if err != nil {
+		return p, onFinish, err
+	}
+
+	id := p.Identifier()
+	start := l.clock.Now()
+	return p, func(err error) {
+		l.latencies.observe(id, l.clock.Now().Sub(start))
+		onFinish(err)
+	}, nil
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ewma
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/peer/hostport"
+	"go.uber.org/yarpc/yarpctest"
+)
+
+func withClock(c clock.Clock) ListOption {
+	return func(cfg *listConfig) {
+		cfg.clock = c
+	}
+}
+
+func newStartedList(t *testing.T, opts ...ListOption) *List {
+	pl := New(yarpctest.NewFakeTransport(), opts...)
+	require.NoError(t, pl.Start())
+	require.NoError(t, pl.Update(peer.ListUpdates{Additions: []peer.Identifier{
+		hostport.PeerIdentifier("a:1"),
+		hostport.PeerIdentifier("b:1"),
+		hostport.PeerIdentifier("c:1"),
+	}}))
+	return pl
+}
+
+// call chooses a peer, lets the request take d, and returns the chosen peer's
+// identifier.
+func call(t *testing.T, pl *List, fake *clock.FakeClock, d time.Duration) string {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	p, onFinish, err := pl.Choose(ctx, &transport.Request{})
+	require.NoError(t, err)
+	fake.Add(d)
+	onFinish(nil)
+	return p.Identifier()
+}
+
+func TestEWMAPrefersFastPeers(t *testing.T) {
+	fake := clock.NewFake()
+	pl := newStartedList(t, ProbeRatio(0), Smoothing(0.5), withClock(fake))
+	defer pl.Stop()
+
+	// Peers without history are tried first.
+	assert.Equal(t, "a:1", call(t, pl, fake, 100*time.Millisecond))
+	assert.Equal(t, "b:1", call(t, pl, fake, 10*time.Millisecond))
+	assert.Equal(t, "c:1", call(t, pl, fake, 50*time.Millisecond))
+
+	// The fastest peer is preferred.
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, "b:1", call(t, pl, fake, 10*time.Millisecond))
+	}
+
+	// Once it degrades, its average rises above the next fastest peer.
+	assert.Equal(t, "b:1", call(t, pl, fake, 200*time.Millisecond))
+	assert.Equal(t, "c:1", call(t, pl, fake, 50*time.Millisecond))
+}
+
+func TestEWMAProbesSlowPeers(t *testing.T) {
+	fake := clock.NewFake()
+	pl := newStartedList(t, ProbeRatio(0.5), Seed(1), withClock(fake))
+	defer pl.Stop()
+
+	latencies := map[string]time.Duration{
+		"a:1": time.Millisecond,
+		"b:1": time.Second,
+		"c:1": time.Second,
+	}
+	counts := make(map[string]int)
+	for i := 0; i < 100; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		p, onFinish, err := pl.Choose(ctx, &transport.Request{})
+		require.NoError(t, err)
+		fake.Add(latencies[p.Identifier()])
+		onFinish(nil)
+		cancel()
+		counts[p.Identifier()]++
+	}
+
+	assert.True(t, counts["a:1"] > 50, "fast peer must receive most requests: %v", counts)
+	assert.True(t, counts["b:1"] > 1, "slow peers must be probed: %v", counts)
+	assert.True(t, counts["c:1"] > 1, "slow peers must be probed: %v", counts)
+}
+
+func TestEWMAForgetsRemovedPeers(t *testing.T) {
+	fake := clock.NewFake()
+	pl := newStartedList(t, ProbeRatio(0), withClock(fake))
+	defer pl.Stop()
+
+	assert.Equal(t, "a:1", call(t, pl, fake, time.Second))
+	assert.Equal(t, "b:1", call(t, pl, fake, time.Millisecond))
+	assert.Equal(t, "c:1", call(t, pl, fake, time.Millisecond))
+
+	require.NoError(t, pl.Update(peer.ListUpdates{Removals: []peer.Identifier{hostport.PeerIdentifier("a:1")}}))
+	require.NoError(t, pl.Update(peer.ListUpdates{Additions: []peer.Identifier{hostport.PeerIdentifier("a:1")}}))
+
+	// The re-added peer has no history, so it is tried first.
+	assert.Equal(t, "a:1", call(t, pl, fake, time.Millisecond))
+}
@@ -0,0 +1,133 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fallback
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/multierr"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/introspection"
+)
+
+var _ peer.Chooser = (*Chooser)(nil)
+
+type options struct {
+	timeout time.Duration
+}
+
+// Option customizes a fallback chooser.
+type Option func(*options)
+
+// Timeout specifies how long the primary chooser may take to choose a peer
+// before the fallback chooser is consulted.
+//
+// Defaults to 100 milliseconds.
+func Timeout(d time.Duration) Option {
+	return func(o *options) {
+		o.timeout = d
+	}
+}
+
+// Chooser is a peer chooser that falls back to a secondary chooser when the
+// primary chooser cannot produce a peer.
+type Chooser struct {
+	primary  peer.Chooser
+	fallback peer.Chooser
+	opts     options
+}
+
+// New creates a chooser that prefers the primary chooser and falls back to
+// the fallback chooser. The chooser takes ownership of both, starting and
+// stopping them with itself.
+func New(primary, fallback peer.Chooser, opts ...Option) *Chooser {
+	o := options{timeout: 100 * time.Millisecond}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &Chooser{primary: primary, fallback: fallback, opts: o}
+}
+
+// Choose chooses a peer from the primary chooser, waiting at most the
+// timeout. If the primary chooser fails and the request's deadline has not
+// passed, Choose chooses a peer from the fallback chooser instead.
+func (c *Chooser) Choose(ctx context.Context, req *transport.Request) (peer.Peer, func(error), error) {
+	primaryCtx, cancel := context.WithTimeout(ctx, c.opts.timeout)
+	p, onFinish, err := c.primary.Choose(primaryCtx, req)
+	cancel()
+	if err == nil {
+		return p, onFinish, nil
+	}
+	if ctx.Err() != nil {
+		return nil, nil, err
+	}
+	return c.fallback.Choose(ctx, req)
+}
+
+// Start starts the primary and fallback choosers.
+func (c *Chooser) Start() error {
+	return multierr.Append(c.primary.Start(), c.fallback.Start())
+}
+
+// Stop stops the primary and fallback choosers.
+func (c *Chooser) Stop() error {
+	return multierr.Append(c.primary.Stop(), c.fallback.Stop())
+}
+
+// IsRunning returns whether both the primary and fallback choosers are
+// running.
+func (c *Chooser) IsRunning() bool {
+	return c.primary.IsRunning() && c.fallback.IsRunning()
+}
+
+// Introspect returns the peers of the primary chooser followed by the peers
+// of the fallback chooser.
+func (c *Chooser) Introspect() introspection.ChooserStatus {
+	state := "Stopped"
+	if c.IsRunning() {
+		state = "Running"
+	}
+
+	var peers []introspection.PeerStatus
+	for _, chooser := range []struct {
+		role    string
+		chooser peer.Chooser
+	}{
+		{"primary", c.primary},
+		{"fallback", c.fallback},
+	} {
+		if ic, ok := chooser.chooser.(introspection.IntrospectableChooser); ok {
+			for _, ps := range ic.Introspect().Peers {
+				ps.State = fmt.Sprintf("%s, %s", chooser.role, ps.State)
+				peers = append(peers, ps)
+			}
+		}
+	}
+
+	return introspection.ChooserStatus{
+		Name:  "fallback",
+		State: fmt.Sprintf("%s (timeout: %v)", state, c.opts.timeout),
+		Peers: peers,
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fallback
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/peer/hostport"
+	"go.uber.org/yarpc/peer/roundrobin"
+	"go.uber.org/yarpc/yarpctest"
+)
+
+func choose(t *testing.T, c *Chooser) string {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	p, onFinish, err := c.Choose(ctx, &transport.Request{})
+	require.NoError(t, err)
+	onFinish(nil)
+	return p.Identifier()
+}
+
+func update(t *testing.T, l peer.List, add, remove string) {
+	var updates peer.ListUpdates
+	if add != "" {
+		updates.Additions = []peer.Identifier{hostport.PeerIdentifier(add)}
+	}
+	if remove != "" {
+		updates.Removals = []peer.Identifier{hostport.PeerIdentifier(remove)}
+	}
+	require.NoError(t, l.Update(updates))
+}
+
+func TestFallback(t *testing.T) {
+	primary := roundrobin.New(yarpctest.NewFakeTransport())
+	bootstrap := roundrobin.New(yarpctest.NewFakeTransport())
+	c := New(primary, bootstrap, Timeout(10*time.Millisecond))
+	require.NoError(t, c.Start())
+	defer c.Stop()
+	assert.True(t, c.IsRunning())
+
+	update(t, bootstrap, "bootstrap:1", "")
+	assert.Equal(t, "bootstrap:1", choose(t, c), "must fall back while the primary list is empty")
+
+	update(t, primary, "primary:1", "")
+	assert.Equal(t, "primary:1", choose(t, c), "must prefer the primary list")
+
+	update(t, primary, "", "primary:1")
+	assert.Equal(t, "bootstrap:1", choose(t, c), "must fall back when the primary list loses its peers")
+}
+
+func TestFallbackChain(t *testing.T) {
+	primary := roundrobin.New(yarpctest.NewFakeTransport())
+	secondary := roundrobin.New(yarpctest.NewFakeTransport())
+	bootstrap := roundrobin.New(yarpctest.NewFakeTransport())
+	c := New(primary, New(secondary, bootstrap, Timeout(10*time.Millisecond)), Timeout(10*time.Millisecond))
+	require.NoError(t, c.Start())
+	defer c.Stop()
+
+	update(t, bootstrap, "bootstrap:1", "")
+	assert.Equal(t, "bootstrap:1", choose(t, c))
+
+	update(t, secondary, "secondary:1", "")
+	assert.Equal(t, "secondary:1", choose(t, c))
+}
+
+func TestFallbackDeadline(t *testing.T) {
+	primary := roundrobin.New(yarpctest.NewFakeTransport())
+	bootstrap := roundrobin.New(yarpctest.NewFakeTransport())
+	c := New(primary, bootstrap, Timeout(time.Second))
+	require.NoError(t, c.Start())
+	defer c.Stop()
+
+	update(t, bootstrap, "bootstrap:1", "")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, _, err := c.Choose(ctx, &transport.Request{})
+	assert.Error(t, err, "must not fall back once the request's deadline has passed")
+}
+
+func TestFallbackIntrospect(t *testing.T) {
+	primary := roundrobin.New(yarpctest.NewFakeTransport())
+	bootstrap := roundrobin.New(yarpctest.NewFakeTransport())
+	c := New(primary, bootstrap)
+	require.NoError(t, c.Start())
+	defer c.Stop()
+
+	update(t, primary, "primary:1", "")
+	update(t, bootstrap, "bootstrap:1", "")
+
+	status := c.Introspect()
+	assert.Equal(t, "fallback", status.Name)
+	assert.Equal(t, "Running (timeout: 100ms)", status.State)
+	if assert.Len(t, status.Peers, 2) {
+		assert.Equal(t, "primary, Available, 0 pending request(s)", status.Peers[0].State)
+		assert.Equal(t, "fallback, Available, 0 pending request(s)", status.Peers[1].State)
+	}
+}
@@ -0,0 +1,36 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package fallback provides a peer chooser that consults a secondary chooser
+// when its primary chooser cannot produce a peer in time, for example while
+// the primary list is empty because service discovery is unavailable, or
+// while every one of its peers is ejected.
+//
+// 	primary := outlier.New(roundrobin.New(transport))
+// 	bootstrap := roundrobin.New(transport)
+// 	chooser := fallback.New(primary, bootstrap, fallback.Timeout(50*time.Millisecond))
+//
+// The primary chooser is given at most the timeout to choose a peer. If it
+// fails within that time, the fallback chooser is given the rest of the
+// request's deadline. Longer chains are built by nesting choosers, with
+// the last resort innermost.
+//
+// 	chooser := fallback.New(primary, fallback.New(secondary, bootstrap))
+package fallback
@@ -0,0 +1,74 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package file
+
+import (
+	"time"
+
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/yarpcconfig"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// Configuration describes how to build a file peer list updater.
+type Configuration struct {
+	Path     string        `config:"path,interpolate"`
+	Interval time.Duration `config:"interval"`
+}
+
+// Spec returns a configuration specification for the file peer list updater,
+// making it possible to read peers from a hosts file with transports that
+// use outbound peer list configuration (like HTTP).
+//
+//  cfg := yarpcconfig.New()
+//  cfg.MustRegisterPeerListUpdater(file.Spec())
+//
+// This enables the file peer list updater:
+//
+//  outbounds:
+//    otherservice:
+//      unary:
+//        http:
+//          url: http://host:port/rpc
+//          weighted-round-robin:
+//            file:
+//              path: /etc/myservice/backends.yaml
+//              interval: 10s
+func Spec() yarpcconfig.PeerListUpdaterSpec {
+	return yarpcconfig.PeerListUpdaterSpec{
+		Name: "file",
+		BuildPeerListUpdater: func(cfg Configuration, k *yarpcconfig.Kit) (peer.Binder, error) {
+			if cfg.Path == "" {
+				return nil, yarpcerrors.InvalidArgumentErrorf("Path is required.")
+			}
+
+			var opts []Option
+			if cfg.Interval < 0 {
+				return nil, yarpcerrors.InvalidArgumentErrorf(
+					"Interval must not be negative. Got: %v.", cfg.Interval)
+			}
+			if cfg.Interval > 0 {
+				opts = append(opts, Interval(cfg.Interval))
+			}
+			return Binder(cfg.Path, opts...), nil
+		},
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package file provides a peer list updater that reads peers from a hosts
+// file and applies changes to the file while running, so that backends can
+// be changed by configuration management without restarting the process.
+//
+// The file holds a YAML or JSON list of peers. Each peer is either a
+// host:port address or an object with an address and an optional weight:
+//
+// 	- 10.0.0.1:8080
+// 	- address: 10.0.0.2:8080
+// 	  weight: 3
+//
+// Peers with a weight are added as weightedroundrobin.WeightedIdentifiers, so
+// weighted peer lists honor them, and changing the weight of a peer removes
+// and adds it again.
+//
+// 	list := weightedroundrobin.New(transport)
+// 	chooser := peer.Bind(list, file.Binder("/etc/myservice/backends.yaml"))
+//
+// The file is read when the updater starts, which fails if the file is
+// missing or invalid, and is read again at an interval. Later failures are
+// logged and keep the last valid peers. Since the file is compared by
+// content, it may be replaced by a rename, as configuration management tools
+// usually do.
+package file
@@ -0,0 +1,68 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package file
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+)
+
+// entry is a peer in a hosts file.
+type entry struct {
+	Address string `yaml:"address"`
+	Weight  int    `yaml:"weight"`
+}
+
+// UnmarshalYAML decodes a peer from either a host:port address or an object
+// with an address and a weight.
+func (e *entry) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if err := unmarshal(&e.Address); err == nil {
+		return nil
+	}
+
+	type plain entry
+	return unmarshal((*plain)(e))
+}
+
+// parseHosts returns the weights of the peers in a hosts file by address.
+// Peers without a weight have a weight of zero.
+func parseHosts(b []byte) (map[string]int, error) {
+	var entries []entry
+	if err := yaml.Unmarshal(b, &entries); err != nil {
+		return nil, err
+	}
+
+	hosts := make(map[string]int, len(entries))
+	for i, e := range entries {
+		if e.Address == "" {
+			return nil, fmt.Errorf("peer %d has no address", i)
+		}
+		if e.Weight < 0 {
+			return nil, fmt.Errorf("peer %q has a negative weight: %d", e.Address, e.Weight)
+		}
+		if _, ok := hosts[e.Address]; ok {
+			return nil, fmt.Errorf("peer %q is listed more than once", e.Address)
+		}
+		hosts[e.Address] = e.Weight
+	}
+	return hosts, nil
+}
@@ -0,0 +1,182 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package file
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"time"
+
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/peer/hostport"
+	"go.uber.org/yarpc/peer/weightedroundrobin"
+	"go.uber.org/yarpc/pkg/lifecycle"
+	"go.uber.org/zap"
+)
+
+var _ transport.Lifecycle = (*Updater)(nil)
+
+// Updater is a peer list updater that keeps a peer list in sync with the
+// peers in a hosts file.
+type Updater struct {
+	list peer.List
+	path string
+	opts options
+	once *lifecycle.Once
+
+	stop chan struct{}
+	done chan struct{}
+
+	// The following are only accessed by Start and then by the watch
+	// goroutine.
+
+	// contents holds the contents of the file last applied.
+	contents []byte
+	// peers holds the weights of the peers sent to the list, by address.
+	peers map[string]int
+}
+
+// New creates an updater that sends the peers in the hosts file at the given
+// path to the peer list once started.
+func New(list peer.List, path string, opts ...Option) *Updater {
+	return &Updater{
+		list:  list,
+		path:  path,
+		opts:  newOptions(opts),
+		once:  lifecycle.NewOnce(),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+		peers: make(map[string]int),
+	}
+}
+
+// Binder returns a peer.Binder that binds peer lists to an updater for the
+// hosts file at the given path.
+func Binder(path string, opts ...Option) peer.Binder {
+	return func(list peer.List) transport.Lifecycle {
+		return New(list, path, opts...)
+	}
+}
+
+// Start reads the hosts file and starts watching it for changes. Start fails
+// if the file cannot be read or is invalid.
+func (u *Updater) Start() error {
+	return u.once.Start(func() error {
+		if err := u.reload(); err != nil {
+			return err
+		}
+		go u.watch()
+		return nil
+	})
+}
+
+// Stop stops watching the hosts file. Peers already sent to the list remain.
+func (u *Updater) Stop() error {
+	return u.once.Stop(func() error {
+		close(u.stop)
+		<-u.done
+		return nil
+	})
+}
+
+// IsRunning returns whether the updater is running.
+func (u *Updater) IsRunning() bool {
+	return u.once.IsRunning()
+}
+
+// watch reloads the hosts file at the interval until the updater stops.
+func (u *Updater) watch() {
+	defer close(u.done)
+
+	ticker := time.NewTicker(u.opts.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-u.stop:
+			return
+		case <-ticker.C:
+			if err := u.reload(); err != nil {
+				u.opts.logger.Warn("failed to reload hosts file, keeping the last valid peers",
+					zap.String("path", u.path), zap.Error(err))
+			}
+		}
+	}
+}
+
+// reload reads the hosts file and applies it to the list if it changed.
+func (u *Updater) reload() error {
+	contents, err := ioutil.ReadFile(u.path)
+	if err != nil {
+		return err
+	}
+	if u.contents != nil && bytes.Equal(contents, u.contents) {
+		return nil
+	}
+
+	hosts, err := parseHosts(contents)
+	if err != nil {
+		return fmt.Errorf("invalid hosts file %q: %v", u.path, err)
+	}
+	u.contents = contents
+	u.sync(hosts)
+	return nil
+}
+
+// sync sends the peers that were added, removed, or reweighted since the
+// last sync to the list.
+func (u *Updater) sync(hosts map[string]int) {
+	var removals, additions []string
+	for addr, weight := range u.peers {
+		if w, ok := hosts[addr]; !ok || w != weight {
+			removals = append(removals, addr)
+		}
+	}
+	for addr, weight := range hosts {
+		if w, ok := u.peers[addr]; !ok || w != weight {
+			additions = append(additions, addr)
+		}
+	}
+	if len(removals) == 0 && len(additions) == 0 {
+		return
+	}
+	sort.Strings(removals)
+	sort.Strings(additions)
+
+	var updates peer.ListUpdates
+	for _, addr := range removals {
+		updates.Removals = append(updates.Removals, hostport.PeerIdentifier(addr))
+	}
+	for _, addr := range additions {
+		var pid peer.Identifier = hostport.PeerIdentifier(addr)
+		if weight := hosts[addr]; weight > 0 {
+			pid = weightedroundrobin.Weighted(pid, weight)
+		}
+		updates.Additions = append(updates.Additions, pid)
+	}
+	if err := u.list.Update(updates); err != nil {
+		u.opts.logger.Warn("failed to update peer list",
+			zap.String("path", u.path), zap.Error(err))
+	}
+	u.peers = hosts
+}
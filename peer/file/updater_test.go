@@ -0,0 +1,153 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package file
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/peer/peertest"
+	"go.uber.org/yarpc/internal/testtime"
+	"go.uber.org/yarpc/peer/weightedroundrobin"
+)
+
+// weights lists the peers in the list with their weights.
+func weights(l *peertest.FakeList) string {
+	var ids []string
+	for _, id := range l.IDs() {
+		var weight int
+		if w, ok := l.Peer(id).(weightedroundrobin.WeightedIdentifier); ok {
+			weight = w.Weight()
+		}
+		ids = append(ids, fmt.Sprintf("%s=%d", id, weight))
+	}
+	return fmt.Sprint(ids)
+}
+
+// writeFile replaces the file by renaming a new file over it, like
+// configuration management tools do.
+func writeFile(t *testing.T, path, contents string) {
+	tmp := path + ".tmp"
+	require.NoError(t, ioutil.WriteFile(tmp, []byte(contents), 0644))
+	require.NoError(t, os.Rename(tmp, path))
+}
+
+func TestUpdater(t *testing.T) {
+	dir, err := ioutil.TempDir("", "yarpc-peer-file")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "hosts.yaml")
+	writeFile(t, path, `
+- 10.0.0.1:8080
+- address: 10.0.0.2:8080
+  weight: 3
+`)
+
+	list := peertest.NewFakeList()
+	u := New(list, path, Interval(time.Millisecond))
+	require.NoError(t, u.Start())
+	defer u.Stop()
+	assert.Equal(t, "[10.0.0.1:8080=0 10.0.0.2:8080=3]", weights(list),
+		"peers must be added when the updater starts")
+
+	writeFile(t, path, `[{"address": "10.0.0.2:8080", "weight": 5}, {"address": "10.0.0.3:8080"}]`)
+	testtime.WaitFor(t, "changes to the file must be applied", func() bool {
+		return weights(list) == "[10.0.0.2:8080=5 10.0.0.3:8080=0]"
+	})
+
+	writeFile(t, path, `- weight: 1`)
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, "[10.0.0.2:8080=5 10.0.0.3:8080=0]", weights(list),
+		"invalid files must keep the last valid peers")
+
+	writeFile(t, path, `- 10.0.0.4:8080`)
+	testtime.WaitFor(t, "valid files must be applied after an invalid one", func() bool {
+		return weights(list) == "[10.0.0.4:8080=0]"
+	})
+
+	require.NoError(t, u.Stop())
+	assert.False(t, u.IsRunning())
+}
+
+func TestUpdaterMissingFile(t *testing.T) {
+	u := New(peertest.NewFakeList(), "/does/not/exist.yaml")
+	assert.Error(t, u.Start(), "must not start without a hosts file")
+}
+
+func TestParseHosts(t *testing.T) {
+	tests := []struct {
+		msg     string
+		give    string
+		want    map[string]int
+		wantErr string
+	}{
+		{
+			msg:  "empty",
+			give: "",
+			want: map[string]int{},
+		},
+		{
+			msg:  "addresses and objects",
+			give: "- a:1\n- {address: b:1, weight: 2}",
+			want: map[string]int{"a:1": 0, "b:1": 2},
+		},
+		{
+			msg:  "JSON",
+			give: `[{"address": "a:1", "weight": 2}, "b:1"]`,
+			want: map[string]int{"a:1": 2, "b:1": 0},
+		},
+		{
+			msg:     "no address",
+			give:    "- weight: 2",
+			wantErr: "peer 0 has no address",
+		},
+		{
+			msg:     "negative weight",
+			give:    "- {address: a:1, weight: -1}",
+			wantErr: `peer "a:1" has a negative weight: -1`,
+		},
+		{
+			msg:     "duplicate",
+			give:    "- a:1\n- a:1",
+			wantErr: `peer "a:1" is listed more than once`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.msg, func(t *testing.T) {
+			got, err := parseHosts([]byte(tt.give))
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Equal(t, tt.wantErr, err.Error())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
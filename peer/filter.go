@@ -0,0 +1,136 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package peer
+
+import (
+	"context"
+
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+const _defaultMaxChoices = 8
+
+// FilterOption customizes the behavior of Filter.
+type FilterOption func(*filterOptions)
+
+type filterOptions struct {
+	maxChoices int
+}
+
+// MaxChoices is how many peers Filter asks the chooser for before it gives
+// up on finding an acceptable one. Choosers that do not cycle through their
+// peers, like random choosers, may need more choices to find an acceptable
+// peer when few peers are acceptable.
+//
+// Defaults to 8.
+func MaxChoices(n int) FilterOption {
+	return func(o *filterOptions) {
+		o.maxChoices = n
+	}
+}
+
+// Filter returns ChooserMiddleware that only sends requests to the peers
+// the given function accepts for them. Peers it rejects are returned to the
+// chooser, and another peer is chosen in their place.
+//
+// It expresses per-request routing policies without a custom chooser, like
+// keeping the requests of a caller out of a zone:
+//
+// 	zones := map[string]string{"10.0.0.1:80": "us-east", "10.1.0.1:80": "us-west"}
+// 	filter := peer.Filter(func(ctx context.Context, req *transport.Request, p apipeer.Peer) bool {
+// 		return req.Caller != "batch" || zones[p.Identifier()] != "us-east"
+// 	})
+// 	chooser := apipeer.ApplyChooserMiddleware(list, filter)
+//
+// The request fails with an unavailable error if no acceptable peer is
+// chosen within MaxChoices.
+func Filter(accept func(context.Context, *transport.Request, peer.Peer) bool, opts ...FilterOption) peer.ChooserMiddleware {
+	o := filterOptions{maxChoices: _defaultMaxChoices}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.maxChoices < 1 {
+		o.maxChoices = 1
+	}
+
+	return peer.ChooserMiddlewareFunc(func(ctx context.Context, req *transport.Request, next peer.Chooser) (peer.Peer, func(error), error) {
+		for i := 0; i < o.maxChoices; i++ {
+			p, onFinish, err := next.Choose(ctx, req)
+			if err != nil {
+				return nil, nil, err
+			}
+			if accept(ctx, req, p) {
+				return p, onFinish, nil
+			}
+			// The rejected peer was never sent the request.
+			onFinish(nil)
+		}
+		return nil, nil, yarpcerrors.Newf(yarpcerrors.CodeUnavailable,
+			"no acceptable peer for request to procedure %q of service %q after %d choices",
+			req.Procedure, req.Service, o.maxChoices)
+	})
+}
+
+// Pin returns ChooserMiddleware that sends requests to the peer the given
+// function picks for them, bypassing the chooser, like a host named in a
+// header to debug a single instance. Requests for which it returns nil are
+// sent to the peer the chooser picks.
+//
+// The pinned peer is retained from the transport for the duration of the
+// request, so it does not need to be one of the peers of the chooser.
+//
+// 	pin := peer.Pin(httpTransport, func(ctx context.Context, req *transport.Request) apipeer.Identifier {
+// 		if host, ok := req.Headers.Get("x-pin-host"); ok {
+// 			return hostport.PeerIdentifier(host)
+// 		}
+// 		return nil
+// 	})
+func Pin(t peer.Transport, pinned func(context.Context, *transport.Request) peer.Identifier) peer.ChooserMiddleware {
+	return peer.ChooserMiddlewareFunc(func(ctx context.Context, req *transport.Request, next peer.Chooser) (peer.Peer, func(error), error) {
+		pid := pinned(ctx, req)
+		if pid == nil {
+			return next.Choose(ctx, req)
+		}
+
+		// Every request subscribes separately, so that releasing the peer
+		// after one request does not release it from under another.
+		sub := &pinSubscriber{}
+		p, err := t.RetainPeer(pid, sub)
+		if err != nil {
+			return nil, nil, err
+		}
+		p.StartRequest()
+		return p, func(error) {
+			p.EndRequest()
+			t.ReleasePeer(pid, sub)
+		}, nil
+	})
+}
+
+// pinSubscriber subscribes to a pinned peer for a single request.
+type pinSubscriber struct {
+	// The field keeps pointers to different subscribers distinct.
+	_ byte
+}
+
+func (*pinSubscriber) NotifyStatusChanged(peer.Identifier) {}
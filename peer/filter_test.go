@@ -0,0 +1,142 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package peer_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	. "go.uber.org/yarpc/peer"
+	"go.uber.org/yarpc/peer/hostport"
+	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/yarpc/yarpctest"
+)
+
+// cyclingChooser chooses its peers in turn and counts the requests that
+// have yet to finish.
+type cyclingChooser struct {
+	peer.Chooser
+
+	peers   []peer.Peer
+	next    int
+	pending int
+}
+
+func newCyclingChooser(ids ...string) *cyclingChooser {
+	c := &cyclingChooser{}
+	trans := yarpctest.NewFakeTransport()
+	for _, id := range ids {
+		p, _ := trans.RetainPeer(hostport.PeerIdentifier(id), nil)
+		c.peers = append(c.peers, p)
+	}
+	return c
+}
+
+func (c *cyclingChooser) Choose(context.Context, *transport.Request) (peer.Peer, func(error), error) {
+	p := c.peers[c.next%len(c.peers)]
+	c.next++
+	c.pending++
+	return p, func(error) { c.pending-- }, nil
+}
+
+func TestFilter(t *testing.T) {
+	chooser := newCyclingChooser("a:1", "b:1", "c:1")
+	filter := Filter(func(_ context.Context, req *transport.Request, p peer.Peer) bool {
+		return req.Caller != "batch" || p.Identifier() == "c:1"
+	})
+
+	p, onFinish, err := filter.Choose(context.Background(), &transport.Request{Caller: "batch"}, chooser)
+	require.NoError(t, err)
+	assert.Equal(t, "c:1", p.Identifier())
+	assert.Equal(t, 1, chooser.pending, "rejected peers must be returned to the chooser")
+	onFinish(nil)
+
+	p, onFinish, err = filter.Choose(context.Background(), &transport.Request{Caller: "web"}, chooser)
+	require.NoError(t, err)
+	assert.Equal(t, "a:1", p.Identifier(), "accepted peers must be used as chosen")
+	onFinish(nil)
+	assert.Equal(t, 0, chooser.pending)
+}
+
+func TestFilterMaxChoices(t *testing.T) {
+	chooser := newCyclingChooser("a:1", "b:1", "c:1")
+	filter := Filter(func(_ context.Context, _ *transport.Request, p peer.Peer) bool {
+		return p.Identifier() == "c:1"
+	}, MaxChoices(2))
+
+	_, _, err := filter.Choose(context.Background(), &transport.Request{Service: "users", Procedure: "get"}, chooser)
+	require.Error(t, err)
+	assert.Equal(t, yarpcerrors.CodeUnavailable, yarpcerrors.FromError(err).Code())
+	assert.Contains(t, err.Error(), `no acceptable peer for request to procedure "get" of service "users" after 2 choices`)
+	assert.Equal(t, 2, chooser.next)
+	assert.Equal(t, 0, chooser.pending)
+}
+
+// countingTransport counts the peers retained from it.
+type countingTransport struct {
+	*yarpctest.FakeTransport
+
+	retained map[peer.Subscriber]string
+}
+
+func (t *countingTransport) RetainPeer(id peer.Identifier, sub peer.Subscriber) (peer.Peer, error) {
+	t.retained[sub] = id.Identifier()
+	return t.FakeTransport.RetainPeer(id, sub)
+}
+
+func (t *countingTransport) ReleasePeer(id peer.Identifier, sub peer.Subscriber) error {
+	delete(t.retained, sub)
+	return t.FakeTransport.ReleasePeer(id, sub)
+}
+
+func TestPin(t *testing.T) {
+	trans := &countingTransport{FakeTransport: yarpctest.NewFakeTransport(), retained: make(map[peer.Subscriber]string)}
+	chooser := newCyclingChooser("a:1")
+	pin := Pin(trans, func(_ context.Context, req *transport.Request) peer.Identifier {
+		if host, ok := req.Headers.Get("pin"); ok {
+			return hostport.PeerIdentifier(host)
+		}
+		return nil
+	})
+
+	req := &transport.Request{Headers: transport.NewHeaders().With("pin", "z:1")}
+	p1, onFinish1, err := pin.Choose(context.Background(), req, chooser)
+	require.NoError(t, err)
+	p2, onFinish2, err := pin.Choose(context.Background(), req, chooser)
+	require.NoError(t, err)
+	assert.Equal(t, "z:1", p1.Identifier())
+	assert.Equal(t, "z:1", p2.Identifier())
+	assert.Len(t, trans.retained, 2, "every request must retain the pinned peer separately")
+	assert.Equal(t, 0, chooser.next, "pinned requests must bypass the chooser")
+
+	onFinish1(nil)
+	onFinish2(nil)
+	assert.Empty(t, trans.retained, "pinned peers must be released when requests finish")
+
+	p, onFinish, err := pin.Choose(context.Background(), &transport.Request{}, chooser)
+	require.NoError(t, err)
+	assert.Equal(t, "a:1", p.Identifier())
+	onFinish(nil)
+}
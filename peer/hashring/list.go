@@ -0,0 +1,83 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package hashring
+
+import (
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/peer/peerlist"
+)
+
+type listConfig struct {
+	capacity int
+	replicas int
+}
+
+var defaultListConfig = listConfig{
+	capacity: 10,
+	replicas: 100,
+}
+
+// ListOption customizes the behavior of a hash ring peer list.
+type ListOption func(*listConfig)
+
+// Capacity specifies the default capacity of the underlying
+// data structures for this list.
+//
+// Defaults to 10.
+func Capacity(capacity int) ListOption {
+	return func(c *listConfig) {
+		c.capacity = capacity
+	}
+}
+
+// Replicas specifies the number of points each peer occupies on the hash
+// ring. More replicas spread keys more evenly across peers at the cost of a
+// larger ring.
+//
+// Defaults to 100.
+func Replicas(replicas int) ListOption {
+	return func(c *listConfig) {
+		c.replicas = replicas
+	}
+}
+
+// New creates a new consistent hash ring peer list.
+func New(transport peer.Transport, opts ...ListOption) *List {
+	cfg := defaultListConfig
+	for _, o := range opts {
+		o(&cfg)
+	}
+
+	return &List{
+		List: peerlist.New(
+			"hashring",
+			transport,
+			newHashRing(cfg.replicas),
+			peerlist.Capacity(cfg.capacity),
+		),
+	}
+}
+
+// List is a PeerList which chooses peers by consistent hashing of the
+// request's shard key.
+type List struct {
+	*peerlist.List
+}
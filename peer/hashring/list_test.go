@@ -0,0 +1,163 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package hashring
+
+import (
+	"context"
+	"math"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/peerlisttest"
+	"go.uber.org/yarpc/peer/hostport"
+	"go.uber.org/yarpc/yarpctest"
+)
+
+func newStartedList(t *testing.T, ids ...string) *List {
+	pl := New(yarpctest.NewFakeTransport())
+	require.NoError(t, pl.Start())
+	require.NoError(t, pl.Update(peer.ListUpdates{Additions: identifiers(ids...)}))
+	return pl
+}
+
+func identifiers(ids ...string) []peer.Identifier {
+	pids := make([]peer.Identifier, len(ids))
+	for i, id := range ids {
+		pids[i] = hostport.PeerIdentifier(id)
+	}
+	return pids
+}
+
+func TestHashRingStableRouting(t *testing.T) {
+	const numKeys = 1000
+
+	pl := newStartedList(t, "1.1.1.1:1", "2.2.2.2:2", "3.3.3.3:3", "4.4.4.4:4")
+	defer pl.Stop()
+
+	before := peerlisttest.Route(t, pl, numKeys)
+	assert.Equal(t, before, peerlisttest.Route(t, pl, numKeys), "routing must be stable")
+
+	counts := make(map[string]int)
+	for _, id := range before {
+		counts[id]++
+	}
+	assert.Len(t, counts, 4, "every peer must receive keys")
+
+	t.Run("removing a peer moves only its keys", func(t *testing.T) {
+		require.NoError(t, pl.Update(peer.ListUpdates{Removals: identifiers("2.2.2.2:2")}))
+		after := peerlisttest.Route(t, pl, numKeys)
+		for key, id := range before {
+			if id == "2.2.2.2:2" {
+				assert.NotEqual(t, "2.2.2.2:2", after[key])
+			} else {
+				assert.Equal(t, id, after[key], "key %q must not move", key)
+			}
+		}
+
+		require.NoError(t, pl.Update(peer.ListUpdates{Additions: identifiers("2.2.2.2:2")}))
+		assert.Equal(t, before, peerlisttest.Route(t, pl, numKeys), "keys must return to the peer")
+	})
+
+	t.Run("adding a peer moves keys only to it", func(t *testing.T) {
+		require.NoError(t, pl.Update(peer.ListUpdates{Additions: identifiers("5.5.5.5:5")}))
+		after := peerlisttest.Route(t, pl, numKeys)
+
+		var moved int
+		for key, id := range before {
+			if after[key] != id {
+				assert.Equal(t, "5.5.5.5:5", after[key])
+				moved++
+			}
+		}
+		assert.True(t, moved > 0, "new peer must receive keys")
+	})
+}
+
+func TestHashRingIndependentOfInsertionOrder(t *testing.T) {
+	a := newStartedList(t, "1.1.1.1:1", "2.2.2.2:2", "3.3.3.3:3")
+	defer a.Stop()
+	b := newStartedList(t, "3.3.3.3:3", "1.1.1.1:1", "2.2.2.2:2")
+	defer b.Stop()
+
+	assert.Equal(t, peerlisttest.Route(t, a, 100), peerlisttest.Route(t, b, 100))
+}
+
+func TestHashRingWithoutShardKey(t *testing.T) {
+	pl := newStartedList(t, "1.1.1.1:1", "2.2.2.2:2", "3.3.3.3:3")
+	defer pl.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	counts := make(map[string]int)
+	for i := 0; i < 30; i++ {
+		p, onFinish, err := pl.Choose(ctx, &transport.Request{})
+		require.NoError(t, err)
+		onFinish(nil)
+		counts[p.Identifier()]++
+	}
+	assert.Equal(t, map[string]int{
+		"1.1.1.1:1": 10,
+		"2.2.2.2:2": 10,
+		"3.3.3.3:3": 10,
+	}, counts)
+}
+
+func TestHashRingEmpty(t *testing.T) {
+	pl := newStartedList(t)
+	defer pl.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, _, err := pl.Choose(ctx, &transport.Request{ShardKey: "foo"})
+	assert.Error(t, err)
+}
+
+func TestHashRingSorted(t *testing.T) {
+	r := newHashRing(10)
+	for _, id := range []string{"3.3.3.3:3", "1.1.1.1:1", "2.2.2.2:2"} {
+		r.Add(hostport.NewPeer(hostport.PeerIdentifier(id), yarpctest.NewFakeTransport()))
+		assert.True(t, sort.SliceIsSorted(r.points, func(i, j int) bool {
+			return r.points[i].before(r.points[j])
+		}), "points must remain sorted after adding %v", id)
+	}
+	assert.Len(t, r.points, 30)
+}
+
+func TestHashRingCounterOverflow(t *testing.T) {
+	r := newHashRing(1)
+	for _, id := range []string{"1.1.1.1:1", "2.2.2.2:2", "3.3.3.3:3"} {
+		r.Add(hostport.NewPeer(hostport.PeerIdentifier(id), yarpctest.NewFakeTransport()))
+	}
+
+	r.next.Store(math.MaxUint32 - 1)
+	for i := 0; i < 4; i++ {
+		assert.NotPanics(t, func() {
+			assert.NotNil(t, r.Choose(context.Background(), &transport.Request{}))
+		}, "choosing must survive the counter wrapping around")
+	}
+}
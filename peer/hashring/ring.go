@@ -0,0 +1,175 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package hashring
+
+import (
+	"context"
+	"hash/crc32"
+	"sort"
+	"strconv"
+
+	"go.uber.org/atomic"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+)
+
+type subscriber struct {
+	peer peer.StatusPeer
+}
+
+func (s *subscriber) NotifyStatusChanged(pid peer.Identifier) {}
+
+// point is a position on the hash ring owned by a peer.
+type point struct {
+	hash uint32
+	sub  *subscriber
+}
+
+// before orders points by hash. Ties are broken by identifier so that the
+// ring does not depend on the order in which peers were added.
+func (p point) before(o point) bool {
+	if p.hash != o.hash {
+		return p.hash < o.hash
+	}
+	return p.sub.peer.Identifier() < o.sub.peer.Identifier()
+}
+
+// hashRing places every available peer on a ring at several points and
+// chooses the peer owning the first point at or after the hash of a
+// request's shard key.
+//
+// hashRing is NOT thread-safe. The peer list calls Add and Remove with its
+// write lock held and Choose with its read lock held, so Choose must not
+// modify the ring.
+type hashRing struct {
+	replicas int
+
+	// points is sorted by hash.
+	points []point
+	// subs holds each peer once, in the order they were added, for requests
+	// without a shard key.
+	subs []*subscriber
+	next atomic.Uint32
+}
+
+func newHashRing(replicas int) *hashRing {
+	if replicas < 1 {
+		replicas = 1
+	}
+	return &hashRing{replicas: replicas}
+}
+
+func hash(key string) uint32 {
+	return crc32.ChecksumIEEE([]byte(key))
+}
+
+// Add places the peer on the ring.
+func (r *hashRing) Add(p peer.StatusPeer) peer.Subscriber {
+	sub := &subscriber{peer: p}
+	id := p.Identifier()
+	added := make([]point, r.replicas)
+	for i := range added {
+		added[i] = point{hash: hash(strconv.Itoa(i) + "-" + id), sub: sub}
+	}
+	sort.Slice(added, func(i, j int) bool { return added[i].before(added[j]) })
+	r.points = merge(r.points, added)
+	r.subs = append(r.subs, sub)
+	return sub
+}
+
+// merge returns the union of two sorted lists of points, in order. Merging
+// the points of a new peer into the ring is linear in the size of the ring,
+// rather than sorting the whole ring again.
+func merge(a, b []point) []point {
+	merged := make([]point, 0, len(a)+len(b))
+	for len(a) > 0 && len(b) > 0 {
+		if b[0].before(a[0]) {
+			merged, b = append(merged, b[0]), b[1:]
+		} else {
+			merged, a = append(merged, a[0]), a[1:]
+		}
+	}
+	merged = append(merged, a...)
+	return append(merged, b...)
+}
+
+// Remove takes the peer off the ring. Its keys move to the peers owning the
+// following points.
+func (r *hashRing) Remove(p peer.StatusPeer, s peer.Subscriber) {
+	sub, ok := s.(*subscriber)
+	if !ok {
+		// Don't panic.
+		return
+	}
+
+	points := r.points[:0]
+	for _, pt := range r.points {
+		if pt.sub != sub {
+			points = append(points, pt)
+		}
+	}
+	r.points = points
+
+	for i, candidate := range r.subs {
+		if candidate == sub {
+			r.subs = append(r.subs[:i], r.subs[i+1:]...)
+			break
+		}
+	}
+}
+
+// Choose returns the peer owning the request's shard key, or the next peer
+// in round-robin order if the request has no shard key. Returns nil if the
+// ring is empty.
+func (r *hashRing) Choose(_ context.Context, req *transport.Request) peer.StatusPeer {
+	if len(r.subs) == 0 {
+		return nil
+	}
+
+	if req == nil || req.ShardKey == "" {
+		// Take the modulo before converting to int, which may be 32 bits
+		// wide and would turn large counters negative.
+		i := (r.next.Inc() - 1) % uint32(len(r.subs))
+		return r.subs[i].peer
+	}
+
+	h := hash(req.ShardKey)
+	i := sort.Search(len(r.points), func(i int) bool {
+		return r.points[i].hash >= h
+	})
+	if i == len(r.points) {
+		// Wrap around to the start of the ring.
+		i = 0
+	}
+	return r.points[i].sub.peer
+}
+
+func (r *hashRing) Start() error {
+	return nil
+}
+
+func (r *hashRing) Stop() error {
+	return nil
+}
+
+func (r *hashRing) IsRunning() bool {
+	return true
+}
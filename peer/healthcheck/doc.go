@@ -0,0 +1,50 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package healthcheck provides active health checking for peers that every
+// peer list honors.
+//
+// Every retained peer is probed at an interval. After a number of
+// consecutive failed probes, the peer reports itself as unavailable through
+// its peer.Status, so peer lists stop choosing it before real requests fail.
+// After a number of consecutive successful probes, it becomes available
+// again. Peers are considered healthy until probes say otherwise.
+//
+// Health checks are installed by wrapping the transport that peer lists
+// retain peers from.
+//
+// 	prober := healthcheck.UnaryProber(
+// 		func(c peer.Chooser) transport.UnaryOutbound { return httpTransport.NewOutbound(c) },
+// 		&transport.Request{
+// 			Caller:    "myservice",
+// 			Service:   "otherservice",
+// 			Encoding:  raw.Encoding,
+// 			Procedure: "health",
+// 		},
+// 	)
+// 	checked := healthcheck.NewTransport(httpTransport, prober,
+// 		healthcheck.Interval(5*time.Second),
+// 	)
+// 	list := roundrobin.New(checked)
+// 	outbound := httpTransport.NewOutbound(list)
+//
+// Probes other than requests to a procedure, such as a transport-level ping,
+// may be provided by implementing Prober.
+package healthcheck
@@ -0,0 +1,96 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package healthcheck
+
+import (
+	"time"
+
+	"go.uber.org/yarpc/internal/clock"
+)
+
+// Option customizes the behavior of health checks.
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(opts *options) { f(opts) }
+
+type options struct {
+	interval           time.Duration
+	timeout            time.Duration
+	unhealthyThreshold int
+	healthyThreshold   int
+	clock              clock.Clock
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		interval:           10 * time.Second,
+		timeout:            time.Second,
+		unhealthyThreshold: 2,
+		healthyThreshold:   1,
+		clock:              clock.NewReal(),
+	}
+	for _, opt := range opts {
+		opt.apply(&o)
+	}
+	return o
+}
+
+// Interval specifies how often each peer is probed.
+//
+// Defaults to 10 seconds.
+func Interval(d time.Duration) Option {
+	return optionFunc(func(o *options) {
+		o.interval = d
+	})
+}
+
+// Timeout specifies how long a probe may take before it fails.
+//
+// Defaults to 1 second.
+func Timeout(d time.Duration) Option {
+	return optionFunc(func(o *options) {
+		o.timeout = d
+	})
+}
+
+// UnhealthyThreshold specifies the number of consecutive failed probes after
+// which a healthy peer becomes unavailable.
+//
+// Defaults to 2.
+func UnhealthyThreshold(n int) Option {
+	return optionFunc(func(o *options) {
+		o.unhealthyThreshold = n
+	})
+}
+
+// HealthyThreshold specifies the number of consecutive successful probes
+// after which an unhealthy peer becomes available again.
+//
+// Defaults to 1.
+func HealthyThreshold(n int) Option {
+	return optionFunc(func(o *options) {
+		o.healthyThreshold = n
+	})
+}
@@ -0,0 +1,160 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package healthcheck
+
+import (
+	"context"
+	"sync"
+
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/internal/clock"
+)
+
+var _ peer.Peer = (*healthPeer)(nil)
+
+// healthPeer wraps a peer with a health check. Its status is the status of
+// the wrapped peer, except that it is unavailable while it is unhealthy.
+type healthPeer struct {
+	peer.Peer
+
+	prober Prober
+	opts   *options
+
+	mu          sync.Mutex
+	subscribers map[peer.Subscriber]struct{}
+	healthy     bool
+	// successes and failures count consecutive probe results.
+	successes int
+	failures  int
+	timer     clock.Timer
+	stopped   bool
+}
+
+func newHealthPeer(p peer.Peer, prober Prober, opts *options) *healthPeer {
+	hp := &healthPeer{
+		Peer:        p,
+		prober:      prober,
+		opts:        opts,
+		subscribers: make(map[peer.Subscriber]struct{}),
+		healthy:     true,
+	}
+	hp.mu.Lock()
+	hp.schedule()
+	hp.mu.Unlock()
+	return hp
+}
+
+// Status returns the status of the wrapped peer, reporting it unavailable
+// while it is unhealthy.
+func (p *healthPeer) Status() peer.Status {
+	status := p.Peer.Status()
+	if !p.Healthy() {
+		status.ConnectionStatus = peer.Unavailable
+	}
+	return status
+}
+
+// Healthy returns whether the peer passed its recent probes.
+func (p *healthPeer) Healthy() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.healthy
+}
+
+// schedule schedules the next probe.
+//
+// Must be called with the lock held.
+func (p *healthPeer) schedule() {
+	if !p.stopped {
+		p.timer = p.opts.clock.AfterFunc(p.opts.interval, p.probe)
+	}
+}
+
+// probe probes the wrapped peer, records the result and schedules the next
+// probe.
+func (p *healthPeer) probe() {
+	ctx, cancel := context.WithTimeout(context.Background(), p.opts.timeout)
+	err := p.prober.Probe(ctx, p.Peer)
+	cancel()
+
+	p.mu.Lock()
+	if p.stopped {
+		p.mu.Unlock()
+		return
+	}
+	changed := p.observe(err)
+	p.schedule()
+	p.mu.Unlock()
+
+	if changed {
+		p.notify()
+	}
+}
+
+// observe records the result of a probe, returning whether the health of the
+// peer changed.
+//
+// Must be called with the lock held.
+func (p *healthPeer) observe(err error) bool {
+	if err != nil {
+		p.successes = 0
+		p.failures++
+		if p.healthy && p.failures >= p.opts.unhealthyThreshold {
+			p.healthy = false
+			return true
+		}
+		return false
+	}
+
+	p.failures = 0
+	p.successes++
+	if !p.healthy && p.successes >= p.opts.healthyThreshold {
+		p.healthy = true
+		return true
+	}
+	return false
+}
+
+// stop stops probing the peer.
+func (p *healthPeer) stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stopped = true
+	if p.timer != nil {
+		p.timer.Stop()
+		p.timer = nil
+	}
+}
+
+// notify tells every subscriber that the peer's status may have changed.
+// Must be called without the lock held, since subscribers read the status.
+func (p *healthPeer) notify() {
+	p.mu.Lock()
+	subscribers := make([]peer.Subscriber, 0, len(p.subscribers))
+	for s := range p.subscribers {
+		subscribers = append(subscribers, s)
+	}
+	p.mu.Unlock()
+
+	for _, s := range subscribers {
+		s.NotifyStatusChanged(p)
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package healthcheck
+
+import (
+	"bytes"
+	"context"
+
+	"go.uber.org/multierr"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+)
+
+// Prober probes the health of a peer.
+type Prober interface {
+	// Probe returns an error if the peer is unhealthy. The context carries
+	// the probe timeout.
+	Probe(ctx context.Context, p peer.Peer) error
+}
+
+// ProberFunc adapts a function into a Prober.
+type ProberFunc func(context.Context, peer.Peer) error
+
+// Probe calls the function.
+func (f ProberFunc) Probe(ctx context.Context, p peer.Peer) error {
+	return f(ctx, p)
+}
+
+// UnaryProber returns a Prober that sends a copy of the given request,
+// without a body, to each peer. The request is sent through an outbound that
+// newOutbound creates for a chooser of only that peer, and the peer is
+// healthy if the call succeeds.
+func UnaryProber(newOutbound func(peer.Chooser) transport.UnaryOutbound, req *transport.Request) Prober {
+	return ProberFunc(func(ctx context.Context, p peer.Peer) (err error) {
+		out := newOutbound(singlePeer{p})
+		if err := out.Start(); err != nil {
+			return err
+		}
+		defer func() { err = multierr.Append(err, out.Stop()) }()
+
+		probe := *req
+		probe.Body = bytes.NewReader(nil)
+		res, err := out.Call(ctx, &probe)
+		if err != nil {
+			return err
+		}
+		return res.Body.Close()
+	})
+}
+
+// singlePeer is a peer chooser that always chooses the same peer.
+type singlePeer struct {
+	p peer.Peer
+}
+
+func (s singlePeer) Choose(context.Context, *transport.Request) (peer.Peer, func(error), error) {
+	return s.p, func(error) {}, nil
+}
+
+func (singlePeer) Start() error    { return nil }
+func (singlePeer) Stop() error     { return nil }
+func (singlePeer) IsRunning() bool { return true }
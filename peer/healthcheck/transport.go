@@ -0,0 +1,106 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package healthcheck
+
+import (
+	"sync"
+
+	"go.uber.org/yarpc/api/peer"
+)
+
+var _ peer.Transport = (*Transport)(nil)
+
+// Transport wraps a peer transport, probing the health of every peer
+// retained through it. Peers are shared by every subscriber that retains the
+// same identifier, and so are their probes.
+type Transport struct {
+	transport peer.Transport
+	prober    Prober
+	opts      options
+
+	mu    sync.Mutex
+	peers map[string]*healthPeer
+}
+
+// NewTransport wraps the given transport with health checks by the given
+// prober.
+func NewTransport(transport peer.Transport, prober Prober, opts ...Option) *Transport {
+	return &Transport{
+		transport: transport,
+		prober:    prober,
+		opts:      newOptions(opts),
+		peers:     make(map[string]*healthPeer),
+	}
+}
+
+// RetainPeer retains the peer from the wrapped transport and starts probing
+// it.
+func (t *Transport) RetainPeer(pid peer.Identifier, s peer.Subscriber) (peer.Peer, error) {
+	p, err := t.transport.RetainPeer(pid, s)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	hp, ok := t.peers[pid.Identifier()]
+	if !ok {
+		hp = newHealthPeer(p, t.prober, &t.opts)
+		t.peers[pid.Identifier()] = hp
+	}
+	hp.mu.Lock()
+	hp.subscribers[s] = struct{}{}
+	hp.mu.Unlock()
+	return hp, nil
+}
+
+// ReleasePeer releases the peer from the wrapped transport, and stops probing
+// it once no subscriber retains it.
+func (t *Transport) ReleasePeer(pid peer.Identifier, s peer.Subscriber) error {
+	t.mu.Lock()
+	if hp, ok := t.peers[pid.Identifier()]; ok {
+		hp.mu.Lock()
+		delete(hp.subscribers, s)
+		unused := len(hp.subscribers) == 0
+		hp.mu.Unlock()
+		if unused {
+			hp.stop()
+			delete(t.peers, pid.Identifier())
+		}
+	}
+	t.mu.Unlock()
+
+	return t.transport.ReleasePeer(pid, s)
+}
+
+// Healthy returns whether the peer with the given identifier passed its
+// recent probes. Peers that are not retained are reported as healthy.
+func (t *Transport) Healthy(pid peer.Identifier) bool {
+	t.mu.Lock()
+	hp, ok := t.peers[pid.Identifier()]
+	t.mu.Unlock()
+
+	if !ok {
+		return true
+	}
+	return hp.Healthy()
+}
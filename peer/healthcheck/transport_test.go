@@ -0,0 +1,143 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/internal/testtime"
+	"go.uber.org/yarpc/peer/hostport"
+	"go.uber.org/yarpc/peer/roundrobin"
+	"go.uber.org/yarpc/yarpctest"
+)
+
+func withClock(c clock.Clock) Option {
+	return optionFunc(func(o *options) {
+		o.clock = c
+	})
+}
+
+// fakeProber fails probes of the peers marked unhealthy.
+type fakeProber struct {
+	mu        sync.Mutex
+	unhealthy map[string]bool
+}
+
+func (p *fakeProber) setHealthy(id string, healthy bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.unhealthy[id] = !healthy
+}
+
+func (p *fakeProber) Probe(ctx context.Context, pr peer.Peer) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.unhealthy[pr.Identifier()] {
+		return errors.New("great sadness")
+	}
+	return nil
+}
+
+// failures returns the number of consecutive failed probes of the peer.
+func failures(tr *Transport, id string) int {
+	tr.mu.Lock()
+	hp := tr.peers[id]
+	tr.mu.Unlock()
+
+	hp.mu.Lock()
+	defer hp.mu.Unlock()
+	return hp.failures
+}
+
+func TestHealthCheck(t *testing.T) {
+	fake := clock.NewFake()
+	prober := &fakeProber{unhealthy: make(map[string]bool)}
+	checked := NewTransport(yarpctest.NewFakeTransport(), prober,
+		Interval(time.Second),
+		UnhealthyThreshold(2),
+		withClock(fake),
+	)
+	l := roundrobin.New(checked)
+	require.NoError(t, l.Start())
+	defer l.Stop()
+
+	a, b := hostport.PeerIdentifier("a:1"), hostport.PeerIdentifier("b:1")
+	require.NoError(t, l.Update(peer.ListUpdates{Additions: []peer.Identifier{a, b}}))
+	assert.Equal(t, 2, l.NumAvailable(), "peers must be healthy until probed")
+
+	prober.setHealthy("b:1", false)
+	fake.Add(time.Second)
+	testtime.WaitFor(t, "probe must fail", func() bool { return failures(checked, "b:1") == 1 })
+	assert.True(t, checked.Healthy(b), "a single failed probe must not make the peer unhealthy")
+
+	fake.Add(time.Second)
+	testtime.WaitFor(t, "peer must become unhealthy", func() bool { return !checked.Healthy(b) })
+	// The list learns of the change after the peer does.
+	testtime.WaitFor(t, "unhealthy peers must be unavailable", func() bool {
+		return l.Introspect().State == "Running (1/2 available)"
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for i := 0; i < 5; i++ {
+		p, onFinish, err := l.Choose(ctx, &transport.Request{})
+		require.NoError(t, err)
+		onFinish(nil)
+		assert.Equal(t, "a:1", p.Identifier())
+	}
+
+	prober.setHealthy("b:1", true)
+	fake.Add(time.Second)
+	testtime.WaitFor(t, "peer must become healthy", func() bool { return checked.Healthy(b) })
+	testtime.WaitFor(t, "healthy peers must be available again", func() bool {
+		return l.Introspect().State == "Running (2/2 available)"
+	})
+	assert.True(t, checked.Healthy(a))
+}
+
+func TestHealthCheckReleaseStopsProbes(t *testing.T) {
+	fake := clock.NewFake()
+	prober := &fakeProber{unhealthy: map[string]bool{"a:1": true}}
+	checked := NewTransport(yarpctest.NewFakeTransport(), prober,
+		Interval(time.Second),
+		UnhealthyThreshold(1),
+		withClock(fake),
+	)
+	l := roundrobin.New(checked)
+	require.NoError(t, l.Start())
+
+	a := hostport.PeerIdentifier("a:1")
+	require.NoError(t, l.Update(peer.ListUpdates{Additions: []peer.Identifier{a}}))
+	require.NoError(t, l.Stop())
+
+	fake.Add(time.Second)
+	assert.True(t, checked.Healthy(a), "released peers must not be probed")
+	assert.Empty(t, checked.peers)
+}
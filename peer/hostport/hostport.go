@@ -35,8 +35,13 @@ func (p PeerIdentifier) Identifier() string {
 	return string(p)
 }
 
-// Identify coerces a string to a PeerIdentifier
+// Identify coerces a string to a PeerIdentifier, normalizing it if it is a
+// valid host:port. Other identifiers, like the paths of Unix sockets, are
+// returned as-is.
 func Identify(peer string) peer.Identifier {
+	if hostport, err := Normalize(peer); err == nil {
+		return PeerIdentifier(hostport)
+	}
 	return PeerIdentifier(peer)
 }
 
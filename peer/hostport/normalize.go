@@ -0,0 +1,107 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package hostport
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// Normalize returns the canonical form of a host:port peer identifier.
+//
+// Host names are lowercased and stripped of any trailing dot, IP addresses
+// are rewritten in their shortest form, and leading zeros are removed from
+// the port. Identifiers which are not valid host:port strings, including
+// identifiers with no port, are rejected with an InvalidArgument error.
+//
+// Transports and peer list updaters should normalize identifiers before
+// using them as keys so that different spellings of the same address map
+// to the same peer.
+func Normalize(hostport string) (string, error) {
+	return NormalizeWithDefaultPort(hostport, "")
+}
+
+// NormalizeWithDefaultPort is like Normalize, but identifiers with no port
+// are given the provided default port instead of being rejected. An empty
+// default port requires a port.
+func NormalizeWithDefaultPort(hostport, defaultPort string) (string, error) {
+	host, port, err := net.SplitHostPort(hostport)
+	if err != nil {
+		if defaultPort == "" || !isMissingPort(err) {
+			return "", invalidIdentifierError(hostport, err.Error())
+		}
+		host, port = strings.TrimSuffix(strings.TrimPrefix(hostport, "["), "]"), defaultPort
+	}
+
+	host, err = normalizeHost(host)
+	if err != nil {
+		return "", invalidIdentifierError(hostport, err.Error())
+	}
+	port, err = normalizePort(port)
+	if err != nil {
+		return "", invalidIdentifierError(hostport, err.Error())
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+func isMissingPort(err error) bool {
+	addrErr, ok := err.(*net.AddrError)
+	return ok && addrErr.Err == "missing port in address"
+}
+
+func normalizeHost(host string) (string, error) {
+	// IPv6 zones are case sensitive and passed through as-is.
+	if strings.Contains(host, "%") {
+		return host, nil
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.String(), nil
+	}
+
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, r := range host {
+		switch {
+		case 'a' <= r && r <= 'z', '0' <= r && r <= '9', r == '-', r == '.', r == '_':
+		default:
+			return "", fmt.Errorf("invalid character %q in host %q", r, host)
+		}
+	}
+	return host, nil
+}
+
+func normalizePort(port string) (string, error) {
+	if port == "" {
+		return "", fmt.Errorf("missing port")
+	}
+	n, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return "", fmt.Errorf("invalid port %q", port)
+	}
+	return strconv.FormatUint(n, 10), nil
+}
+
+func invalidIdentifierError(hostport, reason string) error {
+	return yarpcerrors.InvalidArgumentErrorf("invalid peer identifier %q: %s", hostport, reason)
+}
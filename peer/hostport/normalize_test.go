@@ -0,0 +1,86 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package hostport
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		give        string
+		defaultPort string
+		want        string
+		wantErr     bool
+	}{
+		{give: "localhost:12345", want: "localhost:12345"},
+		{give: "LocalHost:12345", want: "localhost:12345"},
+		{give: "example.com.:80", want: "example.com:80"},
+		{give: "my_host-1:80", want: "my_host-1:80"},
+		{give: "127.0.0.1:0080", want: "127.0.0.1:80"},
+		{give: "localhost:0", want: "localhost:0"},
+		{give: "[::1]:80", want: "[::1]:80"},
+		{give: "[0:0:0:0:0:0:0:1]:80", want: "[::1]:80"},
+		{give: "[FE80::1]:80", want: "[fe80::1]:80"},
+		{give: "[fe80::1%Eth0]:80", want: "[fe80::1%Eth0]:80"},
+		{give: ":80", want: ":80"},
+		{give: "example.com", defaultPort: "80", want: "example.com:80"},
+		{give: "Example.com:8080", defaultPort: "80", want: "example.com:8080"},
+		{give: "[::1]", defaultPort: "443", want: "[::1]:443"},
+		{give: "example.com", wantErr: true},
+		{give: "example.com:", wantErr: true},
+		{give: "example.com:http", wantErr: true},
+		{give: "example.com:65536", wantErr: true},
+		{give: "example.com:-1", wantErr: true},
+		{give: "exa mple.com:80", wantErr: true},
+		{give: "http://example.com:80", wantErr: true},
+		{give: "::1:80", wantErr: true},
+		{give: "[::1:80", wantErr: true},
+		{give: "example.com:80:80", defaultPort: "80", wantErr: true},
+		{give: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.give, func(t *testing.T) {
+			got, err := NormalizeWithDefaultPort(tt.give, tt.defaultPort)
+			if tt.wantErr {
+				assert.True(t, yarpcerrors.IsInvalidArgument(err), "expected InvalidArgument error, got %v", err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+
+			if tt.defaultPort == "" {
+				got, err := Normalize(tt.give)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestIdentifyNormalizes(t *testing.T) {
+	assert.Equal(t, PeerIdentifier("example.com:80"), Identify("Example.COM:080"))
+	assert.Equal(t, PeerIdentifier("not a hostport"), Identify("not a hostport"))
+}
@@ -0,0 +1,39 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package kubernetes provides a peer list updater that tracks the endpoints
+// of a Kubernetes Service.
+//
+// The updater lists the EndpointSlices of the Service from the API server
+// and then watches them, re-listing whenever the watch ends, like a
+// client-go informer. Every ready address is sent to the peer list as a
+// host:port identifier, and addresses are removed as soon as their endpoint
+// stops being ready or goes away.
+//
+// 	list := roundrobin.New(transport)
+// 	chooser := peer.Bind(list, kubernetes.Binder("myservice",
+// 		kubernetes.Namespace("default"),
+// 		kubernetes.PortName("http"),
+// 	))
+//
+// Inside a cluster, the API server and credentials of the pod's service
+// account are used. The service account needs permission to list and watch
+// endpointslices in the namespace of the Service.
+package kubernetes
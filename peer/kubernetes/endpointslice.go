@@ -0,0 +1,113 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package kubernetes
+
+import (
+	"encoding/json"
+	"net"
+	"strconv"
+)
+
+// The following types decode the parts of discovery.k8s.io/v1 EndpointSlices
+// that the updater needs.
+
+type objectMeta struct {
+	Name            string `json:"name"`
+	ResourceVersion string `json:"resourceVersion"`
+}
+
+type endpointSlice struct {
+	Metadata  objectMeta     `json:"metadata"`
+	Endpoints []endpoint     `json:"endpoints"`
+	Ports     []endpointPort `json:"ports"`
+}
+
+type endpoint struct {
+	Addresses  []string           `json:"addresses"`
+	Conditions endpointConditions `json:"conditions"`
+}
+
+type endpointConditions struct {
+	// Ready is nil if the readiness of the endpoint is unknown, in which
+	// case it is considered ready.
+	Ready *bool `json:"ready"`
+}
+
+type endpointPort struct {
+	Name *string `json:"name"`
+	Port *int32  `json:"port"`
+}
+
+type endpointSliceList struct {
+	Metadata objectMeta      `json:"metadata"`
+	Items    []endpointSlice `json:"items"`
+}
+
+// watchEvent is an event of a watch. Its object is an EndpointSlice, except
+// for ERROR events, whose object is a Status.
+type watchEvent struct {
+	Type   string          `json:"type"`
+	Object json.RawMessage `json:"object"`
+}
+
+type status struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// addresses returns the host:port addresses of the ready endpoints of the
+// slice, with the port of the given name. A slice without that port has no
+// addresses.
+func (s *endpointSlice) addresses(portName string) []string {
+	port, ok := s.port(portName)
+	if !ok {
+		return nil
+	}
+
+	var addrs []string
+	for _, ep := range s.Endpoints {
+		if ep.Conditions.Ready != nil && !*ep.Conditions.Ready {
+			continue
+		}
+		for _, addr := range ep.Addresses {
+			addrs = append(addrs, net.JoinHostPort(addr, port))
+		}
+	}
+	return addrs
+}
+
+// port returns the port with the given name, or the first port if the name
+// is empty.
+func (s *endpointSlice) port(name string) (string, bool) {
+	for _, p := range s.Ports {
+		if p.Port == nil {
+			continue
+		}
+		var pname string
+		if p.Name != nil {
+			pname = *p.Name
+		}
+		if name == "" || pname == name {
+			return strconv.Itoa(int(*p.Port)), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,182 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package kubernetes
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// _serviceAccountDir holds the credentials of the pod's service account.
+const _serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// Option customizes the behavior of a Kubernetes peer list updater.
+type Option func(*options)
+
+type options struct {
+	namespace     string
+	portName      string
+	apiServer     string
+	token         string
+	tokenFile     string
+	httpClient    *http.Client
+	retryInterval time.Duration
+	logger        *zap.Logger
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		retryInterval: time.Second,
+		logger:        zap.NewNop(),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Namespace specifies the namespace of the Service.
+//
+// Defaults to the namespace of the pod's service account, or "default"
+// outside a cluster.
+func Namespace(namespace string) Option {
+	return func(o *options) {
+		o.namespace = namespace
+	}
+}
+
+// PortName specifies the name of the endpoint port to send requests to.
+//
+// Defaults to the first port of each EndpointSlice.
+func PortName(name string) Option {
+	return func(o *options) {
+		o.portName = name
+	}
+}
+
+// APIServer specifies the URL of the Kubernetes API server, such as
+// https://10.0.0.1:443, for use outside a cluster. The HTTPClient and Token
+// options provide its credentials.
+//
+// Defaults to the API server of the cluster the pod runs in.
+func APIServer(url string) Option {
+	return func(o *options) {
+		o.apiServer = url
+	}
+}
+
+// Token specifies the bearer token sent to the API server.
+//
+// Defaults to the token of the pod's service account, read again for every
+// request so that rotated tokens are picked up.
+func Token(token string) Option {
+	return func(o *options) {
+		o.token = token
+	}
+}
+
+// HTTPClient specifies the HTTP client used to reach the API server.
+//
+// Defaults to a client trusting the certificate authority of the pod's
+// service account.
+func HTTPClient(client *http.Client) Option {
+	return func(o *options) {
+		o.httpClient = client
+	}
+}
+
+// RetryInterval specifies how long to wait before listing the endpoints again
+// after a failed list or watch.
+//
+// Defaults to 1 second.
+func RetryInterval(d time.Duration) Option {
+	return func(o *options) {
+		o.retryInterval = d
+	}
+}
+
+// Logger specifies a logger for failed lists and watches.
+func Logger(logger *zap.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// complete fills the options that were not given from the environment of the
+// pod, failing if the API server is unknown.
+func (o *options) complete() error {
+	if o.apiServer == "" {
+		host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+		if host == "" || port == "" {
+			return errors.New("the Kubernetes API server is unknown: " +
+				"not running in a cluster and no APIServer option given")
+		}
+		o.apiServer = "https://" + net.JoinHostPort(host, port)
+
+		if o.httpClient == nil {
+			ca, err := ioutil.ReadFile(filepath.Join(_serviceAccountDir, "ca.crt"))
+			if err != nil {
+				return err
+			}
+			pool := x509.NewCertPool()
+			pool.AppendCertsFromPEM(ca)
+			o.httpClient = &http.Client{Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{RootCAs: pool},
+			}}
+		}
+		if o.token == "" {
+			o.tokenFile = filepath.Join(_serviceAccountDir, "token")
+		}
+	}
+
+	if o.namespace == "" {
+		o.namespace = "default"
+		if ns, err := ioutil.ReadFile(filepath.Join(_serviceAccountDir, "namespace")); err == nil {
+			o.namespace = strings.TrimSpace(string(ns))
+		}
+	}
+	if o.httpClient == nil {
+		o.httpClient = http.DefaultClient
+	}
+	return nil
+}
+
+// bearerToken returns the token to send to the API server, if any.
+func (o *options) bearerToken() (string, error) {
+	if o.tokenFile == "" {
+		return o.token, nil
+	}
+	token, err := ioutil.ReadFile(o.tokenFile)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(token)), nil
+}
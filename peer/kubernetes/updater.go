@@ -0,0 +1,287 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/peer/hostport"
+	"go.uber.org/yarpc/pkg/lifecycle"
+	"go.uber.org/zap"
+)
+
+// _serviceNameLabel labels the EndpointSlices of a Service with its name.
+const _serviceNameLabel = "kubernetes.io/service-name"
+
+var _ transport.Lifecycle = (*Updater)(nil)
+
+// Updater is a peer list updater that keeps a peer list in sync with the
+// ready endpoints of a Kubernetes Service.
+type Updater struct {
+	list    peer.List
+	service string
+	opts    options
+	once    *lifecycle.Once
+
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	// The following are only accessed by the watch goroutine.
+
+	// slices holds the ready addresses of every EndpointSlice, by name.
+	slices map[string][]string
+	// peers holds the addresses sent to the list.
+	peers map[string]struct{}
+}
+
+// New creates an updater that sends the ready endpoints of the named Service
+// to the peer list once started.
+func New(list peer.List, service string, opts ...Option) *Updater {
+	return &Updater{
+		list:    list,
+		service: service,
+		opts:    newOptions(opts),
+		once:    lifecycle.NewOnce(),
+		done:    make(chan struct{}),
+		slices:  make(map[string][]string),
+		peers:   make(map[string]struct{}),
+	}
+}
+
+// Binder returns a peer.Binder that binds peer lists to an updater for the
+// named Service.
+func Binder(service string, opts ...Option) peer.Binder {
+	return func(list peer.List) transport.Lifecycle {
+		return New(list, service, opts...)
+	}
+}
+
+// Start starts watching the endpoints of the Service. Start fails only if
+// the API server is unknown; failed lists and watches are logged and
+// retried.
+func (u *Updater) Start() error {
+	return u.once.Start(func() error {
+		if err := u.opts.complete(); err != nil {
+			return err
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		u.cancel = cancel
+		go u.run(ctx)
+		return nil
+	})
+}
+
+// Stop stops watching the endpoints. Peers already sent to the list remain.
+func (u *Updater) Stop() error {
+	return u.once.Stop(func() error {
+		if u.cancel == nil {
+			// Never started watching.
+			return nil
+		}
+		u.cancel()
+		<-u.done
+		return nil
+	})
+}
+
+// IsRunning returns whether the updater is running.
+func (u *Updater) IsRunning() bool {
+	return u.once.IsRunning()
+}
+
+// run lists and watches the EndpointSlices of the Service until the context
+// is canceled, listing them again whenever a watch ends.
+func (u *Updater) run(ctx context.Context) {
+	defer close(u.done)
+
+	for {
+		resourceVersion, err := u.listSlices(ctx)
+		if err == nil {
+			err = u.watchSlices(ctx, resourceVersion)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			// The API server ended the watch.
+			continue
+		}
+
+		u.opts.logger.Warn("failed to watch Kubernetes endpoints, retrying",
+			zap.String("service", u.service), zap.Error(err))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(u.opts.retryInterval):
+		}
+	}
+}
+
+// listSlices replaces the known EndpointSlices with those listed by the API
+// server, returning the resource version to watch from.
+func (u *Updater) listSlices(ctx context.Context) (string, error) {
+	res, err := u.get(ctx, url.Values{})
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	var list endpointSliceList
+	if err := json.NewDecoder(res.Body).Decode(&list); err != nil {
+		return "", err
+	}
+
+	u.slices = make(map[string][]string, len(list.Items))
+	for _, s := range list.Items {
+		u.slices[s.Metadata.Name] = s.addresses(u.opts.portName)
+	}
+	u.sync()
+	return list.Metadata.ResourceVersion, nil
+}
+
+// watchSlices applies changes to the EndpointSlices from the given resource
+// version until the watch ends.
+func (u *Updater) watchSlices(ctx context.Context, resourceVersion string) error {
+	res, err := u.get(ctx, url.Values{
+		"watch":               {"true"},
+		"resourceVersion":     {resourceVersion},
+		"allowWatchBookmarks": {"true"},
+	})
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	dec := json.NewDecoder(res.Body)
+	for {
+		var event watchEvent
+		if err := dec.Decode(&event); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		switch event.Type {
+		case "ADDED", "MODIFIED", "DELETED":
+			var s endpointSlice
+			if err := json.Unmarshal(event.Object, &s); err != nil {
+				return err
+			}
+			if event.Type == "DELETED" {
+				delete(u.slices, s.Metadata.Name)
+			} else {
+				u.slices[s.Metadata.Name] = s.addresses(u.opts.portName)
+			}
+			u.sync()
+		case "ERROR":
+			// Typically 410 Gone, when the resource version is too old to
+			// watch from, which a new list resolves.
+			var st status
+			if err := json.Unmarshal(event.Object, &st); err != nil {
+				return err
+			}
+			return fmt.Errorf("watch failed with code %d: %s", st.Code, st.Message)
+		}
+	}
+}
+
+// get requests the EndpointSlices of the Service.
+func (u *Updater) get(ctx context.Context, query url.Values) (*http.Response, error) {
+	query.Set("labelSelector", _serviceNameLabel+"="+u.service)
+	target := fmt.Sprintf("%s/apis/discovery.k8s.io/v1/namespaces/%s/endpointslices?%s",
+		u.opts.apiServer, url.PathEscape(u.opts.namespace), query.Encode())
+
+	req, err := http.NewRequest("GET", target, nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "application/json")
+
+	token, err := u.opts.bearerToken()
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	res, err := u.opts.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		return nil, fmt.Errorf("unexpected response from the Kubernetes API server: %s", res.Status)
+	}
+	return res, nil
+}
+
+// sync sends the addresses that were added to or removed from the
+// EndpointSlices since the last sync to the list.
+func (u *Updater) sync() {
+	addrs := make(map[string]struct{})
+	for _, slice := range u.slices {
+		for _, addr := range slice {
+			addrs[addr] = struct{}{}
+		}
+	}
+
+	var removals, additions []string
+	for addr := range u.peers {
+		if _, ok := addrs[addr]; !ok {
+			removals = append(removals, addr)
+		}
+	}
+	for addr := range addrs {
+		if _, ok := u.peers[addr]; !ok {
+			additions = append(additions, addr)
+		}
+	}
+	if len(removals) == 0 && len(additions) == 0 {
+		return
+	}
+	sort.Strings(removals)
+	sort.Strings(additions)
+
+	var updates peer.ListUpdates
+	for _, addr := range removals {
+		updates.Removals = append(updates.Removals, hostport.PeerIdentifier(addr))
+	}
+	for _, addr := range additions {
+		updates.Additions = append(updates.Additions, hostport.PeerIdentifier(addr))
+	}
+	if err := u.list.Update(updates); err != nil {
+		u.opts.logger.Warn("failed to update peer list",
+			zap.String("service", u.service), zap.Error(err))
+	}
+	u.peers = addrs
+}
@@ -0,0 +1,151 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package kubernetes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/peer/peertest"
+	"go.uber.org/yarpc/internal/testtime"
+)
+
+// fakeAPIServer serves a list of EndpointSlices and then the events sent to
+// it as a watch.
+type fakeAPIServer struct {
+	t      *testing.T
+	list   endpointSliceList
+	events chan watchEvent
+}
+
+func (s *fakeAPIServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	assert.Equal(s.t, "/apis/discovery.k8s.io/v1/namespaces/prod/endpointslices", r.URL.Path)
+	assert.Equal(s.t, "kubernetes.io/service-name=myservice", r.URL.Query().Get("labelSelector"))
+	assert.Equal(s.t, "Bearer secret", r.Header.Get("Authorization"))
+
+	if r.URL.Query().Get("watch") != "true" {
+		assert.NoError(s.t, json.NewEncoder(w).Encode(s.list))
+		return
+	}
+
+	assert.Equal(s.t, "42", r.URL.Query().Get("resourceVersion"))
+	w.WriteHeader(http.StatusOK)
+	w.(http.Flusher).Flush()
+	enc := json.NewEncoder(w)
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event := <-s.events:
+			assert.NoError(s.t, enc.Encode(event))
+			w.(http.Flusher).Flush()
+		}
+	}
+}
+
+func slice(name string, ready map[string]bool) endpointSlice {
+	portName, port := "http", int32(8080)
+	otherName, otherPort := "admin", int32(9090)
+	s := endpointSlice{
+		Metadata: objectMeta{Name: name},
+		Ports: []endpointPort{
+			{Name: &otherName, Port: &otherPort},
+			{Name: &portName, Port: &port},
+		},
+	}
+	addrs := make([]string, 0, len(ready))
+	for addr := range ready {
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+	for _, addr := range addrs {
+		r := ready[addr]
+		s.Endpoints = append(s.Endpoints, endpoint{
+			Addresses:  []string{addr},
+			Conditions: endpointConditions{Ready: &r},
+		})
+	}
+	return s
+}
+
+func event(t *testing.T, typ string, s endpointSlice) watchEvent {
+	object, err := json.Marshal(s)
+	require.NoError(t, err)
+	return watchEvent{Type: typ, Object: object}
+}
+
+func TestUpdater(t *testing.T) {
+	api := &fakeAPIServer{
+		t: t,
+		list: endpointSliceList{
+			Metadata: objectMeta{ResourceVersion: "42"},
+			Items: []endpointSlice{
+				slice("myservice-a", map[string]bool{"10.0.0.1": true, "10.0.0.2": false}),
+			},
+		},
+		events: make(chan watchEvent),
+	}
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	list := peertest.NewFakeList()
+	u := New(list, "myservice",
+		APIServer(server.URL),
+		Token("secret"),
+		Namespace("prod"),
+		PortName("http"),
+	)
+	require.NoError(t, u.Start())
+	defer u.Stop()
+
+	testtime.WaitFor(t, "ready endpoints must be listed", func() bool {
+		return fmt.Sprint(list.IDs()) == "[10.0.0.1:8080]"
+	})
+
+	api.events <- event(t, "MODIFIED", slice("myservice-a", map[string]bool{"10.0.0.1": true, "10.0.0.2": true}))
+	api.events <- event(t, "ADDED", slice("myservice-b", map[string]bool{"10.0.1.1": true}))
+	testtime.WaitFor(t, "endpoints that become ready must be added", func() bool {
+		return fmt.Sprint(list.IDs()) == "[10.0.0.1:8080 10.0.0.2:8080 10.0.1.1:8080]"
+	})
+
+	api.events <- event(t, "MODIFIED", slice("myservice-a", map[string]bool{"10.0.0.1": false, "10.0.0.2": true}))
+	api.events <- event(t, "DELETED", slice("myservice-b", nil))
+	testtime.WaitFor(t, "endpoints that are not ready or deleted must be removed", func() bool {
+		return fmt.Sprint(list.IDs()) == "[10.0.0.2:8080]"
+	})
+
+	require.NoError(t, u.Stop())
+	assert.False(t, u.IsRunning())
+}
+
+func TestUpdaterOutsideCluster(t *testing.T) {
+	u := New(peertest.NewFakeList(), "myservice")
+	if u.opts.complete() == nil {
+		t.Skip("running inside a Kubernetes cluster")
+	}
+	assert.Error(t, New(peertest.NewFakeList(), "myservice").Start(), "must not start without an API server")
+}
@@ -0,0 +1,40 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package maglev provides an implementation of a peer list that routes each
+// request to a peer chosen by Maglev consistent hashing of the request's
+// shard key.
+//
+// Maglev hashing fills a lookup table with a fixed number of entries, taking
+// turns between peers so that every peer owns nearly the same number of
+// entries. A request is sent to the peer owning the entry at the hash of its
+// ShardKey, so choosing a peer takes constant time regardless of the number
+// of peers. Compared to the hash ring in go.uber.org/yarpc/peer/hashring,
+// load is spread more evenly across large fleets, while peers joining or
+// leaving the list still move only a small share of keys between the
+// remaining peers.
+//
+// The table size should be a prime number much larger than the number of
+// peers. The default of 65537 entries suits fleets of up to several hundred
+// peers.
+//
+// Requests without a shard key are spread across the available peers in
+// round-robin order.
+package maglev
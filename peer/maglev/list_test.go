@@ -0,0 +1,155 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package maglev
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/peerlisttest"
+	"go.uber.org/yarpc/peer/hostport"
+	"go.uber.org/yarpc/yarpctest"
+)
+
+func newStartedList(t *testing.T, opts []ListOption, ids ...string) *List {
+	pl := New(yarpctest.NewFakeTransport(), opts...)
+	require.NoError(t, pl.Start())
+	require.NoError(t, pl.Update(peer.ListUpdates{Additions: identifiers(ids...)}))
+	return pl
+}
+
+func identifiers(ids ...string) []peer.Identifier {
+	pids := make([]peer.Identifier, len(ids))
+	for i, id := range ids {
+		pids[i] = hostport.PeerIdentifier(id)
+	}
+	return pids
+}
+
+func TestMaglevStableRouting(t *testing.T) {
+	const numKeys = 1000
+
+	pl := newStartedList(t, nil, "1.1.1.1:1", "2.2.2.2:2", "3.3.3.3:3", "4.4.4.4:4")
+	defer pl.Stop()
+
+	before := peerlisttest.Route(t, pl, numKeys)
+	assert.Equal(t, before, peerlisttest.Route(t, pl, numKeys), "routing must be stable")
+
+	counts := make(map[string]int)
+	for _, id := range before {
+		counts[id]++
+	}
+	assert.Len(t, counts, 4, "every peer must receive keys")
+
+	t.Run("removing a peer moves its keys and few others", func(t *testing.T) {
+		require.NoError(t, pl.Update(peer.ListUpdates{Removals: identifiers("2.2.2.2:2")}))
+		after := peerlisttest.Route(t, pl, numKeys)
+
+		var moved int
+		for key, id := range before {
+			if id == "2.2.2.2:2" {
+				assert.NotEqual(t, "2.2.2.2:2", after[key])
+			} else if after[key] != id {
+				moved++
+			}
+		}
+		assert.True(t, moved < numKeys/20, "%d keys of remaining peers moved", moved)
+
+		require.NoError(t, pl.Update(peer.ListUpdates{Additions: identifiers("2.2.2.2:2")}))
+		assert.Equal(t, before, peerlisttest.Route(t, pl, numKeys), "keys must return to the peer")
+	})
+}
+
+func TestMaglevEvenSpread(t *testing.T) {
+	ids := make([]string, 50)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("10.0.0.%d:80", i)
+	}
+	pl := newStartedList(t, []ListOption{TableSize(5003)}, ids...)
+	defer pl.Stop()
+
+	table := pl.table
+	require.Len(t, table.entries, 5003)
+
+	counts := make(map[string]int)
+	for _, sub := range table.entries {
+		counts[sub.peer.Identifier()]++
+	}
+	require.Len(t, counts, len(ids))
+	for id, count := range counts {
+		// Peers take turns claiming entries, so every peer owns the same
+		// number of entries, give or take one.
+		assert.True(t, count == 5003/50 || count == 5003/50+1, "peer %q owns %d entries", id, count)
+	}
+}
+
+func TestMaglevIndependentOfInsertionOrder(t *testing.T) {
+	a := newStartedList(t, nil, "1.1.1.1:1", "2.2.2.2:2", "3.3.3.3:3")
+	defer a.Stop()
+	b := newStartedList(t, nil, "3.3.3.3:3", "1.1.1.1:1", "2.2.2.2:2")
+	defer b.Stop()
+
+	assert.Equal(t, peerlisttest.Route(t, a, 100), peerlisttest.Route(t, b, 100))
+}
+
+func TestMaglevTableSizeRoundedUpToPrime(t *testing.T) {
+	assert.Equal(t, 101, newMaglevTable(100).size)
+	assert.Equal(t, 2, newMaglevTable(0).size)
+	assert.Equal(t, 65537, newMaglevTable(65537).size)
+}
+
+func TestMaglevWithoutShardKey(t *testing.T) {
+	pl := newStartedList(t, nil, "1.1.1.1:1", "2.2.2.2:2", "3.3.3.3:3")
+	defer pl.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	counts := make(map[string]int)
+	for i := 0; i < 30; i++ {
+		p, onFinish, err := pl.Choose(ctx, &transport.Request{})
+		require.NoError(t, err)
+		onFinish(nil)
+		counts[p.Identifier()]++
+	}
+	assert.Equal(t, map[string]int{
+		"1.1.1.1:1": 10,
+		"2.2.2.2:2": 10,
+		"3.3.3.3:3": 10,
+	}, counts)
+}
+
+func TestMaglevEmpty(t *testing.T) {
+	pl := newStartedList(t, nil)
+	defer pl.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, _, err := pl.Choose(ctx, &transport.Request{ShardKey: "foo"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,183 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package maglev
+
+import (
+	"context"
+	"hash/fnv"
+	"sort"
+
+	"go.uber.org/atomic"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/splitmix"
+)
+
+type subscriber struct {
+	peer peer.StatusPeer
+}
+
+func (s *subscriber) NotifyStatusChanged(pid peer.Identifier) {}
+
+// maglevTable maps shard keys to available peers with a Maglev lookup table.
+//
+// maglevTable is NOT thread-safe. The peer list calls Add and Remove with its
+// write lock held and Choose with its read lock held, so Choose must not
+// modify the table.
+type maglevTable struct {
+	size int
+
+	// entries holds the peer owning each entry of the lookup table, and is
+	// empty if there are no peers.
+	entries []*subscriber
+	// subs holds each peer once, in the order they were added, for requests
+	// without a shard key.
+	subs []*subscriber
+	next atomic.Uint32
+}
+
+func newMaglevTable(size int) *maglevTable {
+	// Peers' permutations only cover every entry if the size is prime.
+	for !isPrime(size) {
+		size++
+	}
+	return &maglevTable{size: size}
+}
+
+// isPrime reports whether n is a prime number.
+func isPrime(n int) bool {
+	if n < 2 {
+		return false
+	}
+	for d := 2; d*d <= n; d++ {
+		if n%d == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func hash(key string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return h.Sum64()
+}
+
+// Add adds the peer and rebuilds the lookup table.
+func (m *maglevTable) Add(p peer.StatusPeer) peer.Subscriber {
+	sub := &subscriber{peer: p}
+	m.subs = append(m.subs, sub)
+	m.populate()
+	return sub
+}
+
+// Remove removes the peer and rebuilds the lookup table.
+func (m *maglevTable) Remove(p peer.StatusPeer, s peer.Subscriber) {
+	sub, ok := s.(*subscriber)
+	if !ok {
+		// Don't panic.
+		return
+	}
+
+	for i, candidate := range m.subs {
+		if candidate == sub {
+			m.subs = append(m.subs[:i], m.subs[i+1:]...)
+			m.populate()
+			return
+		}
+	}
+}
+
+// populate fills the lookup table.
+//
+// Every peer has a permutation of the table entries, derived from the hash of
+// its identifier. Peers take turns claiming the next entry in their
+// permutation that no other peer has claimed, until the table is full.
+func (m *maglevTable) populate() {
+	if len(m.subs) == 0 {
+		m.entries = nil
+		return
+	}
+
+	// Take turns in the order of identifiers so that the table does not
+	// depend on the order in which peers were added.
+	subs := make([]*subscriber, len(m.subs))
+	copy(subs, m.subs)
+	sort.Slice(subs, func(i, j int) bool {
+		return subs[i].peer.Identifier() < subs[j].peer.Identifier()
+	})
+
+	size := uint64(m.size)
+	offsets := make([]uint64, len(subs))
+	skips := make([]uint64, len(subs))
+	next := make([]uint64, len(subs))
+	for i, sub := range subs {
+		h := hash(sub.peer.Identifier())
+		offsets[i] = h % size
+		skips[i] = splitmix.Mix(h)%(size-1) + 1
+	}
+
+	entries := make([]*subscriber, m.size)
+	for filled := 0; ; {
+		for i, sub := range subs {
+			c := (offsets[i] + next[i]*skips[i]) % size
+			for entries[c] != nil {
+				next[i]++
+				c = (offsets[i] + next[i]*skips[i]) % size
+			}
+			entries[c] = sub
+			next[i]++
+			filled++
+			if filled == m.size {
+				m.entries = entries
+				return
+			}
+		}
+	}
+}
+
+// Choose returns the peer owning the request's shard key, or the next peer
+// in round-robin order if the request has no shard key. Returns nil if there
+// are no peers.
+func (m *maglevTable) Choose(_ context.Context, req *transport.Request) peer.StatusPeer {
+	if len(m.subs) == 0 {
+		return nil
+	}
+
+	if req == nil || req.ShardKey == "" {
+		i := int(m.next.Inc()-1) % len(m.subs)
+		return m.subs[i].peer
+	}
+
+	return m.entries[splitmix.Mix(hash(req.ShardKey))%uint64(m.size)].peer
+}
+
+func (m *maglevTable) Start() error {
+	return nil
+}
+
+func (m *maglevTable) Stop() error {
+	return nil
+}
+
+func (m *maglevTable) IsRunning() bool {
+	return true
+}
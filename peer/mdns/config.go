@@ -0,0 +1,88 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package mdns
+
+import (
+	"net"
+	"time"
+
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/yarpcconfig"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// Configuration describes how to build an mDNS peer list updater.
+type Configuration struct {
+	Service   string        `config:"service"`
+	Domain    string        `config:"domain"`
+	Interface string        `config:"interface"`
+	Interval  time.Duration `config:"interval"`
+}
+
+// Spec returns a configuration specification for the mDNS peer list updater,
+// making it possible to browse a service type on the local network with
+// transports that use outbound peer list configuration (like HTTP).
+//
+//  cfg := yarpcconfig.New()
+//  cfg.MustRegisterPeerListUpdater(mdns.Spec())
+//
+// This enables the mdns peer list updater:
+//
+//  outbounds:
+//    otherservice:
+//      unary:
+//        http:
+//          url: http://host:port/rpc
+//          round-robin:
+//            mdns:
+//              service: _otherservice._tcp
+//              interface: en0
+func Spec() yarpcconfig.PeerListUpdaterSpec {
+	return yarpcconfig.PeerListUpdaterSpec{
+		Name: "mdns",
+		BuildPeerListUpdater: func(cfg Configuration, k *yarpcconfig.Kit) (peer.Binder, error) {
+			if cfg.Service == "" {
+				return nil, yarpcerrors.InvalidArgumentErrorf("Service is required.")
+			}
+
+			var opts []Option
+			if cfg.Domain != "" {
+				opts = append(opts, Domain(cfg.Domain))
+			}
+			if cfg.Interface != "" {
+				iface, err := net.InterfaceByName(cfg.Interface)
+				if err != nil {
+					return nil, yarpcerrors.InvalidArgumentErrorf(
+						"Interface %q is unknown: %v.", cfg.Interface, err)
+				}
+				opts = append(opts, Interface(iface))
+			}
+			if cfg.Interval < 0 {
+				return nil, yarpcerrors.InvalidArgumentErrorf(
+					"Interval must not be negative. Got: %v.", cfg.Interval)
+			}
+			if cfg.Interval > 0 {
+				opts = append(opts, Interval(cfg.Interval))
+			}
+			return Binder(cfg.Service, opts...), nil
+		},
+	}
+}
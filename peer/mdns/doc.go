@@ -0,0 +1,43 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package mdns provides a peer list updater that browses a service type
+// announced over multicast DNS (mDNS), as used by zeroconf and Bonjour, so
+// that services developed or demonstrated on a local network find each other
+// without a registry.
+//
+// The updater joins the mDNS multicast group, queries the PTR records of the
+// service type at an interval, and applies every response and announcement
+// it hears. Each instance is sent to the peer list as a host:port identifier
+// for the IPv4 address of its SRV target, or for the host name of the target
+// if no address was announced. Instances are removed when they say goodbye
+// or their records expire.
+//
+// 	list := roundrobin.New(transport)
+// 	chooser := peer.Bind(list, mdns.Binder("_myservice._tcp"))
+//
+// Services may announce themselves with any mDNS responder, for example:
+//
+// 	dns-sd -R myservice-1 _myservice._tcp local 8080
+// 	avahi-publish-service myservice-1 _myservice._tcp 8080
+//
+// The updater browses over IPv4 only, and is intended for development rather
+// than production environments.
+package mdns
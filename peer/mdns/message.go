@@ -0,0 +1,207 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package mdns
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"strings"
+)
+
+// DNS record types the updater uses.
+const (
+	_typeA    = 1
+	_typePTR  = 12
+	_typeAAAA = 28
+	_typeSRV  = 33
+
+	_classIN = 1
+	// _classMask clears the cache-flush bit that mDNS sets in the class of
+	// records.
+	_classMask = 0x7fff
+
+	// _maxPointers bounds the compression pointers followed in a single name,
+	// so that malicious messages cannot loop forever.
+	_maxPointers = 32
+)
+
+var errMalformed = errors.New("malformed DNS message")
+
+// record is a resource record of a DNS message.
+type record struct {
+	name string
+	typ  uint16
+	ttl  uint32
+
+	// ptr is set for PTR records.
+	ptr string
+	// target and port are set for SRV records.
+	target string
+	port   uint16
+	// ip is set for A and AAAA records.
+	ip net.IP
+}
+
+// buildQuery returns a DNS message querying the PTR records of the name.
+func buildQuery(name string) []byte {
+	b := make([]byte, 12, 12+len(name)+6)
+	binary.BigEndian.PutUint16(b[4:], 1) // QDCOUNT
+	b = appendName(b, name)
+	b = append(b, 0, _typePTR, 0, _classIN)
+	return b
+}
+
+// appendName appends a domain name in uncompressed form.
+func appendName(b []byte, name string) []byte {
+	for _, label := range strings.Split(strings.TrimSuffix(name, "."), ".") {
+		if label == "" {
+			continue
+		}
+		b = append(b, byte(len(label)))
+		b = append(b, label...)
+	}
+	return append(b, 0)
+}
+
+// parseMessage returns the resource records of a DNS message, from its
+// answer, authority, and additional sections. Records of other types or
+// classes are skipped.
+func parseMessage(msg []byte) ([]record, error) {
+	if len(msg) < 12 {
+		return nil, errMalformed
+	}
+	qdcount := int(binary.BigEndian.Uint16(msg[4:]))
+	rrcount := int(binary.BigEndian.Uint16(msg[6:])) +
+		int(binary.BigEndian.Uint16(msg[8:])) +
+		int(binary.BigEndian.Uint16(msg[10:]))
+
+	off := 12
+	for i := 0; i < qdcount; i++ {
+		var err error
+		if _, off, err = readName(msg, off); err != nil {
+			return nil, err
+		}
+		off += 4 // QTYPE and QCLASS
+	}
+
+	var records []record
+	for i := 0; i < rrcount; i++ {
+		var (
+			r   record
+			err error
+		)
+		r.name, off, err = readName(msg, off)
+		if err != nil {
+			return nil, err
+		}
+		if off+10 > len(msg) {
+			return nil, errMalformed
+		}
+		r.typ = binary.BigEndian.Uint16(msg[off:])
+		class := binary.BigEndian.Uint16(msg[off+2:]) & _classMask
+		r.ttl = binary.BigEndian.Uint32(msg[off+4:])
+		rdlen := int(binary.BigEndian.Uint16(msg[off+8:]))
+		off += 10
+		if off+rdlen > len(msg) {
+			return nil, errMalformed
+		}
+		rdata := msg[off : off+rdlen]
+		rdoff := off
+		off += rdlen
+
+		if class != _classIN {
+			continue
+		}
+		switch r.typ {
+		case _typeA:
+			if len(rdata) != net.IPv4len {
+				return nil, errMalformed
+			}
+			r.ip = net.IP(append([]byte(nil), rdata...))
+		case _typeAAAA:
+			if len(rdata) != net.IPv6len {
+				return nil, errMalformed
+			}
+			r.ip = net.IP(append([]byte(nil), rdata...))
+		case _typePTR:
+			if r.ptr, _, err = readName(msg, rdoff); err != nil {
+				return nil, err
+			}
+		case _typeSRV:
+			if len(rdata) < 7 {
+				return nil, errMalformed
+			}
+			r.port = binary.BigEndian.Uint16(rdata[4:])
+			if r.target, _, err = readName(msg, rdoff+6); err != nil {
+				return nil, err
+			}
+		default:
+			continue
+		}
+		records = append(records, r)
+	}
+	return records, nil
+}
+
+// readName reads the possibly compressed domain name at the offset,
+// returning it in lower case with a trailing dot, and the offset after it.
+func readName(msg []byte, off int) (string, int, error) {
+	var (
+		labels   []string
+		next     = -1
+		pointers int
+	)
+	for {
+		if off >= len(msg) {
+			return "", 0, errMalformed
+		}
+		n := int(msg[off])
+		switch n & 0xc0 {
+		case 0x00:
+			if n == 0 {
+				if next < 0 {
+					next = off + 1
+				}
+				return strings.ToLower(strings.Join(labels, ".")) + ".", next, nil
+			}
+			if off+1+n > len(msg) {
+				return "", 0, errMalformed
+			}
+			labels = append(labels, string(msg[off+1:off+1+n]))
+			off += 1 + n
+		case 0xc0:
+			if off+2 > len(msg) {
+				return "", 0, errMalformed
+			}
+			pointers++
+			if pointers > _maxPointers {
+				return "", 0, errMalformed
+			}
+			if next < 0 {
+				next = off + 2
+			}
+			off = int(binary.BigEndian.Uint16(msg[off:]) & 0x3fff)
+		default:
+			return "", 0, errMalformed
+		}
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package mdns
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// buildResponse returns a DNS response holding the records as answers,
+// without name compression.
+func buildResponse(records ...record) []byte {
+	b := make([]byte, 12)
+	binary.BigEndian.PutUint16(b[2:], 0x8400) // response, authoritative
+	binary.BigEndian.PutUint16(b[6:], uint16(len(records)))
+	for _, r := range records {
+		b = appendName(b, r.name)
+
+		var rdata []byte
+		switch r.typ {
+		case _typeA:
+			rdata = r.ip.To4()
+		case _typeAAAA:
+			rdata = r.ip.To16()
+		case _typePTR:
+			rdata = appendName(nil, r.ptr)
+		case _typeSRV:
+			rdata = make([]byte, 6)
+			binary.BigEndian.PutUint16(rdata[4:], r.port)
+			rdata = appendName(rdata, r.target)
+		}
+
+		hdr := make([]byte, 10)
+		binary.BigEndian.PutUint16(hdr, r.typ)
+		binary.BigEndian.PutUint16(hdr[2:], 0x8000|_classIN) // cache flush
+		binary.BigEndian.PutUint32(hdr[4:], r.ttl)
+		binary.BigEndian.PutUint16(hdr[8:], uint16(len(rdata)))
+		b = append(b, hdr...)
+		b = append(b, rdata...)
+	}
+	return b
+}
+
+func TestBuildQuery(t *testing.T) {
+	q := buildQuery("_myservice._tcp.local.")
+	assert.Equal(t, []byte{0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0}, q[:12])
+
+	name, off, err := readName(q, 12)
+	require.NoError(t, err)
+	assert.Equal(t, "_myservice._tcp.local.", name)
+	assert.Equal(t, []byte{0, _typePTR, 0, _classIN}, q[off:])
+}
+
+func TestParseMessage(t *testing.T) {
+	want := []record{
+		{name: "_myservice._tcp.local.", typ: _typePTR, ttl: 120, ptr: "one._myservice._tcp.local."},
+		{name: "one._myservice._tcp.local.", typ: _typeSRV, ttl: 120, target: "host.local.", port: 8080},
+		{name: "host.local.", typ: _typeA, ttl: 120, ip: net.IPv4(10, 0, 0, 1).To4()},
+		{name: "host.local.", typ: _typeAAAA, ttl: 120, ip: net.ParseIP("fe80::1")},
+	}
+	got, err := parseMessage(buildResponse(want...))
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+}
+
+func TestParseMessageCompression(t *testing.T) {
+	// A PTR record whose data points back at the name of the record.
+	msg := make([]byte, 12)
+	binary.BigEndian.PutUint16(msg[6:], 1)
+	msg = appendName(msg, "_myservice._tcp.local.")
+	msg = append(msg, 0, _typePTR, 0, _classIN, 0, 0, 0, 120, 0, 6)
+	msg = append(msg, 3, 'O', 'n', 'e', 0xc0, 12)
+
+	got, err := parseMessage(msg)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, "one._myservice._tcp.local.", got[0].ptr)
+}
+
+func TestParseMessageMalformed(t *testing.T) {
+	valid := buildResponse(record{name: "host.local.", typ: _typeA, ttl: 120, ip: net.IPv4(10, 0, 0, 1)})
+
+	tests := []struct {
+		msg  string
+		give []byte
+	}{
+		{"short header", valid[:11]},
+		{"truncated record", valid[:len(valid)-2]},
+		{"pointer loop", []byte{0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0xc0, 12}},
+		{"reserved label type", []byte{0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0x80}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.msg, func(t *testing.T) {
+			_, err := parseMessage(tt.give)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package mdns
+
+import (
+	"net"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// _mdnsAddr is the IPv4 multicast group and port of mDNS.
+var _mdnsAddr = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: 5353}
+
+// Option customizes the behavior of an mDNS peer list updater.
+type Option func(*options)
+
+type options struct {
+	domain   string
+	iface    *net.Interface
+	interval time.Duration
+	logger   *zap.Logger
+
+	// listen opens the connection queries are sent and responses received
+	// on, and returns the address to send queries to. Tests replace it to
+	// avoid multicast.
+	listen func(iface *net.Interface) (net.PacketConn, net.Addr, error)
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		domain:   "local.",
+		interval: 10 * time.Second,
+		logger:   zap.NewNop(),
+		listen:   listenMulticast,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// listenMulticast joins the mDNS multicast group.
+func listenMulticast(iface *net.Interface) (net.PacketConn, net.Addr, error) {
+	conn, err := net.ListenMulticastUDP("udp4", iface, _mdnsAddr)
+	if err != nil {
+		return nil, nil, err
+	}
+	return conn, _mdnsAddr, nil
+}
+
+// Domain specifies the domain to browse.
+//
+// Defaults to "local.".
+func Domain(domain string) Option {
+	return func(o *options) {
+		o.domain = domain
+	}
+}
+
+// Interface specifies the network interface to browse on.
+//
+// Defaults to the interface chosen by the system.
+func Interface(iface *net.Interface) Option {
+	return func(o *options) {
+		o.iface = iface
+	}
+}
+
+// Interval specifies how often the service type is queried. Announcements
+// and goodbyes are applied as they arrive, regardless of the interval.
+//
+// Defaults to 10 seconds.
+func Interval(d time.Duration) Option {
+	return func(o *options) {
+		o.interval = d
+	}
+}
+
+// Logger specifies a logger for failed queries and malformed responses.
+func Logger(logger *zap.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
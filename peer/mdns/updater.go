@@ -0,0 +1,327 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package mdns
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/peer/hostport"
+	"go.uber.org/yarpc/pkg/lifecycle"
+	"go.uber.org/zap"
+)
+
+// _maxMessageSize is the largest mDNS message the updater reads.
+const _maxMessageSize = 9000
+
+var _ transport.Lifecycle = (*Updater)(nil)
+
+// instance is an instance of the service type, as announced by its PTR and
+// SRV records.
+type instance struct {
+	target  string
+	port    uint16
+	expires time.Time
+}
+
+// host holds the addresses of a host, as announced by its A and AAAA
+// records.
+type host struct {
+	ips     []net.IP
+	expires time.Time
+}
+
+// Updater is a peer list updater that keeps a peer list in sync with the
+// instances of a service type announced over mDNS.
+type Updater struct {
+	list    peer.List
+	service string
+	opts    options
+	once    *lifecycle.Once
+
+	conn   net.PacketConn
+	group  net.Addr
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	// The following are only accessed by the browse goroutine.
+
+	// instances holds the instances of the service type, by instance name.
+	instances map[string]*instance
+	// hosts holds the addresses of the targets of instances, by host name.
+	hosts map[string]*host
+	// peers holds the addresses sent to the list.
+	peers map[string]struct{}
+}
+
+// New creates an updater that browses the service type, such as
+// "_myservice._tcp", and sends the addresses of its instances to the peer
+// list once started.
+func New(list peer.List, service string, opts ...Option) *Updater {
+	o := newOptions(opts)
+	return &Updater{
+		list:      list,
+		service:   fqdn(service + "." + o.domain),
+		opts:      o,
+		once:      lifecycle.NewOnce(),
+		done:      make(chan struct{}),
+		instances: make(map[string]*instance),
+		hosts:     make(map[string]*host),
+		peers:     make(map[string]struct{}),
+	}
+}
+
+// Binder returns a peer.Binder that binds peer lists to an updater for the
+// service type.
+func Binder(service string, opts ...Option) peer.Binder {
+	return func(list peer.List) transport.Lifecycle {
+		return New(list, service, opts...)
+	}
+}
+
+// Start joins the mDNS multicast group and starts browsing the service type.
+// Start fails if the group cannot be joined.
+func (u *Updater) Start() error {
+	return u.once.Start(func() error {
+		conn, group, err := u.opts.listen(u.opts.iface)
+		if err != nil {
+			return err
+		}
+		u.conn, u.group = conn, group
+
+		ctx, cancel := context.WithCancel(context.Background())
+		u.cancel = cancel
+		go u.run(ctx)
+		return nil
+	})
+}
+
+// Stop stops browsing the service type. Peers already sent to the list
+// remain.
+func (u *Updater) Stop() error {
+	return u.once.Stop(func() error {
+		u.cancel()
+		err := u.conn.Close()
+		<-u.done
+		return err
+	})
+}
+
+// IsRunning returns whether the updater is running.
+func (u *Updater) IsRunning() bool {
+	return u.once.IsRunning()
+}
+
+// run queries the service type at the interval and applies every response
+// and announcement until the context is canceled.
+func (u *Updater) run(ctx context.Context) {
+	defer close(u.done)
+
+	messages := make(chan []record)
+	go u.read(ctx, messages)
+
+	ticker := time.NewTicker(u.opts.interval)
+	defer ticker.Stop()
+
+	u.query()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case records := <-messages:
+			u.apply(records, time.Now())
+			u.sync(time.Now())
+		case <-ticker.C:
+			u.query()
+			u.sync(time.Now())
+		}
+	}
+}
+
+// read sends the records of every message received to the channel until
+// the connection is closed.
+func (u *Updater) read(ctx context.Context, messages chan<- []record) {
+	buf := make([]byte, _maxMessageSize)
+	for {
+		n, _, err := u.conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() == nil {
+				u.opts.logger.Warn("failed to read mDNS message", zap.Error(err))
+			}
+			return
+		}
+		records, err := parseMessage(buf[:n])
+		if err != nil {
+			u.opts.logger.Debug("ignoring malformed mDNS message", zap.Error(err))
+			continue
+		}
+		select {
+		case messages <- records:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// query asks for the PTR records of the service type.
+func (u *Updater) query() {
+	if _, err := u.conn.WriteTo(buildQuery(u.service), u.group); err != nil {
+		u.opts.logger.Warn("failed to send mDNS query",
+			zap.String("service", u.service), zap.Error(err))
+	}
+}
+
+// apply records the instances and hosts announced by the records. Records
+// with a TTL of zero are goodbyes, which expire what they announced.
+func (u *Updater) apply(records []record, now time.Time) {
+	for _, r := range records {
+		if r.typ != _typePTR || r.name != u.service {
+			continue
+		}
+		expires := now.Add(time.Duration(r.ttl) * time.Second)
+		if s, ok := u.instances[r.ptr]; ok {
+			s.expires = expires
+		} else if r.ttl > 0 {
+			u.instances[r.ptr] = &instance{expires: expires}
+		}
+	}
+
+	// SRV and address records may come before the PTR records they belong
+	// to, so they are applied once every instance is known.
+	for _, r := range records {
+		expires := now.Add(time.Duration(r.ttl) * time.Second)
+		switch r.typ {
+		case _typeSRV:
+			s, ok := u.instances[r.name]
+			if !ok {
+				continue
+			}
+			s.target, s.port = r.target, r.port
+			if r.ttl == 0 {
+				s.expires = expires
+			}
+		case _typeA, _typeAAAA:
+			h, ok := u.hosts[r.name]
+			if !ok || h.expires.Before(now) {
+				h = &host{}
+				u.hosts[r.name] = h
+			}
+			if !containsIP(h.ips, r.ip) {
+				h.ips = append(h.ips, r.ip)
+			}
+			h.expires = expires
+		}
+	}
+}
+
+// sync forgets expired instances and hosts, and sends the addresses that
+// were added or removed since the last sync to the list.
+func (u *Updater) sync(now time.Time) {
+	for name, s := range u.instances {
+		if !s.expires.After(now) {
+			delete(u.instances, name)
+		}
+	}
+	for name, h := range u.hosts {
+		if !h.expires.After(now) {
+			delete(u.hosts, name)
+		}
+	}
+
+	addrs := make(map[string]struct{}, len(u.instances))
+	for _, s := range u.instances {
+		if s.target == "" {
+			continue
+		}
+		addrs[u.address(s)] = struct{}{}
+	}
+
+	var removals, additions []string
+	for addr := range u.peers {
+		if _, ok := addrs[addr]; !ok {
+			removals = append(removals, addr)
+		}
+	}
+	for addr := range addrs {
+		if _, ok := u.peers[addr]; !ok {
+			additions = append(additions, addr)
+		}
+	}
+	if len(removals) == 0 && len(additions) == 0 {
+		return
+	}
+	sort.Strings(removals)
+	sort.Strings(additions)
+
+	var updates peer.ListUpdates
+	for _, addr := range removals {
+		updates.Removals = append(updates.Removals, hostport.PeerIdentifier(addr))
+	}
+	for _, addr := range additions {
+		updates.Additions = append(updates.Additions, hostport.PeerIdentifier(addr))
+	}
+	if err := u.list.Update(updates); err != nil {
+		u.opts.logger.Warn("failed to update peer list",
+			zap.String("service", u.service), zap.Error(err))
+	}
+	u.peers = addrs
+}
+
+// address returns the host:port address of the instance, preferring an IPv4
+// address of its target, then any address, then the target's host name.
+func (u *Updater) address(s *instance) string {
+	hostname := strings.TrimSuffix(s.target, ".")
+	if h, ok := u.hosts[s.target]; ok && len(h.ips) > 0 {
+		hostname = h.ips[0].String()
+		for _, ip := range h.ips {
+			if ip.To4() != nil {
+				hostname = ip.String()
+				break
+			}
+		}
+	}
+	return net.JoinHostPort(hostname, strconv.Itoa(int(s.port)))
+}
+
+func containsIP(ips []net.IP, ip net.IP) bool {
+	for _, other := range ips {
+		if other.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// fqdn returns the name in lower case with a trailing dot.
+func fqdn(name string) string {
+	name = strings.ToLower(name)
+	name = strings.Replace(name, "..", ".", -1)
+	if !strings.HasSuffix(name, ".") {
+		name += "."
+	}
+	return name
+}
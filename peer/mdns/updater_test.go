@@ -0,0 +1,88 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package mdns
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/peer/peertest"
+	"go.uber.org/yarpc/internal/testtime"
+)
+
+// withLoopback replaces the multicast group with a responder listening on
+// the loopback interface, which receives the queries of the updater.
+func withLoopback(t *testing.T) (Option, net.PacketConn) {
+	responder, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	return func(o *options) {
+		o.listen = func(*net.Interface) (net.PacketConn, net.Addr, error) {
+			conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+			return conn, responder.LocalAddr(), err
+		}
+	}, responder
+}
+
+func TestUpdater(t *testing.T) {
+	opt, responder := withLoopback(t)
+	defer responder.Close()
+
+	list := peertest.NewFakeList()
+	u := New(list, "_myservice._tcp", opt)
+	require.NoError(t, u.Start())
+	defer u.Stop()
+
+	buf := make([]byte, _maxMessageSize)
+	require.NoError(t, responder.SetReadDeadline(time.Now().Add(time.Second)))
+	n, addr, err := responder.ReadFrom(buf)
+	require.NoError(t, err)
+	assert.Equal(t, buildQuery("_myservice._tcp.local."), buf[:n], "must query the service type")
+
+	// Records may arrive in any order, and records of other services must be
+	// ignored.
+	_, err = responder.WriteTo(buildResponse(
+		record{name: "host-a.local.", typ: _typeA, ttl: 120, ip: net.IPv4(10, 0, 0, 1)},
+		record{name: "one._myservice._tcp.local.", typ: _typeSRV, ttl: 120, target: "host-a.local.", port: 8080},
+		record{name: "two._myservice._tcp.local.", typ: _typeSRV, ttl: 120, target: "host-b.local.", port: 9090},
+		record{name: "_myservice._tcp.local.", typ: _typePTR, ttl: 120, ptr: "one._myservice._tcp.local."},
+		record{name: "_myservice._tcp.local.", typ: _typePTR, ttl: 120, ptr: "two._myservice._tcp.local."},
+		record{name: "_other._tcp.local.", typ: _typePTR, ttl: 120, ptr: "three._other._tcp.local."},
+	), addr)
+	require.NoError(t, err)
+	testtime.WaitFor(t, "announced instances must be added", func() bool {
+		return fmt.Sprint(list.IDs()) == "[10.0.0.1:8080 host-b.local:9090]"
+	})
+
+	_, err = responder.WriteTo(buildResponse(
+		record{name: "_myservice._tcp.local.", typ: _typePTR, ttl: 0, ptr: "one._myservice._tcp.local."},
+	), addr)
+	require.NoError(t, err)
+	testtime.WaitFor(t, "instances that say goodbye must be removed", func() bool {
+		return fmt.Sprint(list.IDs()) == "[host-b.local:9090]"
+	})
+
+	require.NoError(t, u.Stop())
+	assert.False(t, u.IsRunning())
+}
@@ -0,0 +1,211 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package peer
+
+import (
+	"sync"
+
+	"go.uber.org/multierr"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/pkg/lifecycle"
+)
+
+// MergeBinders returns a binder (suitable as an argument to peer.Bind) that
+// binds a peer list to the union of the peers of every given binder, for
+// example peers from DNS along with a static fallback file.
+//
+// A peer stays in the peer list for as long as any binder's updater holds
+// it, so removing a peer from one source does not remove it from the list
+// while another source still provides it. When several sources provide the
+// same peer, the list holds the identifier from the first of the binders, so
+// metadata like weights and zones from earlier binders take precedence.
+//
+// The updaters start in order and stop in reverse order.
+func MergeBinders(binders ...peer.Binder) peer.Binder {
+	nonNil := make([]peer.Binder, 0, len(binders))
+	for _, b := range binders {
+		if b != nil {
+			nonNil = append(nonNil, b)
+		}
+	}
+	if len(nonNil) == 1 {
+		return nonNil[0]
+	}
+
+	return func(pl peer.List) transport.Lifecycle {
+		u := &mergedUpdater{
+			once:    lifecycle.NewOnce(),
+			pl:      pl,
+			sources: make([]map[string]peer.Identifier, len(nonNil)),
+			current: make(map[string]mergedPeer),
+		}
+		u.updaters = make([]transport.Lifecycle, len(nonNil))
+		for i, b := range nonNil {
+			u.sources[i] = make(map[string]peer.Identifier)
+			u.updaters[i] = b(mergedSource{u: u, index: i})
+		}
+		return u
+	}
+}
+
+// mergedUpdater runs the updaters of several binders against one peer list.
+type mergedUpdater struct {
+	once     *lifecycle.Once
+	updaters []transport.Lifecycle
+
+	// mu serializes updates to the peer list, so that the list sees changes
+	// in the order the sources make them.
+	mu sync.Mutex
+	pl peer.List
+	// sources maps the identifiers of the peers each source provides to the
+	// source's identifiers for them.
+	sources []map[string]peer.Identifier
+	// current maps the identifier of every peer in the peer list to the
+	// identifier the list holds for it.
+	current map[string]mergedPeer
+}
+
+// Start starts every updater in order, stopping the updaters already started
+// if one fails.
+func (u *mergedUpdater) Start() error {
+	return u.once.Start(u.start)
+}
+
+func (u *mergedUpdater) start() error {
+	for i, updater := range u.updaters {
+		if err := updater.Start(); err != nil {
+			for j := i - 1; j >= 0; j-- {
+				err = multierr.Append(err, u.updaters[j].Stop())
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// Stop stops every updater in reverse order.
+func (u *mergedUpdater) Stop() error {
+	return u.once.Stop(u.stop)
+}
+
+func (u *mergedUpdater) stop() error {
+	var errs error
+	for i := len(u.updaters) - 1; i >= 0; i-- {
+		errs = multierr.Append(errs, u.updaters[i].Stop())
+	}
+	return errs
+}
+
+// IsRunning returns whether every updater is running.
+func (u *mergedUpdater) IsRunning() bool {
+	for _, updater := range u.updaters {
+		if !updater.IsRunning() {
+			return false
+		}
+	}
+	return true
+}
+
+// update applies the updates of the source at the given index to the peer
+// list, adding peers no other source provides, removing peers no source
+// provides any more, and replacing the identifiers of peers whose preferred
+// source changed.
+func (u *mergedUpdater) update(index int, updates peer.ListUpdates) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	src := u.sources[index]
+	touched := make([]string, 0, len(updates.Removals)+len(updates.Additions))
+	for _, pid := range updates.Removals {
+		id := pid.Identifier()
+		delete(src, id)
+		touched = append(touched, id)
+	}
+	for _, pid := range updates.Additions {
+		id := pid.Identifier()
+		src[id] = pid
+		touched = append(touched, id)
+	}
+
+	var merged peer.ListUpdates
+	seen := make(map[string]struct{}, len(touched))
+	for _, id := range touched {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+
+		old, held := u.current[id]
+		next, provided := u.preferred(id)
+
+		// The list keeps its identifier unless the preferred source changed
+		// or the preferred source is the one being updated.
+		if held && provided && old.source == next.source && next.source != index {
+			continue
+		}
+		if held {
+			merged.Removals = append(merged.Removals, old.pid)
+			delete(u.current, id)
+		}
+		if provided {
+			merged.Additions = append(merged.Additions, next.pid)
+			u.current[id] = next
+		}
+	}
+
+	if len(merged.Removals) == 0 && len(merged.Additions) == 0 {
+		return nil
+	}
+	return u.pl.Update(merged)
+}
+
+// preferred returns the identifier of the first source that provides the
+// peer with the given identifier, and whether any source provides it.
+//
+// Must be called with the lock held.
+func (u *mergedUpdater) preferred(id string) (mergedPeer, bool) {
+	for i, src := range u.sources {
+		if pid, ok := src[id]; ok {
+			return mergedPeer{source: i, pid: pid}, true
+		}
+	}
+	return mergedPeer{}, false
+}
+
+// mergedPeer is an identifier for a peer along with the index of the source
+// that provided it.
+type mergedPeer struct {
+	source int
+	pid    peer.Identifier
+}
+
+// mergedSource is the peer list a single binder's updater is bound to. It
+// forwards the changes the updater makes to the merged updater.
+type mergedSource struct {
+	u     *mergedUpdater
+	index int
+}
+
+// Update applies the changes of the source's updater to the peer list.
+func (s mergedSource) Update(updates peer.ListUpdates) error {
+	return s.u.update(s.index, updates)
+}
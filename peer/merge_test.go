@@ -0,0 +1,177 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package peer_test
+
+import (
+	"errors"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	. "go.uber.org/yarpc/peer"
+	"go.uber.org/yarpc/peer/hostport"
+	"go.uber.org/yarpc/peer/weightedroundrobin"
+	"go.uber.org/yarpc/pkg/lifecycle"
+)
+
+// recordingList records the identifiers it holds.
+type recordingList struct {
+	peers map[string]peer.Identifier
+}
+
+func (l *recordingList) Update(updates peer.ListUpdates) error {
+	for _, pid := range updates.Removals {
+		if _, ok := l.peers[pid.Identifier()]; !ok {
+			return errors.New("removed a peer that was not in the list")
+		}
+		delete(l.peers, pid.Identifier())
+	}
+	for _, pid := range updates.Additions {
+		if _, ok := l.peers[pid.Identifier()]; ok {
+			return errors.New("added a peer that was already in the list")
+		}
+		l.peers[pid.Identifier()] = pid
+	}
+	return nil
+}
+
+func (l *recordingList) ids() []string {
+	ids := make([]string, 0, len(l.peers))
+	for id := range l.peers {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// manualUpdater is an updater whose peers are changed by the test.
+type manualUpdater struct {
+	once     *lifecycle.Once
+	pl       peer.List
+	startErr error
+}
+
+func (u *manualUpdater) Start() error {
+	return u.once.Start(func() error { return u.startErr })
+}
+
+func (u *manualUpdater) Stop() error {
+	return u.once.Stop(nil)
+}
+
+func (u *manualUpdater) IsRunning() bool {
+	return u.once.IsRunning()
+}
+
+func (u *manualUpdater) bind(pl peer.List) transport.Lifecycle {
+	u.pl = pl
+	return u
+}
+
+func (u *manualUpdater) update(t *testing.T, removals, additions []peer.Identifier) {
+	require.NoError(t, u.pl.Update(peer.ListUpdates{Removals: removals, Additions: additions}))
+}
+
+func ids(ids ...string) []peer.Identifier {
+	pids := make([]peer.Identifier, len(ids))
+	for i, id := range ids {
+		pids[i] = hostport.PeerIdentifier(id)
+	}
+	return pids
+}
+
+func TestMergeBinders(t *testing.T) {
+	dns := &manualUpdater{once: lifecycle.NewOnce()}
+	file := &manualUpdater{once: lifecycle.NewOnce()}
+	list := &recordingList{peers: make(map[string]peer.Identifier)}
+
+	updater := MergeBinders(dns.bind, nil, file.bind)(list)
+	require.NoError(t, updater.Start())
+	assert.True(t, dns.IsRunning())
+	assert.True(t, file.IsRunning())
+	assert.True(t, updater.IsRunning())
+
+	file.update(t, nil, ids("a:1", "b:1"))
+	dns.update(t, nil, ids("b:1", "c:1"))
+	assert.Equal(t, []string{"a:1", "b:1", "c:1"}, list.ids())
+
+	file.update(t, ids("b:1"), nil)
+	assert.Equal(t, []string{"a:1", "b:1", "c:1"}, list.ids(),
+		"peers must stay while another source provides them")
+
+	dns.update(t, ids("b:1", "c:1"), nil)
+	assert.Equal(t, []string{"a:1"}, list.ids(),
+		"peers must be removed once no source provides them")
+
+	require.NoError(t, updater.Stop())
+	assert.False(t, dns.IsRunning())
+	assert.False(t, file.IsRunning())
+}
+
+func TestMergeBindersPrecedence(t *testing.T) {
+	dns := &manualUpdater{once: lifecycle.NewOnce()}
+	file := &manualUpdater{once: lifecycle.NewOnce()}
+	list := &recordingList{peers: make(map[string]peer.Identifier)}
+
+	updater := MergeBinders(dns.bind, file.bind)(list)
+	require.NoError(t, updater.Start())
+	defer updater.Stop()
+
+	weight := func(id string) int {
+		if w, ok := list.peers[id].(weightedroundrobin.WeightedIdentifier); ok {
+			return w.Weight()
+		}
+		return 0
+	}
+
+	file.update(t, nil, []peer.Identifier{weightedroundrobin.Weighted(hostport.PeerIdentifier("a:1"), 2)})
+	assert.Equal(t, 2, weight("a:1"))
+
+	dns.update(t, nil, []peer.Identifier{weightedroundrobin.Weighted(hostport.PeerIdentifier("a:1"), 5)})
+	assert.Equal(t, 5, weight("a:1"), "identifiers from earlier binders must take precedence")
+
+	file.update(t,
+		[]peer.Identifier{hostport.PeerIdentifier("a:1")},
+		[]peer.Identifier{weightedroundrobin.Weighted(hostport.PeerIdentifier("a:1"), 3)},
+	)
+	assert.Equal(t, 5, weight("a:1"), "later binders must not replace preferred identifiers")
+
+	dns.update(t,
+		[]peer.Identifier{hostport.PeerIdentifier("a:1")},
+		[]peer.Identifier{weightedroundrobin.Weighted(hostport.PeerIdentifier("a:1"), 4)},
+	)
+	assert.Equal(t, 4, weight("a:1"), "preferred identifiers must be replaced when they change")
+
+	dns.update(t, ids("a:1"), nil)
+	assert.Equal(t, 3, weight("a:1"), "identifiers must fall back to later binders")
+}
+
+func TestMergeBindersStartError(t *testing.T) {
+	dns := &manualUpdater{once: lifecycle.NewOnce()}
+	file := &manualUpdater{once: lifecycle.NewOnce(), startErr: errors.New("great sadness")}
+
+	updater := MergeBinders(dns.bind, file.bind)(&recordingList{peers: make(map[string]peer.Identifier)})
+	assert.Error(t, updater.Start())
+	assert.False(t, dns.IsRunning(), "started updaters must be stopped if one fails to start")
+}
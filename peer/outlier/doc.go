@@ -0,0 +1,43 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package outlier provides a peer list wrapper that detects peers which
+// fail more than their siblings and temporarily ejects them, in the manner
+// of Envoy's outlier detection.
+//
+// 	list := outlier.New(roundrobin.New(transport),
+// 		outlier.ConsecutiveFailures(5),
+// 		outlier.MaxEjectionPercent(20),
+// 	)
+//
+// The wrapper observes the result of every request it chooses a peer for.
+// A peer is ejected, that is, removed from the wrapped list, when either:
+//
+//  - it fails a number of requests in a row, or
+//  - at the end of an interval, its success rate over the interval falls
+//    more than a number of standard deviations below the mean success rate
+//    of all peers that served enough requests.
+//
+// An ejected peer is returned to the wrapped list once its ejection time has
+// passed. The ejection time doubles each time a peer is ejected, up to a
+// maximum, and decays back while the peer stays healthy. To avoid ejecting a
+// whole fleet during a wider outage, no peer is ejected while the ejected
+// fraction of peers is at or above a maximum percentage.
+package outlier
@@ -0,0 +1,331 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package outlier
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/introspection"
+	"go.uber.org/yarpc/pkg/lifecycle"
+)
+
+var _ peer.ChooserList = (*List)(nil)
+
+// peerStats holds the outlier detection state of a peer.
+type peerStats struct {
+	id peer.Identifier
+
+	consecutiveFailures int
+	// successes and failures count requests in the current interval.
+	successes int
+	failures  int
+
+	ejected      bool
+	ejectedUntil time.Time
+	// ejections counts recent ejections, doubling the ejection time.
+	ejections int
+}
+
+// List is a peer list wrapper which ejects outlying peers from the list it
+// wraps.
+type List struct {
+	list peer.ChooserList
+	opts options
+
+	mu    sync.Mutex
+	peers map[string]*peerStats
+
+	once *lifecycle.Once
+	stop chan struct{}
+	done chan struct{}
+}
+
+// New wraps the given peer list with outlier detection.
+func New(list peer.ChooserList, opts ...Option) *List {
+	return &List{
+		list:  list,
+		opts:  newOptions(opts),
+		peers: make(map[string]*peerStats),
+		once:  lifecycle.NewOnce(),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+}
+
+// Update applies the additions and removals to the wrapped list. Ejected
+// peers stay out of the wrapped list until their ejection time has passed.
+func (l *List) Update(updates peer.ListUpdates) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var forward peer.ListUpdates
+	for _, pid := range updates.Removals {
+		stats, ok := l.peers[pid.Identifier()]
+		if ok && stats.ejected {
+			// Already removed from the wrapped list.
+			delete(l.peers, pid.Identifier())
+			continue
+		}
+		delete(l.peers, pid.Identifier())
+		forward.Removals = append(forward.Removals, pid)
+	}
+	for _, pid := range updates.Additions {
+		l.peers[pid.Identifier()] = &peerStats{id: pid}
+		forward.Additions = append(forward.Additions, pid)
+	}
+	return l.list.Update(forward)
+}
+
+// Choose chooses a peer from the wrapped list and observes the result of the
+// request.
+func (l *List) Choose(ctx context.Context, req *transport.Request) (peer.Peer, func(error), error) {
+	p, onFinish, err := l.list.Choose(ctx, req)
+	if err != nil {
+		return p, onFinish, err
+	}
+
+	id := p.Identifier()
+	return p, func(err error) {
+		onFinish(err)
+		l.observe(id, err)
+	}, nil
+}
+
+// observe records the result of a request to the given peer, ejecting the
+// peer if it has failed too many requests in a row.
+func (l *List) observe(id string, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	stats, ok := l.peers[id]
+	if !ok {
+		return
+	}
+	if !l.opts.isFailure(err) {
+		stats.successes++
+		stats.consecutiveFailures = 0
+		return
+	}
+
+	stats.failures++
+	stats.consecutiveFailures++
+	if l.opts.consecutiveFailures > 0 &&
+		stats.consecutiveFailures >= l.opts.consecutiveFailures &&
+		!stats.ejected {
+		if l.eject(stats, l.opts.clock.Now()) {
+			// The request has already finished, so there is no caller to
+			// report a failure to release the peer to.
+			_ = l.list.Update(peer.ListUpdates{Removals: []peer.Identifier{stats.id}})
+		}
+	}
+}
+
+// eject marks the peer as ejected, unless the maximum percentage of peers is
+// already ejected. Returns whether the peer was ejected.
+//
+// Must be called with the lock held.
+func (l *List) eject(stats *peerStats, now time.Time) bool {
+	var ejected int
+	for _, s := range l.peers {
+		if s.ejected {
+			ejected++
+		}
+	}
+	if ejected*100 >= l.opts.maxEjectionPercent*len(l.peers) {
+		return false
+	}
+
+	stats.ejected = true
+	stats.ejections++
+	stats.ejectedUntil = now.Add(l.ejectionTime(stats.ejections))
+	return true
+}
+
+// ejectionTime returns how long a peer is ejected for the given ejection.
+func (l *List) ejectionTime(ejections int) time.Duration {
+	d := l.opts.baseEjectionTime
+	for i := 1; i < ejections; i++ {
+		d *= 2
+		if d >= l.opts.maxEjectionTime {
+			return l.opts.maxEjectionTime
+		}
+	}
+	if d > l.opts.maxEjectionTime {
+		return l.opts.maxEjectionTime
+	}
+	return d
+}
+
+// sweep runs at the end of every interval. It returns peers whose ejection
+// has ended to the wrapped list, ejects peers with outlying success rates,
+// and starts a new interval.
+func (l *List) sweep() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.opts.clock.Now()
+	var forward peer.ListUpdates
+
+	for _, stats := range l.peers {
+		switch {
+		case stats.ejected && !now.Before(stats.ejectedUntil):
+			stats.ejected = false
+			stats.consecutiveFailures = 0
+			forward.Additions = append(forward.Additions, stats.id)
+		case !stats.ejected && stats.ejections > 0:
+			// Healthy peers are gradually forgiven.
+			stats.ejections--
+		}
+	}
+
+	for _, stats := range l.successRateOutliers() {
+		if l.eject(stats, now) {
+			forward.Removals = append(forward.Removals, stats.id)
+		}
+	}
+
+	for _, stats := range l.peers {
+		stats.successes = 0
+		stats.failures = 0
+	}
+
+	return l.list.Update(forward)
+}
+
+// successRateOutliers returns the peers whose success rate in this interval
+// is more than the configured number of standard deviations below the mean.
+//
+// Must be called with the lock held.
+func (l *List) successRateOutliers() []*peerStats {
+	if l.opts.successRateStdevFactor <= 0 {
+		return nil
+	}
+
+	var (
+		candidates []*peerStats
+		rates      []float64
+		sum        float64
+	)
+	for _, stats := range l.peers {
+		total := stats.successes + stats.failures
+		if stats.ejected || total == 0 || total < l.opts.successRateRequestVolume {
+			continue
+		}
+		rate := float64(stats.successes) / float64(total)
+		candidates = append(candidates, stats)
+		rates = append(rates, rate)
+		sum += rate
+	}
+	if len(candidates) == 0 || len(candidates) < l.opts.successRateMinimumHosts {
+		return nil
+	}
+
+	mean := sum / float64(len(rates))
+	var variance float64
+	for _, rate := range rates {
+		variance += (rate - mean) * (rate - mean)
+	}
+	stdev := math.Sqrt(variance / float64(len(rates)))
+	threshold := mean - l.opts.successRateStdevFactor*stdev
+
+	var outliers []*peerStats
+	for i, stats := range candidates {
+		if rates[i] < threshold {
+			outliers = append(outliers, stats)
+		}
+	}
+	return outliers
+}
+
+func (l *List) run() {
+	defer close(l.done)
+	for {
+		select {
+		case <-l.opts.clock.After(l.opts.interval):
+			// Errors are ignored because the wrapped list reports failures to
+			// retain or release peers to the transport.
+			_ = l.sweep()
+		case <-l.stop:
+			return
+		}
+	}
+}
+
+// Start starts the wrapped list and outlier detection.
+func (l *List) Start() error {
+	return l.once.Start(func() error {
+		if err := l.list.Start(); err != nil {
+			return err
+		}
+		go l.run()
+		return nil
+	})
+}
+
+// Stop stops outlier detection and the wrapped list.
+func (l *List) Stop() error {
+	return l.once.Stop(func() error {
+		close(l.stop)
+		<-l.done
+		return l.list.Stop()
+	})
+}
+
+// IsRunning returns whether the list is running.
+func (l *List) IsRunning() bool {
+	return l.once.IsRunning()
+}
+
+// Introspect returns the status of the wrapped list, followed by the peers
+// that are currently ejected from it.
+func (l *List) Introspect() introspection.ChooserStatus {
+	var status introspection.ChooserStatus
+	if ic, ok := l.list.(introspection.IntrospectableChooser); ok {
+		status = ic.Introspect()
+	}
+
+	var ejected []introspection.PeerStatus
+	l.mu.Lock()
+	for id, stats := range l.peers {
+		if stats.ejected {
+			ejected = append(ejected, introspection.PeerStatus{
+				Identifier: id,
+				State:      fmt.Sprintf("Ejected until %s", stats.ejectedUntil.Format(time.RFC3339)),
+			})
+		}
+	}
+	l.mu.Unlock()
+	sort.Slice(ejected, func(i, j int) bool {
+		return ejected[i].Identifier < ejected[j].Identifier
+	})
+
+	status.Name = fmt.Sprintf("outlier(%s)", status.Name)
+	status.State = fmt.Sprintf("%s, %d ejected", status.State, len(ejected))
+	status.Peers = append(status.Peers, ejected...)
+	return status
+}
@@ -0,0 +1,225 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package outlier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/peer/hostport"
+	"go.uber.org/yarpc/peer/roundrobin"
+	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/yarpc/yarpctest"
+)
+
+var errUnavailable = yarpcerrors.UnavailableErrorf("unavailable")
+
+func withClock(c clock.Clock) Option {
+	return func(o *options) {
+		o.clock = c
+	}
+}
+
+func identifiers(ids ...string) []peer.Identifier {
+	pids := make([]peer.Identifier, len(ids))
+	for i, id := range ids {
+		pids[i] = hostport.PeerIdentifier(id)
+	}
+	return pids
+}
+
+func newStartedList(t *testing.T, ids []string, opts ...Option) (*List, *roundrobin.List) {
+	inner := roundrobin.New(yarpctest.NewFakeTransport())
+	l := New(inner, opts...)
+	require.NoError(t, l.Start())
+	require.NoError(t, l.Update(peer.ListUpdates{Additions: identifiers(ids...)}))
+	return l, inner
+}
+
+// call chooses a peer and finishes the request, failing it if the chosen
+// peer is in bad.
+func call(t *testing.T, l *List, bad map[string]bool) string {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	p, onFinish, err := l.Choose(ctx, &transport.Request{})
+	require.NoError(t, err)
+	if bad[p.Identifier()] {
+		onFinish(errUnavailable)
+	} else {
+		onFinish(nil)
+	}
+	return p.Identifier()
+}
+
+func TestConsecutiveFailuresEjection(t *testing.T) {
+	fake := clock.NewFake()
+	l, inner := newStartedList(t, []string{"a:1", "b:1", "c:1"},
+		ConsecutiveFailures(3),
+		MaxEjectionPercent(50),
+		BaseEjectionTime(time.Minute),
+		MaxEjectionTime(3*time.Minute),
+		SuccessRateStdevFactor(0),
+		// Intervals are driven by the test.
+		Interval(time.Hour),
+		withClock(fake),
+	)
+	defer l.Stop()
+
+	bad := map[string]bool{"b:1": true}
+	for i := 0; i < 9; i++ {
+		call(t, l, bad)
+	}
+	assert.Equal(t, 2, inner.NumAvailable(), "b:1 must be ejected")
+	for i := 0; i < 10; i++ {
+		assert.NotEqual(t, "b:1", call(t, l, bad))
+	}
+
+	// The ejection ends after the base ejection time.
+	fake.Add(time.Minute)
+	require.NoError(t, l.sweep())
+	assert.Equal(t, 3, inner.NumAvailable(), "b:1 must return")
+
+	// The second ejection lasts twice as long.
+	for i := 0; i < 9; i++ {
+		call(t, l, bad)
+	}
+	assert.Equal(t, 2, inner.NumAvailable(), "b:1 must be ejected again")
+	fake.Add(time.Minute)
+	require.NoError(t, l.sweep())
+	assert.Equal(t, 2, inner.NumAvailable(), "b:1 must still be ejected")
+	fake.Add(time.Minute)
+	require.NoError(t, l.sweep())
+	assert.Equal(t, 3, inner.NumAvailable(), "b:1 must return")
+}
+
+func TestIntrospect(t *testing.T) {
+	fake := clock.NewFake()
+	l, _ := newStartedList(t, []string{"a:1", "b:1"},
+		ConsecutiveFailures(1),
+		MaxEjectionPercent(50),
+		BaseEjectionTime(time.Minute),
+		SuccessRateStdevFactor(0),
+		Interval(time.Hour),
+		withClock(fake),
+	)
+	defer l.Stop()
+
+	bad := map[string]bool{"b:1": true}
+	for i := 0; i < 2; i++ {
+		call(t, l, bad)
+	}
+
+	status := l.Introspect()
+	assert.Equal(t, "outlier(roundrobin)", status.Name)
+	assert.Equal(t, "Running (1/1 available), 1 ejected", status.State)
+	if assert.Len(t, status.Peers, 2) {
+		assert.Equal(t, "a:1", status.Peers[0].Identifier)
+		assert.Equal(t, "b:1", status.Peers[1].Identifier)
+		assert.Equal(t, "Ejected until "+fake.Now().Add(time.Minute).Format(time.RFC3339), status.Peers[1].State)
+	}
+}
+
+func TestMaxEjectionPercent(t *testing.T) {
+	l, inner := newStartedList(t, []string{"a:1", "b:1", "c:1", "d:1"},
+		ConsecutiveFailures(1),
+		MaxEjectionPercent(25),
+	)
+	defer l.Stop()
+
+	l.observe("a:1", errUnavailable)
+	l.observe("b:1", errUnavailable)
+	assert.Equal(t, 3, inner.NumAvailable(), "only a quarter of the peers may be ejected")
+}
+
+func TestSuccessRateEjection(t *testing.T) {
+	ids := []string{"a:1", "b:1", "c:1", "d:1", "e:1", "f:1"}
+	l, inner := newStartedList(t, ids,
+		ConsecutiveFailures(0),
+		MaxEjectionPercent(50),
+		SuccessRateMinimumHosts(5),
+		SuccessRateRequestVolume(10),
+		SuccessRateStdevFactor(1.9),
+	)
+	defer l.Stop()
+
+	for _, id := range ids {
+		for i := 0; i < 100; i++ {
+			var err error
+			// f:1 fails half of its requests, and a:1 an occasional one.
+			if (id == "f:1" && i%2 == 0) || (id == "a:1" && i%50 == 0) {
+				err = errUnavailable
+			}
+			l.observe(id, err)
+		}
+	}
+	require.NoError(t, l.sweep())
+	assert.Equal(t, 5, inner.NumAvailable(), "f:1 must be ejected")
+
+	// Too few peers served enough requests to compare success rates.
+	for i := 0; i < 100; i++ {
+		l.observe("a:1", errors.New("great sadness"))
+	}
+	require.NoError(t, l.sweep())
+	assert.Equal(t, 5, inner.NumAvailable())
+}
+
+func TestRemovingEjectedPeer(t *testing.T) {
+	fake := clock.NewFake()
+	l, inner := newStartedList(t, []string{"a:1", "b:1"},
+		ConsecutiveFailures(1),
+		MaxEjectionPercent(50),
+		withClock(fake),
+	)
+	defer l.Stop()
+
+	l.observe("b:1", errUnavailable)
+	assert.Equal(t, 1, inner.NumAvailable())
+
+	require.NoError(t, l.Update(peer.ListUpdates{Removals: identifiers("b:1")}))
+	fake.Add(time.Hour)
+	require.NoError(t, l.sweep())
+	assert.Equal(t, 1, inner.NumAvailable(), "removed peers must not return")
+}
+
+func TestIsServerFailure(t *testing.T) {
+	for _, tt := range []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{errors.New("great sadness"), true},
+		{yarpcerrors.UnavailableErrorf("unavailable"), true},
+		{yarpcerrors.DeadlineExceededErrorf("too slow"), true},
+		{yarpcerrors.InvalidArgumentErrorf("bad request"), false},
+		{yarpcerrors.NotFoundErrorf("not found"), false},
+	} {
+		assert.Equal(t, tt.want, isServerFailure(tt.err), fmt.Sprint(tt.err))
+	}
+}
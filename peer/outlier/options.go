@@ -0,0 +1,173 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package outlier
+
+import (
+	"time"
+
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+type options struct {
+	consecutiveFailures int
+	interval            time.Duration
+	baseEjectionTime    time.Duration
+	maxEjectionTime     time.Duration
+	maxEjectionPercent  int
+
+	successRateStdevFactor   float64
+	successRateMinimumHosts  int
+	successRateRequestVolume int
+
+	isFailure func(error) bool
+	clock     clock.Clock
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		consecutiveFailures:      5,
+		interval:                 10 * time.Second,
+		baseEjectionTime:         30 * time.Second,
+		maxEjectionTime:          300 * time.Second,
+		maxEjectionPercent:       10,
+		successRateStdevFactor:   1.9,
+		successRateMinimumHosts:  5,
+		successRateRequestVolume: 100,
+		isFailure:                isServerFailure,
+		clock:                    clock.NewReal(),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Option customizes outlier detection.
+type Option func(*options)
+
+// ConsecutiveFailures specifies the number of failures in a row after which
+// a peer is ejected. Zero disables ejection for consecutive failures.
+//
+// Defaults to 5.
+func ConsecutiveFailures(n int) Option {
+	return func(o *options) {
+		o.consecutiveFailures = n
+	}
+}
+
+// Interval specifies how often success rates are compared and the ejection
+// of ejected peers is reconsidered.
+//
+// Defaults to 10 seconds.
+func Interval(d time.Duration) Option {
+	return func(o *options) {
+		o.interval = d
+	}
+}
+
+// BaseEjectionTime specifies how long a peer is ejected for the first time.
+// Each further ejection doubles the time.
+//
+// Defaults to 30 seconds.
+func BaseEjectionTime(d time.Duration) Option {
+	return func(o *options) {
+		o.baseEjectionTime = d
+	}
+}
+
+// MaxEjectionTime specifies the longest time a peer is ejected for.
+//
+// Defaults to 300 seconds.
+func MaxEjectionTime(d time.Duration) Option {
+	return func(o *options) {
+		o.maxEjectionTime = d
+	}
+}
+
+// MaxEjectionPercent specifies the percentage of peers at or above which no
+// further peers are ejected.
+//
+// Defaults to 10.
+func MaxEjectionPercent(percent int) Option {
+	return func(o *options) {
+		o.maxEjectionPercent = percent
+	}
+}
+
+// SuccessRateStdevFactor specifies how many standard deviations below the
+// mean success rate a peer's success rate must fall for the peer to be
+// ejected. Zero disables success rate ejection.
+//
+// Defaults to 1.9.
+func SuccessRateStdevFactor(factor float64) Option {
+	return func(o *options) {
+		o.successRateStdevFactor = factor
+	}
+}
+
+// SuccessRateMinimumHosts specifies how many peers must have served at least
+// the success rate request volume in an interval for success rates to be
+// compared.
+//
+// Defaults to 5.
+func SuccessRateMinimumHosts(n int) Option {
+	return func(o *options) {
+		o.successRateMinimumHosts = n
+	}
+}
+
+// SuccessRateRequestVolume specifies the number of requests a peer must
+// serve in an interval for its success rate to be considered.
+//
+// Defaults to 100.
+func SuccessRateRequestVolume(n int) Option {
+	return func(o *options) {
+		o.successRateRequestVolume = n
+	}
+}
+
+// IsFailure specifies which request errors count as failures of the peer.
+//
+// Defaults to errors with the codes Unknown, DeadlineExceeded, Internal,
+// Unavailable and DataLoss, which indicate a fault of the server rather than
+// of the request.
+func IsFailure(f func(error) bool) Option {
+	return func(o *options) {
+		o.isFailure = f
+	}
+}
+
+func isServerFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	switch yarpcerrors.FromError(err).Code() {
+	case yarpcerrors.CodeUnknown,
+		yarpcerrors.CodeDeadlineExceeded,
+		yarpcerrors.CodeInternal,
+		yarpcerrors.CodeUnavailable,
+		yarpcerrors.CodeDataLoss:
+		return true
+	default:
+		return false
+	}
+}
@@ -24,6 +24,7 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"sort"
 	"sync"
 	"time"
 
@@ -31,8 +32,10 @@ import (
 	"go.uber.org/multierr"
 	"go.uber.org/yarpc/api/peer"
 	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/clock"
 	"go.uber.org/yarpc/internal/introspection"
 	intyarpcerrors "go.uber.org/yarpc/internal/yarpcerrors"
+	"go.uber.org/yarpc/peer/hostport"
 	"go.uber.org/yarpc/pkg/lifecycle"
 	"go.uber.org/yarpc/yarpcerrors"
 )
@@ -42,14 +45,17 @@ var (
 )
 
 type listOptions struct {
-	capacity  int
-	noShuffle bool
-	seed      int64
+	capacity     int
+	noShuffle    bool
+	seed         int64
+	drainTimeout time.Duration
+	clock        clock.Clock
 }
 
 var defaultListOptions = listOptions{
 	capacity: 10,
 	seed:     time.Now().UnixNano(),
+	clock:    clock.NewReal(),
 }
 
 // ListOption customizes the behavior of a list.
@@ -87,6 +93,19 @@ func Seed(seed int64) ListOption {
 	})
 }
 
+// DrainTimeout specifies how long a removed peer may finish the requests
+// already sent to it. A removed peer is never chosen again, but the list only
+// releases it, allowing the transport to close its connections, once the
+// requests chosen from this list finish or the timeout passes, whichever
+// comes first.
+//
+// Defaults to zero, which releases removed peers immediately.
+func DrainTimeout(timeout time.Duration) ListOption {
+	return listOptionFunc(func(options *listOptions) {
+		options.drainTimeout = timeout
+	})
+}
+
 // New creates a new peer list with an identifier chooser for available peers.
 func New(name string, transport peer.Transport, availableChooser peer.ListImplementation, opts ...ListOption) *List {
 	options := defaultListOptions
@@ -105,6 +124,9 @@ func New(name string, transport peer.Transport, availableChooser peer.ListImplem
 		noShuffle:          options.noShuffle,
 		randSrc:            rand.NewSource(options.seed),
 		peerAvailableEvent: make(chan struct{}, 1),
+		drainTimeout:       options.drainTimeout,
+		clock:              options.clock,
+		draining:           make(map[*peerThunk]struct{}),
 	}
 }
 
@@ -134,6 +156,14 @@ type List struct {
 	noShuffle bool
 	randSrc   rand.Source
 
+	drainTimeout time.Duration
+	clock        clock.Clock
+	// draining holds removed peers that are waiting for their requests to
+	// finish before they are released. It is guarded by drainingLock rather
+	// than the list's lock, because requests finish without holding it.
+	drainingLock sync.Mutex
+	draining     map[*peerThunk]struct{}
+
 	once *lifecycle.Once
 }
 
@@ -184,14 +214,14 @@ func (pl *List) updateInitialized(updates peer.ListUpdates) error {
 func (pl *List) updateUninitialized(updates peer.ListUpdates) error {
 	var errs error
 	for _, peerID := range updates.Removals {
-		if _, ok := pl.uninitializedPeers[peerID.Identifier()]; ok {
-			delete(pl.uninitializedPeers, peerID.Identifier())
+		if _, ok := pl.uninitializedPeers[peerKey(peerID)]; ok {
+			delete(pl.uninitializedPeers, peerKey(peerID))
 		} else {
 			errs = multierr.Append(errs, peer.ErrPeerRemoveNotInList(peerID.Identifier()))
 		}
 	}
 	for _, peerID := range updates.Additions {
-		pl.uninitializedPeers[peerID.Identifier()] = peerID
+		pl.uninitializedPeers[peerKey(peerID)] = peerID
 	}
 
 	return errs
@@ -224,18 +254,18 @@ func (pl *List) addPeer(t *peerThunk) error {
 
 // Must be run in a mutex.Lock()
 func (pl *List) addToUnavailablePeers(t *peerThunk) error {
-	pl.unavailablePeers[t.peer.Identifier()] = t
+	pl.unavailablePeers[peerKey(t.id)] = t
 	return nil
 }
 
 // Must be run in a mutex.Lock()
 func (pl *List) addToAvailablePeers(t *peerThunk) error {
-	if pl.availablePeers[t.peer.Identifier()] != nil {
-		return peer.ErrPeerAddAlreadyInList(t.peer.Identifier())
+	if pl.availablePeers[peerKey(t.id)] != nil {
+		return peer.ErrPeerAddAlreadyInList(t.id.Identifier())
 	}
 	sub := pl.availableChooser.Add(t)
 	t.SetSubscriber(sub)
-	pl.availablePeers[t.Identifier()] = t
+	pl.availablePeers[peerKey(t.id)] = t
 	pl.notifyPeerAvailable()
 	return nil
 }
@@ -261,7 +291,7 @@ func (pl *List) start() error {
 	var errs error
 	for _, pid := range add {
 		errs = multierr.Append(errs, pl.addPeerIdentifier(pid))
-		delete(pl.uninitializedPeers, pid.Identifier())
+		delete(pl.uninitializedPeers, peerKey(pid))
 	}
 
 	pl.shouldRetainPeers.Store(true)
@@ -293,6 +323,18 @@ func (pl *List) stop() error {
 	errs = pl.releaseAll(errs, unavailablePeers)
 	pl.addToUninitialized(unavailablePeers)
 
+	// Removed peers that are still draining are released without waiting
+	// for their requests.
+	pl.drainingLock.Lock()
+	draining := make([]*peerThunk, 0, len(pl.draining))
+	for t := range pl.draining {
+		draining = append(draining, t)
+	}
+	pl.drainingLock.Unlock()
+	for _, t := range draining {
+		errs = multierr.Append(errs, pl.releaseDrained(t))
+	}
+
 	pl.shouldRetainPeers.Store(false)
 
 	return errs
@@ -300,7 +342,7 @@ func (pl *List) stop() error {
 
 func (pl *List) addToUninitialized(thunks []*peerThunk) {
 	for _, t := range thunks {
-		pl.uninitializedPeers[t.id.Identifier()] = t.id
+		pl.uninitializedPeers[peerKey(t.id)] = t.id
 	}
 }
 
@@ -341,7 +383,8 @@ func (pl *List) releaseAll(errs error, peers []*peerThunk) error {
 }
 
 // removePeerIdentifier will go remove references to the peer identifier and release
-// it from the transport
+// it from the transport, after draining its requests if the list has a drain
+// timeout.
 // Must be run in a mutex.Lock()
 func (pl *List) removePeerIdentifier(pid peer.Identifier) error {
 	t, err := pl.removePeerIdentifierReferences(pid)
@@ -350,18 +393,59 @@ func (pl *List) removePeerIdentifier(pid peer.Identifier) error {
 		return err
 	}
 
-	return pl.transport.ReleasePeer(pid, t)
+	if pl.drainTimeout <= 0 {
+		return pl.transport.ReleasePeer(pid, t)
+	}
+	pl.drain(t)
+	return nil
+}
+
+// drain releases the removed peer once the requests chosen for it from this
+// list finish, or once the drain timeout passes.
+//
+// Must be run in a mutex.Lock(), so that no request can be chosen for the
+// peer after it has been removed.
+func (pl *List) drain(t *peerThunk) {
+	pl.drainingLock.Lock()
+	pl.draining[t] = struct{}{}
+	pl.drainingLock.Unlock()
+
+	if !t.startDraining() {
+		// Errors releasing a drained peer have no caller to report to.
+		_ = pl.releaseDrained(t)
+		return
+	}
+	pl.clock.AfterFunc(pl.drainTimeout, func() {
+		_ = pl.releaseDrained(t)
+	})
+}
+
+// releaseDrained releases a removed peer from the transport, unless it has
+// already been released.
+//
+// May be run with or without the list's lock held, since it is also called
+// when a request finishes.
+func (pl *List) releaseDrained(t *peerThunk) error {
+	pl.drainingLock.Lock()
+	_, ok := pl.draining[t]
+	delete(pl.draining, t)
+	pl.drainingLock.Unlock()
+
+	if !ok {
+		return nil
+	}
+	return pl.transport.ReleasePeer(t.id, t)
 }
 
 // removePeerIdentifierReferences will search through the Available and Unavailable Peers
 // for the PeerID and remove it
 // Must be run in a mutex.Lock()
 func (pl *List) removePeerIdentifierReferences(pid peer.Identifier) (*peerThunk, error) {
-	if t := pl.availablePeers[pid.Identifier()]; t != nil {
+	if t := pl.availablePeers[peerKey(pid)]; t != nil {
 		return t, pl.removeFromAvailablePeers(t)
 	}
 
-	if t, ok := pl.unavailablePeers[pid.Identifier()]; ok && t != nil {
+	if t, ok := pl.unavailablePeers[peerKey(pid)]; ok && t != nil {
 		pl.removeFromUnavailablePeers(t)
 		return t, nil
 	}
@@ -373,7 +457,7 @@ func (pl *List) removePeerIdentifierReferences(pid peer.Identifier) (*peerThunk,
 // Peer should already be validated as non-nil and in the Available list.
 // Must be run in a mutex.Lock()
 func (pl *List) removeFromAvailablePeers(t *peerThunk) error {
-	delete(pl.availablePeers, t.peer.Identifier())
+	delete(pl.availablePeers, peerKey(t.id))
 	pl.availableChooser.Remove(t, t.Subscriber())
 	t.SetSubscriber(nil)
 	return nil
@@ -383,7 +467,7 @@ func (pl *List) removeFromAvailablePeers(t *peerThunk) error {
 // Peer should already be validated as non-nil and in the Unavailable list.
 // Must be run in a mutex.Lock()
 func (pl *List) removeFromUnavailablePeers(t *peerThunk) {
-	delete(pl.unavailablePeers, t.peer.Identifier())
+	delete(pl.unavailablePeers, peerKey(t.id))
 }
 
 // Choose selects the next available peer in the peer list
@@ -391,10 +475,18 @@ func (pl *List) Choose(ctx context.Context, req *transport.Request) (peer.Peer,
 	if err := pl.once.WaitUntilRunning(ctx); err != nil {
 		return nil, nil, intyarpcerrors.AnnotateWithInfo(yarpcerrors.FromError(err), "%s peer list is not running", pl.name)
 	}
+	if id, ok := peer.PinnedPeer(ctx); ok {
+		return pl.choosePinned(ctx, id)
+	}
 
 	for {
 		pl.lock.RLock()
 		p := pl.availableChooser.Choose(ctx, req)
+		if p != nil {
+			// The request is counted before the lock is released, so that a
+			// peer removed concurrently drains it.
+			p.(*peerThunk).pending.Inc()
+		}
 		pl.lock.RUnlock()
 
 		if p != nil {
@@ -409,6 +501,35 @@ func (pl *List) Choose(ctx context.Context, req *transport.Request) (peer.Peer,
 	}
 }
 
+// choosePinned returns the peer a request is pinned to, bypassing the
+// implementation, and waits for it to become available if it is not.
+func (pl *List) choosePinned(ctx context.Context, id string) (peer.Peer, func(error), error) {
+	// Peers are retained by their normalized identifiers.
+	id = hostport.Identify(id).Identifier()
+	for {
+		pl.lock.RLock()
+		t, available := pl.availablePeers[id]
+		_, unavailable := pl.unavailablePeers[id]
+		if available {
+			t.pending.Inc()
+		}
+		pl.lock.RUnlock()
+
+		if available {
+			pl.notifyPeerAvailable()
+			t.onStart()
+			return t.peer, t.boundOnFinish, nil
+		}
+		if !unavailable {
+			return nil, nil, yarpcerrors.Newf(yarpcerrors.CodeUnavailable,
+				"%s peer list does not retain peer %q the request is pinned to", pl.name, id)
+		}
+		if err := pl.waitForPeerAddedEvent(ctx); err != nil {
+			return nil, nil, err
+		}
+	}
+}
+
 // IsRunning returns whether the peer list is running.
 func (pl *List) IsRunning() bool {
 	return pl.once.IsRunning()
@@ -459,13 +580,20 @@ func (pl *List) NotifyStatusChanged(pid peer.Identifier) {
 	}
 }
 
+// peerKey returns the key of a peer in the maps of the list. Peers are keyed
+// by their normalized identifiers, so that different spellings of the same
+// address are the same peer.
+func peerKey(pid peer.Identifier) string {
+	return hostport.Identify(pid.Identifier()).Identifier()
+}
+
 // getThunk returns either the available or unavailable peer thunk.
 // Must be called under a lock.
 func (pl *List) getThunk(pid peer.Identifier) *peerThunk {
-	if t := pl.availablePeers[pid.Identifier()]; t != nil {
+	if t := pl.availablePeers[peerKey(pid)]; t != nil {
 		return t
 	}
-	return pl.unavailablePeers[pid.Identifier()]
+	return pl.unavailablePeers[peerKey(pid)]
 }
 
 // notifyStatusChanged gets called by peer thunks
@@ -473,13 +601,13 @@ func (pl *List) notifyStatusChanged(pid peer.Identifier) {
 	pl.lock.Lock()
 	defer pl.lock.Unlock()
 
-	if t := pl.availablePeers[pid.Identifier()]; t != nil {
+	if t := pl.availablePeers[peerKey(pid)]; t != nil {
 		// TODO: log error
 		_ = pl.handleAvailablePeerStatusChange(t)
 		return
 	}
 
-	if t := pl.unavailablePeers[pid.Identifier()]; t != nil {
+	if t := pl.unavailablePeers[peerKey(pid)]; t != nil {
 		// TODO: log error
 		_ = pl.handleUnavailablePeerStatusChange(t)
 	}
@@ -497,7 +625,7 @@ func (pl *List) handleAvailablePeerStatusChange(t *peerThunk) error {
 
 	pl.availableChooser.Remove(t, t.Subscriber())
 	t.SetSubscriber(nil)
-	delete(pl.availablePeers, t.peer.Identifier())
+	delete(pl.availablePeers, peerKey(t.id))
 
 	return pl.addToUnavailablePeers(t)
 
@@ -518,13 +646,34 @@ func (pl *List) handleUnavailablePeerStatusChange(t *peerThunk) error {
 
 // Available returns whether the identifier peer is available for traffic.
 func (pl *List) Available(p peer.Identifier) bool {
-	_, ok := pl.availablePeers[p.Identifier()]
+	_, ok := pl.availablePeers[peerKey(p)]
 	return ok
 }
 
+// Retains returns whether the list retains the peer with the given
+// identifier, whether or not it is available.
+func (pl *List) Retains(p peer.Identifier) bool {
+	pl.lock.RLock()
+	defer pl.lock.RUnlock()
+	return pl.getThunk(p) != nil
+}
+
+// Attributes returns the attributes of the identifier the peer was added
+// with, or nil if the peer is not in the list or has no attributes.
+func (pl *List) Attributes(p peer.Identifier) peer.Attributes {
+	pl.lock.RLock()
+	defer pl.lock.RUnlock()
+
+	t := pl.getThunk(p)
+	if t == nil {
+		return nil
+	}
+	return peer.AttributesOf(t.id)
+}
+
 // Uninitialized returns whether a peer is waiting for the peer list to start.
 func (pl *List) Uninitialized(p peer.Identifier) bool {
-	_, ok := pl.uninitializedPeers[p.Identifier()]
+	_, ok := pl.uninitializedPeers[peerKey(p)]
 	return ok
 }
 
@@ -558,7 +707,8 @@ func (pl *List) NumUninitialized() int {
 	return len(pl.uninitializedPeers)
 }
 
-// Introspect returns a ChooserStatus with a summary of the Peers.
+// Introspect returns a ChooserStatus with a summary of the Peers, sorted by
+// identifier.
 func (pl *List) Introspect() introspection.ChooserStatus {
 	state := "Stopped"
 	if pl.IsRunning() {
@@ -566,41 +716,39 @@ func (pl *List) Introspect() introspection.ChooserStatus {
 	}
 
 	pl.lock.Lock()
-	availables := make([]peer.Peer, 0, len(pl.availablePeers))
+	thunks := make([]*peerThunk, 0, len(pl.availablePeers)+len(pl.unavailablePeers))
 	for _, t := range pl.availablePeers {
-		availables = append(availables, t.peer)
+		thunks = append(thunks, t)
 	}
-	unavailables := make([]peer.Peer, 0, len(pl.unavailablePeers))
 	for _, t := range pl.unavailablePeers {
-		unavailables = append(unavailables, t.peer)
+		thunks = append(thunks, t)
 	}
+	available := len(pl.availablePeers)
 	pl.lock.Unlock()
 
-	peersStatus := make([]introspection.PeerStatus, 0,
-		len(availables)+len(unavailables))
-
-	buildPeerStatus := func(peer peer.Peer) introspection.PeerStatus {
-		ps := peer.Status()
-		return introspection.PeerStatus{
-			Identifier: peer.Identifier(),
+	peersStatus := make([]introspection.PeerStatus, 0, len(thunks))
+	for _, t := range thunks {
+		ps := t.peer.Status()
+		status := introspection.PeerStatus{
+			Identifier: t.peer.Identifier(),
 			State: fmt.Sprintf("%s, %d pending request(s)",
 				ps.ConnectionStatus.String(),
 				ps.PendingRequestCount),
+			ConnectionStatus: ps.ConnectionStatus.String(),
+			PendingRequests:  ps.PendingRequestCount,
 		}
+		if err := t.LastError(); err != nil {
+			status.LastError = err.Error()
+		}
+		peersStatus = append(peersStatus, status)
 	}
-
-	for _, peer := range availables {
-		peersStatus = append(peersStatus, buildPeerStatus(peer))
-	}
-
-	for _, peer := range unavailables {
-		peersStatus = append(peersStatus, buildPeerStatus(peer))
-	}
+	sort.Slice(peersStatus, func(i, j int) bool {
+		return peersStatus[i].Identifier < peersStatus[j].Identifier
+	})
 
 	return introspection.ChooserStatus{
-		Name: "Single",
-		State: fmt.Sprintf("%s (%d/%d available)", state, len(availables),
-			len(availables)+len(unavailables)),
+		Name:  pl.name,
+		State: fmt.Sprintf("%s (%d/%d available)", state, available, len(thunks)),
 		Peers: peersStatus,
 	}
 }
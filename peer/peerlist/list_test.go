@@ -21,12 +21,21 @@
 package peerlist
 
 import (
+	"context"
 	"math/rand"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/internal/testtime"
 	"go.uber.org/yarpc/peer/hostport"
+	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/yarpc/yarpctest"
 )
 
 const (
@@ -77,3 +86,178 @@ func TestShuffle(t *testing.T) {
 		})
 	}
 }
+
+// firstPeer is a list implementation that always chooses the first peer.
+type firstPeer struct {
+	peers []peer.StatusPeer
+}
+
+func (l *firstPeer) Add(p peer.StatusPeer) peer.Subscriber {
+	l.peers = append(l.peers, p)
+	return nil
+}
+
+func (l *firstPeer) Remove(p peer.StatusPeer, _ peer.Subscriber) {
+	for i, candidate := range l.peers {
+		if candidate == p {
+			l.peers = append(l.peers[:i], l.peers[i+1:]...)
+			return
+		}
+	}
+}
+
+func (l *firstPeer) Choose(context.Context, *transport.Request) peer.StatusPeer {
+	if len(l.peers) == 0 {
+		return nil
+	}
+	return l.peers[0]
+}
+
+func (l *firstPeer) Start() error    { return nil }
+func (l *firstPeer) Stop() error     { return nil }
+func (l *firstPeer) IsRunning() bool { return true }
+
+// releaseRecorder is a transport that records the peers it releases.
+type releaseRecorder struct {
+	peer.Transport
+
+	mu       sync.Mutex
+	released []string
+}
+
+func (t *releaseRecorder) ReleasePeer(id peer.Identifier, s peer.Subscriber) error {
+	t.mu.Lock()
+	t.released = append(t.released, id.Identifier())
+	t.mu.Unlock()
+	return t.Transport.ReleasePeer(id, s)
+}
+
+func (t *releaseRecorder) releasedPeers() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]string(nil), t.released...)
+}
+
+func TestDrainTimeout(t *testing.T) {
+	fake := clock.NewFake()
+	trans := &releaseRecorder{Transport: yarpctest.NewFakeTransport()}
+	pl := New("test", trans, &firstPeer{}, DrainTimeout(time.Minute),
+		listOptionFunc(func(o *listOptions) { o.clock = fake }))
+	require.NoError(t, pl.Start())
+
+	choose := func() func(error) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_, onFinish, err := pl.Choose(ctx, &transport.Request{})
+		require.NoError(t, err)
+		return onFinish
+	}
+
+	// A removed peer is released when its last request finishes.
+	require.NoError(t, pl.Update(peer.ListUpdates{Additions: []peer.Identifier{id1}}))
+	onFinish := choose()
+	require.NoError(t, pl.Update(peer.ListUpdates{Removals: []peer.Identifier{id1}}))
+	assert.Empty(t, trans.releasedPeers(), "must not release a peer with requests in flight")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, _, err := pl.Choose(ctx, &transport.Request{})
+	assert.Error(t, err, "must not choose a removed peer")
+
+	onFinish(nil)
+	assert.Equal(t, []string{id1.Identifier()}, trans.releasedPeers())
+
+	// A removed peer is released when the drain timeout passes.
+	require.NoError(t, pl.Update(peer.ListUpdates{Additions: []peer.Identifier{id2}}))
+	onFinish = choose()
+	require.NoError(t, pl.Update(peer.ListUpdates{Removals: []peer.Identifier{id2}}))
+	fake.Add(time.Minute)
+	testtime.WaitFor(t, "the removed peer must be released after the drain timeout", func() bool {
+		return len(trans.releasedPeers()) == 2
+	})
+	assert.Equal(t, []string{id1.Identifier(), id2.Identifier()}, trans.releasedPeers())
+	onFinish(nil)
+	assert.Len(t, trans.releasedPeers(), 2, "must release a peer only once")
+
+	// A removed peer without requests is released immediately.
+	require.NoError(t, pl.Update(peer.ListUpdates{Additions: []peer.Identifier{id3}}))
+	require.NoError(t, pl.Update(peer.ListUpdates{Removals: []peer.Identifier{id3}}))
+	assert.Len(t, trans.releasedPeers(), 3)
+
+	// Stopping the list releases draining peers.
+	require.NoError(t, pl.Update(peer.ListUpdates{Additions: []peer.Identifier{id1}}))
+	onFinish = choose()
+	require.NoError(t, pl.Update(peer.ListUpdates{Removals: []peer.Identifier{id1}}))
+	require.NoError(t, pl.Stop())
+	assert.Len(t, trans.releasedPeers(), 4)
+	onFinish(nil)
+	assert.Len(t, trans.releasedPeers(), 4)
+}
+
+func TestAttributes(t *testing.T) {
+	chooser := &firstPeer{}
+	pl := New("test", yarpctest.NewFakeTransport(), chooser)
+	require.NoError(t, pl.Start())
+	defer pl.Stop()
+
+	attributed := peer.WithAttributes(id1, peer.Attributes{peer.ZoneAttribute: "us-east-1a"})
+	require.NoError(t, pl.Update(peer.ListUpdates{Additions: []peer.Identifier{attributed, id2}}))
+
+	assert.Equal(t, peer.Attributes{peer.ZoneAttribute: "us-east-1a"}, pl.Attributes(id1))
+	assert.Nil(t, pl.Attributes(id2), "peers added without attributes must have none")
+	assert.Nil(t, pl.Attributes(id3), "peers not in the list must have no attributes")
+
+	require.Len(t, chooser.peers, 2)
+	for _, p := range chooser.peers {
+		if p.Identifier() == id1.Identifier() {
+			assert.Equal(t, "us-east-1a", peer.AttributesOf(p).Zone(),
+				"implementations must see the attributes of the peers added to them")
+		}
+	}
+}
+
+func TestChoosePinned(t *testing.T) {
+	pl := New("test", yarpctest.NewFakeTransport(), &firstPeer{})
+	require.NoError(t, pl.Start())
+	defer pl.Stop()
+	require.NoError(t, pl.Update(peer.ListUpdates{Additions: []peer.Identifier{id1, id2}}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	p, onFinish, err := pl.Choose(peer.ContextWithPinnedPeer(ctx, id2.Identifier()), &transport.Request{})
+	require.NoError(t, err)
+	assert.Equal(t, id2.Identifier(), p.Identifier(), "must choose the pinned peer over the implementation")
+	onFinish(nil)
+
+	_, _, err = pl.Choose(peer.ContextWithPinnedPeer(ctx, id3.Identifier()), &transport.Request{})
+	require.Error(t, err)
+	assert.Equal(t, yarpcerrors.CodeUnavailable, yarpcerrors.FromError(err).Code())
+	assert.Contains(t, err.Error(), `does not retain peer "1.1.1.1:1111"`)
+
+	require.NoError(t, pl.Update(peer.ListUpdates{Additions: []peer.Identifier{hostport.Identify("localhost:80")}}))
+	p, onFinish, err = pl.Choose(peer.ContextWithPinnedPeer(ctx, "LOCALHOST:80"), &transport.Request{})
+	require.NoError(t, err, "pinned identifiers must be normalized")
+	assert.Equal(t, "localhost:80", p.Identifier())
+	onFinish(nil)
+}
+
+func TestNormalizedIdentifiers(t *testing.T) {
+	pl := New("test", yarpctest.NewFakeTransport(), &firstPeer{})
+	require.NoError(t, pl.Update(peer.ListUpdates{Additions: []peer.Identifier{
+		hostport.PeerIdentifier("LocalHost:0080"),
+		hostport.PeerIdentifier("localhost:80"),
+	}}))
+	assert.Equal(t, 1, pl.NumUninitialized(), "spellings of the same address must be one peer")
+
+	require.NoError(t, pl.Start())
+	defer pl.Stop()
+	assert.Equal(t, 1, pl.NumAvailable()+pl.NumUnavailable())
+
+	err := pl.Update(peer.ListUpdates{Additions: []peer.Identifier{hostport.PeerIdentifier("LOCALHOST:80")}})
+	assert.Equal(t, peer.ErrPeerAddAlreadyInList("LOCALHOST:80"), err)
+	assert.True(t, pl.Retains(hostport.PeerIdentifier("LOCALHOST:80")))
+
+	require.NoError(t, pl.Update(peer.ListUpdates{Removals: []peer.Identifier{hostport.PeerIdentifier("localhost:80")}}))
+	assert.Equal(t, 0, pl.NumAvailable()+pl.NumUnavailable())
+}
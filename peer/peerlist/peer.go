@@ -23,6 +23,7 @@ package peerlist
 import (
 	"sync"
 
+	"go.uber.org/atomic"
 	"go.uber.org/yarpc/api/peer"
 )
 
@@ -35,20 +36,68 @@ type peerThunk struct {
 	peer          peer.Peer
 	subscriber    peer.Subscriber
 	boundOnFinish func(error)
+	lastError     error
+
+	// pending counts the requests chosen for this peer from the list that
+	// have not finished.
+	pending atomic.Int32
+	// draining is set once the peer is removed from the list, after which
+	// the last request to finish releases the peer.
+	draining bool
 }
 
 func (t *peerThunk) onStart() {
 	t.peer.StartRequest()
 }
 
-func (t *peerThunk) onFinish(error) {
+func (t *peerThunk) onFinish(err error) {
+	if err != nil {
+		t.lock.Lock()
+		t.lastError = err
+		t.lock.Unlock()
+	}
 	t.peer.EndRequest()
+
+	if t.pending.Dec() == 0 && t.isDraining() {
+		// Errors releasing a drained peer have no caller to report to.
+		_ = t.list.releaseDrained(t)
+	}
+}
+
+// startDraining marks the removed peer as draining, and returns whether it
+// has requests left to drain.
+func (t *peerThunk) startDraining() bool {
+	t.lock.Lock()
+	t.draining = true
+	t.lock.Unlock()
+	return t.pending.Load() > 0
+}
+
+func (t *peerThunk) isDraining() bool {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return t.draining
+}
+
+// LastError returns the most recent error a request to the peer finished
+// with, or nil.
+func (t *peerThunk) LastError() error {
+	t.lock.RLock()
+	err := t.lastError
+	t.lock.RUnlock()
+	return err
 }
 
 func (t *peerThunk) Identifier() string {
 	return t.peer.Identifier()
 }
 
+// Attributes returns the attributes of the identifier the peer was added
+// with, so that choosers may consume them.
+func (t *peerThunk) Attributes() peer.Attributes {
+	return peer.AttributesOf(t.id)
+}
+
 func (t *peerThunk) Status() peer.Status {
 	return t.peer.Status()
 }
@@ -63,8 +112,11 @@ func (t *peerThunk) EndRequest() {
 
 // NotifyStatusChanged forwards a status notification to the peer list and to
 // the underlying identifier chooser list.
+//
+// The list looks the peer up by the identifier it was added with, which may
+// differ from the identifier the transport reports.
 func (t *peerThunk) NotifyStatusChanged(pid peer.Identifier) {
-	t.list.notifyStatusChanged(pid)
+	t.list.notifyStatusChanged(t.id)
 
 	s := t.Subscriber()
 	if s != nil {
@@ -22,6 +22,7 @@ package pendingheap
 
 import (
 	"fmt"
+	"time"
 
 	"go.uber.org/yarpc/api/peer"
 	"go.uber.org/yarpc/yarpcconfig"
@@ -31,6 +32,9 @@ import (
 // Configuration descripes how to build a fewest pending heap peer list.
 type Configuration struct {
 	Capacity *int `config:"capacity"`
+	// DrainTimeout is how long a removed peer may finish the requests
+	// already sent to it before it is released.
+	DrainTimeout time.Duration `config:"drain-timeout"`
 }
 
 // Spec returns a configuration specification for the pending heap peer list
@@ -51,20 +55,36 @@ type Configuration struct {
 //            peers:
 //              - 127.0.0.1:8080
 //              - 127.0.0.1:8081
+//
+// Peers removed from the list are released immediately, unless
+// drain-timeout gives their requests in flight time to finish:
+//
+//          fewest-pending-requests:
+//            drain-timeout: 30s
+//            peers:
+//              - 127.0.0.1:8080
 func Spec() yarpcconfig.PeerListSpec {
 	return yarpcconfig.PeerListSpec{
 		Name: "fewest-pending-requests",
 		BuildPeerList: func(cfg Configuration, t peer.Transport, k *yarpcconfig.Kit) (peer.ChooserList, error) {
-			if cfg.Capacity == nil {
-				return New(t), nil
+			var opts []ListOption
+			if cfg.Capacity != nil {
+				if *cfg.Capacity <= 0 {
+					return nil, yarpcerrors.Newf(yarpcerrors.CodeInvalidArgument,
+						fmt.Sprintf("Capacity must be greater than 0. Got: %d.", *cfg.Capacity))
+				}
+				opts = append(opts, Capacity(*cfg.Capacity))
 			}
 
-			if *cfg.Capacity <= 0 {
+			if cfg.DrainTimeout < 0 {
 				return nil, yarpcerrors.Newf(yarpcerrors.CodeInvalidArgument,
-					fmt.Sprintf("Capacity must be greater than 0. Got: %d.", *cfg.Capacity))
+					fmt.Sprintf("DrainTimeout must not be negative. Got: %v.", cfg.DrainTimeout))
+			}
+			if cfg.DrainTimeout > 0 {
+				opts = append(opts, DrainTimeout(cfg.DrainTimeout))
 			}
 
-			return New(t, Capacity(*cfg.Capacity)), nil
+			return New(t, opts...), nil
 		},
 	}
 }
@@ -22,6 +22,7 @@ package pendingheap
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"go.uber.org/yarpc/api/peer"
@@ -60,6 +61,20 @@ func TestPendingHeapConfig(t *testing.T) {
 				Capacity: &twenty,
 			},
 		},
+		{
+			name: "negative drain timeout",
+			cfg: Configuration{
+				DrainTimeout: -time.Second,
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid drain timeout",
+			cfg: Configuration{
+				Capacity:     &twenty,
+				DrainTimeout: time.Second,
+			},
+		},
 	}
 
 	s := Spec()
@@ -21,13 +21,16 @@
 package pendingheap
 
 import (
+	"time"
+
 	"go.uber.org/yarpc/api/peer"
 	"go.uber.org/yarpc/peer/peerlist"
 )
 
 type listConfig struct {
-	capacity int
-	shuffle  bool
+	capacity     int
+	shuffle      bool
+	drainTimeout time.Duration
 }
 
 var defaultListConfig = listConfig{
@@ -48,6 +51,17 @@ func Capacity(capacity int) ListOption {
 	}
 }
 
+// DrainTimeout specifies how long a removed peer may finish the requests
+// already sent to it before it is released. A removed peer is never chosen
+// again.
+//
+// Defaults to zero, which releases removed peers immediately.
+func DrainTimeout(timeout time.Duration) ListOption {
+	return func(c *listConfig) {
+		c.drainTimeout = timeout
+	}
+}
+
 // New creates a new pending heap.
 func New(transport peer.Transport, opts ...ListOption) *List {
 	cfg := defaultListConfig
@@ -61,6 +75,9 @@ func New(transport peer.Transport, opts ...ListOption) *List {
 	if !cfg.shuffle {
 		plOpts = append(plOpts, peerlist.NoShuffle())
 	}
+	if cfg.drainTimeout > 0 {
+		plOpts = append(plOpts, peerlist.DrainTimeout(cfg.drainTimeout))
+	}
 
 	return &List{
 		List: peerlist.New(
@@ -0,0 +1,37 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package rendezvous provides an implementation of a peer list that routes
+// each request to a peer chosen by rendezvous, or highest random weight,
+// hashing of the request's shard key.
+//
+// Every available peer is scored against the request's ShardKey, and the
+// peer with the highest score is chosen. Unlike the hash ring in
+// go.uber.org/yarpc/peer/hashring, there is no ring to maintain and no
+// replicas to tune: every peer is equally likely to win any key, so keys are
+// spread evenly across peers. When a peer is removed or becomes unavailable,
+// only its keys move, each to the peer with the next highest score.
+//
+// Choosing a peer scores every available peer, so this list suits small to
+// medium peer sets. Use go.uber.org/yarpc/peer/maglev for large fleets.
+//
+// Requests without a shard key are spread across the available peers in
+// round-robin order.
+package rendezvous
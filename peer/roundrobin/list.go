@@ -28,9 +28,10 @@ import (
 )
 
 type listConfig struct {
-	capacity int
-	shuffle  bool
-	seed     int64
+	capacity     int
+	shuffle      bool
+	seed         int64
+	drainTimeout time.Duration
 }
 
 var defaultListConfig = listConfig{
@@ -52,6 +53,17 @@ func Capacity(capacity int) ListOption {
 	}
 }
 
+// DrainTimeout specifies how long a removed peer may finish the requests
+// already sent to it before it is released. A removed peer is never chosen
+// again.
+//
+// Defaults to zero, which releases removed peers immediately.
+func DrainTimeout(timeout time.Duration) ListOption {
+	return func(c *listConfig) {
+		c.drainTimeout = timeout
+	}
+}
+
 // New creates a new round robin peer list.
 func New(transport peer.Transport, opts ...ListOption) *List {
 	cfg := defaultListConfig
@@ -66,6 +78,9 @@ func New(transport peer.Transport, opts ...ListOption) *List {
 	if !cfg.shuffle {
 		plOpts = append(plOpts, peerlist.NoShuffle())
 	}
+	if cfg.drainTimeout > 0 {
+		plOpts = append(plOpts, peerlist.DrainTimeout(cfg.drainTimeout))
+	}
 
 	return &List{
 		List: peerlist.New(
@@ -934,7 +934,7 @@ func TestIntrospect(t *testing.T) {
 	}))
 
 	chooserStatus := pl.Introspect()
-	assert.Equal(t, "Single", chooserStatus.Name)
+	assert.Equal(t, "roundrobin", chooserStatus.Name)
 	assert.Equal(t, "Running (2/3 available)", chooserStatus.State)
 
 	peerIdentifierToPeerStatus := make(map[string]introspection.PeerStatus, len(chooserStatus.Peers))
@@ -944,6 +944,36 @@ func TestIntrospect(t *testing.T) {
 	checkPeerStatus(t, peerIdentifierToPeerStatus, "foo", "Unavailable, 0 pending request(s)")
 	checkPeerStatus(t, peerIdentifierToPeerStatus, "bar", "Available, 1 pending request(s)")
 	checkPeerStatus(t, peerIdentifierToPeerStatus, "baz", "Available, 2 pending request(s)")
+
+	baz := peerIdentifierToPeerStatus["baz"]
+	assert.Equal(t, "Available", baz.ConnectionStatus)
+	assert.Equal(t, 2, baz.PendingRequests)
+	assert.Empty(t, baz.LastError)
+}
+
+func TestIntrospectLastError(t *testing.T) {
+	pl := New(testTransport{})
+	assert.NoError(t, pl.Start())
+	assert.NoError(t, pl.Update(peer.ListUpdates{
+		Additions: []peer.Identifier{newTestPeer("foo", 0, peer.Available)},
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	_, onFinish, err := pl.Choose(ctx, nil)
+	assert.NoError(t, err)
+	onFinish(fmt.Errorf("connection reset"))
+
+	_, onFinish, err = pl.Choose(ctx, nil)
+	assert.NoError(t, err)
+	onFinish(nil)
+
+	chooserStatus := pl.Introspect()
+	if assert.Len(t, chooserStatus.Peers, 1) {
+		assert.Equal(t, "connection reset", chooserStatus.Peers[0].LastError,
+			"successful requests must not clear the last error")
+	}
 }
 
 func checkPeerStatus(
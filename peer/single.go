@@ -27,7 +27,9 @@ import (
 	"go.uber.org/yarpc/api/peer"
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/internal/introspection"
+	"go.uber.org/yarpc/peer/hostport"
 	"go.uber.org/yarpc/pkg/lifecycle"
+	"go.uber.org/yarpc/yarpcerrors"
 )
 
 // Single implements the Chooser interface for a single peer
@@ -56,10 +58,20 @@ func (s *Single) Choose(ctx context.Context, _ *transport.Request) (peer.Peer, f
 	if err := s.once.WaitUntilRunning(ctx); err != nil {
 		return nil, nil, err
 	}
+	if id, ok := peer.PinnedPeer(ctx); ok && !samePeer(id, s.pid.Identifier()) {
+		return nil, nil, yarpcerrors.Newf(yarpcerrors.CodeUnavailable,
+			"single peer chooser does not retain peer %q the request is pinned to", id)
+	}
 	s.p.StartRequest()
 	return s.p, s.boundOnFinish, s.err
 }
 
+// samePeer reports whether the identifiers name the same peer once they are
+// normalized.
+func samePeer(a, b string) bool {
+	return hostport.Identify(a).Identifier() == hostport.Identify(b).Identifier()
+}
+
 func (s *Single) onFinish(_ error) {
 	s.p.EndRequest()
 }
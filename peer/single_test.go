@@ -21,12 +21,17 @@
 package peer_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	peerapi "go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/internal/introspection"
 	"go.uber.org/yarpc/peer"
 	"go.uber.org/yarpc/peer/hostport"
+	"go.uber.org/yarpc/yarpcerrors"
 	"go.uber.org/yarpc/yarpctest"
 )
 
@@ -37,3 +42,27 @@ func TestSingleIntrospect(t *testing.T) {
 		Peers: []introspection.PeerStatus{{Identifier: "x", State: "uninitialized"}},
 	}, single.Introspect())
 }
+
+func TestSingleChoosePinned(t *testing.T) {
+	single := peer.NewSingle(hostport.PeerIdentifier("x"), yarpctest.NewFakeTransport())
+	require.NoError(t, single.Start())
+	defer single.Stop()
+
+	p, onFinish, err := single.Choose(peerapi.ContextWithPinnedPeer(context.Background(), "x"), &transport.Request{})
+	require.NoError(t, err)
+	assert.Equal(t, "x", p.Identifier())
+	onFinish(nil)
+
+	single = peer.NewSingle(hostport.PeerIdentifier("localhost:80"), yarpctest.NewFakeTransport())
+	require.NoError(t, single.Start())
+	defer single.Stop()
+
+	p, onFinish, err = single.Choose(peerapi.ContextWithPinnedPeer(context.Background(), "LOCALHOST:80"), &transport.Request{})
+	require.NoError(t, err, "pinned identifiers must be normalized")
+	assert.Equal(t, "localhost:80", p.Identifier())
+	onFinish(nil)
+
+	_, _, err = single.Choose(peerapi.ContextWithPinnedPeer(context.Background(), "y"), &transport.Request{})
+	require.Error(t, err)
+	assert.Equal(t, yarpcerrors.CodeUnavailable, yarpcerrors.FromError(err).Code())
+}
@@ -0,0 +1,140 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package peer
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+
+	"go.uber.org/yarpc/api/peer"
+)
+
+// SnapshotList applies snapshots of the full membership of a peer list to
+// it, so that peer list updaters can send the whole set of peers they know
+// rather than computing additions and removals themselves.
+//
+// The SnapshotList remembers the peers of the last snapshot it applied and
+// sends the peer list only the difference, in a single update. Peers whose
+// attributes changed, like their weight or zone, are removed and added
+// again with their new identifiers. Snapshots that are older than the last
+// applied snapshot are rejected with peer.ErrStaleSnapshot.
+//
+// Each updater should use its own SnapshotList, since the SnapshotList only
+// knows about the peers it sent to the list itself.
+//
+// 	snapshots := peer.NewSnapshotList(pl)
+// 	err := snapshots.Apply(peer.Snapshot{Version: index, Peers: pids})
+type SnapshotList struct {
+	mu sync.Mutex
+
+	list    peer.List
+	version uint64
+	peers   map[string]peer.Identifier
+}
+
+// NewSnapshotList returns a SnapshotList that applies snapshots to the given
+// peer list.
+func NewSnapshotList(pl peer.List) *SnapshotList {
+	return &SnapshotList{
+		list:  pl,
+		peers: make(map[string]peer.Identifier),
+	}
+}
+
+// Apply replaces the peers in the list with the peers of the snapshot.
+//
+// If the list fails to apply some of the changes, the snapshot is still
+// considered applied, and the errors are returned.
+func (s *SnapshotList) Apply(snapshot peer.Snapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if snapshot.Version != 0 && s.version != 0 && snapshot.Version <= s.version {
+		return peer.ErrStaleSnapshot{Version: snapshot.Version, Applied: s.version}
+	}
+
+	peers := make(map[string]peer.Identifier, len(snapshot.Peers))
+	for _, pid := range snapshot.Peers {
+		peers[pid.Identifier()] = pid
+	}
+
+	err := s.update(peers)
+	s.peers = peers
+	s.version = snapshot.Version
+	return err
+}
+
+// Clear removes every peer the SnapshotList added to the list and forgets
+// the version of the last snapshot, as updaters do when they stop.
+func (s *SnapshotList) Clear() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.update(nil)
+	s.peers = make(map[string]peer.Identifier)
+	s.version = 0
+	return err
+}
+
+// Version returns the version of the last applied snapshot.
+func (s *SnapshotList) Version() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.version
+}
+
+// update sends the difference between the applied peers and the given
+// peers to the list.
+//
+// Must be called with the lock held.
+func (s *SnapshotList) update(peers map[string]peer.Identifier) error {
+	var removals, additions []string
+	for id, old := range s.peers {
+		if pid, ok := peers[id]; !ok || !sameAttributes(old, pid) {
+			removals = append(removals, id)
+		}
+	}
+	for id, pid := range peers {
+		if old, ok := s.peers[id]; !ok || !sameAttributes(old, pid) {
+			additions = append(additions, id)
+		}
+	}
+	if len(removals) == 0 && len(additions) == 0 {
+		return nil
+	}
+	sort.Strings(removals)
+	sort.Strings(additions)
+
+	var updates peer.ListUpdates
+	for _, id := range removals {
+		updates.Removals = append(updates.Removals, s.peers[id])
+	}
+	for _, id := range additions {
+		updates.Additions = append(updates.Additions, peers[id])
+	}
+	return s.list.Update(updates)
+}
+
+func sameAttributes(a, b peer.Identifier) bool {
+	return reflect.DeepEqual(peer.AttributesOf(a), peer.AttributesOf(b))
+}
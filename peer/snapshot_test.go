@@ -0,0 +1,83 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package peer_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/peer"
+	. "go.uber.org/yarpc/peer"
+	"go.uber.org/yarpc/peer/hostport"
+)
+
+func TestSnapshotList(t *testing.T) {
+	list := &recordingList{peers: make(map[string]peer.Identifier)}
+	snapshots := NewSnapshotList(list)
+
+	require.NoError(t, snapshots.Apply(peer.Snapshot{Version: 1, Peers: ids("a:1", "b:1")}))
+	assert.Equal(t, []string{"a:1", "b:1"}, list.ids())
+
+	zoned := peer.WithAttributes(hostport.PeerIdentifier("b:1"), peer.Attributes{peer.ZoneAttribute: "us-east-1a"})
+	require.NoError(t, snapshots.Apply(peer.Snapshot{
+		Version: 2,
+		Peers:   []peer.Identifier{zoned, hostport.PeerIdentifier("c:1")},
+	}))
+	assert.Equal(t, []string{"b:1", "c:1"}, list.ids())
+	assert.Equal(t, "us-east-1a", peer.AttributesOf(list.peers["b:1"]).Zone(),
+		"peers whose attributes changed must be added again")
+	assert.Equal(t, uint64(2), snapshots.Version())
+
+	err := snapshots.Apply(peer.Snapshot{Version: 2, Peers: ids("d:1")})
+	assert.Equal(t, peer.ErrStaleSnapshot{Version: 2, Applied: 2}, err)
+	assert.Equal(t, []string{"b:1", "c:1"}, list.ids(), "stale snapshots must not be applied")
+
+	require.NoError(t, snapshots.Apply(peer.Snapshot{Peers: ids("c:1", "d:1")}))
+	assert.Equal(t, []string{"c:1", "d:1"}, list.ids(), "unversioned snapshots must always be applied")
+	require.NoError(t, snapshots.Apply(peer.Snapshot{Version: 1, Peers: ids("d:1")}))
+	assert.Equal(t, []string{"d:1"}, list.ids(), "versions must start over after an unversioned snapshot")
+
+	require.NoError(t, snapshots.Clear())
+	assert.Empty(t, list.ids())
+	assert.Equal(t, uint64(0), snapshots.Version())
+}
+
+func TestSnapshotListUnchanged(t *testing.T) {
+	list := &countingList{recordingList: recordingList{peers: make(map[string]peer.Identifier)}}
+	snapshots := NewSnapshotList(list)
+
+	require.NoError(t, snapshots.Apply(peer.Snapshot{Version: 1, Peers: ids("a:1", "b:1")}))
+	require.NoError(t, snapshots.Apply(peer.Snapshot{Version: 2, Peers: ids("b:1", "a:1")}))
+	assert.Equal(t, 1, list.updates, "snapshots without changes must not update the list")
+}
+
+// countingList counts its updates.
+type countingList struct {
+	recordingList
+
+	updates int
+}
+
+func (l *countingList) Update(updates peer.ListUpdates) error {
+	l.updates++
+	return l.recordingList.Update(updates)
+}
@@ -0,0 +1,40 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package subset provides a peer list wrapper that limits each client to a
+// bounded, deterministic subset of a large peer membership.
+//
+// With huge backend fleets, having every client hold a connection to every
+// backend wastes resources on both sides. A subset list receives the full
+// membership from a peer list updater, but forwards only a subset of it to
+// the peer list it wraps.
+//
+// 	list := subset.New(roundrobin.New(transport), instanceID, 25)
+//
+// Each subset is chosen by rendezvous hashing of the client's identifier with
+// each peer's identifier, so:
+//
+//  - the same client identifier always selects the same subset of a given
+//    membership,
+//  - different client identifiers select different subsets, spreading
+//    clients evenly across the fleet, and
+//  - when peers join or leave, at most one peer of the subset is replaced
+//    for each peer that joins or leaves, so connections are not churned.
+package subset
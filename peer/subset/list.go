@@ -0,0 +1,166 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package subset
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/hrw"
+	"go.uber.org/yarpc/internal/introspection"
+)
+
+var _ peer.ChooserList = (*List)(nil)
+
+// List is a peer list that forwards a deterministic subset of the peers it
+// is given to the peer list it wraps.
+type List struct {
+	list     peer.ChooserList
+	clientID string
+	size     int
+
+	mu sync.Mutex
+	// members holds every peer added to the list.
+	members map[string]peer.Identifier
+	// subset holds the peers forwarded to the wrapped list.
+	subset map[string]peer.Identifier
+}
+
+// New wraps the given peer list so that it receives at most size peers,
+// selected deterministically for the given client identifier. The client
+// identifier should be unique to each client instance, such as a host name
+// or instance ID. A size of zero or less forwards every peer.
+func New(list peer.ChooserList, clientID string, size int) *List {
+	return &List{
+		list:     list,
+		clientID: clientID,
+		size:     size,
+		members:  make(map[string]peer.Identifier),
+		subset:   make(map[string]peer.Identifier),
+	}
+}
+
+// Update applies the additions and removals to the full membership and
+// forwards the resulting changes to the subset to the wrapped list.
+func (l *List) Update(updates peer.ListUpdates) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, pid := range updates.Removals {
+		delete(l.members, pid.Identifier())
+	}
+	for _, pid := range updates.Additions {
+		l.members[pid.Identifier()] = pid
+	}
+
+	subset := l.selectSubset()
+
+	var forward peer.ListUpdates
+	for id, pid := range l.subset {
+		if _, ok := subset[id]; !ok {
+			forward.Removals = append(forward.Removals, pid)
+		}
+	}
+	for id, pid := range subset {
+		if _, ok := l.subset[id]; !ok {
+			forward.Additions = append(forward.Additions, pid)
+		}
+	}
+	l.subset = subset
+
+	return l.list.Update(forward)
+}
+
+// selectSubset returns the members with the highest rendezvous scores for
+// this client.
+//
+// Must be called with the lock held.
+func (l *List) selectSubset() map[string]peer.Identifier {
+	if l.size <= 0 || len(l.members) <= l.size {
+		subset := make(map[string]peer.Identifier, len(l.members))
+		for id, pid := range l.members {
+			subset[id] = pid
+		}
+		return subset
+	}
+
+	type scored struct {
+		id    string
+		score uint64
+	}
+	candidates := make([]scored, 0, len(l.members))
+	for id := range l.members {
+		candidates = append(candidates, scored{id: id, score: hrw.Score(l.clientID, id)})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		return candidates[i].id < candidates[j].id
+	})
+
+	subset := make(map[string]peer.Identifier, l.size)
+	for _, c := range candidates[:l.size] {
+		subset[c.id] = l.members[c.id]
+	}
+	return subset
+}
+
+// Choose chooses a peer from the wrapped list.
+func (l *List) Choose(ctx context.Context, req *transport.Request) (peer.Peer, func(error), error) {
+	return l.list.Choose(ctx, req)
+}
+
+// Start starts the wrapped list.
+func (l *List) Start() error {
+	return l.list.Start()
+}
+
+// Stop stops the wrapped list.
+func (l *List) Stop() error {
+	return l.list.Stop()
+}
+
+// IsRunning returns whether the wrapped list is running.
+func (l *List) IsRunning() bool {
+	return l.list.IsRunning()
+}
+
+// Introspect returns the status of the wrapped list, noting how many of the
+// peers given to this list are in the subset.
+func (l *List) Introspect() introspection.ChooserStatus {
+	var status introspection.ChooserStatus
+	if ic, ok := l.list.(introspection.IntrospectableChooser); ok {
+		status = ic.Introspect()
+	}
+
+	l.mu.Lock()
+	members, subset := len(l.members), len(l.subset)
+	l.mu.Unlock()
+
+	status.Name = fmt.Sprintf("subset(%s)", status.Name)
+	status.State = fmt.Sprintf("%s, subset of %d/%d peers", status.State, subset, members)
+	return status
+}
@@ -0,0 +1,168 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package subset
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/peer/hostport"
+	"go.uber.org/yarpc/peer/roundrobin"
+	"go.uber.org/yarpc/yarpctest"
+)
+
+// recordingList is a peer list that records the peers it holds.
+type recordingList struct {
+	*roundrobin.List
+
+	peers   map[string]bool
+	updates []peer.ListUpdates
+}
+
+func newRecordingList() *recordingList {
+	return &recordingList{
+		List:  roundrobin.New(yarpctest.NewFakeTransport()),
+		peers: make(map[string]bool),
+	}
+}
+
+func (l *recordingList) Update(updates peer.ListUpdates) error {
+	l.updates = append(l.updates, updates)
+	for _, pid := range updates.Removals {
+		delete(l.peers, pid.Identifier())
+	}
+	for _, pid := range updates.Additions {
+		l.peers[pid.Identifier()] = true
+	}
+	return l.List.Update(updates)
+}
+
+func (l *recordingList) ids() []string {
+	var ids []string
+	for id := range l.peers {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func peerIDs(from, to int) []peer.Identifier {
+	var pids []peer.Identifier
+	for i := from; i < to; i++ {
+		pids = append(pids, hostport.PeerIdentifier(fmt.Sprintf("10.0.0.%d:80", i)))
+	}
+	return pids
+}
+
+func TestSubsetIsBoundedAndDeterministic(t *testing.T) {
+	a, b, c := newRecordingList(), newRecordingList(), newRecordingList()
+	require.NoError(t, New(a, "client-1", 5).Update(peer.ListUpdates{Additions: peerIDs(0, 100)}))
+	require.NoError(t, New(b, "client-1", 5).Update(peer.ListUpdates{Additions: peerIDs(0, 100)}))
+	require.NoError(t, New(c, "client-2", 5).Update(peer.ListUpdates{Additions: peerIDs(0, 100)}))
+
+	assert.Len(t, a.ids(), 5)
+	assert.Equal(t, a.ids(), b.ids(), "the same client must select the same subset")
+	assert.NotEqual(t, a.ids(), c.ids(), "different clients should select different subsets")
+}
+
+func TestSubsetSmallMembership(t *testing.T) {
+	inner := newRecordingList()
+	require.NoError(t, New(inner, "client", 5).Update(peer.ListUpdates{Additions: peerIDs(0, 3)}))
+	assert.Len(t, inner.ids(), 3, "every peer must be used when there are fewer than the subset size")
+
+	unbounded := newRecordingList()
+	require.NoError(t, New(unbounded, "client", 0).Update(peer.ListUpdates{Additions: peerIDs(0, 50)}))
+	assert.Len(t, unbounded.ids(), 50)
+}
+
+func TestSubsetStableUnderChurn(t *testing.T) {
+	inner := newRecordingList()
+	list := New(inner, "client", 10)
+	require.NoError(t, list.Update(peer.ListUpdates{Additions: peerIDs(0, 100)}))
+	before := inner.ids()
+
+	// Removing peers outside the subset changes nothing.
+	var outside []peer.Identifier
+	for _, pid := range peerIDs(0, 100) {
+		if !inner.peers[pid.Identifier()] {
+			outside = append(outside, pid)
+		}
+	}
+	require.NoError(t, list.Update(peer.ListUpdates{Removals: outside[:10]}))
+	assert.Equal(t, before, inner.ids())
+
+	// Removing a peer in the subset replaces only that peer.
+	removed := hostport.PeerIdentifier(before[0])
+	require.NoError(t, list.Update(peer.ListUpdates{Removals: []peer.Identifier{removed}}))
+	last := inner.updates[len(inner.updates)-1]
+	assert.Equal(t, []peer.Identifier{removed}, last.Removals)
+	assert.Len(t, last.Additions, 1)
+	assert.Len(t, inner.ids(), 10)
+
+	// Adding it back restores the original subset.
+	require.NoError(t, list.Update(peer.ListUpdates{Additions: []peer.Identifier{removed}}))
+	assert.Equal(t, before, inner.ids())
+}
+
+func TestSubsetSpreadsClients(t *testing.T) {
+	const (
+		numPeers   = 20
+		numClients = 200
+		size       = 5
+	)
+
+	counts := make(map[string]int)
+	for i := 0; i < numClients; i++ {
+		inner := newRecordingList()
+		require.NoError(t, New(inner, fmt.Sprintf("client-%d", i), size).Update(peer.ListUpdates{Additions: peerIDs(0, numPeers)}))
+		for _, id := range inner.ids() {
+			counts[id]++
+		}
+	}
+
+	// Each peer is expected to serve numClients*size/numPeers = 50 clients.
+	assert.Len(t, counts, numPeers, "every peer must be selected by some client")
+	for id, n := range counts {
+		assert.True(t, n > 20 && n < 80, "peer %q selected by %d clients", id, n)
+	}
+}
+
+func TestSubsetIntrospect(t *testing.T) {
+	inner := newRecordingList()
+	require.NoError(t, inner.Start())
+	defer inner.Stop()
+
+	l := New(inner, "client-1", 3)
+	require.NoError(t, l.Update(peer.ListUpdates{Additions: peerIDs(0, 10)}))
+
+	status := l.Introspect()
+	assert.Equal(t, "subset(roundrobin)", status.Name)
+	assert.Equal(t, "Running (3/3 available), subset of 3/10 peers", status.State)
+	var ids []string
+	for _, ps := range status.Peers {
+		ids = append(ids, ps.Identifier)
+	}
+	assert.Equal(t, inner.ids(), ids)
+}
@@ -0,0 +1,163 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tiered
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.uber.org/multierr"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/introspection"
+	"go.uber.org/yarpc/peer/hostport"
+)
+
+var _ peer.Chooser = (*Chooser)(nil)
+
+// Tier is a peer chooser holding the peers of one tier. The lists in
+// go.uber.org/yarpc/peer, such as round-robin, satisfy this interface.
+type Tier interface {
+	peer.Chooser
+
+	// NumAvailable returns the number of peers that can be chosen.
+	NumAvailable() int
+	// NumUnavailable returns the number of peers that are retained but
+	// cannot currently be chosen.
+	NumUnavailable() int
+}
+
+// retainer is implemented by tiers which can tell whether they retain a
+// peer, like the lists in go.uber.org/yarpc/peer.
+type retainer interface {
+	Retains(peer.Identifier) bool
+}
+
+// Chooser is a peer chooser that prefers peers in higher tiers.
+type Chooser struct {
+	tiers []Tier
+}
+
+// New creates a chooser over the given tiers, in order of preference. The
+// chooser takes ownership of the tiers, starting and stopping them with
+// itself.
+func New(tiers ...Tier) *Chooser {
+	if len(tiers) == 0 {
+		panic("tiered.New requires at least one tier")
+	}
+	return &Chooser{tiers: tiers}
+}
+
+// Choose chooses a peer from the first tier that has an available peer.
+//
+// If no tier has an available peer, Choose waits for a peer of the first
+// tier that has any peers at all, or of the first tier if none do.
+//
+// Requests pinned to a peer are sent to the first tier that retains it.
+func (c *Chooser) Choose(ctx context.Context, req *transport.Request) (peer.Peer, func(error), error) {
+	if id, ok := peer.PinnedPeer(ctx); ok {
+		if t, ok := c.pinnedTier(id); ok {
+			return t.Choose(ctx, req)
+		}
+	}
+	return c.tier().Choose(ctx, req)
+}
+
+func (c *Chooser) pinnedTier(id string) (Tier, bool) {
+	pid := hostport.PeerIdentifier(id)
+	for _, t := range c.tiers {
+		if r, ok := t.(retainer); ok && r.Retains(pid) {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+func (c *Chooser) tier() Tier {
+	for _, t := range c.tiers {
+		if t.NumAvailable() > 0 {
+			return t
+		}
+	}
+	for _, t := range c.tiers {
+		if t.NumUnavailable() > 0 {
+			return t
+		}
+	}
+	return c.tiers[0]
+}
+
+// Start starts every tier.
+func (c *Chooser) Start() error {
+	var errs error
+	for _, t := range c.tiers {
+		errs = multierr.Append(errs, t.Start())
+	}
+	return errs
+}
+
+// Stop stops every tier.
+func (c *Chooser) Stop() error {
+	var errs error
+	for _, t := range c.tiers {
+		errs = multierr.Append(errs, t.Stop())
+	}
+	return errs
+}
+
+// IsRunning returns whether every tier is running.
+func (c *Chooser) IsRunning() bool {
+	for _, t := range c.tiers {
+		if !t.IsRunning() {
+			return false
+		}
+	}
+	return true
+}
+
+// Introspect returns the status of each tier, with the peers of every tier
+// in order of preference.
+func (c *Chooser) Introspect() introspection.ChooserStatus {
+	state := "Stopped"
+	if c.IsRunning() {
+		state = "Running"
+	}
+
+	tiers := make([]string, len(c.tiers))
+	var peers []introspection.PeerStatus
+	for i, t := range c.tiers {
+		available := t.NumAvailable()
+		tiers[i] = fmt.Sprintf("tier %d: %d/%d available", i+1, available, available+t.NumUnavailable())
+		if ic, ok := t.(introspection.IntrospectableChooser); ok {
+			for _, ps := range ic.Introspect().Peers {
+				ps.State = fmt.Sprintf("tier %d, %s", i+1, ps.State)
+				peers = append(peers, ps)
+			}
+		}
+	}
+
+	return introspection.ChooserStatus{
+		Name:  "tiered",
+		State: fmt.Sprintf("%s (%s)", state, strings.Join(tiers, "; ")),
+		Peers: peers,
+	}
+}
@@ -0,0 +1,36 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package tiered provides a peer chooser for active/passive topologies. It
+// is composed of ordered tiers of peers, such as primary, secondary and
+// disaster recovery, and sends traffic to a lower tier only while every
+// higher tier has no available peers.
+//
+// Each tier is a peer list of any kind, typically bound to its own peer list
+// updater.
+//
+// 	primary := roundrobin.New(transport)
+// 	secondary := roundrobin.New(transport)
+// 	chooser := tiered.New(primary, secondary)
+//
+// The tier is decided afresh for every request, so traffic falls back to a
+// lower tier as soon as a higher tier loses its last available peer, and
+// returns as soon as a peer in the higher tier becomes available again.
+package tiered
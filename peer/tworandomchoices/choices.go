@@ -0,0 +1,119 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tworandomchoices
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+)
+
+type subscriber struct {
+	peer peer.StatusPeer
+	// index of the subscriber in twoRandomChoices.subs.
+	index int
+}
+
+func (s *subscriber) NotifyStatusChanged(pid peer.Identifier) {}
+
+// twoRandomChoices holds the available peers in a slice so that a random
+// peer can be picked, added or removed in constant time.
+//
+// Add and Remove are called with the peer list's write lock held. Choose is
+// called with only its read lock held, so the random source, which is not
+// safe for concurrent use, has a lock of its own.
+type twoRandomChoices struct {
+	subs []*subscriber
+
+	randMu sync.Mutex
+	rand   *rand.Rand
+}
+
+func newTwoRandomChoices(capacity int, seed int64) *twoRandomChoices {
+	return &twoRandomChoices{
+		subs: make([]*subscriber, 0, capacity),
+		rand: rand.New(rand.NewSource(seed)),
+	}
+}
+
+// Add appends the peer.
+func (c *twoRandomChoices) Add(p peer.StatusPeer) peer.Subscriber {
+	sub := &subscriber{peer: p, index: len(c.subs)}
+	c.subs = append(c.subs, sub)
+	return sub
+}
+
+// Remove swaps the last peer into the removed peer's position.
+func (c *twoRandomChoices) Remove(p peer.StatusPeer, s peer.Subscriber) {
+	sub, ok := s.(*subscriber)
+	if !ok || sub.index >= len(c.subs) || c.subs[sub.index] != sub {
+		// Don't panic.
+		return
+	}
+
+	last := len(c.subs) - 1
+	c.subs[sub.index] = c.subs[last]
+	c.subs[sub.index].index = sub.index
+	c.subs[last] = nil
+	c.subs = c.subs[:last]
+}
+
+// Choose picks two distinct peers at random and returns the one with fewer
+// pending requests, or nil if there are no peers.
+func (c *twoRandomChoices) Choose(_ context.Context, _ *transport.Request) peer.StatusPeer {
+	n := len(c.subs)
+	switch n {
+	case 0:
+		return nil
+	case 1:
+		return c.subs[0].peer
+	}
+
+	c.randMu.Lock()
+	i := c.rand.Intn(n)
+	j := c.rand.Intn(n - 1)
+	c.randMu.Unlock()
+	if j >= i {
+		// Skip i so that the two picks are distinct.
+		j++
+	}
+
+	a, b := c.subs[i].peer, c.subs[j].peer
+	if b.Status().PendingRequestCount < a.Status().PendingRequestCount {
+		return b
+	}
+	return a
+}
+
+func (c *twoRandomChoices) Start() error {
+	return nil
+}
+
+func (c *twoRandomChoices) Stop() error {
+	return nil
+}
+
+func (c *twoRandomChoices) IsRunning() bool {
+	return true
+}
@@ -0,0 +1,123 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tworandomchoices
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/peer/hostport"
+	"go.uber.org/yarpc/yarpctest"
+)
+
+type fakeStatusPeer struct {
+	id      string
+	pending int
+}
+
+func (p *fakeStatusPeer) Identifier() string { return p.id }
+
+func (p *fakeStatusPeer) Status() peer.Status {
+	return peer.Status{
+		ConnectionStatus:    peer.Available,
+		PendingRequestCount: p.pending,
+	}
+}
+
+func (p *fakeStatusPeer) StartRequest() { p.pending++ }
+
+func (p *fakeStatusPeer) EndRequest() { p.pending-- }
+
+func TestTwoRandomChoicesAvoidsLoadedPeer(t *testing.T) {
+	c := newTwoRandomChoices(10, 1)
+	assert.Nil(t, c.Choose(context.Background(), &transport.Request{}))
+
+	busy := &fakeStatusPeer{id: "busy", pending: 10}
+	c.Add(busy)
+	assert.Equal(t, busy, c.Choose(context.Background(), &transport.Request{}),
+		"the only peer must be chosen")
+
+	c.Add(&fakeStatusPeer{id: "a"})
+	c.Add(&fakeStatusPeer{id: "b"})
+
+	counts := make(map[string]int)
+	for i := 0; i < 100; i++ {
+		counts[c.Choose(context.Background(), &transport.Request{}).Identifier()]++
+	}
+	assert.Equal(t, 0, counts["busy"], "the loaded peer is always paired with a less loaded one")
+	assert.Equal(t, 100, counts["a"]+counts["b"])
+	assert.True(t, counts["a"] > 0 && counts["b"] > 0, "both idle peers must be chosen: %v", counts)
+}
+
+func TestTwoRandomChoicesRemove(t *testing.T) {
+	c := newTwoRandomChoices(10, 1)
+
+	peers := make(map[string]*fakeStatusPeer)
+	subs := make(map[string]peer.Subscriber)
+	for _, id := range []string{"a", "b", "c", "d"} {
+		peers[id] = &fakeStatusPeer{id: id}
+		subs[id] = c.Add(peers[id])
+	}
+
+	c.Remove(peers["a"], subs["a"])
+	c.Remove(peers["c"], subs["c"])
+	// Removing a peer twice has no effect.
+	c.Remove(peers["a"], subs["a"])
+
+	require.Len(t, c.subs, 2)
+	for i, sub := range c.subs {
+		assert.Equal(t, i, sub.index)
+	}
+
+	counts := make(map[string]int)
+	for i := 0; i < 20; i++ {
+		counts[c.Choose(context.Background(), &transport.Request{}).Identifier()]++
+	}
+	assert.Equal(t, 20, counts["b"]+counts["d"], "removed peers must not be chosen: %v", counts)
+}
+
+func TestTwoRandomChoicesList(t *testing.T) {
+	pl := New(yarpctest.NewFakeTransport(), Seed(1))
+	require.NoError(t, pl.Start())
+	defer pl.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, _, err := pl.Choose(ctx, &transport.Request{})
+	assert.Error(t, err, "must not choose from an empty list")
+
+	require.NoError(t, pl.Update(peer.ListUpdates{Additions: []peer.Identifier{
+		hostport.PeerIdentifier("a:1"),
+		hostport.PeerIdentifier("b:1"),
+	}}))
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	p, onFinish, err := pl.Choose(ctx, &transport.Request{})
+	require.NoError(t, err)
+	onFinish(nil)
+	assert.Contains(t, []string{"a:1", "b:1"}, p.Identifier())
+}
@@ -0,0 +1,215 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package weightedrandom
+
+import (
+	"context"
+	"sync"
+
+	"go.uber.org/atomic"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/splitmix"
+)
+
+// _golden is the increment of the splitmix64 sequence.
+const _golden = 0x9e3779b97f4a7c15
+
+type subscriber struct {
+	peer   peer.StatusPeer
+	weight int
+}
+
+func (s *subscriber) NotifyStatusChanged(pid peer.Identifier) {}
+
+// aliasTable chooses among available peers in proportion to their weights
+// with Vose's alias method.
+//
+// The peer list calls Add and Remove with its write lock held and Choose
+// with its read lock held. The table is rebuilt by Add and Remove, so Choose
+// only reads it, drawing random numbers from an atomic splitmix64 sequence.
+type aliasTable struct {
+	// weightsMu guards the weights, which are updated before the peer list
+	// takes its lock.
+	weightsMu      sync.Mutex
+	defaultWeight  int
+	configWeights  map[string]int
+	updaterWeights map[string]int
+
+	subs []*subscriber
+	// prob holds the probability of choosing each peer when its column is
+	// drawn, and alias the peer chosen otherwise.
+	prob  []float64
+	alias []int
+
+	state atomic.Uint64
+}
+
+func newAliasTable(cfg listConfig) *aliasTable {
+	t := &aliasTable{
+		defaultWeight:  cfg.defaultWeight,
+		configWeights:  cfg.weights,
+		updaterWeights: make(map[string]int),
+	}
+	t.state.Store(uint64(cfg.seed))
+	return t
+}
+
+// updateWeights records the weights of added WeightedIdentifiers and
+// forgets the weights of removed peers.
+func (t *aliasTable) updateWeights(updates peer.ListUpdates) {
+	t.weightsMu.Lock()
+	defer t.weightsMu.Unlock()
+
+	for _, pid := range updates.Removals {
+		delete(t.updaterWeights, pid.Identifier())
+	}
+	for _, pid := range updates.Additions {
+		if w, ok := peer.AttributesOf(pid).Weight(); ok {
+			t.updaterWeights[pid.Identifier()] = w
+		}
+	}
+}
+
+// weight returns the weight of the peer with the given identifier. Weights
+// less than one are treated as one.
+func (t *aliasTable) weight(id string) int {
+	t.weightsMu.Lock()
+	defer t.weightsMu.Unlock()
+
+	w, ok := t.updaterWeights[id]
+	if !ok {
+		w, ok = t.configWeights[id]
+	}
+	if !ok {
+		w = t.defaultWeight
+	}
+	if w < 1 {
+		w = 1
+	}
+	return w
+}
+
+// Add adds the peer with its weight.
+func (t *aliasTable) Add(p peer.StatusPeer) peer.Subscriber {
+	sub := &subscriber{peer: p, weight: t.weight(p.Identifier())}
+	t.subs = append(t.subs, sub)
+	t.rebuild()
+	return sub
+}
+
+// Remove removes the peer.
+func (t *aliasTable) Remove(p peer.StatusPeer, s peer.Subscriber) {
+	sub, ok := s.(*subscriber)
+	if !ok {
+		// Don't panic.
+		return
+	}
+
+	for i, candidate := range t.subs {
+		if candidate == sub {
+			t.subs = append(t.subs[:i], t.subs[i+1:]...)
+			t.rebuild()
+			return
+		}
+	}
+}
+
+// rebuild builds the alias table for the current peers.
+func (t *aliasTable) rebuild() {
+	n := len(t.subs)
+	prob := make([]float64, n)
+	alias := make([]int, n)
+
+	var total float64
+	for _, sub := range t.subs {
+		total += float64(sub.weight)
+	}
+
+	// Scale the weights so that they average to one, and split the peers
+	// into those below and above the average.
+	scaled := make([]float64, n)
+	var small, large []int
+	for i, sub := range t.subs {
+		scaled[i] = float64(sub.weight) * float64(n) / total
+		if scaled[i] < 1 {
+			small = append(small, i)
+		} else {
+			large = append(large, i)
+		}
+	}
+
+	// Fill each small peer's column with the excess of a large peer.
+	for len(small) > 0 && len(large) > 0 {
+		s := small[len(small)-1]
+		small = small[:len(small)-1]
+		l := large[len(large)-1]
+		large = large[:len(large)-1]
+
+		prob[s] = scaled[s]
+		alias[s] = l
+		scaled[l] += scaled[s] - 1
+		if scaled[l] < 1 {
+			small = append(small, l)
+		} else {
+			large = append(large, l)
+		}
+	}
+	// The remaining peers fill their own columns, up to rounding errors.
+	for _, i := range large {
+		prob[i] = 1
+	}
+	for _, i := range small {
+		prob[i] = 1
+	}
+
+	t.prob = prob
+	t.alias = alias
+}
+
+// Choose returns a random peer in proportion to its weight, or nil if there
+// are no peers.
+func (t *aliasTable) Choose(_ context.Context, _ *transport.Request) peer.StatusPeer {
+	n := len(t.subs)
+	if n == 0 {
+		return nil
+	}
+
+	x := splitmix.Mix(t.state.Add(_golden))
+	// The high bits pick a column and the low bits a side of it.
+	i := int((x >> 32) * uint64(n) >> 32)
+	if float64(uint32(x))/(1<<32) < t.prob[i] {
+		return t.subs[i].peer
+	}
+	return t.subs[t.alias[i]].peer
+}
+
+func (t *aliasTable) Start() error {
+	return nil
+}
+
+func (t *aliasTable) Stop() error {
+	return nil
+}
+
+func (t *aliasTable) IsRunning() bool {
+	return true
+}
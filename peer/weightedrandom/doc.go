@@ -0,0 +1,35 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package weightedrandom provides an implementation of a peer list that
+// chooses peers at random in proportion to their weights.
+//
+// The list samples peers with the alias method, which takes constant time
+// regardless of the number of peers. Unlike round-robin or heap-based
+// lists, choosing a peer does not modify any shared state beyond an atomic
+// counter, so the list scales to very large peer sets and many concurrent
+// callers without lock contention.
+//
+// Weights may be given in configuration, with the PeerWeight option, or by a
+// peer list updater that adds identifiers implementing
+// weightedroundrobin.WeightedIdentifier or carrying a weight attribute.
+// Peers without a weight use the default weight, which is 1 unless changed
+// with the DefaultWeight option.
+package weightedrandom
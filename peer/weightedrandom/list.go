@@ -0,0 +1,115 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package weightedrandom
+
+import (
+	"time"
+
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/peer/peerlist"
+)
+
+type listConfig struct {
+	capacity      int
+	defaultWeight int
+	weights       map[string]int
+	seed          int64
+}
+
+// ListOption customizes the behavior of a weighted random list.
+type ListOption func(*listConfig)
+
+// Capacity specifies the default capacity of the underlying
+// data structures for this list.
+//
+// Defaults to 10.
+func Capacity(capacity int) ListOption {
+	return func(c *listConfig) {
+		c.capacity = capacity
+	}
+}
+
+// DefaultWeight specifies the weight of peers that were not given one.
+//
+// Defaults to 1.
+func DefaultWeight(weight int) ListOption {
+	return func(c *listConfig) {
+		c.defaultWeight = weight
+	}
+}
+
+// PeerWeight specifies the weight of the peer with the given identifier.
+// Weights provided by a peer list updater through
+// weightedroundrobin.WeightedIdentifier take precedence over this option.
+func PeerWeight(id string, weight int) ListOption {
+	return func(c *listConfig) {
+		c.weights[id] = weight
+	}
+}
+
+// Seed specifies the seed of the random sequence used to choose peers.
+//
+// Defaults to the time the list is created.
+func Seed(seed int64) ListOption {
+	return func(c *listConfig) {
+		c.seed = seed
+	}
+}
+
+// New creates a new weighted random peer list.
+func New(transport peer.Transport, opts ...ListOption) *List {
+	cfg := listConfig{
+		capacity:      10,
+		defaultWeight: 1,
+		weights:       make(map[string]int),
+		seed:          time.Now().UnixNano(),
+	}
+	for _, o := range opts {
+		o(&cfg)
+	}
+
+	table := newAliasTable(cfg)
+	return &List{
+		List: peerlist.New(
+			"weighted-random",
+			transport,
+			table,
+			peerlist.Capacity(cfg.capacity),
+		),
+		table: table,
+	}
+}
+
+// List is a PeerList which chooses peers at random in proportion to their
+// weights.
+type List struct {
+	*peerlist.List
+
+	table *aliasTable
+}
+
+// Update applies the additions and removals of peer Identifiers to the list.
+// Additions which implement weightedroundrobin.WeightedIdentifier set the
+// weight of their peer.
+func (l *List) Update(updates peer.ListUpdates) error {
+	l.table.updateWeights(updates)
+	return l.List.Update(updates)
+}
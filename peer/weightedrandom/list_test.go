@@ -0,0 +1,117 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package weightedrandom
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/peer/hostport"
+	"go.uber.org/yarpc/peer/weightedroundrobin"
+	"go.uber.org/yarpc/yarpctest"
+)
+
+// choose counts the identifiers of the next n chosen peers.
+func choose(t *testing.T, pl *List, n int) map[string]int {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	counts := make(map[string]int)
+	for i := 0; i < n; i++ {
+		p, onFinish, err := pl.Choose(ctx, &transport.Request{})
+		require.NoError(t, err)
+		onFinish(nil)
+		counts[p.Identifier()]++
+	}
+	return counts
+}
+
+func TestWeightedRandom(t *testing.T) {
+	pl := New(yarpctest.NewFakeTransport(), Seed(1), PeerWeight("a:1", 6), PeerWeight("b:1", 3))
+	require.NoError(t, pl.Start())
+	defer pl.Stop()
+
+	require.NoError(t, pl.Update(peer.ListUpdates{Additions: []peer.Identifier{
+		hostport.PeerIdentifier("a:1"),
+		hostport.PeerIdentifier("b:1"),
+		weightedroundrobin.Weighted(hostport.PeerIdentifier("c:1"), 1),
+	}}))
+
+	counts := choose(t, pl, 10000)
+	assert.InDelta(t, 6000, counts["a:1"], 300)
+	assert.InDelta(t, 3000, counts["b:1"], 300)
+	assert.InDelta(t, 1000, counts["c:1"], 300)
+
+	require.NoError(t, pl.Update(peer.ListUpdates{Removals: []peer.Identifier{
+		hostport.PeerIdentifier("a:1"),
+	}}))
+	counts = choose(t, pl, 1000)
+	assert.Equal(t, 0, counts["a:1"], "removed peers must not be chosen")
+	assert.InDelta(t, 750, counts["b:1"], 100)
+}
+
+func TestAliasTable(t *testing.T) {
+	weights := map[string]int{"a:1": 1, "b:1": 2, "c:1": 3, "d:1": 10, "e:1": 4}
+
+	var opts []ListOption
+	for id, w := range weights {
+		opts = append(opts, PeerWeight(id, w))
+	}
+	pl := New(yarpctest.NewFakeTransport(), opts...)
+	require.NoError(t, pl.Start())
+	defer pl.Stop()
+
+	var ids []peer.Identifier
+	for id := range weights {
+		ids = append(ids, hostport.PeerIdentifier(id))
+	}
+	require.NoError(t, pl.Update(peer.ListUpdates{Additions: ids}))
+
+	// Every column is drawn with the same probability, and splits it between
+	// its own peer and its alias. The shares must add up to the weights.
+	table := pl.table
+	n := float64(len(table.subs))
+	shares := make(map[string]float64)
+	for i, sub := range table.subs {
+		shares[sub.peer.Identifier()] += table.prob[i] / n
+		shares[table.subs[table.alias[i]].peer.Identifier()] += (1 - table.prob[i]) / n
+	}
+	for id, w := range weights {
+		assert.InDelta(t, float64(w)/20, shares[id], 1e-9, "share of peer %q", id)
+	}
+}
+
+func TestWeightedRandomEmpty(t *testing.T) {
+	pl := New(yarpctest.NewFakeTransport())
+	require.NoError(t, pl.Start())
+	defer pl.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, _, err := pl.Choose(ctx, &transport.Request{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package weightedroundrobin
+
+import (
+	"time"
+
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/yarpcconfig"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// Configuration describes how to build a weighted round-robin peer list.
+type Configuration struct {
+	Capacity      *int           `config:"capacity"`
+	DefaultWeight *int           `config:"default-weight"`
+	Weights       map[string]int `config:"weights"`
+	SlowStart     time.Duration  `config:"slow-start"`
+}
+
+// Spec returns a configuration specification for the weighted round-robin
+// peer list implementation, making it possible to send traffic to peers in
+// proportion to their weights with transports that use outbound peer list
+// configuration (like HTTP).
+//
+//  cfg := yarpcconfig.New()
+//  cfg.MustRegisterPeerList(weightedroundrobin.Spec())
+//
+// This enables the weighted round-robin peer list:
+//
+//  outbounds:
+//    otherservice:
+//      unary:
+//        http:
+//          url: https://host:port/rpc
+//          weighted-round-robin:
+//            slow-start: 30s
+//            weights:
+//              127.0.0.1:8080: 4
+//            peers:
+//              - 127.0.0.1:8080
+//              - 127.0.0.1:8081
+func Spec() yarpcconfig.PeerListSpec {
+	return yarpcconfig.PeerListSpec{
+		Name: "weighted-round-robin",
+		BuildPeerList: func(cfg Configuration, t peer.Transport, k *yarpcconfig.Kit) (peer.ChooserList, error) {
+			var opts []ListOption
+
+			if cfg.Capacity != nil {
+				if *cfg.Capacity <= 0 {
+					return nil, yarpcerrors.InvalidArgumentErrorf(
+						"Capacity must be greater than 0. Got: %d.", *cfg.Capacity)
+				}
+				opts = append(opts, Capacity(*cfg.Capacity))
+			}
+
+			if cfg.DefaultWeight != nil {
+				if *cfg.DefaultWeight <= 0 {
+					return nil, yarpcerrors.InvalidArgumentErrorf(
+						"DefaultWeight must be greater than 0. Got: %d.", *cfg.DefaultWeight)
+				}
+				opts = append(opts, DefaultWeight(*cfg.DefaultWeight))
+			}
+
+			for id, weight := range cfg.Weights {
+				if weight <= 0 {
+					return nil, yarpcerrors.InvalidArgumentErrorf(
+						"weight of peer %q must be greater than 0. Got: %d.", id, weight)
+				}
+				opts = append(opts, PeerWeight(id, weight))
+			}
+
+			if cfg.SlowStart < 0 {
+				return nil, yarpcerrors.InvalidArgumentErrorf(
+					"SlowStart must not be negative. Got: %v.", cfg.SlowStart)
+			}
+			if cfg.SlowStart > 0 {
+				opts = append(opts, SlowStart(cfg.SlowStart))
+			}
+
+			return New(t, opts...), nil
+		},
+	}
+}
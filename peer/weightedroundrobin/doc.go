@@ -0,0 +1,43 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package weightedroundrobin provides an implementation of a peer list that
+// sends traffic to each peer in proportion to its weight, using smooth
+// weighted round-robin.
+//
+// Smooth weighted round-robin interleaves peers rather than sending a burst
+// of requests to the heaviest peer. With weights 5, 1 and 1 for peers a, b
+// and c, every seven requests are sent in the order a, a, b, a, c, a, a.
+//
+// Weights may be given in configuration, with the PeerWeight option, or by a
+// peer list updater that adds identifiers implementing WeightedIdentifier or
+// carrying a weight attribute (see peer.WithAttributes in
+// go.uber.org/yarpc/api/peer). Peers without a weight use the default weight,
+// which is 1 unless changed with the DefaultWeight option.
+//
+// Operators may override the weight of a single peer at runtime with
+// List.SetWeight, for example to drain an instance with a weight of zero or
+// to send a canary a small share of traffic. The admin.SetPeerWeight action
+// in go.uber.org/yarpc/x/admin exposes this over HTTP.
+//
+// With the SlowStart option, peers that are added or become available again
+// start with a fraction of their weight, which ramps up to the full weight
+// over a window, so that new instances are warmed up gradually.
+package weightedroundrobin
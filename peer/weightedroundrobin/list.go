@@ -0,0 +1,176 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package weightedroundrobin
+
+import (
+	"time"
+
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/internal/introspection"
+	"go.uber.org/yarpc/peer/peerlist"
+)
+
+type listConfig struct {
+	capacity      int
+	defaultWeight int
+	weights       map[string]int
+	slowStart     time.Duration
+	clock         clock.Clock
+}
+
+// ListOption customizes the behavior of a weighted round-robin list.
+type ListOption func(*listConfig)
+
+// Capacity specifies the default capacity of the underlying
+// data structures for this list.
+//
+// Defaults to 10.
+func Capacity(capacity int) ListOption {
+	return func(c *listConfig) {
+		c.capacity = capacity
+	}
+}
+
+// DefaultWeight specifies the weight of peers that were not given one.
+//
+// Defaults to 1.
+func DefaultWeight(weight int) ListOption {
+	return func(c *listConfig) {
+		c.defaultWeight = weight
+	}
+}
+
+// PeerWeight specifies the weight of the peer with the given identifier.
+// Weights provided by a peer list updater through WeightedIdentifier take
+// precedence over this option.
+func PeerWeight(id string, weight int) ListOption {
+	return func(c *listConfig) {
+		c.weights[id] = weight
+	}
+}
+
+// SlowStart specifies a window over which the share of traffic sent to a
+// peer ramps up, after the peer is added or becomes available again. The
+// peer's effective weight grows linearly from a tenth of its weight to its
+// full weight over the window, so that new instances with cold caches are not
+// immediately given their full share.
+//
+// Defaults to zero, which gives peers their full weight immediately.
+func SlowStart(window time.Duration) ListOption {
+	return func(c *listConfig) {
+		c.slowStart = window
+	}
+}
+
+// WeightedIdentifier is a peer identifier that carries the weight of the
+// peer. Peer list updaters may add WeightedIdentifiers to a weighted
+// round-robin list to set the weight of each peer. To change the weight of a
+// peer, remove and add it again in the same update.
+type WeightedIdentifier interface {
+	peer.Identifier
+
+	Weight() int
+}
+
+// Weighted attaches a weight to a peer identifier.
+func Weighted(id peer.Identifier, weight int) WeightedIdentifier {
+	return weightedIdentifier{id: id, weight: weight}
+}
+
+type weightedIdentifier struct {
+	id peer.Identifier
+
+	weight int
+}
+
+func (i weightedIdentifier) Identifier() string { return i.id.Identifier() }
+func (i weightedIdentifier) Weight() int        { return i.weight }
+
+// New creates a new weighted round-robin peer list.
+func New(transport peer.Transport, opts ...ListOption) *List {
+	cfg := listConfig{
+		capacity:      10,
+		defaultWeight: 1,
+		weights:       make(map[string]int),
+		clock:         clock.NewReal(),
+	}
+	for _, o := range opts {
+		o(&cfg)
+	}
+
+	wrr := newWeightedRing(cfg)
+	return &List{
+		List: peerlist.New(
+			"weighted-round-robin",
+			transport,
+			wrr,
+			peerlist.Capacity(cfg.capacity),
+		),
+		wrr: wrr,
+	}
+}
+
+// List is a PeerList which chooses peers in proportion to their weights.
+type List struct {
+	*peerlist.List
+
+	wrr *weightedRing
+}
+
+// SetWeight changes the weight of the peer with the given identifier at
+// runtime, so that operators can drain or canary a single instance without
+// changing the peers provided by discovery. A weight of zero drains the peer:
+// it remains in the list but receives no requests.
+//
+// The weight takes precedence over weights from options and from
+// WeightedIdentifiers, and is kept if the peer is removed and added again,
+// until it is reset with ResetWeight.
+func (l *List) SetWeight(id string, weight int) {
+	l.wrr.setWeight(id, weight)
+}
+
+// ResetWeight removes a weight set with SetWeight, returning the peer with
+// the given identifier to the weight it would otherwise have.
+func (l *List) ResetWeight(id string) {
+	l.wrr.resetWeight(id)
+}
+
+// Introspect returns a ChooserStatus with a summary of the peers, including
+// their weights.
+func (l *List) Introspect() introspection.ChooserStatus {
+	status := l.List.Introspect()
+	for i := range status.Peers {
+		weight := l.wrr.peerWeight(status.Peers[i].Identifier)
+		status.Peers[i].Weight = weight
+		if weight == 0 {
+			status.Peers[i].State += ", drained"
+		}
+	}
+	return status
+}
+
+// Update applies the additions and removals of peer Identifiers to the list.
+// Additions which implement WeightedIdentifier set the weight of their peer.
+func (l *List) Update(updates peer.ListUpdates) error {
+	l.wrr.updateWeights(updates)
+	return l.List.Update(updates)
+}
@@ -0,0 +1,203 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package weightedroundrobin
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/peer/hostport"
+	"go.uber.org/yarpc/yarpctest"
+)
+
+// choose returns the identifiers of the next n chosen peers.
+func choose(t *testing.T, pl *List, n int) []string {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	ids := make([]string, n)
+	for i := range ids {
+		p, onFinish, err := pl.Choose(ctx, &transport.Request{})
+		require.NoError(t, err)
+		onFinish(nil)
+		ids[i] = p.Identifier()
+	}
+	return ids
+}
+
+func count(ids []string) map[string]int {
+	counts := make(map[string]int)
+	for _, id := range ids {
+		counts[id]++
+	}
+	return counts
+}
+
+func TestWeightedRoundRobin(t *testing.T) {
+	pl := New(yarpctest.NewFakeTransport(), PeerWeight("a:1", 5))
+	require.NoError(t, pl.Start())
+	defer pl.Stop()
+
+	require.NoError(t, pl.Update(peer.ListUpdates{Additions: []peer.Identifier{
+		hostport.PeerIdentifier("a:1"),
+		hostport.PeerIdentifier("b:1"),
+		hostport.PeerIdentifier("c:1"),
+	}}))
+
+	for i := 0; i < 3; i++ {
+		ids := choose(t, pl, 7)
+		assert.Equal(t, map[string]int{"a:1": 5, "b:1": 1, "c:1": 1}, count(ids))
+
+		// Smooth weighted round-robin never sends more than two requests in
+		// a row to the heaviest peer with these weights.
+		var run int
+		for _, id := range ids {
+			if id == "a:1" {
+				run++
+			} else {
+				run = 0
+			}
+			assert.True(t, run <= 2, "peer a:1 chosen %d times in a row: %v", run, ids)
+		}
+	}
+}
+
+func TestWeightedRoundRobinUpdaterWeights(t *testing.T) {
+	pl := New(yarpctest.NewFakeTransport(), DefaultWeight(2), PeerWeight("a:1", 5))
+	require.NoError(t, pl.Start())
+	defer pl.Stop()
+
+	require.NoError(t, pl.Update(peer.ListUpdates{Additions: []peer.Identifier{
+		Weighted(hostport.PeerIdentifier("a:1"), 1),
+		hostport.PeerIdentifier("b:1"),
+		Weighted(hostport.PeerIdentifier("c:1"), 3),
+	}}))
+	assert.Equal(t, map[string]int{"a:1": 1, "b:1": 2, "c:1": 3}, count(choose(t, pl, 6)),
+		"updater weights must take precedence over configured weights")
+
+	require.NoError(t, pl.Update(peer.ListUpdates{
+		Removals:  []peer.Identifier{hostport.PeerIdentifier("a:1"), hostport.PeerIdentifier("c:1")},
+		Additions: []peer.Identifier{hostport.PeerIdentifier("a:1"), Weighted(hostport.PeerIdentifier("c:1"), 1)},
+	}))
+	assert.Equal(t, map[string]int{"a:1": 5, "b:1": 2, "c:1": 1}, count(choose(t, pl, 8)),
+		"removed peers must fall back to configured weights")
+}
+
+func TestWeightedRoundRobinSetWeight(t *testing.T) {
+	pl := New(yarpctest.NewFakeTransport(), PeerWeight("a:1", 2))
+	require.NoError(t, pl.Start())
+	defer pl.Stop()
+
+	require.NoError(t, pl.Update(peer.ListUpdates{Additions: []peer.Identifier{
+		Weighted(hostport.PeerIdentifier("a:1"), 3),
+		hostport.PeerIdentifier("b:1"),
+	}}))
+	assert.Equal(t, map[string]int{"a:1": 3, "b:1": 1}, count(choose(t, pl, 4)))
+
+	pl.SetWeight("b:1", 5)
+	assert.Equal(t, map[string]int{"a:1": 3, "b:1": 5}, count(choose(t, pl, 8)),
+		"set weights must take effect immediately")
+
+	pl.SetWeight("a:1", 0)
+	assert.Equal(t, map[string]int{"b:1": 5}, count(choose(t, pl, 5)),
+		"peers with a weight of zero must be drained")
+
+	require.NoError(t, pl.Update(peer.ListUpdates{
+		Removals:  []peer.Identifier{hostport.PeerIdentifier("a:1")},
+		Additions: []peer.Identifier{Weighted(hostport.PeerIdentifier("a:1"), 3)},
+	}))
+	assert.Equal(t, map[string]int{"b:1": 5}, count(choose(t, pl, 5)),
+		"set weights must survive the peer being added again")
+
+	pl.ResetWeight("a:1")
+	pl.ResetWeight("b:1")
+	assert.Equal(t, map[string]int{"a:1": 3, "b:1": 1}, count(choose(t, pl, 4)),
+		"reset weights must fall back to updater weights")
+
+	pl.SetWeight("a:1", 0)
+	pl.SetWeight("b:1", 0)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, _, err := pl.Choose(ctx, &transport.Request{})
+	assert.Error(t, err, "must not choose a peer when every peer is drained")
+}
+
+func TestWeightedRoundRobinIntrospect(t *testing.T) {
+	pl := New(yarpctest.NewFakeTransport(), PeerWeight("a:1", 5))
+	require.NoError(t, pl.Start())
+	defer pl.Stop()
+
+	require.NoError(t, pl.Update(peer.ListUpdates{Additions: []peer.Identifier{
+		hostport.PeerIdentifier("a:1"),
+		hostport.PeerIdentifier("b:1"),
+	}}))
+	pl.SetWeight("b:1", 0)
+
+	status := pl.Introspect()
+	assert.Equal(t, "weighted-round-robin", status.Name)
+	require.Len(t, status.Peers, 2)
+	assert.Equal(t, 5, status.Peers[0].Weight)
+	assert.Equal(t, "Available, 0 pending request(s)", status.Peers[0].State)
+	assert.Equal(t, 0, status.Peers[1].Weight)
+	assert.Equal(t, "Available, 0 pending request(s), drained", status.Peers[1].State)
+}
+
+func TestWeightedRoundRobinEmpty(t *testing.T) {
+	pl := New(yarpctest.NewFakeTransport())
+	require.NoError(t, pl.Start())
+	defer pl.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, _, err := pl.Choose(ctx, &transport.Request{})
+	assert.Error(t, err)
+}
+
+func TestWeightedRoundRobinSlowStart(t *testing.T) {
+	fake := clock.NewFake()
+	pl := New(yarpctest.NewFakeTransport(), SlowStart(10*time.Second), func(c *listConfig) {
+		c.clock = fake
+	})
+	require.NoError(t, pl.Start())
+	defer pl.Stop()
+
+	require.NoError(t, pl.Update(peer.ListUpdates{Additions: []peer.Identifier{hostport.PeerIdentifier("old:1")}}))
+	fake.Add(time.Minute)
+	require.NoError(t, pl.Update(peer.ListUpdates{Additions: []peer.Identifier{hostport.PeerIdentifier("new:1")}}))
+
+	// The new peer starts with a tenth of its weight.
+	assert.Equal(t, map[string]int{"old:1": 10, "new:1": 1}, count(choose(t, pl, 11)))
+
+	// Halfway through the window, it has half of its weight.
+	fake.Add(5 * time.Second)
+	assert.Equal(t, map[string]int{"old:1": 2, "new:1": 1}, count(choose(t, pl, 3)))
+
+	// After the window, it has its full weight.
+	fake.Add(5 * time.Second)
+	assert.Equal(t, map[string]int{"old:1": 2, "new:1": 2}, count(choose(t, pl, 4)))
+}
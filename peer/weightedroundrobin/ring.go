@@ -0,0 +1,264 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package weightedroundrobin
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/clock"
+)
+
+// _slowStartMinFraction is the fraction of its weight a peer starts with
+// when slow start is enabled.
+const _slowStartMinFraction = 0.1
+
+type subscriber struct {
+	peer peer.StatusPeer
+
+	weight        int
+	currentWeight float64
+	// addedAt is when the peer was added to the ring, either because it was
+	// added to the list or because it became available again.
+	addedAt time.Time
+}
+
+func (s *subscriber) NotifyStatusChanged(pid peer.Identifier) {}
+
+// weightedRing chooses among available peers with smooth weighted
+// round-robin.
+//
+// The peer list calls Choose with only its read lock held, so weightedRing
+// guards its own state.
+type weightedRing struct {
+	mu sync.Mutex
+
+	subs []*subscriber
+
+	defaultWeight   int
+	configWeights   map[string]int
+	updaterWeights  map[string]int
+	overrideWeights map[string]int
+
+	slowStart time.Duration
+	clock     clock.Clock
+}
+
+func newWeightedRing(cfg listConfig) *weightedRing {
+	return &weightedRing{
+		defaultWeight:   cfg.defaultWeight,
+		configWeights:   cfg.weights,
+		updaterWeights:  make(map[string]int),
+		overrideWeights: make(map[string]int),
+		slowStart:       cfg.slowStart,
+		clock:           cfg.clock,
+	}
+}
+
+// updateWeights records the weights of added WeightedIdentifiers and
+// forgets the weights of removed peers.
+func (r *weightedRing) updateWeights(updates peer.ListUpdates) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, pid := range updates.Removals {
+		delete(r.updaterWeights, pid.Identifier())
+	}
+	for _, pid := range updates.Additions {
+		if w, ok := peer.AttributesOf(pid).Weight(); ok {
+			r.updaterWeights[pid.Identifier()] = w
+		}
+	}
+}
+
+// setWeight overrides the weight of the peer with the given identifier,
+// taking effect immediately for a peer already in the ring.
+func (r *weightedRing) setWeight(id string, weight int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if weight < 0 {
+		weight = 0
+	}
+	r.overrideWeights[id] = weight
+	r.reweigh(id)
+}
+
+// resetWeight removes the override of the weight of the peer with the given
+// identifier.
+func (r *weightedRing) resetWeight(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.overrideWeights, id)
+	r.reweigh(id)
+}
+
+// reweigh applies the current weight of the peer with the given identifier
+// to its subscriber, if the peer is in the ring, and restarts the rotation so
+// that the new weights apply from the next request.
+//
+// Must be called with the lock held.
+func (r *weightedRing) reweigh(id string) {
+	for _, sub := range r.subs {
+		if sub.peer.Identifier() == id {
+			sub.weight = r.weight(id)
+		}
+		sub.currentWeight = 0
+	}
+}
+
+// peerWeight returns the weight of the peer with the given identifier.
+func (r *weightedRing) peerWeight(id string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.weight(id)
+}
+
+// weight returns the weight of the peer with the given identifier.
+// Overridden weights may be zero, which drains the peer; other weights less
+// than one are treated as one.
+//
+// Must be called with the lock held.
+func (r *weightedRing) weight(id string) int {
+	if w, ok := r.overrideWeights[id]; ok {
+		return w
+	}
+
+	w, ok := r.updaterWeights[id]
+	if !ok {
+		w, ok = r.configWeights[id]
+	}
+	if !ok {
+		w = r.defaultWeight
+	}
+	if w < 1 {
+		w = 1
+	}
+	return w
+}
+
+// Add adds the peer with its weight.
+func (r *weightedRing) Add(p peer.StatusPeer) peer.Subscriber {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sub := &subscriber{
+		peer:    p,
+		weight:  r.weight(p.Identifier()),
+		addedAt: r.clock.Now(),
+	}
+	r.subs = append(r.subs, sub)
+	return sub
+}
+
+// Remove removes the peer.
+func (r *weightedRing) Remove(p peer.StatusPeer, s peer.Subscriber) {
+	sub, ok := s.(*subscriber)
+	if !ok {
+		// Don't panic.
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, candidate := range r.subs {
+		if candidate == sub {
+			r.subs = append(r.subs[:i], r.subs[i+1:]...)
+			return
+		}
+	}
+}
+
+// Choose returns the next peer according to smooth weighted round-robin, or
+// nil if there are no peers with a weight above zero.
+//
+// Every peer's current weight grows by its effective weight, the peer with
+// the highest current weight is chosen, and the chosen peer's current weight
+// is reduced by the sum of all effective weights.
+func (r *weightedRing) Choose(_ context.Context, _ *transport.Request) peer.StatusPeer {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var (
+		best  *subscriber
+		total float64
+		now   time.Time
+	)
+	if r.slowStart > 0 {
+		now = r.clock.Now()
+	}
+	for _, sub := range r.subs {
+		if sub.weight == 0 {
+			continue
+		}
+		weight := r.effectiveWeight(sub, now)
+		sub.currentWeight += weight
+		total += weight
+		if best == nil || sub.currentWeight > best.currentWeight {
+			best = sub
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	best.currentWeight -= total
+	return best.peer
+}
+
+// effectiveWeight returns the weight of the peer, reduced while the peer is
+// within the slow start window. The effective weight ramps linearly from a
+// tenth of the weight to the full weight over the window.
+//
+// Must be called with the lock held.
+func (r *weightedRing) effectiveWeight(sub *subscriber, now time.Time) float64 {
+	weight := float64(sub.weight)
+	if r.slowStart <= 0 {
+		return weight
+	}
+
+	elapsed := now.Sub(sub.addedAt)
+	if elapsed >= r.slowStart {
+		return weight
+	}
+	fraction := float64(elapsed) / float64(r.slowStart)
+	if fraction < _slowStartMinFraction {
+		fraction = _slowStartMinFraction
+	}
+	return weight * fraction
+}
+
+func (r *weightedRing) Start() error {
+	return nil
+}
+
+func (r *weightedRing) Stop() error {
+	return nil
+}
+
+func (r *weightedRing) IsRunning() bool {
+	return true
+}
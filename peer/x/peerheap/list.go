@@ -22,14 +22,18 @@ package peerheap
 
 import (
 	"context"
+	"fmt"
 	"math"
+	"sort"
 	"sync"
 	"time"
 
 	"go.uber.org/multierr"
 	"go.uber.org/yarpc/api/peer"
 	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/introspection"
 	intyarpcerrors "go.uber.org/yarpc/internal/yarpcerrors"
+	"go.uber.org/yarpc/peer/hostport"
 	"go.uber.org/yarpc/pkg/lifecycle"
 	"go.uber.org/yarpc/yarpcerrors"
 )
@@ -93,6 +97,52 @@ func (pl *List) Stop() error {
 	return pl.once.Stop(pl.clearPeers) // TODO clear peers
 }
 
+// Introspect returns a ChooserStatus with a summary of the peers, sorted by
+// identifier.
+func (pl *List) Introspect() introspection.ChooserStatus {
+	state := "Stopped"
+	if pl.IsRunning() {
+		state = "Running"
+	}
+
+	pl.mu.Lock()
+	scores := make([]*peerScore, 0, len(pl.byIdentifier))
+	for _, ps := range pl.byIdentifier {
+		scores = append(scores, ps)
+	}
+	pl.mu.Unlock()
+
+	var available int
+	peers := make([]introspection.PeerStatus, 0, len(scores))
+	for _, ps := range scores {
+		status := ps.peer.Status()
+		if status.ConnectionStatus == peer.Available {
+			available++
+		}
+		peerStatus := introspection.PeerStatus{
+			Identifier: ps.peer.Identifier(),
+			State: fmt.Sprintf("%s, %d pending request(s)",
+				status.ConnectionStatus.String(),
+				status.PendingRequestCount),
+			ConnectionStatus: status.ConnectionStatus.String(),
+			PendingRequests:  status.PendingRequestCount,
+		}
+		if err := ps.getLastError(); err != nil {
+			peerStatus.LastError = err.Error()
+		}
+		peers = append(peers, peerStatus)
+	}
+	sort.Slice(peers, func(i, j int) bool {
+		return peers[i].Identifier < peers[j].Identifier
+	})
+
+	return introspection.ChooserStatus{
+		Name:  "peerheap",
+		State: fmt.Sprintf("%s (%d/%d available)", state, available, len(scores)),
+		Peers: peers,
+	}
+}
+
 // New returns a new peer heap-chooser-list for the given transport.
 func New(transport peer.Transport, opts ...HeapOption) *List {
 	cfg := defaultHeapConfig
@@ -136,7 +186,7 @@ func (pl *List) Update(updates peer.ListUpdates) error {
 
 // retainPeer must be called with the mutex locked.
 func (pl *List) retainPeer(pid peer.Identifier) error {
-	if _, ok := pl.byIdentifier[pid.Identifier()]; ok {
+	if _, ok := pl.byIdentifier[peerKey(pid)]; ok {
 		return peer.ErrPeerAddAlreadyInList(pid.Identifier())
 	}
 
@@ -149,7 +199,7 @@ func (pl *List) retainPeer(pid peer.Identifier) error {
 	ps.peer = p
 	ps.score = scorePeer(p)
 	ps.boundFinish = ps.finish
-	pl.byIdentifier[pid.Identifier()] = ps
+	pl.byIdentifier[peerKey(pid)] = ps
 	pl.byScore.pushPeer(ps)
 	pl.internalNotifyStatusChanged(ps)
 	return nil
@@ -157,7 +207,7 @@ func (pl *List) retainPeer(pid peer.Identifier) error {
 
 // releasePeer must be called with the mutex locked.
 func (pl *List) releasePeer(pid peer.Identifier) error {
-	ps, ok := pl.byIdentifier[pid.Identifier()]
+	ps, ok := pl.byIdentifier[peerKey(pid)]
 	if !ok {
 		return peer.ErrPeerRemoveNotInList(pid.Identifier())
 	}
@@ -167,7 +217,7 @@ func (pl *List) releasePeer(pid peer.Identifier) error {
 	}
 
 	err := pl.transport.ReleasePeer(pid, ps)
-	delete(pl.byIdentifier, pid.Identifier())
+	delete(pl.byIdentifier, peerKey(pid))
 	pl.byScore.delete(ps.idx)
 	ps.list = nil
 	return err
@@ -202,6 +252,9 @@ func (pl *List) Choose(ctx context.Context, _ *transport.Request) (peer.Peer, fu
 	if err := pl.once.WaitUntilRunning(ctx); err != nil {
 		return nil, nil, intyarpcerrors.AnnotateWithInfo(yarpcerrors.FromError(err), "%s peer list is not running", "peer heap")
 	}
+	if id, ok := peer.PinnedPeer(ctx); ok {
+		return pl.choosePinned(ctx, id)
+	}
 
 	for {
 		if ps, ok := pl.get(); ok {
@@ -216,6 +269,55 @@ func (pl *List) Choose(ctx context.Context, _ *transport.Request) (peer.Peer, fu
 	}
 }
 
+// choosePinned returns the peer a request is pinned to, and waits for it to
+// become available if it is not.
+func (pl *List) choosePinned(ctx context.Context, id string) (peer.Peer, func(error), error) {
+	id = peerKey(hostport.PeerIdentifier(id))
+	for {
+		ps, ok, available := pl.getPinned(id)
+		if !ok {
+			return nil, nil, yarpcerrors.Newf(yarpcerrors.CodeUnavailable,
+				"peer heap does not retain peer %q the request is pinned to", id)
+		}
+		if available {
+			pl.notifyPeerAvailable()
+			ps.peer.StartRequest()
+			return ps.peer, ps.boundFinish, nil
+		}
+		if err := pl.waitForPeerAvailableEvent(ctx); err != nil {
+			return nil, nil, err
+		}
+	}
+}
+
+// Retains returns whether the list retains the peer with the given
+// identifier, whether or not it is available.
+func (pl *List) Retains(pid peer.Identifier) bool {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+
+	_, ok := pl.byIdentifier[peerKey(pid)]
+	return ok
+}
+
+// getPinned returns the peer with the given identifier, whether the list
+// retains it, and whether it is available. Like get, it pushes an available
+// peer back onto the heap, so pinned requests count toward the round-robin
+// order of peers with the same score.
+func (pl *List) getPinned(id string) (*peerScore, bool, bool) {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+
+	ps, ok := pl.byIdentifier[id]
+	if !ok || ps.status.ConnectionStatus != peer.Available {
+		return ps, ok, false
+	}
+
+	pl.byScore.delete(ps.idx)
+	pl.byScore.pushPeer(ps)
+	return ps, true, true
+}
+
 func (pl *List) get() (*peerScore, bool) {
 	pl.mu.Lock()
 	defer pl.mu.Unlock()
@@ -261,13 +363,20 @@ func (pl *List) notifyPeerAvailable() {
 	}
 }
 
+// peerKey returns the key of a peer in byIdentifier. Peers are keyed by
+// their normalized identifiers, so that different spellings of the same
+// address are the same peer.
+func peerKey(pid peer.Identifier) string {
+	return hostport.Identify(pid.Identifier()).Identifier()
+}
+
 // NotifyStatusChanged receives notifications when a peer becomes available,
 // connected, unavailable, or when its pending request count changes.
 // This method satisfies peer.Subscriber and is only used for tests, since
 // the peer heap has a subscriber for each invividual peer.
 func (pl *List) NotifyStatusChanged(pid peer.Identifier) {
 	pl.mu.Lock()
-	ps := pl.byIdentifier[pid.Identifier()]
+	ps := pl.byIdentifier[peerKey(pid)]
 	pl.mu.Unlock()
 	ps.NotifyStatusChanged(pid)
 }
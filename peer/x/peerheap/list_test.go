@@ -22,6 +22,7 @@ package peerheap
 
 import (
 	"context"
+	"errors"
 	"sort"
 	"testing"
 	"time"
@@ -32,7 +33,9 @@ import (
 	"go.uber.org/yarpc/api/peer"
 	. "go.uber.org/yarpc/api/peer/peertest"
 	intyarpcerrors "go.uber.org/yarpc/internal/yarpcerrors"
+	"go.uber.org/yarpc/peer/hostport"
 	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/yarpc/yarpctest"
 )
 
 func newNotRunningError(err error) error {
@@ -763,3 +766,67 @@ func TestPeerHeapList(t *testing.T) {
 		})
 	}
 }
+
+func TestIntrospect(t *testing.T) {
+	pl := New(yarpctest.NewFakeTransport())
+	assert.NoError(t, pl.Start())
+	defer pl.Stop()
+
+	assert.NoError(t, pl.Update(peer.ListUpdates{
+		Additions: []peer.Identifier{hostport.PeerIdentifier("b:1"), hostport.PeerIdentifier("a:1")},
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	p, onFinish, err := pl.Choose(ctx, nil)
+	assert.NoError(t, err)
+	onFinish(errors.New("connection reset"))
+
+	status := pl.Introspect()
+	assert.Equal(t, "peerheap", status.Name)
+	assert.Equal(t, "Running (2/2 available)", status.State)
+	if assert.Len(t, status.Peers, 2) {
+		assert.Equal(t, "a:1", status.Peers[0].Identifier)
+		assert.Equal(t, "b:1", status.Peers[1].Identifier)
+		for _, ps := range status.Peers {
+			assert.Equal(t, "Available", ps.ConnectionStatus)
+			if ps.Identifier == p.Identifier() {
+				assert.Equal(t, "connection reset", ps.LastError)
+			} else {
+				assert.Empty(t, ps.LastError)
+			}
+		}
+	}
+}
+
+func TestChoosePinned(t *testing.T) {
+	pl := New(yarpctest.NewFakeTransport())
+	assert.NoError(t, pl.Start())
+	defer pl.Stop()
+
+	assert.NoError(t, pl.Update(peer.ListUpdates{
+		Additions: []peer.Identifier{hostport.Identify("localhost:80"), hostport.Identify("localhost:81")},
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	p, onFinish, err := pl.Choose(peer.ContextWithPinnedPeer(ctx, "LOCALHOST:80"), nil)
+	if assert.NoError(t, err, "pinned identifiers must be normalized") {
+		assert.Equal(t, "localhost:80", p.Identifier())
+		onFinish(nil)
+	}
+
+	p, onFinish, err = pl.Choose(ctx, nil)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "localhost:81", p.Identifier(), "pinned requests must count toward the round-robin order")
+		onFinish(nil)
+	}
+
+	_, _, err = pl.Choose(peer.ContextWithPinnedPeer(ctx, "localhost:82"), nil)
+	assert.Equal(t, yarpcerrors.CodeUnavailable, yarpcerrors.FromError(err).Code())
+	assert.Contains(t, err.Error(), `does not retain peer "localhost:82"`)
+
+	assert.True(t, pl.Retains(hostport.PeerIdentifier("LOCALHOST:81")))
+	assert.False(t, pl.Retains(hostport.PeerIdentifier("localhost:82")))
+}
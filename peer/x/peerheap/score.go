@@ -20,7 +20,11 @@
 
 package peerheap
 
-import "go.uber.org/yarpc/api/peer"
+import (
+	"sync"
+
+	"go.uber.org/yarpc/api/peer"
+)
 
 // peerScore is a book-keeping object for each retained peer and
 // gets
@@ -35,6 +39,11 @@ type peerScore struct {
 	score  int64
 	idx    int // index in the peer list.
 	last   int // snapshot of the heap's incrementing counter.
+
+	// lastError is guarded by its own lock because requests finish without
+	// holding the list's lock.
+	errMu     sync.Mutex
+	lastError error
 }
 
 func (ps *peerScore) NotifyStatusChanged(_ peer.Identifier) {
@@ -51,6 +60,17 @@ func (ps *peerScore) NotifyStatusChanged(_ peer.Identifier) {
 	ps.list.peerScoreChanged(ps)
 }
 
-func (ps *peerScore) finish(error) {
+func (ps *peerScore) finish(err error) {
+	if err != nil {
+		ps.errMu.Lock()
+		ps.lastError = err
+		ps.errMu.Unlock()
+	}
 	ps.peer.EndRequest()
 }
+
+func (ps *peerScore) getLastError() error {
+	ps.errMu.Lock()
+	defer ps.errMu.Unlock()
+	return ps.lastError
+}
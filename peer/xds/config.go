@@ -0,0 +1,85 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package xds
+
+import (
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/yarpcconfig"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// Configuration describes how to build an xDS peer list updater.
+type Configuration struct {
+	Server      string `config:"server,interpolate"`
+	Cluster     string `config:"cluster"`
+	ServiceName string `config:"service-name"`
+	NodeID      string `config:"node-id,interpolate"`
+	NodeCluster string `config:"node-cluster"`
+}
+
+// Spec returns a configuration specification for the xDS peer list updater,
+// making it possible to subscribe to the endpoints of a cluster from an xDS
+// control plane with transports that use outbound peer list configuration
+// (like HTTP).
+//
+//  cfg := yarpcconfig.New()
+//  cfg.MustRegisterPeerListUpdater(xds.Spec())
+//
+// This enables the xds peer list updater:
+//
+//  outbounds:
+//    otherservice:
+//      unary:
+//        http:
+//          url: http://host:port/rpc
+//          weighted-round-robin:
+//            xds:
+//              server: istiod.istio-system:15010
+//              cluster: outbound|8080||otherservice.default.svc.cluster.local
+//              node-id: ${POD_NAME}
+//
+// Connections to the control plane are insecure. Use Binder with the
+// DialOptions option to connect with transport credentials.
+func Spec() yarpcconfig.PeerListUpdaterSpec {
+	return yarpcconfig.PeerListUpdaterSpec{
+		Name: "xds",
+		BuildPeerListUpdater: func(cfg Configuration, k *yarpcconfig.Kit) (peer.Binder, error) {
+			if cfg.Server == "" {
+				return nil, yarpcerrors.InvalidArgumentErrorf("Server is required.")
+			}
+			if cfg.Cluster == "" {
+				return nil, yarpcerrors.InvalidArgumentErrorf("Cluster is required.")
+			}
+
+			var opts []Option
+			if cfg.ServiceName != "" {
+				opts = append(opts, ServiceName(cfg.ServiceName))
+			}
+			if cfg.NodeID != "" {
+				opts = append(opts, NodeID(cfg.NodeID))
+			}
+			if cfg.NodeCluster != "" {
+				opts = append(opts, NodeCluster(cfg.NodeCluster))
+			}
+			return Binder(cfg.Server, cfg.Cluster, opts...), nil
+		},
+	}
+}
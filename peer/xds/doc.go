@@ -0,0 +1,47 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package xds provides a peer list updater that subscribes to the endpoints
+// of a cluster from an xDS control plane, such as Istiod or a custom control
+// plane.
+//
+// The updater opens an aggregated discovery (ADS) stream to the control
+// plane, subscribes to the cluster to learn the name of its endpoint
+// assignment, and then subscribes to the assignment. Every assignment it
+// receives is applied to the peer list, and acknowledged or rejected as the
+// xDS protocol requires.
+//
+// 	list := weightedroundrobin.New(transport)
+// 	chooser := peer.Bind(list, xds.Binder("istiod.istio-system:15010",
+// 		"outbound|8080||myservice.default.svc.cluster.local",
+// 	))
+//
+// Endpoints are added to the list as Endpoints, which carry the weight and
+// locality the control plane assigned, so weighted and zone-aware peer lists
+// honor them:
+//
+//  - Only endpoints that are healthy or of unknown health are added.
+//  - Only the endpoints of the most preferred priority with any such
+//    endpoints are added, so lower priorities are used as a fallback.
+//  - If the control plane assigns locality weights, every locality receives
+//    its share of requests, split among its endpoints by their weights.
+//
+// The updater speaks the Envoy v3 xDS API.
+package xds
@@ -0,0 +1,206 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package xds
+
+import (
+	"math"
+	"net"
+	"sort"
+	"strconv"
+
+	"go.uber.org/yarpc/peer/weightedroundrobin"
+	"go.uber.org/yarpc/peer/zoneaware"
+)
+
+// _localityWeightScale is the total weight shared by the endpoints of all
+// localities when the control plane assigns locality weights.
+const _localityWeightScale = 1000
+
+var (
+	_ weightedroundrobin.WeightedIdentifier = (*Endpoint)(nil)
+	_ zoneaware.ZonedIdentifier             = (*Endpoint)(nil)
+)
+
+// Endpoint is a peer identifier for an endpoint assigned by the control
+// plane. It carries the weight and locality of the endpoint, so that weighted
+// and zone-aware peer lists honor them.
+type Endpoint struct {
+	addr     string
+	weight   int
+	region   string
+	zone     string
+	subZone  string
+	priority uint32
+}
+
+// Identifier returns the host:port address of the endpoint.
+func (e *Endpoint) Identifier() string { return e.addr }
+
+// Weight returns the share of requests the endpoint should receive, taking
+// the weight of its locality into account.
+func (e *Endpoint) Weight() int { return e.weight }
+
+// Region returns the region of the endpoint's locality.
+func (e *Endpoint) Region() string { return e.region }
+
+// Zone returns the zone of the endpoint's locality.
+func (e *Endpoint) Zone() string { return e.zone }
+
+// SubZone returns the sub-zone of the endpoint's locality.
+func (e *Endpoint) SubZone() string { return e.subZone }
+
+// Priority returns the priority of the endpoint's locality, which is zero
+// for the most preferred endpoints.
+func (e *Endpoint) Priority() uint32 { return e.priority }
+
+func (e *Endpoint) equal(other *Endpoint) bool {
+	return *e == *other
+}
+
+// endpoints returns the endpoints of an assignment that should receive
+// requests.
+//
+// Only the healthy endpoints of the most preferred priority with any healthy
+// endpoints are returned, so lower priorities receive requests only once
+// every endpoint of a higher priority failed. If the control plane assigns
+// locality weights, every locality receives its share of requests, split
+// among its endpoints by their weights; localities without a weight receive
+// none. Otherwise, endpoints are weighted by their own weights alone.
+func endpoints(cla *clusterLoadAssignment) []*Endpoint {
+	byPriority := make(map[uint32][]*localityLBEndpoints)
+	var priorities []uint32
+	for _, l := range cla.Endpoints {
+		if healthyCount(l) == 0 {
+			continue
+		}
+		if _, ok := byPriority[l.Priority]; !ok {
+			priorities = append(priorities, l.Priority)
+		}
+		byPriority[l.Priority] = append(byPriority[l.Priority], l)
+	}
+	if len(priorities) == 0 {
+		return nil
+	}
+	sort.Slice(priorities, func(i, j int) bool { return priorities[i] < priorities[j] })
+	priority := priorities[0]
+	localities := byPriority[priority]
+
+	var totalLocalityWeight uint32
+	for _, l := range localities {
+		totalLocalityWeight += l.LoadBalancingWeight.get()
+	}
+
+	var eps []*Endpoint
+	seen := make(map[string]struct{})
+	for _, l := range localities {
+		var totalWeight uint32
+		for _, lbe := range l.LBEndpoints {
+			if healthy(lbe) {
+				totalWeight += lbEndpointWeight(lbe)
+			}
+		}
+
+		loc := l.Locality
+		if loc == nil {
+			loc = &locality{}
+		}
+		for _, lbe := range l.LBEndpoints {
+			if !healthy(lbe) {
+				continue
+			}
+			addr := lbEndpointAddress(lbe)
+			if _, ok := seen[addr]; ok {
+				continue
+			}
+
+			weight := int(lbEndpointWeight(lbe))
+			if totalLocalityWeight > 0 {
+				localityWeight := l.LoadBalancingWeight.get()
+				if localityWeight == 0 {
+					continue
+				}
+				share := float64(localityWeight) / float64(totalLocalityWeight) *
+					float64(weight) / float64(totalWeight)
+				weight = int(math.Max(1, math.Floor(share*_localityWeightScale+0.5)))
+			}
+
+			seen[addr] = struct{}{}
+			eps = append(eps, &Endpoint{
+				addr:     addr,
+				weight:   weight,
+				region:   loc.Region,
+				zone:     loc.Zone,
+				subZone:  loc.SubZone,
+				priority: priority,
+			})
+		}
+	}
+	return eps
+}
+
+// get returns the value, or zero if it is unset.
+func (v *uint32Value) get() uint32 {
+	if v == nil {
+		return 0
+	}
+	return v.Value
+}
+
+// healthy returns whether the endpoint may receive requests.
+func healthy(lbe *lbEndpoint) bool {
+	if lbEndpointAddress(lbe) == "" {
+		return false
+	}
+	return lbe.HealthStatus == _healthUnknown || lbe.HealthStatus == _healthHealthy
+}
+
+// healthyCount returns the number of endpoints of the locality that may
+// receive requests.
+func healthyCount(l *localityLBEndpoints) int {
+	var n int
+	for _, lbe := range l.LBEndpoints {
+		if healthy(lbe) {
+			n++
+		}
+	}
+	return n
+}
+
+// lbEndpointWeight returns the weight of the endpoint, which defaults to 1.
+func lbEndpointWeight(lbe *lbEndpoint) uint32 {
+	if w := lbe.LoadBalancingWeight.get(); w > 0 {
+		return w
+	}
+	return 1
+}
+
+// lbEndpointAddress returns the host:port address of the endpoint, or an
+// empty string if it has no socket address.
+func lbEndpointAddress(lbe *lbEndpoint) string {
+	if lbe.Endpoint == nil || lbe.Endpoint.Address == nil || lbe.Endpoint.Address.SocketAddress == nil {
+		return ""
+	}
+	sa := lbe.Endpoint.Address.SocketAddress
+	if sa.Address == "" {
+		return ""
+	}
+	return net.JoinHostPort(sa.Address, strconv.Itoa(int(sa.PortValue)))
+}
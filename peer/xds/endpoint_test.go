@@ -0,0 +1,127 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package xds
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func lbe(host string, weight uint32, health int32) *lbEndpoint {
+	e := &lbEndpoint{
+		Endpoint: &endpoint{Address: &address{SocketAddress: &socketAddress{
+			Address:   host,
+			PortValue: 8080,
+		}}},
+		HealthStatus: health,
+	}
+	if weight > 0 {
+		e.LoadBalancingWeight = &uint32Value{Value: weight}
+	}
+	return e
+}
+
+func localityEndpoints(zone string, priority, weight uint32, eps ...*lbEndpoint) *localityLBEndpoints {
+	l := &localityLBEndpoints{
+		Locality:    &locality{Region: "us-east", Zone: zone},
+		LBEndpoints: eps,
+		Priority:    priority,
+	}
+	if weight > 0 {
+		l.LoadBalancingWeight = &uint32Value{Value: weight}
+	}
+	return l
+}
+
+// describe lists the address, zone, and weight of every endpoint.
+func describe(eps []*Endpoint) []string {
+	var s []string
+	for _, ep := range eps {
+		s = append(s, fmt.Sprintf("%s/%s=%d", ep.Identifier(), ep.Zone(), ep.Weight()))
+	}
+	return s
+}
+
+func TestEndpoints(t *testing.T) {
+	tests := []struct {
+		msg        string
+		localities []*localityLBEndpoints
+		want       []string
+	}{
+		{
+			msg:  "no endpoints",
+			want: nil,
+		},
+		{
+			msg: "endpoint weights",
+			localities: []*localityLBEndpoints{
+				localityEndpoints("a", 0, 0, lbe("10.0.0.1", 3, _healthHealthy), lbe("10.0.0.2", 0, _healthUnknown)),
+				localityEndpoints("b", 0, 0, lbe("10.0.1.1", 2, _healthHealthy)),
+			},
+			want: []string{"10.0.0.1:8080/a=3", "10.0.0.2:8080/a=1", "10.0.1.1:8080/b=2"},
+		},
+		{
+			msg: "unhealthy endpoints",
+			localities: []*localityLBEndpoints{
+				localityEndpoints("a", 0, 0,
+					lbe("10.0.0.1", 0, _healthHealthy),
+					lbe("10.0.0.2", 0, 2), // unhealthy
+					lbe("10.0.0.3", 0, 3), // draining
+				),
+			},
+			want: []string{"10.0.0.1:8080/a=1"},
+		},
+		{
+			msg: "locality weights",
+			localities: []*localityLBEndpoints{
+				localityEndpoints("a", 0, 3, lbe("10.0.0.1", 0, _healthHealthy), lbe("10.0.0.2", 0, _healthHealthy)),
+				localityEndpoints("b", 0, 1, lbe("10.0.1.1", 0, _healthHealthy)),
+				localityEndpoints("c", 0, 0, lbe("10.0.2.1", 0, _healthHealthy)),
+			},
+			want: []string{"10.0.0.1:8080/a=375", "10.0.0.2:8080/a=375", "10.0.1.1:8080/b=250"},
+		},
+		{
+			msg: "priorities",
+			localities: []*localityLBEndpoints{
+				localityEndpoints("b", 1, 0, lbe("10.0.1.1", 0, _healthHealthy)),
+				localityEndpoints("a", 0, 0, lbe("10.0.0.1", 0, _healthHealthy)),
+			},
+			want: []string{"10.0.0.1:8080/a=1"},
+		},
+		{
+			msg: "failover to a lower priority",
+			localities: []*localityLBEndpoints{
+				localityEndpoints("a", 0, 0, lbe("10.0.0.1", 0, 2)),
+				localityEndpoints("b", 1, 0, lbe("10.0.1.1", 0, _healthHealthy)),
+			},
+			want: []string{"10.0.1.1:8080/b=1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.msg, func(t *testing.T) {
+			got := endpoints(&clusterLoadAssignment{ClusterName: "myservice", Endpoints: tt.localities})
+			assert.Equal(t, tt.want, describe(got))
+		})
+	}
+}
@@ -0,0 +1,188 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package xds
+
+import "github.com/gogo/protobuf/proto"
+
+// The following types mirror the parts of the Envoy v3 xDS API messages the
+// updater uses, with the same field numbers, so that they can be exchanged
+// with any xDS control plane without depending on the generated Envoy API.
+// Fields that belong to a oneof are declared as plain fields, which encode
+// the same way.
+
+const (
+	_adsMethod = "/envoy.service.discovery.v3.AggregatedDiscoveryService/StreamAggregatedResources"
+
+	_clusterType  = "type.googleapis.com/envoy.config.cluster.v3.Cluster"
+	_endpointType = "type.googleapis.com/envoy.config.endpoint.v3.ClusterLoadAssignment"
+)
+
+// Health statuses of endpoints that may receive requests. Endpoints with any
+// other status, such as unhealthy, draining, or degraded, may not.
+const (
+	_healthUnknown = 0
+	_healthHealthy = 1
+)
+
+type discoveryRequest struct {
+	VersionInfo   string      `protobuf:"bytes,1,opt,name=version_info,proto3"`
+	Node          *node       `protobuf:"bytes,2,opt,name=node"`
+	ResourceNames []string    `protobuf:"bytes,3,rep,name=resource_names"`
+	TypeURL       string      `protobuf:"bytes,4,opt,name=type_url,proto3"`
+	ResponseNonce string      `protobuf:"bytes,5,opt,name=response_nonce,proto3"`
+	ErrorDetail   *statusInfo `protobuf:"bytes,6,opt,name=error_detail"`
+}
+
+func (m *discoveryRequest) Reset()         { *m = discoveryRequest{} }
+func (m *discoveryRequest) String() string { return proto.CompactTextString(m) }
+func (*discoveryRequest) ProtoMessage()    {}
+
+type discoveryResponse struct {
+	VersionInfo string        `protobuf:"bytes,1,opt,name=version_info,proto3"`
+	Resources   []*anyMessage `protobuf:"bytes,2,rep,name=resources"`
+	TypeURL     string        `protobuf:"bytes,4,opt,name=type_url,proto3"`
+	Nonce       string        `protobuf:"bytes,5,opt,name=nonce,proto3"`
+}
+
+func (m *discoveryResponse) Reset()         { *m = discoveryResponse{} }
+func (m *discoveryResponse) String() string { return proto.CompactTextString(m) }
+func (*discoveryResponse) ProtoMessage()    {}
+
+// anyMessage mirrors google.protobuf.Any.
+type anyMessage struct {
+	TypeURL string `protobuf:"bytes,1,opt,name=type_url,proto3"`
+	Value   []byte `protobuf:"bytes,2,opt,name=value,proto3"`
+}
+
+func (m *anyMessage) Reset()         { *m = anyMessage{} }
+func (m *anyMessage) String() string { return proto.CompactTextString(m) }
+func (*anyMessage) ProtoMessage()    {}
+
+// statusInfo mirrors google.rpc.Status.
+type statusInfo struct {
+	Code    int32  `protobuf:"varint,1,opt,name=code,proto3"`
+	Message string `protobuf:"bytes,2,opt,name=message,proto3"`
+}
+
+func (m *statusInfo) Reset()         { *m = statusInfo{} }
+func (m *statusInfo) String() string { return proto.CompactTextString(m) }
+func (*statusInfo) ProtoMessage()    {}
+
+// uint32Value mirrors google.protobuf.UInt32Value.
+type uint32Value struct {
+	Value uint32 `protobuf:"varint,1,opt,name=value,proto3"`
+}
+
+func (m *uint32Value) Reset()         { *m = uint32Value{} }
+func (m *uint32Value) String() string { return proto.CompactTextString(m) }
+func (*uint32Value) ProtoMessage()    {}
+
+type node struct {
+	ID            string `protobuf:"bytes,1,opt,name=id,proto3"`
+	Cluster       string `protobuf:"bytes,2,opt,name=cluster,proto3"`
+	UserAgentName string `protobuf:"bytes,6,opt,name=user_agent_name,proto3"`
+}
+
+func (m *node) Reset()         { *m = node{} }
+func (m *node) String() string { return proto.CompactTextString(m) }
+func (*node) ProtoMessage()    {}
+
+type locality struct {
+	Region  string `protobuf:"bytes,1,opt,name=region,proto3"`
+	Zone    string `protobuf:"bytes,2,opt,name=zone,proto3"`
+	SubZone string `protobuf:"bytes,3,opt,name=sub_zone,proto3"`
+}
+
+func (m *locality) Reset()         { *m = locality{} }
+func (m *locality) String() string { return proto.CompactTextString(m) }
+func (*locality) ProtoMessage()    {}
+
+type cluster struct {
+	Name             string            `protobuf:"bytes,1,opt,name=name,proto3"`
+	EDSClusterConfig *edsClusterConfig `protobuf:"bytes,3,opt,name=eds_cluster_config"`
+}
+
+func (m *cluster) Reset()         { *m = cluster{} }
+func (m *cluster) String() string { return proto.CompactTextString(m) }
+func (*cluster) ProtoMessage()    {}
+
+type edsClusterConfig struct {
+	ServiceName string `protobuf:"bytes,2,opt,name=service_name,proto3"`
+}
+
+func (m *edsClusterConfig) Reset()         { *m = edsClusterConfig{} }
+func (m *edsClusterConfig) String() string { return proto.CompactTextString(m) }
+func (*edsClusterConfig) ProtoMessage()    {}
+
+type clusterLoadAssignment struct {
+	ClusterName string                 `protobuf:"bytes,1,opt,name=cluster_name,proto3"`
+	Endpoints   []*localityLBEndpoints `protobuf:"bytes,2,rep,name=endpoints"`
+}
+
+func (m *clusterLoadAssignment) Reset()         { *m = clusterLoadAssignment{} }
+func (m *clusterLoadAssignment) String() string { return proto.CompactTextString(m) }
+func (*clusterLoadAssignment) ProtoMessage()    {}
+
+type localityLBEndpoints struct {
+	Locality            *locality     `protobuf:"bytes,1,opt,name=locality"`
+	LBEndpoints         []*lbEndpoint `protobuf:"bytes,2,rep,name=lb_endpoints"`
+	LoadBalancingWeight *uint32Value  `protobuf:"bytes,3,opt,name=load_balancing_weight"`
+	Priority            uint32        `protobuf:"varint,5,opt,name=priority,proto3"`
+}
+
+func (m *localityLBEndpoints) Reset()         { *m = localityLBEndpoints{} }
+func (m *localityLBEndpoints) String() string { return proto.CompactTextString(m) }
+func (*localityLBEndpoints) ProtoMessage()    {}
+
+type lbEndpoint struct {
+	Endpoint            *endpoint    `protobuf:"bytes,1,opt,name=endpoint"`
+	HealthStatus        int32        `protobuf:"varint,2,opt,name=health_status,proto3"`
+	LoadBalancingWeight *uint32Value `protobuf:"bytes,4,opt,name=load_balancing_weight"`
+}
+
+func (m *lbEndpoint) Reset()         { *m = lbEndpoint{} }
+func (m *lbEndpoint) String() string { return proto.CompactTextString(m) }
+func (*lbEndpoint) ProtoMessage()    {}
+
+type endpoint struct {
+	Address *address `protobuf:"bytes,1,opt,name=address"`
+}
+
+func (m *endpoint) Reset()         { *m = endpoint{} }
+func (m *endpoint) String() string { return proto.CompactTextString(m) }
+func (*endpoint) ProtoMessage()    {}
+
+type address struct {
+	SocketAddress *socketAddress `protobuf:"bytes,1,opt,name=socket_address"`
+}
+
+func (m *address) Reset()         { *m = address{} }
+func (m *address) String() string { return proto.CompactTextString(m) }
+func (*address) ProtoMessage()    {}
+
+type socketAddress struct {
+	Address   string `protobuf:"bytes,2,opt,name=address,proto3"`
+	PortValue uint32 `protobuf:"varint,3,opt,name=port_value,proto3"`
+}
+
+func (m *socketAddress) Reset()         { *m = socketAddress{} }
+func (m *socketAddress) String() string { return proto.CompactTextString(m) }
+func (*socketAddress) ProtoMessage()    {}
@@ -0,0 +1,112 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package xds
+
+import (
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+// Option customizes the behavior of an xDS peer list updater.
+type Option func(*options)
+
+type options struct {
+	serviceName   string
+	nodeID        string
+	nodeCluster   string
+	dialOptions   []grpc.DialOption
+	retryInterval time.Duration
+	logger        *zap.Logger
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		dialOptions:   []grpc.DialOption{grpc.WithInsecure()},
+		retryInterval: time.Second,
+		logger:        zap.NewNop(),
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		o.nodeID = hostname
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// ServiceName specifies the name of the endpoint assignment to subscribe to,
+// skipping the cluster subscription that would otherwise discover it.
+//
+// Defaults to the EDS service name of the cluster, or the name of the
+// cluster if it has none.
+func ServiceName(name string) Option {
+	return func(o *options) {
+		o.serviceName = name
+	}
+}
+
+// NodeID specifies the ID this node identifies itself with to the control
+// plane.
+//
+// Defaults to the hostname.
+func NodeID(id string) Option {
+	return func(o *options) {
+		o.nodeID = id
+	}
+}
+
+// NodeCluster specifies the cluster this node identifies itself as part of
+// to the control plane.
+func NodeCluster(cluster string) Option {
+	return func(o *options) {
+		o.nodeCluster = cluster
+	}
+}
+
+// DialOptions specifies the options used to dial the control plane, such as
+// its transport credentials.
+//
+// Defaults to an insecure connection.
+func DialOptions(opts ...grpc.DialOption) Option {
+	return func(o *options) {
+		o.dialOptions = opts
+	}
+}
+
+// RetryInterval specifies how long to wait before subscribing again after
+// the stream to the control plane fails.
+//
+// Defaults to 1 second.
+func RetryInterval(d time.Duration) Option {
+	return func(o *options) {
+		o.retryInterval = d
+	}
+}
+
+// Logger specifies a logger for failed streams and rejected resources.
+func Logger(logger *zap.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
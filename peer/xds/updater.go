@@ -0,0 +1,330 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package xds
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/pkg/lifecycle"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// _userAgentName identifies the updater to the control plane.
+const _userAgentName = "yarpc-go"
+
+var _ transport.Lifecycle = (*Updater)(nil)
+
+// Updater is a peer list updater that keeps a peer list in sync with the
+// endpoints an xDS control plane assigns to a cluster.
+type Updater struct {
+	list    peer.List
+	server  string
+	cluster string
+	opts    options
+	once    *lifecycle.Once
+
+	conn   *grpc.ClientConn
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	// endpoints holds the endpoints sent to the list, by address. It is only
+	// accessed by the stream goroutine.
+	endpoints map[string]*Endpoint
+}
+
+// New creates an updater that subscribes to the endpoints of the cluster
+// from the control plane at the given address, and sends them to the peer
+// list once started.
+func New(list peer.List, server, cluster string, opts ...Option) *Updater {
+	return &Updater{
+		list:      list,
+		server:    server,
+		cluster:   cluster,
+		opts:      newOptions(opts),
+		once:      lifecycle.NewOnce(),
+		done:      make(chan struct{}),
+		endpoints: make(map[string]*Endpoint),
+	}
+}
+
+// Binder returns a peer.Binder that binds peer lists to an updater for the
+// cluster.
+func Binder(server, cluster string, opts ...Option) peer.Binder {
+	return func(list peer.List) transport.Lifecycle {
+		return New(list, server, cluster, opts...)
+	}
+}
+
+// Start connects to the control plane and subscribes to the endpoints of the
+// cluster. Failed streams are logged and retried.
+func (u *Updater) Start() error {
+	return u.once.Start(func() error {
+		conn, err := grpc.Dial(u.server, u.opts.dialOptions...)
+		if err != nil {
+			return err
+		}
+		u.conn = conn
+
+		ctx, cancel := context.WithCancel(context.Background())
+		u.cancel = cancel
+		go u.run(ctx)
+		return nil
+	})
+}
+
+// Stop unsubscribes from the control plane. Peers already sent to the list
+// remain.
+func (u *Updater) Stop() error {
+	return u.once.Stop(func() error {
+		u.cancel()
+		<-u.done
+		return u.conn.Close()
+	})
+}
+
+// IsRunning returns whether the updater is running.
+func (u *Updater) IsRunning() bool {
+	return u.once.IsRunning()
+}
+
+// run subscribes to the control plane until the context is canceled,
+// subscribing again whenever the stream fails.
+func (u *Updater) run(ctx context.Context) {
+	defer close(u.done)
+
+	for {
+		err := u.subscribe(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		u.opts.logger.Warn("xDS stream failed, retrying",
+			zap.String("server", u.server),
+			zap.String("cluster", u.cluster),
+			zap.Error(err))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(u.opts.retryInterval):
+		}
+	}
+}
+
+// subscribe opens an aggregated discovery stream, subscribes to the cluster
+// and then to its endpoints, and applies every endpoint assignment it
+// receives until the stream fails.
+func (u *Updater) subscribe(ctx context.Context) error {
+	stream, err := u.conn.NewStream(ctx, &grpc.StreamDesc{
+		ClientStreams: true,
+		ServerStreams: true,
+	}, _adsMethod)
+	if err != nil {
+		return err
+	}
+	s := &adsStream{
+		stream: stream,
+		node: &node{
+			ID:            u.opts.nodeID,
+			Cluster:       u.opts.nodeCluster,
+			UserAgentName: _userAgentName,
+		},
+		versions: make(map[string]string),
+	}
+
+	serviceName := u.opts.serviceName
+	if serviceName != "" {
+		err = s.request(_endpointType, serviceName, "", nil)
+	} else {
+		err = s.request(_clusterType, u.cluster, "", nil)
+	}
+	if err != nil {
+		return err
+	}
+
+	for {
+		res := new(discoveryResponse)
+		if err := stream.RecvMsg(res); err != nil {
+			return err
+		}
+
+		switch res.TypeURL {
+		case _clusterType:
+			name, decodeErr := u.edsServiceName(res)
+			if err := s.respond(res, u.cluster, decodeErr); err != nil {
+				return err
+			}
+			if decodeErr != nil {
+				u.reject(res, decodeErr)
+				continue
+			}
+			if name == "" || name == serviceName {
+				continue
+			}
+			serviceName = name
+			if err := s.request(_endpointType, serviceName, "", nil); err != nil {
+				return err
+			}
+
+		case _endpointType:
+			cla, decodeErr := findAssignment(res, serviceName)
+			if err := s.respond(res, serviceName, decodeErr); err != nil {
+				return err
+			}
+			if decodeErr != nil {
+				u.reject(res, decodeErr)
+				continue
+			}
+			if cla != nil {
+				u.sync(endpoints(cla))
+			}
+		}
+	}
+}
+
+// reject logs a response that was rejected.
+func (u *Updater) reject(res *discoveryResponse, err error) {
+	u.opts.logger.Warn("rejected xDS response",
+		zap.String("cluster", u.cluster),
+		zap.String("type", res.TypeURL),
+		zap.String("version", res.VersionInfo),
+		zap.Error(err))
+}
+
+// edsServiceName returns the name of the endpoint assignment of the cluster
+// in a cluster response, or an empty string if the response does not include
+// the cluster.
+func (u *Updater) edsServiceName(res *discoveryResponse) (string, error) {
+	for _, r := range res.Resources {
+		if r.TypeURL != _clusterType {
+			return "", fmt.Errorf("unexpected resource type %q", r.TypeURL)
+		}
+		var c cluster
+		if err := proto.Unmarshal(r.Value, &c); err != nil {
+			return "", err
+		}
+		if c.Name != u.cluster {
+			continue
+		}
+		if c.EDSClusterConfig != nil && c.EDSClusterConfig.ServiceName != "" {
+			return c.EDSClusterConfig.ServiceName, nil
+		}
+		return c.Name, nil
+	}
+	return "", nil
+}
+
+// findAssignment returns the named endpoint assignment in an endpoint
+// response, or nil if the response does not include it.
+func findAssignment(res *discoveryResponse, name string) (*clusterLoadAssignment, error) {
+	for _, r := range res.Resources {
+		if r.TypeURL != _endpointType {
+			return nil, fmt.Errorf("unexpected resource type %q", r.TypeURL)
+		}
+		cla := new(clusterLoadAssignment)
+		if err := proto.Unmarshal(r.Value, cla); err != nil {
+			return nil, err
+		}
+		if cla.ClusterName == name {
+			return cla, nil
+		}
+	}
+	return nil, nil
+}
+
+// sync sends the endpoints that were added, removed, or changed since the
+// last sync to the list. Changed endpoints are removed and added again in
+// the same update.
+func (u *Updater) sync(eps []*Endpoint) {
+	next := make(map[string]*Endpoint, len(eps))
+	for _, ep := range eps {
+		next[ep.addr] = ep
+	}
+
+	var removals, additions []string
+	for addr, ep := range u.endpoints {
+		if n, ok := next[addr]; !ok || !n.equal(ep) {
+			removals = append(removals, addr)
+		}
+	}
+	for addr, ep := range next {
+		if o, ok := u.endpoints[addr]; !ok || !o.equal(ep) {
+			additions = append(additions, addr)
+		}
+	}
+	if len(removals) == 0 && len(additions) == 0 {
+		return
+	}
+	sort.Strings(removals)
+	sort.Strings(additions)
+
+	var updates peer.ListUpdates
+	for _, addr := range removals {
+		updates.Removals = append(updates.Removals, u.endpoints[addr])
+	}
+	for _, addr := range additions {
+		updates.Additions = append(updates.Additions, next[addr])
+	}
+	if err := u.list.Update(updates); err != nil {
+		u.opts.logger.Warn("failed to update peer list",
+			zap.String("cluster", u.cluster), zap.Error(err))
+	}
+	u.endpoints = next
+}
+
+// adsStream sends requests on an aggregated discovery stream, tracking the
+// last accepted version of every resource type.
+type adsStream struct {
+	stream   grpc.ClientStream
+	node     *node
+	versions map[string]string
+}
+
+// request subscribes to the named resource of the given type.
+func (s *adsStream) request(typeURL, name, nonce string, errorDetail *statusInfo) error {
+	return s.stream.SendMsg(&discoveryRequest{
+		VersionInfo:   s.versions[typeURL],
+		Node:          s.node,
+		ResourceNames: []string{name},
+		TypeURL:       typeURL,
+		ResponseNonce: nonce,
+		ErrorDetail:   errorDetail,
+	})
+}
+
+// respond acknowledges a response, accepting its version, or rejects it with
+// the error, keeping the last accepted version.
+func (s *adsStream) respond(res *discoveryResponse, name string, err error) error {
+	if err != nil {
+		return s.request(res.TypeURL, name, res.Nonce, &statusInfo{
+			Code:    int32(codes.InvalidArgument),
+			Message: err.Error(),
+		})
+	}
+	s.versions[res.TypeURL] = res.VersionInfo
+	return s.request(res.TypeURL, name, res.Nonce, nil)
+}
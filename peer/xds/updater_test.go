@@ -0,0 +1,191 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package xds
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/peer/peertest"
+	"go.uber.org/yarpc/internal/testtime"
+	"go.uber.org/yarpc/peer/weightedroundrobin"
+	"google.golang.org/grpc"
+)
+
+// weights lists the peers in the list with their weights.
+func weights(l *peertest.FakeList) string {
+	var ids []string
+	for _, id := range l.IDs() {
+		var weight int
+		if w, ok := l.Peer(id).(weightedroundrobin.WeightedIdentifier); ok {
+			weight = w.Weight()
+		}
+		ids = append(ids, fmt.Sprintf("%s=%d", id, weight))
+	}
+	return fmt.Sprint(ids)
+}
+
+// fakeControlPlane serves a single aggregated discovery stream, passing the
+// requests it receives and the responses it sends through channels.
+type fakeControlPlane struct {
+	requests  chan *discoveryRequest
+	responses chan *discoveryResponse
+}
+
+func (c *fakeControlPlane) stream(srv interface{}, stream grpc.ServerStream) error {
+	errs := make(chan error, 1)
+	go func() {
+		for {
+			req := new(discoveryRequest)
+			if err := stream.RecvMsg(req); err != nil {
+				errs <- err
+				return
+			}
+			c.requests <- req
+		}
+	}()
+
+	for {
+		select {
+		case err := <-errs:
+			return err
+		case res := <-c.responses:
+			if err := stream.SendMsg(res); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (c *fakeControlPlane) serve(t *testing.T) (addr string, stop func()) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := grpc.NewServer()
+	server.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "envoy.service.discovery.v3.AggregatedDiscoveryService",
+		HandlerType: (*interface{})(nil),
+		Streams: []grpc.StreamDesc{{
+			StreamName:    "StreamAggregatedResources",
+			Handler:       c.stream,
+			ServerStreams: true,
+			ClientStreams: true,
+		}},
+	}, c)
+	go server.Serve(ln)
+	return ln.Addr().String(), server.Stop
+}
+
+func (c *fakeControlPlane) expect(t *testing.T, typeURL, name, version, nonce string, rejected bool) {
+	select {
+	case req := <-c.requests:
+		require.NotNil(t, req.Node)
+		assert.Equal(t, "test-node", req.Node.ID)
+		assert.Equal(t, typeURL, req.TypeURL)
+		assert.Equal(t, []string{name}, req.ResourceNames)
+		assert.Equal(t, version, req.VersionInfo)
+		assert.Equal(t, nonce, req.ResponseNonce)
+		assert.Equal(t, rejected, req.ErrorDetail != nil, "unexpected error detail: %v", req.ErrorDetail)
+	case <-time.After(time.Second):
+		t.Fatalf("expected a request for %v", typeURL)
+	}
+}
+
+func resource(t *testing.T, typeURL string, msg proto.Message) *anyMessage {
+	b, err := proto.Marshal(msg)
+	require.NoError(t, err)
+	return &anyMessage{TypeURL: typeURL, Value: b}
+}
+
+func TestUpdater(t *testing.T) {
+	cp := &fakeControlPlane{
+		requests:  make(chan *discoveryRequest, 10),
+		responses: make(chan *discoveryResponse),
+	}
+	addr, stop := cp.serve(t)
+	defer stop()
+
+	list := peertest.NewFakeList()
+	u := New(list, addr, "mycluster", NodeID("test-node"))
+	require.NoError(t, u.Start())
+	defer u.Stop()
+
+	cp.expect(t, _clusterType, "mycluster", "", "", false)
+	cp.responses <- &discoveryResponse{
+		VersionInfo: "1",
+		TypeURL:     _clusterType,
+		Nonce:       "a",
+		Resources: []*anyMessage{resource(t, _clusterType, &cluster{
+			Name:             "mycluster",
+			EDSClusterConfig: &edsClusterConfig{ServiceName: "myservice"},
+		})},
+	}
+	cp.expect(t, _clusterType, "mycluster", "1", "a", false)
+	cp.expect(t, _endpointType, "myservice", "", "", false)
+
+	cp.responses <- &discoveryResponse{
+		VersionInfo: "1",
+		TypeURL:     _endpointType,
+		Nonce:       "b",
+		Resources: []*anyMessage{resource(t, _endpointType, &clusterLoadAssignment{
+			ClusterName: "myservice",
+			Endpoints: []*localityLBEndpoints{
+				localityEndpoints("a", 0, 0, lbe("10.0.0.1", 2, _healthHealthy), lbe("10.0.0.2", 0, _healthHealthy)),
+			},
+		})},
+	}
+	cp.expect(t, _endpointType, "myservice", "1", "b", false)
+	testtime.WaitFor(t, "assigned endpoints must be added", func() bool {
+		return weights(list) == "[10.0.0.1:8080=2 10.0.0.2:8080=1]"
+	})
+
+	cp.responses <- &discoveryResponse{
+		VersionInfo: "2",
+		TypeURL:     _endpointType,
+		Nonce:       "c",
+		Resources:   []*anyMessage{{TypeURL: _endpointType, Value: []byte("not a protobuf")}},
+	}
+	cp.expect(t, _endpointType, "myservice", "1", "c", true)
+
+	cp.responses <- &discoveryResponse{
+		VersionInfo: "3",
+		TypeURL:     _endpointType,
+		Nonce:       "d",
+		Resources: []*anyMessage{resource(t, _endpointType, &clusterLoadAssignment{
+			ClusterName: "myservice",
+			Endpoints: []*localityLBEndpoints{
+				localityEndpoints("a", 0, 0, lbe("10.0.0.1", 5, _healthHealthy), lbe("10.0.0.3", 0, _healthHealthy)),
+			},
+		})},
+	}
+	cp.expect(t, _endpointType, "myservice", "3", "d", false)
+	testtime.WaitFor(t, "changed endpoints must be applied", func() bool {
+		return weights(list) == "[10.0.0.1:8080=5 10.0.0.3:8080=1]"
+	})
+
+	require.NoError(t, u.Stop())
+	assert.False(t, u.IsRunning())
+}
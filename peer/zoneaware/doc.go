@@ -0,0 +1,43 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package zoneaware provides a peer list that prefers peers in the caller's
+// own zone, spilling requests over to peers in other zones only when the
+// local zone is short of healthy peers.
+//
+// The list wraps two peer lists of any kind, such as round-robin lists: one
+// for peers in the local zone and one for all other peers. Each peer's zone
+// comes either from the peer list updater, by adding identifiers that
+// implement ZonedIdentifier or carry a zone attribute, or from the PeerZone
+// option. Peers with no known zone are treated as remote.
+//
+// 	list := zoneaware.New(
+// 		"us-east-1a",
+// 		roundrobin.New(transport),
+// 		roundrobin.New(transport),
+// 		zoneaware.SpilloverThreshold(0.5),
+// 	)
+//
+// While the fraction of available local peers is at or above the spillover
+// threshold, every request is sent to the local zone. Below the threshold,
+// a growing share of requests is sent to other zones, in proportion to the
+// shortfall, until all requests go to other zones when no local peer is
+// available.
+package zoneaware
@@ -0,0 +1,290 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zoneaware
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"go.uber.org/multierr"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/introspection"
+	"go.uber.org/yarpc/peer/hostport"
+)
+
+var _ peer.ChooserList = (*List)(nil)
+
+// ZoneList is a peer list holding the peers of one side of a zone-aware
+// list. The lists in go.uber.org/yarpc/peer, such as round-robin, satisfy
+// this interface.
+type ZoneList interface {
+	peer.ChooserList
+
+	// NumAvailable returns the number of peers that can be chosen.
+	NumAvailable() int
+	// NumUnavailable returns the number of peers that are retained but
+	// cannot currently be chosen.
+	NumUnavailable() int
+}
+
+// ZonedIdentifier is a peer identifier that carries the zone of the peer.
+// Peer list updaters may add ZonedIdentifiers to a zone-aware list.
+type ZonedIdentifier interface {
+	peer.Identifier
+
+	Zone() string
+}
+
+// Zoned attaches a zone to a peer identifier.
+func Zoned(id peer.Identifier, zone string) ZonedIdentifier {
+	return zonedIdentifier{id: id, zone: zone}
+}
+
+type zonedIdentifier struct {
+	id peer.Identifier
+
+	zone string
+}
+
+func (i zonedIdentifier) Identifier() string { return i.id.Identifier() }
+func (i zonedIdentifier) Zone() string       { return i.zone }
+
+type listOptions struct {
+	threshold float64
+	zones     map[string]string
+	seed      int64
+}
+
+// ListOption customizes the behavior of a zone-aware list.
+type ListOption func(*listOptions)
+
+// SpilloverThreshold specifies the fraction of local peers, between 0 and 1,
+// that must be available for all requests to stay in the local zone.
+//
+// Defaults to 0.5.
+func SpilloverThreshold(threshold float64) ListOption {
+	return func(o *listOptions) {
+		o.threshold = threshold
+	}
+}
+
+// PeerZone specifies the zone of the peer with the given identifier. Zones
+// provided by a peer list updater through ZonedIdentifier take precedence
+// over this option.
+func PeerZone(id, zone string) ListOption {
+	return func(o *listOptions) {
+		o.zones[id] = zone
+	}
+}
+
+// Seed specifies the random seed used to decide which requests spill over.
+//
+// Defaults to approximately the process start time in nanoseconds.
+func Seed(seed int64) ListOption {
+	return func(o *listOptions) {
+		o.seed = seed
+	}
+}
+
+// List is a peer list that prefers peers in the local zone.
+type List struct {
+	zone   string
+	local  ZoneList
+	remote ZoneList
+
+	threshold float64
+	zones     map[string]string
+
+	mu sync.Mutex
+	// isLocal records which list each peer was added to, by normalized
+	// identifier, so that it can be removed from the same list and requests
+	// pinned to it can be sent there.
+	isLocal map[string]bool
+	rand    *rand.Rand
+}
+
+// New creates a zone-aware list for a caller in the given zone. Peers in that
+// zone are added to the local list and all other peers to the remote list.
+// The zone-aware list takes ownership of both lists, starting and stopping
+// them with itself.
+func New(zone string, local, remote ZoneList, opts ...ListOption) *List {
+	options := listOptions{
+		threshold: 0.5,
+		zones:     make(map[string]string),
+		seed:      time.Now().UnixNano(),
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+
+	return &List{
+		zone:      zone,
+		local:     local,
+		remote:    remote,
+		threshold: options.threshold,
+		zones:     options.zones,
+		isLocal:   make(map[string]bool),
+		rand:      rand.New(rand.NewSource(options.seed)),
+	}
+}
+
+// Update partitions the additions and removals by zone and applies them to
+// the local and remote lists.
+func (l *List) Update(updates peer.ListUpdates) error {
+	var local, remote peer.ListUpdates
+
+	l.mu.Lock()
+	for _, pid := range updates.Removals {
+		if l.isLocal[peerKey(pid)] {
+			local.Removals = append(local.Removals, pid)
+		} else {
+			remote.Removals = append(remote.Removals, pid)
+		}
+		delete(l.isLocal, peerKey(pid))
+	}
+	for _, pid := range updates.Additions {
+		if l.zoneOf(pid) == l.zone {
+			local.Additions = append(local.Additions, pid)
+			l.isLocal[peerKey(pid)] = true
+		} else {
+			remote.Additions = append(remote.Additions, pid)
+		}
+	}
+	l.mu.Unlock()
+
+	return multierr.Combine(
+		l.local.Update(local),
+		l.remote.Update(remote),
+	)
+}
+
+func (l *List) zoneOf(pid peer.Identifier) string {
+	if zone := peer.AttributesOf(pid).Zone(); zone != "" {
+		return zone
+	}
+	return l.zones[pid.Identifier()]
+}
+
+// peerKey returns the key of a peer in isLocal.
+func peerKey(pid peer.Identifier) string {
+	return hostport.Identify(pid.Identifier()).Identifier()
+}
+
+// Choose chooses a peer from the local list, or from the remote list if the
+// request spills over. Requests pinned to a peer are sent to the list the
+// peer was added to.
+func (l *List) Choose(ctx context.Context, req *transport.Request) (peer.Peer, func(error), error) {
+	if id, ok := peer.PinnedPeer(ctx); ok {
+		return l.pinnedList(id).Choose(ctx, req)
+	}
+	if l.spillover() {
+		return l.remote.Choose(ctx, req)
+	}
+	return l.local.Choose(ctx, req)
+}
+
+func (l *List) pinnedList(id string) ZoneList {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.isLocal[peerKey(hostport.PeerIdentifier(id))] {
+		return l.local
+	}
+	return l.remote
+}
+
+// spillover decides whether the next request should be sent to the remote
+// list.
+func (l *List) spillover() bool {
+	localAvailable := l.local.NumAvailable()
+	localTotal := localAvailable + l.local.NumUnavailable()
+	remoteAvailable := l.remote.NumAvailable()
+
+	switch {
+	case localAvailable == 0:
+		// Wait for a local peer only if there is no remote peer to use.
+		return remoteAvailable > 0 || localTotal == 0
+	case remoteAvailable == 0:
+		return false
+	}
+
+	healthy := float64(localAvailable) / float64(localTotal)
+	if healthy >= l.threshold {
+		return false
+	}
+
+	// Spill over in proportion to the shortfall below the threshold.
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.rand.Float64() >= healthy/l.threshold
+}
+
+// Start starts the local and remote lists.
+func (l *List) Start() error {
+	return multierr.Combine(l.local.Start(), l.remote.Start())
+}
+
+// Stop stops the local and remote lists.
+func (l *List) Stop() error {
+	return multierr.Combine(l.local.Stop(), l.remote.Stop())
+}
+
+// IsRunning returns whether both lists are running.
+func (l *List) IsRunning() bool {
+	return l.local.IsRunning() && l.remote.IsRunning()
+}
+
+// Introspect returns the status of the local and remote lists, with the
+// peers of both.
+func (l *List) Introspect() introspection.ChooserStatus {
+	state := "Stopped"
+	if l.IsRunning() {
+		state = "Running"
+	}
+
+	var peers []introspection.PeerStatus
+	for _, side := range []struct {
+		name string
+		list ZoneList
+	}{
+		{"local", l.local},
+		{"remote", l.remote},
+	} {
+		if ic, ok := side.list.(introspection.IntrospectableChooser); ok {
+			for _, ps := range ic.Introspect().Peers {
+				ps.State = fmt.Sprintf("%s, %s", side.name, ps.State)
+				peers = append(peers, ps)
+			}
+		}
+	}
+
+	localAvailable, remoteAvailable := l.local.NumAvailable(), l.remote.NumAvailable()
+	return introspection.ChooserStatus{
+		Name: "zone-aware",
+		State: fmt.Sprintf("%s in zone %q (%d/%d local, %d/%d remote available)", state, l.zone,
+			localAvailable, localAvailable+l.local.NumUnavailable(),
+			remoteAvailable, remoteAvailable+l.remote.NumUnavailable()),
+		Peers: peers,
+	}
+}
@@ -0,0 +1,187 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zoneaware
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/peer/hostport"
+	"go.uber.org/yarpc/peer/roundrobin"
+	"go.uber.org/yarpc/yarpctest"
+)
+
+func TestZoneAwarePartitionsPeers(t *testing.T) {
+	local := roundrobin.New(yarpctest.NewFakeTransport())
+	remote := roundrobin.New(yarpctest.NewFakeTransport())
+	pl := New("zone-a", local, remote, PeerZone("c:1", "zone-a"), PeerZone("d:1", "zone-a"))
+	require.NoError(t, pl.Start())
+	defer pl.Stop()
+	assert.True(t, pl.IsRunning())
+
+	require.NoError(t, pl.Update(peer.ListUpdates{Additions: []peer.Identifier{
+		Zoned(hostport.PeerIdentifier("a:1"), "zone-a"),
+		// Zones may also be given as attributes.
+		peer.WithAttributes(hostport.PeerIdentifier("b:1"), peer.Attributes{peer.ZoneAttribute: "zone-b"}),
+		hostport.PeerIdentifier("c:1"),
+		// The updater's zone takes precedence.
+		Zoned(hostport.PeerIdentifier("d:1"), "zone-b"),
+		hostport.PeerIdentifier("e:1"),
+	}}))
+	assert.Equal(t, 2, local.NumAvailable())
+	assert.Equal(t, 3, remote.NumAvailable())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for i := 0; i < 10; i++ {
+		p, onFinish, err := pl.Choose(ctx, &transport.Request{})
+		require.NoError(t, err)
+		onFinish(nil)
+		assert.Contains(t, []string{"a:1", "c:1"}, p.Identifier(), "healthy local zone must be preferred")
+	}
+
+	// Removals apply to the list each peer was added to.
+	require.NoError(t, pl.Update(peer.ListUpdates{Removals: []peer.Identifier{
+		hostport.PeerIdentifier("a:1"),
+		hostport.PeerIdentifier("c:1"),
+		hostport.PeerIdentifier("d:1"),
+	}}))
+	assert.Equal(t, 0, local.NumAvailable())
+	assert.Equal(t, 2, remote.NumAvailable())
+
+	p, onFinish, err := pl.Choose(ctx, &transport.Request{})
+	require.NoError(t, err, "requests must spill over when the local zone is empty")
+	onFinish(nil)
+	assert.Contains(t, []string{"b:1", "e:1"}, p.Identifier())
+}
+
+// fakeZoneList is a ZoneList with fixed peer counts.
+type fakeZoneList struct {
+	*roundrobin.List
+
+	available   int
+	unavailable int
+}
+
+func newFakeZoneList(available, unavailable int) *fakeZoneList {
+	return &fakeZoneList{
+		List:        roundrobin.New(yarpctest.NewFakeTransport()),
+		available:   available,
+		unavailable: unavailable,
+	}
+}
+
+func (l *fakeZoneList) NumAvailable() int   { return l.available }
+func (l *fakeZoneList) NumUnavailable() int { return l.unavailable }
+
+func TestZoneAwarePinnedPeer(t *testing.T) {
+	local := roundrobin.New(yarpctest.NewFakeTransport())
+	remote := roundrobin.New(yarpctest.NewFakeTransport())
+	pl := New("zone-a", local, remote)
+	require.NoError(t, pl.Start())
+	defer pl.Stop()
+
+	require.NoError(t, pl.Update(peer.ListUpdates{Additions: []peer.Identifier{
+		Zoned(hostport.PeerIdentifier("a:1"), "zone-a"),
+		Zoned(hostport.PeerIdentifier("b:1"), "zone-b"),
+	}}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for _, id := range []string{"a:1", "B:1"} {
+		p, onFinish, err := pl.Choose(peer.ContextWithPinnedPeer(ctx, id), &transport.Request{})
+		require.NoError(t, err, "pinned requests must be sent to the zone of the peer")
+		onFinish(nil)
+		assert.Equal(t, hostport.Identify(id).Identifier(), p.Identifier())
+	}
+
+	_, _, err := pl.Choose(peer.ContextWithPinnedPeer(ctx, "c:1"), &transport.Request{})
+	assert.Error(t, err)
+}
+
+func TestZoneAwareSpillover(t *testing.T) {
+	tests := []struct {
+		desc             string
+		localAvailable   int
+		localUnavailable int
+		remoteAvailable  int
+		wantRemoteMin    int
+		wantRemoteMax    int
+	}{
+		{
+			desc:             "healthy local zone",
+			localAvailable:   5,
+			localUnavailable: 5,
+			remoteAvailable:  10,
+			wantRemoteMin:    0,
+			wantRemoteMax:    0,
+		},
+		{
+			desc:             "degraded local zone",
+			localAvailable:   1,
+			localUnavailable: 3,
+			remoteAvailable:  10,
+			// A quarter of local peers are available, half of the 0.5
+			// threshold, so about half of requests spill over.
+			wantRemoteMin: 400,
+			wantRemoteMax: 600,
+		},
+		{
+			desc:             "no available local peers",
+			localUnavailable: 4,
+			remoteAvailable:  10,
+			wantRemoteMin:    1000,
+			wantRemoteMax:    1000,
+		},
+		{
+			desc:             "no available remote peers",
+			localAvailable:   1,
+			localUnavailable: 9,
+			wantRemoteMin:    0,
+			wantRemoteMax:    0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			pl := New("zone-a",
+				newFakeZoneList(tt.localAvailable, tt.localUnavailable),
+				newFakeZoneList(tt.remoteAvailable, 0),
+				Seed(1),
+			)
+
+			var remote int
+			for i := 0; i < 1000; i++ {
+				if pl.spillover() {
+					remote++
+				}
+			}
+			assert.True(t, remote >= tt.wantRemoteMin && remote <= tt.wantRemoteMax,
+				"%d of 1000 requests spilled over, want between %d and %d",
+				remote, tt.wantRemoteMin, tt.wantRemoteMax)
+		})
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zookeeper
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net"
+	"strconv"
+)
+
+// Client is the part of a ZooKeeper client the updater needs.
+type Client interface {
+	// ChildrenW returns the names of the children of the node at the path,
+	// and a channel that is closed or receives a value once they change.
+	ChildrenW(path string) (children []string, changed <-chan struct{}, err error)
+
+	// Get returns the data of the node at the path.
+	Get(path string) ([]byte, error)
+}
+
+// Decoder returns the host:port address of an instance from the name and
+// data of its node.
+type Decoder func(name string, data []byte) (string, error)
+
+// curatorInstance holds the fields of a Curator ServiceInstance the updater
+// needs.
+type curatorInstance struct {
+	Address string `json:"address"`
+	Port    *int   `json:"port"`
+	SSLPort *int   `json:"sslPort"`
+}
+
+// decodeInstance decodes a Curator ServiceInstance or a host:port address
+// from the data of the node, or uses the name of the node if it has no data.
+func decodeInstance(name string, data []byte) (string, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return name, nil
+	}
+	if data[0] != '{' {
+		return string(data), nil
+	}
+
+	var inst curatorInstance
+	if err := json.Unmarshal(data, &inst); err != nil {
+		return "", err
+	}
+	port := inst.Port
+	if port == nil {
+		port = inst.SSLPort
+	}
+	if inst.Address == "" || port == nil {
+		return "", errors.New("service instance has no address or port")
+	}
+	return net.JoinHostPort(inst.Address, strconv.Itoa(*port)), nil
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package zookeeper provides a peer list updater that tracks the ephemeral
+// children of a service path in ZooKeeper, as written by Curator service
+// discovery and similar registries.
+//
+// Instances register themselves by creating an ephemeral node under the
+// service path, which ZooKeeper deletes when their session ends. The updater
+// lists the children of the path, reads the address of every new child, and
+// lists them again whenever ZooKeeper notifies it that they changed.
+//
+// The address of a child is read from its data, which may be a Curator
+// ServiceInstance in JSON, with address and port fields, or a plain host:port
+// string. Children without data are expected to be named by their host:port
+// address. The WithDecoder option handles other formats.
+//
+// This package implements the updater on top of the Client interface only.
+// It does not include a ZooKeeper client, and its tests run against an
+// in-memory Client rather than a ZooKeeper ensemble. Applications adapt the
+// client they already use to the Client interface, for example with
+// github.com/samuel/go-zookeeper:
+//
+// 	type zkClient struct{ conn *zk.Conn }
+//
+// 	func (c zkClient) ChildrenW(path string) ([]string, <-chan struct{}, error) {
+// 		children, _, events, err := c.conn.ChildrenW(path)
+// 		if err != nil {
+// 			return nil, nil, err
+// 		}
+// 		changed := make(chan struct{})
+// 		go func() {
+// 			<-events
+// 			close(changed)
+// 		}()
+// 		return children, changed, nil
+// 	}
+//
+// 	func (c zkClient) Get(path string) ([]byte, error) {
+// 		data, _, err := c.conn.Get(path)
+// 		return data, err
+// 	}
+//
+// The updater is then bound to a peer list like any other:
+//
+// 	list := roundrobin.New(transport)
+// 	chooser := peer.Bind(list, zookeeper.Binder(zkClient{conn}, "/services/myservice"))
+package zookeeper
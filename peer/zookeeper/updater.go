@@ -0,0 +1,210 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zookeeper
+
+import (
+	"context"
+	"path"
+	"sort"
+	"time"
+
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/peer/hostport"
+	"go.uber.org/yarpc/pkg/lifecycle"
+	"go.uber.org/zap"
+)
+
+var _ transport.Lifecycle = (*Updater)(nil)
+
+// Updater is a peer list updater that keeps a peer list in sync with the
+// children of a service path in ZooKeeper.
+type Updater struct {
+	list   peer.List
+	client Client
+	path   string
+	opts   options
+	once   *lifecycle.Once
+
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	// The following are only accessed by the watch goroutine.
+
+	// children holds the address of every child that was read, by name.
+	// Ephemeral nodes are not expected to change, so a child is only read
+	// once.
+	children map[string]string
+	// peers holds the addresses sent to the list.
+	peers map[string]struct{}
+}
+
+// New creates an updater that sends the addresses of the children of the
+// service path to the peer list once started.
+func New(list peer.List, client Client, path string, opts ...Option) *Updater {
+	return &Updater{
+		list:     list,
+		client:   client,
+		path:     path,
+		opts:     newOptions(opts),
+		once:     lifecycle.NewOnce(),
+		done:     make(chan struct{}),
+		children: make(map[string]string),
+		peers:    make(map[string]struct{}),
+	}
+}
+
+// Binder returns a peer.Binder that binds peer lists to an updater for the
+// service path.
+func Binder(client Client, path string, opts ...Option) peer.Binder {
+	return func(list peer.List) transport.Lifecycle {
+		return New(list, client, path, opts...)
+	}
+}
+
+// Start starts watching the children of the service path. Failed lists are
+// logged and retried.
+func (u *Updater) Start() error {
+	return u.once.Start(func() error {
+		ctx, cancel := context.WithCancel(context.Background())
+		u.cancel = cancel
+		go u.run(ctx)
+		return nil
+	})
+}
+
+// Stop stops watching the children. Peers already sent to the list remain.
+// Stop waits for a ZooKeeper request in flight to return.
+func (u *Updater) Stop() error {
+	return u.once.Stop(func() error {
+		if u.cancel == nil {
+			// Never started watching.
+			return nil
+		}
+		u.cancel()
+		<-u.done
+		return nil
+	})
+}
+
+// IsRunning returns whether the updater is running.
+func (u *Updater) IsRunning() bool {
+	return u.once.IsRunning()
+}
+
+// run lists the children of the service path until the context is canceled,
+// listing them again whenever they change.
+func (u *Updater) run(ctx context.Context) {
+	defer close(u.done)
+
+	for {
+		names, changed, err := u.client.ChildrenW(u.path)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			u.opts.logger.Warn("failed to list ZooKeeper children, retrying",
+				zap.String("path", u.path), zap.Error(err))
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(u.opts.retryInterval):
+			}
+			continue
+		}
+
+		u.update(names)
+		select {
+		case <-ctx.Done():
+			return
+		case <-changed:
+		}
+	}
+}
+
+// update reads the children that were added since the last update, forgets
+// those that were removed, and syncs the list.
+func (u *Updater) update(names []string) {
+	children := make(map[string]string, len(names))
+	for _, name := range names {
+		if addr, ok := u.children[name]; ok {
+			children[name] = addr
+			continue
+		}
+
+		data, err := u.client.Get(path.Join(u.path, name))
+		if err != nil {
+			// The child may have been deleted since it was listed, in which
+			// case the next list will not include it.
+			u.opts.logger.Warn("failed to read ZooKeeper child",
+				zap.String("path", u.path), zap.String("child", name), zap.Error(err))
+			continue
+		}
+		addr, err := u.opts.decoder(name, data)
+		if err != nil {
+			u.opts.logger.Warn("failed to decode ZooKeeper child",
+				zap.String("path", u.path), zap.String("child", name), zap.Error(err))
+			continue
+		}
+		children[name] = addr
+	}
+	u.children = children
+	u.sync()
+}
+
+// sync sends the addresses that were added or removed since the last sync to
+// the list.
+func (u *Updater) sync() {
+	addrs := make(map[string]struct{}, len(u.children))
+	for _, addr := range u.children {
+		addrs[addr] = struct{}{}
+	}
+
+	var removals, additions []string
+	for addr := range u.peers {
+		if _, ok := addrs[addr]; !ok {
+			removals = append(removals, addr)
+		}
+	}
+	for addr := range addrs {
+		if _, ok := u.peers[addr]; !ok {
+			additions = append(additions, addr)
+		}
+	}
+	if len(removals) == 0 && len(additions) == 0 {
+		return
+	}
+	sort.Strings(removals)
+	sort.Strings(additions)
+
+	var updates peer.ListUpdates
+	for _, addr := range removals {
+		updates.Removals = append(updates.Removals, hostport.PeerIdentifier(addr))
+	}
+	for _, addr := range additions {
+		updates.Additions = append(updates.Additions, hostport.PeerIdentifier(addr))
+	}
+	if err := u.list.Update(updates); err != nil {
+		u.opts.logger.Warn("failed to update peer list",
+			zap.String("path", u.path), zap.Error(err))
+	}
+	u.peers = addrs
+}
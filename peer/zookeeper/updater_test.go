@@ -0,0 +1,170 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package zookeeper
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/peer/peertest"
+	"go.uber.org/yarpc/internal/testtime"
+)
+
+// fakeClient holds the children of a single path and notifies watchers when
+// they are replaced.
+type fakeClient struct {
+	t *testing.T
+
+	mu       sync.Mutex
+	children map[string][]byte
+	changed  chan struct{}
+	gets     int
+}
+
+func (c *fakeClient) set(children map[string][]byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.children = children
+	if c.changed != nil {
+		close(c.changed)
+		c.changed = nil
+	}
+}
+
+func (c *fakeClient) getCount() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.gets
+}
+
+func (c *fakeClient) ChildrenW(path string) ([]string, <-chan struct{}, error) {
+	assert.Equal(c.t, "/services/myservice", path)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var names []string
+	for name := range c.children {
+		names = append(names, name)
+	}
+	if c.changed == nil {
+		c.changed = make(chan struct{})
+	}
+	return names, c.changed, nil
+}
+
+func (c *fakeClient) Get(path string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gets++
+	for name, data := range c.children {
+		if path == "/services/myservice/"+name {
+			return data, nil
+		}
+	}
+	return nil, errors.New("no node")
+}
+
+func TestUpdater(t *testing.T) {
+	client := &fakeClient{t: t}
+	client.set(map[string][]byte{
+		"a": []byte(`{"name":"myservice","id":"a","address":"10.0.0.1","port":8080,"sslPort":null}`),
+		"b": []byte("10.0.0.2:8080"),
+	})
+
+	list := peertest.NewFakeList()
+	u := New(list, client, "/services/myservice")
+	require.NoError(t, u.Start())
+	defer u.Stop()
+
+	testtime.WaitFor(t, "registered instances must be listed", func() bool {
+		return fmt.Sprint(list.IDs()) == "[10.0.0.1:8080 10.0.0.2:8080]"
+	})
+	assert.Equal(t, 2, client.getCount())
+
+	client.set(map[string][]byte{
+		"b":             []byte("10.0.0.2:8080"),
+		"10.0.0.3:8080": nil,
+	})
+	testtime.WaitFor(t, "changed children must be applied", func() bool {
+		return fmt.Sprint(list.IDs()) == "[10.0.0.2:8080 10.0.0.3:8080]"
+	})
+	assert.Equal(t, 3, client.getCount(), "children must only be read once")
+
+	require.NoError(t, u.Stop())
+	assert.False(t, u.IsRunning())
+}
+
+func TestDecodeInstance(t *testing.T) {
+	tests := []struct {
+		msg     string
+		name    string
+		data    string
+		want    string
+		wantErr bool
+	}{
+		{
+			msg:  "curator instance",
+			data: `{"address":"10.0.0.1","port":8080}`,
+			want: "10.0.0.1:8080",
+		},
+		{
+			msg:  "curator instance with only an SSL port",
+			data: `{"address":"10.0.0.1","port":null,"sslPort":8443}`,
+			want: "10.0.0.1:8443",
+		},
+		{
+			msg:     "curator instance without a port",
+			data:    `{"address":"10.0.0.1"}`,
+			wantErr: true,
+		},
+		{
+			msg:     "invalid JSON",
+			data:    `{"address":`,
+			wantErr: true,
+		},
+		{
+			msg:  "host and port",
+			data: " 10.0.0.1:8080\n",
+			want: "10.0.0.1:8080",
+		},
+		{
+			msg:  "no data",
+			name: "10.0.0.1:8080",
+			want: "10.0.0.1:8080",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.msg, func(t *testing.T) {
+			got, err := decodeInstance(tt.name, []byte(tt.data))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
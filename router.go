@@ -26,6 +26,8 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/internal/humanize"
@@ -49,26 +51,160 @@ type serviceProcedureEncoding struct {
 
 // MapRouter is a Router that maintains a map of the registered
 // procedures.
+//
+// Procedures may be registered and unregistered while requests are being
+// routed. Every change builds a new routing table which replaces the old one
+// atomically, so requests are routed with either the old or the new table.
 type MapRouter struct {
-	defaultService            string
+	defaultService string
+
+	// mu serializes changes to the routing table.
+	mu     *sync.Mutex
+	routes *atomic.Value // *mapRoutes
+}
+
+// mapRoutes is a routing table of a MapRouter. It is never modified once the
+// MapRouter uses it.
+type mapRoutes struct {
 	serviceProcedures         map[serviceProcedure]transport.Procedure
 	serviceProcedureEncodings map[serviceProcedureEncoding]transport.Procedure
 	supportedEncodings        map[serviceProcedure][]string
 	serviceNames              map[string]struct{}
+
+	// aliases maps alternative names of services to the services.
+	aliases map[string]string
+}
+
+func (r *mapRoutes) clone() *mapRoutes {
+	c := &mapRoutes{
+		serviceProcedures:         make(map[serviceProcedure]transport.Procedure, len(r.serviceProcedures)),
+		serviceProcedureEncodings: make(map[serviceProcedureEncoding]transport.Procedure, len(r.serviceProcedureEncodings)),
+		supportedEncodings:        make(map[serviceProcedure][]string, len(r.supportedEncodings)),
+		serviceNames:              make(map[string]struct{}, len(r.serviceNames)),
+		aliases:                   make(map[string]string, len(r.aliases)),
+	}
+	for k, v := range r.serviceProcedures {
+		c.serviceProcedures[k] = v
+	}
+	for k, v := range r.serviceProcedureEncodings {
+		c.serviceProcedureEncodings[k] = v
+	}
+	for k, v := range r.supportedEncodings {
+		c.supportedEncodings[k] = append([]string(nil), v...)
+	}
+	for k, v := range r.serviceNames {
+		c.serviceNames[k] = v
+	}
+	for k, v := range r.aliases {
+		c.aliases[k] = v
+	}
+	return c
+}
+
+// names returns the names of the given service: the service itself followed
+// by its aliases.
+func (r *mapRoutes) names(service string) []string {
+	names := []string{service}
+	for alias, s := range r.aliases {
+		if s == service {
+			names = append(names, alias)
+		}
+	}
+	return names
+}
+
+func (r *mapRoutes) add(p transport.Procedure) {
+	r.serviceNames[p.Service] = struct{}{}
+
+	sp := serviceProcedure{
+		service:   p.Service,
+		procedure: p.Name,
+	}
+
+	if p.Encoding == "" {
+		// Protect against masking encoding-specific routes.
+		if _, ok := r.serviceProcedures[sp]; ok {
+			panic(fmt.Sprintf("Cannot register multiple handlers for every encoding for service %q and procedure  %q", sp.service, sp.procedure))
+		}
+		if se, ok := r.supportedEncodings[sp]; ok {
+			panic(fmt.Sprintf("Cannot register a handler for every encoding for service %q and procedure %q when there are already handlers for %s", sp.service, sp.procedure, humanize.QuotedJoin(se, "and", "no encodings")))
+		}
+		// This supports wild card encodings (for backward compatibility,
+		// since type models like Thrift were not previously required to
+		// specify the encoding of every procedure).
+		r.serviceProcedures[sp] = p
+		return
+	}
+
+	spe := serviceProcedureEncoding{
+		service:   p.Service,
+		procedure: p.Name,
+		encoding:  p.Encoding,
+	}
+
+	// Protect against overriding wildcards
+	if _, ok := r.serviceProcedures[sp]; ok {
+		panic(fmt.Sprintf("Cannot register a handler for both (service, procedure) on any * encoding and (service, procedure, encoding), specifically (%q, %q, %q)", p.Service, p.Name, p.Encoding))
+	}
+	// Route to individual handlers for unique combinations of service,
+	// procedure, and encoding. This shall henceforth be the
+	// recommended way for models to register procedures.
+	r.serviceProcedureEncodings[spe] = p
+	// Record supported encodings.
+	r.supportedEncodings[sp] = append(r.supportedEncodings[sp], string(p.Encoding))
+}
+
+func (r *mapRoutes) remove(p transport.Procedure) {
+	sp := serviceProcedure{
+		service:   p.Service,
+		procedure: p.Name,
+	}
+
+	if p.Encoding == "" {
+		delete(r.serviceProcedures, sp)
+		return
+	}
+
+	delete(r.serviceProcedureEncodings, serviceProcedureEncoding{
+		service:   p.Service,
+		procedure: p.Name,
+		encoding:  p.Encoding,
+	})
+	encodings := r.supportedEncodings[sp][:0]
+	for _, e := range r.supportedEncodings[sp] {
+		if e != string(p.Encoding) {
+			encodings = append(encodings, e)
+		}
+	}
+	if len(encodings) == 0 {
+		delete(r.supportedEncodings, sp)
+	} else {
+		r.supportedEncodings[sp] = encodings
+	}
 }
 
 // NewMapRouter builds a new MapRouter that uses the given name as the
 // default service name.
 func NewMapRouter(defaultService string) MapRouter {
-	return MapRouter{
-		defaultService:            defaultService,
+	routes := new(atomic.Value)
+	routes.Store(&mapRoutes{
 		serviceProcedures:         make(map[serviceProcedure]transport.Procedure),
 		serviceProcedureEncodings: make(map[serviceProcedureEncoding]transport.Procedure),
 		supportedEncodings:        make(map[serviceProcedure][]string),
 		serviceNames:              map[string]struct{}{defaultService: {}},
+		aliases:                   make(map[string]string),
+	})
+	return MapRouter{
+		defaultService: defaultService,
+		mu:             new(sync.Mutex),
+		routes:         routes,
 	}
 }
 
+func (m MapRouter) load() *mapRoutes {
+	return m.routes.Load().(*mapRoutes)
+}
+
 // Register registers the procedure with the MapRouter.
 // If the procedure does not specify its service name, the procedure will
 // inherit the default service name of the router.
@@ -76,7 +212,17 @@ func NewMapRouter(defaultService string) MapRouter {
 // same name and service name can exist if they handle different encodings.
 // If a procedure does not specify an encoding, it can only support one handler.
 // The router will select that handler regardless of the encoding.
+//
+// Procedures are also registered under the aliases of their service, and
+// procedures registered under an alias belong to the service it names.
+//
+// If any of the procedures cannot be registered, Register panics without
+// registering any of them.
 func (m MapRouter) Register(rs []transport.Procedure) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	routes := m.load().clone()
 	for _, r := range rs {
 		if r.Service == "" {
 			r.Service = m.defaultService
@@ -86,55 +232,124 @@ func (m MapRouter) Register(rs []transport.Procedure) {
 			panic("Expected procedure name not to be empty string in registration")
 		}
 
-		m.serviceNames[r.Service] = struct{}{}
-
-		sp := serviceProcedure{
-			service:   r.Service,
-			procedure: r.Name,
+		if service, ok := routes.aliases[r.Service]; ok {
+			r.Service = service
+		}
+		for _, name := range routes.names(r.Service) {
+			r.Service = name
+			routes.add(r)
 		}
+	}
+	m.routes.Store(routes)
+}
 
-		if r.Encoding == "" {
-			// Protect against masking encoding-specific routes.
-			if _, ok := m.serviceProcedures[sp]; ok {
-				panic(fmt.Sprintf("Cannot register multiple handlers for every encoding for service %q and procedure  %q", sp.service, sp.procedure))
-			}
-			if se, ok := m.supportedEncodings[sp]; ok {
-				panic(fmt.Sprintf("Cannot register a handler for every encoding for service %q and procedure %q when there are already handlers for %s", sp.service, sp.procedure, humanize.QuotedJoin(se, "and", "no encodings")))
-			}
-			// This supports wild card encodings (for backward compatibility,
-			// since type models like Thrift were not previously required to
-			// specify the encoding of every procedure).
-			m.serviceProcedures[sp] = r
-			continue
+// RegisterAlias makes the MapRouter route requests for the alias to the
+// procedures of the given service, including procedures registered later,
+// as if they were registered under both names. This lets a service answer
+// for the names of other services, like services it replaces.
+//
+// RegisterAlias panics if the alias already has procedures or aliases of its
+// own.
+func (m MapRouter) RegisterAlias(alias, service string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	routes := m.load().clone()
+	if s, ok := routes.aliases[service]; ok {
+		service = s
+	}
+	if alias == service {
+		panic(fmt.Sprintf("Cannot register service %q as an alias of itself", alias))
+	}
+	if s, ok := routes.aliases[alias]; ok {
+		panic(fmt.Sprintf("Cannot register %q as an alias of service %q, it is already an alias of service %q", alias, service, s))
+	}
+	if len(routes.names(alias)) > 1 {
+		panic(fmt.Sprintf("Cannot register %q as an alias of service %q, it has aliases of its own", alias, service))
+	}
+	for sp := range routes.serviceProcedures {
+		if sp.service == alias {
+			panic(fmt.Sprintf("Cannot register %q as an alias of service %q, it has procedures of its own", alias, service))
+		}
+	}
+	for spe := range routes.serviceProcedureEncodings {
+		if spe.service == alias {
+			panic(fmt.Sprintf("Cannot register %q as an alias of service %q, it has procedures of its own", alias, service))
 		}
+	}
 
-		spe := serviceProcedureEncoding{
-			service:   r.Service,
-			procedure: r.Name,
-			encoding:  r.Encoding,
+	var procedures []transport.Procedure
+	for _, p := range routes.serviceProcedures {
+		if p.Service == service {
+			procedures = append(procedures, p)
+		}
+	}
+	for _, p := range routes.serviceProcedureEncodings {
+		if p.Service == service {
+			procedures = append(procedures, p)
 		}
+	}
+
+	routes.aliases[alias] = service
+	routes.serviceNames[alias] = struct{}{}
+	for _, p := range procedures {
+		p.Service = alias
+		routes.add(p)
+	}
+	m.routes.Store(routes)
+}
 
-		// Protect against overriding wildcards
-		if _, ok := m.serviceProcedures[sp]; ok {
-			panic(fmt.Sprintf("Cannot register a handler for both (service, procedure) on any * encoding and (service, procedure, encoding), specifically (%q, %q, %q)", r.Service, r.Name, r.Encoding))
+// Unregister removes the procedures with the same service, name, and
+// encoding as the given procedures from the MapRouter, along with their
+// copies under the aliases of the service. If a procedure does not specify
+// its service name, it is removed from the default service of the router.
+// Procedures that are not registered are ignored.
+//
+// Requests that are being handled by a removed procedure are not affected.
+func (m MapRouter) Unregister(rs []transport.Procedure) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	routes := m.load().clone()
+	for _, r := range rs {
+		if r.Service == "" {
+			r.Service = m.defaultService
+		}
+		if service, ok := routes.aliases[r.Service]; ok {
+			r.Service = service
+		}
+		for _, name := range routes.names(r.Service) {
+			r.Service = name
+			routes.remove(r)
 		}
-		// Route to individual handlers for unique combinations of service,
-		// procedure, and encoding. This shall henceforth be the
-		// recommended way for models to register procedures.
-		m.serviceProcedureEncodings[spe] = r
-		// Record supported encodings.
-		m.supportedEncodings[sp] = append(m.supportedEncodings[sp], string(r.Encoding))
 	}
+
+	// Forget services without procedures, other than the default service
+	// and aliases.
+	services := map[string]struct{}{m.defaultService: {}}
+	for alias := range routes.aliases {
+		services[alias] = struct{}{}
+	}
+	for sp := range routes.serviceProcedures {
+		services[sp.service] = struct{}{}
+	}
+	for spe := range routes.serviceProcedureEncodings {
+		services[spe.service] = struct{}{}
+	}
+	routes.serviceNames = services
+
+	m.routes.Store(routes)
 }
 
 // Procedures returns a list procedures that
 // have been registered so far.
 func (m MapRouter) Procedures() []transport.Procedure {
-	procs := make([]transport.Procedure, 0, len(m.serviceProcedures)+len(m.serviceProcedureEncodings))
-	for _, v := range m.serviceProcedures {
+	routes := m.load()
+	procs := make([]transport.Procedure, 0, len(routes.serviceProcedures)+len(routes.serviceProcedureEncodings))
+	for _, v := range routes.serviceProcedures {
 		procs = append(procs, v)
 	}
-	for _, v := range m.serviceProcedureEncodings {
+	for _, v := range routes.serviceProcedureEncodings {
 		procs = append(procs, v)
 	}
 	sort.Sort(sortableProcedures(procs))
@@ -159,15 +374,16 @@ func (ps sortableProcedures) Swap(i int, j int) {
 // noted on the transport request, or returns an unrecognized procedure error
 // (testable with transport.IsUnrecognizedProcedureError(err)).
 func (m MapRouter) Choose(ctx context.Context, req *transport.Request) (transport.HandlerSpec, error) {
+	routes := m.load()
 	service, procedure, encoding := req.Service, req.Procedure, req.Encoding
 	if service == "" {
 		service = m.defaultService
 	}
 
-	if _, ok := m.serviceNames[service]; !ok {
+	if _, ok := routes.serviceNames[service]; !ok {
 		return transport.HandlerSpec{},
 			yarpcerrors.Newf(yarpcerrors.CodeUnimplemented, "unrecognized service name %q, "+
-				"available services: %s", req.Service, getAvailableServiceNames(m.serviceNames))
+				"available services: %s", req.Service, getAvailableServiceNames(routes.serviceNames))
 	}
 
 	// Fully specified combinations of service, procedure, and encoding.
@@ -176,7 +392,7 @@ func (m MapRouter) Choose(ctx context.Context, req *transport.Request) (transpor
 		procedure: procedure,
 		encoding:  encoding,
 	}
-	if procedure, ok := m.serviceProcedureEncodings[spe]; ok {
+	if procedure, ok := routes.serviceProcedureEncodings[spe]; ok {
 		return procedure.HandlerSpec, nil
 	}
 
@@ -186,12 +402,12 @@ func (m MapRouter) Choose(ctx context.Context, req *transport.Request) (transpor
 		service:   service,
 		procedure: procedure,
 	}
-	if procedure, ok := m.serviceProcedures[sp]; ok {
+	if procedure, ok := routes.serviceProcedures[sp]; ok {
 		return procedure.HandlerSpec, nil
 	}
 
 	// Supported procedure, unrecognized encoding.
-	if wantEncodings := m.supportedEncodings[sp]; len(wantEncodings) == 1 {
+	if wantEncodings := routes.supportedEncodings[sp]; len(wantEncodings) == 1 {
 		// To maintain backward compatibility with the error messages provided
 		// on the wire (as verified by Crossdock across all language
 		// implementations), this routes an invalid encoding to the sole
@@ -199,7 +415,7 @@ func (m MapRouter) Choose(ctx context.Context, req *transport.Request) (transpor
 		// The handler is then responsible for detecting the invalid encoding
 		// and providing an error including "failed to decode".
 		spe.encoding = transport.Encoding(wantEncodings[0])
-		return m.serviceProcedureEncodings[spe].HandlerSpec, nil
+		return routes.serviceProcedureEncodings[spe].HandlerSpec, nil
 	}
 
 	return transport.HandlerSpec{}, yarpcerrors.Newf(yarpcerrors.CodeUnimplemented, "unrecognized procedure %q for service %q", req.Procedure, req.Service)
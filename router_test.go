@@ -24,6 +24,7 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"sync"
 	"testing"
 
 	"github.com/golang/mock/gomock"
@@ -331,3 +332,117 @@ func TestUnknownServiceName(t *testing.T) {
 	})
 	assert.Contains(t, err.Error(), `unrecognized service name "wrongService", available services: "service1", "service2"`)
 }
+
+func TestMapRouterUnregister(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	m := NewMapRouter("myservice")
+	foo := transporttest.NewMockUnaryHandler(mockCtrl)
+	bar := transporttest.NewMockUnaryHandler(mockCtrl)
+	bazJSON := transporttest.NewMockUnaryHandler(mockCtrl)
+	bazThrift := transporttest.NewMockUnaryHandler(mockCtrl)
+	m.Register([]transport.Procedure{
+		{Name: "foo", HandlerSpec: transport.NewUnaryHandlerSpec(foo)},
+		{Name: "bar", Service: "anotherservice", HandlerSpec: transport.NewUnaryHandlerSpec(bar)},
+		{Name: "baz", Encoding: "json", HandlerSpec: transport.NewUnaryHandlerSpec(bazJSON)},
+		{Name: "baz", Encoding: "thrift", HandlerSpec: transport.NewUnaryHandlerSpec(bazThrift)},
+	})
+
+	m.Unregister([]transport.Procedure{
+		{Name: "foo"},
+		{Name: "bar", Service: "anotherservice"},
+		{Name: "baz", Encoding: "json"},
+		{Name: "unknown"},
+	})
+	assert.Equal(t, []transport.Procedure{
+		{Name: "baz", Service: "myservice", Encoding: "thrift", HandlerSpec: transport.NewUnaryHandlerSpec(bazThrift)},
+	}, m.Procedures())
+
+	_, err := m.Choose(context.Background(), &transport.Request{Service: "myservice", Procedure: "foo"})
+	assert.Error(t, err, "unregistered procedures must not be routed")
+	_, err = m.Choose(context.Background(), &transport.Request{Service: "anotherservice", Procedure: "bar"})
+	assert.Contains(t, err.Error(), `unrecognized service name "anotherservice"`, "services without procedures must be forgotten")
+
+	spec, err := m.Choose(context.Background(), &transport.Request{Service: "myservice", Procedure: "baz", Encoding: "json"})
+	assert.NoError(t, err, "the sole remaining encoding must handle other encodings")
+	assert.Equal(t, bazThrift, spec.Unary())
+
+	// Procedures can be registered again after they are unregistered.
+	m.Register([]transport.Procedure{{Name: "foo", HandlerSpec: transport.NewUnaryHandlerSpec(foo)}})
+	spec, err = m.Choose(context.Background(), &transport.Request{Service: "myservice", Procedure: "foo"})
+	assert.NoError(t, err)
+	assert.Equal(t, foo, spec.Unary())
+}
+
+func TestMapRouterFailedRegister(t *testing.T) {
+	m := NewMapRouter("myservice")
+	assert.Panics(t, func() {
+		m.Register([]transport.Procedure{{Name: "foo"}, {Name: ""}})
+	})
+	assert.Empty(t, m.Procedures(), "procedures must not be registered if any fail")
+}
+
+func TestMapRouterConcurrentChanges(t *testing.T) {
+	m := NewMapRouter("myservice")
+	m.Register([]transport.Procedure{{Name: "stable"}})
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			procedure := []transport.Procedure{{Name: fmt.Sprintf("plugin-%d", i)}}
+			m.Register(procedure)
+			m.Unregister(procedure)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			_, err := m.Choose(context.Background(), &transport.Request{Procedure: "stable"})
+			assert.NoError(t, err)
+			m.Procedures()
+		}
+	}()
+	wg.Wait()
+	assert.Len(t, m.Procedures(), 1)
+}
+
+func TestMapRouterAliases(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	m := NewMapRouter("myservice")
+	foo := transporttest.NewMockUnaryHandler(mockCtrl)
+	bar := transporttest.NewMockUnaryHandler(mockCtrl)
+	m.Register([]transport.Procedure{{Name: "foo", Encoding: "json", HandlerSpec: transport.NewUnaryHandlerSpec(foo)}})
+	m.RegisterAlias("legacy", "myservice")
+	m.Register([]transport.Procedure{{Name: "bar", Service: "legacy", HandlerSpec: transport.NewUnaryHandlerSpec(bar)}})
+
+	for _, service := range []string{"myservice", "legacy"} {
+		spec, err := m.Choose(context.Background(), &transport.Request{Service: service, Procedure: "foo", Encoding: "json"})
+		if assert.NoError(t, err, "foo of %q must be routed", service) {
+			assert.Equal(t, foo, spec.Unary())
+		}
+		spec, err = m.Choose(context.Background(), &transport.Request{Service: service, Procedure: "bar"})
+		if assert.NoError(t, err, "bar of %q must be routed", service) {
+			assert.Equal(t, bar, spec.Unary())
+		}
+	}
+
+	var services []string
+	for _, p := range m.Procedures() {
+		services = append(services, p.Service+"::"+p.Name)
+	}
+	assert.Equal(t, []string{"legacy::bar", "legacy::foo", "myservice::bar", "myservice::foo"}, services,
+		"procedures must be listed under aliases too")
+
+	m.Unregister([]transport.Procedure{{Name: "foo", Encoding: "json"}})
+	_, err := m.Choose(context.Background(), &transport.Request{Service: "legacy", Procedure: "foo", Encoding: "json"})
+	assert.Error(t, err, "unregistered procedures must be removed from aliases")
+
+	assert.Panics(t, func() { m.RegisterAlias("myservice", "legacy") }, "services with procedures cannot be aliases")
+	assert.Panics(t, func() { m.RegisterAlias("legacy", "other") }, "aliases cannot be registered twice")
+	assert.Panics(t, func() { m.RegisterAlias("myservice", "myservice") }, "services cannot be aliases of themselves")
+}
@@ -28,12 +28,15 @@ import (
 	"go.uber.org/yarpc"
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/internal/bufferpool"
+	"go.uber.org/yarpc/internal/tlsidentity"
 	"go.uber.org/yarpc/yarpcerrors"
 	"go.uber.org/zap"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 )
 
@@ -90,6 +93,15 @@ func (h *handler) getBasicTransportRequest(ctx context.Context, streamMethod str
 		return nil, err
 	}
 	transportRequest.Transport = transportName
+	transportRequest.TransportHeaders = fromIncomingMetadata(md)
+	if p, ok := peer.FromContext(ctx); ok {
+		if p.Addr != nil {
+			transportRequest.RemoteAddr = p.Addr.String()
+		}
+		if tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			transportRequest.CallerIdentity = tlsidentity.FromConnectionState(&tlsInfo.State)
+		}
+	}
 
 	procedure, err := procedureFromStreamMethod(streamMethod)
 	if err != nil {
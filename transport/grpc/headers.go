@@ -66,6 +66,17 @@ const (
 	// if there was an application error.
 	ApplicationErrorHeader = "rpc-application-error"
 
+	// ApplicationErrorNameHeader is the header key for the name of the
+	// application error.
+	ApplicationErrorNameHeader = "rpc-application-error-name"
+	// ApplicationErrorMessageHeader is the header key for the message of the
+	// application error.
+	ApplicationErrorMessageHeader = "rpc-application-error-message"
+	// ApplicationErrorDetailsHeader is the header key for the details of the
+	// application error. It is a binary header, which gRPC encodes with
+	// base64.
+	ApplicationErrorDetailsHeader = "rpc-application-error-details-bin"
+
 	// ApplicationErrorHeaderValue is the value that will be set for
 	// ApplicationErrorHeader is there was an application error.
 	//
@@ -145,6 +156,18 @@ func metadataToTransportRequest(md metadata.MD) (*transport.Request, error) {
 	return request, nil
 }
 
+// fromIncomingMetadata returns the first value of each entry of the given
+// metadata, as the transport headers of an inbound request.
+func fromIncomingMetadata(md metadata.MD) transport.Headers {
+	headers := transport.NewHeadersWithCapacity(md.Len())
+	for k, vs := range md {
+		if len(vs) > 0 {
+			headers = headers.With(k, vs[0])
+		}
+	}
+	return headers
+}
+
 // addApplicationHeaders adds the headers to md.
 func addApplicationHeaders(md metadata.MD, headers transport.Headers) error {
 	for header, value := range headers.Items() {
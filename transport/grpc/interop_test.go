@@ -0,0 +1,283 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/yarpc/yarpctest/grpcinterop"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// These tests run the YARPC gRPC transport against stock grpc-go clients
+// and servers to guard wire compatibility.
+
+func TestInteropOutboundUnary(t *testing.T) {
+	t.Parallel()
+	withReferenceServer(t, func(t *testing.T, out *Outbound) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		res, err := out.Call(ctx, &transport.Request{
+			Caller:    "caller",
+			Service:   "service",
+			Encoding:  "raw",
+			Procedure: "Echo::Unary",
+			Headers:   transport.NewHeaders().With("foo", "bar"),
+			Body:      bytes.NewReader([]byte("hello")),
+		})
+		require.NoError(t, err)
+		body, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "hello", string(body))
+
+		foo, _ := res.Headers.Get("foo")
+		assert.Equal(t, "bar", foo)
+
+		deadline, ok := res.Headers.Get(grpcinterop.DeadlineKey)
+		require.True(t, ok, "deadline must be propagated")
+		remaining, err := strconv.Atoi(deadline)
+		require.NoError(t, err)
+		assert.True(t, remaining > 0 && remaining <= 1000, "unexpected remaining deadline %dms", remaining)
+	})
+}
+
+func TestInteropOutboundErrors(t *testing.T) {
+	t.Parallel()
+	withReferenceServer(t, func(t *testing.T, out *Outbound) {
+		tests := []struct {
+			code    codes.Code
+			message string
+			want    error
+		}{
+			{codes.NotFound, "no such key", yarpcerrors.Newf(yarpcerrors.CodeNotFound, "no such key")},
+			{codes.PermissionDenied, "go away", yarpcerrors.Newf(yarpcerrors.CodePermissionDenied, "go away")},
+			{codes.Unavailable, "", yarpcerrors.Newf(yarpcerrors.CodeUnavailable, "")},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.code.String(), func(t *testing.T) {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+				defer cancel()
+
+				_, err := out.Call(ctx, &transport.Request{
+					Caller:    "caller",
+					Service:   "service",
+					Encoding:  "raw",
+					Procedure: "Echo::Unary",
+					Headers: transport.NewHeaders().
+						With(grpcinterop.ErrorCodeKey, strconv.Itoa(int(tt.code))).
+						With(grpcinterop.ErrorMessageKey, tt.message),
+					Body: bytes.NewReader(nil),
+				})
+				assert.Equal(t, tt.want, err)
+			})
+		}
+	})
+}
+
+func TestInteropOutboundStream(t *testing.T) {
+	t.Parallel()
+	withReferenceServer(t, func(t *testing.T, out *Outbound) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		stream, err := out.CallStream(ctx, &transport.StreamRequest{
+			Meta: &transport.RequestMeta{
+				Caller:    "caller",
+				Service:   "service",
+				Encoding:  "raw",
+				Procedure: "Echo::Stream",
+			},
+		})
+		require.NoError(t, err)
+
+		for _, msg := range []string{"a", "b", "c"} {
+			require.NoError(t, stream.SendMessage(ctx, &transport.StreamMessage{
+				Body: ioutil.NopCloser(bytes.NewReader([]byte(msg))),
+			}))
+			res, err := stream.ReceiveMessage(ctx)
+			require.NoError(t, err)
+			body, err := ioutil.ReadAll(res.Body)
+			require.NoError(t, err)
+			assert.Equal(t, msg, string(body))
+		}
+
+		require.NoError(t, stream.Close(ctx))
+		_, err = stream.ReceiveMessage(ctx)
+		assert.Equal(t, io.EOF, err)
+	})
+}
+
+func TestInteropInboundUnary(t *testing.T) {
+	t.Parallel()
+	withReferenceClient(t, func(t *testing.T, client *grpcinterop.Client) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		body, md, err := client.Call(ctx, "/Echo/Unary", interopMetadata("foo", "bar"), []byte("hello"))
+		require.NoError(t, err)
+		assert.Equal(t, "hello", string(body))
+		assert.Equal(t, []string{"bar"}, md["foo"])
+		assert.Equal(t, []string{"true"}, md["has-deadline"])
+		assert.Equal(t, []string{"service"}, md[ServiceHeader])
+	})
+}
+
+func TestInteropInboundErrors(t *testing.T) {
+	t.Parallel()
+	withReferenceClient(t, func(t *testing.T, client *grpcinterop.Client) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		_, md, err := client.Call(ctx, "/Echo/Fail", interopMetadata(), nil)
+		assert.Equal(t, status.Error(codes.NotFound, "no such key"), err)
+		assert.Empty(t, md[ErrorNameHeader])
+
+		_, _, err = client.Call(ctx, "/Echo/Unknown", interopMetadata(), nil)
+		assert.Equal(t, codes.Unknown, grpcCode(err))
+
+		// The caller header is required.
+		md = interopMetadata()
+		delete(md, CallerHeader)
+		_, _, err = client.Call(ctx, "/Echo/Unary", md, nil)
+		assert.Equal(t, codes.InvalidArgument, grpcCode(err))
+	})
+}
+
+func TestInteropInboundStream(t *testing.T) {
+	t.Parallel()
+	withReferenceClient(t, func(t *testing.T, client *grpcinterop.Client) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		stream, err := client.Stream(ctx, "/Echo/Stream", interopMetadata())
+		require.NoError(t, err)
+		for _, msg := range []string{"a", "b", "c"} {
+			require.NoError(t, stream.SendMsg([]byte(msg)))
+			var res []byte
+			require.NoError(t, stream.RecvMsg(&res))
+			assert.Equal(t, msg, string(res))
+		}
+		require.NoError(t, stream.CloseSend())
+		var res []byte
+		assert.Equal(t, io.EOF, stream.RecvMsg(&res))
+	})
+}
+
+func withReferenceServer(t *testing.T, f func(*testing.T, *Outbound)) {
+	server, err := grpcinterop.NewServer()
+	require.NoError(t, err)
+	defer server.Stop()
+
+	trans := NewTransport()
+	require.NoError(t, trans.Start())
+	defer func() { assert.NoError(t, trans.Stop()) }()
+
+	out := trans.NewSingleOutbound(server.Addr())
+	require.NoError(t, out.Start())
+	defer func() { assert.NoError(t, out.Stop()) }()
+
+	f(t, out)
+}
+
+func withReferenceClient(t *testing.T, f func(*testing.T, *grpcinterop.Client)) {
+	trans := NewTransport()
+	require.NoError(t, trans.Start())
+	defer func() { assert.NoError(t, trans.Stop()) }()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	inbound := trans.NewInbound(listener)
+	inbound.SetRouter(newTestRouter([]transport.Procedure{
+		{Name: "Echo::Unary", HandlerSpec: transport.NewUnaryHandlerSpec(interopEchoHandler{})},
+		{Name: "Echo::Fail", HandlerSpec: transport.NewUnaryHandlerSpec(interopFailHandler{})},
+		{Name: "Echo::Stream", HandlerSpec: transport.NewStreamHandlerSpec(interopStreamHandler{})},
+	}))
+	require.NoError(t, inbound.Start())
+	defer func() { assert.NoError(t, inbound.Stop()) }()
+
+	client, err := grpcinterop.NewClient(listener.Addr().String())
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, client.Close()) }()
+
+	f(t, client)
+}
+
+// interopMetadata returns the metadata a third-party gRPC client must send
+// to call a YARPC service, along with the given key-value pairs.
+func interopMetadata(kv ...string) metadata.MD {
+	md := metadata.Pairs(kv...)
+	md[CallerHeader] = []string{"caller"}
+	md[ServiceHeader] = []string{"service"}
+	md[EncodingHeader] = []string{"raw"}
+	return md
+}
+
+func grpcCode(err error) codes.Code {
+	s, _ := status.FromError(err)
+	return s.Code()
+}
+
+type interopEchoHandler struct{}
+
+func (interopEchoHandler) Handle(ctx context.Context, req *transport.Request, w transport.ResponseWriter) error {
+	_, hasDeadline := ctx.Deadline()
+	w.AddHeaders(req.Headers.With("has-deadline", strconv.FormatBool(hasDeadline)))
+	_, err := io.Copy(w, req.Body)
+	return err
+}
+
+type interopFailHandler struct{}
+
+func (interopFailHandler) Handle(context.Context, *transport.Request, transport.ResponseWriter) error {
+	return yarpcerrors.Newf(yarpcerrors.CodeNotFound, "no such key")
+}
+
+type interopStreamHandler struct{}
+
+func (interopStreamHandler) HandleStream(stream *transport.ServerStream) error {
+	for {
+		msg, err := stream.ReceiveMessage(stream.Context())
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := stream.SendMessage(stream.Context(), msg); err != nil {
+			return err
+		}
+	}
+}
@@ -27,6 +27,7 @@ import (
 	"go.uber.org/yarpc/api/backoff"
 	intbackoff "go.uber.org/yarpc/internal/backoff"
 	"go.uber.org/zap"
+	"google.golang.org/grpc/credentials"
 )
 
 const (
@@ -133,6 +134,21 @@ type InboundOption func(*inboundOptions)
 
 func (InboundOption) grpcOption() {}
 
+// InboundCredentials specifies the transport credentials of the inbound, like
+// credentials.NewTLS to serve TLS.
+//
+// To authenticate callers with their certificates, require and verify them
+// with the ClientAuth and ClientCAs fields of the TLS configuration. The
+// identity in the certificate of each caller is then available in the
+// CallerIdentity of its requests.
+//
+// The default is to not use TLS.
+func InboundCredentials(creds credentials.TransportCredentials) InboundOption {
+	return func(inboundOptions *inboundOptions) {
+		inboundOptions.creds = creds
+	}
+}
+
 // OutboundOption is an option for an outbound.
 type OutboundOption func(*outboundOptions)
 
@@ -172,7 +188,9 @@ func newTransportOptions(options []TransportOption) *transportOptions {
 	return transportOptions
 }
 
-type inboundOptions struct{}
+type inboundOptions struct {
+	creds credentials.TransportCredentials
+}
 
 func newInboundOptions(options []InboundOption) *inboundOptions {
 	inboundOptions := &inboundOptions{}
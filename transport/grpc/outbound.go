@@ -113,11 +113,15 @@ func (o *Outbound) Call(ctx context.Context, request *transport.Request) (*trans
 	if err != nil {
 		return nil, err
 	}
-	return &transport.Response{
+	response := &transport.Response{
 		Body:             ioutil.NopCloser(bytes.NewBuffer(responseBody)),
 		Headers:          responseHeaders,
 		ApplicationError: metadataToIsApplicationError(responseMD),
-	}, invokeErr
+	}
+	if response.ApplicationError {
+		response.ApplicationErrorMeta = metadataToApplicationErrorMeta(responseMD)
+	}
+	return response, invokeErr
 }
 
 func (o *Outbound) invoke(
@@ -201,6 +205,26 @@ func metadataToIsApplicationError(responseMD metadata.MD) bool {
 	return ok && len(value) > 0 && len(value[0]) > 0
 }
 
+func metadataToApplicationErrorMeta(responseMD metadata.MD) *transport.ApplicationErrorMeta {
+	first := func(key string) string {
+		if values := responseMD[key]; len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+	meta := transport.ApplicationErrorMeta{
+		Name:    first(ApplicationErrorNameHeader),
+		Message: first(ApplicationErrorMessageHeader),
+	}
+	if details := first(ApplicationErrorDetailsHeader); details != "" {
+		meta.Details = []byte(details)
+	}
+	if meta.Name == "" && meta.Message == "" && len(meta.Details) == 0 {
+		return nil
+	}
+	return &meta
+}
+
 func invokeErrorToYARPCError(err error, responseMD metadata.MD) error {
 	if err == nil {
 		return nil
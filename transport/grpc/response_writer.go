@@ -56,6 +56,21 @@ func (r *responseWriter) SetApplicationError() {
 	r.AddSystemHeader(ApplicationErrorHeader, ApplicationErrorHeaderValue)
 }
 
+func (r *responseWriter) SetApplicationErrorMeta(meta *transport.ApplicationErrorMeta) {
+	if meta == nil {
+		return
+	}
+	if meta.Name != "" {
+		r.AddSystemHeader(ApplicationErrorNameHeader, meta.Name)
+	}
+	if meta.Message != "" {
+		r.AddSystemHeader(ApplicationErrorMessageHeader, meta.Message)
+	}
+	if len(meta.Details) > 0 {
+		r.AddSystemHeader(ApplicationErrorDetailsHeader, string(meta.Details))
+	}
+}
+
 func (r *responseWriter) AddSystemHeader(key string, value string) {
 	if r.md == nil {
 		r.md = metadata.New(nil)
@@ -26,6 +26,7 @@ import (
 
 	"go.uber.org/multierr"
 	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/peer/hostport"
 	"go.uber.org/yarpc/pkg/lifecycle"
 )
 
@@ -98,7 +99,7 @@ func (t *Transport) NewOutbound(peerChooser peer.Chooser, options ...OutboundOpt
 func (t *Transport) RetainPeer(peerIdentifier peer.Identifier, peerSubscriber peer.Subscriber) (peer.Peer, error) {
 	t.lock.Lock()
 	defer t.lock.Unlock()
-	address := peerIdentifier.Identifier()
+	address := peerAddress(peerIdentifier)
 	p, ok := t.addressToPeer[address]
 	if !ok {
 		var err error
@@ -116,7 +117,7 @@ func (t *Transport) RetainPeer(peerIdentifier peer.Identifier, peerSubscriber pe
 func (t *Transport) ReleasePeer(peerIdentifier peer.Identifier, peerSubscriber peer.Subscriber) error {
 	t.lock.Lock()
 	defer t.lock.Unlock()
-	address := peerIdentifier.Identifier()
+	address := peerAddress(peerIdentifier)
 	p, ok := t.addressToPeer[address]
 	if !ok {
 		return peer.ErrTransportHasNoReferenceToPeer{
@@ -134,3 +135,12 @@ func (t *Transport) ReleasePeer(peerIdentifier peer.Identifier, peerSubscriber p
 	}
 	return nil
 }
+
+// peerAddress returns the normalized address of a peer, if it is a valid
+// host:port, or its identifier otherwise.
+func peerAddress(pid peer.Identifier) string {
+	if addr, err := hostport.Normalize(pid.Identifier()); err == nil {
+		return addr
+	}
+	return pid.Identifier()
+}
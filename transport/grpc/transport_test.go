@@ -79,6 +79,29 @@ func TestReleasePeerErrorNoPeer(t *testing.T) {
 	}, transport.ReleasePeer(testIdentifier{address}, peerSubscriber))
 }
 
+func TestRetainPeerNormalizesIdentifiers(t *testing.T) {
+	transport := NewTransport()
+	assert.NoError(t, transport.Start())
+	defer func() { assert.NoError(t, transport.Stop()) }()
+
+	peerSubscriber := testPeerSubscriber{}
+	p1, err := transport.RetainPeer(testIdentifier{"LocalHost:0080"}, peerSubscriber)
+	require.NoError(t, err)
+	p2, err := transport.RetainPeer(testIdentifier{"localhost:80"}, peerSubscriber)
+	require.NoError(t, err)
+	assert.Equal(t, p1, p2)
+	assert.Equal(t, "localhost:80", p1.Identifier())
+	assert.Len(t, transport.addressToPeer, 1)
+
+	assert.NoError(t, transport.ReleasePeer(testIdentifier{"LOCALHOST:80"}, peerSubscriber))
+	assert.Empty(t, transport.addressToPeer)
+
+	p, err := transport.RetainPeer(testIdentifier{"unix:///tmp/yarpc.sock"}, peerSubscriber)
+	require.NoError(t, err, "identifiers which are not host:ports must be retained as-is")
+	assert.Equal(t, "unix:///tmp/yarpc.sock", p.Identifier())
+	assert.NoError(t, transport.ReleasePeer(testIdentifier{"unix:///tmp/yarpc.sock"}, peerSubscriber))
+}
+
 type testPeerSubscriber struct{}
 
 func (testPeerSubscriber) NotifyStatusChanged(peer.Identifier) {}
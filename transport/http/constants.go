@@ -74,6 +74,18 @@ const (
 	// BothResponseError feature is enabled.
 	ErrorMessageHeader = "Rpc-Error-Message"
 
+	// ApplicationErrorNameHeader contains the name of the application error
+	// of a response, like the name of a Thrift exception.
+	ApplicationErrorNameHeader = "Rpc-Application-Error-Name"
+
+	// ApplicationErrorMessageHeader contains the message of the application
+	// error of a response.
+	ApplicationErrorMessageHeader = "Rpc-Application-Error-Message"
+
+	// ApplicationErrorDetailsHeader contains the details of the application
+	// error of a response, encoded with standard base64.
+	ApplicationErrorDetailsHeader = "Rpc-Application-Error-Details"
+
 	// AcceptsBothResponseErrorHeader says that the BothResponseError
 	// feature is supported on the client. If the value is "true",
 	// this indicates true.
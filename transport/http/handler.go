@@ -23,6 +23,7 @@ package http
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"fmt"
 	"net/http"
 	"time"
@@ -34,6 +35,7 @@ import (
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/internal/bufferpool"
 	"go.uber.org/yarpc/internal/iopool"
+	"go.uber.org/yarpc/internal/tlsidentity"
 	"go.uber.org/yarpc/pkg/errors"
 	"go.uber.org/yarpc/yarpcerrors"
 	"go.uber.org/zap"
@@ -45,6 +47,18 @@ func popHeader(h http.Header, n string) string {
 	return v
 }
 
+// fromHTTPRequestHeaders returns the first value of each of the given HTTP
+// headers, keeping their names as received.
+func fromHTTPRequestHeaders(h http.Header) transport.Headers {
+	headers := transport.NewHeadersWithCapacity(len(h))
+	for k, vs := range h {
+		if len(vs) > 0 {
+			headers = headers.With(k, vs[0])
+		}
+	}
+	return headers
+}
+
 // handler adapts a transport.Handler into a handler for net/http.
 type handler struct {
 	router            transport.Router
@@ -96,17 +110,23 @@ func (h handler) callHandler(responseWriter *responseWriter, req *http.Request,
 	if req.Method != http.MethodPost {
 		return yarpcerrors.Newf(yarpcerrors.CodeNotFound, "request method was %s but only %s is allowed", req.Method, http.MethodPost)
 	}
+	// The transport headers are read before the headers YARPC reserves are
+	// popped off.
+	transportHeaders := fromHTTPRequestHeaders(req.Header)
 	treq := &transport.Request{
-		Caller:          popHeader(req.Header, CallerHeader),
-		Service:         service,
-		Procedure:       procedure,
-		Encoding:        transport.Encoding(popHeader(req.Header, EncodingHeader)),
-		Transport:       transportName,
-		ShardKey:        popHeader(req.Header, ShardKeyHeader),
-		RoutingKey:      popHeader(req.Header, RoutingKeyHeader),
-		RoutingDelegate: popHeader(req.Header, RoutingDelegateHeader),
-		Headers:         applicationHeaders.FromHTTPHeaders(req.Header, transport.Headers{}),
-		Body:            req.Body,
+		Caller:           popHeader(req.Header, CallerHeader),
+		CallerIdentity:   tlsidentity.FromConnectionState(req.TLS),
+		RemoteAddr:       req.RemoteAddr,
+		TransportHeaders: transportHeaders,
+		Service:          service,
+		Procedure:        procedure,
+		Encoding:         transport.Encoding(popHeader(req.Header, EncodingHeader)),
+		Transport:        transportName,
+		ShardKey:         popHeader(req.Header, ShardKeyHeader),
+		RoutingKey:       popHeader(req.Header, RoutingKeyHeader),
+		RoutingDelegate:  popHeader(req.Header, RoutingDelegateHeader),
+		Headers:          applicationHeaders.FromHTTPHeaders(req.Header, transport.Headers{}),
+		Body:             req.Body,
 	}
 	for header := range h.grabHeaders {
 		if value := req.Header.Get(header); value != "" {
@@ -260,6 +280,21 @@ func (rw *responseWriter) SetApplicationError() {
 	rw.w.Header().Set(ApplicationStatusHeader, ApplicationErrorStatus)
 }
 
+func (rw *responseWriter) SetApplicationErrorMeta(meta *transport.ApplicationErrorMeta) {
+	if meta == nil {
+		return
+	}
+	if meta.Name != "" {
+		rw.w.Header().Set(ApplicationErrorNameHeader, meta.Name)
+	}
+	if meta.Message != "" {
+		rw.w.Header().Set(ApplicationErrorMessageHeader, meta.Message)
+	}
+	if len(meta.Details) > 0 {
+		rw.w.Header().Set(ApplicationErrorDetailsHeader, base64.StdEncoding.EncodeToString(meta.Details))
+	}
+}
+
 func (rw *responseWriter) AddSystemHeader(key string, value string) {
 	rw.w.Header().Set(key, value)
 }
@@ -98,6 +98,49 @@ func TestHandlerSuccess(t *testing.T) {
 	assert.Equal(t, rw.Body.String(), "")
 }
 
+func TestHandlerTransportInfo(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	headers := make(http.Header)
+	headers.Set(CallerHeader, "moe")
+	headers.Set(EncodingHeader, "raw")
+	headers.Set(TTLMSHeader, "1000")
+	headers.Set(ProcedureHeader, "nyuck")
+	headers.Set(ServiceHeader, "curly")
+	headers.Set("User-Agent", "stooge/1.0")
+
+	router := transporttest.NewMockRouter(mockCtrl)
+	rpcHandler := transporttest.NewMockUnaryHandler(mockCtrl)
+	router.EXPECT().Choose(gomock.Any(), gomock.Any()).Return(transport.NewUnaryHandlerSpec(rpcHandler), nil)
+
+	var got *transport.Request
+	rpcHandler.EXPECT().Handle(gomock.Any(), gomock.Any(), gomock.Any()).Do(
+		func(_ context.Context, req *transport.Request, _ transport.ResponseWriter) {
+			got = req
+		}).Return(nil)
+
+	httpHandler := handler{router: router, tracer: &opentracing.NoopTracer{}}
+	req := &http.Request{
+		Method:     "POST",
+		Header:     headers,
+		RemoteAddr: "10.0.0.1:53412",
+		Body:       ioutil.NopCloser(bytes.NewReader([]byte("Nyuck Nyuck"))),
+	}
+	rw := httptest.NewRecorder()
+	httpHandler.ServeHTTP(rw, req)
+	require.Equal(t, 200, rw.Code)
+
+	require.NotNil(t, got)
+	assert.Equal(t, "10.0.0.1:53412", got.RemoteAddr)
+	userAgent, _ := got.TransportHeaders.Get("User-Agent")
+	assert.Equal(t, "stooge/1.0", userAgent)
+	caller, _ := got.TransportHeaders.Get(CallerHeader)
+	assert.Equal(t, "moe", caller, "transport headers must include the headers YARPC reserves")
+	_, ok := got.Headers.Get(CallerHeader)
+	assert.False(t, ok)
+}
+
 func TestHandlerHeaders(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -433,3 +476,21 @@ func TestResponseWriter(t *testing.T) {
 	assert.Equal(t, "123", recorder.Header().Get("rpc-header-shard-key"))
 	assert.Equal(t, "hello", recorder.Body.String())
 }
+
+func TestResponseWriterApplicationErrorMeta(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	writer := newResponseWriter(recorder)
+
+	meta := &transport.ApplicationErrorMeta{
+		Name:    "KeyDoesNotExist",
+		Message: "key foo does not exist",
+		Details: []byte{0x0b, 0x00, 0x01},
+	}
+	writer.SetApplicationError()
+	writer.SetApplicationErrorMeta(meta)
+	writer.Close(http.StatusOK)
+
+	assert.Equal(t, "KeyDoesNotExist", recorder.Header().Get(ApplicationErrorNameHeader))
+	assert.Equal(t, meta, applicationErrorMetaFromHeaders(recorder.Header()))
+	assert.Nil(t, applicationErrorMetaFromHeaders(make(http.Header)))
+}
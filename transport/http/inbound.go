@@ -21,6 +21,7 @@
 package http
 
 import (
+	"crypto/tls"
 	"net"
 	"net/http"
 	"strings"
@@ -79,6 +80,19 @@ func GrabHeaders(headers ...string) InboundOption {
 	}
 }
 
+// InboundTLS specifies that the inbound should serve HTTPS with the given TLS
+// configuration.
+//
+// To authenticate callers with their certificates, require and verify them
+// with the ClientAuth and ClientCAs fields of the configuration. The identity
+// in the certificate of each caller is then available in the CallerIdentity
+// of its requests.
+func InboundTLS(config *tls.Config) InboundOption {
+	return func(i *Inbound) {
+		i.tlsConfig = config
+	}
+}
+
 // NewInbound builds a new HTTP inbound that listens on the given address and
 // sharing this transport.
 func (t *Transport) NewInbound(addr string, opts ...InboundOption) *Inbound {
@@ -110,6 +124,7 @@ type Inbound struct {
 	transport   *Transport
 	grabHeaders map[string]struct{}
 	interceptor func(http.Handler) http.Handler
+	tlsConfig   *tls.Config
 
 	once *lifecycle.Once
 
@@ -167,8 +182,9 @@ func (i *Inbound) start() error {
 	}
 
 	i.server = intnet.NewHTTPServer(&http.Server{
-		Addr:    i.addr,
-		Handler: httpHandler,
+		Addr:      i.addr,
+		Handler:   httpHandler,
+		TLSConfig: i.tlsConfig,
 	})
 	if err := i.server.ListenAndServe(); err != nil {
 		return err
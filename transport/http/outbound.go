@@ -22,6 +22,7 @@ package http
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 	"io/ioutil"
 	"log"
@@ -268,6 +269,9 @@ func (o *Outbound) call(ctx context.Context, treq *transport.Request) (*transpor
 		Body:             response.Body,
 		ApplicationError: response.Header.Get(ApplicationStatusHeader) == ApplicationErrorStatus,
 	}
+	if tres.ApplicationError {
+		tres.ApplicationErrorMeta = applicationErrorMetaFromHeaders(response.Header)
+	}
 
 	bothResponseError := response.Header.Get(BothResponseErrorHeader) == AcceptTrue
 	if bothResponseError && o.bothResponseError {
@@ -282,6 +286,19 @@ func (o *Outbound) call(ctx context.Context, treq *transport.Request) (*transpor
 	return nil, getYARPCErrorFromResponse(response, false)
 }
 
+// applicationErrorMetaFromHeaders returns the ApplicationErrorMeta the
+// given response headers carry, if any.
+func applicationErrorMetaFromHeaders(h http.Header) *transport.ApplicationErrorMeta {
+	name := h.Get(ApplicationErrorNameHeader)
+	message := h.Get(ApplicationErrorMessageHeader)
+	// Details which are not valid base64 are dropped.
+	details, _ := base64.StdEncoding.DecodeString(h.Get(ApplicationErrorDetailsHeader))
+	if name == "" && message == "" && len(details) == 0 {
+		return nil
+	}
+	return &transport.ApplicationErrorMeta{Name: name, Message: message, Details: details}
+}
+
 func (o *Outbound) getPeerForRequest(ctx context.Context, treq *transport.Request) (*httpPeer, func(error), error) {
 	p, onFinish, err := o.chooser.Choose(ctx, treq)
 	if err != nil {
@@ -31,6 +31,7 @@ import (
 	"go.uber.org/yarpc/api/peer"
 	"go.uber.org/yarpc/api/transport"
 	"go.uber.org/yarpc/internal/backoff"
+	"go.uber.org/yarpc/peer/hostport"
 	"go.uber.org/yarpc/pkg/lifecycle"
 	"go.uber.org/zap"
 )
@@ -272,18 +273,31 @@ func (a *Transport) IsRunning() bool {
 }
 
 // RetainPeer gets or creates a Peer for the specified peer.Subscriber (usually a peer.Chooser)
+//
+// Identifiers which are valid host:port addresses are normalized so that
+// different spellings of the same address share a peer. Other identifiers,
+// such as hosts without a port, are used as-is since the outbound's URL
+// template determines how they are dialed.
 func (a *Transport) RetainPeer(pid peer.Identifier, sub peer.Subscriber) (peer.Peer, error) {
 	a.lock.Lock()
 	defer a.lock.Unlock()
 
-	p := a.getOrCreatePeer(pid)
+	p := a.getOrCreatePeer(peerAddress(pid))
 	p.Subscribe(sub)
 	return p, nil
 }
 
+// peerAddress returns the normalized address of a peer, if it is a valid
+// host:port, or its identifier otherwise.
+func peerAddress(pid peer.Identifier) string {
+	if addr, err := hostport.Normalize(pid.Identifier()); err == nil {
+		return addr
+	}
+	return pid.Identifier()
+}
+
 // **NOTE** should only be called while the lock write mutex is acquired
-func (a *Transport) getOrCreatePeer(pid peer.Identifier) *httpPeer {
-	addr := pid.Identifier()
+func (a *Transport) getOrCreatePeer(addr string) *httpPeer {
 	if p, ok := a.peers[addr]; ok {
 		return p
 	}
@@ -300,7 +314,8 @@ func (a *Transport) ReleasePeer(pid peer.Identifier, sub peer.Subscriber) error
 	a.lock.Lock()
 	defer a.lock.Unlock()
 
-	p, ok := a.peers[pid.Identifier()]
+	addr := peerAddress(pid)
+	p, ok := a.peers[addr]
 	if !ok {
 		return peer.ErrTransportHasNoReferenceToPeer{
 			TransportName:  "http.Transport",
@@ -313,7 +328,7 @@ func (a *Transport) ReleasePeer(pid peer.Identifier, sub peer.Subscriber) error
 	}
 
 	if p.NumSubscribers() == 0 {
-		delete(a.peers, pid.Identifier())
+		delete(a.peers, addr)
 		p.Release()
 	}
 
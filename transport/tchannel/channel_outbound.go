@@ -22,6 +22,7 @@ package tchannel
 
 import (
 	"context"
+	"encoding/base64"
 	"io"
 
 	"github.com/uber/tchannel-go"
@@ -197,11 +198,15 @@ func (o *ChannelOutbound) Call(ctx context.Context, req *transport.Request) (*tr
 			"does not match the service name received in the response: sent %q, got: %q", req.Service, resSvcName)
 	}
 
-	return &transport.Response{
+	tres := &transport.Response{
 		Headers:          headers,
 		Body:             resBody,
 		ApplicationError: res.ApplicationError(),
-	}, getResponseErrorAndDeleteHeaderKeys(headers)
+	}
+	if meta := getApplicationErrorMetaAndDeleteHeaderKeys(headers); tres.ApplicationError {
+		tres.ApplicationErrorMeta = meta
+	}
+	return tres, getResponseErrorAndDeleteHeaderKeys(headers)
 }
 
 // Introspect returns basic status about this outbound.
@@ -260,6 +265,25 @@ func getResponseErrorAndDeleteHeaderKeys(headers transport.Headers) error {
 	return intyarpcerrors.NewWithNamef(errorCode, errorName, errorMessage)
 }
 
+// getApplicationErrorMetaAndDeleteHeaderKeys returns the
+// ApplicationErrorMeta the given response headers carry, if any.
+func getApplicationErrorMetaAndDeleteHeaderKeys(headers transport.Headers) *transport.ApplicationErrorMeta {
+	defer func() {
+		headers.Del(ApplicationErrorNameHeaderKey)
+		headers.Del(ApplicationErrorMessageHeaderKey)
+		headers.Del(ApplicationErrorDetailsHeaderKey)
+	}()
+	name, _ := headers.Get(ApplicationErrorNameHeaderKey)
+	message, _ := headers.Get(ApplicationErrorMessageHeaderKey)
+	encodedDetails, _ := headers.Get(ApplicationErrorDetailsHeaderKey)
+	// Details which are not valid base64 are dropped.
+	details, _ := base64.StdEncoding.DecodeString(encodedDetails)
+	if name == "" && message == "" && len(details) == 0 {
+		return nil
+	}
+	return &transport.ApplicationErrorMeta{Name: name, Message: message, Details: details}
+}
+
 // ServiceHeaderKey is internal key used by YARPC, we need to remove it before give response to client
 // only does verification when there is a response service header.
 func checkServiceMatchAndDeleteHeaderKey(reqSvcName string, resHeaders transport.Headers) (bool, string) {
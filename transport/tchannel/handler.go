@@ -22,6 +22,7 @@ package tchannel
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 	"time"
 
@@ -161,6 +162,7 @@ func (h handler) callHandler(ctx context.Context, call inboundCall, responseWrit
 	if tcall, ok := call.(tchannelCall); ok {
 		tracer := h.tracer
 		ctx = tchannel.ExtractInboundSpan(ctx, tcall.InboundCall, headers.Items(), tracer)
+		treq.RemoteAddr = tcall.RemotePeer().HostPort
 	}
 
 	body, err := call.Arg3Reader()
@@ -244,6 +246,21 @@ func (rw *responseWriter) SetApplicationError() {
 	rw.isApplicationError = true
 }
 
+func (rw *responseWriter) SetApplicationErrorMeta(meta *transport.ApplicationErrorMeta) {
+	if meta == nil {
+		return
+	}
+	if meta.Name != "" {
+		rw.addHeader(ApplicationErrorNameHeaderKey, meta.Name)
+	}
+	if meta.Message != "" {
+		rw.addHeader(ApplicationErrorMessageHeaderKey, meta.Message)
+	}
+	if len(meta.Details) > 0 {
+		rw.addHeader(ApplicationErrorDetailsHeaderKey, base64.StdEncoding.EncodeToString(meta.Details))
+	}
+}
+
 func (rw *responseWriter) Write(s []byte) (int, error) {
 	if rw.failedWith != nil {
 		return 0, rw.failedWith
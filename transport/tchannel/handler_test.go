@@ -509,6 +509,27 @@ func TestResponseWriterEmptyBodyHeaders(t *testing.T) {
 	assert.False(t, res.applicationError, "application error must be false")
 }
 
+func TestResponseWriterApplicationErrorMeta(t *testing.T) {
+	res := newResponseRecorder()
+	w := newResponseWriter(res, tchannel.Raw, canonicalizedHeaderCase)
+
+	meta := &transport.ApplicationErrorMeta{
+		Name:    "KeyDoesNotExist",
+		Message: "key foo does not exist",
+		Details: []byte{0x0b, 0x00, 0x01},
+	}
+	w.SetApplicationError()
+	w.SetApplicationErrorMeta(meta)
+	require.NoError(t, w.Close())
+	assert.True(t, res.applicationError, "application error must be true")
+
+	headers := w.headers
+	assert.Equal(t, meta, getApplicationErrorMetaAndDeleteHeaderKeys(headers))
+	_, ok := headers.Get(ApplicationErrorNameHeaderKey)
+	assert.False(t, ok, "reserved headers must be deleted")
+	assert.Nil(t, getApplicationErrorMetaAndDeleteHeaderKeys(transport.NewHeaders()))
+}
+
 func TestGetSystemError(t *testing.T) {
 	tests := []struct {
 		giveErr  error
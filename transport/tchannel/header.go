@@ -40,6 +40,15 @@ const (
 	ErrorMessageHeaderKey = "$rpc$-error-message"
 	// ServiceHeaderKey is the response header key for the respond service
 	ServiceHeaderKey = "$rpc$-service"
+	// ApplicationErrorNameHeaderKey is the response header key for the name
+	// of the application error.
+	ApplicationErrorNameHeaderKey = "$rpc$-application-error-name"
+	// ApplicationErrorMessageHeaderKey is the response header key for the
+	// message of the application error.
+	ApplicationErrorMessageHeaderKey = "$rpc$-application-error-message"
+	// ApplicationErrorDetailsHeaderKey is the response header key for the
+	// details of the application error, encoded with standard base64.
+	ApplicationErrorDetailsHeaderKey = "$rpc$-application-error-details"
 )
 
 var _reservedHeaderKeys = map[string]struct{}{
@@ -47,6 +56,10 @@ var _reservedHeaderKeys = map[string]struct{}{
 	ErrorNameHeaderKey:    {},
 	ErrorMessageHeaderKey: {},
 	ServiceHeaderKey:      {},
+
+	ApplicationErrorNameHeaderKey:    {},
+	ApplicationErrorMessageHeaderKey: {},
+	ApplicationErrorDetailsHeaderKey: {},
 }
 
 func isReservedHeaderKey(key string) bool {
@@ -171,11 +171,15 @@ func callWithPeer(ctx context.Context, req *transport.Request, peer *tchannel.Pe
 			"does not match the service name received in the response: sent %q, got: %q", req.Service, resSvcName)
 	}
 
-	return &transport.Response{
+	tres := &transport.Response{
 		Headers:          headers,
 		Body:             resBody,
 		ApplicationError: res.ApplicationError(),
-	}, getResponseErrorAndDeleteHeaderKeys(headers)
+	}
+	if meta := getApplicationErrorMetaAndDeleteHeaderKeys(headers); tres.ApplicationError {
+		tres.ApplicationErrorMeta = meta
+	}
+	return tres, getResponseErrorAndDeleteHeaderKeys(headers)
 }
 
 func (o *Outbound) getPeerForRequest(ctx context.Context, treq *transport.Request) (*tchannelPeer, func(error), error) {
@@ -132,7 +132,7 @@ func TestCancelMaintainConn(t *testing.T) {
 	require.NoError(t, err)
 	transport.Start()
 	transport.Stop()
-	_, err = transport.RetainPeer(identify("127.0.0.1:66408"), noSub{})
+	_, err = transport.RetainPeer(identify("127.0.0.1:6640"), noSub{})
 	require.NoError(t, err)
 }
 
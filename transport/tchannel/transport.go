@@ -31,6 +31,7 @@ import (
 	backoffapi "go.uber.org/yarpc/api/backoff"
 	"go.uber.org/yarpc/api/peer"
 	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/peer/hostport"
 	"go.uber.org/yarpc/pkg/lifecycle"
 	"go.uber.org/zap"
 )
@@ -121,18 +122,25 @@ func (t *Transport) ListenAddr() string {
 
 // RetainPeer adds a peer subscriber (typically a peer chooser) and causes the
 // transport to maintain persistent connections with that peer.
+//
+// Peer identifiers must be host:port addresses. They are normalized so that
+// different spellings of the same address share a peer.
 func (t *Transport) RetainPeer(pid peer.Identifier, sub peer.Subscriber) (peer.Peer, error) {
+	addr, err := hostport.Normalize(pid.Identifier())
+	if err != nil {
+		return nil, err
+	}
+
 	t.lock.Lock()
 	defer t.lock.Unlock()
 
-	p := t.getOrCreatePeer(pid)
+	p := t.getOrCreatePeer(addr)
 	p.Subscribe(sub)
 	return p, nil
 }
 
 // **NOTE** should only be called while the lock write mutex is acquired
-func (t *Transport) getOrCreatePeer(pid peer.Identifier) *tchannelPeer {
-	addr := pid.Identifier()
+func (t *Transport) getOrCreatePeer(addr string) *tchannelPeer {
 	if p, ok := t.peers[addr]; ok {
 		return p
 	}
@@ -152,8 +160,9 @@ func (t *Transport) ReleasePeer(pid peer.Identifier, sub peer.Subscriber) error
 	t.lock.Lock()
 	defer t.lock.Unlock()
 
-	p, ok := t.peers[pid.Identifier()]
-	if !ok {
+	addr, err := hostport.Normalize(pid.Identifier())
+	p, ok := t.peers[addr]
+	if err != nil || !ok {
 		return peer.ErrTransportHasNoReferenceToPeer{
 			TransportName:  "tchannel.Transport",
 			PeerIdentifier: pid.Identifier(),
@@ -167,7 +176,7 @@ func (t *Transport) ReleasePeer(pid peer.Identifier, sub peer.Subscriber) error
 	if p.NumSubscribers() == 0 {
 		// Release the peer so that the connection retention loop stops.
 		p.Release()
-		delete(t.peers, pid.Identifier())
+		delete(t.peers, addr)
 	}
 
 	return nil
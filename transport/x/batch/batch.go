@@ -0,0 +1,71 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package batch provides a oneway outbound which buffers requests and sends
+// them to an underlying outbound in batches.
+//
+// Batching amortizes per-call overhead for bulk oneway submission. Outbounds
+// which can send many requests at once, such as Kafka producers or SQS
+// (SendMessageBatch), implement BatchOnewayOutbound. Other oneway outbounds
+// are called once per request as each batch is flushed.
+//
+// Callers of CallOneway wait until the batch containing their request has
+// been sent, and receive the error for their request or for the batch as a
+// whole.
+//
+// 	outbound := batch.NewOnewayOutbound(sqsOutbound, batch.MaxBatchSize(10), batch.FlushInterval(50*time.Millisecond))
+//
+// This package is experimental. Breaking changes may be made between minor
+// releases.
+package batch
+
+import (
+	"context"
+	"strings"
+
+	"go.uber.org/yarpc/api/transport"
+)
+
+// BatchOnewayOutbound is a oneway outbound which can send many requests at
+// once.
+type BatchOnewayOutbound interface {
+	transport.OnewayOutbound
+
+	// CallOnewayBatch sends all the given requests and returns one Ack for
+	// each.
+	//
+	// If only some of the requests failed, CallOnewayBatch returns an Errors
+	// with one entry per request. Any other error applies to every request.
+	CallOnewayBatch(ctx context.Context, reqs []*transport.Request) ([]transport.Ack, error)
+}
+
+// Errors reports the outcome of each request of a partially failed batch.
+// Entries for requests which succeeded are nil.
+type Errors []error
+
+func (e Errors) Error() string {
+	var msgs []string
+	for _, err := range e {
+		if err != nil {
+			msgs = append(msgs, err.Error())
+		}
+	}
+	return strings.Join(msgs, "; ")
+}
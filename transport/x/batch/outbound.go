@@ -0,0 +1,283 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package batch
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/pkg/lifecycle"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+const (
+	_defaultMaxBatchSize  = 10
+	_defaultFlushInterval = 10 * time.Millisecond
+	_defaultFlushTimeout  = 5 * time.Second
+)
+
+var _ transport.OnewayOutbound = (*OnewayOutbound)(nil)
+
+// OutboundOption customizes a batching OnewayOutbound.
+type OutboundOption func(*OnewayOutbound)
+
+// MaxBatchSize sets the number of requests after which a batch is sent
+// immediately.
+//
+// Defaults to 10.
+func MaxBatchSize(n int) OutboundOption {
+	return func(o *OnewayOutbound) {
+		o.maxBatchSize = n
+	}
+}
+
+// FlushInterval sets how long the first request of a batch waits for more
+// requests before the batch is sent.
+//
+// Defaults to 10 milliseconds.
+func FlushInterval(d time.Duration) OutboundOption {
+	return func(o *OnewayOutbound) {
+		o.flushInterval = d
+	}
+}
+
+// FlushTimeout bounds how long sending a batch may take if none of the
+// requests in the batch have a deadline. Otherwise, the batch is sent with
+// the latest deadline of its requests.
+//
+// Defaults to 5 seconds.
+func FlushTimeout(d time.Duration) OutboundOption {
+	return func(o *OnewayOutbound) {
+		o.flushTimeout = d
+	}
+}
+
+// OnewayOutbound buffers oneway requests and sends them to an underlying
+// outbound in batches.
+type OnewayOutbound struct {
+	out           transport.OnewayOutbound
+	maxBatchSize  int
+	flushInterval time.Duration
+	flushTimeout  time.Duration
+
+	calls chan *call
+	stop  chan struct{}
+	wg    sync.WaitGroup
+	once  *lifecycle.Once
+}
+
+type call struct {
+	ctx  context.Context
+	req  *transport.Request
+	done chan result
+}
+
+type result struct {
+	ack transport.Ack
+	err error
+}
+
+// NewOnewayOutbound builds a OnewayOutbound which batches requests sent to
+// the given outbound. The underlying outbound is started and stopped along
+// with this one.
+func NewOnewayOutbound(out transport.OnewayOutbound, opts ...OutboundOption) *OnewayOutbound {
+	o := &OnewayOutbound{
+		out:           out,
+		maxBatchSize:  _defaultMaxBatchSize,
+		flushInterval: _defaultFlushInterval,
+		flushTimeout:  _defaultFlushTimeout,
+		calls:         make(chan *call),
+		stop:          make(chan struct{}),
+		once:          lifecycle.NewOnce(),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.maxBatchSize <= 0 {
+		o.maxBatchSize = 1
+	}
+	return o
+}
+
+// Transports returns the transports used by the underlying outbound.
+func (o *OnewayOutbound) Transports() []transport.Transport {
+	return o.out.Transports()
+}
+
+// Start starts the underlying outbound and begins batching requests.
+func (o *OnewayOutbound) Start() error {
+	return o.once.Start(func() error {
+		if err := o.out.Start(); err != nil {
+			return err
+		}
+		o.wg.Add(1)
+		go o.loop()
+		return nil
+	})
+}
+
+// Stop sends any buffered requests and stops the underlying outbound.
+func (o *OnewayOutbound) Stop() error {
+	return o.once.Stop(func() error {
+		close(o.stop)
+		o.wg.Wait()
+		return o.out.Stop()
+	})
+}
+
+// IsRunning returns whether the outbound is running.
+func (o *OnewayOutbound) IsRunning() bool {
+	return o.once.IsRunning()
+}
+
+// CallOneway adds the request to the current batch and waits until the
+// batch has been sent.
+func (o *OnewayOutbound) CallOneway(ctx context.Context, req *transport.Request) (transport.Ack, error) {
+	if !o.once.IsRunning() {
+		return nil, yarpcerrors.Newf(yarpcerrors.CodeFailedPrecondition, "batch outbound has not been started")
+	}
+
+	// The request body is read now since it is sent after other requests
+	// have been buffered.
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	buffered := *req
+	buffered.Body = bytes.NewReader(body)
+
+	c := &call{ctx: ctx, req: &buffered, done: make(chan result, 1)}
+	select {
+	case o.calls <- c:
+	case <-o.stop:
+		return nil, yarpcerrors.Newf(yarpcerrors.CodeUnavailable, "batch outbound is stopping")
+	case <-ctx.Done():
+		return nil, ctxError(ctx)
+	}
+
+	select {
+	case r := <-c.done:
+		return r.ack, r.err
+	case <-ctx.Done():
+		// The request may still be sent with its batch.
+		return nil, ctxError(ctx)
+	}
+}
+
+func ctxError(ctx context.Context) error {
+	if ctx.Err() == context.DeadlineExceeded {
+		return yarpcerrors.Newf(yarpcerrors.CodeDeadlineExceeded, "timed out waiting for batch to be sent")
+	}
+	return yarpcerrors.Newf(yarpcerrors.CodeCancelled, "cancelled while waiting for batch to be sent")
+}
+
+func (o *OnewayOutbound) loop() {
+	defer o.wg.Done()
+
+	var (
+		pending []*call
+		timer   = time.NewTimer(o.flushInterval)
+	)
+	timer.Stop()
+	defer timer.Stop()
+
+	flush := func() {
+		timer.Stop()
+		if len(pending) > 0 {
+			o.send(pending)
+			pending = nil
+		}
+	}
+
+	for {
+		select {
+		case c := <-o.calls:
+			pending = append(pending, c)
+			if len(pending) == 1 {
+				timer.Reset(o.flushInterval)
+			}
+			if len(pending) >= o.maxBatchSize {
+				flush()
+			}
+		case <-timer.C:
+			flush()
+		case <-o.stop:
+			flush()
+			return
+		}
+	}
+}
+
+// send sends a batch and reports the outcome to each caller.
+func (o *OnewayOutbound) send(calls []*call) {
+	ctx, cancel := o.batchContext(calls)
+	defer cancel()
+
+	reqs := make([]*transport.Request, len(calls))
+	for i, c := range calls {
+		reqs[i] = c.req
+	}
+
+	results := make([]result, len(calls))
+	if b, ok := o.out.(BatchOnewayOutbound); ok {
+		acks, err := b.CallOnewayBatch(ctx, reqs)
+		errs, partial := err.(Errors)
+		for i := range results {
+			switch {
+			case partial && len(errs) == len(results):
+				results[i].err = errs[i]
+			case err != nil:
+				results[i].err = err
+			}
+			if results[i].err == nil && i < len(acks) {
+				results[i].ack = acks[i]
+			}
+		}
+	} else {
+		for i, req := range reqs {
+			results[i].ack, results[i].err = o.out.CallOneway(ctx, req)
+		}
+	}
+
+	for i, c := range calls {
+		c.done <- results[i]
+	}
+}
+
+// batchContext returns a context for sending a batch which lasts as long as
+// the longest deadline of its requests.
+func (o *OnewayOutbound) batchContext(calls []*call) (context.Context, context.CancelFunc) {
+	var latest time.Time
+	for _, c := range calls {
+		deadline, ok := c.ctx.Deadline()
+		if !ok {
+			return context.WithTimeout(context.Background(), o.flushTimeout)
+		}
+		if deadline.After(latest) {
+			latest = deadline
+		}
+	}
+	return context.WithDeadline(context.Background(), latest)
+}
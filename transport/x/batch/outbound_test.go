@@ -0,0 +1,249 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package batch
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+type ack string
+
+func (a ack) String() string { return string(a) }
+
+// fakeOutbound records the bodies of requests sent to it. Bodies listed in
+// fail are rejected.
+type fakeOutbound struct {
+	sync.Mutex
+
+	fail    map[string]bool
+	bodies  []string
+	batches []int
+}
+
+func newFakeOutbound() *fakeOutbound {
+	return &fakeOutbound{fail: make(map[string]bool)}
+}
+
+func (o *fakeOutbound) Transports() []transport.Transport { return nil }
+func (o *fakeOutbound) Start() error                      { return nil }
+func (o *fakeOutbound) Stop() error                       { return nil }
+func (o *fakeOutbound) IsRunning() bool                   { return true }
+
+func (o *fakeOutbound) CallOneway(ctx context.Context, req *transport.Request) (transport.Ack, error) {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	o.Lock()
+	defer o.Unlock()
+	if o.fail[string(body)] {
+		return nil, errors.New("rejected")
+	}
+	o.bodies = append(o.bodies, string(body))
+	return ack(strconv.Itoa(len(o.bodies))), nil
+}
+
+// sent returns the bodies of all requests sent so far, sorted.
+func (o *fakeOutbound) sent() []string {
+	o.Lock()
+	defer o.Unlock()
+	bodies := append([]string(nil), o.bodies...)
+	sort.Strings(bodies)
+	return bodies
+}
+
+// fakeBatchOutbound implements BatchOnewayOutbound on top of fakeOutbound.
+type fakeBatchOutbound struct {
+	*fakeOutbound
+
+	err error
+}
+
+func (o *fakeBatchOutbound) CallOnewayBatch(ctx context.Context, reqs []*transport.Request) ([]transport.Ack, error) {
+	o.Lock()
+	o.batches = append(o.batches, len(reqs))
+	o.Unlock()
+
+	if o.err != nil {
+		return nil, o.err
+	}
+
+	acks := make([]transport.Ack, len(reqs))
+	errs := make(Errors, len(reqs))
+	failed := false
+	for i, req := range reqs {
+		acks[i], errs[i] = o.CallOneway(ctx, req)
+		failed = failed || errs[i] != nil
+	}
+	if failed {
+		return acks, errs
+	}
+	return acks, nil
+}
+
+func newTestRequest(body string) *transport.Request {
+	return &transport.Request{
+		Caller:    "caller",
+		Service:   "service",
+		Encoding:  "raw",
+		Procedure: "procedure",
+		Body:      bytes.NewReader([]byte(body)),
+	}
+}
+
+// callAll sends each body concurrently and returns the error for each.
+func callAll(out transport.OnewayOutbound, bodies ...string) []error {
+	errs := make([]error, len(bodies))
+	var wg sync.WaitGroup
+	for i, body := range bodies {
+		wg.Add(1)
+		go func(i int, body string) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			_, errs[i] = out.CallOneway(ctx, newTestRequest(body))
+		}(i, body)
+	}
+	wg.Wait()
+	return errs
+}
+
+func TestBatchesBySize(t *testing.T) {
+	under := &fakeBatchOutbound{fakeOutbound: newFakeOutbound()}
+	out := NewOnewayOutbound(under, MaxBatchSize(4), FlushInterval(time.Hour))
+	require.NoError(t, out.Start())
+	defer out.Stop()
+
+	for _, err := range callAll(out, "a", "b", "c", "d", "e", "f", "g", "h") {
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, []int{4, 4}, under.batches)
+	assert.Equal(t, []string{"a", "b", "c", "d", "e", "f", "g", "h"}, under.sent())
+}
+
+func TestBatchesByInterval(t *testing.T) {
+	under := &fakeBatchOutbound{fakeOutbound: newFakeOutbound()}
+	out := NewOnewayOutbound(under, MaxBatchSize(100), FlushInterval(5*time.Millisecond))
+	require.NoError(t, out.Start())
+	defer out.Stop()
+
+	ack, err := out.CallOneway(context.Background(), newTestRequest("a"))
+	require.NoError(t, err)
+	assert.Equal(t, "1", ack.String())
+	assert.Equal(t, []int{1}, under.batches)
+}
+
+func TestPartialBatchFailure(t *testing.T) {
+	under := &fakeBatchOutbound{fakeOutbound: newFakeOutbound()}
+	under.fail["bad"] = true
+	out := NewOnewayOutbound(under, MaxBatchSize(2), FlushInterval(time.Hour))
+	require.NoError(t, out.Start())
+	defer out.Stop()
+
+	errs := callAll(out, "good", "bad")
+	assert.NoError(t, errs[0])
+	assert.EqualError(t, errs[1], "rejected")
+}
+
+func TestBatchFailureFansOut(t *testing.T) {
+	under := &fakeBatchOutbound{fakeOutbound: newFakeOutbound(), err: errors.New("great sadness")}
+	out := NewOnewayOutbound(under, MaxBatchSize(3), FlushInterval(time.Hour))
+	require.NoError(t, out.Start())
+	defer out.Stop()
+
+	for _, err := range callAll(out, "a", "b", "c") {
+		assert.EqualError(t, err, "great sadness")
+	}
+}
+
+func TestUnderlyingOutboundWithoutBatching(t *testing.T) {
+	under := newFakeOutbound()
+	under.fail["bad"] = true
+	out := NewOnewayOutbound(under, MaxBatchSize(3), FlushInterval(time.Hour))
+	require.NoError(t, out.Start())
+	defer out.Stop()
+
+	errs := callAll(out, "a", "bad", "c")
+	assert.NoError(t, errs[0])
+	assert.EqualError(t, errs[1], "rejected")
+	assert.NoError(t, errs[2])
+	assert.Equal(t, []string{"a", "c"}, under.sent())
+}
+
+func TestStopFlushesBufferedRequests(t *testing.T) {
+	under := newFakeOutbound()
+	out := NewOnewayOutbound(under, FlushInterval(time.Hour))
+	require.NoError(t, out.Start())
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := out.CallOneway(context.Background(), newTestRequest("a"))
+		done <- err
+	}()
+
+	// Give the request time to be buffered before stopping.
+	select {
+	case err := <-done:
+		t.Fatalf("call returned before flush: %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	require.NoError(t, out.Stop())
+	require.NoError(t, <-done)
+	assert.Equal(t, []string{"a"}, under.sent())
+}
+
+func TestCallerDeadline(t *testing.T) {
+	under := newFakeOutbound()
+	out := NewOnewayOutbound(under, FlushInterval(time.Hour))
+	require.NoError(t, out.Start())
+	defer out.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := out.CallOneway(ctx, newTestRequest("a"))
+	assert.Equal(t, yarpcerrors.CodeDeadlineExceeded, yarpcerrors.FromError(err).Code())
+}
+
+func TestNotStarted(t *testing.T) {
+	out := NewOnewayOutbound(newFakeOutbound())
+	_, err := out.CallOneway(context.Background(), newTestRequest("a"))
+	assert.Equal(t, yarpcerrors.CodeFailedPrecondition, yarpcerrors.FromError(err).Code())
+}
+
+func TestErrors(t *testing.T) {
+	err := Errors{nil, errors.New("foo"), nil, errors.New("bar")}
+	assert.EqualError(t, err, "foo; bar")
+}
@@ -0,0 +1,49 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package pipe implements a YARPC transport over a single
+// io.ReadWriteCloser, such as the standard input and output of a
+// subprocess.
+//
+// This enables plugin architectures where a host process spawns children
+// and speaks YARPC to them over pipes. Requests flow in both directions:
+// either side may register an inbound, an outbound, or both. Concurrent
+// requests are multiplexed over the pipe and matched with their responses
+// by ID.
+//
+// In the host,
+//
+// 	rwc, err := pipe.Command(exec.Command("./plugin"))
+// 	...
+// 	trans := pipe.NewTransport(rwc)
+// 	outbound := trans.NewOutbound()
+//
+// and in the plugin,
+//
+// 	trans := pipe.NewTransport(pipe.Stdio())
+// 	inbound := trans.NewInbound()
+//
+// Processes using this transport must not write anything else to the pipe;
+// in particular, a plugin must log to standard error rather than standard
+// output.
+//
+// This package is experimental. Breaking changes may be made between minor
+// releases.
+package pipe
@@ -0,0 +1,74 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package pipe
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Every frame written to the pipe is an envelope holding the kind of
+// message, the ID of the call it belongs to, and its payload.
+type kind byte
+
+const (
+	kindRequest kind = iota + 1
+	kindOnewayRequest
+	kindResponse
+)
+
+func (k kind) String() string {
+	switch k {
+	case kindRequest:
+		return "request"
+	case kindOnewayRequest:
+		return "oneway request"
+	case kindResponse:
+		return "response"
+	default:
+		return fmt.Sprintf("kind(%d)", byte(k))
+	}
+}
+
+func encodeEnvelope(k kind, id uint64, payload []byte) []byte {
+	b := make([]byte, 1+binary.MaxVarintLen64, 1+binary.MaxVarintLen64+len(payload))
+	b[0] = byte(k)
+	n := binary.PutUvarint(b[1:], id)
+	return append(b[:1+n], payload...)
+}
+
+func decodeEnvelope(b []byte) (kind, uint64, []byte, error) {
+	if len(b) == 0 {
+		return 0, 0, nil, errors.New("empty frame")
+	}
+	k := kind(b[0])
+	switch k {
+	case kindRequest, kindOnewayRequest, kindResponse:
+	default:
+		return 0, 0, nil, fmt.Errorf("unknown frame %v", k)
+	}
+	id, n := binary.Uvarint(b[1:])
+	if n <= 0 {
+		return 0, 0, nil, errors.New("malformed call ID")
+	}
+	return k, id, b[1+n:], nil
+}
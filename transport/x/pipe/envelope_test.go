@@ -0,0 +1,57 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package pipe
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvelopeRoundTrip(t *testing.T) {
+	for _, k := range []kind{kindRequest, kindOnewayRequest, kindResponse} {
+		b := encodeEnvelope(k, 300, []byte("payload"))
+		gotKind, gotID, payload, err := decodeEnvelope(b)
+		require.NoError(t, err)
+		assert.Equal(t, k, gotKind)
+		assert.Equal(t, uint64(300), gotID)
+		assert.Equal(t, "payload", string(payload))
+	}
+}
+
+func TestDecodeEnvelopeErrors(t *testing.T) {
+	tests := []struct {
+		desc string
+		give []byte
+	}{
+		{desc: "empty", give: nil},
+		{desc: "unknown kind", give: []byte{42, 1}},
+		{desc: "missing ID", give: []byte{byte(kindRequest)}},
+		{desc: "truncated ID", give: []byte{byte(kindRequest), 0x80}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, _, _, err := decodeEnvelope(tt.give)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,204 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package pipe
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/framing"
+	"go.uber.org/yarpc/pkg/lifecycle"
+	"go.uber.org/yarpc/serialize"
+	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/zap"
+)
+
+var _ transport.Inbound = (*Inbound)(nil)
+
+// Inbound handles unary and oneway requests sent by the process at the
+// other end of the pipe.
+//
+// A transport supports at most one inbound.
+type Inbound struct {
+	transport *Transport
+	router    transport.Router
+	once      *lifecycle.Once
+}
+
+// NewInbound builds an inbound which handles requests received over the
+// transport's pipe.
+func (t *Transport) NewInbound() *Inbound {
+	return &Inbound{
+		transport: t,
+		once:      lifecycle.NewOnce(),
+	}
+}
+
+// SetRouter configures the router through which requests are dispatched.
+func (i *Inbound) SetRouter(router transport.Router) {
+	i.router = router
+}
+
+// Transports returns the transport used by this inbound.
+func (i *Inbound) Transports() []transport.Transport {
+	return []transport.Transport{i.transport}
+}
+
+// Start begins dispatching requests received over the pipe. Until then,
+// requests fail with Unavailable.
+func (i *Inbound) Start() error {
+	return i.once.Start(func() error {
+		if i.router == nil {
+			return yarpcerrors.Newf(yarpcerrors.CodeInternal, "no router configured for pipe inbound")
+		}
+		i.transport.setRouter(i.router)
+		return nil
+	})
+}
+
+// Stop stops dispatching requests received over the pipe.
+func (i *Inbound) Stop() error {
+	return i.once.Stop(func() error {
+		i.transport.setRouter(nil)
+		return nil
+	})
+}
+
+// IsRunning returns whether the inbound is running.
+func (i *Inbound) IsRunning() bool {
+	return i.once.IsRunning()
+}
+
+func (t *Transport) serve(k kind, id uint64, payload []byte) {
+	defer t.wg.Done()
+
+	res := t.handle(k, payload)
+	if k == kindOnewayRequest {
+		if res.Err != nil {
+			t.logger.Error("failed to handle oneway request from pipe", zap.Error(res.Err))
+		}
+		return
+	}
+	if err := t.write(kindResponse, id, framing.EncodeResponse(res)); err != nil {
+		t.logger.Debug("failed to write response to pipe", zap.Error(err))
+	}
+}
+
+func (t *Transport) handle(k kind, payload []byte) framing.Response {
+	start := time.Now()
+
+	router := t.getRouter()
+	if router == nil {
+		return errorResponse(yarpcerrors.Newf(yarpcerrors.CodeUnavailable, "no pipe inbound is running"))
+	}
+
+	ttl, serialized, err := framing.DecodeRequest(payload)
+	if err != nil {
+		return errorResponse(yarpcerrors.Newf(yarpcerrors.CodeInvalidArgument, "malformed request: %v", err))
+	}
+	spanContext, req, err := serialize.FromBytes(t.tracer, serialized)
+	if err != nil {
+		return errorResponse(yarpcerrors.Newf(yarpcerrors.CodeInvalidArgument, "malformed request: %v", err))
+	}
+	req.Transport = transportName
+	if err := transport.ValidateRequest(req); err != nil {
+		return errorResponse(err)
+	}
+
+	extractOpenTracingSpan := transport.ExtractOpenTracingSpan{
+		ParentSpanContext: spanContext,
+		Tracer:            t.tracer,
+		TransportName:     transportName,
+		StartTime:         start,
+	}
+	ctx, span := extractOpenTracingSpan.Do(t.ctx, req)
+	defer span.Finish()
+
+	spec, err := router.Choose(ctx, req)
+	if err != nil {
+		return errorResponse(transport.UpdateSpanWithErr(span, err))
+	}
+
+	switch {
+	case k == kindRequest && spec.Type() == transport.Unary:
+		ctx, cancel := context.WithTimeout(ctx, ttl)
+		defer cancel()
+
+		w := &responseWriter{}
+		if err := transport.InvokeUnaryHandler(transport.UnaryInvokeRequest{
+			Context:        ctx,
+			StartTime:      start,
+			Request:        req,
+			ResponseWriter: w,
+			Handler:        spec.Unary(),
+			Logger:         t.logger,
+		}); err != nil {
+			return errorResponse(transport.UpdateSpanWithErr(span, err))
+		}
+		return framing.Response{
+			Headers:          w.headers,
+			ApplicationError: w.applicationError,
+			Body:             w.buffer.Bytes(),
+		}
+
+	case k == kindOnewayRequest && spec.Type() == transport.Oneway:
+		return errorResponse(transport.UpdateSpanWithErr(span, transport.InvokeOnewayHandler(transport.OnewayInvokeRequest{
+			Context: ctx,
+			Request: req,
+			Handler: spec.Oneway(),
+			Logger:  t.logger,
+		})))
+
+	default:
+		return errorResponse(transport.UpdateSpanWithErr(span, yarpcerrors.Newf(
+			yarpcerrors.CodeUnimplemented, "procedure %q is a %s procedure but received a %v", req.Procedure, spec.Type().String(), k)))
+	}
+}
+
+func errorResponse(err error) framing.Response {
+	if err == nil {
+		return framing.Response{}
+	}
+	return framing.Response{Err: yarpcerrors.FromError(err)}
+}
+
+// responseWriter buffers a unary response until the handler returns.
+type responseWriter struct {
+	headers          transport.Headers
+	applicationError bool
+	buffer           bytes.Buffer
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	return w.buffer.Write(p)
+}
+
+func (w *responseWriter) AddHeaders(h transport.Headers) {
+	for k, v := range h.OriginalItems() {
+		w.headers = w.headers.With(k, v)
+	}
+}
+
+func (w *responseWriter) SetApplicationError() {
+	w.applicationError = true
+}
@@ -0,0 +1,171 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package pipe
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"time"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/framing"
+	"go.uber.org/yarpc/pkg/lifecycle"
+	"go.uber.org/yarpc/serialize"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+var (
+	_ transport.UnaryOutbound  = (*Outbound)(nil)
+	_ transport.OnewayOutbound = (*Outbound)(nil)
+)
+
+// Outbound sends unary and oneway requests to the process at the other end
+// of the pipe.
+type Outbound struct {
+	transport *Transport
+	once      *lifecycle.Once
+}
+
+// NewOutbound builds an outbound which sends requests over the transport's
+// pipe.
+func (t *Transport) NewOutbound() *Outbound {
+	return &Outbound{
+		transport: t,
+		once:      lifecycle.NewOnce(),
+	}
+}
+
+// Transports returns the transport used by this outbound.
+func (o *Outbound) Transports() []transport.Transport {
+	return []transport.Transport{o.transport}
+}
+
+// Start starts the outbound.
+func (o *Outbound) Start() error {
+	return o.once.Start(nil)
+}
+
+// Stop stops the outbound.
+func (o *Outbound) Stop() error {
+	return o.once.Stop(nil)
+}
+
+// IsRunning returns whether the outbound is running.
+func (o *Outbound) IsRunning() bool {
+	return o.once.IsRunning()
+}
+
+// Call sends a unary request and waits for its response.
+func (o *Outbound) Call(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	if !o.once.IsRunning() {
+		return nil, yarpcerrors.Newf(yarpcerrors.CodeFailedPrecondition, "pipe outbound has not been started")
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return nil, yarpcerrors.Newf(yarpcerrors.CodeInvalidArgument, "missing TTL")
+	}
+
+	start := time.Now()
+	createOpenTracingSpan := transport.CreateOpenTracingSpan{
+		Tracer:        o.transport.tracer,
+		TransportName: transportName,
+		StartTime:     start,
+	}
+	ctx, span := createOpenTracingSpan.Do(ctx, req)
+	defer span.Finish()
+
+	serialized, err := serialize.ToBytes(o.transport.tracer, span.Context(), req)
+	if err != nil {
+		return nil, transport.UpdateSpanWithErr(span, err)
+	}
+
+	id, responses, err := o.transport.register()
+	if err != nil {
+		return nil, transport.UpdateSpanWithErr(span, err)
+	}
+	defer o.transport.unregister(id)
+
+	if err := o.transport.write(kindRequest, id, framing.EncodeRequest(deadline.Sub(start), serialized)); err != nil {
+		return nil, transport.UpdateSpanWithErr(span, yarpcerrors.Newf(
+			yarpcerrors.CodeUnavailable, "failed to write request to pipe: %v", err))
+	}
+
+	var payload []byte
+	select {
+	case payload, ok = <-responses:
+		if !ok {
+			return nil, transport.UpdateSpanWithErr(span, o.transport.closedError())
+		}
+	case <-ctx.Done():
+		return nil, transport.UpdateSpanWithErr(span, yarpcerrors.Newf(
+			yarpcerrors.CodeDeadlineExceeded, "call to procedure %q timed out: %v", req.Procedure, ctx.Err()))
+	}
+
+	res, err := framing.DecodeResponse(payload)
+	if err != nil {
+		return nil, transport.UpdateSpanWithErr(span, yarpcerrors.Newf(
+			yarpcerrors.CodeInternal, "malformed response: %v", err))
+	}
+	if res.Err != nil {
+		return nil, transport.UpdateSpanWithErr(span, res.Err)
+	}
+	return &transport.Response{
+		Headers:          res.Headers,
+		Body:             ioutil.NopCloser(bytes.NewReader(res.Body)),
+		ApplicationError: res.ApplicationError,
+	}, nil
+}
+
+// CallOneway writes a oneway request to the pipe. It returns as soon as the
+// request has been written.
+func (o *Outbound) CallOneway(ctx context.Context, req *transport.Request) (transport.Ack, error) {
+	if !o.once.IsRunning() {
+		return nil, yarpcerrors.Newf(yarpcerrors.CodeFailedPrecondition, "pipe outbound has not been started")
+	}
+
+	start := time.Now()
+	createOpenTracingSpan := transport.CreateOpenTracingSpan{
+		Tracer:        o.transport.tracer,
+		TransportName: transportName,
+		StartTime:     start,
+	}
+	_, span := createOpenTracingSpan.Do(ctx, req)
+	defer span.Finish()
+
+	serialized, err := serialize.ToBytes(o.transport.tracer, span.Context(), req)
+	if err != nil {
+		return nil, transport.UpdateSpanWithErr(span, err)
+	}
+
+	// Oneway requests have no response, so they do not need an ID.
+	if err := o.transport.write(kindOnewayRequest, 0, framing.EncodeRequest(0, serialized)); err != nil {
+		return nil, transport.UpdateSpanWithErr(span, yarpcerrors.Newf(
+			yarpcerrors.CodeUnavailable, "failed to write request to pipe: %v", err))
+	}
+	return ack{}, nil
+}
+
+type ack struct{}
+
+func (ack) String() string {
+	return ""
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package pipe
+
+import (
+	"io"
+	"os"
+	"os/exec"
+
+	"go.uber.org/multierr"
+)
+
+// Command starts the given command and returns a pipe to its standard
+// input and output. Closing the pipe closes the command's standard input
+// and waits for it to exit.
+//
+// The command's Stdin and Stdout must not be set.
+func Command(cmd *exec.Cmd) (io.ReadWriteCloser, error) {
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &commandPipe{cmd: cmd, stdin: stdin, stdout: stdout}, nil
+}
+
+type commandPipe struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout io.ReadCloser
+}
+
+func (p *commandPipe) Read(b []byte) (int, error) {
+	return p.stdout.Read(b)
+}
+
+func (p *commandPipe) Write(b []byte) (int, error) {
+	return p.stdin.Write(b)
+}
+
+func (p *commandPipe) Close() error {
+	// Wait closes stdout once the command exits.
+	return multierr.Append(p.stdin.Close(), p.cmd.Wait())
+}
+
+// Stdio returns a pipe to the standard input and output of the current
+// process, for use by plugins spawned with Command.
+func Stdio() io.ReadWriteCloser {
+	return stdio{}
+}
+
+type stdio struct{}
+
+func (stdio) Read(b []byte) (int, error) {
+	return os.Stdin.Read(b)
+}
+
+func (stdio) Write(b []byte) (int, error) {
+	return os.Stdout.Write(b)
+}
+
+func (stdio) Close() error {
+	return multierr.Append(os.Stdin.Close(), os.Stdout.Close())
+}
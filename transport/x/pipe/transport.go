@@ -0,0 +1,234 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package pipe
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/opentracing/opentracing-go"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/framing"
+	"go.uber.org/yarpc/pkg/lifecycle"
+	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/zap"
+)
+
+const transportName = "pipe"
+
+var _ transport.Transport = (*Transport)(nil)
+
+// TransportOption customizes the behavior of a pipe Transport.
+type TransportOption func(*Transport)
+
+// Tracer configures the tracer used by the transport's inbound and
+// outbounds.
+//
+// Defaults to opentracing.GlobalTracer().
+func Tracer(tracer opentracing.Tracer) TransportOption {
+	return func(t *Transport) {
+		t.tracer = tracer
+	}
+}
+
+// Logger configures the logger used to report malformed frames and handler
+// failures.
+//
+// Defaults to a no-op logger.
+func Logger(logger *zap.Logger) TransportOption {
+	return func(t *Transport) {
+		t.logger = logger
+	}
+}
+
+// MaxFrameSize sets the maximum size of request and response frames.
+//
+// Defaults to 4 MiB.
+func MaxFrameSize(n int) TransportOption {
+	return func(t *Transport) {
+		t.maxFrameSize = n
+	}
+}
+
+// Transport multiplexes requests in both directions over a single pipe.
+// The pipe is closed when the transport is stopped.
+type Transport struct {
+	rwc          io.ReadWriteCloser
+	tracer       opentracing.Tracer
+	logger       *zap.Logger
+	maxFrameSize int
+
+	writeLock sync.Mutex
+
+	lock    sync.Mutex
+	nextID  uint64
+	pending map[uint64]chan []byte
+	err     error
+	router  transport.Router
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	once   *lifecycle.Once
+}
+
+// NewTransport builds a new transport over the given pipe.
+func NewTransport(rwc io.ReadWriteCloser, opts ...TransportOption) *Transport {
+	ctx, cancel := context.WithCancel(context.Background())
+	t := &Transport{
+		rwc:          rwc,
+		tracer:       opentracing.GlobalTracer(),
+		logger:       zap.NewNop(),
+		maxFrameSize: framing.DefaultMaxFrameSize,
+		pending:      make(map[uint64]chan []byte),
+		ctx:          ctx,
+		cancel:       cancel,
+		once:         lifecycle.NewOnce(),
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// Start starts reading from the pipe.
+func (t *Transport) Start() error {
+	return t.once.Start(func() error {
+		t.wg.Add(1)
+		go t.read()
+		return nil
+	})
+}
+
+// Stop closes the pipe, fails all outstanding calls, and waits for
+// in-flight requests to be handled.
+func (t *Transport) Stop() error {
+	return t.once.Stop(func() error {
+		t.cancel()
+		err := t.rwc.Close()
+		t.wg.Wait()
+		return err
+	})
+}
+
+// IsRunning returns whether the transport is running.
+func (t *Transport) IsRunning() bool {
+	return t.once.IsRunning()
+}
+
+func (t *Transport) read() {
+	defer t.wg.Done()
+	for {
+		b, err := framing.ReadFrame(t.rwc, t.maxFrameSize)
+		if err != nil {
+			if t.ctx.Err() == nil && err != io.EOF {
+				t.logger.Error("failed to read from pipe", zap.Error(err))
+			}
+			t.fail(err)
+			return
+		}
+
+		k, id, payload, err := decodeEnvelope(b)
+		if err != nil {
+			t.logger.Warn("dropping malformed frame from pipe", zap.Error(err))
+			continue
+		}
+		switch k {
+		case kindResponse:
+			t.deliver(id, payload)
+		default:
+			t.wg.Add(1)
+			go t.serve(k, id, payload)
+		}
+	}
+}
+
+// fail records that the pipe is no longer readable and releases all
+// outstanding calls.
+func (t *Transport) fail(err error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.err = err
+	for id, ch := range t.pending {
+		close(ch)
+		delete(t.pending, id)
+	}
+}
+
+// register allocates an ID for a call and returns the channel on which its
+// response is delivered.
+func (t *Transport) register() (uint64, <-chan []byte, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.err != nil {
+		return 0, nil, yarpcerrors.Newf(yarpcerrors.CodeUnavailable, "pipe is closed: %v", t.err)
+	}
+	t.nextID++
+	ch := make(chan []byte, 1)
+	t.pending[t.nextID] = ch
+	return t.nextID, ch, nil
+}
+
+func (t *Transport) unregister(id uint64) {
+	t.lock.Lock()
+	delete(t.pending, id)
+	t.lock.Unlock()
+}
+
+func (t *Transport) deliver(id uint64, payload []byte) {
+	t.lock.Lock()
+	ch, ok := t.pending[id]
+	delete(t.pending, id)
+	t.lock.Unlock()
+
+	// Responses to calls which have already timed out are dropped.
+	if ok {
+		ch <- payload
+	}
+}
+
+// closedError returns the reason the pipe was closed.
+func (t *Transport) closedError() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return yarpcerrors.Newf(yarpcerrors.CodeUnavailable, "pipe is closed: %v", t.err)
+}
+
+func (t *Transport) write(k kind, id uint64, payload []byte) error {
+	t.writeLock.Lock()
+	defer t.writeLock.Unlock()
+	return framing.WriteFrame(t.rwc, encodeEnvelope(k, id, payload))
+}
+
+func (t *Transport) setRouter(router transport.Router) {
+	t.lock.Lock()
+	t.router = router
+	t.lock.Unlock()
+}
+
+func (t *Transport) getRouter() transport.Router {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.router
+}
@@ -0,0 +1,267 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package pipe
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+type unaryHandlerFunc func(context.Context, *transport.Request, transport.ResponseWriter) error
+
+func (f unaryHandlerFunc) Handle(ctx context.Context, req *transport.Request, w transport.ResponseWriter) error {
+	return f(ctx, req, w)
+}
+
+type onewayHandlerFunc func(context.Context, *transport.Request) error
+
+func (f onewayHandlerFunc) HandleOneway(ctx context.Context, req *transport.Request) error {
+	return f(ctx, req)
+}
+
+func newRequest(procedure, body string) *transport.Request {
+	return &transport.Request{
+		Caller:    "caller",
+		Service:   "service",
+		Encoding:  "raw",
+		Procedure: procedure,
+		Headers:   transport.NewHeaders().With("foo", "bar"),
+		Body:      bytes.NewReader([]byte(body)),
+	}
+}
+
+// newRouter builds a router with an echo procedure, a failing procedure,
+// and a oneway procedure which publishes request bodies to the given
+// channel.
+func newRouter(t *testing.T, oneway chan<- string) transport.Router {
+	router := yarpc.NewMapRouter("service")
+	router.Register([]transport.Procedure{
+		{
+			Name:    "echo",
+			Service: "service",
+			HandlerSpec: transport.NewUnaryHandlerSpec(unaryHandlerFunc(
+				func(ctx context.Context, req *transport.Request, w transport.ResponseWriter) error {
+					assert.Equal(t, "pipe", req.Transport)
+					body, err := ioutil.ReadAll(req.Body)
+					if err != nil {
+						return err
+					}
+					w.AddHeaders(req.Headers)
+					_, err = w.Write(body)
+					return err
+				})),
+		},
+		{
+			Name:    "fail",
+			Service: "service",
+			HandlerSpec: transport.NewUnaryHandlerSpec(unaryHandlerFunc(
+				func(context.Context, *transport.Request, transport.ResponseWriter) error {
+					return yarpcerrors.Newf(yarpcerrors.CodeNotFound, "no such thing")
+				})),
+		},
+		{
+			Name:    "sleep",
+			Service: "service",
+			HandlerSpec: transport.NewUnaryHandlerSpec(unaryHandlerFunc(
+				func(ctx context.Context, _ *transport.Request, _ transport.ResponseWriter) error {
+					<-ctx.Done()
+					return yarpcerrors.Newf(yarpcerrors.CodeDeadlineExceeded, "%v", ctx.Err())
+				})),
+		},
+		{
+			Name:    "sink",
+			Service: "service",
+			HandlerSpec: transport.NewOnewayHandlerSpec(onewayHandlerFunc(
+				func(_ context.Context, req *transport.Request) error {
+					body, err := ioutil.ReadAll(req.Body)
+					oneway <- string(body)
+					return err
+				})),
+		},
+	})
+	return router
+}
+
+func start(t *testing.T, lcs ...transport.Lifecycle) func() {
+	for _, lc := range lcs {
+		require.NoError(t, lc.Start())
+	}
+	return func() {
+		for i := len(lcs) - 1; i >= 0; i-- {
+			assert.NoError(t, lcs[i].Stop())
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	hostConn, pluginConn := net.Pipe()
+	host := NewTransport(hostConn)
+	plugin := NewTransport(pluginConn)
+
+	oneway := make(chan string, 1)
+	inbound := plugin.NewInbound()
+	inbound.SetRouter(newRouter(t, oneway))
+	outbound := host.NewOutbound()
+
+	defer start(t, host, plugin, inbound, outbound)()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	res, err := outbound.Call(ctx, newRequest("echo", "hello"))
+	require.NoError(t, err)
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(body))
+	assert.Equal(t, map[string]string{"foo": "bar"}, res.Headers.Items())
+
+	_, err = outbound.Call(ctx, newRequest("fail", ""))
+	assert.Equal(t, yarpcerrors.Newf(yarpcerrors.CodeNotFound, "no such thing"), err)
+
+	_, err = outbound.Call(ctx, newRequest("sink", ""))
+	assert.Equal(t, yarpcerrors.CodeUnimplemented, yarpcerrors.FromError(err).Code())
+
+	_, err = outbound.CallOneway(ctx, newRequest("sink", "event"))
+	require.NoError(t, err)
+	assert.Equal(t, "event", <-oneway)
+
+	shortCtx, shortCancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer shortCancel()
+	_, err = outbound.Call(shortCtx, newRequest("sleep", ""))
+	assert.Equal(t, yarpcerrors.CodeDeadlineExceeded, yarpcerrors.FromError(err).Code())
+}
+
+func TestConcurrentCalls(t *testing.T) {
+	hostConn, pluginConn := net.Pipe()
+	host := NewTransport(hostConn)
+	plugin := NewTransport(pluginConn)
+
+	inbound := plugin.NewInbound()
+	inbound.SetRouter(newRouter(t, nil))
+	outbound := host.NewOutbound()
+
+	defer start(t, host, plugin, inbound, outbound)()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	results := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		go func(body string) {
+			res, err := outbound.Call(ctx, newRequest("echo", body))
+			if err == nil {
+				var got []byte
+				got, err = ioutil.ReadAll(res.Body)
+				assert.Equal(t, body, string(got))
+			}
+			results <- err
+		}(string(rune('a' + i)))
+	}
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, <-results)
+	}
+}
+
+func TestInboundNotRunning(t *testing.T) {
+	hostConn, pluginConn := net.Pipe()
+	host := NewTransport(hostConn)
+	plugin := NewTransport(pluginConn)
+	outbound := host.NewOutbound()
+
+	defer start(t, host, plugin, outbound)()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	_, err := outbound.Call(ctx, newRequest("echo", ""))
+	assert.Equal(t, yarpcerrors.CodeUnavailable, yarpcerrors.FromError(err).Code())
+}
+
+func TestPipeClosed(t *testing.T) {
+	hostConn, pluginConn := net.Pipe()
+	host := NewTransport(hostConn)
+	plugin := NewTransport(pluginConn)
+
+	inbound := plugin.NewInbound()
+	inbound.SetRouter(newRouter(t, nil))
+	outbound := host.NewOutbound()
+
+	defer start(t, host, outbound)()
+	require.NoError(t, plugin.Start())
+	require.NoError(t, inbound.Start())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := outbound.Call(ctx, newRequest("sleep", ""))
+		done <- err
+	}()
+
+	// Give the call a chance to be sent before the plugin goes away.
+	time.Sleep(10 * time.Millisecond)
+	require.NoError(t, plugin.Stop())
+
+	err := <-done
+	assert.Equal(t, yarpcerrors.CodeUnavailable, yarpcerrors.FromError(err).Code())
+
+	_, err = outbound.Call(ctx, newRequest("echo", ""))
+	assert.Equal(t, yarpcerrors.CodeUnavailable, yarpcerrors.FromError(err).Code())
+}
+
+func TestCommand(t *testing.T) {
+	if _, err := exec.LookPath("cat"); err != nil {
+		t.Skip("cat is not available")
+	}
+
+	// cat echoes requests back to us, so a transport with both an inbound
+	// and an outbound calls itself through the subprocess.
+	rwc, err := Command(exec.Command("cat"))
+	require.NoError(t, err)
+	trans := NewTransport(rwc)
+
+	inbound := trans.NewInbound()
+	inbound.SetRouter(newRouter(t, nil))
+	outbound := trans.NewOutbound()
+
+	defer start(t, trans, inbound, outbound)()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	res, err := outbound.Call(ctx, newRequest("echo", "hello"))
+	require.NoError(t, err)
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(body))
+}
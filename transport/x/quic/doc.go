@@ -0,0 +1,45 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package quic implements a YARPC transport directly on QUIC streams.
+//
+// Each RPC is mapped to its own bidirectional QUIC stream. The caller
+// writes a single length-prefixed request frame and closes its side of the
+// stream; the server replies with a single response frame. Because every
+// call uses an independent stream, a slow or lost call does not block other
+// calls on the same connection, and QUIC's connection migration keeps calls
+// flowing when a mobile client changes networks.
+//
+// This package implements the RPC layer on top of the Session, Stream, and
+// Listener interfaces only. It does not include a QUIC implementation, and
+// its tests run against in-memory streams rather than QUIC connections. To
+// use it, adapt the QUIC library of your choice to these interfaces and
+// provide a Dialer.
+//
+// 	quicTransport := quic.NewTransport(dialer)
+// 	inbound := quicTransport.NewInbound(listener)
+// 	outbound := quicTransport.NewSingleOutbound("myservice.example.com:4433")
+//
+// Unary and oneway RPCs are supported. Oneway requests are acknowledged
+// once the server has routed them to a handler.
+//
+// This package is experimental. Breaking changes may be made between minor
+// releases.
+package quic
@@ -0,0 +1,153 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package quic
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// pipeStream is one end of an in-memory bidirectional stream. Closing it
+// only closes its sending side.
+type pipeStream struct {
+	r *io.PipeReader
+	w *io.PipeWriter
+}
+
+func newStreamPair() (*pipeStream, *pipeStream) {
+	r1, w1 := io.Pipe()
+	r2, w2 := io.Pipe()
+	return &pipeStream{r: r1, w: w2}, &pipeStream{r: r2, w: w1}
+}
+
+func (s *pipeStream) Read(p []byte) (int, error)  { return s.r.Read(p) }
+func (s *pipeStream) Write(p []byte) (int, error) { return s.w.Write(p) }
+func (s *pipeStream) Close() error                { return s.w.Close() }
+func (s *pipeStream) SetDeadline(time.Time) error { return nil }
+
+type fakeAddr string
+
+func (a fakeAddr) Network() string { return "quic" }
+func (a fakeAddr) String() string  { return string(a) }
+
+// fakeSession is one end of an in-memory connection.
+type fakeSession struct {
+	remote *fakeSession
+	accept chan Stream
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+func newSessionPair() (*fakeSession, *fakeSession) {
+	a := &fakeSession{accept: make(chan Stream), closed: make(chan struct{})}
+	b := &fakeSession{accept: make(chan Stream), closed: make(chan struct{})}
+	a.remote, b.remote = b, a
+	return a, b
+}
+
+func (s *fakeSession) OpenStreamSync(ctx context.Context) (Stream, error) {
+	local, remote := newStreamPair()
+	select {
+	case s.remote.accept <- remote:
+		return local, nil
+	case <-s.closed:
+		return nil, errors.New("session closed")
+	case <-s.remote.closed:
+		return nil, errors.New("session closed by peer")
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (s *fakeSession) AcceptStream(ctx context.Context) (Stream, error) {
+	select {
+	case stream := <-s.accept:
+		return stream, nil
+	case <-s.closed:
+		return nil, errors.New("session closed")
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (s *fakeSession) RemoteAddr() net.Addr { return fakeAddr("remote") }
+
+func (s *fakeSession) Close() error {
+	s.closeOnce.Do(func() { close(s.closed) })
+	return nil
+}
+
+// fakeNetwork connects dialers to a single listener.
+type fakeNetwork struct {
+	sessions chan Session
+	closed   chan struct{}
+	dials    int
+	mu       sync.Mutex
+}
+
+func newFakeNetwork() *fakeNetwork {
+	return &fakeNetwork{sessions: make(chan Session), closed: make(chan struct{})}
+}
+
+func (n *fakeNetwork) Dial(ctx context.Context, addr string) (Session, error) {
+	n.mu.Lock()
+	n.dials++
+	n.mu.Unlock()
+
+	client, server := newSessionPair()
+	select {
+	case n.sessions <- server:
+		return client, nil
+	case <-n.closed:
+		return nil, errors.New("connection refused")
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (n *fakeNetwork) numDials() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.dials
+}
+
+func (n *fakeNetwork) Accept(ctx context.Context) (Session, error) {
+	select {
+	case s := <-n.sessions:
+		return s, nil
+	case <-n.closed:
+		return nil, errors.New("listener closed")
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (n *fakeNetwork) Addr() net.Addr { return fakeAddr("local") }
+
+func (n *fakeNetwork) Close() error {
+	close(n.closed)
+	return nil
+}
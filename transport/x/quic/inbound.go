@@ -0,0 +1,250 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package quic
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/framing"
+	"go.uber.org/yarpc/pkg/lifecycle"
+	"go.uber.org/yarpc/serialize"
+	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/zap"
+)
+
+var _ transport.Inbound = (*Inbound)(nil)
+
+// Inbound serves YARPC requests received over QUIC streams.
+type Inbound struct {
+	transport *Transport
+	listener  Listener
+	router    transport.Router
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	once *lifecycle.Once
+}
+
+// NewInbound builds an inbound which serves requests on connections
+// accepted by the given listener.
+func (t *Transport) NewInbound(listener Listener) *Inbound {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Inbound{
+		transport: t,
+		listener:  listener,
+		ctx:       ctx,
+		cancel:    cancel,
+		once:      lifecycle.NewOnce(),
+	}
+}
+
+// SetRouter configures the router through which requests are dispatched.
+func (i *Inbound) SetRouter(router transport.Router) {
+	i.router = router
+}
+
+// Transports returns the transport used by this inbound.
+func (i *Inbound) Transports() []transport.Transport {
+	return []transport.Transport{i.transport}
+}
+
+// Addr returns the address the inbound is listening on.
+func (i *Inbound) Addr() string {
+	return i.listener.Addr().String()
+}
+
+// Start begins accepting connections.
+func (i *Inbound) Start() error {
+	return i.once.Start(func() error {
+		if i.router == nil {
+			return yarpcerrors.Newf(yarpcerrors.CodeInternal, "no router configured for quic inbound")
+		}
+		i.wg.Add(1)
+		go i.accept()
+		return nil
+	})
+}
+
+// Stop stops accepting connections and waits for in-flight requests.
+func (i *Inbound) Stop() error {
+	return i.once.Stop(func() error {
+		i.cancel()
+		err := i.listener.Close()
+		i.wg.Wait()
+		return err
+	})
+}
+
+// IsRunning returns whether the inbound is running.
+func (i *Inbound) IsRunning() bool {
+	return i.once.IsRunning()
+}
+
+func (i *Inbound) accept() {
+	defer i.wg.Done()
+	for {
+		session, err := i.listener.Accept(i.ctx)
+		if err != nil {
+			if i.ctx.Err() == nil {
+				i.transport.logger.Error("quic inbound failed to accept connection", zap.Error(err))
+			}
+			return
+		}
+		i.wg.Add(1)
+		go i.serveSession(session)
+	}
+}
+
+func (i *Inbound) serveSession(session Session) {
+	defer i.wg.Done()
+	defer session.Close()
+	for {
+		stream, err := session.AcceptStream(i.ctx)
+		if err != nil {
+			return
+		}
+		i.wg.Add(1)
+		go i.serveStream(stream)
+	}
+}
+
+func (i *Inbound) serveStream(stream Stream) {
+	defer i.wg.Done()
+	defer stream.Close()
+
+	res := i.handle(stream)
+	if err := framing.WriteFrame(stream, framing.EncodeResponse(res)); err != nil {
+		i.transport.logger.Debug("quic inbound failed to write response", zap.Error(err))
+	}
+}
+
+func (i *Inbound) handle(stream Stream) framing.Response {
+	start := time.Now()
+
+	b, err := framing.ReadFrame(stream, i.transport.maxFrameSize)
+	if err != nil {
+		return errorResponse(yarpcerrors.Newf(yarpcerrors.CodeInvalidArgument, "failed to read request: %v", err))
+	}
+	ttl, serialized, err := framing.DecodeRequest(b)
+	if err != nil {
+		return errorResponse(yarpcerrors.Newf(yarpcerrors.CodeInvalidArgument, "malformed request: %v", err))
+	}
+	spanContext, req, err := serialize.FromBytes(i.transport.tracer, serialized)
+	if err != nil {
+		return errorResponse(yarpcerrors.Newf(yarpcerrors.CodeInvalidArgument, "malformed request: %v", err))
+	}
+	req.Transport = transportName
+	if err := transport.ValidateRequest(req); err != nil {
+		return errorResponse(err)
+	}
+
+	extractOpenTracingSpan := transport.ExtractOpenTracingSpan{
+		ParentSpanContext: spanContext,
+		Tracer:            i.transport.tracer,
+		TransportName:     transportName,
+		StartTime:         start,
+	}
+	ctx, span := extractOpenTracingSpan.Do(i.ctx, req)
+
+	spec, err := i.router.Choose(ctx, req)
+	if err != nil {
+		span.Finish()
+		return errorResponse(transport.UpdateSpanWithErr(span, err))
+	}
+
+	switch spec.Type() {
+	case transport.Unary:
+		defer span.Finish()
+		ctx, cancel := context.WithTimeout(ctx, ttl)
+		defer cancel()
+
+		w := newResponseWriter()
+		err := transport.InvokeUnaryHandler(transport.UnaryInvokeRequest{
+			Context:        ctx,
+			StartTime:      start,
+			Request:        req,
+			ResponseWriter: w,
+			Handler:        spec.Unary(),
+			Logger:         i.transport.logger,
+		})
+		if err != nil {
+			return errorResponse(transport.UpdateSpanWithErr(span, err))
+		}
+		return framing.Response{
+			Headers:          w.headers,
+			ApplicationError: w.applicationError,
+			Body:             w.buffer.Bytes(),
+		}
+
+	case transport.Oneway:
+		i.wg.Add(1)
+		go func() {
+			defer i.wg.Done()
+			defer span.Finish()
+			transport.UpdateSpanWithErr(span, transport.InvokeOnewayHandler(transport.OnewayInvokeRequest{
+				Context: ctx,
+				Request: req,
+				Handler: spec.Oneway(),
+				Logger:  i.transport.logger,
+			}))
+		}()
+		return framing.Response{}
+
+	default:
+		span.Finish()
+		return errorResponse(yarpcerrors.Newf(yarpcerrors.CodeUnimplemented, "transport quic does not handle %s handlers", spec.Type().String()))
+	}
+}
+
+func errorResponse(err error) framing.Response {
+	return framing.Response{Err: yarpcerrors.FromError(err)}
+}
+
+// responseWriter buffers a unary response until the handler returns.
+type responseWriter struct {
+	headers          transport.Headers
+	applicationError bool
+	buffer           bytes.Buffer
+}
+
+func newResponseWriter() *responseWriter {
+	return &responseWriter{}
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	return w.buffer.Write(p)
+}
+
+func (w *responseWriter) AddHeaders(h transport.Headers) {
+	for k, v := range h.OriginalItems() {
+		w.headers = w.headers.With(k, v)
+	}
+}
+
+func (w *responseWriter) SetApplicationError() {
+	w.applicationError = true
+}
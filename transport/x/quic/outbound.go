@@ -0,0 +1,173 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package quic
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"time"
+
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/framing"
+	"go.uber.org/yarpc/pkg/lifecycle"
+	"go.uber.org/yarpc/serialize"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+var (
+	_ transport.UnaryOutbound  = (*Outbound)(nil)
+	_ transport.OnewayOutbound = (*Outbound)(nil)
+)
+
+// Outbound sends unary and oneway requests to a single address over QUIC.
+type Outbound struct {
+	transport *Transport
+	addr      string
+
+	once *lifecycle.Once
+}
+
+// NewSingleOutbound builds an outbound which sends requests to the given
+// address.
+func (t *Transport) NewSingleOutbound(addr string) *Outbound {
+	return &Outbound{
+		transport: t,
+		addr:      addr,
+		once:      lifecycle.NewOnce(),
+	}
+}
+
+// Transports returns the transport used by this outbound.
+func (o *Outbound) Transports() []transport.Transport {
+	return []transport.Transport{o.transport}
+}
+
+// Start starts the outbound.
+func (o *Outbound) Start() error {
+	return o.once.Start(nil)
+}
+
+// Stop stops the outbound.
+func (o *Outbound) Stop() error {
+	return o.once.Stop(nil)
+}
+
+// IsRunning returns whether the outbound is running.
+func (o *Outbound) IsRunning() bool {
+	return o.once.IsRunning()
+}
+
+// Call sends a unary request over a new stream and waits for its response.
+func (o *Outbound) Call(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	res, err := o.roundTrip(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return &transport.Response{
+		Headers:          res.Headers,
+		Body:             ioutil.NopCloser(bytes.NewReader(res.Body)),
+		ApplicationError: res.ApplicationError,
+	}, nil
+}
+
+// CallOneway sends a oneway request over a new stream and waits until the
+// server has accepted it.
+func (o *Outbound) CallOneway(ctx context.Context, req *transport.Request) (transport.Ack, error) {
+	if _, err := o.roundTrip(ctx, req); err != nil {
+		return nil, err
+	}
+	return ack{}, nil
+}
+
+func (o *Outbound) roundTrip(ctx context.Context, req *transport.Request) (framing.Response, error) {
+	if !o.once.IsRunning() {
+		return framing.Response{}, yarpcerrors.Newf(yarpcerrors.CodeFailedPrecondition, "quic outbound to %q has not been started", o.addr)
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return framing.Response{}, yarpcerrors.Newf(yarpcerrors.CodeInvalidArgument, "missing TTL")
+	}
+
+	start := time.Now()
+	createOpenTracingSpan := transport.CreateOpenTracingSpan{
+		Tracer:        o.transport.tracer,
+		TransportName: transportName,
+		StartTime:     start,
+	}
+	ctx, span := createOpenTracingSpan.Do(ctx, req)
+	defer span.Finish()
+
+	serialized, err := serialize.ToBytes(o.transport.tracer, span.Context(), req)
+	if err != nil {
+		return framing.Response{}, transport.UpdateSpanWithErr(span, err)
+	}
+
+	session, err := o.transport.session(ctx, o.addr)
+	if err != nil {
+		return framing.Response{}, transport.UpdateSpanWithErr(span, yarpcerrors.Newf(
+			yarpcerrors.CodeUnavailable, "failed to connect to %q: %v", o.addr, err))
+	}
+	stream, err := session.OpenStreamSync(ctx)
+	if err != nil {
+		o.transport.discardSession(o.addr, session)
+		return framing.Response{}, transport.UpdateSpanWithErr(span, yarpcerrors.Newf(
+			yarpcerrors.CodeUnavailable, "failed to open stream to %q: %v", o.addr, err))
+	}
+	if err := stream.SetDeadline(deadline); err != nil {
+		return framing.Response{}, transport.UpdateSpanWithErr(span, err)
+	}
+
+	frame := framing.EncodeRequest(deadline.Sub(start), serialized)
+	if err := framing.WriteFrame(stream, frame); err != nil {
+		return framing.Response{}, transport.UpdateSpanWithErr(span, o.streamError(ctx, err))
+	}
+	if err := stream.Close(); err != nil {
+		return framing.Response{}, transport.UpdateSpanWithErr(span, o.streamError(ctx, err))
+	}
+
+	b, err := framing.ReadFrame(stream, o.transport.maxFrameSize)
+	if err != nil {
+		return framing.Response{}, transport.UpdateSpanWithErr(span, o.streamError(ctx, err))
+	}
+	res, err := framing.DecodeResponse(b)
+	if err != nil {
+		return framing.Response{}, transport.UpdateSpanWithErr(span, yarpcerrors.Newf(
+			yarpcerrors.CodeInternal, "malformed response from %q: %v", o.addr, err))
+	}
+	if res.Err != nil {
+		return framing.Response{}, transport.UpdateSpanWithErr(span, res.Err)
+	}
+	return res, nil
+}
+
+func (o *Outbound) streamError(ctx context.Context, err error) error {
+	if ctx.Err() == context.DeadlineExceeded {
+		return yarpcerrors.Newf(yarpcerrors.CodeDeadlineExceeded, "call to %q timed out: %v", o.addr, err)
+	}
+	return yarpcerrors.Newf(yarpcerrors.CodeUnavailable, "stream to %q failed: %v", o.addr, err)
+}
+
+type ack struct{}
+
+func (ack) String() string {
+	return ""
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package quic
+
+import (
+	"context"
+	"io"
+	"net"
+	"time"
+)
+
+// Stream is a bidirectional QUIC stream.
+type Stream interface {
+	io.Reader
+	io.Writer
+
+	// Close closes the sending side of the stream, signaling to the remote
+	// end that no more data will be written. Reading remains possible.
+	Close() error
+
+	// SetDeadline sets the read and write deadlines of the stream.
+	SetDeadline(time.Time) error
+}
+
+// Session is a QUIC connection capable of multiplexing many streams.
+type Session interface {
+	// OpenStreamSync opens a new bidirectional stream, blocking until the
+	// peer allows it or the context finishes.
+	OpenStreamSync(context.Context) (Stream, error)
+
+	// AcceptStream blocks until the peer opens a bidirectional stream or the
+	// context finishes.
+	AcceptStream(context.Context) (Stream, error)
+
+	// RemoteAddr returns the address of the peer.
+	RemoteAddr() net.Addr
+
+	// Close closes the connection and all of its streams.
+	Close() error
+}
+
+// Listener accepts incoming QUIC connections.
+type Listener interface {
+	// Accept blocks until a new connection is established or the context
+	// finishes.
+	Accept(context.Context) (Session, error)
+
+	// Addr returns the local address of the listener.
+	Addr() net.Addr
+
+	// Close stops accepting connections.
+	Close() error
+}
+
+// Dialer establishes a QUIC connection to the given address.
+type Dialer func(ctx context.Context, addr string) (Session, error)
@@ -0,0 +1,152 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package quic
+
+import (
+	"context"
+	"sync"
+
+	"github.com/opentracing/opentracing-go"
+	"go.uber.org/multierr"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/framing"
+	"go.uber.org/yarpc/pkg/lifecycle"
+	"go.uber.org/zap"
+)
+
+const transportName = "quic"
+
+var _ transport.Transport = (*Transport)(nil)
+
+// TransportOption customizes the behavior of a QUIC Transport.
+type TransportOption func(*Transport)
+
+// Tracer configures the tracer used by the transport's inbounds and
+// outbounds.
+//
+// Defaults to opentracing.GlobalTracer().
+func Tracer(tracer opentracing.Tracer) TransportOption {
+	return func(t *Transport) {
+		t.tracer = tracer
+	}
+}
+
+// Logger configures the logger used by the transport's inbounds.
+//
+// Defaults to a no-op logger.
+func Logger(logger *zap.Logger) TransportOption {
+	return func(t *Transport) {
+		t.logger = logger
+	}
+}
+
+// MaxFrameSize sets the maximum size of request and response frames.
+//
+// Defaults to 4 MiB.
+func MaxFrameSize(n int) TransportOption {
+	return func(t *Transport) {
+		t.maxFrameSize = n
+	}
+}
+
+// Transport is a QUIC transport. It maintains one connection per remote
+// address, shared by all outbounds to that address.
+type Transport struct {
+	dialer       Dialer
+	tracer       opentracing.Tracer
+	logger       *zap.Logger
+	maxFrameSize int
+
+	lock     sync.Mutex
+	sessions map[string]Session
+
+	once *lifecycle.Once
+}
+
+// NewTransport builds a new QUIC transport which connects to remote
+// addresses with the given dialer.
+func NewTransport(dialer Dialer, opts ...TransportOption) *Transport {
+	t := &Transport{
+		dialer:       dialer,
+		tracer:       opentracing.GlobalTracer(),
+		logger:       zap.NewNop(),
+		maxFrameSize: framing.DefaultMaxFrameSize,
+		sessions:     make(map[string]Session),
+		once:         lifecycle.NewOnce(),
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// Start starts the transport.
+func (t *Transport) Start() error {
+	return t.once.Start(nil)
+}
+
+// Stop stops the transport, closing all connections.
+func (t *Transport) Stop() error {
+	return t.once.Stop(func() error {
+		t.lock.Lock()
+		defer t.lock.Unlock()
+
+		var errs error
+		for addr, s := range t.sessions {
+			errs = multierr.Append(errs, s.Close())
+			delete(t.sessions, addr)
+		}
+		return errs
+	})
+}
+
+// IsRunning returns whether the transport is running.
+func (t *Transport) IsRunning() bool {
+	return t.once.IsRunning()
+}
+
+// session returns the connection to the given address, dialing it if
+// necessary.
+func (t *Transport) session(ctx context.Context, addr string) (Session, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if s, ok := t.sessions[addr]; ok {
+		return s, nil
+	}
+	s, err := t.dialer(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	t.sessions[addr] = s
+	return s, nil
+}
+
+// discardSession closes and forgets the given connection so that the next
+// call to the address establishes a new one.
+func (t *Transport) discardSession(addr string, s Session) {
+	t.lock.Lock()
+	if t.sessions[addr] == s {
+		delete(t.sessions, addr)
+	}
+	t.lock.Unlock()
+	_ = s.Close()
+}
@@ -0,0 +1,185 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package quic
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+type unaryHandlerFunc func(context.Context, *transport.Request, transport.ResponseWriter) error
+
+func (f unaryHandlerFunc) Handle(ctx context.Context, req *transport.Request, w transport.ResponseWriter) error {
+	return f(ctx, req, w)
+}
+
+type onewayHandlerFunc func(context.Context, *transport.Request) error
+
+func (f onewayHandlerFunc) HandleOneway(ctx context.Context, req *transport.Request) error {
+	return f(ctx, req)
+}
+
+func newRouter(procedures ...transport.Procedure) transport.Router {
+	router := yarpc.NewMapRouter("service")
+	router.Register(procedures)
+	return router
+}
+
+func newRequest(procedure, body string) *transport.Request {
+	return &transport.Request{
+		Caller:    "caller",
+		Service:   "service",
+		Encoding:  "raw",
+		Procedure: procedure,
+		Headers:   transport.NewHeaders().With("foo", "bar"),
+		Body:      bytes.NewReader([]byte(body)),
+	}
+}
+
+func setup(t *testing.T, procedures ...transport.Procedure) (*fakeNetwork, *Outbound, func()) {
+	network := newFakeNetwork()
+	trans := NewTransport(network.Dial)
+	in := trans.NewInbound(network)
+	in.SetRouter(newRouter(procedures...))
+	out := trans.NewSingleOutbound("remote")
+
+	require.NoError(t, trans.Start())
+	require.NoError(t, in.Start())
+	require.NoError(t, out.Start())
+	return network, out, func() {
+		assert.NoError(t, out.Stop())
+		assert.NoError(t, in.Stop())
+		assert.NoError(t, trans.Stop())
+	}
+}
+
+func TestUnaryRoundTrip(t *testing.T) {
+	network, out, stop := setup(t,
+		transport.Procedure{
+			Name:    "echo",
+			Service: "service",
+			HandlerSpec: transport.NewUnaryHandlerSpec(unaryHandlerFunc(
+				func(ctx context.Context, req *transport.Request, w transport.ResponseWriter) error {
+					assert.Equal(t, "quic", req.Transport)
+					_, hasDeadline := ctx.Deadline()
+					assert.True(t, hasDeadline, "handler must have a deadline")
+
+					body, err := ioutil.ReadAll(req.Body)
+					if err != nil {
+						return err
+					}
+					w.AddHeaders(req.Headers)
+					_, err = w.Write(body)
+					return err
+				})),
+		},
+		transport.Procedure{
+			Name:    "fail",
+			Service: "service",
+			HandlerSpec: transport.NewUnaryHandlerSpec(unaryHandlerFunc(
+				func(context.Context, *transport.Request, transport.ResponseWriter) error {
+					return yarpcerrors.Newf(yarpcerrors.CodeNotFound, "no such thing")
+				})),
+		},
+		transport.Procedure{
+			Name:    "appError",
+			Service: "service",
+			HandlerSpec: transport.NewUnaryHandlerSpec(unaryHandlerFunc(
+				func(_ context.Context, _ *transport.Request, w transport.ResponseWriter) error {
+					w.SetApplicationError()
+					return nil
+				})),
+		},
+	)
+	defer stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	res, err := out.Call(ctx, newRequest("echo", "hello"))
+	require.NoError(t, err)
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(body))
+	assert.Equal(t, map[string]string{"foo": "bar"}, res.Headers.Items())
+
+	_, err = out.Call(ctx, newRequest("fail", ""))
+	assert.Equal(t, yarpcerrors.Newf(yarpcerrors.CodeNotFound, "no such thing"), err)
+
+	res, err = out.Call(ctx, newRequest("appError", ""))
+	require.NoError(t, err)
+	assert.True(t, res.ApplicationError)
+
+	_, err = out.Call(ctx, newRequest("unknown", ""))
+	assert.Equal(t, yarpcerrors.CodeUnimplemented, yarpcerrors.FromError(err).Code())
+
+	// All calls shared a single connection.
+	assert.Equal(t, 1, network.numDials())
+}
+
+func TestOnewayRoundTrip(t *testing.T) {
+	bodies := make(chan string, 1)
+	_, out, stop := setup(t, transport.Procedure{
+		Name:    "sink",
+		Service: "service",
+		HandlerSpec: transport.NewOnewayHandlerSpec(onewayHandlerFunc(
+			func(_ context.Context, req *transport.Request) error {
+				body, err := ioutil.ReadAll(req.Body)
+				bodies <- string(body)
+				return err
+			})),
+	})
+	defer stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	_, err := out.CallOneway(ctx, newRequest("sink", "hello"))
+	require.NoError(t, err)
+	assert.Equal(t, "hello", <-bodies)
+}
+
+func TestOutboundErrors(t *testing.T) {
+	network := newFakeNetwork()
+	out := NewTransport(network.Dial).NewSingleOutbound("remote")
+
+	_, err := out.Call(context.Background(), newRequest("echo", ""))
+	assert.Equal(t, yarpcerrors.CodeFailedPrecondition, yarpcerrors.FromError(err).Code())
+
+	require.NoError(t, out.Start())
+	_, err = out.Call(context.Background(), newRequest("echo", ""))
+	assert.Equal(t, yarpcerrors.CodeInvalidArgument, yarpcerrors.FromError(err).Code())
+
+	network.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err = out.Call(ctx, newRequest("echo", ""))
+	assert.Equal(t, yarpcerrors.CodeUnavailable, yarpcerrors.FromError(err).Code())
+}
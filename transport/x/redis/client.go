@@ -0,0 +1,70 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package redis
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/yarpc/api/transport"
+)
+
+// Message is a single entry read from a Redis stream.
+type Message struct {
+	// ID of the entry in the stream.
+	ID string
+
+	// Payload is the serialized YARPC request.
+	Payload []byte
+
+	// Deliveries is the number of times this entry has been delivered to a
+	// consumer of the group, including this delivery. Clients that do not
+	// track deliveries may leave this as zero.
+	Deliveries int
+}
+
+// Client is the subset of Redis Stream commands used by this transport.
+type Client interface {
+	transport.Lifecycle
+
+	// Add appends the payload to the given stream with XADD and returns the
+	// ID of the new entry.
+	Add(ctx context.Context, stream string, payload []byte) (string, error)
+
+	// CreateGroup creates the consumer group for the given stream with
+	// XGROUP CREATE ... MKSTREAM. It MUST NOT fail if the group already
+	// exists.
+	CreateGroup(stream, group string) error
+
+	// ReadGroup reads up to count new entries for the consumer with
+	// XREADGROUP, blocking for at most the given duration. It returns an
+	// empty list if no entries arrive in time.
+	ReadGroup(stream, group, consumer string, count int, block time.Duration) ([]Message, error)
+
+	// Claim transfers up to count pending entries that have been idle for at
+	// least minIdle to the given consumer with XPENDING and XCLAIM, and
+	// returns them.
+	Claim(stream, group, consumer string, minIdle time.Duration, count int) ([]Message, error)
+
+	// Ack acknowledges the given entries with XACK, removing them from the
+	// group's pending entries list.
+	Ack(stream, group string, ids ...string) error
+}
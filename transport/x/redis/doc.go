@@ -0,0 +1,40 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package redis implements a oneway YARPC transport backed by Redis Streams.
+//
+// Outbound requests are serialized and appended to a stream with XADD.
+// Inbounds read entries from the stream as members of a consumer group
+// (XREADGROUP), dispatch them to the registered oneway handlers, and
+// acknowledge them (XACK) once the handler succeeds. Entries whose handlers
+// fail remain pending and are claimed again (XCLAIM) after they have been
+// idle for a configurable duration, giving at-least-once delivery.
+//
+// This package implements the transport on top of the Client interface only.
+// It does not include a Redis client, and its tests run against an in-memory
+// Client rather than a Redis server. To use it, implement Client with the
+// Redis library of your choice.
+//
+// 	outbound := redis.NewOnewayOutbound(client, "myservice")
+// 	inbound := redis.NewInbound(client, "myservice", "myservice-workers")
+//
+// This package is experimental. Breaking changes may be made between minor
+// releases.
+package redis
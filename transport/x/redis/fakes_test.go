@@ -0,0 +1,180 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package redis
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/yarpc/api/transport"
+)
+
+// fakeClient is an in-memory implementation of the Redis Stream commands
+// used by this transport, supporting a single stream and consumer group.
+type fakeClient struct {
+	sync.Mutex
+
+	running bool
+	nextID  int
+	groups  map[string]bool
+	// entries that have not been delivered to the group yet
+	queue []Message
+	// entries that have been delivered but not acknowledged
+	pending map[string]*pendingEntry
+	acked   []string
+	added   chan struct{}
+}
+
+type pendingEntry struct {
+	msg       Message
+	delivered time.Time
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{
+		groups:  make(map[string]bool),
+		pending: make(map[string]*pendingEntry),
+		added:   make(chan struct{}, 1),
+	}
+}
+
+func (c *fakeClient) Start() error {
+	c.Lock()
+	c.running = true
+	c.Unlock()
+	return nil
+}
+
+func (c *fakeClient) Stop() error {
+	c.Lock()
+	c.running = false
+	c.Unlock()
+	return nil
+}
+
+func (c *fakeClient) IsRunning() bool {
+	c.Lock()
+	defer c.Unlock()
+	return c.running
+}
+
+func (c *fakeClient) Add(_ context.Context, _ string, payload []byte) (string, error) {
+	c.Lock()
+	c.nextID++
+	id := strconv.Itoa(c.nextID) + "-0"
+	c.queue = append(c.queue, Message{ID: id, Payload: payload})
+	c.Unlock()
+
+	select {
+	case c.added <- struct{}{}:
+	default:
+	}
+	return id, nil
+}
+
+func (c *fakeClient) CreateGroup(_, group string) error {
+	c.Lock()
+	c.groups[group] = true
+	c.Unlock()
+	return nil
+}
+
+func (c *fakeClient) ReadGroup(_, group, _ string, count int, block time.Duration) ([]Message, error) {
+	deadline := time.After(block)
+	for {
+		c.Lock()
+		if !c.groups[group] {
+			c.Unlock()
+			return nil, errors.New("NOGROUP")
+		}
+		if len(c.queue) > 0 {
+			n := count
+			if n > len(c.queue) {
+				n = len(c.queue)
+			}
+			msgs := c.queue[:n]
+			c.queue = c.queue[n:]
+			for j := range msgs {
+				msgs[j].Deliveries = 1
+				c.pending[msgs[j].ID] = &pendingEntry{msg: msgs[j], delivered: time.Now()}
+			}
+			c.Unlock()
+			return msgs, nil
+		}
+		c.Unlock()
+
+		select {
+		case <-c.added:
+		case <-deadline:
+			return nil, nil
+		}
+	}
+}
+
+func (c *fakeClient) Claim(_, _, _ string, minIdle time.Duration, count int) ([]Message, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	var msgs []Message
+	for _, p := range c.pending {
+		if len(msgs) >= count {
+			break
+		}
+		if time.Since(p.delivered) < minIdle {
+			continue
+		}
+		p.msg.Deliveries++
+		p.delivered = time.Now()
+		msgs = append(msgs, p.msg)
+	}
+	return msgs, nil
+}
+
+func (c *fakeClient) Ack(_, _ string, ids ...string) error {
+	c.Lock()
+	for _, id := range ids {
+		delete(c.pending, id)
+		c.acked = append(c.acked, id)
+	}
+	c.Unlock()
+	return nil
+}
+
+func (c *fakeClient) numPending() int {
+	c.Lock()
+	defer c.Unlock()
+	return len(c.pending)
+}
+
+func (c *fakeClient) numAcked() int {
+	c.Lock()
+	defer c.Unlock()
+	return len(c.acked)
+}
+
+type onewayHandlerFunc func(context.Context, *transport.Request) error
+
+func (f onewayHandlerFunc) HandleOneway(ctx context.Context, req *transport.Request) error {
+	return f(ctx, req)
+}
@@ -0,0 +1,333 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package redis
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/pkg/lifecycle"
+	"go.uber.org/yarpc/serialize"
+	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/zap"
+)
+
+var _ transport.Inbound = (*Inbound)(nil)
+
+const (
+	_defaultBatchSize     = 16
+	_defaultBlockTimeout  = time.Second
+	_defaultClaimMinIdle  = 30 * time.Second
+	_defaultMaxDeliveries = 16
+	_defaultHandleTimeout = time.Minute
+)
+
+// InboundOption customizes a Redis Inbound.
+type InboundOption func(*Inbound)
+
+// Consumer sets the name of this consumer within the consumer group.
+//
+// Defaults to the hostname and process ID.
+func Consumer(name string) InboundOption {
+	return func(i *Inbound) {
+		i.consumer = name
+	}
+}
+
+// BatchSize sets the maximum number of entries read or claimed at once.
+//
+// Defaults to 16.
+func BatchSize(n int) InboundOption {
+	return func(i *Inbound) {
+		i.batchSize = n
+	}
+}
+
+// BlockTimeout sets how long a single XREADGROUP blocks waiting for new
+// entries. This also bounds how long Stop waits for the read loop to exit.
+//
+// Defaults to one second.
+func BlockTimeout(d time.Duration) InboundOption {
+	return func(i *Inbound) {
+		i.blockTimeout = d
+	}
+}
+
+// ClaimMinIdle sets how long an entry must remain unacknowledged before
+// this consumer claims it for redelivery.
+//
+// Defaults to 30 seconds.
+func ClaimMinIdle(d time.Duration) InboundOption {
+	return func(i *Inbound) {
+		i.claimMinIdle = d
+	}
+}
+
+// MaxDeliveries sets the number of deliveries after which an entry whose
+// handler keeps failing is acknowledged and dropped. Entries are only
+// dropped if the Client reports delivery counts.
+//
+// Defaults to 16.
+func MaxDeliveries(n int) InboundOption {
+	return func(i *Inbound) {
+		i.maxDeliveries = n
+	}
+}
+
+// HandleTimeout sets the deadline for each handler invocation.
+//
+// Defaults to one minute.
+func HandleTimeout(d time.Duration) InboundOption {
+	return func(i *Inbound) {
+		i.handleTimeout = d
+	}
+}
+
+// InboundTracer configures the tracer used to continue spans propagated
+// through enqueued requests.
+//
+// Defaults to opentracing.GlobalTracer().
+func InboundTracer(tracer opentracing.Tracer) InboundOption {
+	return func(i *Inbound) {
+		i.tracer = tracer
+	}
+}
+
+// InboundLogger configures the logger used to report handler failures.
+//
+// Defaults to a no-op logger.
+func InboundLogger(logger *zap.Logger) InboundOption {
+	return func(i *Inbound) {
+		i.logger = logger
+	}
+}
+
+// Inbound consumes oneway requests from a Redis stream as a member of a
+// consumer group.
+type Inbound struct {
+	client   Client
+	stream   string
+	group    string
+	consumer string
+
+	batchSize     int
+	blockTimeout  time.Duration
+	claimMinIdle  time.Duration
+	maxDeliveries int
+	handleTimeout time.Duration
+
+	tracer opentracing.Tracer
+	logger *zap.Logger
+	router transport.Router
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+	once *lifecycle.Once
+}
+
+// NewInbound builds a new Redis Inbound which consumes the given stream as
+// a member of the given consumer group.
+func NewInbound(client Client, stream, group string, opts ...InboundOption) *Inbound {
+	i := &Inbound{
+		client:        client,
+		stream:        stream,
+		group:         group,
+		consumer:      defaultConsumerName(),
+		batchSize:     _defaultBatchSize,
+		blockTimeout:  _defaultBlockTimeout,
+		claimMinIdle:  _defaultClaimMinIdle,
+		maxDeliveries: _defaultMaxDeliveries,
+		handleTimeout: _defaultHandleTimeout,
+		tracer:        opentracing.GlobalTracer(),
+		logger:        zap.NewNop(),
+		stop:          make(chan struct{}),
+		once:          lifecycle.NewOnce(),
+	}
+	for _, opt := range opts {
+		opt(i)
+	}
+	return i
+}
+
+func defaultConsumerName() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return host + "-" + strconv.Itoa(os.Getpid())
+}
+
+// SetRouter configures the router through which requests are dispatched.
+func (i *Inbound) SetRouter(router transport.Router) {
+	i.router = router
+}
+
+// Transports returns no transports. The Redis client is started and
+// stopped along with the inbound.
+func (i *Inbound) Transports() []transport.Transport {
+	return nil
+}
+
+// Start starts the Redis client, creates the consumer group, and begins
+// consuming the stream.
+func (i *Inbound) Start() error {
+	return i.once.Start(i.start)
+}
+
+func (i *Inbound) start() error {
+	if i.router == nil {
+		return yarpcerrors.Newf(yarpcerrors.CodeInternal, "no router configured for redis inbound")
+	}
+	if err := i.client.Start(); err != nil {
+		return err
+	}
+	if err := i.client.CreateGroup(i.stream, i.group); err != nil {
+		return err
+	}
+
+	i.wg.Add(1)
+	go i.consume()
+	return nil
+}
+
+// Stop stops consuming the stream, waits for in-flight handlers, and stops
+// the Redis client.
+func (i *Inbound) Stop() error {
+	return i.once.Stop(i.stopConsuming)
+}
+
+func (i *Inbound) stopConsuming() error {
+	close(i.stop)
+	i.wg.Wait()
+	return i.client.Stop()
+}
+
+// IsRunning returns whether the inbound is running.
+func (i *Inbound) IsRunning() bool {
+	return i.once.IsRunning()
+}
+
+func (i *Inbound) consume() {
+	defer i.wg.Done()
+
+	lastClaim := time.Time{}
+	for {
+		select {
+		case <-i.stop:
+			return
+		default:
+		}
+
+		// Periodically reclaim entries that other consumers (or we) failed
+		// to acknowledge before reading new ones.
+		if time.Since(lastClaim) >= i.claimMinIdle {
+			lastClaim = time.Now()
+			msgs, err := i.client.Claim(i.stream, i.group, i.consumer, i.claimMinIdle, i.batchSize)
+			if err != nil {
+				i.logger.Error("failed to claim pending redis stream entries", zap.String("stream", i.stream), zap.Error(err))
+			}
+			i.handleAll(msgs)
+		}
+
+		msgs, err := i.client.ReadGroup(i.stream, i.group, i.consumer, i.batchSize, i.blockTimeout)
+		if err != nil {
+			i.logger.Error("failed to read from redis stream", zap.String("stream", i.stream), zap.Error(err))
+			i.sleep(i.blockTimeout)
+			continue
+		}
+		i.handleAll(msgs)
+	}
+}
+
+// sleep waits for the given duration or until the inbound is stopped.
+func (i *Inbound) sleep(d time.Duration) {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+	case <-i.stop:
+	}
+}
+
+func (i *Inbound) handleAll(msgs []Message) {
+	for _, msg := range msgs {
+		if err := i.handle(msg); err != nil {
+			i.logger.Error("failed to handle redis stream entry",
+				zap.String("stream", i.stream), zap.String("id", msg.ID), zap.Error(err))
+			if i.maxDeliveries <= 0 || msg.Deliveries < i.maxDeliveries {
+				// Leave the entry pending so that it is claimed again.
+				continue
+			}
+			i.logger.Warn("dropping redis stream entry after too many deliveries",
+				zap.String("stream", i.stream), zap.String("id", msg.ID), zap.Int("deliveries", msg.Deliveries))
+		}
+		if err := i.client.Ack(i.stream, i.group, msg.ID); err != nil {
+			i.logger.Error("failed to acknowledge redis stream entry",
+				zap.String("stream", i.stream), zap.String("id", msg.ID), zap.Error(err))
+		}
+	}
+}
+
+func (i *Inbound) handle(msg Message) error {
+	start := time.Now()
+
+	spanContext, req, err := serialize.FromBytes(i.tracer, msg.Payload)
+	if err != nil {
+		return err
+	}
+	req.Transport = transportName
+
+	extractOpenTracingSpan := transport.ExtractOpenTracingSpan{
+		ParentSpanContext: spanContext,
+		Tracer:            i.tracer,
+		TransportName:     transportName,
+		StartTime:         start,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), i.handleTimeout)
+	defer cancel()
+	ctx, span := extractOpenTracingSpan.Do(ctx, req)
+	defer span.Finish()
+
+	if err := transport.ValidateRequest(req); err != nil {
+		return transport.UpdateSpanWithErr(span, err)
+	}
+
+	spec, err := i.router.Choose(ctx, req)
+	if err != nil {
+		return transport.UpdateSpanWithErr(span, err)
+	}
+	if spec.Type() != transport.Oneway {
+		return transport.UpdateSpanWithErr(span, yarpcerrors.Newf(
+			yarpcerrors.CodeUnimplemented, "transport redis does not handle %s handlers", spec.Type().String()))
+	}
+
+	return transport.UpdateSpanWithErr(span, transport.InvokeOnewayHandler(transport.OnewayInvokeRequest{
+		Context: ctx,
+		Request: req,
+		Handler: spec.Oneway(),
+		Logger:  i.logger,
+	}))
+}
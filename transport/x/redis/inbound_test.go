@@ -0,0 +1,123 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package redis
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/testtime"
+)
+
+func newTestRequest(body string) *transport.Request {
+	return &transport.Request{
+		Caller:    "caller",
+		Service:   "service",
+		Encoding:  "raw",
+		Procedure: "procedure",
+		Body:      bytes.NewReader([]byte(body)),
+	}
+}
+
+func newTestRouter(h transport.OnewayHandler) transport.Router {
+	router := yarpc.NewMapRouter("service")
+	router.Register([]transport.Procedure{{
+		Name:        "procedure",
+		Service:     "service",
+		HandlerSpec: transport.NewOnewayHandlerSpec(h),
+	}})
+	return router
+}
+
+func TestInboundRoundTrip(t *testing.T) {
+	client := newFakeClient()
+
+	bodies := make(chan string, 1)
+	in := NewInbound(client, "stream", "group", BlockTimeout(10*time.Millisecond))
+	in.SetRouter(newTestRouter(onewayHandlerFunc(func(ctx context.Context, req *transport.Request) error {
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return err
+		}
+		assert.Equal(t, transportName, req.Transport)
+		_, hasDeadline := ctx.Deadline()
+		assert.True(t, hasDeadline, "handlers must have a deadline")
+		bodies <- string(body)
+		return nil
+	})))
+	require.NoError(t, in.Start())
+	defer in.Stop()
+
+	out := NewOnewayOutbound(client, "stream")
+	require.NoError(t, out.Start())
+	_, err := out.CallOneway(context.Background(), newTestRequest("hello"))
+	require.NoError(t, err)
+
+	select {
+	case body := <-bodies:
+		assert.Equal(t, "hello", body)
+	case <-time.After(time.Second):
+		t.Fatal("handler was not called")
+	}
+	testtime.WaitFor(t, "entry must be acknowledged", func() bool { return client.numAcked() == 1 })
+	assert.Equal(t, 0, client.numPending())
+}
+
+func TestInboundRedeliversFailures(t *testing.T) {
+	client := newFakeClient()
+
+	var calls atomic.Int32
+	in := NewInbound(client, "stream", "group",
+		BlockTimeout(5*time.Millisecond),
+		ClaimMinIdle(5*time.Millisecond),
+		MaxDeliveries(3),
+	)
+	in.SetRouter(newTestRouter(onewayHandlerFunc(func(context.Context, *transport.Request) error {
+		calls.Inc()
+		return errors.New("great sadness")
+	})))
+	require.NoError(t, in.Start())
+	defer in.Stop()
+
+	out := NewOnewayOutbound(client, "stream")
+	require.NoError(t, out.Start())
+	_, err := out.CallOneway(context.Background(), newTestRequest("hello"))
+	require.NoError(t, err)
+
+	// The entry is delivered three times before being dropped.
+	testtime.WaitFor(t, "entry must be acknowledged after its last delivery", func() bool { return client.numAcked() == 1 })
+	assert.Equal(t, int32(3), calls.Load())
+	assert.Equal(t, 0, client.numPending())
+}
+
+func TestInboundRequiresRouter(t *testing.T) {
+	in := NewInbound(newFakeClient(), "stream", "group")
+	assert.Error(t, in.Start())
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/pkg/lifecycle"
+	"go.uber.org/yarpc/serialize"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+const transportName = "redis"
+
+var _ transport.OnewayOutbound = (*OnewayOutbound)(nil)
+
+// OutboundOption customizes a Redis OnewayOutbound.
+type OutboundOption func(*OnewayOutbound)
+
+// OutboundTracer configures the tracer used to propagate spans through
+// enqueued requests.
+//
+// Defaults to opentracing.GlobalTracer().
+func OutboundTracer(tracer opentracing.Tracer) OutboundOption {
+	return func(o *OnewayOutbound) {
+		o.tracer = tracer
+	}
+}
+
+// OnewayOutbound enqueues oneway requests on a Redis stream.
+type OnewayOutbound struct {
+	client Client
+	stream string
+	tracer opentracing.Tracer
+
+	once *lifecycle.Once
+}
+
+// NewOnewayOutbound builds a new Redis OnewayOutbound which appends requests
+// to the given stream.
+func NewOnewayOutbound(client Client, stream string, opts ...OutboundOption) *OnewayOutbound {
+	o := &OnewayOutbound{
+		client: client,
+		stream: stream,
+		tracer: opentracing.GlobalTracer(),
+		once:   lifecycle.NewOnce(),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Transports returns no transports. The Redis client is started and
+// stopped along with the outbound.
+func (o *OnewayOutbound) Transports() []transport.Transport {
+	return nil
+}
+
+// Start starts the outbound and its Redis client.
+func (o *OnewayOutbound) Start() error {
+	return o.once.Start(o.client.Start)
+}
+
+// Stop stops the outbound and its Redis client.
+func (o *OnewayOutbound) Stop() error {
+	return o.once.Stop(o.client.Stop)
+}
+
+// IsRunning returns whether the outbound is running.
+func (o *OnewayOutbound) IsRunning() bool {
+	return o.once.IsRunning()
+}
+
+// CallOneway serializes the request and appends it to the stream, returning
+// once Redis has accepted the entry.
+func (o *OnewayOutbound) CallOneway(ctx context.Context, req *transport.Request) (transport.Ack, error) {
+	if !o.once.IsRunning() {
+		return nil, yarpcerrors.Newf(yarpcerrors.CodeFailedPrecondition, "redis outbound for stream %q has not been started", o.stream)
+	}
+
+	createOpenTracingSpan := transport.CreateOpenTracingSpan{
+		Tracer:        o.tracer,
+		TransportName: transportName,
+		StartTime:     time.Now(),
+	}
+	ctx, span := createOpenTracingSpan.Do(ctx, req)
+	defer span.Finish()
+
+	payload, err := serialize.ToBytes(o.tracer, span.Context(), req)
+	if err != nil {
+		return nil, transport.UpdateSpanWithErr(span, err)
+	}
+
+	id, err := o.client.Add(ctx, o.stream, payload)
+	if err != nil {
+		return nil, transport.UpdateSpanWithErr(span, yarpcerrors.Newf(
+			yarpcerrors.CodeUnavailable, "failed to add request to redis stream %q: %v", o.stream, err))
+	}
+	return ack(id), nil
+}
+
+// ack is the ID of the stream entry holding the request.
+type ack string
+
+func (a ack) String() string {
+	return string(a)
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package sqs
+
+import (
+	"context"
+	"time"
+)
+
+// Message is a single message received from an SQS queue.
+type Message struct {
+	// ID of the message assigned by SQS.
+	ID string
+
+	// ReceiptHandle identifies this receipt of the message. It is required
+	// to delete the message.
+	ReceiptHandle string
+
+	// Body of the message.
+	Body string
+
+	// ReceiveCount is the number of times the message has been received,
+	// including this time (the ApproximateReceiveCount attribute). Clients
+	// that do not request the attribute may leave this as zero.
+	ReceiveCount int
+}
+
+// Client is the subset of the SQS API used by this transport.
+type Client interface {
+	// SendMessage sends a message with the given body to the queue and
+	// returns its ID.
+	SendMessage(ctx context.Context, queueURL, body string) (string, error)
+
+	// ReceiveMessages long-polls the queue for up to max messages, waiting
+	// for at most the given duration. It returns an empty list if no
+	// messages arrive in time.
+	ReceiveMessages(ctx context.Context, queueURL string, max int, wait time.Duration) ([]Message, error)
+
+	// DeleteMessage deletes a received message from the queue.
+	DeleteMessage(ctx context.Context, queueURL, receiptHandle string) error
+}
+
+// BatchClient is a Client which can send many messages in a single
+// SendMessageBatch call. OnewayOutbound uses it to send batches of requests.
+type BatchClient interface {
+	Client
+
+	// SendMessageBatch sends up to ten messages to the queue. It returns the
+	// ID of each message and an error for each message which failed, at the
+	// same index as its body. An error is returned if the call as a whole
+	// failed.
+	SendMessageBatch(ctx context.Context, queueURL string, bodies []string) (ids []string, errs []error, err error)
+}
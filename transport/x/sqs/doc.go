@@ -0,0 +1,40 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package sqs implements a oneway YARPC transport backed by Amazon SQS.
+//
+// Outbound requests are serialized and sent to a queue as messages.
+// Inbounds long-poll the queue, dispatch received messages to the
+// registered oneway handlers, and delete them once the handler succeeds.
+// Messages whose handlers fail become visible again after the queue's
+// visibility timeout and are redelivered. Optionally, messages which have
+// been received too many times are moved to a dead-letter queue instead.
+//
+// This package implements the transport on top of the Client interface only.
+// It does not include an SQS client, and its tests run against an in-memory
+// Client rather than SQS. To use it, implement Client with the AWS SDK of
+// your choice.
+//
+// 	outbound := sqs.NewOnewayOutbound(client, queueURL)
+// 	inbound := sqs.NewInbound(client, queueURL, sqs.DeadLetterQueue(dlqURL, 5))
+//
+// This package is experimental. Breaking changes may be made between minor
+// releases.
+package sqs
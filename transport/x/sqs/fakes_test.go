@@ -0,0 +1,154 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package sqs
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// fakeClient is an in-memory SQS. Received messages become visible again
+// after visibilityTimeout unless they are deleted.
+type fakeClient struct {
+	sync.Mutex
+
+	visibilityTimeout time.Duration
+	nextID            int
+	queues            map[string][]*fakeMessage
+	deleted           []string
+}
+
+type fakeMessage struct {
+	Message
+	visibleAt time.Time
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{
+		visibilityTimeout: 10 * time.Millisecond,
+		queues:            make(map[string][]*fakeMessage),
+	}
+}
+
+func (c *fakeClient) SendMessage(_ context.Context, queueURL, body string) (string, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.nextID++
+	id := strconv.Itoa(c.nextID)
+	c.queues[queueURL] = append(c.queues[queueURL], &fakeMessage{Message: Message{ID: id, Body: body}})
+	return id, nil
+}
+
+func (c *fakeClient) ReceiveMessages(ctx context.Context, queueURL string, max int, wait time.Duration) ([]Message, error) {
+	deadline := time.Now().Add(wait)
+	for {
+		if msgs := c.receive(queueURL, max); len(msgs) > 0 {
+			return msgs, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
+
+func (c *fakeClient) receive(queueURL string, max int) []Message {
+	c.Lock()
+	defer c.Unlock()
+
+	var msgs []Message
+	now := time.Now()
+	for _, m := range c.queues[queueURL] {
+		if len(msgs) == max {
+			break
+		}
+		if m.visibleAt.After(now) {
+			continue
+		}
+		m.ReceiveCount++
+		m.ReceiptHandle = m.ID + "-" + strconv.Itoa(m.ReceiveCount)
+		m.visibleAt = now.Add(c.visibilityTimeout)
+		msgs = append(msgs, m.Message)
+	}
+	return msgs
+}
+
+func (c *fakeClient) DeleteMessage(_ context.Context, queueURL, receiptHandle string) error {
+	c.Lock()
+	defer c.Unlock()
+
+	msgs := c.queues[queueURL]
+	for idx, m := range msgs {
+		if m.ReceiptHandle == receiptHandle {
+			c.queues[queueURL] = append(msgs[:idx], msgs[idx+1:]...)
+			c.deleted = append(c.deleted, m.ID)
+			return nil
+		}
+	}
+	return errors.New("no such receipt handle")
+}
+
+func (c *fakeClient) size(queueURL string) int {
+	c.Lock()
+	defer c.Unlock()
+	return len(c.queues[queueURL])
+}
+
+// fakeBatchClient adds SendMessageBatch to fakeClient. Messages at the
+// positions listed in reject fail individually.
+type fakeBatchClient struct {
+	*fakeClient
+
+	reject  map[int]bool
+	batches []int
+}
+
+func newFakeBatchClient() *fakeBatchClient {
+	return &fakeBatchClient{fakeClient: newFakeClient(), reject: make(map[int]bool)}
+}
+
+func (c *fakeBatchClient) SendMessageBatch(ctx context.Context, queueURL string, bodies []string) ([]string, []error, error) {
+	if len(bodies) > 10 {
+		return nil, nil, errors.New("too many messages in batch")
+	}
+	c.Lock()
+	c.batches = append(c.batches, len(bodies))
+	c.Unlock()
+
+	ids := make([]string, len(bodies))
+	errs := make([]error, len(bodies))
+	for i, body := range bodies {
+		if c.reject[i] {
+			errs[i] = errors.New("message rejected")
+			continue
+		}
+		ids[i], _ = c.SendMessage(ctx, queueURL, body)
+	}
+	return ids, errs, nil
+}
@@ -0,0 +1,316 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package sqs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/pkg/lifecycle"
+	"go.uber.org/yarpc/serialize"
+	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/zap"
+)
+
+var _ transport.Inbound = (*Inbound)(nil)
+
+const (
+	// SQS returns at most 10 messages per receive and waits at most 20
+	// seconds.
+	_maxBatchSize     = 10
+	_defaultBatchSize = _maxBatchSize
+	_defaultWaitTime  = 20 * time.Second
+
+	_defaultConcurrency   = 1
+	_defaultHandleTimeout = time.Minute
+	_receiveErrorBackoff  = time.Second
+)
+
+// InboundOption customizes an SQS Inbound.
+type InboundOption func(*Inbound)
+
+// BatchSize sets the maximum number of messages received at once, up to 10.
+//
+// Defaults to 10.
+func BatchSize(n int) InboundOption {
+	return func(i *Inbound) {
+		i.batchSize = n
+	}
+}
+
+// WaitTime sets how long a single receive long-polls for messages, up to 20
+// seconds. This also bounds how long Stop waits for pollers to exit if the
+// Client ignores context cancellation.
+//
+// Defaults to 20 seconds.
+func WaitTime(d time.Duration) InboundOption {
+	return func(i *Inbound) {
+		i.waitTime = d
+	}
+}
+
+// Concurrency sets the number of goroutines polling the queue. Each poller
+// handles the messages it receives one at a time.
+//
+// Defaults to 1.
+func Concurrency(n int) InboundOption {
+	return func(i *Inbound) {
+		i.concurrency = n
+	}
+}
+
+// HandleTimeout sets the deadline for each handler invocation. It should be
+// shorter than the queue's visibility timeout so that messages are not
+// redelivered while they are still being handled.
+//
+// Defaults to one minute.
+func HandleTimeout(d time.Duration) InboundOption {
+	return func(i *Inbound) {
+		i.handleTimeout = d
+	}
+}
+
+// DeadLetterQueue moves messages whose handlers fail to the queue with the
+// given URL once they have been received maxReceives times. Messages are
+// only moved if the Client reports receive counts.
+//
+// By default, failed messages are redelivered until the queue's own redrive
+// policy, if any, removes them.
+func DeadLetterQueue(queueURL string, maxReceives int) InboundOption {
+	return func(i *Inbound) {
+		i.dlqURL = queueURL
+		i.maxReceives = maxReceives
+	}
+}
+
+// InboundTracer configures the tracer used to continue spans propagated
+// through enqueued requests.
+//
+// Defaults to opentracing.GlobalTracer().
+func InboundTracer(tracer opentracing.Tracer) InboundOption {
+	return func(i *Inbound) {
+		i.tracer = tracer
+	}
+}
+
+// InboundLogger configures the logger used to report handler failures.
+//
+// Defaults to a no-op logger.
+func InboundLogger(logger *zap.Logger) InboundOption {
+	return func(i *Inbound) {
+		i.logger = logger
+	}
+}
+
+// Inbound receives oneway requests from an SQS queue.
+type Inbound struct {
+	client   Client
+	queueURL string
+
+	batchSize     int
+	waitTime      time.Duration
+	concurrency   int
+	handleTimeout time.Duration
+	dlqURL        string
+	maxReceives   int
+
+	tracer opentracing.Tracer
+	logger *zap.Logger
+	router transport.Router
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	once   *lifecycle.Once
+}
+
+// NewInbound builds a new SQS Inbound which receives requests from the
+// queue with the given URL.
+func NewInbound(client Client, queueURL string, opts ...InboundOption) *Inbound {
+	ctx, cancel := context.WithCancel(context.Background())
+	i := &Inbound{
+		client:        client,
+		queueURL:      queueURL,
+		batchSize:     _defaultBatchSize,
+		waitTime:      _defaultWaitTime,
+		concurrency:   _defaultConcurrency,
+		handleTimeout: _defaultHandleTimeout,
+		tracer:        opentracing.GlobalTracer(),
+		logger:        zap.NewNop(),
+		ctx:           ctx,
+		cancel:        cancel,
+		once:          lifecycle.NewOnce(),
+	}
+	for _, opt := range opts {
+		opt(i)
+	}
+	if i.batchSize <= 0 || i.batchSize > _maxBatchSize {
+		i.batchSize = _maxBatchSize
+	}
+	if i.concurrency <= 0 {
+		i.concurrency = _defaultConcurrency
+	}
+	return i
+}
+
+// SetRouter configures the router through which requests are dispatched.
+func (i *Inbound) SetRouter(router transport.Router) {
+	i.router = router
+}
+
+// Transports returns no transports. The SQS client has no lifecycle.
+func (i *Inbound) Transports() []transport.Transport {
+	return nil
+}
+
+// Start begins polling the queue.
+func (i *Inbound) Start() error {
+	return i.once.Start(i.start)
+}
+
+func (i *Inbound) start() error {
+	if i.router == nil {
+		return yarpcerrors.Newf(yarpcerrors.CodeInternal, "no router configured for sqs inbound")
+	}
+	for n := 0; n < i.concurrency; n++ {
+		i.wg.Add(1)
+		go i.poll()
+	}
+	return nil
+}
+
+// Stop stops polling the queue and waits for in-flight handlers.
+func (i *Inbound) Stop() error {
+	return i.once.Stop(func() error {
+		i.cancel()
+		i.wg.Wait()
+		return nil
+	})
+}
+
+// IsRunning returns whether the inbound is running.
+func (i *Inbound) IsRunning() bool {
+	return i.once.IsRunning()
+}
+
+func (i *Inbound) poll() {
+	defer i.wg.Done()
+
+	for i.ctx.Err() == nil {
+		msgs, err := i.client.ReceiveMessages(i.ctx, i.queueURL, i.batchSize, i.waitTime)
+		if err != nil {
+			if i.ctx.Err() != nil {
+				return
+			}
+			i.logger.Error("failed to receive from sqs queue", zap.String("queue", i.queueURL), zap.Error(err))
+			i.sleep(_receiveErrorBackoff)
+			continue
+		}
+		for _, msg := range msgs {
+			i.process(msg)
+		}
+	}
+}
+
+// sleep waits for the given duration or until the inbound is stopped.
+func (i *Inbound) sleep(d time.Duration) {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+	case <-i.ctx.Done():
+	}
+}
+
+func (i *Inbound) process(msg Message) {
+	// Messages are settled even while stopping so that work which has
+	// already been done is not redelivered.
+	ctx := context.Background()
+
+	err := i.handle(msg)
+	if err != nil {
+		i.logger.Error("failed to handle sqs message",
+			zap.String("queue", i.queueURL), zap.String("id", msg.ID), zap.Error(err))
+		if i.dlqURL == "" || i.maxReceives <= 0 || msg.ReceiveCount < i.maxReceives {
+			// Leave the message in the queue so that it is redelivered.
+			return
+		}
+		if _, err := i.client.SendMessage(ctx, i.dlqURL, msg.Body); err != nil {
+			i.logger.Error("failed to move sqs message to dead-letter queue",
+				zap.String("queue", i.queueURL), zap.String("id", msg.ID), zap.Error(err))
+			return
+		}
+		i.logger.Warn("moved sqs message to dead-letter queue after too many receives",
+			zap.String("queue", i.queueURL), zap.String("id", msg.ID), zap.Int("receives", msg.ReceiveCount))
+	}
+	if err := i.client.DeleteMessage(ctx, i.queueURL, msg.ReceiptHandle); err != nil {
+		i.logger.Error("failed to delete sqs message",
+			zap.String("queue", i.queueURL), zap.String("id", msg.ID), zap.Error(err))
+	}
+}
+
+func (i *Inbound) handle(msg Message) error {
+	start := time.Now()
+
+	payload, err := decodeBody(msg.Body)
+	if err != nil {
+		return err
+	}
+	spanContext, req, err := serialize.FromBytes(i.tracer, payload)
+	if err != nil {
+		return err
+	}
+	req.Transport = transportName
+
+	extractOpenTracingSpan := transport.ExtractOpenTracingSpan{
+		ParentSpanContext: spanContext,
+		Tracer:            i.tracer,
+		TransportName:     transportName,
+		StartTime:         start,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), i.handleTimeout)
+	defer cancel()
+	ctx, span := extractOpenTracingSpan.Do(ctx, req)
+	defer span.Finish()
+
+	if err := transport.ValidateRequest(req); err != nil {
+		return transport.UpdateSpanWithErr(span, err)
+	}
+
+	spec, err := i.router.Choose(ctx, req)
+	if err != nil {
+		return transport.UpdateSpanWithErr(span, err)
+	}
+	if spec.Type() != transport.Oneway {
+		return transport.UpdateSpanWithErr(span, yarpcerrors.Newf(
+			yarpcerrors.CodeUnimplemented, "transport sqs does not handle %s handlers", spec.Type().String()))
+	}
+
+	return transport.UpdateSpanWithErr(span, transport.InvokeOnewayHandler(transport.OnewayInvokeRequest{
+		Context: ctx,
+		Request: req,
+		Handler: spec.Oneway(),
+		Logger:  i.logger,
+	}))
+}
@@ -0,0 +1,247 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package sqs
+
+import (
+	"context"
+	"encoding/base64"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/pkg/lifecycle"
+	"go.uber.org/yarpc/serialize"
+	"go.uber.org/yarpc/transport/x/batch"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+const (
+	transportName = "sqs"
+
+	// _maxSendBatchSize is the most messages SQS accepts in a single
+	// SendMessageBatch call.
+	_maxSendBatchSize = 10
+)
+
+var _ batch.BatchOnewayOutbound = (*OnewayOutbound)(nil)
+
+// OutboundOption customizes an SQS OnewayOutbound.
+type OutboundOption func(*OnewayOutbound)
+
+// OutboundTracer configures the tracer used to propagate spans through
+// enqueued requests.
+//
+// Defaults to opentracing.GlobalTracer().
+func OutboundTracer(tracer opentracing.Tracer) OutboundOption {
+	return func(o *OnewayOutbound) {
+		o.tracer = tracer
+	}
+}
+
+// OnewayOutbound sends oneway requests to an SQS queue.
+type OnewayOutbound struct {
+	client   Client
+	queueURL string
+	tracer   opentracing.Tracer
+
+	once *lifecycle.Once
+}
+
+// NewOnewayOutbound builds a new SQS OnewayOutbound which sends requests to
+// the queue with the given URL.
+func NewOnewayOutbound(client Client, queueURL string, opts ...OutboundOption) *OnewayOutbound {
+	o := &OnewayOutbound{
+		client:   client,
+		queueURL: queueURL,
+		tracer:   opentracing.GlobalTracer(),
+		once:     lifecycle.NewOnce(),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Transports returns no transports. The SQS client has no lifecycle.
+func (o *OnewayOutbound) Transports() []transport.Transport {
+	return nil
+}
+
+// Start starts the outbound.
+func (o *OnewayOutbound) Start() error {
+	return o.once.Start(nil)
+}
+
+// Stop stops the outbound.
+func (o *OnewayOutbound) Stop() error {
+	return o.once.Stop(nil)
+}
+
+// IsRunning returns whether the outbound is running.
+func (o *OnewayOutbound) IsRunning() bool {
+	return o.once.IsRunning()
+}
+
+// CallOneway serializes the request and sends it to the queue, returning
+// once SQS has accepted the message.
+func (o *OnewayOutbound) CallOneway(ctx context.Context, req *transport.Request) (transport.Ack, error) {
+	if !o.once.IsRunning() {
+		return nil, yarpcerrors.Newf(yarpcerrors.CodeFailedPrecondition, "sqs outbound for queue %q has not been started", o.queueURL)
+	}
+
+	createOpenTracingSpan := transport.CreateOpenTracingSpan{
+		Tracer:        o.tracer,
+		TransportName: transportName,
+		StartTime:     time.Now(),
+	}
+	ctx, span := createOpenTracingSpan.Do(ctx, req)
+	defer span.Finish()
+
+	payload, err := serialize.ToBytes(o.tracer, span.Context(), req)
+	if err != nil {
+		return nil, transport.UpdateSpanWithErr(span, err)
+	}
+
+	id, err := o.client.SendMessage(ctx, o.queueURL, encodeBody(payload))
+	if err != nil {
+		return nil, transport.UpdateSpanWithErr(span, yarpcerrors.Newf(
+			yarpcerrors.CodeUnavailable, "failed to send request to sqs queue %q: %v", o.queueURL, err))
+	}
+	return ack(id), nil
+}
+
+// CallOnewayBatch sends the requests to the queue. If the client is a
+// BatchClient, requests are sent using SendMessageBatch, ten at a time.
+// Otherwise, each request is sent individually.
+//
+// If some of the requests fail, a batch.Errors is returned with the error for
+// each request.
+func (o *OnewayOutbound) CallOnewayBatch(ctx context.Context, reqs []*transport.Request) ([]transport.Ack, error) {
+	if !o.once.IsRunning() {
+		return nil, yarpcerrors.Newf(yarpcerrors.CodeFailedPrecondition, "sqs outbound for queue %q has not been started", o.queueURL)
+	}
+
+	bc, ok := o.client.(BatchClient)
+	if !ok {
+		return o.callEach(ctx, reqs)
+	}
+
+	acks := make([]transport.Ack, len(reqs))
+	errs := make(batch.Errors, len(reqs))
+	failed := false
+	for start := 0; start < len(reqs); start += _maxSendBatchSize {
+		end := start + _maxSendBatchSize
+		if end > len(reqs) {
+			end = len(reqs)
+		}
+		if o.sendBatch(ctx, bc, reqs[start:end], acks[start:end], errs[start:end]) {
+			failed = true
+		}
+	}
+	if failed {
+		return acks, errs
+	}
+	return acks, nil
+}
+
+// callEach sends each request with CallOneway.
+func (o *OnewayOutbound) callEach(ctx context.Context, reqs []*transport.Request) ([]transport.Ack, error) {
+	acks := make([]transport.Ack, len(reqs))
+	errs := make(batch.Errors, len(reqs))
+	failed := false
+	for i, req := range reqs {
+		acks[i], errs[i] = o.CallOneway(ctx, req)
+		if errs[i] != nil {
+			failed = true
+		}
+	}
+	if failed {
+		return acks, errs
+	}
+	return acks, nil
+}
+
+// sendBatch sends up to ten requests in one SendMessageBatch call, filling in
+// the ack or error for each. It returns true if any request failed.
+func (o *OnewayOutbound) sendBatch(ctx context.Context, bc BatchClient, reqs []*transport.Request, acks []transport.Ack, errs []error) bool {
+	start := time.Now()
+	spans := make([]opentracing.Span, len(reqs))
+	bodies := make([]string, 0, len(reqs))
+	// indexes maps each body to the request it was built from.
+	indexes := make([]int, 0, len(reqs))
+	failed := false
+	for i, req := range reqs {
+		createOpenTracingSpan := transport.CreateOpenTracingSpan{
+			Tracer:        o.tracer,
+			TransportName: transportName,
+			StartTime:     start,
+		}
+		_, spans[i] = createOpenTracingSpan.Do(ctx, req)
+		defer spans[i].Finish()
+
+		payload, err := serialize.ToBytes(o.tracer, spans[i].Context(), req)
+		if err != nil {
+			errs[i] = transport.UpdateSpanWithErr(spans[i], err)
+			failed = true
+			continue
+		}
+		bodies = append(bodies, encodeBody(payload))
+		indexes = append(indexes, i)
+	}
+	if len(bodies) == 0 {
+		return failed
+	}
+
+	ids, sendErrs, err := bc.SendMessageBatch(ctx, o.queueURL, bodies)
+	for j, i := range indexes {
+		switch {
+		case err != nil:
+			errs[i] = yarpcerrors.Newf(yarpcerrors.CodeUnavailable, "failed to send request to sqs queue %q: %v", o.queueURL, err)
+		case j < len(sendErrs) && sendErrs[j] != nil:
+			errs[i] = yarpcerrors.Newf(yarpcerrors.CodeUnavailable, "failed to send request to sqs queue %q: %v", o.queueURL, sendErrs[j])
+		case j < len(ids):
+			acks[i] = ack(ids[j])
+			continue
+		default:
+			errs[i] = yarpcerrors.Newf(yarpcerrors.CodeInternal, "sqs queue %q returned no result for request", o.queueURL)
+		}
+		transport.UpdateSpanWithErr(spans[i], errs[i])
+		failed = true
+	}
+	return failed
+}
+
+// SQS message bodies must be text, so serialized requests are base64
+// encoded.
+func encodeBody(payload []byte) string {
+	return base64.StdEncoding.EncodeToString(payload)
+}
+
+func decodeBody(body string) ([]byte, error) {
+	return base64.StdEncoding.DecodeString(body)
+}
+
+// ack is the ID of the message holding the request.
+type ack string
+
+func (a ack) String() string {
+	return string(a)
+}
@@ -0,0 +1,219 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package sqs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/testtime"
+	"go.uber.org/yarpc/transport/x/batch"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+const (
+	testQueue = "https://sqs.example.com/queue"
+	testDLQ   = "https://sqs.example.com/dlq"
+)
+
+type onewayHandlerFunc func(context.Context, *transport.Request) error
+
+func (f onewayHandlerFunc) HandleOneway(ctx context.Context, req *transport.Request) error {
+	return f(ctx, req)
+}
+
+func newTestRequest(body string) *transport.Request {
+	return &transport.Request{
+		Caller:    "caller",
+		Service:   "service",
+		Encoding:  "raw",
+		Procedure: "procedure",
+		Body:      bytes.NewReader([]byte(body)),
+	}
+}
+
+func newTestRouter(h transport.OnewayHandler) transport.Router {
+	router := yarpc.NewMapRouter("service")
+	router.Register([]transport.Procedure{{
+		Name:        "procedure",
+		Service:     "service",
+		HandlerSpec: transport.NewOnewayHandlerSpec(h),
+	}})
+	return router
+}
+
+func TestRoundTrip(t *testing.T) {
+	client := newFakeClient()
+
+	out := NewOnewayOutbound(client, testQueue)
+	require.NoError(t, out.Start())
+	defer out.Stop()
+
+	bodies := make(chan string, 1)
+	in := NewInbound(client, testQueue, WaitTime(10*time.Millisecond))
+	in.SetRouter(newTestRouter(onewayHandlerFunc(func(_ context.Context, req *transport.Request) error {
+		assert.Equal(t, "sqs", req.Transport)
+		body, err := ioutil.ReadAll(req.Body)
+		bodies <- string(body)
+		return err
+	})))
+	require.NoError(t, in.Start())
+	defer in.Stop()
+
+	ack, err := out.CallOneway(context.Background(), newTestRequest("hello"))
+	require.NoError(t, err)
+	assert.Equal(t, "1", ack.String())
+
+	assert.Equal(t, "hello", <-bodies)
+	testtime.WaitFor(t, "message must be deleted from the queue", func() bool { return client.size(testQueue) == 0 })
+}
+
+func TestRedeliveryAndDeadLetterQueue(t *testing.T) {
+	client := newFakeClient()
+
+	out := NewOnewayOutbound(client, testQueue)
+	require.NoError(t, out.Start())
+	defer out.Stop()
+
+	var calls atomic.Int32
+	in := NewInbound(client, testQueue, WaitTime(10*time.Millisecond), DeadLetterQueue(testDLQ, 3))
+	in.SetRouter(newTestRouter(onewayHandlerFunc(func(context.Context, *transport.Request) error {
+		calls.Inc()
+		return errors.New("great sadness")
+	})))
+	require.NoError(t, in.Start())
+	defer in.Stop()
+
+	_, err := out.CallOneway(context.Background(), newTestRequest("hello"))
+	require.NoError(t, err)
+
+	testtime.WaitFor(t, "message must be moved to the dead letter queue", func() bool { return client.size(testDLQ) == 1 && client.size(testQueue) == 0 })
+	assert.Equal(t, int32(3), calls.Load())
+
+	// The dead-lettered message is the original request.
+	msgs, err := client.ReceiveMessages(context.Background(), testDLQ, 1, 0)
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	payload, err := decodeBody(msgs[0].Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(payload), "hello")
+}
+
+func TestFailedMessagesStayWithoutDeadLetterQueue(t *testing.T) {
+	client := newFakeClient()
+
+	out := NewOnewayOutbound(client, testQueue)
+	require.NoError(t, out.Start())
+	defer out.Stop()
+
+	var calls atomic.Int32
+	in := NewInbound(client, testQueue, WaitTime(10*time.Millisecond))
+	in.SetRouter(newTestRouter(onewayHandlerFunc(func(context.Context, *transport.Request) error {
+		calls.Inc()
+		return errors.New("great sadness")
+	})))
+	require.NoError(t, in.Start())
+
+	_, err := out.CallOneway(context.Background(), newTestRequest("hello"))
+	require.NoError(t, err)
+
+	testtime.WaitFor(t, "message must be delivered three times", func() bool { return calls.Load() >= 3 })
+	require.NoError(t, in.Stop())
+	assert.Equal(t, 1, client.size(testQueue))
+	assert.Equal(t, 0, client.size(testDLQ))
+}
+
+func TestOutboundNotStarted(t *testing.T) {
+	out := NewOnewayOutbound(newFakeClient(), testQueue)
+	_, err := out.CallOneway(context.Background(), newTestRequest("hello"))
+	assert.Equal(t, yarpcerrors.CodeFailedPrecondition, yarpcerrors.FromError(err).Code())
+}
+
+func TestInboundRequiresRouter(t *testing.T) {
+	assert.Error(t, NewInbound(newFakeClient(), testQueue).Start())
+}
+
+func TestCallOnewayBatch(t *testing.T) {
+	client := newFakeBatchClient()
+
+	out := NewOnewayOutbound(client, testQueue)
+	require.NoError(t, out.Start())
+	defer out.Stop()
+
+	reqs := make([]*transport.Request, 12)
+	for i := range reqs {
+		reqs[i] = newTestRequest("hello")
+	}
+	acks, err := out.CallOnewayBatch(context.Background(), reqs)
+	require.NoError(t, err)
+	require.Len(t, acks, 12)
+	assert.Equal(t, "12", acks[11].String())
+	assert.Equal(t, []int{10, 2}, client.batches)
+	assert.Equal(t, 12, client.size(testQueue))
+}
+
+func TestCallOnewayBatchPartialFailure(t *testing.T) {
+	client := newFakeBatchClient()
+
+	out := NewOnewayOutbound(client, testQueue)
+	require.NoError(t, out.Start())
+	defer out.Stop()
+
+	client.reject[1] = true
+
+	acks, err := out.CallOnewayBatch(context.Background(), []*transport.Request{
+		newTestRequest("good"),
+		newTestRequest("bad"),
+	})
+	require.Error(t, err)
+	errs, ok := err.(batch.Errors)
+	require.True(t, ok, "expected batch.Errors, got %T", err)
+	require.Len(t, errs, 2)
+	assert.NoError(t, errs[0])
+	assert.Equal(t, yarpcerrors.CodeUnavailable, yarpcerrors.FromError(errs[1]).Code())
+	assert.NotNil(t, acks[0])
+	assert.Nil(t, acks[1])
+}
+
+func TestCallOnewayBatchWithoutBatchClient(t *testing.T) {
+	client := newFakeClient()
+
+	out := NewOnewayOutbound(client, testQueue)
+	require.NoError(t, out.Start())
+	defer out.Stop()
+
+	acks, err := out.CallOnewayBatch(context.Background(), []*transport.Request{
+		newTestRequest("a"),
+		newTestRequest("b"),
+	})
+	require.NoError(t, err)
+	assert.Len(t, acks, 2)
+	assert.Equal(t, 2, client.size(testQueue))
+}
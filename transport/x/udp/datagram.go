@@ -0,0 +1,80 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package udp
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// _version is the first byte of every datagram, reserved to allow the
+// format to evolve.
+const _version byte = 1
+
+var errEmptyDatagram = errors.New("empty datagram")
+
+// newDatagram returns an empty datagram with room for size bytes.
+func newDatagram(size int) []byte {
+	return append(make([]byte, 0, size), _version)
+}
+
+// frameSize returns the number of bytes a request of the given length
+// occupies in a datagram.
+func frameSize(n int) int {
+	var lenBuf [binary.MaxVarintLen64]byte
+	return binary.PutUvarint(lenBuf[:], uint64(n)) + n
+}
+
+// appendFrame appends a length-prefixed request to a datagram.
+func appendFrame(datagram []byte, b []byte) []byte {
+	var lenBuf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(lenBuf[:], uint64(len(b)))
+	datagram = append(datagram, lenBuf[:n]...)
+	return append(datagram, b...)
+}
+
+// splitDatagram returns the requests held in a datagram. The returned
+// slices alias the datagram.
+func splitDatagram(datagram []byte) ([][]byte, error) {
+	if len(datagram) == 0 {
+		return nil, errEmptyDatagram
+	}
+	if datagram[0] != _version {
+		return nil, fmt.Errorf("unsupported datagram version %d", datagram[0])
+	}
+
+	var frames [][]byte
+	rest := datagram[1:]
+	for len(rest) > 0 {
+		n, read := binary.Uvarint(rest)
+		if read <= 0 {
+			return nil, errors.New("malformed frame length")
+		}
+		rest = rest[read:]
+		if n > uint64(len(rest)) {
+			return nil, fmt.Errorf("frame of %d bytes exceeds remaining %d bytes", n, len(rest))
+		}
+		frames = append(frames, rest[:n])
+		rest = rest[n:]
+	}
+	return frames, nil
+}
@@ -0,0 +1,63 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package udp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatagramRoundTrip(t *testing.T) {
+	frames := [][]byte{[]byte("foo"), {}, make([]byte, 300)}
+
+	datagram := newDatagram(0)
+	size := 1
+	for _, f := range frames {
+		datagram = appendFrame(datagram, f)
+		size += frameSize(len(f))
+	}
+	assert.Len(t, datagram, size)
+
+	got, err := splitDatagram(datagram)
+	require.NoError(t, err)
+	assert.Equal(t, frames, got)
+}
+
+func TestSplitDatagramErrors(t *testing.T) {
+	tests := []struct {
+		desc     string
+		datagram []byte
+	}{
+		{desc: "empty", datagram: nil},
+		{desc: "unknown version", datagram: []byte{2, 1, 'a'}},
+		{desc: "truncated frame", datagram: []byte{_version, 5, 'a'}},
+		{desc: "truncated length", datagram: []byte{_version, 0x80}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := splitDatagram(tt.datagram)
+			assert.Error(t, err)
+		})
+	}
+}
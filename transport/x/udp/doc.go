@@ -0,0 +1,38 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package udp implements a best-effort oneway YARPC transport over UDP.
+//
+// It is intended for extremely high-volume, loss-tolerant calls such as
+// telemetry, where the overhead of a connection-oriented transport is
+// unacceptable. Requests may be dropped, duplicated, or reordered by the
+// network, and callers receive no indication of whether a request was
+// handled. Only oneway procedures are supported.
+//
+// Each datagram holds one or more serialized requests, each prefixed with
+// its length. Outbounds may optionally batch requests, packing as many as
+// fit into a single datagram before it is sent.
+//
+// 	outbound := udp.NewOnewayOutbound("127.0.0.1:6831", udp.BatchInterval(50*time.Millisecond))
+// 	inbound := udp.NewInbound(":6831")
+//
+// This package is experimental. Breaking changes may be made between minor
+// releases.
+package udp
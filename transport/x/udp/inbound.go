@@ -0,0 +1,239 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package udp
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/pkg/lifecycle"
+	"go.uber.org/yarpc/serialize"
+	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/zap"
+)
+
+const _defaultHandleTimeout = time.Minute
+
+var _ transport.Inbound = (*Inbound)(nil)
+
+// InboundOption customizes a UDP Inbound.
+type InboundOption func(*Inbound)
+
+// HandleTimeout sets the deadline for each handler invocation.
+//
+// Defaults to one minute.
+func HandleTimeout(d time.Duration) InboundOption {
+	return func(i *Inbound) {
+		i.handleTimeout = d
+	}
+}
+
+// InboundTracer configures the tracer used to continue spans propagated
+// with requests.
+//
+// Defaults to opentracing.GlobalTracer().
+func InboundTracer(tracer opentracing.Tracer) InboundOption {
+	return func(i *Inbound) {
+		i.tracer = tracer
+	}
+}
+
+// InboundLogger configures the logger used to report malformed datagrams
+// and handler failures.
+//
+// Defaults to a no-op logger.
+func InboundLogger(logger *zap.Logger) InboundOption {
+	return func(i *Inbound) {
+		i.logger = logger
+	}
+}
+
+// Inbound receives oneway requests over UDP.
+type Inbound struct {
+	addr          string
+	handleTimeout time.Duration
+	tracer        opentracing.Tracer
+	logger        *zap.Logger
+	router        transport.Router
+
+	conn net.PacketConn
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+	once *lifecycle.Once
+}
+
+// NewInbound builds a new UDP Inbound which listens on the given address.
+// Use ":0" to listen on a random port.
+func NewInbound(addr string, opts ...InboundOption) *Inbound {
+	i := &Inbound{
+		addr:          addr,
+		handleTimeout: _defaultHandleTimeout,
+		tracer:        opentracing.GlobalTracer(),
+		logger:        zap.NewNop(),
+		stop:          make(chan struct{}),
+		once:          lifecycle.NewOnce(),
+	}
+	for _, opt := range opts {
+		opt(i)
+	}
+	return i
+}
+
+// SetRouter configures the router through which requests are dispatched.
+func (i *Inbound) SetRouter(router transport.Router) {
+	i.router = router
+}
+
+// Transports returns no transports. The UDP socket is owned by the inbound.
+func (i *Inbound) Transports() []transport.Transport {
+	return nil
+}
+
+// Addr returns the address on which the inbound is listening, or nil if
+// it has not been started.
+func (i *Inbound) Addr() net.Addr {
+	if i.conn == nil {
+		return nil
+	}
+	return i.conn.LocalAddr()
+}
+
+// Start starts listening for datagrams.
+func (i *Inbound) Start() error {
+	return i.once.Start(i.start)
+}
+
+func (i *Inbound) start() error {
+	if i.router == nil {
+		return yarpcerrors.Newf(yarpcerrors.CodeInternal, "no router configured for udp inbound")
+	}
+	conn, err := net.ListenPacket("udp", i.addr)
+	if err != nil {
+		return err
+	}
+	i.conn = conn
+
+	i.wg.Add(1)
+	go i.serve()
+	return nil
+}
+
+// Stop closes the UDP socket and waits for in-flight handlers.
+func (i *Inbound) Stop() error {
+	return i.once.Stop(i.stopServing)
+}
+
+func (i *Inbound) stopServing() error {
+	close(i.stop)
+	err := i.conn.Close()
+	i.wg.Wait()
+	return err
+}
+
+// IsRunning returns whether the inbound is running.
+func (i *Inbound) IsRunning() bool {
+	return i.once.IsRunning()
+}
+
+func (i *Inbound) serve() {
+	defer i.wg.Done()
+
+	buf := make([]byte, _maxDatagramSize)
+	for {
+		n, from, err := i.conn.ReadFrom(buf)
+		if err != nil {
+			select {
+			case <-i.stop:
+				return
+			default:
+			}
+			i.logger.Error("failed to read udp datagram", zap.Error(err))
+			continue
+		}
+
+		// The buffer is reused for the next datagram.
+		datagram := append([]byte(nil), buf[:n]...)
+		frames, err := splitDatagram(datagram)
+		if err != nil {
+			i.logger.Warn("dropping malformed udp datagram", zap.Stringer("from", from), zap.Error(err))
+			continue
+		}
+
+		i.wg.Add(1)
+		go i.handleAll(frames)
+	}
+}
+
+func (i *Inbound) handleAll(frames [][]byte) {
+	defer i.wg.Done()
+
+	for _, frame := range frames {
+		if err := i.handle(frame); err != nil {
+			i.logger.Error("failed to handle udp request", zap.Error(err))
+		}
+	}
+}
+
+func (i *Inbound) handle(frame []byte) error {
+	start := time.Now()
+
+	spanContext, req, err := serialize.FromBytes(i.tracer, frame)
+	if err != nil {
+		return err
+	}
+	req.Transport = transportName
+
+	extractOpenTracingSpan := transport.ExtractOpenTracingSpan{
+		ParentSpanContext: spanContext,
+		Tracer:            i.tracer,
+		TransportName:     transportName,
+		StartTime:         start,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), i.handleTimeout)
+	defer cancel()
+	ctx, span := extractOpenTracingSpan.Do(ctx, req)
+	defer span.Finish()
+
+	if err := transport.ValidateRequest(req); err != nil {
+		return transport.UpdateSpanWithErr(span, err)
+	}
+
+	spec, err := i.router.Choose(ctx, req)
+	if err != nil {
+		return transport.UpdateSpanWithErr(span, err)
+	}
+	if spec.Type() != transport.Oneway {
+		return transport.UpdateSpanWithErr(span, yarpcerrors.Newf(
+			yarpcerrors.CodeUnimplemented, "transport udp does not handle %s handlers", spec.Type().String()))
+	}
+
+	return transport.UpdateSpanWithErr(span, transport.InvokeOnewayHandler(transport.OnewayInvokeRequest{
+		Context: ctx,
+		Request: req,
+		Handler: spec.Oneway(),
+		Logger:  i.logger,
+	}))
+}
@@ -0,0 +1,258 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package udp
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/pkg/lifecycle"
+	"go.uber.org/yarpc/serialize"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+const (
+	transportName = "udp"
+
+	// _defaultMaxDatagramSize keeps datagrams below the typical Ethernet MTU
+	// to avoid IP fragmentation.
+	_defaultMaxDatagramSize = 1400
+
+	// _maxDatagramSize is the largest UDP payload.
+	_maxDatagramSize = 65507
+)
+
+var _ transport.OnewayOutbound = (*OnewayOutbound)(nil)
+
+// OutboundOption customizes a UDP OnewayOutbound.
+type OutboundOption func(*OnewayOutbound)
+
+// OutboundTracer configures the tracer used to propagate spans with
+// requests.
+//
+// Defaults to opentracing.GlobalTracer().
+func OutboundTracer(tracer opentracing.Tracer) OutboundOption {
+	return func(o *OnewayOutbound) {
+		o.tracer = tracer
+	}
+}
+
+// MaxDatagramSize sets the maximum size of datagrams sent by the outbound.
+// Requests which do not fit in a single datagram are rejected.
+//
+// Defaults to 1400 bytes.
+func MaxDatagramSize(n int) OutboundOption {
+	return func(o *OnewayOutbound) {
+		o.maxDatagramSize = n
+	}
+}
+
+// BatchInterval enables batching. Requests are buffered and packed into as
+// few datagrams as possible, which are sent when full or after the given
+// interval, whichever comes first. Buffered requests are sent when the
+// outbound is stopped.
+//
+// Batching is disabled by default and every request is sent in its own
+// datagram.
+func BatchInterval(d time.Duration) OutboundOption {
+	return func(o *OnewayOutbound) {
+		o.batchInterval = d
+	}
+}
+
+// OnewayOutbound sends oneway requests to a single address over UDP.
+//
+// Successful calls only indicate that the request was handed to the
+// operating system (or buffered, if batching is enabled), not that it was
+// received.
+type OnewayOutbound struct {
+	addr            string
+	tracer          opentracing.Tracer
+	maxDatagramSize int
+	batchInterval   time.Duration
+
+	conn net.Conn
+
+	mu    sync.Mutex
+	batch []byte
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+	once *lifecycle.Once
+}
+
+// NewOnewayOutbound builds a new UDP OnewayOutbound which sends requests to
+// the given address.
+func NewOnewayOutbound(addr string, opts ...OutboundOption) *OnewayOutbound {
+	o := &OnewayOutbound{
+		addr:            addr,
+		tracer:          opentracing.GlobalTracer(),
+		maxDatagramSize: _defaultMaxDatagramSize,
+		stop:            make(chan struct{}),
+		once:            lifecycle.NewOnce(),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.maxDatagramSize <= 0 || o.maxDatagramSize > _maxDatagramSize {
+		o.maxDatagramSize = _maxDatagramSize
+	}
+	o.batch = newDatagram(o.maxDatagramSize)
+	return o
+}
+
+// Transports returns no transports. The UDP socket is owned by the
+// outbound.
+func (o *OnewayOutbound) Transports() []transport.Transport {
+	return nil
+}
+
+// Start opens the UDP socket and, if batching is enabled, starts flushing
+// batches periodically.
+func (o *OnewayOutbound) Start() error {
+	return o.once.Start(o.start)
+}
+
+func (o *OnewayOutbound) start() error {
+	conn, err := net.Dial("udp", o.addr)
+	if err != nil {
+		return err
+	}
+	o.conn = conn
+
+	if o.batchInterval > 0 {
+		o.wg.Add(1)
+		go o.flushLoop()
+	}
+	return nil
+}
+
+// Stop sends any buffered requests and closes the UDP socket.
+func (o *OnewayOutbound) Stop() error {
+	return o.once.Stop(o.stopSending)
+}
+
+func (o *OnewayOutbound) stopSending() error {
+	close(o.stop)
+	o.wg.Wait()
+
+	o.mu.Lock()
+	err := o.flush()
+	o.mu.Unlock()
+
+	if cerr := o.conn.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// IsRunning returns whether the outbound is running.
+func (o *OnewayOutbound) IsRunning() bool {
+	return o.once.IsRunning()
+}
+
+// CallOneway serializes the request and sends it, or adds it to the current
+// batch if batching is enabled.
+func (o *OnewayOutbound) CallOneway(ctx context.Context, req *transport.Request) (transport.Ack, error) {
+	if !o.once.IsRunning() {
+		return nil, yarpcerrors.Newf(yarpcerrors.CodeFailedPrecondition, "udp outbound to %q has not been started", o.addr)
+	}
+
+	createOpenTracingSpan := transport.CreateOpenTracingSpan{
+		Tracer:        o.tracer,
+		TransportName: transportName,
+		StartTime:     time.Now(),
+	}
+	ctx, span := createOpenTracingSpan.Do(ctx, req)
+	defer span.Finish()
+
+	b, err := serialize.ToBytes(o.tracer, span.Context(), req)
+	if err != nil {
+		return nil, transport.UpdateSpanWithErr(span, err)
+	}
+	size := frameSize(len(b))
+	if 1+size > o.maxDatagramSize {
+		return nil, transport.UpdateSpanWithErr(span, yarpcerrors.Newf(
+			yarpcerrors.CodeInvalidArgument, "request of %d bytes does not fit in a datagram of %d bytes", size, o.maxDatagramSize))
+	}
+
+	if o.batchInterval <= 0 {
+		if _, err := o.conn.Write(appendFrame(newDatagram(1+size), b)); err != nil {
+			return nil, transport.UpdateSpanWithErr(span, o.writeError(err))
+		}
+		return ack{}, nil
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if len(o.batch)+size > o.maxDatagramSize {
+		// A failure only loses the requests already in the batch, which
+		// were acknowledged when they were buffered.
+		_ = o.flush()
+	}
+	o.batch = appendFrame(o.batch, b)
+	return ack{}, nil
+}
+
+func (o *OnewayOutbound) flushLoop() {
+	defer o.wg.Done()
+
+	ticker := time.NewTicker(o.batchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			o.mu.Lock()
+			// Errors are dropped here: nobody is waiting on the batch.
+			_ = o.flush()
+			o.mu.Unlock()
+		case <-o.stop:
+			return
+		}
+	}
+}
+
+// flush sends the current batch, if any. The caller must hold o.mu.
+func (o *OnewayOutbound) flush() error {
+	if len(o.batch) <= 1 {
+		return nil
+	}
+	_, err := o.conn.Write(o.batch)
+	o.batch = o.batch[:1]
+	if err != nil {
+		return o.writeError(err)
+	}
+	return nil
+}
+
+func (o *OnewayOutbound) writeError(err error) error {
+	return yarpcerrors.Newf(yarpcerrors.CodeUnavailable, "failed to send datagram to %q: %v", o.addr, err)
+}
+
+type ack struct{}
+
+func (ack) String() string {
+	return ""
+}
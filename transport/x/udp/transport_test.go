@@ -0,0 +1,159 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package udp
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+type onewayHandlerFunc func(context.Context, *transport.Request) error
+
+func (f onewayHandlerFunc) HandleOneway(ctx context.Context, req *transport.Request) error {
+	return f(ctx, req)
+}
+
+func newRequest(body string) *transport.Request {
+	return &transport.Request{
+		Caller:    "caller",
+		Service:   "service",
+		Encoding:  "raw",
+		Procedure: "sink",
+		Body:      bytes.NewReader([]byte(body)),
+	}
+}
+
+// startInbound starts an inbound on a random port whose handler publishes
+// request bodies to the returned channel.
+func startInbound(t *testing.T) (*Inbound, <-chan string) {
+	bodies := make(chan string, 16)
+	router := yarpc.NewMapRouter("service")
+	router.Register([]transport.Procedure{{
+		Name:    "sink",
+		Service: "service",
+		HandlerSpec: transport.NewOnewayHandlerSpec(onewayHandlerFunc(
+			func(_ context.Context, req *transport.Request) error {
+				assert.Equal(t, "udp", req.Transport)
+				body, err := ioutil.ReadAll(req.Body)
+				bodies <- string(body)
+				return err
+			})),
+	}})
+
+	in := NewInbound("127.0.0.1:0")
+	in.SetRouter(router)
+	require.NoError(t, in.Start())
+	return in, bodies
+}
+
+func receive(t *testing.T, bodies <-chan string) string {
+	select {
+	case body := <-bodies:
+		return body
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for request")
+		return ""
+	}
+}
+
+func TestUnbatched(t *testing.T) {
+	in, bodies := startInbound(t)
+	defer in.Stop()
+
+	out := NewOnewayOutbound(in.Addr().String())
+	require.NoError(t, out.Start())
+	defer out.Stop()
+
+	_, err := out.CallOneway(context.Background(), newRequest("hello"))
+	require.NoError(t, err)
+	assert.Equal(t, "hello", receive(t, bodies))
+}
+
+func TestBatched(t *testing.T) {
+	in, bodies := startInbound(t)
+	defer in.Stop()
+
+	out := NewOnewayOutbound(in.Addr().String(), BatchInterval(time.Hour))
+	require.NoError(t, out.Start())
+
+	for _, body := range []string{"a", "b", "c"} {
+		_, err := out.CallOneway(context.Background(), newRequest(body))
+		require.NoError(t, err)
+	}
+	select {
+	case <-bodies:
+		t.Fatal("batched requests must not be sent before a flush")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// Stopping the outbound flushes the batch.
+	require.NoError(t, out.Stop())
+	assert.Equal(t, "a", receive(t, bodies))
+	assert.Equal(t, "b", receive(t, bodies))
+	assert.Equal(t, "c", receive(t, bodies))
+}
+
+func TestBatchFlushesWhenFull(t *testing.T) {
+	in, bodies := startInbound(t)
+	defer in.Stop()
+
+	out := NewOnewayOutbound(in.Addr().String(), BatchInterval(time.Hour), MaxDatagramSize(512))
+	require.NoError(t, out.Start())
+	defer out.Stop()
+
+	big := strings.Repeat("x", 200)
+	for i := 0; i < 3; i++ {
+		_, err := out.CallOneway(context.Background(), newRequest(big))
+		require.NoError(t, err)
+	}
+	// The third request did not fit, so the first batch was sent.
+	assert.Equal(t, big, receive(t, bodies))
+}
+
+func TestOutboundErrors(t *testing.T) {
+	in, _ := startInbound(t)
+	defer in.Stop()
+
+	out := NewOnewayOutbound(in.Addr().String(), MaxDatagramSize(64))
+
+	_, err := out.CallOneway(context.Background(), newRequest("hello"))
+	assert.Equal(t, yarpcerrors.CodeFailedPrecondition, yarpcerrors.FromError(err).Code())
+
+	require.NoError(t, out.Start())
+	defer out.Stop()
+
+	_, err = out.CallOneway(context.Background(), newRequest(strings.Repeat("x", 100)))
+	assert.Equal(t, yarpcerrors.CodeInvalidArgument, yarpcerrors.FromError(err).Code())
+}
+
+func TestInboundRequiresRouter(t *testing.T) {
+	assert.Error(t, NewInbound("127.0.0.1:0").Start())
+}
@@ -0,0 +1,134 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package admin
+
+import (
+	"context"
+	"strconv"
+
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/peer/hostport"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// DrainPeer builds an action which removes a peer from a peer list, so that
+// no new requests are sent to it. The peer is named by the "peer"
+// parameter.
+func DrainPeer(name string, list peer.List) Action {
+	return Action{
+		Name:        name,
+		Description: "Remove a peer from the peer list.",
+		Params:      []string{"peer"},
+		Run: func(_ context.Context, params map[string]string) error {
+			return list.Update(peer.ListUpdates{
+				Removals: []peer.Identifier{hostport.PeerIdentifier(params["peer"])},
+			})
+		},
+	}
+}
+
+// RestorePeer builds an action which adds a peer back to a peer list after
+// it was drained. The peer is named by the "peer" parameter.
+func RestorePeer(name string, list peer.List) Action {
+	return Action{
+		Name:        name,
+		Description: "Add a peer to the peer list.",
+		Params:      []string{"peer"},
+		Run: func(_ context.Context, params map[string]string) error {
+			return list.Update(peer.ListUpdates{
+				Additions: []peer.Identifier{hostport.PeerIdentifier(params["peer"])},
+			})
+		},
+	}
+}
+
+// Limiter is implemented by components whose limits may be changed at
+// runtime, such as bulkhead middleware.
+type Limiter interface {
+	// SetLimit changes the limit for the given key.
+	SetLimit(key string, limit int)
+}
+
+// SetLimit builds an action which changes a limit of the given Limiter. The
+// "key" parameter names the limit, such as a procedure, and the "limit"
+// parameter is the new value.
+func SetLimit(name string, limiter Limiter) Action {
+	return Action{
+		Name:        name,
+		Description: "Change a limit.",
+		Params:      []string{"key", "limit"},
+		Run: func(_ context.Context, params map[string]string) error {
+			limit, err := strconv.Atoi(params["limit"])
+			if err != nil {
+				return yarpcerrors.InvalidArgumentErrorf("invalid limit %q: %v", params["limit"], err)
+			}
+			limiter.SetLimit(params["key"], limit)
+			return nil
+		},
+	}
+}
+
+// Weighter is implemented by peer lists whose peer weights may be changed at
+// runtime, such as weighted round-robin lists.
+type Weighter interface {
+	// SetWeight changes the weight of the peer with the given identifier.
+	SetWeight(id string, weight int)
+}
+
+// SetPeerWeight builds an action which changes the weight of a peer in the
+// given list. The "peer" parameter is the peer's identifier and the "weight"
+// parameter is its new weight; a weight of zero drains the peer.
+func SetPeerWeight(name string, list Weighter) Action {
+	return Action{
+		Name:        name,
+		Description: "Change the weight of a peer.",
+		Params:      []string{"peer", "weight"},
+		Run: func(_ context.Context, params map[string]string) error {
+			weight, err := strconv.Atoi(params["weight"])
+			if err != nil {
+				return yarpcerrors.InvalidArgumentErrorf("invalid weight %q: %v", params["weight"], err)
+			}
+			if weight < 0 {
+				return yarpcerrors.InvalidArgumentErrorf("weight must not be negative, got %d", weight)
+			}
+			list.SetWeight(params["peer"], weight)
+			return nil
+		},
+	}
+}
+
+// Switch builds an action which turns something on or off, such as a
+// manually opened circuit or fault injection. The "enabled" parameter is
+// parsed with strconv.ParseBool.
+func Switch(name, description string, set func(ctx context.Context, enabled bool) error) Action {
+	return Action{
+		Name:        name,
+		Description: description,
+		Params:      []string{"enabled"},
+		Run: func(ctx context.Context, params map[string]string) error {
+			enabled, err := strconv.ParseBool(params["enabled"])
+			if err != nil {
+				return yarpcerrors.InvalidArgumentErrorf("invalid value for enabled %q: %v", params["enabled"], err)
+			}
+			return set(ctx, enabled)
+		},
+	}
+}
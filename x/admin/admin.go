@@ -0,0 +1,165 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package admin exposes runtime traffic controls to operators.
+//
+// An Admin holds a set of named actions, such as draining a peer, changing
+// the weight of a peer, adjusting a concurrency limit, or opening a circuit
+// by hand. Actions may be run programmatically with Run, or through the HTTP
+// handler returned by Handler, which serves a debug page with a form for
+// each action.
+//
+// 	a := admin.New(admin.BearerToken(os.Getenv("ADMIN_TOKEN")), admin.Logger(logger))
+// 	a.Register(
+// 		admin.DrainPeer("drain-backend", backendList),
+// 		admin.RestorePeer("restore-backend", backendList),
+// 		admin.SetPeerWeight("weigh-backend", weightedBackendList),
+// 		admin.SetLimit("reports-limit", bulkheadMiddleware),
+// 		admin.Switch("payments-circuit", "Open the payments circuit.", breaker.SetOpen),
+// 	)
+// 	http.Handle("/debug/yarpc/admin", a.Handler())
+//
+// The HTTP handler rejects every request unless an authorization option is
+// given, and runs actions only for requests from the debug page itself or
+// with the RequestHeader set. Every action that is run is logged.
+//
+// This package is experimental. Breaking changes may be made between minor
+// releases.
+package admin
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sort"
+	"sync"
+
+	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/zap"
+)
+
+// Action is a runtime control which operators may run.
+type Action struct {
+	// Name uniquely identifies the action.
+	Name string
+
+	// Description explains what the action does.
+	Description string
+
+	// Params are the names of the parameters the action requires.
+	Params []string
+
+	// Run performs the action with the given parameters. All parameters
+	// listed in Params are present and non-empty.
+	Run func(ctx context.Context, params map[string]string) error
+}
+
+// Admin is a set of actions which may be run at runtime.
+type Admin struct {
+	opts options
+
+	// csrfToken is embedded in the forms of the debug page so that form
+	// submissions from other sites can be told apart from our own.
+	csrfToken string
+
+	mu      sync.RWMutex
+	actions map[string]Action
+}
+
+// New builds a new Admin with no actions.
+func New(opts ...Option) *Admin {
+	return &Admin{
+		opts:      applyOptions(opts...),
+		csrfToken: newCSRFToken(),
+		actions:   make(map[string]Action),
+	}
+}
+
+func newCSRFToken() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic("yarpc/admin: failed to generate CSRF token: " + err.Error())
+	}
+	return hex.EncodeToString(b)
+}
+
+// Register adds actions to the Admin. It fails if an action has no name or
+// the name is already in use.
+func (a *Admin) Register(actions ...Action) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, action := range actions {
+		if action.Name == "" {
+			return yarpcerrors.InvalidArgumentErrorf("admin actions must have a name")
+		}
+		if action.Run == nil {
+			return yarpcerrors.InvalidArgumentErrorf("admin action %q has no Run function", action.Name)
+		}
+		if _, ok := a.actions[action.Name]; ok {
+			return yarpcerrors.Newf(yarpcerrors.CodeAlreadyExists, "admin action %q is already registered", action.Name)
+		}
+		a.actions[action.Name] = action
+	}
+	return nil
+}
+
+// Actions returns the registered actions, sorted by name.
+func (a *Admin) Actions() []Action {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	actions := make([]Action, 0, len(a.actions))
+	for _, action := range a.actions {
+		actions = append(actions, action)
+	}
+	sort.Slice(actions, func(i, j int) bool {
+		return actions[i].Name < actions[j].Name
+	})
+	return actions
+}
+
+// Run runs the named action with the given parameters.
+func (a *Admin) Run(ctx context.Context, name string, params map[string]string) error {
+	a.mu.RLock()
+	action, ok := a.actions[name]
+	a.mu.RUnlock()
+	if !ok {
+		return yarpcerrors.Newf(yarpcerrors.CodeNotFound, "unknown admin action %q", name)
+	}
+
+	args := make(map[string]string, len(action.Params))
+	for _, param := range action.Params {
+		value := params[param]
+		if value == "" {
+			return yarpcerrors.InvalidArgumentErrorf("admin action %q requires parameter %q", name, param)
+		}
+		args[param] = value
+	}
+
+	err := action.Run(ctx, args)
+	fields := []zap.Field{zap.String("action", name), zap.Any("params", args)}
+	if err != nil {
+		a.opts.logger.Warn("Admin action failed.", append(fields, zap.Error(err))...)
+		return err
+	}
+	a.opts.logger.Info("Admin action succeeded.", fields...)
+	return nil
+}
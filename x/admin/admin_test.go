@@ -0,0 +1,178 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package admin
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/peer"
+	"go.uber.org/yarpc/peer/hostport"
+	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type fakeList struct {
+	updates []peer.ListUpdates
+}
+
+func (l *fakeList) Update(updates peer.ListUpdates) error {
+	l.updates = append(l.updates, updates)
+	return nil
+}
+
+type fakeLimiter map[string]int
+
+func (l fakeLimiter) SetLimit(key string, limit int) {
+	l[key] = limit
+}
+
+type fakeWeighter map[string]int
+
+func (w fakeWeighter) SetWeight(id string, weight int) {
+	w[id] = weight
+}
+
+func TestRegister(t *testing.T) {
+	a := New()
+	nop := func(context.Context, map[string]string) error { return nil }
+
+	require.NoError(t, a.Register(Action{Name: "b", Run: nop}, Action{Name: "a", Run: nop}))
+
+	err := a.Register(Action{Name: "a", Run: nop})
+	assert.Equal(t, yarpcerrors.CodeAlreadyExists, yarpcerrors.FromError(err).Code())
+
+	err = a.Register(Action{Run: nop})
+	assert.True(t, yarpcerrors.IsInvalidArgument(err))
+
+	err = a.Register(Action{Name: "c"})
+	assert.True(t, yarpcerrors.IsInvalidArgument(err))
+
+	var names []string
+	for _, action := range a.Actions() {
+		names = append(names, action.Name)
+	}
+	assert.Equal(t, []string{"a", "b"}, names)
+}
+
+func TestRun(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	a := New(Logger(zap.New(core)))
+
+	var got map[string]string
+	require.NoError(t, a.Register(
+		Action{
+			Name:   "echo",
+			Params: []string{"foo"},
+			Run: func(_ context.Context, params map[string]string) error {
+				got = params
+				return nil
+			},
+		},
+		Action{
+			Name: "fail",
+			Run: func(context.Context, map[string]string) error {
+				return errors.New("great sadness")
+			},
+		},
+	))
+
+	require.NoError(t, a.Run(context.Background(), "echo", map[string]string{"foo": "bar", "baz": "qux"}))
+	assert.Equal(t, map[string]string{"foo": "bar"}, got, "only declared parameters are passed")
+
+	err := a.Run(context.Background(), "echo", nil)
+	assert.True(t, yarpcerrors.IsInvalidArgument(err))
+
+	err = a.Run(context.Background(), "unknown", nil)
+	assert.Equal(t, yarpcerrors.CodeNotFound, yarpcerrors.FromError(err).Code())
+
+	assert.EqualError(t, a.Run(context.Background(), "fail", nil), "great sadness")
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 2)
+	assert.Equal(t, zapcore.InfoLevel, entries[0].Level)
+	assert.Equal(t, zapcore.WarnLevel, entries[1].Level)
+}
+
+func TestPeerActions(t *testing.T) {
+	list := &fakeList{}
+	a := New()
+	require.NoError(t, a.Register(DrainPeer("drain", list), RestorePeer("restore", list)))
+
+	params := map[string]string{"peer": "127.0.0.1:8080"}
+	require.NoError(t, a.Run(context.Background(), "drain", params))
+	require.NoError(t, a.Run(context.Background(), "restore", params))
+
+	id := hostport.PeerIdentifier("127.0.0.1:8080")
+	assert.Equal(t, []peer.ListUpdates{
+		{Removals: []peer.Identifier{id}},
+		{Additions: []peer.Identifier{id}},
+	}, list.updates)
+}
+
+func TestSetLimit(t *testing.T) {
+	limiter := make(fakeLimiter)
+	a := New()
+	require.NoError(t, a.Register(SetLimit("limit", limiter)))
+
+	require.NoError(t, a.Run(context.Background(), "limit", map[string]string{"key": "Reports::generate", "limit": "4"}))
+	assert.Equal(t, fakeLimiter{"Reports::generate": 4}, limiter)
+
+	err := a.Run(context.Background(), "limit", map[string]string{"key": "Reports::generate", "limit": "many"})
+	assert.True(t, yarpcerrors.IsInvalidArgument(err))
+}
+
+func TestSetPeerWeight(t *testing.T) {
+	weighter := make(fakeWeighter)
+	a := New()
+	require.NoError(t, a.Register(SetPeerWeight("weight", weighter)))
+
+	require.NoError(t, a.Run(context.Background(), "weight", map[string]string{"peer": "127.0.0.1:8080", "weight": "0"}))
+	assert.Equal(t, fakeWeighter{"127.0.0.1:8080": 0}, weighter)
+
+	err := a.Run(context.Background(), "weight", map[string]string{"peer": "127.0.0.1:8080", "weight": "heavy"})
+	assert.True(t, yarpcerrors.IsInvalidArgument(err))
+
+	err = a.Run(context.Background(), "weight", map[string]string{"peer": "127.0.0.1:8080", "weight": "-1"})
+	assert.True(t, yarpcerrors.IsInvalidArgument(err))
+}
+
+func TestSwitch(t *testing.T) {
+	var enabled bool
+	a := New()
+	require.NoError(t, a.Register(Switch("circuit", "Open the circuit.", func(_ context.Context, on bool) error {
+		enabled = on
+		return nil
+	})))
+
+	require.NoError(t, a.Run(context.Background(), "circuit", map[string]string{"enabled": "true"}))
+	assert.True(t, enabled)
+	require.NoError(t, a.Run(context.Background(), "circuit", map[string]string{"enabled": "false"}))
+	assert.False(t, enabled)
+
+	err := a.Run(context.Background(), "circuit", map[string]string{"enabled": "maybe"})
+	assert.True(t, yarpcerrors.IsInvalidArgument(err))
+}
@@ -0,0 +1,212 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package admin
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/zap"
+)
+
+var _pageTmpl = template.Must(template.New("admin").Parse(`
+<html>
+	<head>
+	<title>/debug/yarpc/admin</title>
+	<style type="text/css">
+		body {
+			font-family: "Courier New", Courier, monospace;
+		}
+		form {
+			border: 1px solid #3A3A3A;
+			padding: 8px;
+			margin-bottom: 8px;
+		}
+		.error {
+			color: #CC0000;
+		}
+	</style>
+	</head>
+	<body>
+	<h1>/debug/yarpc/admin</h1>
+	{{with .Result}}<p>{{.}}</p>{{end}}
+	{{with .Error}}<p class="error">{{.}}</p>{{end}}
+	{{range .Actions}}
+	<form method="POST">
+		<h3>{{.Name}}</h3>
+		<p>{{.Description}}</p>
+		<input type="hidden" name="action" value="{{.Name}}" />
+		<input type="hidden" name="{{$.TokenField}}" value="{{$.Token}}" />
+		{{range .Params}}
+		<label>{{.}} <input type="text" name="{{.}}" /></label>
+		{{end}}
+		<input type="submit" value="Run" />
+	</form>
+	{{end}}
+	</body>
+</html>
+`))
+
+// RequestHeader must be set, with any value, on POST requests to the
+// handler from clients other than its own debug page. Browsers do not send
+// custom headers on cross-site requests unless the server allows it, so
+// requests with this header did not come from a form on another site.
+const RequestHeader = "X-Yarpc-Admin"
+
+// _csrfTokenField is the form field that holds the CSRF token of the Admin
+// in forms on the debug page.
+const _csrfTokenField = "csrf_token"
+
+type pageData struct {
+	Actions    []Action
+	Result     string
+	Error      string
+	Token      string
+	TokenField string
+}
+
+// Handler returns an HTTP handler for the Admin.
+//
+// GET requests render a page with a form for each action. POST requests run
+// the action named by the "action" form value, with the remaining form
+// values as its parameters. Browsers are shown the page again with the
+// outcome; other clients receive a plain text response.
+//
+// To protect against cross-site request forgery, POST requests must either
+// come from a form on the debug page or carry the RequestHeader, and are
+// rejected if their Origin or Referer header names another host.
+func (a *Admin) Handler() http.Handler {
+	return http.HandlerFunc(a.serveHTTP)
+}
+
+func (a *Admin) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if a.opts.authorize == nil {
+		http.Error(w, "admin handler has no authorization configured", http.StatusForbidden)
+		return
+	}
+	if err := a.opts.authorize(r); err != nil {
+		w.Header().Set("WWW-Authenticate", `Basic realm="yarpc admin"`)
+		http.Error(w, yarpcerrors.FromError(err).Message(), http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		a.render(w, http.StatusOK, pageData{})
+	case http.MethodPost:
+		a.runForm(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (a *Admin) runForm(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := a.checkSameSite(r); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	name := r.PostForm.Get("action")
+	params := make(map[string]string, len(r.PostForm))
+	for k := range r.PostForm {
+		params[k] = r.PostForm.Get(k)
+	}
+	delete(params, "action")
+	delete(params, _csrfTokenField)
+
+	var data pageData
+	status := http.StatusOK
+	if err := a.Run(r.Context(), name, params); err != nil {
+		status = toHTTPStatus(err)
+		data.Error = err.Error()
+	} else {
+		data.Result = fmt.Sprintf("Ran %q.", name)
+	}
+
+	if strings.Contains(r.Header.Get("Accept"), "text/html") {
+		a.render(w, status, data)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(status)
+	if data.Error != "" {
+		fmt.Fprintln(w, data.Error)
+	} else {
+		fmt.Fprintln(w, data.Result)
+	}
+}
+
+// checkSameSite returns an error unless the request was sent by the debug
+// page or by a client that set the RequestHeader, and was not sent from
+// another origin. The request form must already be parsed.
+func (a *Admin) checkSameSite(r *http.Request) error {
+	if origin := r.Header.Get("Origin"); origin != "" {
+		if !sameHost(origin, r.Host) {
+			return fmt.Errorf("cross-origin request from %q rejected", origin)
+		}
+	} else if referer := r.Header.Get("Referer"); referer != "" {
+		if !sameHost(referer, r.Host) {
+			return fmt.Errorf("cross-origin request from %q rejected", referer)
+		}
+	}
+
+	if r.Header.Get(RequestHeader) != "" || secureEqual(r.PostForm.Get(_csrfTokenField), a.csrfToken) {
+		return nil
+	}
+	return fmt.Errorf("request must come from the admin page or set the %q header", RequestHeader)
+}
+
+func sameHost(rawURL, host string) bool {
+	u, err := url.Parse(rawURL)
+	return err == nil && u.Host != "" && strings.EqualFold(u.Host, host)
+}
+
+func (a *Admin) render(w http.ResponseWriter, status int, data pageData) {
+	data.Actions = a.Actions()
+	data.Token = a.csrfToken
+	data.TokenField = _csrfTokenField
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if err := _pageTmpl.Execute(w, data); err != nil {
+		a.opts.logger.Error("yarpc/admin: failed executing template", zap.Error(err))
+	}
+}
+
+func toHTTPStatus(err error) int {
+	switch yarpcerrors.FromError(err).Code() {
+	case yarpcerrors.CodeInvalidArgument:
+		return http.StatusBadRequest
+	case yarpcerrors.CodeNotFound:
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package admin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestAdmin(t *testing.T, opts ...Option) (*Admin, *bool) {
+	var enabled bool
+	a := New(opts...)
+	require.NoError(t, a.Register(Switch("faults", "Inject faults.", func(_ context.Context, on bool) error {
+		enabled = on
+		return nil
+	})))
+	return a, &enabled
+}
+
+func postForm(values url.Values) *http.Request {
+	r := httptest.NewRequest("POST", "/", strings.NewReader(values.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Header.Set(RequestHeader, "true")
+	return r
+}
+
+func TestHandlerRequiresAuthorization(t *testing.T) {
+	a, _ := newTestAdmin(t)
+	w := httptest.NewRecorder()
+	a.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestHandlerBearerToken(t *testing.T) {
+	a, _ := newTestAdmin(t, BearerToken("secret"))
+
+	tests := []struct {
+		header string
+		want   int
+	}{
+		{header: "", want: http.StatusUnauthorized},
+		{header: "Bearer wrong", want: http.StatusUnauthorized},
+		{header: "secret", want: http.StatusUnauthorized},
+		{header: "Bearer secret", want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		if tt.header != "" {
+			r.Header.Set("Authorization", tt.header)
+		}
+		w := httptest.NewRecorder()
+		a.Handler().ServeHTTP(w, r)
+		assert.Equal(t, tt.want, w.Code, "Authorization: %q", tt.header)
+	}
+}
+
+func TestHandlerBasicAuth(t *testing.T) {
+	a, _ := newTestAdmin(t, BasicAuth("admin", "secret"))
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.SetBasicAuth("admin", "wrong")
+	w := httptest.NewRecorder()
+	a.Handler().ServeHTTP(w, r)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.NotEmpty(t, w.Header().Get("WWW-Authenticate"))
+
+	r = httptest.NewRequest("GET", "/", nil)
+	r.SetBasicAuth("admin", "secret")
+	w = httptest.NewRecorder()
+	a.Handler().ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `name="enabled"`)
+	assert.Contains(t, w.Body.String(), "Inject faults.")
+}
+
+func TestHandlerRunsActions(t *testing.T) {
+	a, enabled := newTestAdmin(t, Authorize(func(*http.Request) error { return nil }))
+
+	w := httptest.NewRecorder()
+	a.Handler().ServeHTTP(w, postForm(url.Values{"action": {"faults"}, "enabled": {"true"}}))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.True(t, *enabled)
+
+	w = httptest.NewRecorder()
+	a.Handler().ServeHTTP(w, postForm(url.Values{"action": {"faults"}, "enabled": {"maybe"}}))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	a.Handler().ServeHTTP(w, postForm(url.Values{"action": {"unknown"}}))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// Browsers are shown the page with the outcome.
+	r := postForm(url.Values{"action": {"faults"}, "enabled": {"false"}})
+	r.Header.Set("Accept", "text/html,application/xhtml+xml")
+	w = httptest.NewRecorder()
+	a.Handler().ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "Ran &#34;faults&#34;.")
+	assert.False(t, *enabled)
+
+	w = httptest.NewRecorder()
+	a.Handler().ServeHTTP(w, httptest.NewRequest("DELETE", "/", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestHandlerRejectsCrossSiteRequests(t *testing.T) {
+	a, enabled := newTestAdmin(t, Authorize(func(*http.Request) error { return nil }))
+
+	// Forms on the page carry the token of the Admin.
+	w := httptest.NewRecorder()
+	a.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `name="csrf_token" value="`+a.csrfToken+`"`)
+
+	tests := []struct {
+		desc   string
+		token  string
+		header bool
+		origin string
+		ref    string
+		want   int
+	}{
+		{desc: "plain form", want: http.StatusForbidden},
+		{desc: "wrong token", token: "wrong", want: http.StatusForbidden},
+		{desc: "page form", token: a.csrfToken, want: http.StatusOK},
+		{desc: "page form from page", token: a.csrfToken, origin: "http://example.com", want: http.StatusOK},
+		{desc: "header", header: true, want: http.StatusOK},
+		{desc: "cross-origin", token: a.csrfToken, origin: "http://evil.example", want: http.StatusForbidden},
+		{desc: "cross-origin with header", header: true, origin: "http://evil.example", want: http.StatusForbidden},
+		{desc: "opaque origin", token: a.csrfToken, origin: "null", want: http.StatusForbidden},
+		{desc: "cross-site referer", token: a.csrfToken, ref: "http://evil.example/page", want: http.StatusForbidden},
+		{desc: "same-site referer", token: a.csrfToken, ref: "http://example.com/debug", want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			*enabled = false
+			values := url.Values{"action": {"faults"}, "enabled": {"true"}}
+			if tt.token != "" {
+				values.Set("csrf_token", tt.token)
+			}
+			r := httptest.NewRequest("POST", "/", strings.NewReader(values.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tt.header {
+				r.Header.Set(RequestHeader, "true")
+			}
+			if tt.origin != "" {
+				r.Header.Set("Origin", tt.origin)
+			}
+			if tt.ref != "" {
+				r.Header.Set("Referer", tt.ref)
+			}
+
+			w := httptest.NewRecorder()
+			a.Handler().ServeHTTP(w, r)
+			assert.Equal(t, tt.want, w.Code, w.Body.String())
+			assert.Equal(t, tt.want == http.StatusOK, *enabled)
+		})
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package admin
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/zap"
+)
+
+// Option customizes an Admin.
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(opts *options) { f(opts) }
+
+type options struct {
+	logger    *zap.Logger
+	authorize func(*http.Request) error
+}
+
+func applyOptions(opts ...Option) options {
+	options := options{logger: zap.NewNop()}
+	for _, opt := range opts {
+		opt.apply(&options)
+	}
+	return options
+}
+
+// Logger specifies the logger to which every action run is logged.
+//
+// Defaults to a no-op logger.
+func Logger(logger *zap.Logger) Option {
+	return optionFunc(func(opts *options) {
+		opts.logger = logger
+	})
+}
+
+// Authorize specifies a function which decides whether an HTTP request may
+// use the admin handler. Requests for which it returns an error are
+// rejected.
+//
+// The handler rejects every request if no authorization option is given.
+func Authorize(authorize func(*http.Request) error) Option {
+	return optionFunc(func(opts *options) {
+		opts.authorize = authorize
+	})
+}
+
+// BearerToken authorizes HTTP requests with an "Authorization: Bearer"
+// header holding the given token.
+func BearerToken(token string) Option {
+	return Authorize(func(r *http.Request) error {
+		const prefix = "Bearer "
+		header := r.Header.Get("Authorization")
+		if token == "" || !strings.HasPrefix(header, prefix) || !secureEqual(header[len(prefix):], token) {
+			return yarpcerrors.Newf(yarpcerrors.CodeUnauthenticated, "invalid bearer token")
+		}
+		return nil
+	})
+}
+
+// BasicAuth authorizes HTTP requests with HTTP basic authentication using
+// the given credentials. Browsers prompt for these, which makes the debug
+// page usable without other tooling.
+func BasicAuth(username, password string) Option {
+	return Authorize(func(r *http.Request) error {
+		u, p, ok := r.BasicAuth()
+		if password == "" || !ok || !secureEqual(u, username) || !secureEqual(p, password) {
+			return yarpcerrors.Newf(yarpcerrors.CodeUnauthenticated, "invalid credentials")
+		}
+		return nil
+	})
+}
+
+func secureEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
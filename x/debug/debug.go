@@ -21,14 +21,14 @@
 package debug
 
 import (
+	"encoding/json"
 	"html/template"
 	"io"
 	"net/http"
 	"runtime/debug"
 
 	"go.uber.org/yarpc"
-
-	"go.uber.org/yarpc/internal/introspection"
+	"go.uber.org/yarpc/api/introspection"
 	"go.uber.org/zap"
 )
 
@@ -96,6 +96,7 @@ var (
 	<h2>Dispatcher "{{.Name}}" <small>({{.ID}})</small></h2>
 	<table>
 		<tr>
+			<th>Service</th>
 			<th>Procedure</th>
 			<th>Encoding</th>
 			<th>Signature</th>
@@ -103,6 +104,7 @@ var (
 		</tr>
 		{{range .Procedures}}
 		<tr>
+			<td>{{.Service}}</td>
 			<td>{{.Name}}</td>
 			<td>{{.Encoding}}</td>
 			<td>{{.Signature}}</td>
@@ -162,7 +164,7 @@ var (
 			<td>
 				<ul>
 				{{range .Chooser.Peers}}
-					<li>{{.Identifier}} ({{.State}})</li>
+					<li>{{.Identifier}} ({{.State}}){{if .Weight}}, weight {{.Weight}}{{end}}{{with .LastError}}, last error: {{.}}{{end}}</li>
 				{{end}}
 				</ul>
 			</td>
@@ -170,6 +172,99 @@ var (
 		</tbody>
 		{{end}}
 	</table>
+	<h3>Transports</h3>
+	<table>
+		<tr>
+			<th>Type</th>
+			<th>State</th>
+		</tr>
+		{{range .Transports}}
+		<tr>
+			<td>{{.Type}}</td>
+			<td>{{.State}}</td>
+		</tr>
+		{{end}}
+	</table>
+	<h3>Middleware</h3>
+	<table>
+		<tr>
+			<th></th>
+			<th>Unary</th>
+			<th>Oneway</th>
+			<th>Stream</th>
+		</tr>
+		{{with .Middleware.Inbound}}
+		<tr>
+			<th>Inbound</th>
+			<td><ol>{{range .Unary}}<li>{{.}}</li>{{end}}</ol></td>
+			<td><ol>{{range .Oneway}}<li>{{.}}</li>{{end}}</ol></td>
+			<td><ol>{{range .Stream}}<li>{{.}}</li>{{end}}</ol></td>
+		</tr>
+		{{end}}
+		{{with .Middleware.Outbound}}
+		<tr>
+			<th>Outbound</th>
+			<td><ol>{{range .Unary}}<li>{{.}}</li>{{end}}</ol></td>
+			<td><ol>{{range .Oneway}}<li>{{.}}</li>{{end}}</ol></td>
+			<td><ol>{{range .Stream}}<li>{{.}}</li>{{end}}</ol></td>
+		</tr>
+		{{end}}
+	</table>
+	{{with .Metrics}}
+	<h3>Metrics</h3>
+	<table>
+		<tr>
+			<th>Counter</th>
+			<th>Tags</th>
+			<th>Value</th>
+		</tr>
+		{{range .Counters}}
+		<tr>
+			<td>{{.Name}}</td>
+			<td>{{range $k, $v := .Tags}}{{$k}}={{$v}} {{end}}</td>
+			<td>{{.Value}}</td>
+		</tr>
+		{{end}}
+	</table>
+	<br />
+	<table>
+		<tr>
+			<th>Latency</th>
+			<th>Tags</th>
+			<th>Count</th>
+			<th>P50</th>
+			<th>P90</th>
+			<th>P99</th>
+			<th>Max</th>
+		</tr>
+		{{range .Latencies}}
+		<tr>
+			<td>{{.Name}}</td>
+			<td>{{range $k, $v := .Tags}}{{$k}}={{$v}} {{end}}</td>
+			<td>{{.Count}}</td>
+			<td>{{.P50}}{{.Unit}}</td>
+			<td>{{.P90}}{{.Unit}}</td>
+			<td>{{.P99}}{{.Unit}}</td>
+			<td>{{.Max}}{{.Unit}}</td>
+		</tr>
+		{{end}}
+	</table>
+	<br />
+	<table>
+		<tr>
+			<th>Outbound Key</th>
+			<th>RPC Type</th>
+			<th>Peers</th>
+		</tr>
+		{{range .Peers}}
+		<tr>
+			<td>{{.OutboundKey}}</td>
+			<td>{{.RPCType}}</td>
+			<td>{{.Peers}}</td>
+		</tr>
+		{{end}}
+	</table>
+	{{end}}
 {{end}}
 	</body>
 </html>
@@ -177,6 +272,11 @@ var (
 )
 
 // NewHandler returns a http.HandlerFunc to expose dispatcher status and package versions.
+//
+// The status is rendered as an HTML page, or as JSON if the request has the
+// query parameter format=json. Both include the procedures, inbounds,
+// transports, and middleware of the dispatcher, and the status of every peer
+// of each outbound's peer list.
 func NewHandler(dispatcher *yarpc.Dispatcher, opts ...Option) http.HandlerFunc {
 	return newHandler(dispatcher, opts...).handle
 }
@@ -196,15 +296,23 @@ func newHandler(dispatcher *yarpc.Dispatcher, options ...Option) *handler {
 	}
 }
 
-func (h *handler) handle(responseWriter http.ResponseWriter, _ *http.Request) {
+func (h *handler) handle(responseWriter http.ResponseWriter, req *http.Request) {
 	defer func() {
 		if r := recover(); r != nil {
 			responseWriter.WriteHeader(http.StatusInternalServerError)
 			h.logger.Error("Unary handler panicked:", zap.Any("recover", r), zap.ByteString("stacktrace", debug.Stack()))
 		}
 	}()
+	data := newTmplData(h.dispatcher.Introspect())
+	if req != nil && req.URL.Query().Get("format") == "json" {
+		responseWriter.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(responseWriter).Encode(data); err != nil {
+			h.logger.Error("yarpc/debug: failed encoding JSON", zap.Error(err))
+		}
+		return
+	}
 	responseWriter.Header().Set("Content-Type", "text/html; charset=utf-8")
-	if err := h.tmpl.Execute(responseWriter, newTmplData(h.dispatcher.Introspect())); err != nil {
+	if err := h.tmpl.Execute(responseWriter, data); err != nil {
 		// TODO: does this work, since we already tried a write?
 		responseWriter.WriteHeader(http.StatusInternalServerError)
 		h.logger.Error("yarpc/debug: failed executing template", zap.Error(err))
@@ -212,8 +320,8 @@ func (h *handler) handle(responseWriter http.ResponseWriter, _ *http.Request) {
 }
 
 type tmplData struct {
-	Dispatchers     []introspection.DispatcherStatus
-	PackageVersions []introspection.PackageVersion
+	Dispatchers     []introspection.DispatcherStatus `json:"dispatchers"`
+	PackageVersions []introspection.PackageVersion   `json:"packageVersions"`
 }
 
 func newTmplData(dispatcherStatus introspection.DispatcherStatus) *tmplData {
@@ -31,7 +31,9 @@ import (
 
 	"go.uber.org/yarpc"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/introspection"
 	yarpchttp "go.uber.org/yarpc/transport/http"
 )
 
@@ -68,6 +70,20 @@ func TestHandler(t *testing.T) {
 	require.Equal(t, string(expectedData), string(data))
 }
 
+func TestHandlerJSON(t *testing.T) {
+	dispatcher := newTestDispatcher()
+
+	expectedData, err := json.Marshal(newTmplData(dispatcher.Introspect()))
+	require.NoError(t, err)
+
+	responseRecorder := httptest.NewRecorder()
+	NewHandler(dispatcher)(responseRecorder, httptest.NewRequest("GET", "/debug/yarpc?format=json", nil))
+
+	require.Equal(t, http.StatusOK, responseRecorder.Code)
+	require.Equal(t, "application/json", responseRecorder.Header().Get("Content-Type"))
+	require.JSONEq(t, string(expectedData), responseRecorder.Body.String())
+}
+
 func TestHandlerError(t *testing.T) {
 	dispatcher := newTestDispatcher()
 
@@ -91,3 +107,27 @@ func newTestDispatcher() *yarpc.Dispatcher {
 		},
 	})
 }
+
+func TestHandlerHTML(t *testing.T) {
+	dispatcher := newTestDispatcher()
+
+	responseRecorder := httptest.NewRecorder()
+	NewHandler(dispatcher)(responseRecorder, httptest.NewRequest("GET", "/debug/yarpc", nil))
+
+	require.Equal(t, http.StatusOK, responseRecorder.Code)
+	body := responseRecorder.Body.String()
+	assert.Contains(t, body, "<td>*http.Transport</td>", "transports must be rendered")
+	assert.Contains(t, body, "<li>*observability.Middleware</li>", "middleware must be rendered")
+}
+
+func TestIntrospect(t *testing.T) {
+	dispatcher := newTestDispatcher()
+
+	status := dispatcher.Introspect()
+	assert.Equal(t, []introspection.TransportStatus{{Type: "*http.Transport", State: "Stopped"}}, status.Transports)
+	assert.Equal(t, []string{"*observability.Middleware"}, status.Middleware.Inbound.Unary)
+	assert.Equal(t, []string{"*observability.Middleware"}, status.Middleware.Outbound.Oneway)
+	require.Len(t, status.Outbounds, 2)
+	assert.Equal(t, "oneway", status.Outbounds[0].RPCType, "outbounds must be sorted")
+	assert.Equal(t, "unary", status.Outbounds[1].RPCType, "outbounds must be sorted")
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package allowlist provides inbound middleware that only lets callers with
+// certain verified identities call each procedure.
+//
+// The identity of a caller is the CallerIdentity of its requests, which
+// inbound transports derive from the certificate the caller presents over
+// mutually authenticated TLS. Unlike the caller name, which is only asserted
+// by the caller, it cannot be forged.
+//
+// 	tlsConfig := &tls.Config{
+// 		Certificates: []tls.Certificate{cert},
+// 		ClientAuth:   tls.RequireAndVerifyClientCert,
+// 		ClientCAs:    roots,
+// 	}
+// 	al := allowlist.New(
+// 		allowlist.Default("spiffe://example.com/*"),
+// 		allowlist.Procedure("Admin::drain", "spiffe://example.com/ops/console"),
+// 	)
+// 	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+// 		Name: "myservice",
+// 		Inbounds: yarpc.Inbounds{
+// 			grpc.NewTransport().NewInbound(listener, grpc.InboundCredentials(credentials.NewTLS(tlsConfig))),
+// 		},
+// 		InboundMiddleware: yarpc.InboundMiddleware{
+// 			Unary:  al,
+// 			Oneway: al,
+// 		},
+// 	})
+//
+// An identity ending with "*" allows every identity that starts with what
+// precedes it, like all the workloads of a SPIFFE trust domain.
+//
+// Requests from callers without a verified identity are rejected with an
+// Unauthenticated error, and requests from callers that are not allowed are
+// rejected with a PermissionDenied error.
+package allowlist
+
+import (
+	"context"
+	"strings"
+
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+var (
+	_ middleware.UnaryInbound  = (*Middleware)(nil)
+	_ middleware.OnewayInbound = (*Middleware)(nil)
+)
+
+// Middleware is inbound middleware which rejects requests from callers that
+// are not allowed to call the procedure.
+type Middleware struct {
+	opts options
+}
+
+// New builds allowlist middleware.
+func New(opts ...Option) *Middleware {
+	return &Middleware{opts: newOptions(opts)}
+}
+
+// Handle implements middleware.UnaryInbound.
+func (m *Middleware) Handle(ctx context.Context, req *transport.Request, w transport.ResponseWriter, h transport.UnaryHandler) error {
+	if err := m.authorize(req); err != nil {
+		return err
+	}
+	return h.Handle(ctx, req, w)
+}
+
+// HandleOneway implements middleware.OnewayInbound.
+func (m *Middleware) HandleOneway(ctx context.Context, req *transport.Request, h transport.OnewayHandler) error {
+	if err := m.authorize(req); err != nil {
+		return err
+	}
+	return h.HandleOneway(ctx, req)
+}
+
+// authorize returns an error if the caller of the request may not call its
+// procedure.
+func (m *Middleware) authorize(req *transport.Request) error {
+	allowed, ok := m.opts.allowed[req.Procedure]
+	if !ok {
+		if m.opts.defaultAllowed == nil {
+			return nil
+		}
+		allowed = m.opts.defaultAllowed
+	}
+
+	if req.CallerIdentity == "" {
+		return yarpcerrors.Newf(yarpcerrors.CodeUnauthenticated,
+			"procedure %q of service %q requires an authenticated caller", req.Procedure, req.Service)
+	}
+	for _, identity := range allowed {
+		if matches(identity, req.CallerIdentity) {
+			return nil
+		}
+	}
+	return yarpcerrors.Newf(yarpcerrors.CodePermissionDenied,
+		"caller %q may not call procedure %q of service %q", req.CallerIdentity, req.Procedure, req.Service)
+}
+
+// matches returns whether the allowed identity matches the identity of a
+// caller.
+func matches(allowed, identity string) bool {
+	if strings.HasSuffix(allowed, "*") {
+		return strings.HasPrefix(identity, strings.TrimSuffix(allowed, "*"))
+	}
+	return allowed == identity
+}
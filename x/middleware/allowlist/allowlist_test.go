@@ -0,0 +1,118 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package allowlist
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+type nopHandler struct{}
+
+func (nopHandler) Handle(context.Context, *transport.Request, transport.ResponseWriter) error {
+	return nil
+}
+
+func (nopHandler) HandleOneway(context.Context, *transport.Request) error {
+	return nil
+}
+
+func TestAllowlist(t *testing.T) {
+	mw := New(
+		Default("spiffe://example.com/*"),
+		Procedure("Admin::drain", "spiffe://example.com/ops/console"),
+		Procedure("Admin::disabled"),
+	)
+
+	tests := []struct {
+		desc      string
+		procedure string
+		identity  string
+		wantCode  yarpcerrors.Code
+	}{
+		{
+			desc:      "allowed by the procedure",
+			procedure: "Admin::drain",
+			identity:  "spiffe://example.com/ops/console",
+		},
+		{
+			desc:      "not allowed by the procedure",
+			procedure: "Admin::drain",
+			identity:  "spiffe://example.com/frontend",
+			wantCode:  yarpcerrors.CodePermissionDenied,
+		},
+		{
+			desc:      "allowed by default",
+			procedure: "Users::get",
+			identity:  "spiffe://example.com/frontend",
+		},
+		{
+			desc:      "not allowed by default",
+			procedure: "Users::get",
+			identity:  "spiffe://example.org/frontend",
+			wantCode:  yarpcerrors.CodePermissionDenied,
+		},
+		{
+			desc:      "not authenticated",
+			procedure: "Users::get",
+			wantCode:  yarpcerrors.CodeUnauthenticated,
+		},
+		{
+			desc:      "no one allowed",
+			procedure: "Admin::disabled",
+			identity:  "spiffe://example.com/ops/console",
+			wantCode:  yarpcerrors.CodePermissionDenied,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			req := &transport.Request{
+				Service:        "myservice",
+				Procedure:      tt.procedure,
+				CallerIdentity: tt.identity,
+			}
+			errs := []error{
+				mw.Handle(context.Background(), req, &transporttest.FakeResponseWriter{}, nopHandler{}),
+				mw.HandleOneway(context.Background(), req, nopHandler{}),
+			}
+			for _, err := range errs {
+				if tt.wantCode == yarpcerrors.CodeOK {
+					assert.NoError(t, err)
+					continue
+				}
+				assert.Equal(t, tt.wantCode, yarpcerrors.FromError(err).Code())
+			}
+		})
+	}
+}
+
+func TestAllowlistWithoutDefault(t *testing.T) {
+	mw := New(Procedure("Admin::drain", "spiffe://example.com/ops/console"))
+	err := mw.Handle(context.Background(), &transport.Request{Procedure: "Users::get"},
+		&transporttest.FakeResponseWriter{}, nopHandler{})
+	assert.NoError(t, err, "procedures without an allowlist must be open without a default")
+}
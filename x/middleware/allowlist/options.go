@@ -0,0 +1,68 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package allowlist
+
+// Option customizes the behavior of allowlist middleware.
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(opts *options) { f(opts) }
+
+type options struct {
+	defaultAllowed []string
+	allowed        map[string][]string
+}
+
+func newOptions(opts []Option) options {
+	o := options{allowed: make(map[string][]string)}
+	for _, opt := range opts {
+		opt.apply(&o)
+	}
+	return o
+}
+
+// Default allows the given caller identities to call procedures without an
+// allowlist of their own. Calling Default again adds to the identities.
+//
+// Without Default, procedures without an allowlist of their own may be
+// called by anyone, authenticated or not. Default without identities lets
+// no one call them.
+func Default(identities ...string) Option {
+	return optionFunc(func(o *options) {
+		o.defaultAllowed = append(o.defaultAllowed, identities...)
+		if o.defaultAllowed == nil {
+			o.defaultAllowed = []string{}
+		}
+	})
+}
+
+// Procedure allows the given caller identities to call the named procedure,
+// regardless of the Default identities. Calling Procedure again for the same
+// procedure adds to its identities, and Procedure without identities lets no
+// one call the procedure.
+func Procedure(procedure string, identities ...string) Option {
+	return optionFunc(func(o *options) {
+		o.allowed[procedure] = append(o.allowed[procedure], identities...)
+	})
+}
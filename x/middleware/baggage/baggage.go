@@ -0,0 +1,175 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package baggage provides middleware that propagates the baggage of
+// requests, set with yarpc.WithBaggage, to the services they call.
+//
+// Used as outbound middleware, it writes the baggage of the context of each
+// call into its headers. Used as inbound middleware, it reads the baggage of
+// the requests a service handles from their headers, where yarpc.Baggage
+// finds it. A service that does both passes the baggage along to the
+// services it calls on behalf of its requests, so that every service
+// downstream sees it, over every transport.
+//
+// 	bg := baggage.New(baggage.Keys("tenant", "experiment"))
+// 	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+// 		Name: "myservice",
+// 		InboundMiddleware: yarpc.InboundMiddleware{
+// 			Unary:  bg,
+// 			Oneway: bg,
+// 		},
+// 		OutboundMiddleware: yarpc.OutboundMiddleware{
+// 			Unary:  bg,
+// 			Oneway: bg,
+// 		},
+// 	})
+//
+// Since every request carries the baggage, it is bounded: only the items
+// with the given keys, if any, are propagated, up to a number of items and
+// bytes.
+package baggage
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/baggage"
+)
+
+var (
+	_ middleware.UnaryInbound   = (*Middleware)(nil)
+	_ middleware.OnewayInbound  = (*Middleware)(nil)
+	_ middleware.UnaryOutbound  = (*Middleware)(nil)
+	_ middleware.OnewayOutbound = (*Middleware)(nil)
+)
+
+// HeaderPrefix is the prefix of the headers that carry baggage items. The
+// item with the key "tenant" is carried by the header "baggage-tenant".
+const HeaderPrefix = "baggage-"
+
+// Middleware propagates baggage across services.
+type Middleware struct {
+	opts options
+}
+
+// New builds baggage middleware.
+func New(opts ...Option) *Middleware {
+	return &Middleware{opts: newOptions(opts)}
+}
+
+// Handle implements middleware.UnaryInbound.
+func (m *Middleware) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter, h transport.UnaryHandler) error {
+	return h.Handle(m.extract(ctx, req), req, resw)
+}
+
+// HandleOneway implements middleware.OnewayInbound.
+func (m *Middleware) HandleOneway(ctx context.Context, req *transport.Request, h transport.OnewayHandler) error {
+	return h.HandleOneway(m.extract(ctx, req), req)
+}
+
+// Call implements middleware.UnaryOutbound.
+func (m *Middleware) Call(ctx context.Context, req *transport.Request, out transport.UnaryOutbound) (*transport.Response, error) {
+	return out.Call(ctx, m.inject(ctx, req))
+}
+
+// CallOneway implements middleware.OnewayOutbound.
+func (m *Middleware) CallOneway(ctx context.Context, req *transport.Request, out transport.OnewayOutbound) (transport.Ack, error) {
+	return out.CallOneway(ctx, m.inject(ctx, req))
+}
+
+// extract returns a copy of the context with the baggage in the headers of
+// the request added to its baggage.
+func (m *Middleware) extract(ctx context.Context, req *transport.Request) context.Context {
+	var items map[string]string
+	for k, v := range req.Headers.Items() {
+		if !strings.HasPrefix(k, HeaderPrefix) || len(k) == len(HeaderPrefix) {
+			continue
+		}
+		if items == nil {
+			items = make(map[string]string)
+		}
+		items[k[len(HeaderPrefix):]] = v
+	}
+	items = m.bound(items)
+	if len(items) == 0 {
+		return ctx
+	}
+
+	for k, v := range baggage.FromContext(ctx) {
+		if _, ok := items[k]; !ok {
+			items[k] = v
+		}
+	}
+	return baggage.WithItems(ctx, items)
+}
+
+// inject returns a copy of the request with the baggage of the context in
+// its headers, or the request itself if there is none.
+func (m *Middleware) inject(ctx context.Context, req *transport.Request) *transport.Request {
+	items := m.bound(baggage.FromContext(ctx))
+	if len(items) == 0 {
+		return req
+	}
+
+	headers := transport.NewHeadersWithCapacity(req.Headers.Len() + len(items))
+	for k, v := range req.Headers.Items() {
+		headers = headers.With(k, v)
+	}
+	for k, v := range items {
+		headers = headers.With(HeaderPrefix+k, v)
+	}
+	r := *req
+	r.Headers = headers
+	return &r
+}
+
+// bound returns the items that may be propagated, within the limits.
+func (m *Middleware) bound(items map[string]string) map[string]string {
+	keys := make([]string, 0, len(items))
+	for k := range items {
+		if m.opts.keys == nil || m.opts.keys[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var (
+		bounded map[string]string
+		size    int
+	)
+	for _, k := range keys {
+		if len(bounded) >= m.opts.maxItems {
+			break
+		}
+		v := items[k]
+		if size+len(k)+len(v) > m.opts.maxSize {
+			continue
+		}
+		if bounded == nil {
+			bounded = make(map[string]string, len(keys))
+		}
+		bounded[k] = v
+		size += len(k) + len(v)
+	}
+	return bounded
+}
@@ -0,0 +1,147 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package baggage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/internal/baggage"
+)
+
+// handlerFunc handles requests with a function.
+type handlerFunc func(context.Context, *transport.Request, transport.ResponseWriter) error
+
+func (f handlerFunc) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter) error {
+	return f(ctx, req, resw)
+}
+
+// recordingOutbound records the requests it is called with.
+type recordingOutbound struct {
+	transport.Outbound
+
+	req *transport.Request
+}
+
+func (o *recordingOutbound) Call(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	o.req = req
+	return &transport.Response{}, nil
+}
+
+func (o *recordingOutbound) CallOneway(ctx context.Context, req *transport.Request) (transport.Ack, error) {
+	o.req = req
+	return nil, nil
+}
+
+func TestPropagation(t *testing.T) {
+	mw := New()
+	out := &recordingOutbound{}
+
+	req := &transport.Request{
+		Procedure: "Users::get",
+		Headers: transport.NewHeaders().
+			With("Baggage-Tenant", "acme").
+			With("baggage-", "ignored").
+			With("other", "header"),
+	}
+	err := mw.Handle(context.Background(), req, &transporttest.FakeResponseWriter{}, handlerFunc(
+		func(ctx context.Context, _ *transport.Request, _ transport.ResponseWriter) error {
+			assert.Equal(t, map[string]string{"tenant": "acme"}, baggage.FromContext(ctx))
+
+			ctx = baggage.With(ctx, "experiment", "blue")
+			_, err := mw.Call(ctx, &transport.Request{
+				Procedure: "get",
+				Headers:   transport.NewHeaders().With("key", "value"),
+			}, out)
+			return err
+		}))
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{
+		"key":                "value",
+		"baggage-tenant":     "acme",
+		"baggage-experiment": "blue",
+	}, out.req.Headers.Items(), "baggage must be passed along to downstream services")
+}
+
+func TestNoBaggage(t *testing.T) {
+	mw := New()
+	out := &recordingOutbound{}
+
+	req := &transport.Request{Procedure: "Users::notify"}
+	_, err := mw.CallOneway(context.Background(), req, out)
+	require.NoError(t, err)
+	assert.True(t, req == out.req, "requests without baggage must be left alone")
+}
+
+func TestBounds(t *testing.T) {
+	items := map[string]string{
+		"a-tenant":     "acme",
+		"b-experiment": "blue",
+		"c-locale":     "en-US",
+		"d":            "x",
+	}
+
+	tests := []struct {
+		desc string
+		opts []Option
+		want map[string]string
+	}{
+		{
+			desc: "defaults",
+			want: items,
+		},
+		{
+			desc: "keys",
+			opts: []Option{Keys("A-Tenant", "c-locale", "unset")},
+			want: map[string]string{"a-tenant": "acme", "c-locale": "en-US"},
+		},
+		{
+			desc: "max items",
+			opts: []Option{MaxItems(2)},
+			want: map[string]string{"a-tenant": "acme", "b-experiment": "blue"},
+		},
+		{
+			desc: "max size",
+			opts: []Option{MaxSize(30)},
+			want: map[string]string{"a-tenant": "acme", "b-experiment": "blue", "d": "x"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			out := &recordingOutbound{}
+			ctx := baggage.WithItems(context.Background(), items)
+			_, err := New(tt.opts...).Call(ctx, &transport.Request{Procedure: "get"}, out)
+			require.NoError(t, err)
+
+			got := make(map[string]string)
+			for k, v := range out.req.Headers.Items() {
+				got[k[len(HeaderPrefix):]] = v
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
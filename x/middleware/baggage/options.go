@@ -0,0 +1,86 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package baggage
+
+import "go.uber.org/yarpc/api/transport"
+
+// Option customizes the behavior of baggage middleware.
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(opts *options) { f(opts) }
+
+type options struct {
+	keys     map[string]bool
+	maxItems int
+	maxSize  int
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		maxItems: 16,
+		maxSize:  4096,
+	}
+	for _, opt := range opts {
+		opt.apply(&o)
+	}
+	return o
+}
+
+// Keys limits the baggage that is propagated to the items with the given
+// keys. Keys are case-insensitive.
+//
+// By default, every item is propagated, within the limits of MaxItems and
+// MaxSize.
+func Keys(keys ...string) Option {
+	return optionFunc(func(o *options) {
+		if o.keys == nil {
+			o.keys = make(map[string]bool, len(keys))
+		}
+		for _, k := range keys {
+			o.keys[transport.CanonicalizeHeaderKey(k)] = true
+		}
+	})
+}
+
+// MaxItems is the most baggage items propagated with a request. Items past
+// the limit, in the order of their keys, are dropped.
+//
+// Defaults to 16.
+func MaxItems(n int) Option {
+	return optionFunc(func(o *options) {
+		o.maxItems = n
+	})
+}
+
+// MaxSize is the most bytes of keys and values of baggage propagated with a
+// request. Items that would exceed the limit, in the order of their keys,
+// are dropped.
+//
+// Defaults to 4096.
+func MaxSize(n int) Option {
+	return optionFunc(func(o *options) {
+		o.maxSize = n
+	})
+}
@@ -0,0 +1,205 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package bulkhead provides inbound middleware that isolates the handler
+// concurrency of each procedure, so that a single slow procedure cannot
+// consume every handler goroutine and starve the other procedures of a
+// service.
+//
+// 	bh := bulkhead.New(
+// 		bulkhead.DefaultLimit(64),
+// 		bulkhead.ProcedureLimit("Reports::generate", 4),
+// 	)
+// 	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+// 		Name: "myservice",
+// 		InboundMiddleware: yarpc.InboundMiddleware{
+// 			Unary:  bh,
+// 			Oneway: bh,
+// 		},
+// 	})
+//
+// Requests that find their bulkhead full are rejected with a
+// ResourceExhausted error.
+package bulkhead
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/net/metrics"
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+const _procedureTag = "procedure"
+
+var (
+	_ middleware.UnaryInbound  = (*Middleware)(nil)
+	_ middleware.OnewayInbound = (*Middleware)(nil)
+)
+
+// Middleware is inbound middleware which bounds the number of concurrent
+// requests to each procedure.
+type Middleware struct {
+	opts options
+
+	mu        sync.RWMutex
+	bulkheads map[string]*bulkhead
+
+	inFlight   *metrics.GaugeVector
+	rejections *metrics.CounterVector
+}
+
+// New builds bulkhead middleware.
+func New(opts ...Option) *Middleware {
+	o := newOptions(opts)
+	// Errors are ignored because these metrics are unique to this
+	// middleware; at worst, they are not recorded.
+	inFlight, _ := o.meter.GaugeVector(metrics.Spec{
+		Name:    "bulkhead_in_flight",
+		Help:    "Number of requests being handled within each bulkhead.",
+		VarTags: []string{_procedureTag},
+	})
+	rejections, _ := o.meter.CounterVector(metrics.Spec{
+		Name:    "bulkhead_rejections",
+		Help:    "Number of requests rejected because their bulkhead was full.",
+		VarTags: []string{_procedureTag},
+	})
+	return &Middleware{
+		opts:       o,
+		bulkheads:  make(map[string]*bulkhead),
+		inFlight:   inFlight,
+		rejections: rejections,
+	}
+}
+
+// Handle implements middleware.UnaryInbound.
+func (m *Middleware) Handle(ctx context.Context, req *transport.Request, w transport.ResponseWriter, h transport.UnaryHandler) error {
+	b := m.bulkhead(req.Procedure)
+	if b == nil {
+		return h.Handle(ctx, req, w)
+	}
+	if err := b.acquire(ctx, m.opts.queueTimeout); err != nil {
+		return err
+	}
+	defer b.release()
+	return h.Handle(ctx, req, w)
+}
+
+// HandleOneway implements middleware.OnewayInbound.
+func (m *Middleware) HandleOneway(ctx context.Context, req *transport.Request, h transport.OnewayHandler) error {
+	b := m.bulkhead(req.Procedure)
+	if b == nil {
+		return h.HandleOneway(ctx, req)
+	}
+	if err := b.acquire(ctx, m.opts.queueTimeout); err != nil {
+		return err
+	}
+	defer b.release()
+	return h.HandleOneway(ctx, req)
+}
+
+// SetLimit changes the number of concurrent requests allowed to the named
+// procedure at runtime. A limit of zero or less leaves the procedure
+// unbounded.
+//
+// Requests already in flight count against the limit they were admitted
+// under.
+func (m *Middleware) SetLimit(procedure string, n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.opts.limits[procedure] = n
+	// The bulkhead is rebuilt with the new limit on the next request.
+	delete(m.bulkheads, procedure)
+}
+
+// bulkhead returns the bulkhead for the given procedure, or nil if the
+// procedure is unbounded.
+func (m *Middleware) bulkhead(procedure string) *bulkhead {
+	m.mu.RLock()
+	b, ok := m.bulkheads[procedure]
+	m.mu.RUnlock()
+	if ok {
+		return b
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if b, ok := m.bulkheads[procedure]; ok {
+		return b
+	}
+
+	limit, ok := m.opts.limits[procedure]
+	if !ok {
+		limit = m.opts.defaultLimit
+	}
+	if limit > 0 {
+		b = &bulkhead{
+			procedure: procedure,
+			slots:     make(chan struct{}, limit),
+			inFlight:  m.inFlight.MustGet(_procedureTag, procedure),
+			rejected:  m.rejections.MustGet(_procedureTag, procedure),
+		}
+	}
+	// Unbounded procedures are cached as nil.
+	m.bulkheads[procedure] = b
+	return b
+}
+
+type bulkhead struct {
+	procedure string
+	slots     chan struct{}
+
+	inFlight *metrics.Gauge
+	rejected *metrics.Counter
+}
+
+func (b *bulkhead) acquire(ctx context.Context, queueTimeout time.Duration) error {
+	select {
+	case b.slots <- struct{}{}:
+		b.inFlight.Inc()
+		return nil
+	default:
+	}
+
+	if queueTimeout > 0 {
+		timer := time.NewTimer(queueTimeout)
+		defer timer.Stop()
+		select {
+		case b.slots <- struct{}{}:
+			b.inFlight.Inc()
+			return nil
+		case <-timer.C:
+		case <-ctx.Done():
+		}
+	}
+
+	b.rejected.Inc()
+	return yarpcerrors.Newf(yarpcerrors.CodeResourceExhausted,
+		"bulkhead for procedure %q is full: %d requests in flight", b.procedure, cap(b.slots))
+}
+
+func (b *bulkhead) release() {
+	b.inFlight.Dec()
+	<-b.slots
+}
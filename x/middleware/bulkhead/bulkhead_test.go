@@ -0,0 +1,202 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package bulkhead
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/net/metrics"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// blockingHandler blocks until released, signalling when it has started.
+type blockingHandler struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func newBlockingHandler() *blockingHandler {
+	return &blockingHandler{
+		started: make(chan struct{}, 10),
+		release: make(chan struct{}),
+	}
+}
+
+func (h *blockingHandler) Handle(context.Context, *transport.Request, transport.ResponseWriter) error {
+	h.started <- struct{}{}
+	<-h.release
+	return nil
+}
+
+func (h *blockingHandler) HandleOneway(context.Context, *transport.Request) error {
+	h.started <- struct{}{}
+	<-h.release
+	return nil
+}
+
+type nopHandler struct{}
+
+func (nopHandler) Handle(context.Context, *transport.Request, transport.ResponseWriter) error {
+	return nil
+}
+
+func TestBulkheadIsolatesProcedures(t *testing.T) {
+	root := metrics.New()
+	mw := New(DefaultLimit(1), Meter(root.Scope()))
+	ctx := context.Background()
+
+	slow := newBlockingHandler()
+	done := make(chan error)
+	go func() {
+		done <- mw.Handle(ctx, &transport.Request{Procedure: "slow"}, &transporttest.FakeResponseWriter{}, slow)
+	}()
+	<-slow.started
+
+	// The slow procedure's bulkhead is full.
+	err := mw.Handle(ctx, &transport.Request{Procedure: "slow"}, &transporttest.FakeResponseWriter{}, nopHandler{})
+	assert.Equal(t, yarpcerrors.CodeResourceExhausted, yarpcerrors.FromError(err).Code())
+
+	// Other procedures are unaffected.
+	assert.NoError(t, mw.Handle(ctx, &transport.Request{Procedure: "fast"}, &transporttest.FakeResponseWriter{}, nopHandler{}))
+
+	close(slow.release)
+	require.NoError(t, <-done)
+
+	// The slot has been released.
+	assert.NoError(t, mw.Handle(ctx, &transport.Request{Procedure: "slow"}, &transporttest.FakeResponseWriter{}, nopHandler{}))
+
+	rejections := make(map[string]int64)
+	for _, c := range root.Snapshot().Counters {
+		assert.Equal(t, "bulkhead_rejections", c.Name)
+		rejections[c.Tags["procedure"]] = c.Value
+	}
+	assert.Equal(t, map[string]int64{"slow": 1, "fast": 0}, rejections)
+}
+
+func TestBulkheadProcedureLimits(t *testing.T) {
+	mw := New(DefaultLimit(1), ProcedureLimit("unbounded", 0), ProcedureLimit("wide", 2))
+	ctx := context.Background()
+
+	for _, tt := range []struct {
+		procedure string
+		accepted  int
+	}{
+		{procedure: "unbounded", accepted: 5},
+		{procedure: "wide", accepted: 2},
+		{procedure: "narrow", accepted: 1},
+	} {
+		t.Run(tt.procedure, func(t *testing.T) {
+			h := newBlockingHandler()
+			results := make(chan error, 5)
+			for i := 0; i < 5; i++ {
+				go func() {
+					results <- mw.HandleOneway(ctx, &transport.Request{Procedure: tt.procedure}, h)
+				}()
+			}
+
+			var rejected int
+			for i := 0; i < 5-tt.accepted; i++ {
+				err := <-results
+				assert.Equal(t, yarpcerrors.CodeResourceExhausted, yarpcerrors.FromError(err).Code())
+				rejected++
+			}
+			for i := 0; i < tt.accepted; i++ {
+				<-h.started
+			}
+			close(h.release)
+			for i := 0; i < tt.accepted; i++ {
+				assert.NoError(t, <-results)
+			}
+			assert.Equal(t, 5-tt.accepted, rejected)
+		})
+	}
+}
+
+func TestBulkheadQueueTimeout(t *testing.T) {
+	mw := New(DefaultLimit(1), QueueTimeout(time.Second))
+	ctx := context.Background()
+
+	h := newBlockingHandler()
+	first := make(chan error)
+	go func() {
+		first <- mw.Handle(ctx, &transport.Request{Procedure: "proc"}, &transporttest.FakeResponseWriter{}, h)
+	}()
+	<-h.started
+
+	second := make(chan error)
+	go func() {
+		second <- mw.Handle(ctx, &transport.Request{Procedure: "proc"}, &transporttest.FakeResponseWriter{}, nopHandler{})
+	}()
+
+	// The second request waits for the first to finish rather than being
+	// rejected.
+	close(h.release)
+	assert.NoError(t, <-first)
+	assert.NoError(t, <-second)
+
+	t.Run("context deadline", func(t *testing.T) {
+		h := newBlockingHandler()
+		go mw.Handle(ctx, &transport.Request{Procedure: "proc"}, &transporttest.FakeResponseWriter{}, h)
+		<-h.started
+		defer close(h.release)
+
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		err := mw.Handle(ctx, &transport.Request{Procedure: "proc"}, &transporttest.FakeResponseWriter{}, nopHandler{})
+		assert.Equal(t, yarpcerrors.CodeResourceExhausted, yarpcerrors.FromError(err).Code())
+	})
+}
+
+func TestBulkheadSetLimit(t *testing.T) {
+	mw := New(DefaultLimit(1))
+	ctx := context.Background()
+
+	slow := newBlockingHandler()
+	done := make(chan error, 2)
+	go func() {
+		done <- mw.Handle(ctx, &transport.Request{Procedure: "slow"}, &transporttest.FakeResponseWriter{}, slow)
+	}()
+	<-slow.started
+
+	err := mw.Handle(ctx, &transport.Request{Procedure: "slow"}, &transporttest.FakeResponseWriter{}, nopHandler{})
+	assert.Equal(t, yarpcerrors.CodeResourceExhausted, yarpcerrors.FromError(err).Code())
+
+	// Raising the limit admits more requests immediately.
+	mw.SetLimit("slow", 2)
+	go func() {
+		done <- mw.Handle(ctx, &transport.Request{Procedure: "slow"}, &transporttest.FakeResponseWriter{}, slow)
+	}()
+	<-slow.started
+
+	// Removing the limit leaves the procedure unbounded.
+	mw.SetLimit("slow", 0)
+	assert.NoError(t, mw.Handle(ctx, &transport.Request{Procedure: "slow"}, &transporttest.FakeResponseWriter{}, nopHandler{}))
+
+	close(slow.release)
+	require.NoError(t, <-done)
+	require.NoError(t, <-done)
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package bulkhead
+
+import (
+	"time"
+
+	"go.uber.org/net/metrics"
+)
+
+// Option customizes the behavior of bulkhead middleware.
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(opts *options) { f(opts) }
+
+type options struct {
+	defaultLimit int
+	limits       map[string]int
+	queueTimeout time.Duration
+	meter        *metrics.Scope
+}
+
+func newOptions(opts []Option) options {
+	o := options{limits: make(map[string]int)}
+	for _, opt := range opts {
+		opt.apply(&o)
+	}
+	return o
+}
+
+// DefaultLimit bounds the number of concurrent requests to each procedure
+// without an explicit ProcedureLimit. Every such procedure gets a bulkhead
+// of its own with this capacity.
+//
+// Defaults to zero, which leaves those procedures unbounded.
+func DefaultLimit(n int) Option {
+	return optionFunc(func(o *options) {
+		o.defaultLimit = n
+	})
+}
+
+// ProcedureLimit bounds the number of concurrent requests to the named
+// procedure. A limit of zero or less leaves the procedure unbounded,
+// regardless of the DefaultLimit.
+func ProcedureLimit(procedure string, n int) Option {
+	return optionFunc(func(o *options) {
+		o.limits[procedure] = n
+	})
+}
+
+// QueueTimeout allows requests to wait up to the given duration for a slot
+// in a full bulkhead before they are rejected. Requests never wait past
+// their own deadline.
+//
+// Defaults to zero, which rejects requests to a full bulkhead immediately.
+func QueueTimeout(d time.Duration) Option {
+	return optionFunc(func(o *options) {
+		o.queueTimeout = d
+	})
+}
+
+// Meter records the number of in-flight and rejected requests of each
+// bulkhead in the given scope.
+func Meter(meter *metrics.Scope) Option {
+	return optionFunc(func(o *options) {
+		o.meter = meter
+	})
+}
@@ -0,0 +1,350 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package compression provides middleware that compresses the bodies of
+// requests and responses, for transports that do not compress them
+// natively. It works with any encoding.
+//
+// 	comp := compression.New(compression.MinSize(4096))
+// 	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+// 		Name: "myservice",
+// 		InboundMiddleware: yarpc.InboundMiddleware{
+// 			Unary:  comp,
+// 			Oneway: comp,
+// 		},
+// 		OutboundMiddleware: yarpc.OutboundMiddleware{
+// 			Unary:  comp,
+// 			Oneway: comp,
+// 		},
+// 	})
+//
+// Callers and services negotiate an algorithm with application headers.
+// Callers list the algorithms they accept in the AcceptHeader of their
+// requests, and services list theirs in the AcceptHeader of their
+// responses. Bodies compressed with an algorithm carry its name in the
+// CompressionHeader.
+//
+// Services compress responses with the first of their algorithms that the
+// caller accepts. Callers only compress requests to a service once one of
+// its responses has shown which algorithms it accepts, so callers with this
+// middleware can call services without it, and the other way around.
+// Bodies smaller than the MinSize are never compressed.
+package compression
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+const (
+	// AcceptHeader is the application header in which callers and services
+	// list the names of the algorithms they accept, separated by commas.
+	AcceptHeader = "accept-compression"
+
+	// CompressionHeader is the application header that carries the name of
+	// the algorithm a body is compressed with.
+	CompressionHeader = "compression"
+)
+
+var (
+	_ middleware.UnaryInbound   = (*Middleware)(nil)
+	_ middleware.OnewayInbound  = (*Middleware)(nil)
+	_ middleware.UnaryOutbound  = (*Middleware)(nil)
+	_ middleware.OnewayOutbound = (*Middleware)(nil)
+)
+
+// Middleware is middleware which compresses the bodies of requests and
+// responses.
+type Middleware struct {
+	opts   options
+	accept string
+
+	mu       sync.RWMutex
+	services map[string]Compressor
+}
+
+// New builds compression middleware.
+func New(opts ...Option) *Middleware {
+	o := newOptions(opts)
+	names := make([]string, len(o.compressors))
+	for i, c := range o.compressors {
+		names[i] = c.Name()
+	}
+	return &Middleware{
+		opts:     o,
+		accept:   strings.Join(names, ","),
+		services: make(map[string]Compressor),
+	}
+}
+
+// Handle implements middleware.UnaryInbound.
+func (m *Middleware) Handle(ctx context.Context, req *transport.Request, w transport.ResponseWriter, h transport.UnaryHandler) error {
+	decompressed, err := m.decompressRequest(req)
+	if err != nil {
+		return err
+	}
+	w.AddHeaders(transport.NewHeaders().With(AcceptHeader, m.accept))
+
+	accepted, _ := req.Headers.Get(AcceptHeader)
+	c := m.choose(accepted)
+	if c == nil {
+		return h.Handle(ctx, decompressed, w)
+	}
+
+	// The response is buffered to find out whether it is worth compressing
+	// before its headers are sent.
+	rw := &responseWriter{ResponseWriter: w}
+	err = h.Handle(ctx, decompressed, rw)
+	if err != nil || rw.body.Len() < m.opts.minSize {
+		if _, writeErr := w.Write(rw.body.Bytes()); err == nil {
+			err = writeErr
+		}
+		return err
+	}
+
+	compressed, err := compress(c, rw.body.Bytes())
+	if err != nil {
+		return err
+	}
+	w.AddHeaders(transport.NewHeaders().With(CompressionHeader, c.Name()))
+	_, err = w.Write(compressed)
+	return err
+}
+
+// HandleOneway implements middleware.OnewayInbound.
+func (m *Middleware) HandleOneway(ctx context.Context, req *transport.Request, h transport.OnewayHandler) error {
+	decompressed, err := m.decompressRequest(req)
+	if err != nil {
+		return err
+	}
+	return h.HandleOneway(ctx, decompressed)
+}
+
+// Call implements middleware.UnaryOutbound.
+func (m *Middleware) Call(ctx context.Context, req *transport.Request, out transport.UnaryOutbound) (*transport.Response, error) {
+	compressed, err := m.compressRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	res, err := out.Call(ctx, compressed)
+	if err != nil {
+		return nil, err
+	}
+
+	accepted, _ := res.Headers.Get(AcceptHeader)
+	m.learn(req.Service, accepted)
+
+	name, ok := res.Headers.Get(CompressionHeader)
+	if !ok || res.Body == nil {
+		res.Headers = without(res.Headers, AcceptHeader, CompressionHeader)
+		return res, nil
+	}
+	c := m.compressor(name)
+	if c == nil {
+		res.Body.Close()
+		return nil, yarpcerrors.Newf(yarpcerrors.CodeInternal,
+			"response from service %q is compressed with unknown algorithm %q", req.Service, name)
+	}
+	body, err := decompress(c, res.Body)
+	if closeErr := res.Body.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, yarpcerrors.Newf(yarpcerrors.CodeInternal,
+			"failed to decompress response from service %q: %v", req.Service, err)
+	}
+	res.Headers = without(res.Headers, AcceptHeader, CompressionHeader)
+	res.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return res, nil
+}
+
+// CallOneway implements middleware.OnewayOutbound.
+func (m *Middleware) CallOneway(ctx context.Context, req *transport.Request, out transport.OnewayOutbound) (transport.Ack, error) {
+	compressed, err := m.compressRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	return out.CallOneway(ctx, compressed)
+}
+
+// compressRequest returns a copy of the request that lists the algorithms
+// the caller accepts, with its body compressed if the service is known to
+// accept one of them and the body is large enough.
+func (m *Middleware) compressRequest(req *transport.Request) (*transport.Request, error) {
+	// The headers are copied since the caller still owns them.
+	r := *req
+	r.Headers = without(req.Headers).With(AcceptHeader, m.accept)
+
+	m.mu.RLock()
+	c := m.services[req.Service]
+	m.mu.RUnlock()
+	if c == nil || req.Body == nil {
+		return &r, nil
+	}
+
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	r.Body = bytes.NewReader(body)
+	if len(body) < m.opts.minSize {
+		return &r, nil
+	}
+
+	compressed, err := compress(c, body)
+	if err != nil {
+		return nil, err
+	}
+	r.Body = bytes.NewReader(compressed)
+	r.Headers = r.Headers.With(CompressionHeader, c.Name())
+	return &r, nil
+}
+
+// decompressRequest returns a copy of the request with its body
+// decompressed, if it is compressed.
+func (m *Middleware) decompressRequest(req *transport.Request) (*transport.Request, error) {
+	name, ok := req.Headers.Get(CompressionHeader)
+	if !ok {
+		return req, nil
+	}
+	c := m.compressor(name)
+	if c == nil {
+		return nil, yarpcerrors.Newf(yarpcerrors.CodeInvalidArgument,
+			"request to procedure %q of service %q is compressed with unsupported algorithm %q",
+			req.Procedure, req.Service, name)
+	}
+	body, err := decompress(c, req.Body)
+	if err != nil {
+		return nil, yarpcerrors.Newf(yarpcerrors.CodeInvalidArgument,
+			"failed to decompress request to procedure %q of service %q: %v",
+			req.Procedure, req.Service, err)
+	}
+
+	r := *req
+	r.Headers = without(req.Headers, CompressionHeader)
+	r.Body = bytes.NewReader(body)
+	return &r, nil
+}
+
+// learn records which algorithm to compress requests to the service with,
+// given the algorithms it accepts.
+func (m *Middleware) learn(service, accepted string) {
+	c := m.choose(accepted)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if c == nil {
+		delete(m.services, service)
+		return
+	}
+	m.services[service] = c
+}
+
+// choose returns the first compressor whose algorithm is in the given list
+// of accepted algorithms, or nil if there is none.
+func (m *Middleware) choose(accepted string) Compressor {
+	if accepted == "" {
+		return nil
+	}
+	names := strings.Split(accepted, ",")
+	for _, c := range m.opts.compressors {
+		for _, name := range names {
+			if strings.TrimSpace(name) == c.Name() {
+				return c
+			}
+		}
+	}
+	return nil
+}
+
+// compressor returns the compressor for the named algorithm, or nil if
+// there is none.
+func (m *Middleware) compressor(name string) Compressor {
+	for _, c := range m.opts.compressors {
+		if c.Name() == name {
+			return c
+		}
+	}
+	return nil
+}
+
+func compress(c Compressor, body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := c.Compress(&buf)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(body); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decompress(c Compressor, body io.Reader) ([]byte, error) {
+	if body == nil {
+		body = bytes.NewReader(nil)
+	}
+	r, err := c.Decompress(body)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+// without returns a copy of the headers without the given headers.
+func without(headers transport.Headers, keys ...string) transport.Headers {
+	copied := transport.NewHeadersWithCapacity(headers.Len())
+	for k, v := range headers.Items() {
+		copied = copied.With(k, v)
+	}
+	for _, k := range keys {
+		copied.Del(k)
+	}
+	return copied
+}
+
+// responseWriter buffers the body of the response.
+type responseWriter struct {
+	transport.ResponseWriter
+
+	body bytes.Buffer
+}
+
+func (w *responseWriter) SetApplicationErrorMeta(meta *transport.ApplicationErrorMeta) {
+	if setter, ok := w.ResponseWriter.(transport.ApplicationErrorMetaSetter); ok {
+		setter.SetApplicationErrorMeta(meta)
+	}
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	return w.body.Write(p)
+}
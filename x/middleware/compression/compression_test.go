@@ -0,0 +1,232 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package compression
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// echoHandler echoes the request body, and records the request it received.
+type echoHandler struct {
+	req  *transport.Request
+	body string
+}
+
+func (h *echoHandler) Handle(ctx context.Context, req *transport.Request, w transport.ResponseWriter) error {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+	h.req, h.body = req, string(body)
+	w.AddHeaders(transport.NewHeaders().With("echo", "true"))
+	_, err = w.Write(body)
+	return err
+}
+
+func (h *echoHandler) HandleOneway(ctx context.Context, req *transport.Request) error {
+	return h.Handle(ctx, req, &transporttest.FakeResponseWriter{})
+}
+
+// loopback is an outbound that hands requests to inbound middleware, and
+// records the raw requests and responses.
+type loopback struct {
+	transport.Outbound
+
+	inbound *Middleware
+	handler *echoHandler
+
+	sent     *transport.Request
+	received *transporttest.FakeResponseWriter
+}
+
+func (o *loopback) Call(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	sent := *req
+	sent.Body = bytes.NewReader(body)
+	o.sent = &sent
+
+	w := &transporttest.FakeResponseWriter{}
+	req.Body = bytes.NewReader(body)
+	if o.inbound != nil {
+		err = o.inbound.Handle(ctx, req, w, o.handler)
+	} else {
+		err = o.handler.Handle(ctx, req, w)
+	}
+	if err != nil {
+		return nil, err
+	}
+	o.received = w
+	return &transport.Response{
+		Headers: w.Headers,
+		Body:    ioutil.NopCloser(bytes.NewReader(w.Body.Bytes())),
+	}, nil
+}
+
+func (o *loopback) CallOneway(ctx context.Context, req *transport.Request) (transport.Ack, error) {
+	_, err := o.Call(ctx, req)
+	return nil, err
+}
+
+func call(t *testing.T, mw *Middleware, out *loopback, body string) string {
+	res, err := mw.Call(context.Background(), &transport.Request{
+		Service:   "echo",
+		Procedure: "echo",
+		Body:      strings.NewReader(body),
+	}, out)
+	require.NoError(t, err)
+	got, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Equal(t, transport.NewHeaders().With("echo", "true"), res.Headers, "compression headers must be removed")
+	return string(got)
+}
+
+func compressed(h transport.Headers) bool {
+	name, ok := h.Get(CompressionHeader)
+	return ok && name == "gzip"
+}
+
+func TestCompression(t *testing.T) {
+	large := strings.Repeat("hello ", 1000)
+	out := &loopback{inbound: New(), handler: &echoHandler{}}
+	mw := New()
+
+	assert.Equal(t, large, call(t, mw, out, large))
+	assert.False(t, compressed(out.sent.Headers), "requests must not be compressed before the service is known to accept it")
+	assert.True(t, compressed(out.received.Headers), "large responses must be compressed")
+	assert.True(t, out.received.Body.Len() < len(large))
+
+	assert.Equal(t, large, call(t, mw, out, large))
+	assert.True(t, compressed(out.sent.Headers), "requests must be compressed once the service accepts it")
+	assert.Equal(t, large, out.handler.body, "requests must be decompressed for the handler")
+	_, ok := out.handler.req.Headers.Get(CompressionHeader)
+	assert.False(t, ok, "handlers must not see the compression header")
+
+	assert.Equal(t, "hello", call(t, mw, out, "hello"))
+	assert.False(t, compressed(out.sent.Headers), "small requests must not be compressed")
+	assert.False(t, compressed(out.received.Headers), "small responses must not be compressed")
+
+	_, err := mw.CallOneway(context.Background(), &transport.Request{
+		Service: "echo",
+		Body:    strings.NewReader(large),
+	}, out)
+	require.NoError(t, err)
+	assert.True(t, compressed(out.sent.Headers), "oneway requests must be compressed")
+	assert.Equal(t, large, out.handler.body)
+}
+
+func TestServiceWithoutCompression(t *testing.T) {
+	large := strings.Repeat("hello ", 1000)
+	out := &loopback{handler: &echoHandler{}}
+	mw := New()
+
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, large, call(t, mw, out, large))
+		assert.False(t, compressed(out.sent.Headers), "requests must not be compressed for services that do not accept it")
+	}
+}
+
+func TestCallerWithoutCompression(t *testing.T) {
+	large := strings.Repeat("hello ", 1000)
+	h := &echoHandler{}
+	w := &transporttest.FakeResponseWriter{}
+	req := &transport.Request{Body: strings.NewReader(large)}
+
+	require.NoError(t, New().Handle(context.Background(), req, w, h))
+	assert.False(t, compressed(w.Headers), "responses must not be compressed for callers that do not accept it")
+	assert.Equal(t, large, w.Body.String())
+}
+
+func TestUnsupportedCompression(t *testing.T) {
+	req := &transport.Request{
+		Procedure: "echo",
+		Headers:   transport.NewHeaders().With(CompressionHeader, "snappy"),
+		Body:      strings.NewReader("hello"),
+	}
+	err := New(Compressors(Gzip(gzip.BestSpeed))).Handle(context.Background(), req, &transporttest.FakeResponseWriter{}, &echoHandler{})
+	require.Error(t, err)
+	assert.Equal(t, yarpcerrors.CodeInvalidArgument, yarpcerrors.FromError(err).Code())
+
+	req.Headers = transport.NewHeaders().With(CompressionHeader, "gzip")
+	err = New().Handle(context.Background(), req, &transporttest.FakeResponseWriter{}, &echoHandler{})
+	require.Error(t, err)
+	assert.Equal(t, yarpcerrors.CodeInvalidArgument, yarpcerrors.FromError(err).Code(), "corrupt bodies must be rejected")
+}
+
+func TestNegotiation(t *testing.T) {
+	fast := Gzip(gzip.BestSpeed)
+	mw := New(Compressors(renamed{fast, "zstd"}, fast), MinSize(0))
+
+	assert.Equal(t, "zstd,gzip", mw.accept)
+	assert.Equal(t, "gzip", mw.choose("snappy, gzip").Name())
+	assert.Equal(t, "zstd", mw.choose("gzip,zstd").Name(), "the service's preference must win")
+	assert.Nil(t, mw.choose("snappy"))
+	assert.Nil(t, mw.choose(""))
+}
+
+type renamed struct {
+	Compressor
+
+	name string
+}
+
+func (c renamed) Name() string { return c.name }
+
+func TestApplicationErrorMeta(t *testing.T) {
+	meta := &transport.ApplicationErrorMeta{Name: "NotFound", Message: "not found"}
+	h := unaryHandlerFunc(func(_ context.Context, _ *transport.Request, w transport.ResponseWriter) error {
+		w.SetApplicationError()
+		w.(transport.ApplicationErrorMetaSetter).SetApplicationErrorMeta(meta)
+		_, err := w.Write([]byte(strings.Repeat("not found ", 100)))
+		return err
+	})
+
+	w := &transporttest.FakeResponseWriter{}
+	err := New(MinSize(0)).Handle(context.Background(), &transport.Request{
+		Service:   "echo",
+		Procedure: "echo",
+		Headers:   transport.NewHeaders().With(AcceptHeader, "gzip"),
+		Body:      strings.NewReader(""),
+	}, w, h)
+	require.NoError(t, err)
+	assert.True(t, compressed(w.Headers), "response must be compressed")
+	assert.True(t, w.IsApplicationError)
+	assert.Equal(t, meta, w.ApplicationErrorMeta, "application error meta must be forwarded")
+}
+
+type unaryHandlerFunc func(context.Context, *transport.Request, transport.ResponseWriter) error
+
+func (f unaryHandlerFunc) Handle(ctx context.Context, req *transport.Request, w transport.ResponseWriter) error {
+	return f(ctx, req, w)
+}
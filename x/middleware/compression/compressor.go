@@ -0,0 +1,63 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package compression
+
+import (
+	"compress/gzip"
+	"io"
+)
+
+// Compressor compresses and decompresses bodies with one algorithm.
+//
+// Compressors MUST be thread-safe.
+type Compressor interface {
+	// Name is the name of the algorithm, which callers and services use to
+	// negotiate it. Callers and services must agree on the names of the
+	// algorithms they share.
+	Name() string
+
+	// Compress returns a writer that compresses what is written to it into
+	// the given writer, until it is closed.
+	Compress(w io.Writer) (io.WriteCloser, error)
+
+	// Decompress returns a reader that decompresses the given reader.
+	Decompress(r io.Reader) (io.ReadCloser, error)
+}
+
+// Gzip returns a Compressor for gzip at the given compression level, like
+// gzip.DefaultCompression or gzip.BestSpeed. Its name is "gzip".
+func Gzip(level int) Compressor {
+	return gzipCompressor{level: level}
+}
+
+type gzipCompressor struct {
+	level int
+}
+
+func (gzipCompressor) Name() string { return "gzip" }
+
+func (c gzipCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	return gzip.NewWriterLevel(w, c.level)
+}
+
+func (gzipCompressor) Decompress(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package compression
+
+import "compress/gzip"
+
+// Option customizes the behavior of compression middleware.
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(opts *options) { f(opts) }
+
+type options struct {
+	compressors []Compressor
+	minSize     int
+}
+
+func newOptions(opts []Option) options {
+	o := options{minSize: 1024}
+	for _, opt := range opts {
+		opt.apply(&o)
+	}
+	if len(o.compressors) == 0 {
+		o.compressors = []Compressor{Gzip(gzip.DefaultCompression)}
+	}
+	return o
+}
+
+// Compressors are the compression algorithms the middleware supports, in
+// order of preference.
+//
+// Defaults to gzip at the default compression level.
+func Compressors(compressors ...Compressor) Option {
+	return optionFunc(func(o *options) {
+		o.compressors = append(o.compressors, compressors...)
+	})
+}
+
+// MinSize is the size in bytes below which bodies are sent uncompressed,
+// since compressing small bodies costs more than it saves.
+//
+// Defaults to 1024.
+func MinSize(n int) Option {
+	return optionFunc(func(o *options) {
+		o.minSize = n
+	})
+}
@@ -0,0 +1,183 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package concurrencylimit provides inbound middleware that limits the
+// number of requests a service handles concurrently, adjusting the limit
+// automatically from the latency of the requests rather than relying on a
+// hand-tuned value.
+//
+// 	cl := concurrencylimit.New(concurrencylimit.MaxLimit(500))
+// 	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+// 		Name: "myservice",
+// 		InboundMiddleware: yarpc.InboundMiddleware{
+// 			Unary:  cl,
+// 			Oneway: cl,
+// 		},
+// 	})
+//
+// The limit follows the gradient algorithm of Netflix's concurrency-limits
+// library. The middleware compares the latency of each request with a
+// long-term average of latencies. While latencies stay close to the
+// average, the service is not queueing requests and the limit grows; when
+// latencies rise above the average, requests are queueing and the limit
+// shrinks in proportion. Requests beyond the limit are rejected at once with
+// a ResourceExhausted error, shedding excess load before it queues.
+package concurrencylimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+const (
+	// _queueSize is how many requests the limit allows to queue beyond the
+	// limit that latencies suggest, so that the limit can grow.
+	_queueSize = 4
+
+	// _longWindow is the number of requests the long-term average of
+	// latencies spans.
+	_longWindow = 600
+
+	// _backoff is the factor the limit shrinks by when requests time out.
+	_backoff = 0.9
+)
+
+var (
+	_ middleware.UnaryInbound  = (*Middleware)(nil)
+	_ middleware.OnewayInbound = (*Middleware)(nil)
+)
+
+// Middleware is inbound middleware which limits the number of concurrent
+// requests to a limit that adapts to their latency.
+type Middleware struct {
+	opts options
+
+	mu       sync.Mutex
+	limit    float64
+	inFlight int
+	longRTT  time.Duration
+}
+
+// New builds adaptive concurrency limiting middleware.
+func New(opts ...Option) *Middleware {
+	o := newOptions(opts)
+	m := &Middleware{opts: o}
+	m.limit = m.clamp(float64(o.initialLimit))
+	return m
+}
+
+// Handle implements middleware.UnaryInbound.
+func (m *Middleware) Handle(ctx context.Context, req *transport.Request, w transport.ResponseWriter, h transport.UnaryHandler) error {
+	if err := m.acquire(); err != nil {
+		return err
+	}
+	start := m.opts.clock.Now()
+	err := h.Handle(ctx, req, w)
+	m.release(m.opts.clock.Now().Sub(start), err)
+	return err
+}
+
+// HandleOneway implements middleware.OnewayInbound.
+func (m *Middleware) HandleOneway(ctx context.Context, req *transport.Request, h transport.OnewayHandler) error {
+	if err := m.acquire(); err != nil {
+		return err
+	}
+	start := m.opts.clock.Now()
+	err := h.HandleOneway(ctx, req)
+	m.release(m.opts.clock.Now().Sub(start), err)
+	return err
+}
+
+// Limit returns the current concurrency limit.
+func (m *Middleware) Limit() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return int(m.limit)
+}
+
+// InFlight returns the number of requests being handled.
+func (m *Middleware) InFlight() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.inFlight
+}
+
+func (m *Middleware) acquire() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.inFlight >= int(m.limit) {
+		return yarpcerrors.Newf(yarpcerrors.CodeResourceExhausted,
+			"concurrency limit of %d requests exceeded", int(m.limit))
+	}
+	m.inFlight++
+	return nil
+}
+
+// release records the latency of a request that finished and adjusts the
+// limit.
+func (m *Middleware) release(rtt time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	inFlight := m.inFlight
+	m.inFlight--
+
+	if yarpcerrors.IsDeadlineExceeded(err) {
+		// Requests that time out may not have finished their work, so
+		// their latency says little, but they are a sign of overload.
+		m.limit = m.clamp(m.limit * _backoff)
+		return
+	}
+
+	if m.longRTT == 0 {
+		m.longRTT = rtt
+	} else {
+		m.longRTT += (rtt - m.longRTT) * 2 / (_longWindow + 1)
+	}
+	// When latencies have dropped well below the long-term average, like
+	// after a period of overload, the average catches up faster.
+	if rtt > 0 && m.longRTT > 2*rtt {
+		m.longRTT = m.longRTT * 95 / 100
+	}
+
+	// The limit only grows while it is being used, so that an idle service
+	// does not accumulate a limit it never tested.
+	if float64(inFlight) < m.limit/2 {
+		return
+	}
+
+	gradient := 1.0
+	if rtt > 0 {
+		gradient = math.Max(0.5, math.Min(1, float64(m.longRTT)/float64(rtt)))
+	}
+	newLimit := m.limit*gradient + _queueSize
+	m.limit = m.clamp(m.limit*(1-m.opts.smoothing) + newLimit*m.opts.smoothing)
+}
+
+func (m *Middleware) clamp(limit float64) float64 {
+	return math.Max(float64(m.opts.minLimit), math.Min(float64(m.opts.maxLimit), limit))
+}
@@ -0,0 +1,143 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package concurrencylimit
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// blockingHandler blocks until released, signalling when it has started.
+type blockingHandler struct {
+	started chan struct{}
+	release chan struct{}
+	err     error
+}
+
+func newBlockingHandler(err error) *blockingHandler {
+	return &blockingHandler{
+		started: make(chan struct{}, 100),
+		release: make(chan struct{}),
+		err:     err,
+	}
+}
+
+func (h *blockingHandler) Handle(context.Context, *transport.Request, transport.ResponseWriter) error {
+	h.started <- struct{}{}
+	<-h.release
+	return h.err
+}
+
+func (h *blockingHandler) HandleOneway(context.Context, *transport.Request) error {
+	h.started <- struct{}{}
+	<-h.release
+	return h.err
+}
+
+func newTestMiddleware(opts ...Option) (*Middleware, *clock.FakeClock) {
+	fake := clock.NewFake()
+	opts = append(opts, optionFunc(func(o *options) { o.clock = fake }))
+	return New(opts...), fake
+}
+
+// handleBatch handles n concurrent requests that each take the given
+// latency.
+func handleBatch(t *testing.T, m *Middleware, fake *clock.FakeClock, n int, latency time.Duration, err error) {
+	h := newBlockingHandler(err)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = m.Handle(context.Background(), &transport.Request{}, &transporttest.FakeResponseWriter{}, h)
+		}()
+	}
+	for i := 0; i < n; i++ {
+		<-h.started
+	}
+	fake.Add(latency)
+	close(h.release)
+	wg.Wait()
+	require.Equal(t, 0, m.InFlight())
+}
+
+func TestConcurrencyLimitSheds(t *testing.T) {
+	m, _ := newTestMiddleware(InitialLimit(2))
+	ctx := context.Background()
+
+	h := newBlockingHandler(nil)
+	done := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			done <- m.HandleOneway(ctx, &transport.Request{}, h)
+		}()
+	}
+	<-h.started
+	<-h.started
+	assert.Equal(t, 2, m.InFlight())
+
+	err := m.HandleOneway(ctx, &transport.Request{}, h)
+	assert.Equal(t, yarpcerrors.CodeResourceExhausted, yarpcerrors.FromError(err).Code(),
+		"requests beyond the limit must be rejected")
+
+	close(h.release)
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, <-done)
+	}
+}
+
+func TestConcurrencyLimitAdapts(t *testing.T) {
+	m, fake := newTestMiddleware(InitialLimit(10), MaxLimit(100))
+
+	for i := 0; i < 20; i++ {
+		handleBatch(t, m, fake, m.Limit(), 10*time.Millisecond, nil)
+	}
+	grown := m.Limit()
+	assert.True(t, grown > 10, "the limit must grow while latencies are steady, got %d", grown)
+
+	for i := 0; i < 5; i++ {
+		handleBatch(t, m, fake, m.Limit(), 100*time.Millisecond, nil)
+	}
+	assert.True(t, m.Limit() < grown, "the limit must shrink when latencies rise, got %d", m.Limit())
+}
+
+func TestConcurrencyLimitBounds(t *testing.T) {
+	m, fake := newTestMiddleware(InitialLimit(4), MinLimit(3), MaxLimit(5))
+
+	for i := 0; i < 10; i++ {
+		handleBatch(t, m, fake, m.Limit(), 10*time.Millisecond, nil)
+	}
+	assert.Equal(t, 5, m.Limit(), "the limit must not exceed the maximum")
+
+	for i := 0; i < 10; i++ {
+		handleBatch(t, m, fake, m.Limit(), 10*time.Millisecond, yarpcerrors.DeadlineExceededErrorf("too slow"))
+	}
+	assert.Equal(t, 3, m.Limit(), "timeouts must shrink the limit down to the minimum")
+}
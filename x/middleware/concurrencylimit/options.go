@@ -0,0 +1,95 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package concurrencylimit
+
+import "go.uber.org/yarpc/internal/clock"
+
+// Option customizes the behavior of adaptive concurrency limiting
+// middleware.
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(opts *options) { f(opts) }
+
+type options struct {
+	initialLimit int
+	minLimit     int
+	maxLimit     int
+	smoothing    float64
+	clock        clock.Clock
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		initialLimit: 20,
+		minLimit:     1,
+		maxLimit:     1000,
+		smoothing:    0.2,
+		clock:        clock.NewReal(),
+	}
+	for _, opt := range opts {
+		opt.apply(&o)
+	}
+	return o
+}
+
+// InitialLimit is the concurrency limit the middleware starts with, before
+// it has measured any latencies.
+//
+// Defaults to 20.
+func InitialLimit(n int) Option {
+	return optionFunc(func(o *options) {
+		o.initialLimit = n
+	})
+}
+
+// MinLimit is the lowest the concurrency limit may go, however much the
+// latency grows.
+//
+// Defaults to 1.
+func MinLimit(n int) Option {
+	return optionFunc(func(o *options) {
+		o.minLimit = n
+	})
+}
+
+// MaxLimit is the highest the concurrency limit may go.
+//
+// Defaults to 1000.
+func MaxLimit(n int) Option {
+	return optionFunc(func(o *options) {
+		o.maxLimit = n
+	})
+}
+
+// Smoothing is how quickly, between 0 and 1, the concurrency limit moves
+// towards the limit computed for each request. Higher values react faster
+// to changes in latency, lower values are more stable.
+//
+// Defaults to 0.2.
+func Smoothing(s float64) Option {
+	return optionFunc(func(o *options) {
+		o.smoothing = s
+	})
+}
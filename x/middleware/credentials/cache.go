@@ -0,0 +1,168 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package credentials
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/yarpc/api/transport"
+)
+
+// CacheOption customizes the behavior of Cache.
+type CacheOption interface {
+	apply(*cacheOptions)
+}
+
+type cacheOptionFunc func(*cacheOptions)
+
+func (f cacheOptionFunc) apply(opts *cacheOptions) { f(opts) }
+
+type cacheOptions struct {
+	key           func(*transport.Request) string
+	refreshBefore time.Duration
+	now           func() time.Time
+}
+
+// CacheKey decides which requests share credentials, like requests to the
+// same service when tokens are issued for a single audience.
+//
+// Defaults to sharing credentials between all requests.
+func CacheKey(key func(*transport.Request) string) CacheOption {
+	return cacheOptionFunc(func(o *cacheOptions) {
+		o.key = key
+	})
+}
+
+// RefreshBefore is how long before credentials expire they are refreshed.
+// Requests keep using the current credentials while they are refreshed, and
+// if refreshing them fails.
+//
+// Defaults to one minute.
+func RefreshBefore(d time.Duration) CacheOption {
+	return cacheOptionFunc(func(o *cacheOptions) {
+		o.refreshBefore = d
+	})
+}
+
+// Cache reuses the credentials of the given provider until shortly before
+// they expire. Credentials without an expiry are reused forever.
+//
+// Do not cache providers whose credentials depend on the contents of each
+// request, like HMAC.
+func Cache(p Provider, opts ...CacheOption) Provider {
+	o := cacheOptions{
+		key:           func(*transport.Request) string { return "" },
+		refreshBefore: time.Minute,
+		now:           time.Now,
+	}
+	for _, opt := range opts {
+		opt.apply(&o)
+	}
+	return &cache{p: p, opts: o, entries: make(map[string]*cacheEntry)}
+}
+
+type cache struct {
+	p    Provider
+	opts cacheOptions
+
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+}
+
+// cacheEntry holds the credentials of a cache key.
+type cacheEntry struct {
+	// fetchMu is held while credentials are fetched because there are no
+	// valid credentials, so that concurrent requests wait for one fetch.
+	fetchMu sync.Mutex
+
+	mu         sync.Mutex
+	creds      Credentials
+	ok         bool
+	refreshing bool
+}
+
+func (c *cache) entry(key string) *cacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		e = &cacheEntry{}
+		c.entries[key] = e
+	}
+	return e
+}
+
+func (c *cache) Credentials(ctx context.Context, req *transport.Request) (Credentials, error) {
+	e := c.entry(c.opts.key(req))
+	now := c.opts.now()
+
+	e.mu.Lock()
+	creds, valid := e.creds, e.ok && e.creds.valid(now)
+	stale := valid && !e.creds.Expiry.IsZero() && !now.Before(e.creds.Expiry.Add(-c.opts.refreshBefore))
+	refresh := stale && !e.refreshing
+	if refresh {
+		e.refreshing = true
+	}
+	e.mu.Unlock()
+
+	switch {
+	case valid && !refresh:
+		return creds, nil
+	case refresh:
+		// Only the request that noticed the credentials are stale refreshes
+		// them; the others keep using them meanwhile.
+		fresh, err := c.p.Credentials(ctx, req)
+		e.mu.Lock()
+		e.refreshing = false
+		if err == nil {
+			e.creds = fresh
+		}
+		e.mu.Unlock()
+		if err != nil {
+			return creds, nil
+		}
+		return fresh, nil
+	}
+
+	e.fetchMu.Lock()
+	defer e.fetchMu.Unlock()
+
+	// Another request may have fetched credentials while this one waited.
+	e.mu.Lock()
+	if e.ok && e.creds.valid(c.opts.now()) {
+		creds := e.creds
+		e.mu.Unlock()
+		return creds, nil
+	}
+	e.mu.Unlock()
+
+	fresh, err := c.p.Credentials(ctx, req)
+	if err != nil {
+		return Credentials{}, err
+	}
+	e.mu.Lock()
+	e.creds, e.ok = fresh, true
+	e.mu.Unlock()
+	return fresh, nil
+}
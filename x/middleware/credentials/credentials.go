@@ -0,0 +1,146 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package credentials provides outbound middleware that attaches auth
+// material, like OAuth tokens or HMAC signatures, to the requests a service
+// makes.
+//
+// The material comes from a Provider. This package provides static headers,
+// bearer tokens and HMAC signatures, and caches credentials until shortly
+// before they expire.
+//
+// 	tokens := credentials.Cache(credentials.Bearer(func(ctx context.Context) (string, time.Time, error) {
+// 		tok, err := oauthConfig.Token(ctx)
+// 		if err != nil {
+// 			return "", time.Time{}, err
+// 		}
+// 		return tok.AccessToken, tok.Expiry, nil
+// 	}))
+// 	auth := credentials.New(tokens)
+// 	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+// 		Name: "myservice",
+// 		OutboundMiddleware: yarpc.OutboundMiddleware{
+// 			Unary:  auth,
+// 			Oneway: auth,
+// 		},
+// 	})
+package credentials
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+var (
+	_ middleware.UnaryOutbound  = (*Middleware)(nil)
+	_ middleware.OnewayOutbound = (*Middleware)(nil)
+)
+
+// Credentials are auth material attached to a request as headers.
+type Credentials struct {
+	// Headers are added to the request, replacing headers of the same name.
+	Headers map[string]string
+
+	// Expiry is when the credentials stop being valid, or zero if they
+	// never do.
+	Expiry time.Time
+}
+
+// valid returns whether the credentials are valid at the given time.
+func (c Credentials) valid(now time.Time) bool {
+	return c.Expiry.IsZero() || now.Before(c.Expiry)
+}
+
+// Provider provides the credentials of requests.
+type Provider interface {
+	// Credentials returns the credentials of the request. Providers that
+	// need the body of the request, like signers, may replace the body with
+	// a copy after reading it.
+	Credentials(ctx context.Context, req *transport.Request) (Credentials, error)
+}
+
+// ProviderFunc adapts a function into a Provider.
+type ProviderFunc func(context.Context, *transport.Request) (Credentials, error)
+
+// Credentials calls the function.
+func (f ProviderFunc) Credentials(ctx context.Context, req *transport.Request) (Credentials, error) {
+	return f(ctx, req)
+}
+
+// Middleware is outbound middleware which attaches credentials to requests.
+type Middleware struct {
+	providers []Provider
+}
+
+// New builds middleware that attaches the credentials of the given
+// providers to requests. The headers of later providers replace the headers
+// of the same name of earlier providers.
+func New(providers ...Provider) *Middleware {
+	return &Middleware{providers: providers}
+}
+
+// Call implements middleware.UnaryOutbound.
+func (m *Middleware) Call(ctx context.Context, req *transport.Request, out transport.UnaryOutbound) (*transport.Response, error) {
+	req, err := m.authenticate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return out.Call(ctx, req)
+}
+
+// CallOneway implements middleware.OnewayOutbound.
+func (m *Middleware) CallOneway(ctx context.Context, req *transport.Request, out transport.OnewayOutbound) (transport.Ack, error) {
+	req, err := m.authenticate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return out.CallOneway(ctx, req)
+}
+
+// authenticate returns a copy of the request with the credentials of the
+// providers.
+func (m *Middleware) authenticate(ctx context.Context, req *transport.Request) (*transport.Request, error) {
+	r := *req
+	var added []map[string]string
+	for _, p := range m.providers {
+		creds, err := p.Credentials(ctx, &r)
+		if err != nil {
+			return nil, yarpcerrors.Newf(yarpcerrors.CodeUnauthenticated,
+				"failed to get credentials for request to procedure %q of service %q: %v", req.Procedure, req.Service, err)
+		}
+		added = append(added, creds.Headers)
+	}
+
+	headers := transport.NewHeadersWithCapacity(r.Headers.Len())
+	for k, v := range r.Headers.Items() {
+		headers = headers.With(k, v)
+	}
+	for _, h := range added {
+		for k, v := range h {
+			headers = headers.With(k, v)
+		}
+	}
+	r.Headers = headers
+	return &r, nil
+}
@@ -0,0 +1,176 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package credentials
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// recordingOutbound records the request it is called with.
+type recordingOutbound struct {
+	transport.Outbound
+
+	req  *transport.Request
+	body string
+}
+
+func (o *recordingOutbound) Call(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	o.record(req)
+	return &transport.Response{}, nil
+}
+
+func (o *recordingOutbound) CallOneway(ctx context.Context, req *transport.Request) (transport.Ack, error) {
+	o.record(req)
+	return nil, nil
+}
+
+func (o *recordingOutbound) record(req *transport.Request) {
+	o.req = req
+	if req.Body != nil {
+		body, _ := ioutil.ReadAll(req.Body)
+		o.body = string(body)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	mw := New(
+		Static(map[string]string{"api-key": "secret", "authorization": "none"}),
+		Bearer(func(context.Context) (string, time.Time, error) { return "token", time.Time{}, nil }),
+	)
+	out := &recordingOutbound{}
+
+	req := &transport.Request{Procedure: "get", Headers: transport.NewHeaders().With("tenant", "acme")}
+	_, err := mw.Call(context.Background(), req, out)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"tenant":        "acme",
+		"api-key":       "secret",
+		"authorization": "Bearer token",
+	}, out.req.Headers.Items(), "later providers must replace headers of earlier ones")
+	assert.Equal(t, 1, req.Headers.Len(), "the original request must not be modified")
+
+	_, err = mw.CallOneway(context.Background(), &transport.Request{}, out)
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer token", out.req.Headers.Items()["authorization"])
+}
+
+func TestMiddlewareError(t *testing.T) {
+	mw := New(Bearer(func(context.Context) (string, time.Time, error) {
+		return "", time.Time{}, errors.New("token endpoint is down")
+	}))
+
+	_, err := mw.Call(context.Background(), &transport.Request{Service: "users", Procedure: "get"}, &recordingOutbound{})
+	require.Error(t, err)
+	assert.Equal(t, yarpcerrors.CodeUnauthenticated, yarpcerrors.FromError(err).Code())
+	assert.Equal(t,
+		`failed to get credentials for request to procedure "get" of service "users": token endpoint is down`,
+		yarpcerrors.FromError(err).Message())
+}
+
+func TestHMAC(t *testing.T) {
+	signer := HMAC("key1", []byte("secret")).(hmacSigner)
+	signer.now = func() time.Time { return time.Unix(1500000000, 0) }
+	mw := New(signer)
+	out := &recordingOutbound{}
+
+	req := &transport.Request{
+		Caller:    "frontend",
+		Service:   "users",
+		Procedure: "get",
+		Body:      bytes.NewBufferString("hello"),
+	}
+	_, err := mw.Call(context.Background(), req, out)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", out.body, "the body must still be sent after it is signed")
+
+	headers := out.req.Headers.Items()
+	assert.Equal(t, "key1", headers[HMACKeyIDHeader])
+	assert.Equal(t, "1500000000", headers[HMACTimestampHeader])
+	assert.Equal(t, SignHMAC([]byte("secret"), "1500000000", req, []byte("hello")), headers[HMACSignatureHeader])
+	assert.Equal(t, "tLn+5SGGixNcZrn0dmkCbhWPof5jfDVXjFxDu58Oqkc=", headers[HMACSignatureHeader])
+
+	assert.NotEqual(t,
+		SignHMAC([]byte("secret"), "1500000000", req, []byte("hello")),
+		SignHMAC([]byte("secret"), "1500000000", req, []byte("hello!")),
+		"signatures must cover the body")
+}
+
+// countingProvider provides numbered tokens that expire after a minute.
+type countingProvider struct {
+	now   *time.Time
+	calls int
+	err   error
+}
+
+func (p *countingProvider) Credentials(context.Context, *transport.Request) (Credentials, error) {
+	p.calls++
+	if p.err != nil {
+		return Credentials{}, p.err
+	}
+	return Credentials{
+		Headers: map[string]string{"token": string('0' + rune(p.calls))},
+		Expiry:  p.now.Add(time.Minute),
+	}, nil
+}
+
+func TestCache(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	p := &countingProvider{now: &now}
+	c := Cache(p, RefreshBefore(10*time.Second), CacheKey(func(req *transport.Request) string {
+		return req.Service
+	}))
+	c.(*cache).opts.now = func() time.Time { return now }
+
+	token := func(service string) string {
+		creds, err := c.Credentials(context.Background(), &transport.Request{Service: service})
+		require.NoError(t, err)
+		return creds.Headers["token"]
+	}
+
+	assert.Equal(t, "1", token("users"))
+	assert.Equal(t, "1", token("users"), "credentials must be reused")
+	assert.Equal(t, "2", token("storage"), "cache keys must have their own credentials")
+
+	now = now.Add(55 * time.Second)
+	assert.Equal(t, "3", token("users"), "credentials must be refreshed before they expire")
+	assert.Equal(t, 3, p.calls)
+
+	now = now.Add(55 * time.Second)
+	p.err = errors.New("token endpoint is down")
+	assert.Equal(t, "3", token("users"), "failed refreshes must keep the current credentials")
+
+	now = now.Add(10 * time.Second)
+	_, err := c.Credentials(context.Background(), &transport.Request{Service: "users"})
+	assert.Error(t, err, "expired credentials must not be used")
+
+	p.err = nil
+	assert.Equal(t, "6", token("users"))
+}
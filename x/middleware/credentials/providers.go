@@ -0,0 +1,127 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package credentials
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/yarpc/api/transport"
+)
+
+// Headers that carry HMAC signatures.
+const (
+	HMACKeyIDHeader     = "auth-key-id"
+	HMACTimestampHeader = "auth-timestamp"
+	HMACSignatureHeader = "auth-signature"
+)
+
+// Static provides the same headers for every request, like an API key.
+func Static(headers map[string]string) Provider {
+	return ProviderFunc(func(context.Context, *transport.Request) (Credentials, error) {
+		return Credentials{Headers: headers}, nil
+	})
+}
+
+// Bearer provides the tokens of the given function, like OAuth access
+// tokens, in the authorization header of requests. The function returns
+// when the token expires, or zero if it does not.
+//
+// Wrap the provider with Cache to reuse tokens until shortly before they
+// expire.
+func Bearer(token func(context.Context) (string, time.Time, error)) Provider {
+	return ProviderFunc(func(ctx context.Context, _ *transport.Request) (Credentials, error) {
+		tok, expiry, err := token(ctx)
+		if err != nil {
+			return Credentials{}, err
+		}
+		return Credentials{
+			Headers: map[string]string{"authorization": "Bearer " + tok},
+			Expiry:  expiry,
+		}, nil
+	})
+}
+
+// HMAC signs requests with the given secret key, identified to the service
+// by the given key ID.
+//
+// The signature is the base64-encoded HMAC-SHA256 of the following lines,
+// joined by newlines, which the service recomputes to verify the request:
+//
+// 	timestamp, in seconds since the Unix epoch
+// 	caller
+// 	service
+// 	procedure
+// 	hex-encoded SHA-256 digest of the body
+//
+// The key ID, timestamp and signature are sent in the HMACKeyIDHeader,
+// HMACTimestampHeader and HMACSignatureHeader headers.
+func HMAC(keyID string, secret []byte) Provider {
+	return hmacSigner{keyID: keyID, secret: secret, now: time.Now}
+}
+
+type hmacSigner struct {
+	keyID  string
+	secret []byte
+	now    func() time.Time
+}
+
+func (s hmacSigner) Credentials(_ context.Context, req *transport.Request) (Credentials, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(req.Body); err != nil {
+			return Credentials{}, err
+		}
+		req.Body = bytes.NewReader(body)
+	}
+
+	timestamp := strconv.FormatInt(s.now().Unix(), 10)
+	return Credentials{Headers: map[string]string{
+		HMACKeyIDHeader:     s.keyID,
+		HMACTimestampHeader: timestamp,
+		HMACSignatureHeader: SignHMAC(s.secret, timestamp, req, body),
+	}}, nil
+}
+
+// SignHMAC returns the HMAC signature of a request with the given
+// timestamp and body, as described by HMAC. Services use it to verify the
+// signatures of the requests they receive.
+func SignHMAC(secret []byte, timestamp string, req *transport.Request, body []byte) string {
+	digest := sha256.Sum256(body)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strings.Join([]string{
+		timestamp,
+		req.Caller,
+		req.Service,
+		req.Procedure,
+		hex.EncodeToString(digest[:]),
+	}, "\n")))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
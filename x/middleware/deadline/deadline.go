@@ -0,0 +1,139 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package deadline provides middleware that enforces a latency budget for
+// each hop of a request, to stop timeouts from piling up across services.
+//
+// Each hop keeps a slice of the time remaining until the deadline of a
+// request for itself, and passes on only the rest. Requests that would leave
+// the next hop too little time are rejected at once with a DeadlineExceeded
+// error, instead of doing work whose result nobody will wait for. Responses
+// carry the time spent on them, in milliseconds, in the ElapsedHeader
+// application header.
+//
+// 	budget := deadline.New(deadline.ReserveFraction(0.1), deadline.MinBudget(10*time.Millisecond))
+// 	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+// 		Name: "myservice",
+// 		InboundMiddleware: yarpc.InboundMiddleware{
+// 			Unary: budget,
+// 		},
+// 		OutboundMiddleware: yarpc.OutboundMiddleware{
+// 			Unary: budget,
+// 		},
+// 	})
+//
+// Requests without a deadline are passed through unchanged.
+package deadline
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/onclose"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// ElapsedHeader is the application header in which responses carry the
+// number of milliseconds spent on the request.
+const ElapsedHeader = "elapsed-ms"
+
+var (
+	_ middleware.UnaryInbound  = (*Middleware)(nil)
+	_ middleware.UnaryOutbound = (*Middleware)(nil)
+)
+
+// Middleware is inbound and outbound middleware which enforces a latency
+// budget for each hop.
+type Middleware struct {
+	opts options
+}
+
+// New builds deadline budget middleware.
+func New(opts ...Option) *Middleware {
+	return &Middleware{opts: newOptions(opts)}
+}
+
+// Handle implements middleware.UnaryInbound. The handler gets a deadline
+// that leaves this service time to send its response.
+func (m *Middleware) Handle(ctx context.Context, req *transport.Request, w transport.ResponseWriter, h transport.UnaryHandler) error {
+	start := time.Now()
+	ctx, cancel, err := m.budget(ctx, start, req)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	err = h.Handle(ctx, req, w)
+	w.AddHeaders(transport.NewHeaders().With(ElapsedHeader, elapsed(start)))
+	return err
+}
+
+// Call implements middleware.UnaryOutbound. The call gets a deadline that
+// leaves the caller time to handle its response.
+func (m *Middleware) Call(ctx context.Context, req *transport.Request, out transport.UnaryOutbound) (*transport.Response, error) {
+	start := time.Now()
+	ctx, cancel, err := m.budget(ctx, start, req)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := out.Call(ctx, req)
+	if res != nil {
+		res.Headers = res.Headers.With(ElapsedHeader, elapsed(start))
+	}
+	if err != nil {
+		cancel()
+		return res, err
+	}
+	// The context is canceled when the response body is closed rather than
+	// when the call returns, since the body may still be read with it.
+	onclose.Response(res, cancel)
+	return res, nil
+}
+
+// budget returns a context whose deadline keeps this hop's reserve, or an
+// error if too little time would remain for the next hop.
+func (m *Middleware) budget(ctx context.Context, now time.Time, req *transport.Request) (context.Context, context.CancelFunc, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return ctx, func() {}, nil
+	}
+
+	remaining := deadline.Sub(now)
+	reserve := time.Duration(float64(remaining) * m.opts.reserveFraction)
+	if reserve < m.opts.minReserve {
+		reserve = m.opts.minReserve
+	}
+	if budget := remaining - reserve; budget < m.opts.minBudget {
+		return nil, nil, yarpcerrors.DeadlineExceededErrorf(
+			"not enough time left for procedure %q of service %q: %v remaining, %v reserved, %v required",
+			req.Procedure, req.Service, remaining, reserve, m.opts.minBudget)
+	}
+	ctx, cancel := context.WithDeadline(ctx, deadline.Add(-reserve))
+	return ctx, cancel, nil
+}
+
+// elapsed returns the milliseconds since the start.
+func elapsed(start time.Time) string {
+	return strconv.FormatInt(int64(time.Since(start)/time.Millisecond), 10)
+}
@@ -0,0 +1,157 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package deadline
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// deadlineRecorder records the deadline of the requests it handles.
+type deadlineRecorder struct {
+	transport.UnaryOutbound
+
+	deadline time.Time
+	ok       bool
+}
+
+func (r *deadlineRecorder) Handle(ctx context.Context, req *transport.Request, w transport.ResponseWriter) error {
+	r.deadline, r.ok = ctx.Deadline()
+	return nil
+}
+
+func (r *deadlineRecorder) Call(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	r.deadline, r.ok = ctx.Deadline()
+	return &transport.Response{}, nil
+}
+
+// bodyOutbound returns a response whose body reports the error of the
+// context of the call when it is read, or fails with err if it is set.
+type bodyOutbound struct {
+	transport.UnaryOutbound
+
+	err error
+	ctx context.Context
+}
+
+func (o *bodyOutbound) Call(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	o.ctx = ctx
+	if o.err != nil {
+		return nil, o.err
+	}
+	return &transport.Response{Body: ctxBody{ctx}}, nil
+}
+
+type ctxBody struct{ ctx context.Context }
+
+func (b ctxBody) Read([]byte) (int, error) {
+	if err := b.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return 0, io.EOF
+}
+
+func (ctxBody) Close() error { return nil }
+
+func TestDeadlineReserve(t *testing.T) {
+	m := New(ReserveFraction(0.2), MinReserve(time.Millisecond))
+	deadline := time.Now().Add(time.Second)
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+
+	t.Run("inbound", func(t *testing.T) {
+		r := &deadlineRecorder{}
+		w := &transporttest.FakeResponseWriter{}
+		require.NoError(t, m.Handle(ctx, &transport.Request{}, w, r))
+
+		require.True(t, r.ok, "the handler must have a deadline")
+		assert.True(t, r.deadline.Before(deadline.Add(-150*time.Millisecond)), "the deadline must keep a reserve")
+		assert.True(t, r.deadline.After(deadline.Add(-250*time.Millisecond)), "the deadline must not keep more than the reserve")
+
+		_, ok := w.Headers.Get(ElapsedHeader)
+		assert.True(t, ok, "the response must carry the time spent")
+	})
+
+	t.Run("outbound", func(t *testing.T) {
+		r := &deadlineRecorder{}
+		res, err := m.Call(ctx, &transport.Request{}, r)
+		require.NoError(t, err)
+
+		require.True(t, r.ok, "the call must have a deadline")
+		assert.True(t, r.deadline.Before(deadline.Add(-150*time.Millisecond)), "the deadline must keep a reserve")
+
+		_, ok := res.Headers.Get(ElapsedHeader)
+		assert.True(t, ok, "the response must carry the time spent")
+	})
+}
+
+func TestDeadlineResponseBody(t *testing.T) {
+	m := New()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	res, err := m.Call(ctx, &transport.Request{}, &bodyOutbound{})
+	require.NoError(t, err)
+	_, err = ioutil.ReadAll(res.Body)
+	assert.NoError(t, err, "the body must be readable after the call returns")
+
+	require.NoError(t, res.Body.Close())
+	_, err = ioutil.ReadAll(res.Body)
+	assert.Equal(t, context.Canceled, err, "the context must be canceled once the body is closed")
+
+	out := &bodyOutbound{err: errors.New("great sadness")}
+	_, err = m.Call(ctx, &transport.Request{}, out)
+	require.Error(t, err)
+	assert.Equal(t, context.Canceled, out.ctx.Err(), "the context must be canceled when the call fails")
+}
+
+func TestDeadlineInsufficientBudget(t *testing.T) {
+	m := New(MinReserve(10*time.Millisecond), MinBudget(50*time.Millisecond))
+	ctx, cancel := context.WithTimeout(context.Background(), 40*time.Millisecond)
+	defer cancel()
+	req := &transport.Request{Service: "keyvalue", Procedure: "get"}
+
+	r := &deadlineRecorder{}
+	err := m.Handle(ctx, req, &transporttest.FakeResponseWriter{}, r)
+	assert.True(t, yarpcerrors.IsDeadlineExceeded(err), "requests without enough time left must be rejected")
+
+	_, err = m.Call(ctx, req, r)
+	require.Error(t, err)
+	assert.True(t, yarpcerrors.IsDeadlineExceeded(err), "calls without enough time left must be rejected")
+	assert.Contains(t, err.Error(), `not enough time left for procedure "get" of service "keyvalue"`)
+	assert.False(t, r.ok, "rejected requests must not be passed on")
+}
+
+func TestDeadlineWithoutDeadline(t *testing.T) {
+	r := &deadlineRecorder{}
+	require.NoError(t, New().Handle(context.Background(), &transport.Request{}, &transporttest.FakeResponseWriter{}, r))
+	assert.False(t, r.ok, "requests without a deadline must be passed through")
+}
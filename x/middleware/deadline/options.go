@@ -0,0 +1,84 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package deadline
+
+import "time"
+
+// Option customizes the behavior of deadline budget middleware.
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(opts *options) { f(opts) }
+
+type options struct {
+	reserveFraction float64
+	minReserve      time.Duration
+	minBudget       time.Duration
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		reserveFraction: 0.1,
+		minReserve:      time.Millisecond,
+		minBudget:       5 * time.Millisecond,
+	}
+	for _, opt := range opts {
+		opt.apply(&o)
+	}
+	return o
+}
+
+// ReserveFraction is the fraction, between 0 and 1, of the time remaining
+// until the deadline of a request that this hop keeps for itself. The next
+// hop gets a deadline that is earlier by that much, so that this hop still
+// has time to handle its answer, or its failure, before its own caller gives
+// up.
+//
+// Defaults to 0.1.
+func ReserveFraction(f float64) Option {
+	return optionFunc(func(o *options) {
+		o.reserveFraction = f
+	})
+}
+
+// MinReserve is the least time this hop keeps for itself, however little
+// time remains until the deadline.
+//
+// Defaults to one millisecond.
+func MinReserve(d time.Duration) Option {
+	return optionFunc(func(o *options) {
+		o.minReserve = d
+	})
+}
+
+// MinBudget is the least time the next hop must have to handle a request.
+// Requests that would leave it less time are rejected at once with a
+// DeadlineExceeded error, rather than sent to fail later.
+//
+// Defaults to five milliseconds.
+func MinBudget(d time.Duration) Option {
+	return optionFunc(func(o *options) {
+		o.minBudget = d
+	})
+}
@@ -0,0 +1,223 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package faultinject provides middleware that injects faults into requests,
+// so that teams can see how their services and their callers hold up when
+// calls fail or slow down, without a fault-injecting proxy.
+//
+// A fault delays a percentage of the requests it matches, aborts a
+// percentage of them with an error, or both. Faults may be scoped to a
+// procedure, a caller, or both.
+//
+// 	faults := faultinject.New(faultinject.Faults(
+// 		faultinject.Fault{
+// 			Procedure:    "Users::get",
+// 			DelayPercent: 10,
+// 			Delay:        200 * time.Millisecond,
+// 		},
+// 		faultinject.Fault{
+// 			Caller:       "checkout",
+// 			AbortPercent: 1,
+// 			AbortCode:    yarpcerrors.CodeUnavailable,
+// 		},
+// 	))
+// 	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+// 		Name: "myservice",
+// 		InboundMiddleware: yarpc.InboundMiddleware{
+// 			Unary:  faults,
+// 			Oneway: faults,
+// 		},
+// 	})
+//
+// The faults can be changed while the service runs with SetFaults, and
+// removed with SetFaults(nil), to start and stop experiments.
+//
+// The same middleware may be used as outbound middleware to inject faults
+// into the calls a service makes.
+package faultinject
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+var (
+	_ middleware.UnaryInbound   = (*Middleware)(nil)
+	_ middleware.OnewayInbound  = (*Middleware)(nil)
+	_ middleware.UnaryOutbound  = (*Middleware)(nil)
+	_ middleware.OnewayOutbound = (*Middleware)(nil)
+)
+
+// Fault describes a fault to inject into requests.
+type Fault struct {
+	// Procedure is the procedure whose requests the fault applies to, or
+	// empty for every procedure.
+	Procedure string
+
+	// Caller is the caller whose requests the fault applies to, or empty
+	// for every caller.
+	Caller string
+
+	// DelayPercent is the percentage of requests, between 0 and 100, to
+	// delay by Delay before handling them.
+	DelayPercent float64
+	Delay        time.Duration
+
+	// AbortPercent is the percentage of requests, between 0 and 100, to
+	// fail with an error of the AbortCode instead of handling them.
+	// Requests are delayed before they are aborted. The AbortCode defaults
+	// to Unavailable.
+	AbortPercent float64
+	AbortCode    yarpcerrors.Code
+}
+
+// matches returns whether the fault applies to the request.
+func (f Fault) matches(req *transport.Request) bool {
+	return (f.Procedure == "" || f.Procedure == req.Procedure) &&
+		(f.Caller == "" || f.Caller == req.Caller)
+}
+
+// Middleware is middleware which injects faults into requests.
+type Middleware struct {
+	mu     sync.RWMutex
+	faults []Fault
+
+	randMu sync.Mutex
+	rand   *rand.Rand
+}
+
+// New builds fault injection middleware.
+func New(opts ...Option) *Middleware {
+	o := newOptions(opts)
+	return &Middleware{
+		faults: o.faults,
+		rand:   rand.New(rand.NewSource(o.seed)),
+	}
+}
+
+// SetFaults replaces the faults the middleware injects. Only the first fault
+// that matches a request is injected into it, so more specific faults should
+// come first.
+func (m *Middleware) SetFaults(faults []Fault) {
+	faults = append([]Fault(nil), faults...)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.faults = faults
+}
+
+// Faults returns the faults the middleware injects.
+func (m *Middleware) Faults() []Fault {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]Fault(nil), m.faults...)
+}
+
+// Handle implements middleware.UnaryInbound.
+func (m *Middleware) Handle(ctx context.Context, req *transport.Request, w transport.ResponseWriter, h transport.UnaryHandler) error {
+	if err := m.inject(ctx, req); err != nil {
+		return err
+	}
+	return h.Handle(ctx, req, w)
+}
+
+// HandleOneway implements middleware.OnewayInbound.
+func (m *Middleware) HandleOneway(ctx context.Context, req *transport.Request, h transport.OnewayHandler) error {
+	if err := m.inject(ctx, req); err != nil {
+		return err
+	}
+	return h.HandleOneway(ctx, req)
+}
+
+// Call implements middleware.UnaryOutbound.
+func (m *Middleware) Call(ctx context.Context, req *transport.Request, out transport.UnaryOutbound) (*transport.Response, error) {
+	if err := m.inject(ctx, req); err != nil {
+		return nil, err
+	}
+	return out.Call(ctx, req)
+}
+
+// CallOneway implements middleware.OnewayOutbound.
+func (m *Middleware) CallOneway(ctx context.Context, req *transport.Request, out transport.OnewayOutbound) (transport.Ack, error) {
+	if err := m.inject(ctx, req); err != nil {
+		return nil, err
+	}
+	return out.CallOneway(ctx, req)
+}
+
+// inject injects the first fault that matches the request, if any. It
+// returns an error if the request is aborted, or if its context ends while
+// it is delayed.
+func (m *Middleware) inject(ctx context.Context, req *transport.Request) error {
+	f, ok := m.match(req)
+	if !ok {
+		return nil
+	}
+
+	if f.Delay > 0 && m.roll(f.DelayPercent) {
+		timer := time.NewTimer(f.Delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+	if m.roll(f.AbortPercent) {
+		code := f.AbortCode
+		if code == yarpcerrors.CodeOK {
+			code = yarpcerrors.CodeUnavailable
+		}
+		return yarpcerrors.Newf(code,
+			"fault injected into request to procedure %q of service %q from caller %q",
+			req.Procedure, req.Service, req.Caller)
+	}
+	return nil
+}
+
+func (m *Middleware) match(req *transport.Request) (Fault, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, f := range m.faults {
+		if f.matches(req) {
+			return f, true
+		}
+	}
+	return Fault{}, false
+}
+
+// roll returns true for the given percentage of calls.
+func (m *Middleware) roll(percent float64) bool {
+	switch {
+	case percent <= 0:
+		return false
+	case percent >= 100:
+		return true
+	}
+	m.randMu.Lock()
+	defer m.randMu.Unlock()
+	return m.rand.Float64()*100 < percent
+}
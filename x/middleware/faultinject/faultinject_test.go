@@ -0,0 +1,132 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package faultinject
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+type nopHandler struct{}
+
+func (nopHandler) Handle(context.Context, *transport.Request, transport.ResponseWriter) error {
+	return nil
+}
+
+func (nopHandler) HandleOneway(context.Context, *transport.Request) error {
+	return nil
+}
+
+type nopOutbound struct {
+	transport.Outbound
+}
+
+func (nopOutbound) Call(context.Context, *transport.Request) (*transport.Response, error) {
+	return &transport.Response{}, nil
+}
+
+func (nopOutbound) CallOneway(context.Context, *transport.Request) (transport.Ack, error) {
+	return nil, nil
+}
+
+func handle(mw *Middleware, procedure, caller string) error {
+	req := &transport.Request{Service: "users", Procedure: procedure, Caller: caller}
+	return mw.Handle(context.Background(), req, &transporttest.FakeResponseWriter{}, nopHandler{})
+}
+
+func TestAbort(t *testing.T) {
+	mw := New(Faults(
+		Fault{Procedure: "Users::get", Caller: "checkout", AbortPercent: 100, AbortCode: yarpcerrors.CodeInternal},
+		Fault{Procedure: "Users::get", AbortPercent: 100},
+		Fault{Procedure: "Users::healthy"},
+	))
+
+	err := handle(mw, "Users::get", "checkout")
+	require.Error(t, err)
+	assert.Equal(t, yarpcerrors.CodeInternal, yarpcerrors.FromError(err).Code(), "the first matching fault must be injected")
+
+	err = handle(mw, "Users::get", "search")
+	require.Error(t, err)
+	assert.Equal(t, yarpcerrors.CodeUnavailable, yarpcerrors.FromError(err).Code(), "aborts must default to Unavailable")
+
+	assert.NoError(t, handle(mw, "Users::healthy", "search"))
+	assert.NoError(t, handle(mw, "Users::list", "search"), "requests without a fault must be left alone")
+}
+
+func TestAbortPercent(t *testing.T) {
+	mw := New(Faults(Fault{AbortPercent: 25}), Seed(1))
+
+	var aborted int
+	for i := 0; i < 1000; i++ {
+		if handle(mw, "Users::get", "search") != nil {
+			aborted++
+		}
+	}
+	assert.InDelta(t, 250, aborted, 50, "a quarter of the requests must be aborted")
+}
+
+func TestDelay(t *testing.T) {
+	mw := New(Faults(Fault{DelayPercent: 100, Delay: 20 * time.Millisecond}))
+
+	start := time.Now()
+	require.NoError(t, handle(mw, "Users::get", "search"))
+	assert.True(t, time.Since(start) >= 20*time.Millisecond, "the request must be delayed")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	err := mw.HandleOneway(ctx, &transport.Request{Procedure: "Users::get"}, nopHandler{})
+	assert.Equal(t, context.DeadlineExceeded, err, "delays must end with the request's context")
+}
+
+func TestOutbound(t *testing.T) {
+	mw := New(Faults(Fault{Procedure: "Users::get", AbortPercent: 100}))
+	req := &transport.Request{Procedure: "Users::get"}
+
+	_, err := mw.Call(context.Background(), req, nopOutbound{})
+	assert.Error(t, err)
+	_, err = mw.CallOneway(context.Background(), req, nopOutbound{})
+	assert.Error(t, err)
+
+	_, err = mw.Call(context.Background(), &transport.Request{Procedure: "Users::list"}, nopOutbound{})
+	assert.NoError(t, err)
+}
+
+func TestSetFaults(t *testing.T) {
+	mw := New()
+	assert.NoError(t, handle(mw, "Users::get", "search"))
+
+	faults := []Fault{{AbortPercent: 100}}
+	mw.SetFaults(faults)
+	assert.Error(t, handle(mw, "Users::get", "search"), "faults must be injected once set")
+
+	faults[0].AbortPercent = 0
+	assert.Equal(t, []Fault{{AbortPercent: 100}}, mw.Faults(), "the faults must be copied")
+
+	mw.SetFaults(nil)
+	assert.NoError(t, handle(mw, "Users::get", "search"), "faults must stop once removed")
+}
@@ -0,0 +1,224 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package hedge provides outbound middleware that hedges slow requests: when
+// a request takes longer than most requests to its procedure, the middleware
+// sends a backup attempt, returns whichever response arrives first, and
+// cancels the other attempt.
+//
+// 	h := hedge.New(
+// 		hedge.Percentile(0.95),
+// 		hedge.Budget(0.05),
+// 		hedge.Procedures("KeyValue::getValue"),
+// 	)
+// 	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+// 		Name: "myservice",
+// 		OutboundMiddleware: yarpc.OutboundMiddleware{
+// 			Unary: h,
+// 		},
+// 	})
+//
+// The middleware works with the unary outbounds of any transport. Outbounds
+// with a peer list will usually send the backup attempt to a different peer.
+//
+// Hedged requests may be handled twice, so only idempotent procedures should
+// be hedged. The budget caps the extra load hedging adds to the destination.
+package hedge
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+)
+
+// _budgetBurst is the most backup attempts the budget saves up for.
+const _budgetBurst = 10
+
+var _ middleware.UnaryOutbound = (*Middleware)(nil)
+
+// Middleware is unary outbound middleware which sends a backup attempt for
+// requests that take longer than usual.
+type Middleware struct {
+	opts options
+
+	mu        sync.Mutex
+	latencies map[string]*latencies
+	tokens    float64
+}
+
+// New builds hedging middleware.
+func New(opts ...Option) *Middleware {
+	return &Middleware{
+		opts:      newOptions(opts),
+		latencies: make(map[string]*latencies),
+		tokens:    _budgetBurst,
+	}
+}
+
+type attempt struct {
+	res     *transport.Response
+	err     error
+	latency time.Duration
+	cancel  context.CancelFunc
+}
+
+// Call implements middleware.UnaryOutbound.
+func (m *Middleware) Call(ctx context.Context, req *transport.Request, out transport.UnaryOutbound) (*transport.Response, error) {
+	if !m.hedged(req.Procedure) {
+		return out.Call(ctx, req)
+	}
+
+	// The body is read up front so that each attempt can send it.
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+	}
+
+	lat := m.procedure(req.Procedure)
+	m.deposit()
+
+	results := make(chan *attempt, 2)
+	send := func() *attempt {
+		attemptReq := *req
+		attemptReq.Body = bytes.NewReader(body)
+
+		// The context of the attempt that wins is not canceled, since
+		// the response body may still be read with it.
+		attemptCtx, cancel := context.WithCancel(ctx)
+		a := &attempt{cancel: cancel}
+		go func() {
+			start := time.Now()
+			a.res, a.err = out.Call(attemptCtx, &attemptReq)
+			a.latency = time.Since(start)
+			results <- a
+		}()
+		return a
+	}
+
+	primary := send()
+	timer := time.NewTimer(lat.hedgeDelay(m.opts.minDelay, m.opts.maxDelay))
+	defer timer.Stop()
+
+	select {
+	case a := <-results:
+		return m.finish(lat, a)
+	case <-timer.C:
+	}
+
+	if !m.withdraw() {
+		return m.finish(lat, <-results)
+	}
+	backup := send()
+
+	first := <-results
+	if first.err == nil {
+		loser := primary
+		if first == primary {
+			loser = backup
+		}
+		loser.cancel()
+		go discard(results)
+		return m.finish(lat, first)
+	}
+
+	// The first attempt failed, so the other attempt gets its chance.
+	first.cancel()
+	second := <-results
+	if second.err == nil {
+		return m.finish(lat, second)
+	}
+	second.cancel()
+	return m.finish(lat, first)
+}
+
+// finish records the latency of the attempt if it succeeded and returns its
+// result.
+func (m *Middleware) finish(lat *latencies, a *attempt) (*transport.Response, error) {
+	if a.err != nil {
+		a.cancel()
+		return nil, a.err
+	}
+	lat.record(a.latency)
+	return a.res, nil
+}
+
+// discard waits for the losing attempt and closes its response.
+func discard(results <-chan *attempt) {
+	a := <-results
+	a.cancel()
+	if a.res != nil && a.res.Body != nil {
+		a.res.Body.Close()
+	}
+}
+
+// hedged returns whether requests to the procedure are hedged.
+func (m *Middleware) hedged(procedure string) bool {
+	if m.opts.procedures == nil {
+		return true
+	}
+	_, ok := m.opts.procedures[procedure]
+	return ok
+}
+
+// procedure returns the latencies of the procedure.
+func (m *Middleware) procedure(name string) *latencies {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	lat, ok := m.latencies[name]
+	if !ok {
+		lat = newLatencies(m.opts.percentile)
+		m.latencies[name] = lat
+	}
+	return lat
+}
+
+// deposit adds the share of a backup attempt that each request earns to
+// the budget.
+func (m *Middleware) deposit() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.tokens += m.opts.budget
+	if m.tokens > _budgetBurst {
+		m.tokens = _budgetBurst
+	}
+}
+
+// withdraw takes a backup attempt from the budget, and returns whether the
+// budget allowed it.
+func (m *Middleware) withdraw() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.tokens < 1 {
+		return false
+	}
+	m.tokens--
+	return true
+}
@@ -0,0 +1,210 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package hedge
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/testtime"
+)
+
+// fakeOutbound answers the nth call to it with the nth call function, and
+// records the bodies of the requests it receives.
+type fakeOutbound struct {
+	transport.UnaryOutbound
+
+	mu     sync.Mutex
+	calls  []func(context.Context) (*transport.Response, error)
+	bodies []string
+}
+
+func (o *fakeOutbound) Call(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	o.mu.Lock()
+	call := o.calls[len(o.bodies)%len(o.calls)]
+	o.bodies = append(o.bodies, string(body))
+	o.mu.Unlock()
+
+	return call(ctx)
+}
+
+func (o *fakeOutbound) received() []string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]string(nil), o.bodies...)
+}
+
+func respond(body string, after time.Duration) func(context.Context) (*transport.Response, error) {
+	return func(ctx context.Context) (*transport.Response, error) {
+		select {
+		case <-time.After(after):
+			return &transport.Response{Body: ioutil.NopCloser(bytes.NewBufferString(body))}, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func fail(err error, after time.Duration) func(context.Context) (*transport.Response, error) {
+	return func(ctx context.Context) (*transport.Response, error) {
+		time.Sleep(after)
+		return nil, err
+	}
+}
+
+func call(t *testing.T, m *Middleware, out transport.UnaryOutbound, procedure string) (string, error) {
+	res, err := m.Call(context.Background(), &transport.Request{
+		Procedure: procedure,
+		Body:      bytes.NewBufferString("hello"),
+	}, out)
+	if err != nil {
+		return "", err
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	return string(body), nil
+}
+
+func TestHedge(t *testing.T) {
+	canceled := make(chan struct{})
+	out := &fakeOutbound{calls: []func(context.Context) (*transport.Response, error){
+		func(ctx context.Context) (*transport.Response, error) {
+			<-ctx.Done()
+			close(canceled)
+			return nil, ctx.Err()
+		},
+		respond("backup", 0),
+	}}
+
+	body, err := call(t, New(MaxDelay(10*time.Millisecond)), out, "slow")
+	require.NoError(t, err)
+	assert.Equal(t, "backup", body, "the first response must be returned")
+	assert.Equal(t, []string{"hello", "hello"}, out.received(), "both attempts must send the request body")
+
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatal("the losing attempt must be canceled")
+	}
+}
+
+func TestHedgeNotNeeded(t *testing.T) {
+	tests := []struct {
+		desc      string
+		opts      []Option
+		procedure string
+		calls     []func(context.Context) (*transport.Response, error)
+		wantBody  string
+		wantErr   string
+	}{
+		{
+			desc:      "fast response",
+			opts:      []Option{MaxDelay(time.Second)},
+			procedure: "fast",
+			calls:     []func(context.Context) (*transport.Response, error){respond("primary", 0)},
+			wantBody:  "primary",
+		},
+		{
+			desc:      "fast failure",
+			opts:      []Option{MaxDelay(time.Second)},
+			procedure: "fast",
+			calls:     []func(context.Context) (*transport.Response, error){fail(errors.New("great sadness"), 0)},
+			wantErr:   "great sadness",
+		},
+		{
+			desc:      "procedure not hedged",
+			opts:      []Option{MaxDelay(time.Millisecond), Procedures("other")},
+			procedure: "slow",
+			calls:     []func(context.Context) (*transport.Response, error){respond("primary", 20*time.Millisecond)},
+			wantBody:  "primary",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			out := &fakeOutbound{calls: tt.calls}
+			body, err := call(t, New(tt.opts...), out, tt.procedure)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantBody, body)
+			}
+			assert.Len(t, out.received(), 1, "no backup attempt must be sent")
+		})
+	}
+}
+
+func TestHedgeFailedAttempt(t *testing.T) {
+	out := &fakeOutbound{calls: []func(context.Context) (*transport.Response, error){
+		fail(errors.New("great sadness"), 20*time.Millisecond),
+		respond("backup", 40*time.Millisecond),
+	}}
+
+	body, err := call(t, New(MaxDelay(time.Millisecond)), out, "slow")
+	require.NoError(t, err)
+	assert.Equal(t, "backup", body, "the other attempt must be awaited when the first to finish fails")
+}
+
+func TestHedgeBudget(t *testing.T) {
+	out := &fakeOutbound{calls: []func(context.Context) (*transport.Response, error){
+		respond("ok", 20*time.Millisecond),
+	}}
+	m := New(MaxDelay(time.Millisecond), Budget(0))
+
+	for i := 0; i < _budgetBurst+1; i++ {
+		_, err := call(t, m, out, "slow")
+		require.NoError(t, err)
+	}
+	testtime.WaitFor(t, "backup attempts must stop when the budget runs out", func() bool {
+		return len(out.received()) == 2*_budgetBurst+1
+	})
+}
+
+func TestLatencies(t *testing.T) {
+	lat := newLatencies(0.95)
+	for i := 1; i < _minSamples; i++ {
+		lat.record(time.Duration(i) * time.Millisecond)
+	}
+	assert.Equal(t, time.Second, lat.hedgeDelay(0, time.Second),
+		"the maximum delay must be used until enough latencies are recorded")
+
+	for i := _minSamples; i <= 96; i++ {
+		lat.record(time.Duration(i) * time.Millisecond)
+	}
+	assert.Equal(t, 92*time.Millisecond, lat.hedgeDelay(0, time.Second))
+	assert.Equal(t, 200*time.Millisecond, lat.hedgeDelay(200*time.Millisecond, time.Second))
+	assert.Equal(t, 50*time.Millisecond, lat.hedgeDelay(0, 50*time.Millisecond))
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package hedge
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// _window is the number of recent latencies kept for each procedure.
+	_window = 512
+
+	// _minSamples is the number of latencies a procedure must have before
+	// its delay is derived from them.
+	_minSamples = 32
+
+	// _recomputeEvery is how many latencies are recorded between
+	// recomputations of the delay.
+	_recomputeEvery = 16
+)
+
+// latencies tracks the recent latencies of a procedure and the delay after
+// which its requests are hedged.
+type latencies struct {
+	mu sync.Mutex
+
+	percentile float64
+	samples    []time.Duration
+	next       int
+	recorded   int
+
+	delay time.Duration
+}
+
+func newLatencies(percentile float64) *latencies {
+	return &latencies{
+		percentile: percentile,
+		samples:    make([]time.Duration, 0, _window),
+	}
+}
+
+// record adds the latency of a successful attempt.
+func (l *latencies) record(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.samples) < _window {
+		l.samples = append(l.samples, d)
+	} else {
+		l.samples[l.next] = d
+	}
+	l.next = (l.next + 1) % _window
+	l.recorded++
+
+	if len(l.samples) >= _minSamples && (len(l.samples) == _minSamples || l.recorded%_recomputeEvery == 0) {
+		l.delay = l.compute()
+	}
+}
+
+// hedgeDelay returns the configured percentile of the recent latencies,
+// within the given bounds, or the upper bound if there are too few
+// latencies.
+func (l *latencies) hedgeDelay(min, max time.Duration) time.Duration {
+	l.mu.Lock()
+	d, ok := l.delay, len(l.samples) >= _minSamples
+	l.mu.Unlock()
+
+	if !ok || d > max {
+		return max
+	}
+	if d < min {
+		return min
+	}
+	return d
+}
+
+// compute returns the configured percentile of the samples.
+//
+// Must be called with the lock held.
+func (l *latencies) compute() time.Duration {
+	sorted := make([]time.Duration, len(l.samples))
+	copy(sorted, l.samples)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	i := int(l.percentile * float64(len(sorted)))
+	if i >= len(sorted) {
+		i = len(sorted) - 1
+	}
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i]
+}
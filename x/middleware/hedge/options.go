@@ -0,0 +1,111 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package hedge
+
+import "time"
+
+// Option customizes the behavior of hedging middleware.
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(opts *options) { f(opts) }
+
+type options struct {
+	percentile float64
+	minDelay   time.Duration
+	maxDelay   time.Duration
+	budget     float64
+	procedures map[string]struct{}
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		percentile: 0.95,
+		maxDelay:   time.Second,
+		budget:     0.1,
+	}
+	for _, opt := range opts {
+		opt.apply(&o)
+	}
+	return o
+}
+
+// Percentile sends the backup attempt of a request once the request has
+// taken longer than the given percentile, between 0 and 1, of the recent
+// latencies of its procedure.
+//
+// Defaults to 0.95, which hedges the slowest 5% of requests.
+func Percentile(p float64) Option {
+	return optionFunc(func(o *options) {
+		o.percentile = p
+	})
+}
+
+// MinDelay is the least time to wait before sending the backup attempt of a
+// request, however fast the procedure usually is.
+//
+// Defaults to zero.
+func MinDelay(d time.Duration) Option {
+	return optionFunc(func(o *options) {
+		o.minDelay = d
+	})
+}
+
+// MaxDelay is the most time to wait before sending the backup attempt of a
+// request, however slow the procedure usually is. It is also the delay used
+// until enough latencies of the procedure have been observed.
+//
+// Defaults to one second.
+func MaxDelay(d time.Duration) Option {
+	return optionFunc(func(o *options) {
+		o.maxDelay = d
+	})
+}
+
+// Budget caps the number of backup attempts to the given ratio of requests,
+// so that hedging cannot add more than that fraction of load to the
+// destination, even when it is slow across the board.
+//
+// Defaults to 0.1, which allows one backup attempt for every ten requests.
+func Budget(ratio float64) Option {
+	return optionFunc(func(o *options) {
+		o.budget = ratio
+	})
+}
+
+// Procedures restricts hedging to the named procedures. Requests to other
+// procedures are passed through unchanged.
+//
+// Defaults to hedging requests to every procedure. Since a hedged request
+// may be handled twice, only idempotent procedures should be hedged.
+func Procedures(names ...string) Option {
+	return optionFunc(func(o *options) {
+		if o.procedures == nil {
+			o.procedures = make(map[string]struct{}, len(names))
+		}
+		for _, name := range names {
+			o.procedures[name] = struct{}{}
+		}
+	})
+}
@@ -0,0 +1,248 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package idempotency provides inbound middleware that deduplicates requests
+// carrying the same idempotency key, so that requests which are delivered
+// more than once, like retried requests and oneway requests over
+// at-least-once transports, are only handled once.
+//
+// The outcome of the first request with a key is stored for a while. Later
+// requests with the same key, to the same procedure and from the same
+// caller, are answered with the stored outcome instead of being handled
+// again, with the ReplayedHeader set on the response. Duplicates that arrive
+// while the first request is still being handled wait for its outcome.
+//
+// 	dedup := idempotency.New(idempotency.NewMemoryStore(), idempotency.TTL(10*time.Minute))
+// 	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+// 		Name: "myservice",
+// 		InboundMiddleware: yarpc.InboundMiddleware{
+// 			Unary:  dedup,
+// 			Oneway: dedup,
+// 		},
+// 	})
+//
+// Only successes and application errors are stored. Requests that fail with
+// an error are handled again when they are retried, since the error may be
+// temporary.
+//
+// Requests without an idempotency key are passed through unchanged.
+package idempotency
+
+import (
+	"context"
+	"sync"
+
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultHeader is the application header that carries the idempotency
+	// key of a request by default.
+	DefaultHeader = "idempotency-key"
+
+	// ReplayedHeader is the application header set on responses that were
+	// replayed from the outcome of an earlier request.
+	ReplayedHeader = "idempotency-replayed"
+)
+
+var (
+	_ middleware.UnaryInbound  = (*Middleware)(nil)
+	_ middleware.OnewayInbound = (*Middleware)(nil)
+)
+
+// Middleware is inbound middleware which deduplicates requests with the same
+// idempotency key.
+type Middleware struct {
+	store Store
+	opts  options
+
+	mu       sync.Mutex
+	inFlight map[string]chan struct{}
+}
+
+// New builds idempotency middleware that stores the outcomes of requests in
+// the given store.
+func New(store Store, opts ...Option) *Middleware {
+	return &Middleware{
+		store:    store,
+		opts:     newOptions(opts),
+		inFlight: make(map[string]chan struct{}),
+	}
+}
+
+// Handle implements middleware.UnaryInbound.
+func (m *Middleware) Handle(ctx context.Context, req *transport.Request, w transport.ResponseWriter, h transport.UnaryHandler) error {
+	key, ok := m.key(req)
+	if !ok {
+		return h.Handle(ctx, req, w)
+	}
+
+	outcome, release, err := m.lead(ctx, key)
+	if err != nil {
+		return err
+	}
+	if outcome != nil {
+		return replay(outcome, w)
+	}
+	defer release()
+
+	rw := &responseWriter{ResponseWriter: w, headers: make(map[string]string)}
+	if err := h.Handle(ctx, req, rw); err != nil {
+		return err
+	}
+	m.put(ctx, key, &Outcome{
+		Headers:          rw.headers,
+		Body:             rw.body,
+		ApplicationError: rw.applicationError,
+	})
+	return nil
+}
+
+// HandleOneway implements middleware.OnewayInbound.
+func (m *Middleware) HandleOneway(ctx context.Context, req *transport.Request, h transport.OnewayHandler) error {
+	key, ok := m.key(req)
+	if !ok {
+		return h.HandleOneway(ctx, req)
+	}
+
+	outcome, release, err := m.lead(ctx, key)
+	if err != nil || outcome != nil {
+		return err
+	}
+	defer release()
+
+	if err := h.HandleOneway(ctx, req); err != nil {
+		return err
+	}
+	m.put(ctx, key, &Outcome{})
+	return nil
+}
+
+// key returns the key under which the outcome of the request is stored, and
+// whether the request has an idempotency key at all. Keys are scoped to the
+// caller and procedure, so that callers cannot collide with each other.
+func (m *Middleware) key(req *transport.Request) (string, bool) {
+	key, ok := req.Headers.Get(m.opts.header)
+	if !ok || key == "" {
+		return "", false
+	}
+	return req.Caller + "/" + req.Procedure + "/" + key, true
+}
+
+// lead makes this request the one that handles the key, and returns a
+// function to call once its outcome is stored. If the outcome of an earlier
+// request with the key is stored, it returns that outcome instead. While
+// another request with the key is in flight, it waits for its outcome.
+func (m *Middleware) lead(ctx context.Context, key string) (*Outcome, func(), error) {
+	for {
+		m.mu.Lock()
+		done, ok := m.inFlight[key]
+		if !ok {
+			done = make(chan struct{})
+			m.inFlight[key] = done
+		}
+		m.mu.Unlock()
+
+		if ok {
+			select {
+			case <-done:
+				continue
+			case <-ctx.Done():
+				return nil, nil, ctx.Err()
+			}
+		}
+
+		release := func() {
+			m.mu.Lock()
+			delete(m.inFlight, key)
+			m.mu.Unlock()
+			close(done)
+		}
+		outcome, err := m.store.Get(ctx, key)
+		if err != nil {
+			release()
+			return nil, nil, yarpcerrors.Newf(yarpcerrors.CodeUnavailable,
+				"failed to look up the outcome of idempotency key %q: %v", key, err)
+		}
+		if outcome != nil {
+			release()
+			return outcome, nil, nil
+		}
+		return nil, release, nil
+	}
+}
+
+// put stores the outcome of a request. The request was handled, so failing
+// to store its outcome does not fail it.
+func (m *Middleware) put(ctx context.Context, key string, outcome *Outcome) {
+	if err := m.store.Put(ctx, key, outcome, m.opts.ttl); err != nil {
+		m.opts.logger.Error("failed to store the outcome of a request, its duplicates will be handled again",
+			zap.String("key", key), zap.Error(err))
+	}
+}
+
+// replay answers a request with a stored outcome.
+func replay(outcome *Outcome, w transport.ResponseWriter) error {
+	headers := transport.NewHeadersWithCapacity(len(outcome.Headers) + 1)
+	for k, v := range outcome.Headers {
+		headers = headers.With(k, v)
+	}
+	w.AddHeaders(headers.With(ReplayedHeader, "true"))
+	if outcome.ApplicationError {
+		w.SetApplicationError()
+	}
+	_, err := w.Write(outcome.Body)
+	return err
+}
+
+// responseWriter records the response it writes.
+type responseWriter struct {
+	transport.ResponseWriter
+
+	headers          map[string]string
+	body             []byte
+	applicationError bool
+}
+
+func (w *responseWriter) AddHeaders(h transport.Headers) {
+	for k, v := range h.Items() {
+		w.headers[k] = v
+	}
+	w.ResponseWriter.AddHeaders(h)
+}
+
+func (w *responseWriter) SetApplicationError() {
+	w.applicationError = true
+	w.ResponseWriter.SetApplicationError()
+}
+
+func (w *responseWriter) SetApplicationErrorMeta(meta *transport.ApplicationErrorMeta) {
+	if setter, ok := w.ResponseWriter.(transport.ApplicationErrorMetaSetter); ok {
+		setter.SetApplicationErrorMeta(meta)
+	}
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	w.body = append(w.body, p...)
+	return w.ResponseWriter.Write(p)
+}
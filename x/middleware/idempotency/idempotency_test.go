@@ -0,0 +1,227 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// countingHandler answers with the number of requests it handled, and fails
+// with the error it is given.
+type countingHandler struct {
+	mu      sync.Mutex
+	handled int
+	err     error
+	started chan struct{}
+	block   chan struct{}
+}
+
+func (h *countingHandler) handle() (int, error) {
+	if h.started != nil {
+		h.started <- struct{}{}
+	}
+	if h.block != nil {
+		<-h.block
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.handled++
+	return h.handled, h.err
+}
+
+func (h *countingHandler) Handle(ctx context.Context, req *transport.Request, w transport.ResponseWriter) error {
+	n, err := h.handle()
+	if err != nil {
+		return err
+	}
+	w.AddHeaders(transport.NewHeaders().With("count", fmt.Sprint(n)))
+	if req.Procedure == "fail" {
+		w.SetApplicationError()
+	}
+	_, err = fmt.Fprintf(w, "response %d", n)
+	return err
+}
+
+func (h *countingHandler) HandleOneway(ctx context.Context, req *transport.Request) error {
+	_, err := h.handle()
+	return err
+}
+
+func (h *countingHandler) count() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.handled
+}
+
+func request(procedure, caller, key string) *transport.Request {
+	headers := transport.NewHeaders()
+	if key != "" {
+		headers = headers.With("Idempotency-Key", key)
+	}
+	return &transport.Request{Procedure: procedure, Caller: caller, Headers: headers}
+}
+
+func TestDeduplicate(t *testing.T) {
+	mw := New(NewMemoryStore())
+	h := &countingHandler{}
+
+	first := &transporttest.FakeResponseWriter{}
+	require.NoError(t, mw.Handle(context.Background(), request("get", "caller", "abc"), first, h))
+	assert.Equal(t, "response 1", first.Body.String())
+
+	dup := &transporttest.FakeResponseWriter{}
+	require.NoError(t, mw.Handle(context.Background(), request("get", "caller", "abc"), dup, h))
+	assert.Equal(t, "response 1", dup.Body.String(), "duplicates must get the stored response")
+	assert.Equal(t, transport.NewHeaders().With("count", "1").With(ReplayedHeader, "true"), dup.Headers)
+	assert.Equal(t, 1, h.count())
+
+	for _, req := range []*transport.Request{
+		request("get", "caller", "def"),
+		request("get", "other", "abc"),
+		request("put", "caller", "abc"),
+		request("get", "caller", ""),
+		request("get", "caller", ""),
+	} {
+		require.NoError(t, mw.Handle(context.Background(), req, &transporttest.FakeResponseWriter{}, h))
+	}
+	assert.Equal(t, 6, h.count(), "keys must be scoped to the caller and procedure")
+}
+
+func TestDeduplicateApplicationError(t *testing.T) {
+	mw := New(NewMemoryStore())
+	h := &countingHandler{}
+
+	require.NoError(t, mw.Handle(context.Background(), request("fail", "caller", "abc"), &transporttest.FakeResponseWriter{}, h))
+	dup := &transporttest.FakeResponseWriter{}
+	require.NoError(t, mw.Handle(context.Background(), request("fail", "caller", "abc"), dup, h))
+	assert.True(t, dup.IsApplicationError, "application errors must be replayed")
+	assert.Equal(t, 1, h.count())
+}
+
+func TestErrorsAreNotStored(t *testing.T) {
+	mw := New(NewMemoryStore())
+	h := &countingHandler{err: errors.New("great sadness")}
+
+	assert.Error(t, mw.Handle(context.Background(), request("get", "caller", "abc"), &transporttest.FakeResponseWriter{}, h))
+	h.err = nil
+	res := &transporttest.FakeResponseWriter{}
+	require.NoError(t, mw.Handle(context.Background(), request("get", "caller", "abc"), res, h))
+	assert.Equal(t, "response 2", res.Body.String(), "failed requests must be handled again")
+}
+
+func TestDeduplicateOneway(t *testing.T) {
+	mw := New(NewMemoryStore(), Header("x-dedup"))
+	h := &countingHandler{}
+	req := &transport.Request{Procedure: "notify", Headers: transport.NewHeaders().With("x-dedup", "abc")}
+
+	require.NoError(t, mw.HandleOneway(context.Background(), req, h))
+	require.NoError(t, mw.HandleOneway(context.Background(), req, h))
+	assert.Equal(t, 1, h.count())
+}
+
+func TestConcurrentDuplicates(t *testing.T) {
+	mw := New(NewMemoryStore())
+	h := &countingHandler{block: make(chan struct{})}
+
+	var wg sync.WaitGroup
+	bodies := make([]string, 5)
+	for i := range bodies {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			res := &transporttest.FakeResponseWriter{}
+			assert.NoError(t, mw.Handle(context.Background(), request("get", "caller", "abc"), res, h))
+			bodies[i] = res.Body.String()
+		}(i)
+	}
+	close(h.block)
+	wg.Wait()
+
+	assert.Equal(t, 1, h.count(), "duplicates in flight must wait for the first request")
+	for _, body := range bodies {
+		assert.Equal(t, "response 1", body)
+	}
+}
+
+func TestDuplicateWaitEndsWithContext(t *testing.T) {
+	mw := New(NewMemoryStore())
+	h := &countingHandler{started: make(chan struct{}, 1), block: make(chan struct{})}
+	defer close(h.block)
+
+	go mw.Handle(context.Background(), request("get", "caller", "abc"), &transporttest.FakeResponseWriter{}, h)
+	<-h.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := mw.Handle(ctx, request("get", "caller", "abc"), &transporttest.FakeResponseWriter{}, &countingHandler{})
+	assert.Equal(t, context.DeadlineExceeded, err)
+}
+
+type failingStore struct{ Store }
+
+func (failingStore) Get(context.Context, string) (*Outcome, error) {
+	return nil, errors.New("store unavailable")
+}
+
+func TestStoreError(t *testing.T) {
+	mw := New(failingStore{})
+	err := mw.Handle(context.Background(), request("get", "caller", "abc"), &transporttest.FakeResponseWriter{}, &countingHandler{})
+	require.Error(t, err)
+	assert.Equal(t, yarpcerrors.CodeUnavailable, yarpcerrors.FromError(err).Code())
+}
+
+func TestMemoryStoreExpires(t *testing.T) {
+	fake := clock.NewFake()
+	store := NewMemoryStore()
+	store.clock = fake
+	ctx := context.Background()
+
+	require.NoError(t, store.Put(ctx, "a", &Outcome{Body: []byte("a")}, time.Minute))
+	outcome, err := store.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, &Outcome{Body: []byte("a")}, outcome)
+
+	fake.Add(time.Minute)
+	outcome, err = store.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.Nil(t, outcome, "outcomes must expire")
+	assert.Equal(t, 0, store.Len())
+
+	// The outcome put after these is the one that triggers a sweep.
+	for i := 0; i < _sweepInterval-2; i++ {
+		require.NoError(t, store.Put(ctx, fmt.Sprint(i), &Outcome{}, time.Minute))
+	}
+	fake.Add(time.Minute)
+	require.NoError(t, store.Put(ctx, "b", &Outcome{}, time.Minute))
+	assert.Equal(t, 1, store.Len(), "expired outcomes must be swept")
+}
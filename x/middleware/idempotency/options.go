@@ -0,0 +1,83 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package idempotency
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Option customizes the behavior of idempotency middleware.
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(opts *options) { f(opts) }
+
+type options struct {
+	header string
+	ttl    time.Duration
+	logger *zap.Logger
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		header: DefaultHeader,
+		ttl:    time.Hour,
+		logger: zap.NewNop(),
+	}
+	for _, opt := range opts {
+		opt.apply(&o)
+	}
+	return o
+}
+
+// Header is the application header that carries the idempotency key of a
+// request.
+//
+// Defaults to DefaultHeader.
+func Header(name string) Option {
+	return optionFunc(func(o *options) {
+		o.header = name
+	})
+}
+
+// TTL is how long the outcome of a request is kept to answer its duplicates.
+// It should be longer than the time over which requests are retried.
+//
+// Defaults to one hour.
+func TTL(d time.Duration) Option {
+	return optionFunc(func(o *options) {
+		o.ttl = d
+	})
+}
+
+// Logger logs the outcomes that could not be stored.
+//
+// Defaults to not logging.
+func Logger(logger *zap.Logger) Option {
+	return optionFunc(func(o *options) {
+		o.logger = logger
+	})
+}
@@ -0,0 +1,128 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/yarpc/internal/clock"
+)
+
+// _sweepInterval is the number of outcomes stored in a MemoryStore between
+// sweeps of the expired outcomes.
+const _sweepInterval = 1024
+
+// Outcome is the outcome of a request, which is replayed for its duplicates.
+type Outcome struct {
+	// Headers are the application headers of the response.
+	Headers map[string]string
+
+	// Body is the body of the response.
+	Body []byte
+
+	// ApplicationError is whether the response is an application error.
+	ApplicationError bool
+}
+
+// Store stores the outcomes of requests by key.
+//
+// Stores MUST be thread-safe. A store shared by the instances of a service,
+// like one backed by Redis or Memcached, deduplicates requests delivered to
+// different instances.
+type Store interface {
+	// Get returns the outcome stored for the key, or nil if there is none
+	// or it expired.
+	Get(ctx context.Context, key string) (*Outcome, error)
+
+	// Put stores the outcome for the key for the given duration.
+	Put(ctx context.Context, key string, outcome *Outcome, ttl time.Duration) error
+}
+
+// MemoryStore is a Store that keeps outcomes in memory. It only deduplicates
+// requests delivered to the same process.
+type MemoryStore struct {
+	clock clock.Clock
+
+	mu       sync.Mutex
+	outcomes map[string]storedOutcome
+	puts     int
+}
+
+type storedOutcome struct {
+	outcome *Outcome
+	expires time.Time
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+// NewMemoryStore builds an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		clock:    clock.NewReal(),
+		outcomes: make(map[string]storedOutcome),
+	}
+}
+
+// Get implements Store.
+func (s *MemoryStore) Get(ctx context.Context, key string) (*Outcome, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.outcomes[key]
+	if !ok {
+		return nil, nil
+	}
+	if !s.clock.Now().Before(stored.expires) {
+		delete(s.outcomes, key)
+		return nil, nil
+	}
+	return stored.outcome, nil
+}
+
+// Put implements Store.
+func (s *MemoryStore) Put(ctx context.Context, key string, outcome *Outcome, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+	s.outcomes[key] = storedOutcome{outcome: outcome, expires: now.Add(ttl)}
+
+	// Outcomes that are never asked for again are swept from time to time.
+	s.puts++
+	if s.puts%_sweepInterval == 0 {
+		for k, stored := range s.outcomes {
+			if !now.Before(stored.expires) {
+				delete(s.outcomes, k)
+			}
+		}
+	}
+	return nil
+}
+
+// Len returns the number of outcomes in the store, including those that
+// expired but were not removed yet.
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.outcomes)
+}
@@ -0,0 +1,183 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package inspect provides inbound middleware that lets an inspector, like
+// a web application firewall or another security scanner, look at requests
+// before they are handled and reject them.
+//
+// The inspector sees the headers of requests and the start of their bodies,
+// up to MaxBodyBytes, so that requests are never buffered whole.
+//
+// 	waf := inspect.New(inspect.InspectorFunc(func(ctx context.Context, req *inspect.Request) error {
+// 		if bytes.Contains(req.Body, []byte("<script")) {
+// 			return errors.New("script injection")
+// 		}
+// 		return nil
+// 	}))
+// 	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+// 		Name: "myservice",
+// 		InboundMiddleware: yarpc.InboundMiddleware{
+// 			Unary:  waf,
+// 			Oneway: waf,
+// 			Stream: waf,
+// 		},
+// 	})
+//
+// Requests the inspector rejects fail with a PermissionDenied error.
+package inspect
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+var (
+	_ middleware.UnaryInbound  = (*Middleware)(nil)
+	_ middleware.OnewayInbound = (*Middleware)(nil)
+	_ middleware.StreamInbound = (*Middleware)(nil)
+)
+
+// Request is the part of a request an inspector sees.
+type Request struct {
+	Caller    string
+	Service   string
+	Transport string
+	Encoding  transport.Encoding
+	Procedure string
+	Headers   transport.Headers
+
+	// Body is the start of the body of the request, up to MaxBodyBytes. It
+	// must not be modified. Streaming requests have no body.
+	Body []byte
+
+	// Truncated is whether the body is longer than Body.
+	Truncated bool
+}
+
+// Inspector inspects requests before they are handled.
+type Inspector interface {
+	// Inspect returns an error to reject the request. The error is sent to
+	// the caller as the message of a PermissionDenied error.
+	Inspect(ctx context.Context, req *Request) error
+}
+
+// InspectorFunc adapts a function into an Inspector.
+type InspectorFunc func(context.Context, *Request) error
+
+// Inspect calls the function.
+func (f InspectorFunc) Inspect(ctx context.Context, req *Request) error {
+	return f(ctx, req)
+}
+
+// Middleware is inbound middleware which inspects requests.
+type Middleware struct {
+	inspector Inspector
+	opts      options
+}
+
+// New builds middleware that inspects requests with the given inspector.
+func New(inspector Inspector, opts ...Option) *Middleware {
+	return &Middleware{inspector: inspector, opts: newOptions(opts)}
+}
+
+// Handle implements middleware.UnaryInbound.
+func (m *Middleware) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter, h transport.UnaryHandler) error {
+	req, err := m.inspect(ctx, req)
+	if err != nil {
+		return err
+	}
+	return h.Handle(ctx, req, resw)
+}
+
+// HandleOneway implements middleware.OnewayInbound.
+func (m *Middleware) HandleOneway(ctx context.Context, req *transport.Request, h transport.OnewayHandler) error {
+	req, err := m.inspect(ctx, req)
+	if err != nil {
+		return err
+	}
+	return h.HandleOneway(ctx, req)
+}
+
+// HandleStream implements middleware.StreamInbound. Only the headers of
+// streams are inspected.
+func (m *Middleware) HandleStream(s *transport.ServerStream, h transport.StreamHandler) error {
+	meta := s.Request().Meta
+	if err := m.check(s.Context(), &Request{
+		Caller:    meta.Caller,
+		Service:   meta.Service,
+		Transport: meta.Transport,
+		Encoding:  meta.Encoding,
+		Procedure: meta.Procedure,
+		Headers:   meta.Headers,
+	}); err != nil {
+		return err
+	}
+	return h.HandleStream(s)
+}
+
+// inspect inspects the request, and returns a copy of it whose body still
+// starts with the bytes read for the inspector.
+func (m *Middleware) inspect(ctx context.Context, req *transport.Request) (*transport.Request, error) {
+	ireq := &Request{
+		Caller:    req.Caller,
+		Service:   req.Service,
+		Transport: req.Transport,
+		Encoding:  req.Encoding,
+		Procedure: req.Procedure,
+		Headers:   req.Headers,
+	}
+
+	r := *req
+	if m.opts.maxBodyBytes > 0 && req.Body != nil {
+		// One more byte than the inspector sees tells whether the body is
+		// longer.
+		buf := make([]byte, m.opts.maxBodyBytes+1)
+		n, err := io.ReadFull(req.Body, buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return nil, err
+		}
+		buf = buf[:n]
+		r.Body = io.MultiReader(bytes.NewReader(buf), req.Body)
+
+		ireq.Body = buf
+		if n > m.opts.maxBodyBytes {
+			ireq.Body = buf[:m.opts.maxBodyBytes]
+			ireq.Truncated = true
+		}
+	}
+
+	if err := m.check(ctx, ireq); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func (m *Middleware) check(ctx context.Context, req *Request) error {
+	if err := m.inspector.Inspect(ctx, req); err != nil {
+		return yarpcerrors.Newf(yarpcerrors.CodePermissionDenied,
+			"request to procedure %q of service %q rejected by inspection: %v", req.Procedure, req.Service, err)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package inspect
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// bodyHandler records the bodies of the requests it handles.
+type bodyHandler struct {
+	body    string
+	handled bool
+}
+
+func (h *bodyHandler) Handle(_ context.Context, req *transport.Request, _ transport.ResponseWriter) error {
+	return h.HandleOneway(nil, req)
+}
+
+func (h *bodyHandler) HandleOneway(_ context.Context, req *transport.Request) error {
+	body, err := ioutil.ReadAll(req.Body)
+	h.body, h.handled = string(body), true
+	return err
+}
+
+func (h *bodyHandler) HandleStream(*transport.ServerStream) error {
+	h.handled = true
+	return nil
+}
+
+// recordingInspector records the requests it inspects, and rejects those
+// whose body contains a forbidden string.
+type recordingInspector struct {
+	reqs []Request
+}
+
+func (i *recordingInspector) Inspect(_ context.Context, req *Request) error {
+	i.reqs = append(i.reqs, *req)
+	if bytes.Contains(req.Body, []byte("DROP TABLE")) {
+		return errors.New("SQL injection")
+	}
+	if v, _ := req.Headers.Get("user-agent"); v == "sqlmap" {
+		return errors.New("scanner")
+	}
+	return nil
+}
+
+func TestInspect(t *testing.T) {
+	inspector := &recordingInspector{}
+	mw := New(inspector, MaxBodyBytes(8))
+
+	tests := []struct {
+		desc      string
+		body      string
+		wantBody  string
+		truncated bool
+	}{
+		{desc: "short body", body: "hello", wantBody: "hello"},
+		{desc: "exact body", body: "12345678", wantBody: "12345678"},
+		{desc: "long body", body: "hello world", wantBody: "hello wo", truncated: true},
+		{desc: "empty body", body: "", wantBody: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			inspector.reqs = nil
+			h := &bodyHandler{}
+			req := &transport.Request{Procedure: "echo", Body: strings.NewReader(tt.body)}
+			require.NoError(t, mw.Handle(context.Background(), req, &transporttest.FakeResponseWriter{}, h))
+
+			require.Len(t, inspector.reqs, 1)
+			assert.Equal(t, tt.wantBody, string(inspector.reqs[0].Body))
+			assert.Equal(t, tt.truncated, inspector.reqs[0].Truncated)
+			assert.Equal(t, tt.body, h.body, "handlers must receive the whole body")
+		})
+	}
+}
+
+func TestReject(t *testing.T) {
+	inspector := &recordingInspector{}
+	mw := New(inspector)
+	h := &bodyHandler{}
+
+	err := mw.HandleOneway(context.Background(), &transport.Request{
+		Service:   "users",
+		Procedure: "find",
+		Body:      strings.NewReader("'; DROP TABLE users; --"),
+	}, h)
+	require.Error(t, err)
+	assert.Equal(t, yarpcerrors.CodePermissionDenied, yarpcerrors.FromError(err).Code())
+	assert.Equal(t, `request to procedure "find" of service "users" rejected by inspection: SQL injection`,
+		yarpcerrors.FromError(err).Message())
+	assert.False(t, h.handled, "rejected requests must not be handled")
+
+	stream, err := transport.NewServerStream(fakeStream{meta: &transport.RequestMeta{
+		Procedure: "watch",
+		Headers:   transport.NewHeaders().With("user-agent", "sqlmap"),
+	}})
+	require.NoError(t, err)
+	err = mw.HandleStream(stream, h)
+	assert.Equal(t, yarpcerrors.CodePermissionDenied, yarpcerrors.FromError(err).Code())
+	assert.False(t, h.handled)
+	assert.Nil(t, inspector.reqs[1].Body, "streams must be inspected without a body")
+}
+
+func TestNoBody(t *testing.T) {
+	inspector := &recordingInspector{}
+	mw := New(inspector, MaxBodyBytes(0))
+	h := &bodyHandler{}
+
+	req := &transport.Request{Body: strings.NewReader("DROP TABLE")}
+	require.NoError(t, mw.Handle(context.Background(), req, &transporttest.FakeResponseWriter{}, h))
+	assert.Nil(t, inspector.reqs[0].Body)
+	assert.Equal(t, "DROP TABLE", h.body)
+}
+
+type fakeStream struct {
+	transport.Stream
+
+	meta *transport.RequestMeta
+}
+
+func (s fakeStream) Context() context.Context { return context.Background() }
+
+func (s fakeStream) Request() *transport.StreamRequest {
+	return &transport.StreamRequest{Meta: s.meta}
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package jwt
+
+import (
+	"errors"
+	"time"
+)
+
+// Config describes JWT authentication middleware, for services that load
+// their middleware settings from configuration files.
+//
+// 	jwt:
+// 	  header: authorization
+// 	  jwks: https://auth.example.com/.well-known/jwks.json
+// 	  refresh: 1h
+// 	  issuer: https://auth.example.com/
+// 	  audience: keyvalue
+// 	  scopes:
+// 	    KeyValue::setValue: [keyvalue:write]
+type Config struct {
+	Header   string              `config:"header" yaml:"header"`
+	JWKS     string              `config:"jwks" yaml:"jwks"`
+	Refresh  time.Duration       `config:"refresh" yaml:"refresh"`
+	Issuer   string              `config:"issuer" yaml:"issuer"`
+	Audience string              `config:"audience" yaml:"audience"`
+	Leeway   time.Duration       `config:"leeway" yaml:"leeway"`
+	Scopes   map[string][]string `config:"scopes" yaml:"scopes"`
+}
+
+// NewFromConfig builds JWT authentication middleware from configuration.
+// Additional options are applied after the configuration.
+func NewFromConfig(cfg Config, opts ...Option) (*Middleware, error) {
+	if cfg.JWKS == "" {
+		return nil, errors.New(`"jwks" is required`)
+	}
+
+	cfgOpts := []Option{
+		JWKS(cfg.JWKS, cfg.Refresh, nil),
+		Issuer(cfg.Issuer),
+		Audience(cfg.Audience),
+		Leeway(cfg.Leeway),
+	}
+	if cfg.Header != "" {
+		cfgOpts = append(cfgOpts, Header(cfg.Header))
+	}
+	for procedure, scopes := range cfg.Scopes {
+		cfgOpts = append(cfgOpts, ProcedureScopes(procedure, scopes...))
+	}
+	return New(append(cfgOpts, opts...)...), nil
+}
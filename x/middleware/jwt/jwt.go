@@ -0,0 +1,114 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package jwt provides inbound middleware that authenticates requests with
+// JSON Web Tokens, and optionally authorizes them with the scopes the tokens
+// grant.
+//
+// 	auth := jwt.New(
+// 		jwt.JWKS("https://auth.example.com/.well-known/jwks.json", time.Hour, nil),
+// 		jwt.Issuer("https://auth.example.com/"),
+// 		jwt.Audience("keyvalue"),
+// 		jwt.ProcedureScopes("KeyValue::setValue", "keyvalue:write"),
+// 	)
+// 	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+// 		Name: "keyvalue",
+// 		InboundMiddleware: yarpc.InboundMiddleware{
+// 			Unary:  auth,
+// 			Oneway: auth,
+// 		},
+// 	})
+//
+// Tokens are read from the authorization header by default, and must be
+// signed with RSA or ECDSA keys (RS256, RS384, RS512, ES256, ES384 or
+// ES512). Requests without a valid token are rejected with an
+// Unauthenticated error, and requests whose token lacks the scopes of the
+// procedure with a PermissionDenied error. Handlers can read the claims of
+// the token with ClaimsFromContext.
+package jwt
+
+import (
+	"context"
+	"strings"
+
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+var (
+	_ middleware.UnaryInbound  = (*Middleware)(nil)
+	_ middleware.OnewayInbound = (*Middleware)(nil)
+)
+
+// Middleware is inbound middleware which authenticates requests with JSON
+// Web Tokens.
+type Middleware struct {
+	opts options
+}
+
+// New builds JWT authentication middleware.
+func New(opts ...Option) *Middleware {
+	return &Middleware{opts: newOptions(opts)}
+}
+
+// Handle implements middleware.UnaryInbound.
+func (m *Middleware) Handle(ctx context.Context, req *transport.Request, w transport.ResponseWriter, h transport.UnaryHandler) error {
+	ctx, err := m.authenticate(ctx, req)
+	if err != nil {
+		return err
+	}
+	return h.Handle(ctx, req, w)
+}
+
+// HandleOneway implements middleware.OnewayInbound.
+func (m *Middleware) HandleOneway(ctx context.Context, req *transport.Request, h transport.OnewayHandler) error {
+	ctx, err := m.authenticate(ctx, req)
+	if err != nil {
+		return err
+	}
+	return h.HandleOneway(ctx, req)
+}
+
+// authenticate verifies the token of the request and checks that it grants
+// the scopes of the procedure, returning a context with its claims.
+func (m *Middleware) authenticate(ctx context.Context, req *transport.Request) (context.Context, error) {
+	token, ok := req.Headers.Get(m.opts.header)
+	if !ok || token == "" {
+		return nil, yarpcerrors.Newf(yarpcerrors.CodeUnauthenticated, "missing token in header %q", m.opts.header)
+	}
+	if len(token) > len("bearer ") && strings.EqualFold(token[:len("bearer ")], "bearer ") {
+		token = token[len("bearer "):]
+	}
+
+	claims, err := verify(ctx, token, &m.opts)
+	if err != nil {
+		return nil, yarpcerrors.Newf(yarpcerrors.CodeUnauthenticated, "invalid token: %v", err)
+	}
+
+	granted := claims.Scopes()
+	for _, scope := range m.opts.scopes[req.Procedure] {
+		if !contains(granted, scope) {
+			return nil, yarpcerrors.Newf(yarpcerrors.CodePermissionDenied,
+				"token does not grant scope %q required by procedure %q", scope, req.Procedure)
+		}
+	}
+	return withClaims(ctx, claims), nil
+}
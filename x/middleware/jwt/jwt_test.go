@@ -0,0 +1,295 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+var (
+	rsaKey, _ = rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _  = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+)
+
+func encode(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// sign returns a token with the given claims, signed with the key.
+func sign(t *testing.T, alg, kid string, key crypto.Signer, claims map[string]interface{}) string {
+	signed := encode(map[string]string{"alg": alg, "kid": kid}) + "." + encode(claims)
+	digest := sha256.Sum256([]byte(signed))
+
+	var sig []byte
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		var err error
+		sig, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+		require.NoError(t, err)
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		require.NoError(t, err)
+		sig = append(pad(r, 32), pad(s, 32)...)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+// pad returns the big-endian bytes of the integer, left-padded to size.
+func pad(i *big.Int, size int) []byte {
+	b := i.Bytes()
+	return append(make([]byte, size-len(b)), b...)
+}
+
+// recordingHandler records the claims of the requests it handles.
+type recordingHandler struct {
+	claims Claims
+}
+
+func (h *recordingHandler) Handle(ctx context.Context, req *transport.Request, w transport.ResponseWriter) error {
+	h.claims, _ = ClaimsFromContext(ctx)
+	return nil
+}
+
+func (h *recordingHandler) HandleOneway(ctx context.Context, req *transport.Request) error {
+	h.claims, _ = ClaimsFromContext(ctx)
+	return nil
+}
+
+func TestMiddleware(t *testing.T) {
+	fake := clock.NewFake()
+	now := fake.Now().Unix()
+	m := New(
+		Keys(map[string]crypto.PublicKey{"rsa": &rsaKey.PublicKey, "ec": &ecKey.PublicKey}),
+		Issuer("auth"),
+		Audience("keyvalue"),
+		ProcedureScopes("set", "keyvalue:write"),
+		optionFunc(func(o *options) { o.clock = fake }),
+	)
+	valid := map[string]interface{}{
+		"sub":   "alice",
+		"iss":   "auth",
+		"aud":   []string{"keyvalue", "other"},
+		"exp":   now + 60,
+		"scope": "keyvalue:read keyvalue:write",
+	}
+	with := func(k string, v interface{}) map[string]interface{} {
+		claims := make(map[string]interface{}, len(valid))
+		for k, v := range valid {
+			claims[k] = v
+		}
+		claims[k] = v
+		return claims
+	}
+
+	tests := []struct {
+		desc      string
+		header    string
+		procedure string
+		wantCode  yarpcerrors.Code
+		wantErr   string
+	}{
+		{
+			desc:   "RSA token",
+			header: "Bearer " + sign(t, "RS256", "rsa", rsaKey, valid),
+		},
+		{
+			desc:   "ECDSA token",
+			header: sign(t, "ES256", "ec", ecKey, valid),
+		},
+		{
+			desc:      "token with the scopes of the procedure",
+			header:    sign(t, "RS256", "rsa", rsaKey, valid),
+			procedure: "set",
+		},
+		{
+			desc:      "token without the scopes of the procedure",
+			header:    sign(t, "RS256", "rsa", rsaKey, with("scope", "keyvalue:read")),
+			procedure: "set",
+			wantCode:  yarpcerrors.CodePermissionDenied,
+			wantErr:   `token does not grant scope "keyvalue:write" required by procedure "set"`,
+		},
+		{
+			desc:     "missing token",
+			wantCode: yarpcerrors.CodeUnauthenticated,
+			wantErr:  `missing token in header "authorization"`,
+		},
+		{
+			desc:     "malformed token",
+			header:   "Bearer nonsense",
+			wantCode: yarpcerrors.CodeUnauthenticated,
+			wantErr:  "malformed token",
+		},
+		{
+			desc:     "expired token",
+			header:   sign(t, "RS256", "rsa", rsaKey, with("exp", now)),
+			wantCode: yarpcerrors.CodeUnauthenticated,
+			wantErr:  "token expired",
+		},
+		{
+			desc:     "token not valid yet",
+			header:   sign(t, "RS256", "rsa", rsaKey, with("nbf", now+10)),
+			wantCode: yarpcerrors.CodeUnauthenticated,
+			wantErr:  "token not valid yet",
+		},
+		{
+			desc:     "token from another issuer",
+			header:   sign(t, "RS256", "rsa", rsaKey, with("iss", "mallory")),
+			wantCode: yarpcerrors.CodeUnauthenticated,
+			wantErr:  `token issued by "mallory", not "auth"`,
+		},
+		{
+			desc:     "token for another audience",
+			header:   sign(t, "RS256", "rsa", rsaKey, with("aud", "other")),
+			wantCode: yarpcerrors.CodeUnauthenticated,
+			wantErr:  `token not intended for "keyvalue"`,
+		},
+		{
+			desc:     "token signed with another key",
+			header:   sign(t, "ES256", "rsa", ecKey, valid),
+			wantCode: yarpcerrors.CodeUnauthenticated,
+			wantErr:  `signing algorithm "ES256" does not match the key`,
+		},
+		{
+			desc:     "token with an unknown key",
+			header:   sign(t, "RS256", "other", rsaKey, valid),
+			wantCode: yarpcerrors.CodeUnauthenticated,
+			wantErr:  `unknown key "other"`,
+		},
+		{
+			desc:     "unsigned token",
+			header:   encode(map[string]string{"alg": "none"}) + "." + encode(valid) + ".",
+			wantCode: yarpcerrors.CodeUnauthenticated,
+			wantErr:  `unsupported signing algorithm "none"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			req := &transport.Request{Procedure: tt.procedure, Headers: transport.NewHeaders()}
+			if tt.header != "" {
+				req.Headers = req.Headers.With("Authorization", tt.header)
+			}
+
+			h := &recordingHandler{}
+			err := m.Handle(context.Background(), req, &transporttest.FakeResponseWriter{}, h)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				assert.Equal(t, "alice", h.claims.Subject(), "handlers must see the claims of the token")
+				return
+			}
+			require.Error(t, err)
+			assert.Equal(t, tt.wantCode, yarpcerrors.FromError(err).Code())
+			assert.Contains(t, err.Error(), tt.wantErr)
+			assert.Nil(t, h.claims, "rejected requests must not reach the handler")
+		})
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	var (
+		fetches int32
+		kid     atomic.Value
+	)
+	kid.Store("first")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{
+			{
+				"kty": "RSA",
+				"kid": kid.Load().(string),
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(rsaKey.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(rsaKey.E)).Bytes()),
+			},
+			{
+				"kty": "EC",
+				"kid": "ec",
+				"crv": "P-256",
+				"x":   base64.RawURLEncoding.EncodeToString(ecKey.X.Bytes()),
+				"y":   base64.RawURLEncoding.EncodeToString(ecKey.Y.Bytes()),
+			},
+			{"kty": "oct", "kid": "secret", "k": "c2VjcmV0"},
+		}})
+	}))
+	defer server.Close()
+
+	fake := clock.NewFake()
+	m, err := NewFromConfig(Config{JWKS: server.URL, Refresh: time.Hour},
+		optionFunc(func(o *options) { o.clock = fake }))
+	require.NoError(t, err)
+
+	handle := func(kid string, key crypto.Signer) error {
+		alg := "RS256"
+		if _, ok := key.(*ecdsa.PrivateKey); ok {
+			alg = "ES256"
+		}
+		token := sign(t, alg, kid, key, map[string]interface{}{"sub": "alice"})
+		return m.HandleOneway(context.Background(), &transport.Request{
+			Headers: transport.NewHeaders().With("authorization", token),
+		}, &recordingHandler{})
+	}
+
+	require.NoError(t, handle("first", rsaKey))
+	require.NoError(t, handle("ec", ecKey))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&fetches), "the key set must be cached")
+
+	kid.Store("second")
+	fake.Add(time.Second)
+	assert.Error(t, handle("second", rsaKey), "unknown keys must not be fetched again too often")
+	fake.Add(_minRefetch)
+	require.NoError(t, handle("second", rsaKey), "the key set must be fetched again for unknown keys")
+	assert.Equal(t, int32(2), atomic.LoadInt32(&fetches))
+
+	fake.Add(time.Hour)
+	kid.Store("third")
+	require.NoError(t, handle("third", rsaKey))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&fetches), "the key set must be refreshed")
+}
+
+func TestNewFromConfigRequiresJWKS(t *testing.T) {
+	_, err := NewFromConfig(Config{Issuer: "auth"})
+	assert.Error(t, err)
+}
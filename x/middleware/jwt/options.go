@@ -0,0 +1,122 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package jwt
+
+import (
+	"crypto"
+	"net/http"
+	"time"
+
+	"go.uber.org/yarpc/internal/clock"
+)
+
+// Option customizes the behavior of JWT authentication middleware.
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(opts *options) { f(opts) }
+
+type options struct {
+	header   string
+	keys     keySource
+	issuer   string
+	audience string
+	leeway   time.Duration
+	scopes   map[string][]string
+	clock    clock.Clock
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		header: "authorization",
+		scopes: make(map[string][]string),
+		clock:  clock.NewReal(),
+	}
+	for _, opt := range opts {
+		opt.apply(&o)
+	}
+	return o
+}
+
+// Header reads tokens from the named request header. A "Bearer " prefix is
+// stripped from the value.
+//
+// Defaults to "authorization".
+func Header(name string) Option {
+	return optionFunc(func(o *options) {
+		o.header = name
+	})
+}
+
+// JWKS verifies tokens with the keys published in the JSON Web Key Set at
+// the given URL. The key set is fetched when the first request arrives,
+// again after the refresh interval, and again when a token is signed by a
+// key the set does not have.
+//
+// A nil client uses http.DefaultClient.
+func JWKS(url string, refresh time.Duration, client *http.Client) Option {
+	return optionFunc(func(o *options) {
+		o.keys = newRemoteKeys(url, refresh, client)
+	})
+}
+
+// Keys verifies tokens with the given RSA or ECDSA public keys, by key ID.
+// Tokens without a key ID are verified with the key with the empty ID.
+func Keys(keys map[string]crypto.PublicKey) Option {
+	return optionFunc(func(o *options) {
+		o.keys = staticKeys(keys)
+	})
+}
+
+// Issuer only accepts tokens issued by the given issuer.
+func Issuer(iss string) Option {
+	return optionFunc(func(o *options) {
+		o.issuer = iss
+	})
+}
+
+// Audience only accepts tokens intended for the given audience.
+func Audience(aud string) Option {
+	return optionFunc(func(o *options) {
+		o.audience = aud
+	})
+}
+
+// Leeway tolerates the given difference between the clocks of the issuer
+// and the service when checking the expiry and start of tokens.
+//
+// Defaults to zero.
+func Leeway(d time.Duration) Option {
+	return optionFunc(func(o *options) {
+		o.leeway = d
+	})
+}
+
+// ProcedureScopes requires tokens for the named procedure to grant every
+// given scope.
+func ProcedureScopes(procedure string, scopes ...string) Option {
+	return optionFunc(func(o *options) {
+		o.scopes[procedure] = append(o.scopes[procedure], scopes...)
+	})
+}
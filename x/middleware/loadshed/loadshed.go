@@ -0,0 +1,205 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package loadshed provides inbound middleware that sheds requests by
+// priority as the load of a service rises, so that its most important
+// callers keep working during brownouts while less important traffic is
+// turned away.
+//
+// Callers set the priority of their requests with the priority header, or
+// the service assigns priorities to its callers. Each priority has a load
+// threshold: once the load of the service reaches the threshold of a
+// priority, requests with that priority are rejected at once with a
+// ResourceExhausted error. Sheddable requests go first, then low, normal and
+// high priority ones. Critical requests are never shed by default.
+//
+// 	shed := loadshed.New(
+// 		loadshed.MaxInFlight(200),
+// 		loadshed.Signals(loadshed.CPU()),
+// 		loadshed.CallerPriority("checkout", loadshed.Critical),
+// 	)
+// 	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+// 		Name: "myservice",
+// 		InboundMiddleware: yarpc.InboundMiddleware{
+// 			Unary:  shed,
+// 			Oneway: shed,
+// 		},
+// 	})
+//
+// Load is measured as a fraction of the load the service can sustain, where
+// 1 is at capacity, by signals like the number of requests in flight, the
+// CPU utilization of the process, or the time requests spend queued. The
+// load of the service is the highest load of its signals.
+package loadshed
+
+import (
+	"context"
+	"math"
+	"strings"
+
+	"go.uber.org/atomic"
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+var (
+	_ middleware.UnaryInbound  = (*Middleware)(nil)
+	_ middleware.OnewayInbound = (*Middleware)(nil)
+)
+
+// PriorityHeader is the header that carries the priority of requests by
+// default.
+const PriorityHeader = "priority"
+
+// Priority is the importance of a request, which decides how early it is
+// shed as load rises.
+type Priority int
+
+// Priorities, from the first to be shed to the last.
+const (
+	Sheddable Priority = iota
+	Low
+	Normal
+	High
+	Critical
+)
+
+var _priorityNames = map[Priority]string{
+	Sheddable: "sheddable",
+	Low:       "low",
+	Normal:    "normal",
+	High:      "high",
+	Critical:  "critical",
+}
+
+func (p Priority) String() string {
+	if name, ok := _priorityNames[p]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// ParsePriority parses the name of a priority, like "high", as carried by
+// the priority header. Names are case-insensitive.
+func ParsePriority(s string) (Priority, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for p, name := range _priorityNames {
+		if name == s {
+			return p, true
+		}
+	}
+	return Normal, false
+}
+
+// Signal measures the load of a service, as a fraction of the load it can
+// sustain: 0 is idle and 1 is at capacity. Signals are consulted for every
+// request that may be shed, so they must be cheap and safe for concurrent
+// use.
+type Signal interface {
+	Load() float64
+}
+
+// SignalFunc is a Signal backed by a function.
+type SignalFunc func() float64
+
+// Load returns the load the function measures.
+func (f SignalFunc) Load() float64 { return f() }
+
+// Middleware is inbound middleware which sheds requests by priority as load
+// rises.
+type Middleware struct {
+	opts     options
+	inFlight atomic.Int64
+}
+
+// New builds load shedding middleware.
+func New(opts ...Option) *Middleware {
+	return &Middleware{opts: newOptions(opts)}
+}
+
+// Handle implements middleware.UnaryInbound.
+func (m *Middleware) Handle(ctx context.Context, req *transport.Request, w transport.ResponseWriter, h transport.UnaryHandler) error {
+	if err := m.admit(req); err != nil {
+		return err
+	}
+	defer m.inFlight.Dec()
+	return h.Handle(ctx, req, w)
+}
+
+// HandleOneway implements middleware.OnewayInbound.
+func (m *Middleware) HandleOneway(ctx context.Context, req *transport.Request, h transport.OnewayHandler) error {
+	if err := m.admit(req); err != nil {
+		return err
+	}
+	defer m.inFlight.Dec()
+	return h.HandleOneway(ctx, req)
+}
+
+// Load returns the current load of the service, the highest load of its
+// signals.
+func (m *Middleware) Load() float64 {
+	var load float64
+	if m.opts.maxInFlight > 0 {
+		load = float64(m.inFlight.Load()) / float64(m.opts.maxInFlight)
+	}
+	for _, s := range m.opts.signals {
+		load = math.Max(load, s.Load())
+	}
+	return load
+}
+
+// InFlight returns the number of requests being handled.
+func (m *Middleware) InFlight() int {
+	return int(m.inFlight.Load())
+}
+
+// Priority returns the priority of a request: the priority of its caller,
+// if the service assigned it one, or the priority in its header, or the
+// default priority.
+func (m *Middleware) Priority(req *transport.Request) Priority {
+	if p, ok := m.opts.callerPriorities[req.Caller]; ok {
+		return p
+	}
+	if v, ok := req.Headers.Get(m.opts.header); ok {
+		if p, ok := ParsePriority(v); ok {
+			return p
+		}
+	}
+	return m.opts.defaultPriority
+}
+
+// admit counts the request in flight, unless the load has reached the
+// threshold of its priority.
+func (m *Middleware) admit(req *transport.Request) error {
+	p := m.Priority(req)
+	threshold, ok := m.opts.thresholds[p]
+	if !ok {
+		threshold = math.Inf(1)
+	}
+	if !math.IsInf(threshold, 1) {
+		if load := m.Load(); load >= threshold {
+			return yarpcerrors.Newf(yarpcerrors.CodeResourceExhausted,
+				"shedding %v priority requests to procedure %q at load %.2f", p, req.Procedure, load)
+		}
+	}
+	m.inFlight.Inc()
+	return nil
+}
@@ -0,0 +1,211 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package loadshed
+
+import (
+	"context"
+	"math"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+type nopHandler struct{}
+
+func (nopHandler) Handle(context.Context, *transport.Request, transport.ResponseWriter) error {
+	return nil
+}
+
+func (nopHandler) HandleOneway(context.Context, *transport.Request) error {
+	return nil
+}
+
+// blockingHandler handles requests until it is released.
+type blockingHandler struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (h blockingHandler) Handle(context.Context, *transport.Request, transport.ResponseWriter) error {
+	h.started <- struct{}{}
+	<-h.release
+	return nil
+}
+
+func request(caller, priority string) *transport.Request {
+	req := &transport.Request{Caller: caller, Service: "users", Procedure: "Users::get"}
+	if priority != "" {
+		req.Headers = transport.NewHeaders().With(PriorityHeader, priority)
+	}
+	return req
+}
+
+func handle(mw *Middleware, req *transport.Request) error {
+	return mw.Handle(context.Background(), req, &transporttest.FakeResponseWriter{}, nopHandler{})
+}
+
+func TestParsePriority(t *testing.T) {
+	for p, name := range _priorityNames {
+		got, ok := ParsePriority(name)
+		assert.True(t, ok)
+		assert.Equal(t, p, got)
+		assert.Equal(t, name, p.String())
+	}
+
+	p, ok := ParsePriority(" HIGH ")
+	assert.True(t, ok, "priorities must be case-insensitive")
+	assert.Equal(t, High, p)
+
+	_, ok = ParsePriority("urgent")
+	assert.False(t, ok)
+	assert.Equal(t, "unknown", Priority(42).String())
+}
+
+func TestPriority(t *testing.T) {
+	mw := New(CallerPriority("checkout", Critical), DefaultPriority(Low))
+
+	assert.Equal(t, Critical, mw.Priority(request("checkout", "sheddable")), "caller priorities must take precedence")
+	assert.Equal(t, High, mw.Priority(request("search", "high")))
+	assert.Equal(t, Low, mw.Priority(request("search", "urgent")), "unknown priorities must get the default")
+	assert.Equal(t, Low, mw.Priority(request("search", "")))
+
+	mw = New(Header("x-priority"))
+	req := request("search", "")
+	req.Headers = transport.NewHeaders().With("x-priority", "sheddable")
+	assert.Equal(t, Sheddable, mw.Priority(req))
+}
+
+func TestShedding(t *testing.T) {
+	var load float64
+	mw := New(Signals(SignalFunc(func() float64 { return load })))
+
+	tests := []struct {
+		load float64
+		shed []string
+	}{
+		{load: 0.5},
+		{load: 0.7, shed: []string{"sheddable"}},
+		{load: 0.85, shed: []string{"sheddable", "low"}},
+		{load: 0.95, shed: []string{"sheddable", "low", "normal"}},
+		{load: 2, shed: []string{"sheddable", "low", "normal", "high"}},
+	}
+
+	for _, tt := range tests {
+		load = tt.load
+		shed := make(map[string]bool)
+		for _, name := range tt.shed {
+			shed[name] = true
+		}
+		for _, name := range _priorityNames {
+			err := handle(mw, request("search", name))
+			if !shed[name] {
+				assert.NoError(t, err, "%v priority requests must not be shed at load %v", name, tt.load)
+				continue
+			}
+			require.Error(t, err, "%v priority requests must be shed at load %v", name, tt.load)
+			assert.Equal(t, yarpcerrors.CodeResourceExhausted, yarpcerrors.FromError(err).Code())
+		}
+	}
+	assert.Equal(t, 0, mw.InFlight(), "requests must not be counted once they finish")
+}
+
+func TestThresholds(t *testing.T) {
+	mw := New(
+		Signals(SignalFunc(func() float64 { return 5 })),
+		Threshold(Critical, 3),
+		Threshold(High, math.Inf(1)),
+	)
+	err := mw.HandleOneway(context.Background(), request("search", "critical"), nopHandler{})
+	assert.Error(t, err)
+	err = mw.HandleOneway(context.Background(), request("search", "high"), nopHandler{})
+	assert.NoError(t, err)
+}
+
+func TestMaxInFlight(t *testing.T) {
+	mw := New(MaxInFlight(10))
+	h := blockingHandler{started: make(chan struct{}), release: make(chan struct{})}
+
+	errs := make(chan error, 7)
+	for i := 0; i < 7; i++ {
+		go func() {
+			errs <- mw.Handle(context.Background(), request("search", "critical"), &transporttest.FakeResponseWriter{}, h)
+		}()
+		<-h.started
+	}
+	assert.Equal(t, 7, mw.InFlight())
+	assert.InDelta(t, 0.7, mw.Load(), 1e-9)
+
+	assert.Error(t, handle(mw, request("search", "sheddable")))
+	assert.NoError(t, handle(mw, request("search", "low")))
+
+	close(h.release)
+	for i := 0; i < 7; i++ {
+		assert.NoError(t, <-errs)
+	}
+	assert.Equal(t, 0.0, mw.Load())
+}
+
+func TestCPUSignal(t *testing.T) {
+	clk := clock.NewFake()
+	var cpu time.Duration
+	available := true
+	s := newCPUSignal(clk, func() (time.Duration, bool) { return cpu, available })
+
+	assert.Equal(t, 0.0, s.Load(), "the first sample has nothing to compare with")
+
+	clk.Add(time.Second)
+	cpu = time.Second
+	assert.InDelta(t, 1/float64(runtime.GOMAXPROCS(0)), s.Load(), 1e-9)
+
+	clk.Add(_cpuInterval / 2)
+	cpu = 10 * time.Second
+	assert.InDelta(t, 1/float64(runtime.GOMAXPROCS(0)), s.Load(), 1e-9, "samples must not be taken more often than the interval")
+
+	available = false
+	clk.Add(time.Second)
+	assert.Equal(t, 0.0, s.Load())
+
+	assert.True(t, CPU().Load() >= 0)
+}
+
+func TestQueueDelay(t *testing.T) {
+	q := NewQueueDelay(100 * time.Millisecond)
+	assert.Equal(t, 0.0, q.Load())
+
+	for i := 0; i < 200; i++ {
+		q.Observe(200 * time.Millisecond)
+	}
+	assert.InDelta(t, 2, q.Load(), 0.01, "the load must follow the average delay")
+
+	for i := 0; i < 200; i++ {
+		q.Observe(0)
+	}
+	assert.InDelta(t, 0, q.Load(), 0.01)
+
+	assert.Equal(t, 0.0, NewQueueDelay(0).Load())
+}
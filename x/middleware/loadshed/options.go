@@ -0,0 +1,119 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package loadshed
+
+import "math"
+
+// Option customizes the behavior of load shedding middleware.
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(opts *options) { f(opts) }
+
+type options struct {
+	header           string
+	defaultPriority  Priority
+	callerPriorities map[string]Priority
+	thresholds       map[Priority]float64
+	maxInFlight      int
+	signals          []Signal
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		header:           PriorityHeader,
+		defaultPriority:  Normal,
+		callerPriorities: make(map[string]Priority),
+		thresholds: map[Priority]float64{
+			Sheddable: 0.7,
+			Low:       0.8,
+			Normal:    0.9,
+			High:      1,
+			Critical:  math.Inf(1),
+		},
+	}
+	for _, opt := range opts {
+		opt.apply(&o)
+	}
+	return o
+}
+
+// Header is the header that carries the priority of requests.
+//
+// Defaults to PriorityHeader.
+func Header(name string) Option {
+	return optionFunc(func(o *options) {
+		o.header = name
+	})
+}
+
+// DefaultPriority is the priority of requests that have no priority, or an
+// unknown one.
+//
+// Defaults to Normal.
+func DefaultPriority(p Priority) Option {
+	return optionFunc(func(o *options) {
+		o.defaultPriority = p
+	})
+}
+
+// CallerPriority assigns a priority to the requests of a caller, which takes
+// precedence over the priority in their header. Use it for callers that
+// must keep working, or whose traffic is best effort, without relying on
+// them to set the header.
+func CallerPriority(caller string, p Priority) Option {
+	return optionFunc(func(o *options) {
+		o.callerPriorities[caller] = p
+	})
+}
+
+// Threshold is the load at which requests with the priority are shed. A
+// threshold of math.Inf(1) means the requests are never shed.
+//
+// Defaults to 0.7 for Sheddable, 0.8 for Low, 0.9 for Normal and 1 for High
+// priority requests. Critical requests are never shed.
+func Threshold(p Priority, load float64) Option {
+	return optionFunc(func(o *options) {
+		o.thresholds[p] = load
+	})
+}
+
+// MaxInFlight is the number of requests in flight the service can sustain.
+// When set, the number of requests in flight is a load signal, at full load
+// with that many requests.
+//
+// Requests are not counted as a signal by default.
+func MaxInFlight(n int) Option {
+	return optionFunc(func(o *options) {
+		o.maxInFlight = n
+	})
+}
+
+// Signals adds signals that measure the load of the service, like CPU or a
+// QueueDelay.
+func Signals(signals ...Signal) Option {
+	return optionFunc(func(o *options) {
+		o.signals = append(o.signals, signals...)
+	})
+}
@@ -0,0 +1,123 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package loadshed
+
+import (
+	"runtime"
+	"sync"
+	"time"
+
+	"go.uber.org/yarpc/internal/clock"
+)
+
+// _cpuInterval is how often the CPU signal samples the CPU time of the
+// process.
+const _cpuInterval = 250 * time.Millisecond
+
+// _queueDelaySmoothing is the weight of each observation in the moving
+// average of queue delays.
+const _queueDelaySmoothing = 0.1
+
+// CPU returns a Signal that measures the CPU utilization of the process, as
+// a fraction of the CPUs it may use, GOMAXPROCS. The utilization is sampled
+// at most four times a second, over the time since the previous sample.
+//
+// The load is always zero on platforms where the CPU time of the process is
+// not available.
+func CPU() Signal {
+	return newCPUSignal(clock.NewReal(), processCPUTime)
+}
+
+type cpuSignal struct {
+	clock   clock.Clock
+	cpuTime func() (time.Duration, bool)
+
+	mu       sync.Mutex
+	lastWall time.Time
+	lastCPU  time.Duration
+	load     float64
+}
+
+func newCPUSignal(c clock.Clock, cpuTime func() (time.Duration, bool)) *cpuSignal {
+	return &cpuSignal{clock: c, cpuTime: cpuTime}
+}
+
+func (s *cpuSignal) Load() float64 {
+	now := s.clock.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.lastWall.IsZero() && now.Sub(s.lastWall) < _cpuInterval {
+		return s.load
+	}
+	cpu, ok := s.cpuTime()
+	if !ok {
+		return 0
+	}
+	if !s.lastWall.IsZero() {
+		wall := now.Sub(s.lastWall)
+		s.load = float64(cpu-s.lastCPU) / (float64(wall) * float64(runtime.GOMAXPROCS(0)))
+	}
+	s.lastWall = now
+	s.lastCPU = cpu
+	return s.load
+}
+
+// QueueDelay is a Signal that measures the time requests wait in a queue,
+// like the queue of a worker pool that handlers hand their work to, against
+// a target delay. The load is the moving average of the delays observed,
+// as a fraction of the target.
+//
+// 	delay := loadshed.NewQueueDelay(50 * time.Millisecond)
+// 	shed := loadshed.New(loadshed.Signals(delay))
+//
+// 	// In the worker pool:
+// 	delay.Observe(time.Since(task.enqueued))
+type QueueDelay struct {
+	target time.Duration
+
+	mu      sync.Mutex
+	average float64
+}
+
+// NewQueueDelay builds a QueueDelay signal that is at full load when the
+// average delay reaches the target.
+func NewQueueDelay(target time.Duration) *QueueDelay {
+	return &QueueDelay{target: target}
+}
+
+// Observe records the time a request waited in the queue.
+func (q *QueueDelay) Observe(d time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.average += (float64(d) - q.average) * _queueDelaySmoothing
+}
+
+// Load returns the average delay as a fraction of the target.
+func (q *QueueDelay) Load() float64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.target <= 0 {
+		return 0
+	}
+	return q.average / float64(q.target)
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ratelimit
+
+import "go.uber.org/yarpc/internal/clock"
+
+// Option customizes the behavior of rate limiting middleware.
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(opts *options) { f(opts) }
+
+// limit is a rate of requests per second with a burst.
+type limit struct {
+	rps   float64
+	burst int
+}
+
+type options struct {
+	global     *limit
+	procedures map[string]limit
+	callers    map[string]limit
+	clock      clock.Clock
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		procedures: make(map[string]limit),
+		callers:    make(map[string]limit),
+		clock:      clock.NewReal(),
+	}
+	for _, opt := range opts {
+		opt.apply(&o)
+	}
+	return o
+}
+
+// Rate limits all requests together to rps requests per second, allowing
+// bursts of up to burst requests.
+//
+// Defaults to no global limit.
+func Rate(rps float64, burst int) Option {
+	return optionFunc(func(o *options) {
+		o.global = &limit{rps: rps, burst: burst}
+	})
+}
+
+// ProcedureRate limits the requests to the named procedure to rps requests
+// per second, allowing bursts of up to burst requests.
+func ProcedureRate(procedure string, rps float64, burst int) Option {
+	return optionFunc(func(o *options) {
+		o.procedures[procedure] = limit{rps: rps, burst: burst}
+	})
+}
+
+// CallerRate limits the requests from the named caller to rps requests per
+// second, allowing bursts of up to burst requests.
+func CallerRate(caller string, rps float64, burst int) Option {
+	return optionFunc(func(o *options) {
+		o.callers[caller] = limit{rps: rps, burst: burst}
+	})
+}
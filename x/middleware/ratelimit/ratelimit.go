@@ -0,0 +1,198 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package ratelimit provides inbound middleware that limits the rate of
+// requests a service accepts with token buckets, globally, per procedure,
+// and per caller.
+//
+// 	rl := ratelimit.New(
+// 		ratelimit.Rate(1000, 100),
+// 		ratelimit.ProcedureRate("Reports::generate", 10, 5),
+// 		ratelimit.CallerRate("batch-importer", 50, 50),
+// 	)
+// 	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+// 		Name: "myservice",
+// 		InboundMiddleware: yarpc.InboundMiddleware{
+// 			Unary:  rl,
+// 			Oneway: rl,
+// 		},
+// 	})
+//
+// A request must be allowed by every limit that applies to it. Throttled
+// requests are rejected with a ResourceExhausted error that says how long to
+// wait before retrying. Unary responses also carry the wait, in
+// milliseconds, in the RetryAfterHeader application header, for transports
+// that send headers along with errors.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// RetryAfterHeader is the application header in which throttled unary
+// responses carry the number of milliseconds to wait before retrying.
+const RetryAfterHeader = "retry-after-ms"
+
+var (
+	_ middleware.UnaryInbound  = (*Middleware)(nil)
+	_ middleware.OnewayInbound = (*Middleware)(nil)
+)
+
+// Middleware is inbound middleware which limits the rate of requests.
+type Middleware struct {
+	clock clock.Clock
+
+	global     *bucket
+	procedures map[string]*bucket
+	callers    map[string]*bucket
+}
+
+// New builds rate limiting middleware.
+func New(opts ...Option) *Middleware {
+	o := newOptions(opts)
+	now := o.clock.Now()
+	m := &Middleware{
+		clock:      o.clock,
+		procedures: make(map[string]*bucket, len(o.procedures)),
+		callers:    make(map[string]*bucket, len(o.callers)),
+	}
+	if o.global != nil {
+		m.global = newBucket("all requests", *o.global, now)
+	}
+	for procedure, l := range o.procedures {
+		m.procedures[procedure] = newBucket("procedure "+strconv.Quote(procedure), l, now)
+	}
+	for caller, l := range o.callers {
+		m.callers[caller] = newBucket("caller "+strconv.Quote(caller), l, now)
+	}
+	return m
+}
+
+// Handle implements middleware.UnaryInbound.
+func (m *Middleware) Handle(ctx context.Context, req *transport.Request, w transport.ResponseWriter, h transport.UnaryHandler) error {
+	if b, wait := m.take(req); b != nil {
+		w.AddHeaders(transport.NewHeaders().With(RetryAfterHeader, strconv.FormatInt(millis(wait), 10)))
+		return throttled(b, wait)
+	}
+	return h.Handle(ctx, req, w)
+}
+
+// HandleOneway implements middleware.OnewayInbound.
+func (m *Middleware) HandleOneway(ctx context.Context, req *transport.Request, h transport.OnewayHandler) error {
+	if b, wait := m.take(req); b != nil {
+		return throttled(b, wait)
+	}
+	return h.HandleOneway(ctx, req)
+}
+
+// take takes a token for the request from every bucket that applies to it.
+// If a bucket is empty, the tokens already taken are returned, and the empty
+// bucket is returned with how long until it has a token again.
+func (m *Middleware) take(req *transport.Request) (*bucket, time.Duration) {
+	now := m.clock.Now()
+	var taken []*bucket
+	for _, b := range []*bucket{m.global, m.procedures[req.Procedure], m.callers[req.Caller]} {
+		if b == nil {
+			continue
+		}
+		if wait := b.take(now); wait > 0 {
+			for _, t := range taken {
+				t.refund()
+			}
+			return b, wait
+		}
+		taken = append(taken, b)
+	}
+	return nil, 0
+}
+
+func throttled(b *bucket, wait time.Duration) error {
+	return yarpcerrors.Newf(yarpcerrors.CodeResourceExhausted,
+		"rate limit of %v requests per second for %s exceeded, retry after %v", b.rps, b.name, wait)
+}
+
+// millis returns the duration in milliseconds, rounded up.
+func millis(d time.Duration) int64 {
+	return int64(math.Ceil(float64(d) / float64(time.Millisecond)))
+}
+
+// bucket is a token bucket which fills at rps tokens per second up to
+// burst tokens.
+type bucket struct {
+	name  string
+	rps   float64
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func newBucket(name string, l limit, now time.Time) *bucket {
+	return &bucket{
+		name:   name,
+		rps:    l.rps,
+		burst:  float64(l.burst),
+		tokens: float64(l.burst),
+		last:   now,
+	}
+}
+
+// take takes a token from the bucket, or returns how long until the bucket
+// has a token if it is empty.
+func (b *bucket) take(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if now.After(b.last) {
+		b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rps)
+		b.last = now
+	}
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+	if b.rps <= 0 {
+		// The bucket never refills.
+		return time.Duration(math.MaxInt64)
+	}
+	wait := time.Duration((1 - b.tokens) / b.rps * float64(time.Second))
+	if wait <= 0 {
+		wait = time.Nanosecond
+	}
+	return wait
+}
+
+// refund returns a token taken from the bucket.
+func (b *bucket) refund() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens = math.Min(b.burst, b.tokens+1)
+}
@@ -0,0 +1,133 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/internal/clock"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+type nopHandler struct{}
+
+func (nopHandler) Handle(context.Context, *transport.Request, transport.ResponseWriter) error {
+	return nil
+}
+
+func (nopHandler) HandleOneway(context.Context, *transport.Request) error {
+	return nil
+}
+
+func newTestMiddleware(opts ...Option) (*Middleware, *clock.FakeClock) {
+	fake := clock.NewFake()
+	opts = append(opts, optionFunc(func(o *options) { o.clock = fake }))
+	return New(opts...), fake
+}
+
+func TestRateLimit(t *testing.T) {
+	mw, fake := newTestMiddleware(Rate(10, 2))
+	ctx := context.Background()
+	req := &transport.Request{Procedure: "hello"}
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, mw.Handle(ctx, req, &transporttest.FakeResponseWriter{}, nopHandler{}), "bursts must be allowed")
+	}
+
+	w := &transporttest.FakeResponseWriter{}
+	err := mw.Handle(ctx, req, w, nopHandler{})
+	require.Error(t, err)
+	assert.Equal(t, yarpcerrors.CodeResourceExhausted, yarpcerrors.FromError(err).Code())
+	assert.Contains(t, err.Error(), "rate limit of 10 requests per second for all requests exceeded, retry after 100ms")
+	retryAfter, ok := w.Headers.Get(RetryAfterHeader)
+	assert.True(t, ok, "throttled responses must carry a retry-after hint")
+	assert.Equal(t, "100", retryAfter)
+
+	fake.Add(50 * time.Millisecond)
+	err = mw.HandleOneway(ctx, req, nopHandler{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "retry after 50ms")
+
+	fake.Add(50 * time.Millisecond)
+	assert.NoError(t, mw.HandleOneway(ctx, req, nopHandler{}), "the bucket must refill over time")
+}
+
+func TestRateLimitProceduresAndCallers(t *testing.T) {
+	mw, _ := newTestMiddleware(
+		ProcedureRate("narrow", 1, 1),
+		CallerRate("greedy", 1, 2),
+	)
+	ctx := context.Background()
+
+	tests := []struct {
+		desc    string
+		req     *transport.Request
+		wantErr string
+	}{
+		{
+			desc: "first request to a limited procedure",
+			req:  &transport.Request{Caller: "greedy", Procedure: "narrow"},
+		},
+		{
+			desc:    "second request to a limited procedure",
+			req:     &transport.Request{Caller: "polite", Procedure: "narrow"},
+			wantErr: `for procedure "narrow" exceeded`,
+		},
+		{
+			desc: "unlimited procedure and caller",
+			req:  &transport.Request{Caller: "polite", Procedure: "wide"},
+		},
+		{
+			desc: "second request from a limited caller",
+			req:  &transport.Request{Caller: "greedy", Procedure: "wide"},
+		},
+		{
+			desc:    "third request from a limited caller",
+			req:     &transport.Request{Caller: "greedy", Procedure: "wide"},
+			wantErr: `for caller "greedy" exceeded`,
+		},
+	}
+
+	for _, tt := range tests {
+		err := mw.Handle(ctx, tt.req, &transporttest.FakeResponseWriter{}, nopHandler{})
+		if tt.wantErr == "" {
+			assert.NoError(t, err, tt.desc)
+		} else if assert.Error(t, err, tt.desc) {
+			assert.Contains(t, err.Error(), tt.wantErr, tt.desc)
+		}
+	}
+}
+
+func TestRateLimitRefundsTokens(t *testing.T) {
+	mw, _ := newTestMiddleware(Rate(1, 2), CallerRate("greedy", 1, 1))
+	ctx := context.Background()
+
+	require.NoError(t, mw.Handle(ctx, &transport.Request{Caller: "greedy"}, &transporttest.FakeResponseWriter{}, nopHandler{}))
+	require.Error(t, mw.Handle(ctx, &transport.Request{Caller: "greedy"}, &transporttest.FakeResponseWriter{}, nopHandler{}))
+	assert.NoError(t, mw.Handle(ctx, &transport.Request{Caller: "polite"}, &transporttest.FakeResponseWriter{}, nopHandler{}),
+		"throttled requests must not use up the global limit")
+}
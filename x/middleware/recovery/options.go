@@ -0,0 +1,78 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package recovery
+
+import "go.uber.org/zap"
+
+// Option customizes the behavior of recovery middleware.
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(opts *options) { f(opts) }
+
+type options struct {
+	reporters     []Reporter
+	logger        *zap.Logger
+	repanicOneway bool
+}
+
+func newOptions(opts []Option) options {
+	o := options{logger: zap.NewNop()}
+	for _, opt := range opts {
+		opt.apply(&o)
+	}
+	return o
+}
+
+// Report reports every recovered panic to the given reporter, like one that
+// sends it to an error tracker or counts it. Reporters are called in the
+// order they are given.
+func Report(r Reporter) Option {
+	return optionFunc(func(o *options) {
+		o.reporters = append(o.reporters, r)
+	})
+}
+
+// Logger logs every recovered panic with its stack trace at the error level.
+//
+// Defaults to not logging.
+func Logger(logger *zap.Logger) Option {
+	return optionFunc(func(o *options) {
+		o.logger = logger
+	})
+}
+
+// RepanicOneway panics again after reporting a panic of a oneway handler.
+//
+// The error a oneway handler returns is never seen by its caller, so a
+// service may prefer to crash, or let outer recovery handle the panic,
+// rather than carry on.
+//
+// Defaults to false, which returns an Internal error like for the other RPC
+// types.
+func RepanicOneway(repanic bool) Option {
+	return optionFunc(func(o *options) {
+		o.repanicOneway = repanic
+	})
+}
@@ -0,0 +1,159 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package recovery provides inbound middleware that recovers from panics in
+// handlers, reports them with their stack traces, and turns them into
+// Internal errors.
+//
+// 	rec := recovery.New(
+// 		recovery.Logger(logger),
+// 		recovery.Report(recovery.ReporterFunc(func(ctx context.Context, p *recovery.Panic) {
+// 			sentry.CaptureMessage(fmt.Sprintf("%v\n%s", p.Value, p.Stack))
+// 		})),
+// 	)
+// 	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+// 		Name: "myservice",
+// 		InboundMiddleware: yarpc.InboundMiddleware{
+// 			Unary:  rec,
+// 			Oneway: rec,
+// 			Stream: rec,
+// 		},
+// 	})
+//
+// Transports already recover from panics in handlers, but only log them.
+// Place this middleware last, closest to the handlers, to also recover from
+// panics in the other middleware.
+package recovery
+
+import (
+	"context"
+	"runtime/debug"
+
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/zap"
+)
+
+var (
+	_ middleware.UnaryInbound  = (*Middleware)(nil)
+	_ middleware.OnewayInbound = (*Middleware)(nil)
+	_ middleware.StreamInbound = (*Middleware)(nil)
+)
+
+// Panic is a panic recovered from a handler.
+type Panic struct {
+	// Type is the RPC type of the handler.
+	Type transport.Type
+
+	// Request is the request the handler was handling.
+	Request *transport.RequestMeta
+
+	// Value is the value the handler panicked with.
+	Value interface{}
+
+	// Stack is the stack trace of the goroutine that panicked, as formatted
+	// by runtime/debug.Stack.
+	Stack []byte
+}
+
+// Reporter reports recovered panics.
+//
+// Reporters MUST be thread-safe and SHOULD NOT block for long, since the
+// request does not complete until they return.
+type Reporter interface {
+	ReportPanic(ctx context.Context, p *Panic)
+}
+
+// ReporterFunc adapts a function into a Reporter.
+type ReporterFunc func(context.Context, *Panic)
+
+// ReportPanic implements Reporter.
+func (f ReporterFunc) ReportPanic(ctx context.Context, p *Panic) { f(ctx, p) }
+
+// Middleware is inbound middleware which recovers from panics in handlers.
+type Middleware struct {
+	opts options
+}
+
+// New builds recovery middleware.
+func New(opts ...Option) *Middleware {
+	return &Middleware{opts: newOptions(opts)}
+}
+
+// Handle implements middleware.UnaryInbound.
+func (m *Middleware) Handle(ctx context.Context, req *transport.Request, w transport.ResponseWriter, h transport.UnaryHandler) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = m.recovered(ctx, transport.Unary, req.ToRequestMeta(), r)
+		}
+	}()
+	return h.Handle(ctx, req, w)
+}
+
+// HandleOneway implements middleware.OnewayInbound.
+func (m *Middleware) HandleOneway(ctx context.Context, req *transport.Request, h transport.OnewayHandler) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = m.recovered(ctx, transport.Oneway, req.ToRequestMeta(), r)
+			if m.opts.repanicOneway {
+				panic(r)
+			}
+		}
+	}()
+	return h.HandleOneway(ctx, req)
+}
+
+// HandleStream implements middleware.StreamInbound.
+func (m *Middleware) HandleStream(s *transport.ServerStream, h transport.StreamHandler) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = m.recovered(s.Context(), transport.Streaming, s.Request().Meta, r)
+		}
+	}()
+	return h.HandleStream(s)
+}
+
+// recovered reports a panic recovered from a handler and returns the error
+// to answer the request with. It must be called from the deferred function
+// that recovered, so that the stack trace leads to the panic.
+func (m *Middleware) recovered(ctx context.Context, rpcType transport.Type, req *transport.RequestMeta, value interface{}) error {
+	p := &Panic{
+		Type:    rpcType,
+		Request: req,
+		Value:   value,
+		Stack:   debug.Stack(),
+	}
+
+	m.opts.logger.Error("handler panicked",
+		zap.Stringer("rpcType", rpcType),
+		zap.String("service", req.Service),
+		zap.String("procedure", req.Procedure),
+		zap.String("caller", req.Caller),
+		zap.Any("panic", value),
+		zap.ByteString("stack", p.Stack),
+	)
+	for _, r := range m.opts.reporters {
+		r.ReportPanic(ctx, p)
+	}
+
+	return yarpcerrors.Newf(yarpcerrors.CodeInternal,
+		"handler for procedure %q of service %q panicked: %v", req.Procedure, req.Service, value)
+}
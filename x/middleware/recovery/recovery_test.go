@@ -0,0 +1,141 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package recovery
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// panickingHandler panics when called, from a function that the stack
+// traces of the tests must lead to.
+type panickingHandler struct{}
+
+func (panickingHandler) Handle(context.Context, *transport.Request, transport.ResponseWriter) error {
+	return explode()
+}
+
+func (panickingHandler) HandleOneway(context.Context, *transport.Request) error {
+	return explode()
+}
+
+func (panickingHandler) HandleStream(*transport.ServerStream) error {
+	return explode()
+}
+
+func explode() error {
+	panic("great sadness")
+}
+
+type okHandler struct{}
+
+func (okHandler) Handle(context.Context, *transport.Request, transport.ResponseWriter) error {
+	return nil
+}
+
+// fakeStream is a stream with a request and nothing else.
+type fakeStream struct {
+	transport.Stream
+
+	req *transport.StreamRequest
+}
+
+func (s fakeStream) Context() context.Context          { return context.Background() }
+func (s fakeStream) Request() *transport.StreamRequest { return s.req }
+
+// recordingReporter records the panics reported to it.
+type recordingReporter struct {
+	mu     sync.Mutex
+	panics []*Panic
+}
+
+func (r *recordingReporter) ReportPanic(ctx context.Context, p *Panic) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.panics = append(r.panics, p)
+}
+
+func assertRecovered(t *testing.T, err error, reporter *recordingReporter, rpcType transport.Type) {
+	require.Error(t, err)
+	assert.Equal(t, yarpcerrors.CodeInternal, yarpcerrors.FromError(err).Code())
+	assert.Contains(t, err.Error(), "great sadness")
+
+	require.Len(t, reporter.panics, 1)
+	p := reporter.panics[0]
+	assert.Equal(t, rpcType, p.Type)
+	assert.Equal(t, "great sadness", p.Value)
+	assert.Equal(t, "Users::get", p.Request.Procedure)
+	assert.Contains(t, string(p.Stack), "recovery.explode", "the stack trace must lead to the panic")
+}
+
+func TestRecoverUnary(t *testing.T) {
+	reporter := &recordingReporter{}
+	mw := New(Report(reporter))
+	req := &transport.Request{Service: "users", Procedure: "Users::get"}
+
+	err := mw.Handle(context.Background(), req, &transporttest.FakeResponseWriter{}, panickingHandler{})
+	assertRecovered(t, err, reporter, transport.Unary)
+
+	err = mw.Handle(context.Background(), req, &transporttest.FakeResponseWriter{}, okHandler{})
+	assert.NoError(t, err)
+	assert.Len(t, reporter.panics, 1, "handlers that do not panic must not be reported")
+}
+
+func TestRecoverOneway(t *testing.T) {
+	reporter := &recordingReporter{}
+	mw := New(Report(reporter))
+	req := &transport.Request{Service: "users", Procedure: "Users::get"}
+
+	err := mw.HandleOneway(context.Background(), req, panickingHandler{})
+	assertRecovered(t, err, reporter, transport.Oneway)
+}
+
+func TestRecoverOnewayRepanic(t *testing.T) {
+	reporter := &recordingReporter{}
+	mw := New(Report(reporter), RepanicOneway(true))
+	req := &transport.Request{Service: "users", Procedure: "Users::get"}
+
+	assert.PanicsWithValue(t, "great sadness", func() {
+		mw.HandleOneway(context.Background(), req, panickingHandler{})
+	})
+	assert.Len(t, reporter.panics, 1, "the panic must be reported before panicking again")
+}
+
+func TestRecoverStream(t *testing.T) {
+	reporter := &recordingReporter{}
+	mw := New(Report(reporter))
+	stream, err := transport.NewServerStream(fakeStream{
+		req: &transport.StreamRequest{
+			Meta: &transport.RequestMeta{Service: "users", Procedure: "Users::get"},
+		},
+	})
+	require.NoError(t, err)
+
+	err = mw.HandleStream(stream, panickingHandler{})
+	assertRecovered(t, err, reporter, transport.Streaming)
+}
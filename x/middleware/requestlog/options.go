@@ -0,0 +1,115 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package requestlog
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Option customizes the behavior of request logging middleware.
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(opts *options) { f(opts) }
+
+type options struct {
+	logger      *zap.Logger
+	defaultRate float64
+	rates       map[string]float64
+	maxPayload  int
+	disabled    bool
+	seed        int64
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		logger:      zap.NewNop(),
+		defaultRate: 1,
+		rates:       make(map[string]float64),
+		seed:        time.Now().UnixNano(),
+	}
+	for _, opt := range opts {
+		opt.apply(&o)
+	}
+	return o
+}
+
+// Logger is the logger requests are logged to, at the info level.
+//
+// Defaults to not logging.
+func Logger(logger *zap.Logger) Option {
+	return optionFunc(func(o *options) {
+		o.logger = logger
+	})
+}
+
+// DefaultSampleRate is the fraction of requests, between 0 and 1, logged for
+// procedures without a ProcedureSampleRate.
+//
+// Defaults to 1, which logs every request.
+func DefaultSampleRate(rate float64) Option {
+	return optionFunc(func(o *options) {
+		o.defaultRate = rate
+	})
+}
+
+// ProcedureSampleRate is the fraction of requests, between 0 and 1, logged
+// for the named procedure.
+func ProcedureSampleRate(procedure string, rate float64) Option {
+	return optionFunc(func(o *options) {
+		o.rates[procedure] = rate
+	})
+}
+
+// Payloads logs up to the given number of bytes of the body of each sampled
+// request and response.
+//
+// Payloads may hold personal or secret information. Only log them where the
+// logs are as protected as the data.
+//
+// Defaults to zero, which does not log payloads.
+func Payloads(maxBytes int) Option {
+	return optionFunc(func(o *options) {
+		o.maxPayload = maxBytes
+	})
+}
+
+// Disabled builds the middleware disabled, so that it logs nothing until it
+// is enabled with SetEnabled.
+func Disabled() Option {
+	return optionFunc(func(o *options) {
+		o.disabled = true
+	})
+}
+
+// Seed specifies the random seed used to sample requests.
+//
+// Defaults to approximately the time the middleware is built in nanoseconds.
+func Seed(seed int64) Option {
+	return optionFunc(func(o *options) {
+		o.seed = seed
+	})
+}
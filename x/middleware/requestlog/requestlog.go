@@ -0,0 +1,279 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package requestlog provides inbound middleware that logs a sample of the
+// requests a service handles, with their metadata, outcome, latency and,
+// optionally, the beginning of their payloads.
+//
+// The middleware can be enabled, disabled and resampled while the service
+// runs, so that requests can be looked at when debugging a problem in
+// production without redeploying the service.
+//
+// 	reqlog := requestlog.New(
+// 		requestlog.Logger(logger),
+// 		requestlog.DefaultSampleRate(0.01),
+// 		requestlog.ProcedureSampleRate("Payments::charge", 0.1),
+// 		requestlog.Disabled(),
+// 	)
+// 	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+// 		Name: "myservice",
+// 		InboundMiddleware: yarpc.InboundMiddleware{
+// 			Unary:  reqlog,
+// 			Oneway: reqlog,
+// 		},
+// 	})
+//
+// 	// Later, from an admin endpoint:
+// 	reqlog.SetSampleRate("Payments::charge", 1)
+// 	reqlog.SetEnabled(true)
+package requestlog
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"sync"
+	"time"
+
+	"go.uber.org/atomic"
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/zap"
+)
+
+var (
+	_ middleware.UnaryInbound  = (*Middleware)(nil)
+	_ middleware.OnewayInbound = (*Middleware)(nil)
+)
+
+// Middleware is inbound middleware which logs a sample of requests.
+type Middleware struct {
+	logger     *zap.Logger
+	maxPayload int
+	enabled    atomic.Bool
+
+	mu          sync.RWMutex
+	defaultRate float64
+	rates       map[string]float64
+
+	randMu sync.Mutex
+	rand   *rand.Rand
+}
+
+// New builds request logging middleware.
+func New(opts ...Option) *Middleware {
+	o := newOptions(opts)
+	m := &Middleware{
+		logger:      o.logger,
+		maxPayload:  o.maxPayload,
+		defaultRate: o.defaultRate,
+		rates:       o.rates,
+		rand:        rand.New(rand.NewSource(o.seed)),
+	}
+	m.enabled.Store(!o.disabled)
+	return m
+}
+
+// SetEnabled enables or disables logging.
+func (m *Middleware) SetEnabled(enabled bool) {
+	m.enabled.Store(enabled)
+}
+
+// Enabled returns whether requests are being logged.
+func (m *Middleware) Enabled() bool {
+	return m.enabled.Load()
+}
+
+// SetDefaultSampleRate changes the fraction of requests logged for
+// procedures without a sample rate of their own.
+func (m *Middleware) SetDefaultSampleRate(rate float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.defaultRate = rate
+}
+
+// SetSampleRate changes the fraction of requests logged for the named
+// procedure.
+func (m *Middleware) SetSampleRate(procedure string, rate float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rates[procedure] = rate
+}
+
+// ResetSampleRate makes the named procedure use the default sample rate
+// again.
+func (m *Middleware) ResetSampleRate(procedure string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.rates, procedure)
+}
+
+// Handle implements middleware.UnaryInbound.
+func (m *Middleware) Handle(ctx context.Context, req *transport.Request, w transport.ResponseWriter, h transport.UnaryHandler) error {
+	if !m.sampled(req.Procedure) {
+		return h.Handle(ctx, req, w)
+	}
+
+	req, reqBody := m.captureRequest(req)
+	rw := &responseWriter{ResponseWriter: w}
+	if m.maxPayload > 0 {
+		rw.body = newCapture(m.maxPayload)
+	}
+	start := time.Now()
+	err := h.Handle(ctx, req, rw)
+	m.log(transport.Unary, req, time.Since(start), err, rw.applicationError, reqBody, rw.body)
+	return err
+}
+
+// HandleOneway implements middleware.OnewayInbound.
+func (m *Middleware) HandleOneway(ctx context.Context, req *transport.Request, h transport.OnewayHandler) error {
+	if !m.sampled(req.Procedure) {
+		return h.HandleOneway(ctx, req)
+	}
+
+	req, reqBody := m.captureRequest(req)
+	start := time.Now()
+	err := h.HandleOneway(ctx, req)
+	m.log(transport.Oneway, req, time.Since(start), err, false, reqBody, nil)
+	return err
+}
+
+// sampled returns whether to log a request to the named procedure.
+func (m *Middleware) sampled(procedure string) bool {
+	if !m.enabled.Load() {
+		return false
+	}
+
+	m.mu.RLock()
+	rate, ok := m.rates[procedure]
+	if !ok {
+		rate = m.defaultRate
+	}
+	m.mu.RUnlock()
+
+	switch {
+	case rate <= 0:
+		return false
+	case rate >= 1:
+		return true
+	}
+	m.randMu.Lock()
+	defer m.randMu.Unlock()
+	return m.rand.Float64() < rate
+}
+
+// captureRequest returns a copy of the request whose body is captured as
+// the handler reads it, if payloads are logged.
+func (m *Middleware) captureRequest(req *transport.Request) (*transport.Request, *capture) {
+	if m.maxPayload <= 0 || req.Body == nil {
+		return req, nil
+	}
+	body := newCapture(m.maxPayload)
+	r := *req
+	r.Body = io.TeeReader(req.Body, body)
+	return &r, body
+}
+
+func (m *Middleware) log(
+	rpcType transport.Type,
+	req *transport.Request,
+	latency time.Duration,
+	err error,
+	applicationError bool,
+	reqBody, resBody *capture,
+) {
+	fields := []zap.Field{
+		zap.Stringer("rpcType", rpcType),
+		zap.Object("request", req),
+		zap.Duration("latency", latency),
+		zap.Bool("successful", err == nil && !applicationError),
+	}
+	if applicationError {
+		fields = append(fields, zap.Bool("applicationError", true))
+	}
+	if err != nil {
+		fields = append(fields, zap.Error(err))
+	}
+	if reqBody != nil {
+		fields = append(fields, reqBody.fields("requestBody")...)
+	}
+	if resBody != nil {
+		fields = append(fields, resBody.fields("responseBody")...)
+	}
+	m.logger.Info("sampled request", fields...)
+}
+
+// responseWriter records whether the response is an application error and
+// captures its body, if payloads are logged.
+type responseWriter struct {
+	transport.ResponseWriter
+
+	body             *capture
+	applicationError bool
+}
+
+func (w *responseWriter) SetApplicationError() {
+	w.applicationError = true
+	w.ResponseWriter.SetApplicationError()
+}
+
+func (w *responseWriter) SetApplicationErrorMeta(meta *transport.ApplicationErrorMeta) {
+	if setter, ok := w.ResponseWriter.(transport.ApplicationErrorMetaSetter); ok {
+		setter.SetApplicationErrorMeta(meta)
+	}
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	if w.body != nil {
+		w.body.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// capture keeps the first bytes written to it and counts the rest.
+type capture struct {
+	max   int
+	buf   []byte
+	total int
+}
+
+func newCapture(max int) *capture {
+	return &capture{max: max}
+}
+
+func (c *capture) Write(p []byte) (int, error) {
+	c.total += len(p)
+	if n := c.max - len(c.buf); n > 0 {
+		if n > len(p) {
+			n = len(p)
+		}
+		c.buf = append(c.buf, p[:n]...)
+	}
+	return len(p), nil
+}
+
+// fields returns the log fields of the captured bytes under the given key.
+func (c *capture) fields(key string) []zap.Field {
+	fields := []zap.Field{zap.ByteString(key, c.buf)}
+	if c.total > len(c.buf) {
+		fields = append(fields, zap.Int(key+"Size", c.total))
+	}
+	return fields
+}
@@ -0,0 +1,157 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package requestlog
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// echoHandler echoes the request body, failing with an application error if
+// the body says so.
+type echoHandler struct{}
+
+func (echoHandler) Handle(ctx context.Context, req *transport.Request, w transport.ResponseWriter) error {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+	if string(body) == "fail" {
+		w.SetApplicationError()
+	}
+	_, err = w.Write(body)
+	return err
+}
+
+func (echoHandler) HandleOneway(ctx context.Context, req *transport.Request) error {
+	_, err := ioutil.ReadAll(req.Body)
+	return err
+}
+
+func call(t *testing.T, mw *Middleware, procedure, body string) string {
+	res := &transporttest.FakeResponseWriter{}
+	req := &transport.Request{
+		Caller:    "caller",
+		Service:   "service",
+		Procedure: procedure,
+		Body:      bytes.NewBufferString(body),
+	}
+	require.NoError(t, mw.Handle(context.Background(), req, res, echoHandler{}))
+	return res.Body.String()
+}
+
+func TestLogRequest(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	mw := New(Logger(zap.New(core)), Payloads(4))
+
+	assert.Equal(t, "hello", call(t, mw, "Echo::echo", "hello"), "the response must be unchanged")
+	call(t, mw, "Echo::echo", "fail")
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 2)
+
+	fields := entries[0].ContextMap()
+	assert.Equal(t, "Unary", fields["rpcType"])
+	assert.Equal(t, true, fields["successful"])
+	assert.Equal(t, "hell", fields["requestBody"], "payloads must be truncated")
+	assert.Equal(t, int64(5), fields["requestBodySize"])
+	assert.Equal(t, "hell", fields["responseBody"])
+	request, ok := fields["request"].(map[string]interface{})
+	require.True(t, ok, "request must be logged as an object")
+	assert.Equal(t, "Echo::echo", request["procedure"])
+	assert.Equal(t, "caller", request["caller"])
+
+	fields = entries[1].ContextMap()
+	assert.Equal(t, false, fields["successful"])
+	assert.Equal(t, true, fields["applicationError"])
+	assert.Equal(t, "fail", fields["requestBody"])
+	assert.NotContains(t, fields, "requestBodySize")
+}
+
+func TestLogOneway(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	mw := New(Logger(zap.New(core)))
+
+	req := &transport.Request{Procedure: "Echo::echo", Body: bytes.NewBufferString("hello")}
+	require.NoError(t, mw.HandleOneway(context.Background(), req, echoHandler{}))
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 1)
+	fields := entries[0].ContextMap()
+	assert.Equal(t, "Oneway", fields["rpcType"])
+	assert.NotContains(t, fields, "requestBody", "payloads must not be logged by default")
+}
+
+func TestSampling(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	mw := New(
+		Logger(zap.New(core)),
+		DefaultSampleRate(0),
+		ProcedureSampleRate("Echo::sampled", 0.5),
+		Seed(1),
+	)
+
+	for i := 0; i < 1000; i++ {
+		call(t, mw, "Echo::sampled", fmt.Sprint(i))
+		call(t, mw, "Echo::other", fmt.Sprint(i))
+	}
+	assert.InDelta(t, 500, logs.Len(), 50, "half of the requests must be sampled")
+	for _, entry := range logs.AllUntimed() {
+		request := entry.ContextMap()["request"].(map[string]interface{})
+		assert.Equal(t, "Echo::sampled", request["procedure"])
+	}
+}
+
+func TestDynamicConfiguration(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	mw := New(Logger(zap.New(core)), Disabled())
+
+	call(t, mw, "Echo::echo", "hello")
+	assert.Equal(t, 0, logs.Len(), "disabled middleware must not log")
+	assert.False(t, mw.Enabled())
+
+	mw.SetEnabled(true)
+	call(t, mw, "Echo::echo", "hello")
+	assert.Equal(t, 1, logs.Len())
+
+	mw.SetDefaultSampleRate(0)
+	call(t, mw, "Echo::echo", "hello")
+	assert.Equal(t, 1, logs.Len(), "requests must not be logged at a sample rate of zero")
+
+	mw.SetSampleRate("Echo::echo", 1)
+	call(t, mw, "Echo::echo", "hello")
+	assert.Equal(t, 2, logs.Len())
+
+	mw.ResetSampleRate("Echo::echo")
+	call(t, mw, "Echo::echo", "hello")
+	assert.Equal(t, 2, logs.Len(), "the procedure must use the default sample rate again")
+}
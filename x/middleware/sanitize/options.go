@@ -0,0 +1,110 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package sanitize
+
+import (
+	"regexp"
+
+	"go.uber.org/zap"
+)
+
+// Option customizes the behavior of error sanitizing middleware.
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(opts *options) { f(opts) }
+
+type options struct {
+	rules   []rule
+	exempt  map[string]struct{}
+	logger  *zap.Logger
+	noRules bool
+}
+
+// rule replaces the parts of error messages that match a pattern.
+type rule struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		logger: zap.NewNop(),
+		exempt: make(map[string]struct{}),
+	}
+	for _, opt := range opts {
+		opt.apply(&o)
+	}
+	if len(o.rules) == 0 && !o.noRules {
+		for _, p := range DefaultPatterns {
+			o.rules = append(o.rules, rule{pattern: p, replacement: Redacted})
+		}
+	}
+	return o
+}
+
+// Redact replaces the parts of error messages that match any of the
+// patterns with Redacted.
+//
+// Defaults to redacting DefaultPatterns, unless Redact or Replace is given.
+func Redact(patterns ...*regexp.Regexp) Option {
+	return optionFunc(func(o *options) {
+		for _, p := range patterns {
+			o.rules = append(o.rules, rule{pattern: p, replacement: Redacted})
+		}
+		o.noRules = true
+	})
+}
+
+// Replace replaces the parts of error messages that match the pattern with
+// the replacement, which may refer to submatches like regexp.ReplaceAllString.
+// Patterns are applied in the order they are given.
+//
+// Defaults to redacting DefaultPatterns, unless Redact or Replace is given.
+func Replace(pattern *regexp.Regexp, replacement string) Option {
+	return optionFunc(func(o *options) {
+		o.rules = append(o.rules, rule{pattern: pattern, replacement: replacement})
+		o.noRules = true
+	})
+}
+
+// Exempt leaves the errors returned to the named callers as they are, like
+// the services of the same team, which may need the details to debug.
+func Exempt(callers ...string) Option {
+	return optionFunc(func(o *options) {
+		for _, c := range callers {
+			o.exempt[c] = struct{}{}
+		}
+	})
+}
+
+// Logger logs the original messages of the errors that are sanitized, so
+// that the details are not lost to the service itself.
+//
+// Defaults to not logging.
+func Logger(logger *zap.Logger) Option {
+	return optionFunc(func(o *options) {
+		o.logger = logger
+	})
+}
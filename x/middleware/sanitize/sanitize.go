@@ -0,0 +1,145 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package sanitize provides inbound middleware that redacts the messages of
+// the errors a service returns, so that internal details like SQL queries,
+// file paths and personal information do not leak to its callers. Error
+// codes and names are preserved.
+//
+// 	sanitizer := sanitize.New(
+// 		sanitize.Redact(sanitize.DefaultPatterns...),
+// 		sanitize.Replace(regexp.MustCompile(`user \d+`), "user <id>"),
+// 		sanitize.Logger(logger),
+// 	)
+// 	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+// 		Name: "myservice",
+// 		InboundMiddleware: yarpc.InboundMiddleware{
+// 			Unary:  sanitizer,
+// 			Oneway: sanitizer,
+// 			Stream: sanitizer,
+// 		},
+// 	})
+//
+// Only errors are sanitized. Application errors are part of the response
+// body, which the middleware leaves alone.
+package sanitize
+
+import (
+	"context"
+	"regexp"
+
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/zap"
+)
+
+// Redacted replaces the parts of error messages that are redacted.
+const Redacted = "[redacted]"
+
+// Patterns of details that commonly leak into error messages.
+var (
+	// SQLPattern matches SQL statements, up to the end of the line.
+	SQLPattern = regexp.MustCompile(`(?i)\b(?:select\s.*?\sfrom|insert\s+into|update\s.*?\sset|delete\s+from)\b.*`)
+
+	// FilePathPattern matches absolute Unix and Windows file paths.
+	FilePathPattern = regexp.MustCompile(`(?:/[\w.\-]+){2,}/?|\b[A-Za-z]:\\[\w.\-\\]+`)
+
+	// EmailPattern matches email addresses.
+	EmailPattern = regexp.MustCompile(`[\w.+\-]+@[\w\-]+(?:\.[\w\-]+)+`)
+
+	// IPAddressPattern matches IPv4 addresses, with an optional port.
+	IPAddressPattern = regexp.MustCompile(`\b\d{1,3}(?:\.\d{1,3}){3}(?::\d+)?\b`)
+
+	// DefaultPatterns are the patterns redacted by default.
+	DefaultPatterns = []*regexp.Regexp{SQLPattern, FilePathPattern, EmailPattern, IPAddressPattern}
+)
+
+var (
+	_ middleware.UnaryInbound  = (*Middleware)(nil)
+	_ middleware.OnewayInbound = (*Middleware)(nil)
+	_ middleware.StreamInbound = (*Middleware)(nil)
+)
+
+// Middleware is inbound middleware which sanitizes the errors of requests.
+type Middleware struct {
+	opts options
+}
+
+// New builds error sanitizing middleware.
+func New(opts ...Option) *Middleware {
+	return &Middleware{opts: newOptions(opts)}
+}
+
+// Handle implements middleware.UnaryInbound.
+func (m *Middleware) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter, h transport.UnaryHandler) error {
+	return m.sanitize(req.Caller, req.Procedure, h.Handle(ctx, req, resw))
+}
+
+// HandleOneway implements middleware.OnewayInbound.
+func (m *Middleware) HandleOneway(ctx context.Context, req *transport.Request, h transport.OnewayHandler) error {
+	return m.sanitize(req.Caller, req.Procedure, h.HandleOneway(ctx, req))
+}
+
+// HandleStream implements middleware.StreamInbound.
+func (m *Middleware) HandleStream(s *transport.ServerStream, h transport.StreamHandler) error {
+	meta := s.Request().Meta
+	return m.sanitize(meta.Caller, meta.Procedure, h.HandleStream(s))
+}
+
+// Sanitize returns the error with its message sanitized, preserving its
+// code and name. It returns errors with nothing to sanitize as they are.
+func (m *Middleware) Sanitize(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	status := yarpcerrors.FromError(err)
+	message := status.Message()
+	for _, r := range m.opts.rules {
+		message = r.pattern.ReplaceAllString(message, r.replacement)
+	}
+	if message == status.Message() {
+		return err
+	}
+	sanitized := yarpcerrors.Newf(status.Code(), "%s", message)
+	if name := status.Name(); name != "" {
+		sanitized = sanitized.WithName(name)
+	}
+	return sanitized
+}
+
+func (m *Middleware) sanitize(caller, procedure string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := m.opts.exempt[caller]; ok {
+		return err
+	}
+
+	sanitized := m.Sanitize(err)
+	if sanitized != err {
+		m.opts.logger.Info("Sanitized error message.",
+			zap.String("caller", caller),
+			zap.String("procedure", procedure),
+			zap.Error(err))
+	}
+	return sanitized
+}
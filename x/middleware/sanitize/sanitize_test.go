@@ -0,0 +1,159 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package sanitize
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+type errorHandler struct{ err error }
+
+func (h errorHandler) Handle(context.Context, *transport.Request, transport.ResponseWriter) error {
+	return h.err
+}
+
+func (h errorHandler) HandleOneway(context.Context, *transport.Request) error {
+	return h.err
+}
+
+func (h errorHandler) HandleStream(*transport.ServerStream) error {
+	return h.err
+}
+
+func TestDefaultPatterns(t *testing.T) {
+	tests := []struct {
+		give string
+		want string
+	}{
+		{
+			give: `query failed: SELECT * FROM users WHERE email = 'alice@example.com'`,
+			want: "query failed: [redacted]",
+		},
+		{
+			give: "failed to open /var/lib/users/alice.db: permission denied",
+			want: "failed to open [redacted]: permission denied",
+		},
+		{
+			give: `failed to open C:\data\users.db`,
+			want: "failed to open [redacted]",
+		},
+		{
+			give: "no account for bob.smith+test@example.co.uk",
+			want: "no account for [redacted]",
+		},
+		{
+			give: "dial tcp 10.0.12.7:5432: connection refused",
+			want: "dial tcp [redacted]: connection refused",
+		},
+		{
+			give: "user not found",
+			want: "user not found",
+		},
+		{
+			give: "nothing to update",
+			want: "nothing to update",
+		},
+	}
+
+	m := New()
+	for _, tt := range tests {
+		err := m.Sanitize(yarpcerrors.Newf(yarpcerrors.CodeInternal, "%s", tt.give))
+		assert.Equal(t, tt.want, yarpcerrors.FromError(err).Message(), "sanitizing %q", tt.give)
+		assert.Equal(t, yarpcerrors.CodeInternal, yarpcerrors.FromError(err).Code())
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	m := New(
+		Replace(regexp.MustCompile(`user (\d+)`), "user <id>"),
+		Redact(regexp.MustCompile(`secret`)),
+	)
+
+	err := m.Sanitize(yarpcerrors.Newf(yarpcerrors.CodeNotFound, "user 42 has no secret").WithName("no-user"))
+	status := yarpcerrors.FromError(err)
+	assert.Equal(t, yarpcerrors.CodeNotFound, status.Code())
+	assert.Equal(t, "no-user", status.Name())
+	assert.Equal(t, "user <id> has no [redacted]", status.Message())
+
+	err = m.Sanitize(errors.New("user 42 is at /home/user42"))
+	assert.Equal(t, yarpcerrors.CodeUnknown, yarpcerrors.FromError(err).Code())
+	assert.Equal(t, "user <id> is at /home/user42", yarpcerrors.FromError(err).Message(),
+		"default patterns must not apply when patterns are given")
+
+	original := errors.New("great sadness")
+	assert.True(t, original == m.Sanitize(original), "errors with nothing to sanitize must be returned as they are")
+	assert.NoError(t, m.Sanitize(nil))
+}
+
+func TestMiddleware(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	m := New(Exempt("internal"), Logger(zap.New(core)))
+	h := errorHandler{err: yarpcerrors.Newf(yarpcerrors.CodeInternal, "failed to read /etc/users.conf")}
+	const want = "failed to read [redacted]"
+
+	err := m.Handle(context.Background(), &transport.Request{Caller: "external", Procedure: "get"},
+		&transporttest.FakeResponseWriter{}, h)
+	assert.Equal(t, want, yarpcerrors.FromError(err).Message())
+
+	err = m.HandleOneway(context.Background(), &transport.Request{Caller: "external"}, h)
+	assert.Equal(t, want, yarpcerrors.FromError(err).Message())
+
+	stream, err := transport.NewServerStream(fakeStream{meta: &transport.RequestMeta{Caller: "external"}})
+	require.NoError(t, err)
+	err = m.HandleStream(stream, h)
+	assert.Equal(t, want, yarpcerrors.FromError(err).Message())
+
+	err = m.Handle(context.Background(), &transport.Request{Caller: "internal"},
+		&transporttest.FakeResponseWriter{}, h)
+	assert.Equal(t, h.err, err, "exempt callers must get errors as they are")
+
+	assert.NoError(t, m.Handle(context.Background(), &transport.Request{Caller: "external"},
+		&transporttest.FakeResponseWriter{}, errorHandler{}))
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 3)
+	assert.Equal(t, map[string]interface{}{
+		"caller":    "external",
+		"procedure": "get",
+		"error":     "code:internal message:failed to read /etc/users.conf",
+	}, entries[0].ContextMap(), "original errors must be logged")
+}
+
+type fakeStream struct {
+	transport.Stream
+
+	meta *transport.RequestMeta
+}
+
+func (s fakeStream) Request() *transport.StreamRequest {
+	return &transport.StreamRequest{Meta: s.meta}
+}
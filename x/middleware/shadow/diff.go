@@ -0,0 +1,204 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package shadow
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// JSONDiff returns a function, for use with the Diff option, that compares
+// results structurally, treating their bodies as JSON. Bodies that are not
+// JSON are compared byte for byte.
+//
+// Fields are compared by their paths, like "user.emails.0", which are made
+// of object keys and array indexes separated by dots. Fields whose paths
+// match one of the given patterns are ignored, like timestamps or request
+// IDs that differ between any two responses. In patterns, "*" matches any
+// one key or index, and a pattern that matches a field also matches its
+// descendants.
+//
+// 	shadow.Diff(shadow.JSONDiff("updatedAt", "items.*.etag"))
+func JSONDiff(ignore ...string) func(primary, shadow Result) []string {
+	patterns := make([][]string, len(ignore))
+	for i, p := range ignore {
+		patterns[i] = strings.Split(p, ".")
+	}
+
+	return func(primary, shadow Result) []string {
+		if diffs, done := diffOutcomes(primary, shadow); done {
+			return diffs
+		}
+
+		var p, s interface{}
+		if !decodeJSON(primary.Body, &p) || !decodeJSON(shadow.Body, &s) {
+			if !bytes.Equal(primary.Body, shadow.Body) {
+				return []string{"body differs"}
+			}
+			return nil
+		}
+
+		d := differ{ignore: patterns}
+		d.diff(nil, p, s)
+		return d.diffs
+	}
+}
+
+// diffOutcomes describes how results differ in whether and how they failed.
+// It returns false if both results succeeded, and their bodies must be
+// compared.
+func diffOutcomes(primary, shadow Result) (diffs []string, done bool) {
+	switch {
+	case primary.Err != nil && shadow.Err != nil:
+		// Errors of the same code are considered the same.
+		p, s := yarpcerrors.FromError(primary.Err).Code(), yarpcerrors.FromError(shadow.Err).Code()
+		if p != s {
+			return []string{fmt.Sprintf("error code: %v != %v", p, s)}, true
+		}
+		return nil, true
+	case primary.Err != nil:
+		return []string{fmt.Sprintf("error: primary failed with %v, shadow succeeded", primary.Err)}, true
+	case shadow.Err != nil:
+		return []string{fmt.Sprintf("error: shadow failed with %v, primary succeeded", shadow.Err)}, true
+	case primary.ApplicationError != shadow.ApplicationError:
+		return []string{fmt.Sprintf("application error: %v != %v", primary.ApplicationError, shadow.ApplicationError)}, true
+	}
+	return nil, false
+}
+
+func decodeJSON(body []byte, v interface{}) bool {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	return dec.Decode(v) == nil && !dec.More()
+}
+
+// differ collects the differences between two decoded JSON values.
+type differ struct {
+	ignore [][]string
+	diffs  []string
+}
+
+func (d *differ) diff(path []string, p, s interface{}) {
+	if d.ignored(path) {
+		return
+	}
+
+	switch p := p.(type) {
+	case map[string]interface{}:
+		if s, ok := s.(map[string]interface{}); ok {
+			d.diffObjects(path, p, s)
+			return
+		}
+	case []interface{}:
+		if s, ok := s.([]interface{}); ok {
+			d.diffArrays(path, p, s)
+			return
+		}
+	}
+	if !reflect.DeepEqual(p, s) {
+		d.add(path, "%v != %v", format(p), format(s))
+	}
+}
+
+func (d *differ) diffObjects(path []string, p, s map[string]interface{}) {
+	keys := make([]string, 0, len(p)+len(s))
+	for k := range p {
+		keys = append(keys, k)
+	}
+	for k := range s {
+		if _, ok := p[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		field := append(path[:len(path):len(path)], k)
+		pv, inPrimary := p[k]
+		sv, inShadow := s[k]
+		switch {
+		case d.ignored(field):
+		case !inShadow:
+			d.add(field, "missing in shadow")
+		case !inPrimary:
+			d.add(field, "missing in primary")
+		default:
+			d.diff(field, pv, sv)
+		}
+	}
+}
+
+func (d *differ) diffArrays(path []string, p, s []interface{}) {
+	if len(p) != len(s) {
+		d.add(path, "length %v != %v", len(p), len(s))
+	}
+	n := len(p)
+	if len(s) < n {
+		n = len(s)
+	}
+	for i := 0; i < n; i++ {
+		d.diff(append(path[:len(path):len(path)], strconv.Itoa(i)), p[i], s[i])
+	}
+}
+
+func (d *differ) add(path []string, format string, args ...interface{}) {
+	field := "body"
+	if len(path) > 0 {
+		field = strings.Join(path, ".")
+	}
+	d.diffs = append(d.diffs, field+": "+fmt.Sprintf(format, args...))
+}
+
+// ignored returns whether the field at the path matches an ignore pattern.
+func (d *differ) ignored(path []string) bool {
+	for _, pattern := range d.ignore {
+		if len(pattern) > len(path) {
+			continue
+		}
+		matched := true
+		for i, segment := range pattern {
+			if segment != "*" && segment != path[i] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// format formats a decoded JSON value as JSON.
+func format(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
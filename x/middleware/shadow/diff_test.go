@@ -0,0 +1,117 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package shadow
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+func TestJSONDiff(t *testing.T) {
+	tests := []struct {
+		desc    string
+		ignore  []string
+		primary Result
+		shadow  Result
+		want    []string
+	}{
+		{
+			desc:    "same fields in a different order",
+			primary: Result{Body: []byte(`{"id": 1, "name": "alice"}`)},
+			shadow:  Result{Body: []byte(`{"name":"alice","id":1}`)},
+		},
+		{
+			desc:    "changed, missing and extra fields",
+			primary: Result{Body: []byte(`{"id": 1, "name": "alice", "tags": ["a", "b"], "age": 30}`)},
+			shadow:  Result{Body: []byte(`{"id": 1.0, "name": "bob", "tags": ["a", "c", "d"], "email": "bob@example.com"}`)},
+			want: []string{
+				"age: missing in shadow",
+				"email: missing in primary",
+				"id: 1 != 1.0",
+				`name: "alice" != "bob"`,
+				"tags: length 2 != 3",
+				`tags.1: "b" != "c"`,
+			},
+		},
+		{
+			desc:    "ignored fields",
+			ignore:  []string{"updatedAt", "items.*.etag", "debug"},
+			primary: Result{Body: []byte(`{"updatedAt": 1, "items": [{"id": 1, "etag": "x"}], "debug": {"host": "a"}}`)},
+			shadow:  Result{Body: []byte(`{"updatedAt": 2, "items": [{"id": 2, "etag": "y"}], "debug": {"host": "b"}}`)},
+			want:    []string{"items.0.id: 1 != 2"},
+		},
+		{
+			desc:    "different types",
+			primary: Result{Body: []byte(`{"items": []}`)},
+			shadow:  Result{Body: []byte(`{"items": {}}`)},
+			want:    []string{"items: [] != {}"},
+		},
+		{
+			desc:    "scalar bodies",
+			primary: Result{Body: []byte(`true`)},
+			shadow:  Result{Body: []byte(`false`)},
+			want:    []string{"body: true != false"},
+		},
+		{
+			desc:    "bodies that are not JSON",
+			primary: Result{Body: []byte("hello")},
+			shadow:  Result{Body: []byte("hello world")},
+			want:    []string{"body differs"},
+		},
+		{
+			desc:    "identical bodies that are not JSON",
+			primary: Result{Body: []byte("hello")},
+			shadow:  Result{Body: []byte("hello")},
+		},
+		{
+			desc:    "errors of the same code",
+			primary: Result{Err: yarpcerrors.Newf(yarpcerrors.CodeNotFound, "no alice")},
+			shadow:  Result{Err: yarpcerrors.Newf(yarpcerrors.CodeNotFound, "alice not found")},
+		},
+		{
+			desc:    "errors of different codes",
+			primary: Result{Err: yarpcerrors.Newf(yarpcerrors.CodeNotFound, "no alice")},
+			shadow:  Result{Err: errors.New("great sadness")},
+			want:    []string{"error code: not-found != unknown"},
+		},
+		{
+			desc:    "shadow failure",
+			primary: Result{Body: []byte(`{}`)},
+			shadow:  Result{Err: errors.New("great sadness")},
+			want:    []string{"error: shadow failed with great sadness, primary succeeded"},
+		},
+		{
+			desc:    "application errors",
+			primary: Result{Body: []byte(`{}`), ApplicationError: true},
+			shadow:  Result{Body: []byte(`{}`)},
+			want:    []string{"application error: true != false"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			assert.Equal(t, tt.want, JSONDiff(tt.ignore...)(tt.primary, tt.shadow))
+		})
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package shadow
+
+import (
+	"time"
+
+	"go.uber.org/net/metrics"
+	"go.uber.org/zap"
+)
+
+// Option customizes the behavior of shadowing middleware.
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(opts *options) { f(opts) }
+
+type options struct {
+	percent     float64
+	procedures  map[string]struct{}
+	timeout     time.Duration
+	maxInFlight int
+	compare     func(primary, shadow Result) bool
+	diff        func(primary, shadow Result) []string
+	meter       *metrics.Scope
+	logger      *zap.Logger
+	seed        int64
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		percent:     100,
+		timeout:     time.Second,
+		maxInFlight: 100,
+		compare:     Equal,
+		logger:      zap.NewNop(),
+		seed:        time.Now().UnixNano(),
+	}
+	for _, opt := range opts {
+		opt.apply(&o)
+	}
+	return o
+}
+
+// Percent is the percentage of requests, between 0 and 100, mirrored to the
+// shadow outbound.
+//
+// Defaults to 100, which mirrors every request.
+func Percent(percent float64) Option {
+	return optionFunc(func(o *options) {
+		o.percent = percent
+	})
+}
+
+// Procedures only mirrors requests to the named procedures.
+//
+// Defaults to mirroring requests to every procedure.
+func Procedures(procedures ...string) Option {
+	return optionFunc(func(o *options) {
+		if o.procedures == nil {
+			o.procedures = make(map[string]struct{}, len(procedures))
+		}
+		for _, p := range procedures {
+			o.procedures[p] = struct{}{}
+		}
+	})
+}
+
+// Timeout bounds how long mirrored requests may take when the original
+// request has no deadline. Mirrored requests otherwise get as long as the
+// original request.
+//
+// Defaults to one second.
+func Timeout(d time.Duration) Option {
+	return optionFunc(func(o *options) {
+		o.timeout = d
+	})
+}
+
+// MaxInFlight bounds the number of mirrored requests in flight. Requests
+// that would exceed it are not mirrored, so that a slow shadow cannot pile
+// up goroutines. A limit of zero or less leaves them unbounded.
+//
+// Defaults to 100.
+func MaxInFlight(n int) Option {
+	return optionFunc(func(o *options) {
+		o.maxInFlight = n
+	})
+}
+
+// Compare decides whether the response of the shadow outbound matches the
+// response of the primary outbound, like a function that ignores fields
+// that differ between any two responses, such as timestamps.
+//
+// Defaults to Equal.
+func Compare(compare func(primary, shadow Result) bool) Option {
+	return optionFunc(func(o *options) {
+		o.compare = compare
+	})
+}
+
+// Diff describes how the response of the shadow outbound differs from the
+// response of the primary outbound, like JSONDiff. The responses match if
+// it finds no differences. The differences of mismatched responses are
+// logged with the Logger.
+//
+// Diff takes precedence over Compare.
+func Diff(diff func(primary, shadow Result) []string) Option {
+	return optionFunc(func(o *options) {
+		o.diff = diff
+	})
+}
+
+// Logger logs the mirrored requests whose responses do not match, along with
+// how they differ if Diff is used.
+//
+// Defaults to not logging.
+func Logger(logger *zap.Logger) Option {
+	return optionFunc(func(o *options) {
+		o.logger = logger
+	})
+}
+
+// Meter records the number of mirrored requests by procedure and by whether
+// their response matched the response of the primary outbound in the given
+// scope.
+func Meter(meter *metrics.Scope) Option {
+	return optionFunc(func(o *options) {
+		o.meter = meter
+	})
+}
+
+// Seed specifies the random seed used to choose which requests to mirror.
+//
+// Defaults to approximately the time the middleware is built in nanoseconds.
+func Seed(seed int64) Option {
+	return optionFunc(func(o *options) {
+		o.seed = seed
+	})
+}
@@ -0,0 +1,286 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package shadow provides outbound middleware that mirrors a percentage of
+// requests to a shadow outbound, like a new implementation of a service,
+// so that it can be tried on production traffic before it takes any.
+//
+// Mirrored requests are sent asynchronously, alongside the original
+// requests. The responses of the shadow outbound are never returned to the
+// caller. They are compared with the responses of the primary outbound, and
+// the number of matches and mismatches is recorded for each procedure.
+// Responses may be compared structurally with JSONDiff, which ignores the
+// fields that are expected to differ and describes the differences of
+// mismatches for the logs.
+//
+// 	shadowOutbound := http.NewTransport().NewSingleOutbound("http://users-v2:8080")
+// 	mirror := shadow.New(shadowOutbound, shadow.Percent(5), shadow.Meter(scope))
+// 	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+// 		Name: "myservice",
+// 		Outbounds: yarpc.Outbounds{
+// 			"users": {
+// 				Unary: middleware.ApplyUnaryOutbound(usersOutbound, mirror),
+// 			},
+// 		},
+// 	})
+//
+// The shadow outbound is not part of the dispatcher, so it must be started
+// and stopped along with it. Call Wait before stopping the shadow outbound
+// to let the mirrored requests in flight finish.
+//
+// Mirrored requests are handled by the shadow as if they were real, so only
+// requests without side effects, or a shadow whose side effects do not
+// matter, should be mirrored.
+package shadow
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"math/rand"
+	"sync"
+
+	"go.uber.org/net/metrics"
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/zap"
+)
+
+const (
+	_procedureTag = "procedure"
+	_resultTag    = "result"
+
+	_match    = "match"
+	_mismatch = "mismatch"
+	_dropped  = "dropped"
+)
+
+var _ middleware.UnaryOutbound = (*Middleware)(nil)
+
+// Result is the result of a request to the primary or shadow outbound.
+type Result struct {
+	// Body is the body of the response.
+	Body []byte
+
+	// Headers are the headers of the response.
+	Headers transport.Headers
+
+	// ApplicationError is whether the response is an application error.
+	ApplicationError bool
+
+	// Err is the error the request failed with, if any.
+	Err error
+}
+
+// Equal returns whether two results are the same: either they failed with
+// errors of the same code, or they are both application errors or both
+// successes with the same body.
+func Equal(primary, shadow Result) bool {
+	if primary.Err != nil || shadow.Err != nil {
+		return primary.Err != nil && shadow.Err != nil &&
+			yarpcerrors.FromError(primary.Err).Code() == yarpcerrors.FromError(shadow.Err).Code()
+	}
+	return primary.ApplicationError == shadow.ApplicationError && bytes.Equal(primary.Body, shadow.Body)
+}
+
+// Middleware is unary outbound middleware which mirrors requests to a shadow
+// outbound.
+type Middleware struct {
+	shadow transport.UnaryOutbound
+	opts   options
+
+	slots   chan struct{}
+	wg      sync.WaitGroup
+	results *metrics.CounterVector
+
+	randMu sync.Mutex
+	rand   *rand.Rand
+}
+
+// New builds shadowing middleware that mirrors requests to the given
+// outbound.
+func New(shadow transport.UnaryOutbound, opts ...Option) *Middleware {
+	o := newOptions(opts)
+	// Errors are ignored because these metrics are unique to this
+	// middleware; at worst, they are not recorded.
+	results, _ := o.meter.CounterVector(metrics.Spec{
+		Name:    "shadow_requests",
+		Help:    "Number of requests mirrored to the shadow outbound, by whether its response matched.",
+		VarTags: []string{_procedureTag, _resultTag},
+	})
+	m := &Middleware{
+		shadow:  shadow,
+		opts:    o,
+		results: results,
+		rand:    rand.New(rand.NewSource(o.seed)),
+	}
+	if o.maxInFlight > 0 {
+		m.slots = make(chan struct{}, o.maxInFlight)
+	}
+	return m
+}
+
+// Wait waits for the mirrored requests in flight to finish.
+func (m *Middleware) Wait() {
+	m.wg.Wait()
+}
+
+// Call implements middleware.UnaryOutbound.
+func (m *Middleware) Call(ctx context.Context, req *transport.Request, out transport.UnaryOutbound) (*transport.Response, error) {
+	if !m.mirrored(req.Procedure) {
+		return out.Call(ctx, req)
+	}
+	if !m.acquire() {
+		m.results.MustGet(_procedureTag, req.Procedure, _resultTag, _dropped).Inc()
+		return out.Call(ctx, req)
+	}
+
+	// The body is read up front so that both outbounds can send it.
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(req.Body); err != nil {
+			m.release()
+			return nil, err
+		}
+	}
+	primaryReq := *req
+	primaryReq.Body = bytes.NewReader(body)
+	shadowReq := *req
+	shadowReq.Body = bytes.NewReader(body)
+
+	// The mirrored request outlives the original request, so it gets a
+	// context of its own.
+	shadowCtx, cancel := context.WithTimeout(context.Background(), m.opts.timeout)
+	if deadline, ok := ctx.Deadline(); ok {
+		cancel()
+		shadowCtx, cancel = context.WithDeadline(context.Background(), deadline)
+	}
+
+	primary := make(chan Result, 1)
+	m.wg.Add(1)
+	go m.mirror(shadowCtx, cancel, &shadowReq, primary)
+
+	res, err := out.Call(ctx, &primaryReq)
+	if err != nil {
+		primary <- Result{Err: err}
+		return nil, err
+	}
+
+	// The body of the response is buffered so that it can be both compared
+	// and returned.
+	result := Result{Headers: res.Headers, ApplicationError: res.ApplicationError}
+	if res.Body != nil {
+		result.Body, err = ioutil.ReadAll(res.Body)
+		if closeErr := res.Body.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			primary <- Result{Err: err}
+			return nil, err
+		}
+		res.Body = ioutil.NopCloser(bytes.NewReader(result.Body))
+	}
+	primary <- result
+	return res, nil
+}
+
+// mirror sends the request to the shadow outbound and compares its response
+// with the response of the primary outbound.
+func (m *Middleware) mirror(ctx context.Context, cancel context.CancelFunc, req *transport.Request, primary <-chan Result) {
+	defer m.wg.Done()
+	defer m.release()
+	defer cancel()
+
+	var result Result
+	res, err := m.shadow.Call(ctx, req)
+	if err != nil {
+		result.Err = err
+	} else {
+		result.Headers = res.Headers
+		result.ApplicationError = res.ApplicationError
+		if res.Body != nil {
+			result.Body, result.Err = ioutil.ReadAll(res.Body)
+			res.Body.Close()
+		}
+	}
+
+	var (
+		primaryResult = <-primary
+		matched       bool
+		diffs         []string
+	)
+	if m.opts.diff != nil {
+		diffs = m.opts.diff(primaryResult, result)
+		matched = len(diffs) == 0
+	} else {
+		matched = m.opts.compare(primaryResult, result)
+	}
+
+	outcome := _mismatch
+	if matched {
+		outcome = _match
+	} else {
+		m.opts.logger.Info("Shadow response did not match.",
+			zap.String("procedure", req.Procedure),
+			zap.Strings("differences", diffs))
+	}
+	m.results.MustGet(_procedureTag, req.Procedure, _resultTag, outcome).Inc()
+}
+
+// mirrored returns whether to mirror a request to the named procedure.
+func (m *Middleware) mirrored(procedure string) bool {
+	if m.opts.procedures != nil {
+		if _, ok := m.opts.procedures[procedure]; !ok {
+			return false
+		}
+	}
+
+	switch {
+	case m.opts.percent <= 0:
+		return false
+	case m.opts.percent >= 100:
+		return true
+	}
+	m.randMu.Lock()
+	defer m.randMu.Unlock()
+	return m.rand.Float64()*100 < m.opts.percent
+}
+
+// acquire takes a slot for a mirrored request, and returns whether one was
+// free.
+func (m *Middleware) acquire() bool {
+	if m.slots == nil {
+		return true
+	}
+	select {
+	case m.slots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (m *Middleware) release() {
+	if m.slots != nil {
+		<-m.slots
+	}
+}
@@ -0,0 +1,217 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package shadow
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/net/metrics"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// fakeOutbound answers requests with a prefix and the request body, and
+// records the requests it receives.
+type fakeOutbound struct {
+	transport.Outbound
+
+	prefix string
+	err    error
+	block  chan struct{}
+
+	mu       sync.Mutex
+	requests []string
+	deadline bool
+}
+
+func (o *fakeOutbound) Call(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	if o.block != nil {
+		<-o.block
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	o.mu.Lock()
+	o.requests = append(o.requests, string(body))
+	_, o.deadline = ctx.Deadline()
+	o.mu.Unlock()
+
+	if o.err != nil {
+		return nil, o.err
+	}
+	return &transport.Response{Body: ioutil.NopCloser(bytes.NewBufferString(o.prefix + string(body)))}, nil
+}
+
+func (o *fakeOutbound) received() []string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]string(nil), o.requests...)
+}
+
+func call(t *testing.T, mw *Middleware, out transport.UnaryOutbound, procedure, body string) string {
+	res, err := mw.Call(context.Background(), &transport.Request{
+		Procedure: procedure,
+		Body:      bytes.NewBufferString(body),
+	}, out)
+	require.NoError(t, err)
+	got, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	return string(got)
+}
+
+func results(root *metrics.Root) map[string]int64 {
+	counts := make(map[string]int64)
+	for _, c := range root.Snapshot().Counters {
+		counts[c.Tags[_procedureTag]+" "+c.Tags[_resultTag]] = c.Value
+	}
+	return counts
+}
+
+func TestShadow(t *testing.T) {
+	root := metrics.New()
+	primary := &fakeOutbound{}
+	shadow := &fakeOutbound{}
+	mw := New(shadow, Meter(root.Scope()))
+
+	// The shadow is only changed once the mirrored requests are done.
+	assert.Equal(t, "hello", call(t, mw, primary, "Users::get", "hello"), "the primary response must be returned")
+	mw.Wait()
+	shadow.prefix = "v2 "
+	assert.Equal(t, "hello", call(t, mw, primary, "Users::get", "hello"), "the shadow response must be discarded")
+	mw.Wait()
+	shadow.err = yarpcerrors.Newf(yarpcerrors.CodeInternal, "great sadness")
+	assert.Equal(t, "hello", call(t, mw, primary, "Users::get", "hello"), "shadow errors must be discarded")
+	mw.Wait()
+
+	assert.Equal(t, []string{"hello", "hello", "hello"}, primary.received())
+	assert.Equal(t, []string{"hello", "hello", "hello"}, shadow.received(), "the request body must be mirrored")
+	assert.True(t, shadow.deadline, "mirrored requests must have a deadline")
+	assert.Equal(t, map[string]int64{
+		"Users::get match":    1,
+		"Users::get mismatch": 2,
+	}, results(root))
+}
+
+func TestShadowErrors(t *testing.T) {
+	root := metrics.New()
+	primary := &fakeOutbound{err: yarpcerrors.Newf(yarpcerrors.CodeNotFound, "no such user")}
+	shadow := &fakeOutbound{err: yarpcerrors.Newf(yarpcerrors.CodeNotFound, "user not found")}
+	mw := New(shadow, Meter(root.Scope()))
+
+	_, err := mw.Call(context.Background(), &transport.Request{Procedure: "Users::get", Body: &bytes.Buffer{}}, primary)
+	assert.Equal(t, primary.err, err)
+	mw.Wait()
+	assert.Equal(t, map[string]int64{"Users::get match": 1}, results(root), "errors of the same code must match")
+}
+
+func TestShadowProcedures(t *testing.T) {
+	primary := &fakeOutbound{}
+	shadow := &fakeOutbound{}
+	mw := New(shadow, Procedures("Users::get"), Percent(50), MaxInFlight(0), Seed(1))
+
+	for i := 0; i < 1000; i++ {
+		call(t, mw, primary, "Users::get", "get")
+		call(t, mw, primary, "Users::delete", "delete")
+	}
+	mw.Wait()
+
+	mirrored := shadow.received()
+	assert.InDelta(t, 500, len(mirrored), 50, "half of the requests must be mirrored")
+	assert.NotContains(t, mirrored, "delete", "only the given procedures must be mirrored")
+}
+
+func TestShadowMaxInFlight(t *testing.T) {
+	root := metrics.New()
+	primary := &fakeOutbound{}
+	shadow := &fakeOutbound{block: make(chan struct{})}
+	mw := New(shadow, MaxInFlight(1), Meter(root.Scope()))
+
+	call(t, mw, primary, "Users::get", "first")
+	call(t, mw, primary, "Users::get", "second")
+	close(shadow.block)
+	mw.Wait()
+
+	assert.Equal(t, []string{"first", "second"}, primary.received())
+	assert.Equal(t, []string{"first"}, shadow.received(), "requests must not be mirrored while the shadow is saturated")
+	assert.Equal(t, map[string]int64{
+		"Users::get match":   1,
+		"Users::get dropped": 1,
+	}, results(root))
+}
+
+func TestShadowCompare(t *testing.T) {
+	root := metrics.New()
+	shadow := &fakeOutbound{prefix: "v2 "}
+	mw := New(shadow, Meter(root.Scope()), Compare(func(primary, shadow Result) bool {
+		return bytes.HasSuffix(shadow.Body, primary.Body)
+	}))
+
+	call(t, mw, &fakeOutbound{}, "Users::get", "hello")
+	mw.Wait()
+	assert.Equal(t, map[string]int64{"Users::get match": 1}, results(root))
+}
+
+func TestShadowDiff(t *testing.T) {
+	root := metrics.New()
+	core, logs := observer.New(zap.InfoLevel)
+	shadow := &fakeOutbound{prefix: `{"version": 2, "name": `}
+	mw := New(shadow, Meter(root.Scope()), Logger(zap.New(core)), Diff(JSONDiff("version")))
+
+	call(t, mw, &fakeOutbound{prefix: `{"version": 1, "name": `}, "Users::get", `"alice"}`)
+	mw.Wait()
+	call(t, mw, &fakeOutbound{prefix: `{"version": 1, "name": "bob", "alias": `}, "Users::get", `"alice"}`)
+	mw.Wait()
+	assert.Equal(t, map[string]int64{"Users::get match": 1, "Users::get mismatch": 1}, results(root))
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 1, "only mismatches must be logged")
+	assert.Equal(t, map[string]interface{}{
+		"procedure":   "Users::get",
+		"differences": []interface{}{"alias: missing in shadow", `name: "bob" != "alice"`},
+	}, entries[0].ContextMap())
+}
+
+func TestShadowTimeout(t *testing.T) {
+	shadow := &fakeOutbound{block: make(chan struct{})}
+	mw := New(shadow, Timeout(time.Minute))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err := mw.Call(ctx, &transport.Request{Procedure: "Users::get", Body: &bytes.Buffer{}}, &fakeOutbound{})
+	require.NoError(t, err)
+	cancel()
+	close(shadow.block)
+	mw.Wait()
+	assert.Len(t, shadow.received(), 1, "mirrored requests must outlive the original request")
+}
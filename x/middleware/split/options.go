@@ -0,0 +1,81 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package split
+
+import (
+	"time"
+
+	"go.uber.org/yarpc/api/transport"
+)
+
+// Option customizes the behavior of traffic splitting middleware.
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(opts *options) { f(opts) }
+
+type options struct {
+	sticky func(*transport.Request) string
+	seed   int64
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		seed: time.Now().UnixNano(),
+	}
+	for _, opt := range opts {
+		opt.apply(&o)
+	}
+	return o
+}
+
+// Sticky assigns requests with the same key to the same target, as long as
+// the percentages of the targets do not change, instead of assigning each
+// request at random. With a single target, raising its percentage only moves
+// requests from the wrapped outbound to the target.
+//
+// Requests with an empty key are assigned at random.
+func Sticky(key func(*transport.Request) string) Option {
+	return optionFunc(func(o *options) {
+		o.sticky = key
+	})
+}
+
+// StickyHeader assigns requests with the same value of the named header to
+// the same target, like a user or tenant ID. See Sticky.
+func StickyHeader(name string) Option {
+	return Sticky(func(req *transport.Request) string {
+		v, _ := req.Headers.Get(name)
+		return v
+	})
+}
+
+// Seed specifies the random seed used to assign requests to targets.
+//
+// Defaults to approximately the time the middleware is built in nanoseconds.
+func Seed(seed int64) Option {
+	return optionFunc(func(o *options) {
+		o.seed = seed
+	})
+}
@@ -0,0 +1,247 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package split provides outbound middleware that splits the traffic to a
+// service between the outbound it wraps and other outbounds for the same
+// service, like the outbound of a canary deployment.
+//
+// 	canary := http.NewTransport().NewSingleOutbound("http://canary:8080")
+// 	splitter, err := split.New([]split.Target{
+// 		{Name: "canary", Unary: canary, Percent: 5},
+// 	}, split.StickyHeader("x-user-id"))
+// 	if err != nil {
+// 		log.Fatal(err)
+// 	}
+// 	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+// 		Name: "myservice",
+// 		Outbounds: yarpc.Outbounds{
+// 			"users":        {Unary: stable},
+// 			"users-canary": {ServiceName: "users", Unary: canary},
+// 		},
+// 		OutboundMiddleware: yarpc.OutboundMiddleware{
+// 			Unary: splitter,
+// 		},
+// 	})
+//
+// Registering the outbounds of the targets with the dispatcher, as above,
+// lets it start and stop them along with the other outbounds.
+//
+// The targets may be replaced while the service runs with SetTargets, for
+// example to raise the percentage of a rollout when its configuration
+// changes.
+package split
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+)
+
+var (
+	_ middleware.UnaryOutbound  = (*Middleware)(nil)
+	_ middleware.OnewayOutbound = (*Middleware)(nil)
+)
+
+// Target is an outbound that receives part of the traffic to a service.
+type Target struct {
+	// Name identifies the target.
+	Name string
+
+	// Unary and Oneway are the outbounds of the target. Requests of an RPC
+	// type the target has no outbound for are never sent to it.
+	Unary  transport.UnaryOutbound
+	Oneway transport.OnewayOutbound
+
+	// Percent is the percentage of requests, between 0 and 100, sent to the
+	// target.
+	Percent float64
+
+	// Headers, if set, sends every request with all of these headers to the
+	// target, regardless of its percentage. Header names are
+	// case-insensitive.
+	Headers map[string]string
+
+	// Procedures, if set, only splits the requests to the named procedures.
+	Procedures []string
+}
+
+// matches returns whether the request has all the headers of the target.
+func (t *Target) matches(req *transport.Request) bool {
+	if len(t.Headers) == 0 {
+		return false
+	}
+	for k, want := range t.Headers {
+		if got, ok := req.Headers.Get(k); !ok || got != want {
+			return false
+		}
+	}
+	return true
+}
+
+// splits returns whether the target takes part in splitting the requests
+// to the named procedure.
+func (t *Target) splits(procedure string) bool {
+	if len(t.Procedures) == 0 {
+		return true
+	}
+	for _, p := range t.Procedures {
+		if p == procedure {
+			return true
+		}
+	}
+	return false
+}
+
+// validateTargets returns an error if the targets cannot be split between.
+func validateTargets(targets []Target) error {
+	names := make(map[string]struct{}, len(targets))
+	total := 0.0
+	for _, t := range targets {
+		if t.Name == "" {
+			return fmt.Errorf("targets must have a name")
+		}
+		if _, ok := names[t.Name]; ok {
+			return fmt.Errorf("target %q is given more than once", t.Name)
+		}
+		names[t.Name] = struct{}{}
+
+		if t.Unary == nil && t.Oneway == nil {
+			return fmt.Errorf("target %q has no outbound", t.Name)
+		}
+		if t.Percent < 0 || t.Percent > 100 {
+			return fmt.Errorf("percentage of target %q must be between 0 and 100, got %v", t.Name, t.Percent)
+		}
+		total += t.Percent
+	}
+	if total > 100 {
+		return fmt.Errorf("percentages of targets must add up to at most 100, got %v", total)
+	}
+	return nil
+}
+
+// Middleware is outbound middleware which sends part of the requests it
+// sees to other outbounds.
+type Middleware struct {
+	opts    options
+	targets atomic.Value // []Target
+
+	randMu sync.Mutex
+	rand   *rand.Rand
+}
+
+// New builds traffic splitting middleware that sends each target its
+// percentage of the requests, and the remaining requests to the wrapped
+// outbound.
+func New(targets []Target, opts ...Option) (*Middleware, error) {
+	m := &Middleware{opts: newOptions(opts)}
+	m.rand = rand.New(rand.NewSource(m.opts.seed))
+	if err := m.SetTargets(targets...); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// SetTargets replaces the targets of the middleware. It is safe to call
+// while requests are being made. The targets are left unchanged if they
+// are invalid.
+func (m *Middleware) SetTargets(targets ...Target) error {
+	if err := validateTargets(targets); err != nil {
+		return err
+	}
+	m.targets.Store(append([]Target(nil), targets...))
+	return nil
+}
+
+// Targets returns the current targets of the middleware.
+func (m *Middleware) Targets() []Target {
+	return append([]Target(nil), m.targets.Load().([]Target)...)
+}
+
+// Call implements middleware.UnaryOutbound.
+func (m *Middleware) Call(ctx context.Context, req *transport.Request, out transport.UnaryOutbound) (*transport.Response, error) {
+	if t := m.target(req, func(t *Target) bool { return t.Unary != nil }); t != nil {
+		return t.Unary.Call(ctx, req)
+	}
+	return out.Call(ctx, req)
+}
+
+// CallOneway implements middleware.OnewayOutbound.
+func (m *Middleware) CallOneway(ctx context.Context, req *transport.Request, out transport.OnewayOutbound) (transport.Ack, error) {
+	if t := m.target(req, func(t *Target) bool { return t.Oneway != nil }); t != nil {
+		return t.Oneway.CallOneway(ctx, req)
+	}
+	return out.CallOneway(ctx, req)
+}
+
+// target returns the target to send the request to, or nil to send it to
+// the wrapped outbound. Targets without an outbound for the request, as
+// reported by ok, are skipped.
+func (m *Middleware) target(req *transport.Request, ok func(*Target) bool) *Target {
+	targets := m.targets.Load().([]Target)
+	if len(targets) == 0 {
+		return nil
+	}
+
+	for i := range targets {
+		t := &targets[i]
+		if ok(t) && t.splits(req.Procedure) && t.matches(req) {
+			return t
+		}
+	}
+
+	bucket := m.bucket(req)
+	total := 0.0
+	for i := range targets {
+		t := &targets[i]
+		if !t.splits(req.Procedure) {
+			continue
+		}
+		total += t.Percent
+		if bucket < total {
+			if ok(t) {
+				return t
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+// bucket returns a number in [0, 100) that places the request among the
+// targets. Sticky keys always get the same number.
+func (m *Middleware) bucket(req *transport.Request) float64 {
+	if m.opts.sticky != nil {
+		if key := m.opts.sticky(req); key != "" {
+			h := fnv.New32a()
+			h.Write([]byte(key))
+			return float64(h.Sum32()%10000) / 100
+		}
+	}
+
+	m.randMu.Lock()
+	defer m.randMu.Unlock()
+	return m.rand.Float64() * 100
+}
@@ -0,0 +1,189 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package split
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+)
+
+// countingOutbound counts the requests it receives.
+type countingOutbound struct {
+	transport.Outbound
+
+	calls int
+}
+
+func (o *countingOutbound) Call(context.Context, *transport.Request) (*transport.Response, error) {
+	o.calls++
+	return &transport.Response{}, nil
+}
+
+func (o *countingOutbound) CallOneway(context.Context, *transport.Request) (transport.Ack, error) {
+	o.calls++
+	return nil, nil
+}
+
+func TestSplit(t *testing.T) {
+	stable, canary, next := &countingOutbound{}, &countingOutbound{}, &countingOutbound{}
+	m, err := New([]Target{
+		{Name: "canary", Unary: canary, Percent: 10},
+		{Name: "next", Unary: next, Oneway: next, Percent: 20},
+	}, Seed(1))
+	require.NoError(t, err)
+
+	const n = 10000
+	for i := 0; i < n; i++ {
+		_, err := m.Call(context.Background(), &transport.Request{Procedure: "get"}, stable)
+		require.NoError(t, err)
+	}
+	assert.InDelta(t, 0.1*n, canary.calls, 0.02*n)
+	assert.InDelta(t, 0.2*n, next.calls, 0.02*n)
+	assert.Equal(t, n, stable.calls+canary.calls+next.calls)
+
+	stable.calls, canary.calls, next.calls = 0, 0, 0
+	for i := 0; i < n; i++ {
+		_, err := m.CallOneway(context.Background(), &transport.Request{Procedure: "notify"}, stable)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 0, canary.calls, "targets without an outbound for the RPC type must not get requests")
+	assert.InDelta(t, 0.2*n, next.calls, 0.02*n)
+	assert.InDelta(t, 0.8*n, stable.calls, 0.02*n, "the share of skipped targets must go to the wrapped outbound")
+}
+
+func TestHeadersAndProcedures(t *testing.T) {
+	stable, canary := &countingOutbound{}, &countingOutbound{}
+	m, err := New([]Target{{
+		Name:       "canary",
+		Unary:      canary,
+		Percent:    0,
+		Headers:    map[string]string{"X-Canary": "true"},
+		Procedures: []string{"get"},
+	}})
+	require.NoError(t, err)
+
+	call := func(procedure string, headers transport.Headers) {
+		_, err := m.Call(context.Background(), &transport.Request{Procedure: procedure, Headers: headers}, stable)
+		require.NoError(t, err)
+	}
+
+	call("get", transport.NewHeaders().With("x-canary", "true"))
+	assert.Equal(t, 1, canary.calls, "requests with the headers of a target must be sent to it")
+
+	call("get", transport.NewHeaders().With("x-canary", "false"))
+	call("get", transport.NewHeaders())
+	call("put", transport.NewHeaders().With("x-canary", "true"))
+	assert.Equal(t, 1, canary.calls)
+	assert.Equal(t, 3, stable.calls)
+}
+
+func TestSticky(t *testing.T) {
+	stable, canary := &countingOutbound{}, &countingOutbound{}
+	m, err := New([]Target{{Name: "canary", Unary: canary, Percent: 50}}, StickyHeader("user"))
+	require.NoError(t, err)
+
+	assignments := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		user := fmt.Sprint(i)
+		req := &transport.Request{Headers: transport.NewHeaders().With("user", user)}
+		before := canary.calls
+		_, err := m.Call(context.Background(), req, stable)
+		require.NoError(t, err)
+		assignments[user] = canary.calls > before
+	}
+	assert.InDelta(t, 50, canary.calls, 20)
+
+	// Raising the percentage must keep the users on the canary there.
+	require.NoError(t, m.SetTargets(Target{Name: "canary", Unary: canary, Percent: 80}))
+	for user, onCanary := range assignments {
+		req := &transport.Request{Headers: transport.NewHeaders().With("user", user)}
+		before := canary.calls
+		_, err := m.Call(context.Background(), req, stable)
+		require.NoError(t, err)
+		if onCanary {
+			assert.True(t, canary.calls > before, "user %v must stay on the canary", user)
+		}
+	}
+}
+
+func TestSetTargets(t *testing.T) {
+	stable, canary := &countingOutbound{}, &countingOutbound{}
+	m, err := New(nil)
+	require.NoError(t, err)
+	assert.Empty(t, m.Targets())
+
+	_, err = m.Call(context.Background(), &transport.Request{}, stable)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stable.calls)
+
+	require.NoError(t, m.SetTargets(Target{Name: "canary", Unary: canary, Percent: 100}))
+	_, err = m.Call(context.Background(), &transport.Request{}, stable)
+	require.NoError(t, err)
+	assert.Equal(t, 1, canary.calls)
+
+	tests := []struct {
+		desc    string
+		targets []Target
+		wantErr string
+	}{
+		{
+			desc:    "no name",
+			targets: []Target{{Unary: canary}},
+			wantErr: "targets must have a name",
+		},
+		{
+			desc:    "duplicate",
+			targets: []Target{{Name: "a", Unary: canary}, {Name: "a", Unary: canary}},
+			wantErr: `target "a" is given more than once`,
+		},
+		{
+			desc:    "no outbound",
+			targets: []Target{{Name: "a"}},
+			wantErr: `target "a" has no outbound`,
+		},
+		{
+			desc:    "negative",
+			targets: []Target{{Name: "a", Unary: canary, Percent: -1}},
+			wantErr: `percentage of target "a" must be between 0 and 100, got -1`,
+		},
+		{
+			desc:    "too much",
+			targets: []Target{{Name: "a", Unary: canary, Percent: 60}, {Name: "b", Unary: canary, Percent: 60}},
+			wantErr: "percentages of targets must add up to at most 100, got 120",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			err := m.SetTargets(tt.targets...)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+			assert.Len(t, m.Targets(), 1, "invalid targets must not replace the current ones")
+
+			_, err = New(tt.targets)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package spool
+
+import (
+	"time"
+
+	"go.uber.org/yarpc/api/backoff"
+	ibackoff "go.uber.org/yarpc/internal/backoff"
+	"go.uber.org/zap"
+)
+
+// Option customizes the behavior of spooling middleware.
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(opts *options) { f(opts) }
+
+type options struct {
+	backoff backoff.Strategy
+	timeout time.Duration
+	logger  *zap.Logger
+}
+
+func newOptions(opts []Option) options {
+	o := options{
+		backoff: ibackoff.DefaultExponential,
+		timeout: time.Second,
+		logger:  zap.NewNop(),
+	}
+	for _, opt := range opts {
+		opt.apply(&o)
+	}
+	return o
+}
+
+// Backoff is the strategy that decides how long to wait before retrying the
+// delivery of a call after a failed attempt.
+//
+// Defaults to exponential backoff with full jitter, starting at 10ms and
+// capped at one minute.
+func Backoff(s backoff.Strategy) Option {
+	return optionFunc(func(o *options) {
+		o.backoff = s
+	})
+}
+
+// Timeout bounds each attempt to deliver a call.
+//
+// Defaults to one second.
+func Timeout(d time.Duration) Option {
+	return optionFunc(func(o *options) {
+		o.timeout = d
+	})
+}
+
+// Logger logs failed deliveries and calls that are dropped.
+//
+// Defaults to not logging.
+func Logger(logger *zap.Logger) Option {
+	return optionFunc(func(o *options) {
+		o.logger = logger
+	})
+}
@@ -0,0 +1,163 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package spool
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"go.uber.org/yarpc/api/transport"
+)
+
+const (
+	// _recordExt is the extension of the files of spooled calls.
+	_recordExt = ".call"
+
+	// _tempExt is the extension of the files of calls being spooled. They
+	// are renamed once they are complete, so that a crash cannot leave
+	// partial calls behind.
+	_tempExt = ".tmp"
+
+	// _corruptExt is the extension that files of spooled calls which cannot
+	// be read are renamed to, to be inspected by hand.
+	_corruptExt = ".corrupt"
+)
+
+// record is a spooled call.
+type record struct {
+	Caller          string            `json:"caller"`
+	Service         string            `json:"service"`
+	Encoding        string            `json:"encoding"`
+	Procedure       string            `json:"procedure"`
+	Headers         map[string]string `json:"headers,omitempty"`
+	ShardKey        string            `json:"shardKey,omitempty"`
+	RoutingKey      string            `json:"routingKey,omitempty"`
+	RoutingDelegate string            `json:"routingDelegate,omitempty"`
+	Body            []byte            `json:"body,omitempty"`
+}
+
+// newRecord reads the request, including its body, into a record.
+func newRecord(req *transport.Request) (*record, error) {
+	r := &record{
+		Caller:          req.Caller,
+		Service:         req.Service,
+		Encoding:        string(req.Encoding),
+		Procedure:       req.Procedure,
+		Headers:         req.Headers.Items(),
+		ShardKey:        req.ShardKey,
+		RoutingKey:      req.RoutingKey,
+		RoutingDelegate: req.RoutingDelegate,
+	}
+	if req.Body != nil {
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		r.Body = body
+	}
+	return r, nil
+}
+
+// Request returns the request of the spooled call.
+func (r *record) Request() *transport.Request {
+	headers := transport.NewHeadersWithCapacity(len(r.Headers))
+	for k, v := range r.Headers {
+		headers = headers.With(k, v)
+	}
+	return &transport.Request{
+		Caller:          r.Caller,
+		Service:         r.Service,
+		Encoding:        transport.Encoding(r.Encoding),
+		Procedure:       r.Procedure,
+		Headers:         headers,
+		ShardKey:        r.ShardKey,
+		RoutingKey:      r.RoutingKey,
+		RoutingDelegate: r.RoutingDelegate,
+		Body:            bytes.NewReader(r.Body),
+	}
+}
+
+// writeRecord writes the record to the file at the given path, and syncs it
+// to disk before returning.
+func writeRecord(path string, r *record) (err error) {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	tmp := path + _tempExt
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(tmp)
+		}
+	}()
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	return syncDir(filepath.Dir(path))
+}
+
+// syncDir syncs the directory so that the files created or renamed in it
+// survive crashes.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	// Some platforms do not support syncing directories; the files are then
+	// as durable as the platform makes them.
+	d.Sync()
+	return nil
+}
+
+// readRecord reads the record in the file at the given path.
+func readRecord(path string) (*record, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var r record
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("malformed spooled call %q: %v", path, err)
+	}
+	return &r, nil
+}
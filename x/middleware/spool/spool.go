@@ -0,0 +1,354 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package spool provides oneway outbound middleware that delivers calls at
+// least once, even if the service they are made to is down for a while or
+// the calling process restarts.
+//
+// The middleware writes each call to a file in a local directory and acks it
+// as soon as the file is synced to disk. It then delivers the calls to each
+// service in the background, in the order they were made, retrying with
+// backoff until the outbound acks them, and removes their files.
+//
+// 	calls, err := spool.New("/var/spool/myservice")
+// 	if err != nil {
+// 		log.Fatal(err)
+// 	}
+// 	defer calls.Stop()
+//
+// 	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+// 		Name: "myservice",
+// 		Outbounds: yarpc.Outbounds{
+// 			"audit": {Oneway: auditOutbound},
+// 		},
+// 		OutboundMiddleware: yarpc.OutboundMiddleware{
+// 			Oneway: calls,
+// 		},
+// 	})
+//
+// Calls spooled by a previous run of the process are delivered once a new
+// call is made to their service, or once the outbound of their service is
+// handed to Resume.
+//
+// 	calls.Resume("audit", auditOutbound)
+//
+// Calls may be delivered more than once, for example if the process stops
+// between the ack of a call and the removal of its file. Procedures called
+// through the middleware should be idempotent.
+package spool
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/zap"
+)
+
+var _ middleware.OnewayOutbound = (*Middleware)(nil)
+
+// Middleware is oneway outbound middleware which spools calls to disk and
+// delivers them in the background.
+type Middleware struct {
+	dir  string
+	opts options
+
+	mu     sync.Mutex
+	seq    uint64
+	queues map[string]*queue // by service
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// queue holds the spooled calls to a service.
+type queue struct {
+	service string
+
+	// out is the outbound that delivers the calls, once it is known.
+	out transport.OnewayOutbound
+
+	// pending are the paths of the files of the calls, in the order they
+	// were made.
+	pending []string
+
+	// ready is signalled when calls are added or the outbound is set.
+	ready chan struct{}
+}
+
+// New builds spooling middleware that keeps the calls it has yet to deliver
+// in the given directory, creating it if needed. Calls left in the directory
+// by a previous run are delivered as the outbounds of their services become
+// known.
+func New(dir string, opts ...Option) (*Middleware, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create spool directory %q: %v", dir, err)
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spool directory %q: %v", dir, err)
+	}
+
+	m := &Middleware{
+		dir:    dir,
+		opts:   newOptions(opts),
+		queues: make(map[string]*queue),
+		stop:   make(chan struct{}),
+	}
+
+	// File names sort in the order the calls were made.
+	names := make([]string, 0, len(files))
+	for _, f := range files {
+		names = append(names, f.Name())
+	}
+	sort.Strings(names)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		switch filepath.Ext(name) {
+		case _tempExt:
+			// Calls that were not completely written were never acked.
+			os.Remove(path)
+		case _recordExt:
+			r, err := readRecord(path)
+			if err != nil {
+				m.opts.logger.Error("Skipping spooled call that cannot be read.",
+					zap.String("path", path), zap.Error(err))
+				os.Rename(path, strings.TrimSuffix(path, _recordExt)+_corruptExt)
+				continue
+			}
+			q := m.queue(r.Service)
+			q.pending = append(q.pending, path)
+		}
+	}
+	return m, nil
+}
+
+// CallOneway implements middleware.OnewayOutbound. It acks the call once it
+// is spooled; the call is delivered to the given outbound later.
+func (m *Middleware) CallOneway(ctx context.Context, req *transport.Request, out transport.OnewayOutbound) (transport.Ack, error) {
+	// Invalid calls would be retried forever.
+	if err := transport.ValidateRequest(req); err != nil {
+		return nil, err
+	}
+
+	r, err := newRecord(req)
+	if err != nil {
+		return nil, yarpcerrors.Newf(yarpcerrors.CodeInternal,
+			"failed to read oneway call to procedure %q of service %q: %v", req.Procedure, req.Service, err)
+	}
+
+	m.mu.Lock()
+	m.seq++
+	// Names start with the time so that they sort in the order the calls
+	// were made across runs of the process.
+	path := filepath.Join(m.dir, fmt.Sprintf("%020d-%010d%s", time.Now().UnixNano(), m.seq, _recordExt))
+	m.mu.Unlock()
+
+	if err := writeRecord(path, r); err != nil {
+		return nil, yarpcerrors.Newf(yarpcerrors.CodeInternal,
+			"failed to spool oneway call to procedure %q of service %q: %v", req.Procedure, req.Service, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	q := m.queue(req.Service)
+	q.out = out
+	q.pending = append(q.pending, path)
+	signal(q.ready)
+	return ack{}, nil
+}
+
+// Resume delivers the calls spooled for the given service with the given
+// outbound, without waiting for a new call to the service. The outbound
+// must not be wrapped in the middleware, lest the calls be spooled again.
+func (m *Middleware) Resume(service string, out transport.OnewayOutbound) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	q := m.queue(service)
+	q.out = out
+	signal(q.ready)
+}
+
+// Pending returns the number of spooled calls that have yet to be
+// delivered.
+func (m *Middleware) Pending() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := 0
+	for _, q := range m.queues {
+		n += len(q.pending)
+	}
+	return n
+}
+
+// Stop stops delivering calls and waits for the deliveries in progress to
+// finish. Calls made after Stop are still spooled, to be delivered the next
+// time the process runs.
+func (m *Middleware) Stop() {
+	m.stopOnce.Do(func() {
+		m.mu.Lock()
+		close(m.stop)
+		m.mu.Unlock()
+	})
+	m.wg.Wait()
+}
+
+// queue returns the queue of calls to the given service, starting the
+// delivery of its calls if it is new and the middleware is not stopped. It
+// must be called with the lock held.
+func (m *Middleware) queue(service string) *queue {
+	q, ok := m.queues[service]
+	if ok {
+		return q
+	}
+
+	q = &queue{service: service, ready: make(chan struct{}, 1)}
+	m.queues[service] = q
+	select {
+	case <-m.stop:
+	default:
+		m.wg.Add(1)
+		go m.deliver(q)
+	}
+	return q
+}
+
+// deliver delivers the calls in the queue, one at a time, until the
+// middleware is stopped.
+func (m *Middleware) deliver(q *queue) {
+	defer m.wg.Done()
+
+	backoff := m.opts.backoff.Backoff()
+	var attempts uint
+	for {
+		path, out, ok := m.next(q)
+		if !ok {
+			return
+		}
+
+		err := m.send(path, out)
+		if err != nil && !permanent(err) {
+			attempts++
+			m.opts.logger.Warn("Failed to deliver spooled call, retrying.",
+				zap.String("service", q.service), zap.String("path", path),
+				zap.Uint("attempts", attempts), zap.Error(err))
+			select {
+			case <-time.After(backoff.Duration(attempts)):
+				continue
+			case <-m.stop:
+				return
+			}
+		}
+		if err != nil {
+			m.opts.logger.Error("Dropping spooled call that cannot be delivered.",
+				zap.String("service", q.service), zap.String("path", path), zap.Error(err))
+		}
+
+		attempts = 0
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			m.opts.logger.Error("Failed to remove delivered spooled call; it will be delivered again.",
+				zap.String("path", path), zap.Error(err))
+		}
+		m.mu.Lock()
+		q.pending = q.pending[1:]
+		m.mu.Unlock()
+	}
+}
+
+// next waits for the next call in the queue and the outbound to deliver it
+// with. It returns false once the middleware is stopped.
+func (m *Middleware) next(q *queue) (string, transport.OnewayOutbound, bool) {
+	for {
+		m.mu.Lock()
+		if q.out != nil && len(q.pending) > 0 {
+			path, out := q.pending[0], q.out
+			m.mu.Unlock()
+			return path, out, true
+		}
+		m.mu.Unlock()
+
+		select {
+		case <-q.ready:
+		case <-m.stop:
+			return "", nil, false
+		}
+	}
+}
+
+// send makes the spooled call in the file at the given path.
+func (m *Middleware) send(path string, out transport.OnewayOutbound) error {
+	r, err := readRecord(path)
+	if err != nil {
+		return permanentError{err}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.opts.timeout)
+	defer cancel()
+	_, err = out.CallOneway(ctx, r.Request())
+	return err
+}
+
+// permanent returns whether retrying a call that failed with the error
+// cannot succeed.
+func permanent(err error) bool {
+	if _, ok := err.(permanentError); ok {
+		return true
+	}
+	if !yarpcerrors.IsStatus(err) {
+		return false
+	}
+	switch yarpcerrors.FromError(err).Code() {
+	case yarpcerrors.CodeInvalidArgument, yarpcerrors.CodeUnimplemented:
+		return true
+	default:
+		return false
+	}
+}
+
+type permanentError struct{ error }
+
+// signal wakes up the delivery of a queue, if it is waiting.
+func signal(ready chan struct{}) {
+	select {
+	case ready <- struct{}{}:
+	default:
+	}
+}
+
+// ack is the ack of spooled calls.
+type ack struct{}
+
+func (ack) String() string { return "spooled" }
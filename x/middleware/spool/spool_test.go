@@ -0,0 +1,217 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package spool
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/backoff"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/testtime"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// fakeOutbound fails the first calls it receives with an error and records
+// the bodies of the calls it acks.
+type fakeOutbound struct {
+	transport.Outbound
+
+	mu       sync.Mutex
+	failures int
+	err      error
+	attempts int
+	reqs     []*transport.Request
+	bodies   []string
+}
+
+func (o *fakeOutbound) CallOneway(ctx context.Context, req *transport.Request) (transport.Ack, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.attempts++
+	if _, ok := ctx.Deadline(); !ok {
+		return nil, yarpcerrors.Newf(yarpcerrors.CodeInvalidArgument, "missing TTL")
+	}
+	if o.failures != 0 {
+		o.failures--
+		return nil, o.err
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	o.reqs = append(o.reqs, req)
+	o.bodies = append(o.bodies, string(body))
+	return nil, nil
+}
+
+func (o *fakeOutbound) delivered() []string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]string(nil), o.bodies...)
+}
+
+func newRequest(body string) *transport.Request {
+	return &transport.Request{
+		Caller:    "frontend",
+		Service:   "audit",
+		Encoding:  "raw",
+		Procedure: "record",
+		Headers:   transport.NewHeaders().With("tenant", "acme"),
+		ShardKey:  "shard",
+		Body:      bytes.NewReader([]byte(body)),
+	}
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "spool")
+	require.NoError(t, err)
+	return dir
+}
+
+func files(t *testing.T, dir, pattern string) []string {
+	matches, err := filepath.Glob(filepath.Join(dir, pattern))
+	require.NoError(t, err)
+	return matches
+}
+
+func TestDeliver(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	m, err := New(dir, Backoff(backoff.None))
+	require.NoError(t, err)
+	defer m.Stop()
+
+	out := &fakeOutbound{
+		failures: 3,
+		err:      yarpcerrors.Newf(yarpcerrors.CodeUnavailable, "audit is down"),
+	}
+	for _, body := range []string{"a", "b", "c"} {
+		ack, err := m.CallOneway(context.Background(), newRequest(body), out)
+		require.NoError(t, err)
+		assert.Equal(t, "spooled", ack.String())
+	}
+
+	testtime.WaitFor(t, "spooled calls must be delivered", func() bool { return m.Pending() == 0 })
+	assert.Equal(t, []string{"a", "b", "c"}, out.delivered(), "calls must be delivered in order")
+	assert.Equal(t, 6, out.attempts)
+	assert.Empty(t, files(t, dir, "*"), "delivered calls must be removed")
+
+	req := out.reqs[0]
+	assert.Equal(t, "frontend", req.Caller)
+	assert.Equal(t, "audit", req.Service)
+	assert.Equal(t, transport.Encoding("raw"), req.Encoding)
+	assert.Equal(t, "record", req.Procedure)
+	assert.Equal(t, "shard", req.ShardKey)
+	assert.Equal(t, map[string]string{"tenant": "acme"}, req.Headers.Items())
+}
+
+func TestRestart(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	m, err := New(dir, Backoff(backoff.None))
+	require.NoError(t, err)
+	down := &fakeOutbound{
+		failures: -1,
+		err:      yarpcerrors.Newf(yarpcerrors.CodeUnavailable, "audit is down"),
+	}
+	_, err = m.CallOneway(context.Background(), newRequest("a"), down)
+	require.NoError(t, err)
+	m.Stop()
+
+	_, err = m.CallOneway(context.Background(), newRequest("b"), down)
+	require.NoError(t, err, "calls must be spooled after Stop")
+	assert.Len(t, files(t, dir, "*"+_recordExt), 2)
+
+	m, err = New(dir, Backoff(backoff.None))
+	require.NoError(t, err)
+	defer m.Stop()
+	assert.Equal(t, 2, m.Pending(), "calls of previous runs must be picked up")
+
+	up := &fakeOutbound{}
+	m.Resume("audit", up)
+	testtime.WaitFor(t, "spooled calls must be delivered", func() bool { return m.Pending() == 0 })
+	assert.Equal(t, []string{"a", "b"}, up.delivered())
+}
+
+func TestPermanentFailures(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	m, err := New(dir, Backoff(backoff.None))
+	require.NoError(t, err)
+	defer m.Stop()
+
+	out := &fakeOutbound{
+		failures: 1,
+		err:      yarpcerrors.Newf(yarpcerrors.CodeInvalidArgument, "malformed record"),
+	}
+	_, err = m.CallOneway(context.Background(), newRequest("a"), out)
+	require.NoError(t, err)
+	_, err = m.CallOneway(context.Background(), newRequest("b"), out)
+	require.NoError(t, err)
+
+	testtime.WaitFor(t, "spooled calls must be delivered", func() bool { return m.Pending() == 0 })
+	assert.Equal(t, []string{"b"}, out.delivered(), "calls that cannot succeed must be dropped")
+	assert.Equal(t, 2, out.attempts)
+}
+
+func TestInvalidCalls(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	m, err := New(dir)
+	require.NoError(t, err)
+	defer m.Stop()
+
+	req := newRequest("a")
+	req.Procedure = ""
+	_, err = m.CallOneway(context.Background(), req, &fakeOutbound{})
+	assert.Equal(t, yarpcerrors.CodeInvalidArgument, yarpcerrors.FromError(err).Code())
+	assert.Equal(t, 0, m.Pending())
+	assert.Empty(t, files(t, dir, "*"))
+}
+
+func TestUnreadableCalls(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "1"+_recordExt), []byte("{"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "2"+_recordExt+_tempExt), []byte("{"), 0644))
+
+	m, err := New(dir)
+	require.NoError(t, err)
+	defer m.Stop()
+
+	assert.Equal(t, 0, m.Pending())
+	assert.Equal(t, []string{filepath.Join(dir, "1"+_corruptExt)}, files(t, dir, "*"),
+		"unreadable calls must be set aside and partial calls removed")
+}
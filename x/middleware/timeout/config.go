@@ -0,0 +1,59 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package timeout
+
+import (
+	"io"
+	"io/ioutil"
+
+	"go.uber.org/yarpc/internal/config"
+	"gopkg.in/yaml.v2"
+)
+
+// LoadRulesFromYAML reads the rules in the timeouts section of YAML
+// configuration and ignores the other sections, so the rules may be kept in
+// the configuration file given to yarpcconfig.
+//
+// 	timeouts:
+// 	  - default: 1s
+// 	    max: 1m
+// 	  - service: keyvalue
+// 	    procedure: KeyValue::scan
+// 	    default: 10s
+func LoadRulesFromYAML(r io.Reader) ([]Rule, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var data map[string]interface{}
+	if err := yaml.Unmarshal(b, &data); err != nil {
+		return nil, err
+	}
+
+	var cfg struct {
+		Timeouts []Rule `config:"timeouts"`
+	}
+	if err := config.DecodeInto(&cfg, data); err != nil {
+		return nil, err
+	}
+	return cfg.Timeouts, nil
+}
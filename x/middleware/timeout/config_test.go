@@ -0,0 +1,58 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package timeout
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/internal/whitespace"
+)
+
+func TestLoadRulesFromYAML(t *testing.T) {
+	rules, err := LoadRulesFromYAML(strings.NewReader(whitespace.Expand(`
+		inbounds: {}
+		timeouts:
+		  - default: 1s
+		    max: 1m
+		  - service: keyvalue
+		    procedure: KeyValue::scan
+		    default: 10s
+	`)))
+	require.NoError(t, err)
+	assert.Equal(t, []Rule{
+		{Default: time.Second, Max: time.Minute},
+		{Service: "keyvalue", Procedure: "KeyValue::scan", Default: 10 * time.Second},
+	}, rules)
+
+	rules, err = LoadRulesFromYAML(strings.NewReader("inbounds: {}"))
+	require.NoError(t, err)
+	assert.Empty(t, rules, "configuration without timeouts must have no rules")
+
+	_, err = LoadRulesFromYAML(strings.NewReader("timeouts: [{default: soon}]"))
+	assert.Error(t, err, "invalid durations must be rejected")
+
+	_, err = LoadRulesFromYAML(strings.NewReader("timeouts: ["))
+	assert.Error(t, err, "invalid YAML must be rejected")
+}
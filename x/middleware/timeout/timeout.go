@@ -0,0 +1,208 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package timeout provides outbound middleware that gives calls a default
+// deadline per service and procedure when the caller did not set one, and
+// caps deadlines that are too far away.
+//
+// Calls without a deadline are rejected before the outbound middleware of a
+// dispatcher runs, so the middleware is applied to the ClientConfig that
+// clients are built with:
+//
+// 	timeouts := timeout.New(timeout.Rules(
+// 		timeout.Rule{Service: "users", Default: 500 * time.Millisecond},
+// 		timeout.Rule{Service: "users", Procedure: "Users::export", Default: 10 * time.Second},
+// 		timeout.Rule{Max: time.Minute},
+// 	))
+// 	client := json.New(timeouts.ClientConfig(dispatcher.ClientConfig("users")))
+//
+// As outbound middleware of a dispatcher, composed with its other middleware,
+// it only caps deadlines:
+//
+// 	OutboundMiddleware: yarpc.OutboundMiddleware{
+// 		Unary:  yarpc.UnaryOutboundMiddleware(tracing, timeouts),
+// 		Oneway: yarpc.OnewayOutboundMiddleware(tracing, timeouts),
+// 	},
+//
+// The rules may also be loaded from the timeouts section of a configuration
+// file with LoadRulesFromYAML, and replaced while the service runs with
+// SetRules, for example when the file changes.
+package timeout
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/clientconfig"
+	"go.uber.org/yarpc/internal/onclose"
+)
+
+var (
+	_ middleware.UnaryOutbound  = (*Middleware)(nil)
+	_ middleware.OnewayOutbound = (*Middleware)(nil)
+)
+
+// Rule is the timeout policy of calls to a service and procedure.
+type Rule struct {
+	// Service is the service whose calls the rule applies to, or empty for
+	// every service.
+	Service string `config:"service"`
+
+	// Procedure is the procedure whose calls the rule applies to, or empty
+	// for every procedure.
+	Procedure string `config:"procedure"`
+
+	// Default is the timeout of calls whose context has no deadline. Calls
+	// without a deadline are rejected by the outbound if there is no default.
+	Default time.Duration `config:"default"`
+
+	// Max is the longest timeout a call may have. Deadlines further away are
+	// brought forward. There is no limit if it is zero.
+	Max time.Duration `config:"max"`
+}
+
+// specificity ranks how specific a rule is: rules for a procedure of a
+// service come first, then rules for a service, then rules for a procedure
+// of any service, then rules for every call.
+func (r Rule) specificity() int {
+	s := 0
+	if r.Service != "" {
+		s += 2
+	}
+	if r.Procedure != "" {
+		s++
+	}
+	return s
+}
+
+type ruleKey struct {
+	service   string
+	procedure string
+}
+
+// table indexes rules by the service and procedure they apply to.
+type table map[ruleKey]Rule
+
+func newTable(rules []Rule) table {
+	t := make(table, len(rules))
+	for _, r := range rules {
+		k := ruleKey{service: r.Service, procedure: r.Procedure}
+		// The first rule for a service and procedure wins.
+		if _, ok := t[k]; !ok {
+			t[k] = r
+		}
+	}
+	return t
+}
+
+// match returns the most specific rule for the request.
+func (t table) match(req *transport.Request) (Rule, bool) {
+	keys := [...]ruleKey{
+		{service: req.Service, procedure: req.Procedure},
+		{service: req.Service},
+		{procedure: req.Procedure},
+		{},
+	}
+	for _, k := range keys {
+		if r, ok := t[k]; ok {
+			return r, true
+		}
+	}
+	return Rule{}, false
+}
+
+// Middleware is outbound middleware which applies default and maximum
+// timeouts to calls.
+type Middleware struct {
+	rules atomic.Value // []Rule
+	table atomic.Value // table
+}
+
+// New builds timeout middleware.
+func New(opts ...Option) *Middleware {
+	o := newOptions(opts)
+	m := &Middleware{}
+	m.SetRules(o.rules...)
+	return m
+}
+
+// SetRules replaces the rules of the middleware. It is safe to call while
+// calls are being made. The most specific rule for each call applies; of
+// several rules for the same service and procedure, the first applies.
+func (m *Middleware) SetRules(rules ...Rule) {
+	rules = append([]Rule(nil), rules...)
+	m.rules.Store(rules)
+	m.table.Store(newTable(rules))
+}
+
+// Rules returns the current rules of the middleware.
+func (m *Middleware) Rules() []Rule {
+	return append([]Rule(nil), m.rules.Load().([]Rule)...)
+}
+
+// Call implements middleware.UnaryOutbound.
+func (m *Middleware) Call(ctx context.Context, req *transport.Request, out transport.UnaryOutbound) (*transport.Response, error) {
+	ctx, cancel := m.withTimeout(ctx, req)
+	res, err := out.Call(ctx, req)
+	// The context is canceled when the response body is closed rather than
+	// when the call returns, since the body may still be read with it.
+	onclose.Response(res, cancel)
+	return res, err
+}
+
+// CallOneway implements middleware.OnewayOutbound.
+func (m *Middleware) CallOneway(ctx context.Context, req *transport.Request, out transport.OnewayOutbound) (transport.Ack, error) {
+	// The context is not canceled when the call returns, since the outbound
+	// may still use it to deliver the request.
+	ctx, _ = m.withTimeout(ctx, req)
+	return out.CallOneway(ctx, req)
+}
+
+// ClientConfig returns a ClientConfig whose outbounds apply the middleware
+// to the calls made through the given ClientConfig, before the dispatcher
+// validates them.
+func (m *Middleware) ClientConfig(cc transport.ClientConfig) transport.ClientConfig {
+	return clientconfig.ApplyMiddleware(cc, m, m, nil)
+}
+
+// withTimeout returns a context with the deadline the rule for the request
+// calls for.
+func (m *Middleware) withTimeout(ctx context.Context, req *transport.Request) (context.Context, context.CancelFunc) {
+	r, ok := m.table.Load().(table).match(req)
+	if !ok {
+		return ctx, func() {}
+	}
+
+	deadline, hasDeadline := ctx.Deadline()
+	switch {
+	case !hasDeadline && r.Default > 0:
+		timeout := r.Default
+		if r.Max > 0 && timeout > r.Max {
+			timeout = r.Max
+		}
+		return context.WithTimeout(ctx, timeout)
+	case hasDeadline && r.Max > 0 && time.Until(deadline) > r.Max:
+		return context.WithTimeout(ctx, r.Max)
+	}
+	return ctx, func() {}
+}
@@ -0,0 +1,230 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package timeout
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/internal/request"
+)
+
+// deadlineOutbound records the deadlines of the calls it receives.
+type deadlineOutbound struct {
+	transport.Outbound
+
+	deadline    time.Time
+	hasDeadline bool
+}
+
+func (o *deadlineOutbound) Call(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	o.deadline, o.hasDeadline = ctx.Deadline()
+	return &transport.Response{}, nil
+}
+
+func (o *deadlineOutbound) CallOneway(ctx context.Context, req *transport.Request) (transport.Ack, error) {
+	o.deadline, o.hasDeadline = ctx.Deadline()
+	return nil, nil
+}
+
+// bodyOutbound returns a response whose body reports the error of the
+// context of the call when it is read.
+type bodyOutbound struct{ transport.Outbound }
+
+func (bodyOutbound) Call(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	return &transport.Response{Body: ctxBody{ctx}}, nil
+}
+
+type ctxBody struct{ ctx context.Context }
+
+func (b ctxBody) Read([]byte) (int, error) {
+	if err := b.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return 0, io.EOF
+}
+
+func (ctxBody) Close() error { return nil }
+
+func TestMatch(t *testing.T) {
+	rules := []Rule{
+		{Default: time.Second},
+		{Procedure: "ping", Default: 2 * time.Second},
+		{Service: "users", Default: 3 * time.Second},
+		{Service: "users", Procedure: "Users::export", Default: 4 * time.Second},
+		{Service: "users", Procedure: "Users::export", Default: 5 * time.Second},
+	}
+	tbl := newTable(rules)
+
+	tests := []struct {
+		service   string
+		procedure string
+		want      time.Duration
+	}{
+		{service: "users", procedure: "Users::export", want: 4 * time.Second},
+		{service: "users", procedure: "Users::get", want: 3 * time.Second},
+		{service: "users", procedure: "ping", want: 3 * time.Second},
+		{service: "storage", procedure: "ping", want: 2 * time.Second},
+		{service: "storage", procedure: "get", want: time.Second},
+	}
+	for _, tt := range tests {
+		r, ok := tbl.match(&transport.Request{Service: tt.service, Procedure: tt.procedure})
+		require.True(t, ok)
+		assert.Equal(t, tt.want, r.Default, "%v::%v", tt.service, tt.procedure)
+	}
+
+	_, ok := newTable(rules[1:]).match(&transport.Request{Service: "storage", Procedure: "get"})
+	assert.False(t, ok, "calls without a rule must not match")
+}
+
+func TestDefaultTimeout(t *testing.T) {
+	mw := New(Rules(
+		Rule{Service: "users", Default: time.Minute},
+		Rule{Service: "storage", Default: time.Hour, Max: time.Minute},
+	))
+	out := &deadlineOutbound{}
+
+	_, err := mw.Call(context.Background(), &transport.Request{Service: "users"}, out)
+	require.NoError(t, err)
+	require.True(t, out.hasDeadline, "calls without a deadline must get the default")
+	assert.WithinDuration(t, time.Now().Add(time.Minute), out.deadline, time.Second)
+
+	_, err = mw.CallOneway(context.Background(), &transport.Request{Service: "storage"}, out)
+	require.NoError(t, err)
+	require.True(t, out.hasDeadline)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), out.deadline, time.Second, "defaults must be capped")
+
+	_, err = mw.Call(context.Background(), &transport.Request{Service: "search"}, out)
+	require.NoError(t, err)
+	assert.False(t, out.hasDeadline, "calls without a rule must be left alone")
+}
+
+func TestResponseBodyOutlivesCall(t *testing.T) {
+	mw := New(Rules(Rule{Default: time.Minute}))
+
+	res, err := mw.Call(context.Background(), &transport.Request{Service: "users"}, bodyOutbound{})
+	require.NoError(t, err)
+	_, err = ioutil.ReadAll(res.Body)
+	assert.NoError(t, err, "the body must be readable after the call returns")
+
+	require.NoError(t, res.Body.Close())
+	_, err = ioutil.ReadAll(res.Body)
+	assert.Equal(t, context.Canceled, err, "the context must be canceled once the body is closed")
+}
+
+func TestClientConfig(t *testing.T) {
+	mw := New(Rules(Rule{Default: time.Minute}))
+	out := &deadlineOutbound{}
+	stream := &transporttest.MockStreamOutbound{}
+	cc := &transport.OutboundConfig{
+		CallerName: "caller",
+		Outbounds: transport.Outbounds{
+			ServiceName: "users",
+			Unary:       request.UnaryValidatorOutbound{UnaryOutbound: out},
+			Oneway:      request.OnewayValidatorOutbound{OnewayOutbound: out},
+			Stream:      stream,
+		},
+	}
+	req := &transport.Request{
+		Caller:    "caller",
+		Service:   "users",
+		Procedure: "get",
+		Encoding:  "raw",
+	}
+
+	tests := []struct {
+		desc string
+		give transport.ClientConfig
+	}{
+		{desc: "outbound config", give: cc},
+		{desc: "client config", give: struct{ transport.ClientConfig }{cc}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got := mw.ClientConfig(tt.give)
+			assert.Equal(t, "caller", got.Caller())
+			assert.Equal(t, "users", got.Service())
+
+			out.hasDeadline = false
+			_, err := got.GetUnaryOutbound().Call(context.Background(), req)
+			require.NoError(t, err, "calls must get a deadline before they are validated")
+			assert.True(t, out.hasDeadline)
+
+			out.hasDeadline = false
+			_, err = got.GetOnewayOutbound().CallOneway(context.Background(), req)
+			require.NoError(t, err)
+			assert.True(t, out.hasDeadline)
+		})
+	}
+
+	oc, ok := mw.ClientConfig(cc).(*transport.OutboundConfig)
+	require.True(t, ok, "OutboundConfigs must be kept for streaming clients")
+	assert.Equal(t, stream, oc.Outbounds.Stream)
+}
+
+func TestMaxTimeout(t *testing.T) {
+	mw := New(Rules(Rule{Service: "users", Max: time.Minute}))
+	out := &deadlineOutbound{}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	_, err := mw.Call(ctx, &transport.Request{Service: "users"}, out)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), out.deadline, time.Second, "deadlines must be capped")
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	want, _ := ctx.Deadline()
+	_, err = mw.Call(ctx, &transport.Request{Service: "users"}, out)
+	require.NoError(t, err)
+	assert.Equal(t, want, out.deadline, "shorter deadlines must be kept")
+
+	_, err = mw.Call(context.Background(), &transport.Request{Service: "users"}, out)
+	require.NoError(t, err)
+	assert.False(t, out.hasDeadline, "rules without a default must not add deadlines")
+}
+
+func TestSetRules(t *testing.T) {
+	mw := New()
+	assert.Empty(t, mw.Rules())
+
+	rules := []Rule{{Service: "users", Default: time.Minute}}
+	mw.SetRules(rules...)
+	rules[0].Default = time.Hour
+	assert.Equal(t, []Rule{{Service: "users", Default: time.Minute}}, mw.Rules(), "rules must be copied")
+
+	out := &deadlineOutbound{}
+	_, err := mw.Call(context.Background(), &transport.Request{Service: "users"}, out)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), out.deadline, time.Second)
+
+	mw.SetRules()
+	_, err = mw.Call(context.Background(), &transport.Request{Service: "users"}, out)
+	require.NoError(t, err)
+	assert.False(t, out.hasDeadline, "replaced rules must no longer apply")
+}
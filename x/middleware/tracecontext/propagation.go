@@ -0,0 +1,260 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tracecontext
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"go.uber.org/yarpc/api/transport"
+)
+
+// Headers that carry the trace context and baggage of requests, as specified
+// by the W3C Trace Context and Baggage recommendations.
+const (
+	TraceParentHeader = "traceparent"
+	TraceStateHeader  = "tracestate"
+	BaggageHeader     = "baggage"
+)
+
+// TraceID identifies a trace.
+type TraceID [16]byte
+
+// IsValid returns whether the trace ID is not all zeros.
+func (id TraceID) IsValid() bool { return id != TraceID{} }
+
+// String returns the trace ID as lowercase hex.
+func (id TraceID) String() string { return hex.EncodeToString(id[:]) }
+
+// SpanID identifies a span in a trace.
+type SpanID [8]byte
+
+// IsValid returns whether the span ID is not all zeros.
+func (id SpanID) IsValid() bool { return id != SpanID{} }
+
+// String returns the span ID as lowercase hex.
+func (id SpanID) String() string { return hex.EncodeToString(id[:]) }
+
+// SpanContext is the part of a span that is propagated to other services.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+
+	// Sampled is whether the caller may have recorded the span.
+	Sampled bool
+
+	// TraceState is vendor-specific trace state, passed along unchanged.
+	TraceState string
+
+	// Remote is whether the span context was received from another service.
+	Remote bool
+}
+
+// IsValid returns whether the span context has valid trace and span IDs.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// TraceParent returns the span context in the form of the traceparent
+// header.
+func (sc SpanContext) TraceParent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + flags
+}
+
+// ParseTraceParent parses the value of a traceparent header.
+//
+// Values with versions newer than 00 are parsed as far as version 00
+// defines them, as the recommendation requires.
+func ParseTraceParent(s string) (SpanContext, error) {
+	var sc SpanContext
+	// version-traceid-spanid-flags
+	if len(s) < 55 || s[2] != '-' || s[35] != '-' || s[52] != '-' {
+		return sc, fmt.Errorf("malformed traceparent %q", s)
+	}
+	version, err := decodeHex(s[:2])
+	if err != nil || version[0] == 0xff {
+		return sc, fmt.Errorf("unsupported traceparent version in %q", s)
+	}
+	if version[0] == 0 && len(s) != 55 {
+		return sc, fmt.Errorf("malformed traceparent %q", s)
+	}
+	if version[0] > 0 && len(s) > 55 && s[55] != '-' {
+		return sc, fmt.Errorf("malformed traceparent %q", s)
+	}
+
+	traceID, err := decodeHex(s[3:35])
+	if err != nil {
+		return sc, fmt.Errorf("malformed trace ID in traceparent %q", s)
+	}
+	spanID, err := decodeHex(s[36:52])
+	if err != nil {
+		return sc, fmt.Errorf("malformed span ID in traceparent %q", s)
+	}
+	flags, err := decodeHex(s[53:55])
+	if err != nil {
+		return sc, fmt.Errorf("malformed flags in traceparent %q", s)
+	}
+
+	copy(sc.TraceID[:], traceID)
+	copy(sc.SpanID[:], spanID)
+	sc.Sampled = flags[0]&1 == 1
+	if !sc.IsValid() {
+		return SpanContext{}, fmt.Errorf("invalid trace or span ID in traceparent %q", s)
+	}
+	return sc, nil
+}
+
+// decodeHex decodes lowercase hex, which is all the traceparent header
+// allows.
+func decodeHex(s string) ([]byte, error) {
+	if strings.ToLower(s) != s {
+		return nil, fmt.Errorf("%q is not lowercase hex", s)
+	}
+	return hex.DecodeString(s)
+}
+
+// Baggage is a set of key-value pairs propagated along with a trace.
+type Baggage map[string]string
+
+// String returns the baggage in the form of the baggage header, with keys
+// in sorted order.
+func (b Baggage) String() string {
+	keys := make([]string, 0, len(b))
+	for k := range b {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	members := make([]string, len(keys))
+	for i, k := range keys {
+		members[i] = k + "=" + url.PathEscape(b[k])
+	}
+	return strings.Join(members, ",")
+}
+
+// ParseBaggage parses the value of a baggage header. Members that are
+// malformed are skipped, and member properties are dropped.
+func ParseBaggage(s string) Baggage {
+	var b Baggage
+	for _, member := range strings.Split(s, ",") {
+		if i := strings.IndexByte(member, ';'); i >= 0 {
+			member = member[:i]
+		}
+		i := strings.IndexByte(member, '=')
+		if i < 0 {
+			continue
+		}
+		k := strings.TrimSpace(member[:i])
+		v, err := url.PathUnescape(strings.TrimSpace(member[i+1:]))
+		if k == "" || err != nil {
+			continue
+		}
+		if b == nil {
+			b = make(Baggage)
+		}
+		b[k] = v
+	}
+	return b
+}
+
+type spanContextKey struct{}
+
+type baggageKey struct{}
+
+// ContextWithSpanContext returns a copy of the context that carries the span
+// context. Calls made with the context are made on behalf of the span.
+func ContextWithSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, sc)
+}
+
+// SpanContextFromContext returns the span context carried by the context,
+// and whether it carries one.
+func SpanContextFromContext(ctx context.Context) (SpanContext, bool) {
+	sc, ok := ctx.Value(spanContextKey{}).(SpanContext)
+	return sc, ok
+}
+
+// ContextWithBaggage returns a copy of the context that carries the baggage.
+// The baggage is propagated with the calls made with the context.
+func ContextWithBaggage(ctx context.Context, b Baggage) context.Context {
+	return context.WithValue(ctx, baggageKey{}, b)
+}
+
+// BaggageFromContext returns the baggage carried by the context, or nil.
+// The baggage must not be modified; use ContextWithBaggage with a copy
+// instead.
+func BaggageFromContext(ctx context.Context) Baggage {
+	b, _ := ctx.Value(baggageKey{}).(Baggage)
+	return b
+}
+
+// Extract returns a copy of the context that carries the span context and
+// baggage in the headers, if any. Malformed trace contexts are ignored, so
+// that the request starts a new trace.
+func Extract(ctx context.Context, headers transport.Headers) context.Context {
+	if v, ok := headers.Get(TraceParentHeader); ok {
+		if sc, err := ParseTraceParent(v); err == nil {
+			sc.TraceState, _ = headers.Get(TraceStateHeader)
+			sc.Remote = true
+			ctx = ContextWithSpanContext(ctx, sc)
+		}
+	}
+	if v, ok := headers.Get(BaggageHeader); ok {
+		if b := ParseBaggage(v); len(b) > 0 {
+			ctx = ContextWithBaggage(ctx, b)
+		}
+	}
+	return ctx
+}
+
+// Inject returns a copy of the headers with the span context and baggage
+// carried by the context, if any. The given headers are not modified.
+func Inject(ctx context.Context, headers transport.Headers) transport.Headers {
+	sc, ok := SpanContextFromContext(ctx)
+	ok = ok && sc.IsValid()
+	b := BaggageFromContext(ctx)
+	if !ok && len(b) == 0 {
+		return headers
+	}
+
+	injected := transport.NewHeadersWithCapacity(headers.Len() + 3)
+	for k, v := range headers.Items() {
+		injected = injected.With(k, v)
+	}
+	if ok {
+		injected = injected.With(TraceParentHeader, sc.TraceParent())
+		if sc.TraceState != "" {
+			injected = injected.With(TraceStateHeader, sc.TraceState)
+		}
+	}
+	if len(b) > 0 {
+		injected = injected.With(BaggageHeader, b.String())
+	}
+	return injected
+}
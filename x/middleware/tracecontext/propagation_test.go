@@ -0,0 +1,101 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tracecontext
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+)
+
+const _traceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestParseTraceParent(t *testing.T) {
+	sc, err := ParseTraceParent(_traceParent)
+	require.NoError(t, err)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID.String())
+	assert.Equal(t, "00f067aa0ba902b7", sc.SpanID.String())
+	assert.True(t, sc.Sampled)
+	assert.Equal(t, _traceParent, sc.TraceParent(), "trace parents must round trip")
+
+	sc, err = ParseTraceParent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-future")
+	require.NoError(t, err, "newer versions must be parsed as far as version 00 defines them")
+	assert.False(t, sc.Sampled)
+
+	for _, s := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473g-00f067aa0ba902b7-01",
+		"00_4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	} {
+		_, err := ParseTraceParent(s)
+		assert.Error(t, err, "%q must not parse", s)
+	}
+}
+
+func TestBaggage(t *testing.T) {
+	b := ParseBaggage("userId=alice, tenant = acme;ttl=10,malformed,=nokey,note=hello%20world%2C%20hi")
+	assert.Equal(t, Baggage{
+		"userId": "alice",
+		"tenant": "acme",
+		"note":   "hello world, hi",
+	}, b)
+	assert.Equal(t, "note=hello%20world%2C%20hi,tenant=acme,userId=alice", b.String())
+	assert.Equal(t, b, ParseBaggage(b.String()), "baggage must round trip")
+	assert.Nil(t, ParseBaggage(""))
+}
+
+func TestExtractInject(t *testing.T) {
+	headers := transport.NewHeaders().
+		With(TraceParentHeader, _traceParent).
+		With(TraceStateHeader, "vendor=value").
+		With(BaggageHeader, "tenant=acme")
+
+	ctx := Extract(context.Background(), headers)
+	sc, ok := SpanContextFromContext(ctx)
+	require.True(t, ok)
+	assert.True(t, sc.Remote)
+	assert.Equal(t, "vendor=value", sc.TraceState)
+	assert.Equal(t, Baggage{"tenant": "acme"}, BaggageFromContext(ctx))
+
+	original := transport.NewHeaders().With("key", "value")
+	injected := Inject(ctx, original)
+	assert.Equal(t, map[string]string{
+		"key":             "value",
+		TraceParentHeader: _traceParent,
+		TraceStateHeader:  "vendor=value",
+		BaggageHeader:     "tenant=acme",
+	}, injected.Items())
+	assert.Equal(t, 1, original.Len(), "the original headers must not be modified")
+
+	ctx = Extract(context.Background(), transport.NewHeaders().With(TraceParentHeader, "malformed"))
+	_, ok = SpanContextFromContext(ctx)
+	assert.False(t, ok, "malformed trace contexts must be ignored")
+	assert.Equal(t, original.Items(), Inject(ctx, original).Items())
+}
@@ -0,0 +1,191 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package tracecontext provides middleware that propagates traces across
+// services with W3C Trace Context and Baggage headers, and records a span
+// for every request, as an alternative to the OpenTracing support of the
+// transports.
+//
+// Used as inbound middleware, it reads the traceparent, tracestate and
+// baggage headers of requests into their contexts. Used as outbound
+// middleware, it writes the trace context and baggage of the context of each
+// call into its headers, so that the trace continues in the services it
+// calls.
+//
+// 	tc := tracecontext.New(tracecontext.WithTracer(tracer))
+// 	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+// 		Name: "myservice",
+// 		InboundMiddleware: yarpc.InboundMiddleware{
+// 			Unary:  tc,
+// 			Oneway: tc,
+// 		},
+// 		OutboundMiddleware: yarpc.OutboundMiddleware{
+// 			Unary:  tc,
+// 			Oneway: tc,
+// 		},
+// 	})
+//
+// Spans are recorded with a Tracer, which usually adapts an OpenTelemetry
+// tracer. Spans are named after procedures, and have the caller, service,
+// procedure, encoding and transport of their requests as attributes. Failed
+// requests are marked as such, with the code of their error.
+//
+// The headers are application headers, so that they reach the other service
+// over every transport.
+package tracecontext
+
+import (
+	"context"
+
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+var (
+	_ middleware.UnaryInbound   = (*Middleware)(nil)
+	_ middleware.OnewayInbound  = (*Middleware)(nil)
+	_ middleware.UnaryOutbound  = (*Middleware)(nil)
+	_ middleware.OnewayOutbound = (*Middleware)(nil)
+)
+
+// Names of the attributes of spans.
+const (
+	CallerAttribute           = "rpc.caller"
+	ServiceAttribute          = "rpc.service"
+	ProcedureAttribute        = "rpc.procedure"
+	EncodingAttribute         = "rpc.encoding"
+	TransportAttribute        = "rpc.transport"
+	ErrorCodeAttribute        = "rpc.error_code"
+	ApplicationErrorAttribute = "rpc.application_error"
+)
+
+// Middleware propagates trace contexts and records spans for requests.
+type Middleware struct {
+	opts options
+}
+
+// New builds trace context middleware.
+func New(opts ...Option) *Middleware {
+	return &Middleware{opts: newOptions(opts)}
+}
+
+// Handle implements middleware.UnaryInbound.
+func (m *Middleware) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter, h transport.UnaryHandler) error {
+	ctx, span := m.start(Extract(ctx, req.Headers), req, SpanKindServer)
+	w := &responseWriter{ResponseWriter: resw}
+	err := h.Handle(ctx, req, w)
+	m.end(span, err, w.isApplicationError)
+	return err
+}
+
+// HandleOneway implements middleware.OnewayInbound.
+func (m *Middleware) HandleOneway(ctx context.Context, req *transport.Request, h transport.OnewayHandler) error {
+	ctx, span := m.start(Extract(ctx, req.Headers), req, SpanKindServer)
+	err := h.HandleOneway(ctx, req)
+	m.end(span, err, false)
+	return err
+}
+
+// Call implements middleware.UnaryOutbound.
+func (m *Middleware) Call(ctx context.Context, req *transport.Request, out transport.UnaryOutbound) (*transport.Response, error) {
+	ctx, span := m.start(ctx, req, SpanKindClient)
+	res, err := out.Call(ctx, inject(ctx, req))
+	m.end(span, err, res != nil && res.ApplicationError)
+	return res, err
+}
+
+// CallOneway implements middleware.OnewayOutbound.
+func (m *Middleware) CallOneway(ctx context.Context, req *transport.Request, out transport.OnewayOutbound) (transport.Ack, error) {
+	ctx, span := m.start(ctx, req, SpanKindClient)
+	ack, err := out.CallOneway(ctx, inject(ctx, req))
+	m.end(span, err, false)
+	return ack, err
+}
+
+// start starts a span for the request if there is a tracer, and returns a
+// context that carries its span context.
+func (m *Middleware) start(ctx context.Context, req *transport.Request, kind SpanKind) (context.Context, Span) {
+	if m.opts.tracer == nil {
+		return ctx, nil
+	}
+
+	attrs := make([]Attribute, 0, len(m.opts.attributes)+5)
+	attrs = append(attrs, m.opts.attributes...)
+	attrs = append(attrs,
+		Attribute{Key: CallerAttribute, Value: req.Caller},
+		Attribute{Key: ServiceAttribute, Value: req.Service},
+		Attribute{Key: ProcedureAttribute, Value: req.Procedure},
+		Attribute{Key: EncodingAttribute, Value: string(req.Encoding)},
+	)
+	if req.Transport != "" {
+		attrs = append(attrs, Attribute{Key: TransportAttribute, Value: req.Transport})
+	}
+
+	parent, _ := SpanContextFromContext(ctx)
+	ctx, span := m.opts.tracer.Start(ctx, req.Procedure, StartOptions{
+		Kind:       kind,
+		Parent:     parent,
+		Attributes: attrs,
+	})
+	return ContextWithSpanContext(ctx, span.SpanContext()), span
+}
+
+// end ends the span, if any, marking it as failed if the request failed.
+func (m *Middleware) end(span Span, err error, isApplicationError bool) {
+	if span == nil {
+		return
+	}
+	switch {
+	case err != nil:
+		span.SetAttributes(Attribute{Key: ErrorCodeAttribute, Value: yarpcerrors.FromError(err).Code().String()})
+		span.SetError(err.Error())
+	case isApplicationError:
+		span.SetAttributes(Attribute{Key: ApplicationErrorAttribute, Value: "true"})
+		span.SetError("application error")
+	}
+	span.End()
+}
+
+// inject returns a copy of the request with the trace context and baggage of
+// the context in its headers.
+func inject(ctx context.Context, req *transport.Request) *transport.Request {
+	r := *req
+	r.Headers = Inject(ctx, req.Headers)
+	return &r
+}
+
+// responseWriter records whether the handler set an application error.
+type responseWriter struct {
+	transport.ResponseWriter
+
+	isApplicationError bool
+}
+
+func (w *responseWriter) SetApplicationError() {
+	w.isApplicationError = true
+	w.ResponseWriter.SetApplicationError()
+}
+
+func (w *responseWriter) SetApplicationErrorMeta(meta *transport.ApplicationErrorMeta) {
+	if setter, ok := w.ResponseWriter.(transport.ApplicationErrorMetaSetter); ok {
+		setter.SetApplicationErrorMeta(meta)
+	}
+}
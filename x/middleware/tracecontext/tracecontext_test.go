@@ -0,0 +1,220 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tracecontext
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+// fakeTracer records the spans it starts, numbering their span IDs.
+type fakeTracer struct {
+	spans []*fakeSpan
+}
+
+func (t *fakeTracer) Start(ctx context.Context, name string, opts StartOptions) (context.Context, Span) {
+	sc := opts.Parent
+	sc.Remote = false
+	if !sc.TraceID.IsValid() {
+		sc.TraceID = TraceID{1}
+		sc.Sampled = true
+	}
+	sc.SpanID = SpanID{byte(len(t.spans) + 1)}
+
+	span := &fakeSpan{name: name, opts: opts, sc: sc, attrs: make(map[string]string)}
+	for _, attr := range opts.Attributes {
+		span.attrs[attr.Key] = attr.Value
+	}
+	t.spans = append(t.spans, span)
+	return ctx, span
+}
+
+type fakeSpan struct {
+	name  string
+	opts  StartOptions
+	sc    SpanContext
+	attrs map[string]string
+	err   string
+	ended bool
+}
+
+func (s *fakeSpan) SpanContext() SpanContext    { return s.sc }
+func (s *fakeSpan) SetError(description string) { s.err = description }
+func (s *fakeSpan) End()                        { s.ended = true }
+
+func (s *fakeSpan) SetAttributes(attrs ...Attribute) {
+	for _, attr := range attrs {
+		s.attrs[attr.Key] = attr.Value
+	}
+}
+
+// handlerFunc handles requests with a function.
+type handlerFunc func(context.Context, *transport.Request, transport.ResponseWriter) error
+
+func (f handlerFunc) Handle(ctx context.Context, req *transport.Request, resw transport.ResponseWriter) error {
+	return f(ctx, req, resw)
+}
+
+type nopOnewayHandler struct{}
+
+func (nopOnewayHandler) HandleOneway(context.Context, *transport.Request) error { return nil }
+
+// recordingOutbound records the requests it is called with.
+type recordingOutbound struct {
+	transport.Outbound
+
+	req *transport.Request
+	res *transport.Response
+	err error
+}
+
+func (o *recordingOutbound) Call(ctx context.Context, req *transport.Request) (*transport.Response, error) {
+	o.req = req
+	return o.res, o.err
+}
+
+func (o *recordingOutbound) CallOneway(ctx context.Context, req *transport.Request) (transport.Ack, error) {
+	o.req = req
+	return nil, o.err
+}
+
+func TestPropagation(t *testing.T) {
+	tracer := &fakeTracer{}
+	mw := New(WithTracer(tracer), Attributes(Attribute{Key: "host", Value: "host01"}))
+	out := &recordingOutbound{res: &transport.Response{}}
+
+	req := &transport.Request{
+		Caller:    "frontend",
+		Service:   "users",
+		Transport: "http",
+		Encoding:  "json",
+		Procedure: "Users::get",
+		Headers: transport.NewHeaders().
+			With(TraceParentHeader, _traceParent).
+			With(BaggageHeader, "tenant=acme"),
+	}
+	err := mw.Handle(context.Background(), req, &transporttest.FakeResponseWriter{}, handlerFunc(
+		func(ctx context.Context, _ *transport.Request, _ transport.ResponseWriter) error {
+			_, err := mw.Call(ctx, &transport.Request{
+				Caller:    "users",
+				Service:   "storage",
+				Encoding:  "raw",
+				Procedure: "get",
+			}, out)
+			return err
+		}))
+	require.NoError(t, err)
+
+	require.Len(t, tracer.spans, 2)
+	server, client := tracer.spans[0], tracer.spans[1]
+
+	assert.Equal(t, "Users::get", server.name)
+	assert.Equal(t, SpanKindServer, server.opts.Kind)
+	assert.Equal(t, _traceParent, server.opts.Parent.TraceParent(), "server spans must continue the trace of the caller")
+	assert.True(t, server.opts.Parent.Remote)
+	assert.Equal(t, map[string]string{
+		"host":             "host01",
+		CallerAttribute:    "frontend",
+		ServiceAttribute:   "users",
+		ProcedureAttribute: "Users::get",
+		EncodingAttribute:  "json",
+		TransportAttribute: "http",
+	}, server.attrs)
+	assert.True(t, server.ended)
+	assert.Empty(t, server.err)
+
+	assert.Equal(t, "get", client.name)
+	assert.Equal(t, SpanKindClient, client.opts.Kind)
+	assert.Equal(t, server.sc, client.opts.Parent, "client spans must be children of the server span")
+	assert.NotContains(t, client.attrs, TransportAttribute, "unknown transports must be left out")
+	assert.True(t, client.ended)
+
+	traceParent, _ := out.req.Headers.Get(TraceParentHeader)
+	assert.Equal(t, client.sc.TraceParent(), traceParent, "calls must carry the client span context")
+	baggage, _ := out.req.Headers.Get(BaggageHeader)
+	assert.Equal(t, "tenant=acme", baggage, "baggage must be propagated")
+}
+
+func TestPropagationWithoutTracer(t *testing.T) {
+	mw := New()
+	out := &recordingOutbound{}
+
+	req := &transport.Request{
+		Procedure: "Users::notify",
+		Headers:   transport.NewHeaders().With(TraceParentHeader, _traceParent),
+	}
+	err := mw.HandleOneway(context.Background(), req, nopOnewayHandler{})
+	require.NoError(t, err)
+
+	ctx := Extract(context.Background(), req.Headers)
+	_, err = mw.CallOneway(ctx, &transport.Request{Procedure: "notify"}, out)
+	require.NoError(t, err)
+	traceParent, _ := out.req.Headers.Get(TraceParentHeader)
+	assert.Equal(t, _traceParent, traceParent, "trace contexts must be propagated unchanged")
+
+	_, err = mw.CallOneway(context.Background(), &transport.Request{Procedure: "notify"}, out)
+	require.NoError(t, err)
+	assert.Equal(t, 0, out.req.Headers.Len(), "calls without a trace context must not get one")
+}
+
+func TestFailedSpans(t *testing.T) {
+	tracer := &fakeTracer{}
+	mw := New(WithTracer(tracer))
+	req := &transport.Request{Procedure: "Users::get"}
+
+	err := mw.Handle(context.Background(), req, &transporttest.FakeResponseWriter{}, handlerFunc(
+		func(context.Context, *transport.Request, transport.ResponseWriter) error {
+			return yarpcerrors.Newf(yarpcerrors.CodeNotFound, "no such user")
+		}))
+	require.Error(t, err)
+
+	err = mw.Handle(context.Background(), req, &transporttest.FakeResponseWriter{}, handlerFunc(
+		func(_ context.Context, _ *transport.Request, resw transport.ResponseWriter) error {
+			resw.SetApplicationError()
+			return nil
+		}))
+	require.NoError(t, err)
+
+	_, err = mw.Call(context.Background(), req, &recordingOutbound{err: errors.New("great sadness")})
+	require.Error(t, err)
+
+	_, err = mw.Call(context.Background(), req, &recordingOutbound{res: &transport.Response{ApplicationError: true}})
+	require.NoError(t, err)
+
+	require.Len(t, tracer.spans, 4)
+	assert.Equal(t, "not-found", tracer.spans[0].attrs[ErrorCodeAttribute])
+	assert.Contains(t, tracer.spans[0].err, "no such user")
+	assert.Equal(t, "true", tracer.spans[1].attrs[ApplicationErrorAttribute])
+	assert.Equal(t, "application error", tracer.spans[1].err)
+	assert.Equal(t, "unknown", tracer.spans[2].attrs[ErrorCodeAttribute])
+	assert.Equal(t, "great sadness", tracer.spans[2].err)
+	assert.Equal(t, "true", tracer.spans[3].attrs[ApplicationErrorAttribute])
+	for _, span := range tracer.spans {
+		assert.True(t, span.ended, "spans must end")
+	}
+}
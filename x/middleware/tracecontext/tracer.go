@@ -0,0 +1,88 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tracecontext
+
+import "context"
+
+// SpanKind is the role of a span in an RPC.
+type SpanKind int
+
+const (
+	// SpanKindServer is the kind of the spans of requests a service handles.
+	SpanKindServer SpanKind = iota + 1
+
+	// SpanKindClient is the kind of the spans of calls a service makes.
+	SpanKindClient
+)
+
+func (k SpanKind) String() string {
+	switch k {
+	case SpanKindServer:
+		return "server"
+	case SpanKindClient:
+		return "client"
+	default:
+		return "unknown"
+	}
+}
+
+// Attribute is a key-value pair describing a span.
+type Attribute struct {
+	Key   string
+	Value string
+}
+
+// StartOptions describe a span to start.
+type StartOptions struct {
+	Kind SpanKind
+
+	// Parent is the span context of the parent of the span. It is not valid
+	// if the span starts a new trace.
+	Parent SpanContext
+
+	Attributes []Attribute
+}
+
+// Tracer starts spans. Implementations usually adapt the tracer of a
+// tracing library, like an OpenTelemetry tracer, to record and export the
+// spans.
+type Tracer interface {
+	// Start starts a span with the given name. The span must be a child of
+	// the parent in the options, if it is valid, and must have a new trace ID
+	// otherwise.
+	Start(ctx context.Context, name string, opts StartOptions) (context.Context, Span)
+}
+
+// Span is an operation in a trace.
+type Span interface {
+	// SpanContext returns the span context that is propagated to the calls
+	// made on behalf of the span.
+	SpanContext() SpanContext
+
+	// SetAttributes adds attributes to the span.
+	SetAttributes(attrs ...Attribute)
+
+	// SetError marks the span as failed, with a description of the failure.
+	SetError(description string)
+
+	// End ends the span.
+	End()
+}
@@ -0,0 +1,64 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package transcode
+
+import "github.com/gogo/protobuf/proto"
+
+// Option customizes the behavior of transcoding middleware.
+type Option interface {
+	apply(*options)
+}
+
+type optionFunc func(*options)
+
+func (f optionFunc) apply(opts *options) { f(opts) }
+
+type options struct {
+	procedures map[string]procedure
+}
+
+type procedure struct {
+	newRequest  func() proto.Message
+	newResponse func() proto.Message
+}
+
+func newOptions(opts []Option) options {
+	o := options{procedures: make(map[string]procedure)}
+	for _, opt := range opts {
+		opt.apply(&o)
+	}
+	return o
+}
+
+// Procedure transcodes JSON requests to the named procedure, in the form
+// "package.Service::Method", into the Protobuf message returned by
+// newRequest. Responses are read into the message returned by newResponse
+// and written back as JSON.
+//
+// For oneway procedures, newResponse is never called and may be nil.
+func Procedure(name string, newRequest, newResponse func() proto.Message) Option {
+	return optionFunc(func(o *options) {
+		o.procedures[name] = procedure{
+			newRequest:  newRequest,
+			newResponse: newResponse,
+		}
+	})
+}
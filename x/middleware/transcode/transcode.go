@@ -0,0 +1,184 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package transcode provides inbound middleware that translates JSON
+// requests into Protobuf for Protobuf procedures, and translates their
+// responses back to JSON. This lets a single set of handlers serve both
+// Protobuf clients and humans or browsers speaking JSON, with the handlers
+// only ever seeing the Protobuf encoding.
+//
+// 	tc := transcode.New(
+// 		transcode.Procedure(
+// 			"uber.keyvalue.KeyValue::GetValue",
+// 			func() proto.Message { return &keyvaluepb.GetValueRequest{} },
+// 			func() proto.Message { return &keyvaluepb.GetValueResponse{} },
+// 		),
+// 	)
+// 	dispatcher := yarpc.NewDispatcher(yarpc.Config{
+// 		Name: "keyvalue",
+// 		InboundMiddleware: yarpc.InboundMiddleware{
+// 			Unary:  tc,
+// 			Oneway: tc,
+// 		},
+// 	})
+//
+// Requests to other procedures, and requests which are not JSON, pass
+// through untouched.
+package transcode
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/proto"
+	"go.uber.org/yarpc/api/middleware"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/encoding/protobuf"
+	"go.uber.org/yarpc/internal/bufferpool"
+	"go.uber.org/yarpc/pkg/errors"
+)
+
+var (
+	_ middleware.UnaryInbound  = (*Middleware)(nil)
+	_ middleware.OnewayInbound = (*Middleware)(nil)
+
+	_jsonMarshaler   = &jsonpb.Marshaler{}
+	_jsonUnmarshaler = &jsonpb.Unmarshaler{AllowUnknownFields: true}
+)
+
+// Middleware is inbound middleware which transcodes JSON requests to
+// registered Protobuf procedures.
+type Middleware struct {
+	opts options
+}
+
+// New builds transcoding middleware.
+func New(opts ...Option) *Middleware {
+	return &Middleware{opts: newOptions(opts)}
+}
+
+// Handle implements middleware.UnaryInbound.
+func (m *Middleware) Handle(ctx context.Context, req *transport.Request, w transport.ResponseWriter, h transport.UnaryHandler) error {
+	p, ok := m.procedure(req)
+	if !ok {
+		return h.Handle(ctx, req, w)
+	}
+
+	treq, err := transcodeRequest(req, p)
+	if err != nil {
+		return err
+	}
+
+	buf := bufferpool.Get()
+	defer bufferpool.Put(buf)
+
+	appErr := h.Handle(ctx, treq, &bufferedResponseWriter{ResponseWriter: w, body: buf})
+	if buf.Len() > 0 {
+		if err := transcodeResponse(req, p, buf.Bytes(), w); err != nil && appErr == nil {
+			return err
+		}
+	}
+	return appErr
+}
+
+// HandleOneway implements middleware.OnewayInbound.
+func (m *Middleware) HandleOneway(ctx context.Context, req *transport.Request, h transport.OnewayHandler) error {
+	p, ok := m.procedure(req)
+	if !ok {
+		return h.HandleOneway(ctx, req)
+	}
+
+	treq, err := transcodeRequest(req, p)
+	if err != nil {
+		return err
+	}
+	return h.HandleOneway(ctx, treq)
+}
+
+// procedure returns the message types of the procedure if the request
+// should be transcoded.
+func (m *Middleware) procedure(req *transport.Request) (procedure, bool) {
+	switch req.Encoding {
+	case protobuf.JSONEncoding, protobuf.ProtoJSONEncoding:
+		p, ok := m.opts.procedures[req.Procedure]
+		return p, ok
+	default:
+		return procedure{}, false
+	}
+}
+
+// transcodeRequest returns a copy of the request with its JSON body
+// converted to Protobuf.
+func transcodeRequest(req *transport.Request, p procedure) (*transport.Request, error) {
+	buf := bufferpool.Get()
+	defer bufferpool.Put(buf)
+	if _, err := buf.ReadFrom(req.Body); err != nil {
+		return nil, err
+	}
+
+	var body []byte
+	if buf.Len() > 0 {
+		message := p.newRequest()
+		if err := _jsonUnmarshaler.Unmarshal(bytes.NewReader(buf.Bytes()), message); err != nil {
+			return nil, errors.RequestBodyDecodeError(req, err)
+		}
+		var err error
+		if body, err = proto.Marshal(message); err != nil {
+			return nil, errors.RequestBodyDecodeError(req, err)
+		}
+	}
+
+	treq := *req
+	treq.Encoding = protobuf.Encoding
+	treq.Body = bytes.NewReader(body)
+	return &treq, nil
+}
+
+// transcodeResponse converts a Protobuf response body to JSON and writes it
+// to w.
+func transcodeResponse(req *transport.Request, p procedure, body []byte, w transport.ResponseWriter) error {
+	message := p.newResponse()
+	if err := proto.Unmarshal(body, message); err != nil {
+		return errors.ResponseBodyEncodeError(req, err)
+	}
+	if err := _jsonMarshaler.Marshal(w, message); err != nil {
+		return errors.ResponseBodyEncodeError(req, err)
+	}
+	return nil
+}
+
+// bufferedResponseWriter holds back the response body so that it may be
+// transcoded. Headers and application errors are passed through.
+type bufferedResponseWriter struct {
+	transport.ResponseWriter
+
+	body *bufferpool.Buffer
+}
+
+func (w *bufferedResponseWriter) SetApplicationErrorMeta(meta *transport.ApplicationErrorMeta) {
+	if setter, ok := w.ResponseWriter.(transport.ApplicationErrorMetaSetter); ok {
+		setter.SetApplicationErrorMeta(meta)
+	}
+}
+
+func (w *bufferedResponseWriter) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
@@ -0,0 +1,187 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package transcode
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/api/transport/transporttest"
+	"go.uber.org/yarpc/encoding/protobuf"
+	"go.uber.org/yarpc/internal/examples/protobuf/examplepb"
+	"go.uber.org/yarpc/yarpcerrors"
+)
+
+const _getValue = "uber.yarpc.internal.examples.protobuf.example.KeyValue::GetValue"
+
+type unaryHandlerFunc func(context.Context, *transport.Request, transport.ResponseWriter) error
+
+func (f unaryHandlerFunc) Handle(ctx context.Context, req *transport.Request, w transport.ResponseWriter) error {
+	return f(ctx, req, w)
+}
+
+type onewayHandlerFunc func(context.Context, *transport.Request) error
+
+func (f onewayHandlerFunc) HandleOneway(ctx context.Context, req *transport.Request) error {
+	return f(ctx, req)
+}
+
+func newMiddleware() *Middleware {
+	return New(Procedure(
+		_getValue,
+		func() proto.Message { return &examplepb.GetValueRequest{} },
+		func() proto.Message { return &examplepb.GetValueResponse{} },
+	))
+}
+
+// getValue is a Protobuf-only handler which echoes the requested key.
+func getValue(t *testing.T, appErr error) transport.UnaryHandler {
+	return unaryHandlerFunc(func(_ context.Context, req *transport.Request, w transport.ResponseWriter) error {
+		assert.Equal(t, protobuf.Encoding, req.Encoding)
+		body, err := ioutil.ReadAll(req.Body)
+		require.NoError(t, err)
+
+		var getReq examplepb.GetValueRequest
+		require.NoError(t, proto.Unmarshal(body, &getReq))
+
+		w.AddHeaders(transport.NewHeaders().With("foo", "bar"))
+		if appErr != nil {
+			w.SetApplicationError()
+		}
+		res, err := proto.Marshal(&examplepb.GetValueResponse{Value: "value of " + getReq.Key})
+		require.NoError(t, err)
+		_, err = w.Write(res)
+		require.NoError(t, err)
+		return appErr
+	})
+}
+
+func TestTranscodeJSON(t *testing.T) {
+	tests := []struct {
+		desc     string
+		encoding transport.Encoding
+		appErr   error
+	}{
+		{desc: "json", encoding: protobuf.JSONEncoding},
+		{desc: "proto+json", encoding: protobuf.ProtoJSONEncoding},
+		{desc: "application error", encoding: protobuf.JSONEncoding, appErr: errors.New("great sadness")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			w := new(transporttest.FakeResponseWriter)
+			err := newMiddleware().Handle(context.Background(), &transport.Request{
+				Procedure: _getValue,
+				Encoding:  tt.encoding,
+				Body:      bytes.NewReader([]byte(`{"key": "foo"}`)),
+			}, w, getValue(t, tt.appErr))
+			assert.Equal(t, tt.appErr, err)
+
+			assert.JSONEq(t, `{"value": "value of foo"}`, w.Body.String())
+			assert.Equal(t, tt.appErr != nil, w.IsApplicationError)
+			assert.Equal(t, map[string]string{"foo": "bar"}, w.Headers.Items())
+		})
+	}
+}
+
+func TestTranscodeApplicationErrorMeta(t *testing.T) {
+	meta := &transport.ApplicationErrorMeta{Name: "NotFound", Message: "not found"}
+	h := unaryHandlerFunc(func(ctx context.Context, req *transport.Request, w transport.ResponseWriter) error {
+		w.(transport.ApplicationErrorMetaSetter).SetApplicationErrorMeta(meta)
+		return getValue(t, errors.New("not found")).Handle(ctx, req, w)
+	})
+
+	w := new(transporttest.FakeResponseWriter)
+	err := newMiddleware().Handle(context.Background(), &transport.Request{
+		Procedure: _getValue,
+		Encoding:  protobuf.JSONEncoding,
+		Body:      bytes.NewReader([]byte(`{"key": "foo"}`)),
+	}, w, h)
+	assert.EqualError(t, err, "not found")
+	assert.True(t, w.IsApplicationError)
+	assert.Equal(t, meta, w.ApplicationErrorMeta, "application error meta must be forwarded")
+}
+
+func TestPassThrough(t *testing.T) {
+	tests := []struct {
+		desc      string
+		procedure string
+		encoding  transport.Encoding
+	}{
+		{desc: "protobuf request", procedure: _getValue, encoding: protobuf.Encoding},
+		{desc: "unknown procedure", procedure: "other", encoding: protobuf.JSONEncoding},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			req := &transport.Request{
+				Procedure: tt.procedure,
+				Encoding:  tt.encoding,
+				Body:      bytes.NewReader([]byte("body")),
+			}
+			var called bool
+			h := unaryHandlerFunc(func(_ context.Context, got *transport.Request, w transport.ResponseWriter) error {
+				called = true
+				assert.True(t, got == req, "request was modified")
+				return nil
+			})
+			require.NoError(t, newMiddleware().Handle(context.Background(), req, new(transporttest.FakeResponseWriter), h))
+			assert.True(t, called)
+		})
+	}
+}
+
+func TestInvalidJSON(t *testing.T) {
+	h := unaryHandlerFunc(func(context.Context, *transport.Request, transport.ResponseWriter) error {
+		t.Fatal("handler must not be called")
+		return nil
+	})
+	err := newMiddleware().Handle(context.Background(), &transport.Request{
+		Procedure: _getValue,
+		Encoding:  protobuf.JSONEncoding,
+		Body:      bytes.NewReader([]byte(`{"key":`)),
+	}, new(transporttest.FakeResponseWriter), h)
+	assert.Equal(t, yarpcerrors.CodeInvalidArgument, yarpcerrors.FromError(err).Code())
+}
+
+func TestTranscodeOneway(t *testing.T) {
+	var got examplepb.GetValueRequest
+	h := onewayHandlerFunc(func(_ context.Context, req *transport.Request) error {
+		assert.Equal(t, protobuf.Encoding, req.Encoding)
+		body, err := ioutil.ReadAll(req.Body)
+		require.NoError(t, err)
+		return proto.Unmarshal(body, &got)
+	})
+
+	require.NoError(t, newMiddleware().HandleOneway(context.Background(), &transport.Request{
+		Procedure: _getValue,
+		Encoding:  protobuf.JSONEncoding,
+		Body:      bytes.NewReader([]byte(`{"key": "foo"}`)),
+	}, h))
+	assert.Equal(t, "foo", got.Key)
+}
@@ -0,0 +1,153 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package register
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+var _ Registry = (*Consul)(nil)
+
+// Consul registers instances as services with the local Consul agent, each
+// with a TTL check that the registrar passes when it renews the
+// registration. Instances are tagged with the name of their transport.
+type Consul struct {
+	// Address is the address of the Consul agent.
+	//
+	// Defaults to http://127.0.0.1:8500.
+	Address string
+
+	// Token is the ACL token for the Consul agent, if any.
+	Token string
+
+	// Tags are added to the tags of every instance.
+	Tags []string
+
+	// TTL is how long an instance stays passing without a renewal.
+	//
+	// Defaults to 15 seconds.
+	TTL time.Duration
+
+	// DeregisterAfter is how long an instance may be critical before the
+	// agent deregisters it.
+	//
+	// Defaults to 1 minute.
+	DeregisterAfter time.Duration
+
+	// HTTPClient is the client used to call the agent.
+	//
+	// Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+type consulCheck struct {
+	TTL                            string `json:"TTL"`
+	DeregisterCriticalServiceAfter string `json:"DeregisterCriticalServiceAfter"`
+}
+
+type consulService struct {
+	ID      string      `json:"ID"`
+	Name    string      `json:"Name"`
+	Address string      `json:"Address"`
+	Port    int         `json:"Port"`
+	Tags    []string    `json:"Tags"`
+	Check   consulCheck `json:"Check"`
+}
+
+// Register registers the instance as a service with a TTL check.
+func (c *Consul) Register(ctx context.Context, inst Instance) error {
+	ttl, deregisterAfter := c.TTL, c.DeregisterAfter
+	if ttl <= 0 {
+		ttl = 15 * time.Second
+	}
+	if deregisterAfter <= 0 {
+		deregisterAfter = time.Minute
+	}
+
+	body, err := json.Marshal(consulService{
+		ID:      inst.ID,
+		Name:    inst.Service,
+		Address: inst.Host,
+		Port:    inst.Port,
+		Tags:    append([]string{inst.Transport}, c.Tags...),
+		Check: consulCheck{
+			TTL:                            ttl.String(),
+			DeregisterCriticalServiceAfter: deregisterAfter.String(),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if err := c.put(ctx, "/v1/agent/service/register", body); err != nil {
+		return err
+	}
+	// The check starts critical, so pass it at once.
+	return c.Renew(ctx, inst)
+}
+
+// Renew passes the TTL check of the instance.
+func (c *Consul) Renew(ctx context.Context, inst Instance) error {
+	return c.put(ctx, "/v1/agent/check/pass/service:"+url.PathEscape(inst.ID), nil)
+}
+
+// Deregister deregisters the service of the instance.
+func (c *Consul) Deregister(ctx context.Context, inst Instance) error {
+	return c.put(ctx, "/v1/agent/service/deregister/"+url.PathEscape(inst.ID), nil)
+}
+
+func (c *Consul) put(ctx context.Context, path string, body []byte) error {
+	addr := c.Address
+	if addr == "" {
+		addr = "http://127.0.0.1:8500"
+	}
+	req, err := http.NewRequest("PUT", addr+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	if c.Token != "" {
+		req.Header.Set("X-Consul-Token", c.Token)
+	}
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	io.Copy(ioutil.Discard, res.Body)
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected response from Consul: %s", res.Status)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package register
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAgent records the calls to the Consul agent API.
+type fakeAgent struct {
+	t *testing.T
+
+	mu       sync.Mutex
+	calls    []string
+	services map[string]consulService
+}
+
+func (a *fakeAgent) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	assert.Equal(a.t, "PUT", r.Method)
+	assert.Equal(a.t, "secret", r.Header.Get("X-Consul-Token"))
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.calls = append(a.calls, r.URL.Path)
+	if r.URL.Path == "/v1/agent/service/register" {
+		var s consulService
+		assert.NoError(a.t, json.NewDecoder(r.Body).Decode(&s))
+		a.services[s.ID] = s
+	}
+}
+
+func TestConsul(t *testing.T) {
+	agent := &fakeAgent{t: t, services: make(map[string]consulService)}
+	server := httptest.NewServer(agent)
+	defer server.Close()
+
+	consul := &Consul{Address: server.URL, Token: "secret", Tags: []string{"primary"}, TTL: 10 * time.Second}
+	inst := Instance{ID: "myservice-http-10.0.0.1:8080", Service: "myservice", Transport: "http", Host: "10.0.0.1", Port: 8080}
+
+	ctx := context.Background()
+	require.NoError(t, consul.Register(ctx, inst))
+	require.NoError(t, consul.Renew(ctx, inst))
+	require.NoError(t, consul.Deregister(ctx, inst))
+
+	assert.Equal(t, []string{
+		"/v1/agent/service/register",
+		"/v1/agent/check/pass/service:myservice-http-10.0.0.1:8080",
+		"/v1/agent/check/pass/service:myservice-http-10.0.0.1:8080",
+		"/v1/agent/service/deregister/myservice-http-10.0.0.1:8080",
+	}, agent.calls)
+	assert.Equal(t, consulService{
+		ID:      "myservice-http-10.0.0.1:8080",
+		Name:    "myservice",
+		Address: "10.0.0.1",
+		Port:    8080,
+		Tags:    []string{"http", "primary"},
+		Check:   consulCheck{TTL: "10s", DeregisterCriticalServiceAfter: "1m0s"},
+	}, agent.services[inst.ID])
+}
+
+func TestConsulError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "permission denied", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	err := (&Consul{Address: server.URL}).Register(context.Background(), Instance{ID: "a"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "403 Forbidden")
+}
@@ -0,0 +1,211 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package register
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/multierr"
+)
+
+var _ Registry = (*Etcd)(nil)
+
+var errLeaseExpired = errors.New("the lease of the instance expired")
+
+// Etcd registers instances as keys under a prefix, each attached to a lease
+// that the registrar keeps alive when it renews the registration. The value
+// of each key is the host:port address of the instance, which the etcd peer
+// list updater understands.
+//
+// Etcd uses the JSON gateway of the etcd v3 API.
+type Etcd struct {
+	// Endpoints are the addresses of the etcd servers, tried in order.
+	//
+	// Defaults to http://127.0.0.1:2379.
+	Endpoints []string
+
+	// Prefix is the prefix of the instances' keys, like /services/myservice/.
+	Prefix string
+
+	// TTL is how long a key lasts without a renewal.
+	//
+	// Defaults to 15 seconds.
+	TTL time.Duration
+
+	// HTTPClient is the client used to call etcd.
+	//
+	// Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+
+	mu     sync.Mutex
+	leases map[string]int64
+}
+
+// The following types mirror the JSON forms of the etcd v3 API messages the
+// registry uses. Bytes are base64 encoded and 64-bit integers are encoded as
+// strings by the gateway.
+
+type etcdLeaseGrantRequest struct {
+	TTL int64 `json:"TTL,string"`
+}
+
+type etcdLeaseRequest struct {
+	ID int64 `json:"ID,string"`
+}
+
+type etcdLeaseResponse struct {
+	ID  int64 `json:"ID,string"`
+	TTL int64 `json:"TTL,string"`
+}
+
+type etcdKeepAliveResponse struct {
+	Result etcdLeaseResponse `json:"result"`
+}
+
+type etcdPutRequest struct {
+	Key   []byte `json:"key"`
+	Value []byte `json:"value"`
+	Lease int64  `json:"lease,string"`
+}
+
+// Register grants a lease and puts the key of the instance with it.
+func (e *Etcd) Register(ctx context.Context, inst Instance) error {
+	ttl := e.TTL
+	if ttl <= 0 {
+		ttl = 15 * time.Second
+	}
+
+	var lease etcdLeaseResponse
+	if err := e.post(ctx, "/v3/lease/grant", etcdLeaseGrantRequest{TTL: int64(ttl / time.Second)}, &lease); err != nil {
+		return err
+	}
+	put := etcdPutRequest{
+		Key:   []byte(e.Prefix + inst.ID),
+		Value: []byte(inst.Address()),
+		Lease: lease.ID,
+	}
+	if err := e.post(ctx, "/v3/kv/put", put, nil); err != nil {
+		return multierr.Append(err, e.post(ctx, "/v3/lease/revoke", etcdLeaseRequest{ID: lease.ID}, nil))
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.leases == nil {
+		e.leases = make(map[string]int64)
+	}
+	e.leases[inst.ID] = lease.ID
+	return nil
+}
+
+// Renew keeps the lease of the instance alive.
+func (e *Etcd) Renew(ctx context.Context, inst Instance) error {
+	id, ok := e.lease(inst)
+	if !ok {
+		return errLeaseExpired
+	}
+
+	var res etcdKeepAliveResponse
+	if err := e.post(ctx, "/v3/lease/keepalive", etcdLeaseRequest{ID: id}, &res); err != nil {
+		return err
+	}
+	// etcd answers keep alives of leases that expired with a TTL of zero.
+	if res.Result.TTL <= 0 {
+		return errLeaseExpired
+	}
+	return nil
+}
+
+// Deregister revokes the lease of the instance, which deletes its key.
+func (e *Etcd) Deregister(ctx context.Context, inst Instance) error {
+	id, ok := e.lease(inst)
+	if !ok {
+		return nil
+	}
+	if err := e.post(ctx, "/v3/lease/revoke", etcdLeaseRequest{ID: id}, nil); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.leases, inst.ID)
+	return nil
+}
+
+func (e *Etcd) lease(inst Instance) (int64, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	id, ok := e.leases[inst.ID]
+	return id, ok
+}
+
+// post posts the request to each endpoint in turn until one answers, and
+// decodes the answer into the response, if any.
+func (e *Etcd) post(ctx context.Context, path string, request, response interface{}) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	endpoints := e.Endpoints
+	if len(endpoints) == 0 {
+		endpoints = []string{"http://127.0.0.1:2379"}
+	}
+	client := e.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	var errs error
+	for _, endpoint := range endpoints {
+		req, err := http.NewRequest("POST", strings.TrimSuffix(endpoint, "/")+path, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req = req.WithContext(ctx)
+		req.Header.Set("Content-Type", "application/json")
+
+		res, err := client.Do(req)
+		if err != nil {
+			errs = multierr.Append(errs, err)
+			continue
+		}
+		if res.StatusCode != http.StatusOK {
+			res.Body.Close()
+			errs = multierr.Append(errs, fmt.Errorf("unexpected response from etcd: %s", res.Status))
+			continue
+		}
+
+		if response != nil {
+			err = json.NewDecoder(res.Body).Decode(response)
+		}
+		res.Body.Close()
+		return err
+	}
+	return errs
+}
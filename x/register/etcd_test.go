@@ -0,0 +1,117 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package register
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeEtcd implements the leases and keys of the etcd v3 JSON gateway.
+type fakeEtcd struct {
+	t *testing.T
+
+	mu     sync.Mutex
+	nextID int64
+	leases map[int64]int64
+	keys   map[string]etcdPutRequest
+}
+
+func (e *fakeEtcd) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	enc := json.NewEncoder(w)
+	dec := json.NewDecoder(r.Body)
+	switch r.URL.Path {
+	case "/v3/lease/grant":
+		var req etcdLeaseGrantRequest
+		assert.NoError(e.t, dec.Decode(&req))
+		e.nextID++
+		e.leases[e.nextID] = req.TTL
+		assert.NoError(e.t, enc.Encode(etcdLeaseResponse{ID: e.nextID, TTL: req.TTL}))
+	case "/v3/kv/put":
+		var req etcdPutRequest
+		assert.NoError(e.t, dec.Decode(&req))
+		e.keys[string(req.Key)] = req
+		assert.NoError(e.t, enc.Encode(struct{}{}))
+	case "/v3/lease/keepalive":
+		var req etcdLeaseRequest
+		assert.NoError(e.t, dec.Decode(&req))
+		assert.NoError(e.t, enc.Encode(etcdKeepAliveResponse{
+			Result: etcdLeaseResponse{ID: req.ID, TTL: e.leases[req.ID]},
+		}))
+	case "/v3/lease/revoke":
+		var req etcdLeaseRequest
+		assert.NoError(e.t, dec.Decode(&req))
+		delete(e.leases, req.ID)
+		for k, kv := range e.keys {
+			if kv.Lease == req.ID {
+				delete(e.keys, k)
+			}
+		}
+		assert.NoError(e.t, enc.Encode(struct{}{}))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestEtcd(t *testing.T) {
+	fake := &fakeEtcd{t: t, leases: make(map[int64]int64), keys: make(map[string]etcdPutRequest)}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	etcd := &Etcd{
+		// The first endpoint is down, so the registry must fail over.
+		Endpoints: []string{"http://127.0.0.1:1", server.URL},
+		Prefix:    "/services/myservice/",
+		TTL:       10 * time.Second,
+	}
+	inst := Instance{ID: "myservice-http-10.0.0.1:8080", Host: "10.0.0.1", Port: 8080}
+
+	ctx := context.Background()
+	require.NoError(t, etcd.Register(ctx, inst))
+	kv, ok := fake.keys["/services/myservice/myservice-http-10.0.0.1:8080"]
+	require.True(t, ok, "the key of the instance must be put")
+	assert.Equal(t, "10.0.0.1:8080", string(kv.Value))
+	assert.Equal(t, int64(10), fake.leases[kv.Lease])
+
+	require.NoError(t, etcd.Renew(ctx, inst))
+
+	// Expire the lease behind the registry's back.
+	fake.mu.Lock()
+	delete(fake.leases, kv.Lease)
+	fake.mu.Unlock()
+	assert.Equal(t, errLeaseExpired, etcd.Renew(ctx, inst), "expired leases must fail to renew")
+
+	require.NoError(t, etcd.Register(ctx, inst))
+	require.NoError(t, etcd.Deregister(ctx, inst))
+	assert.Empty(t, fake.keys, "keys must be deleted with their leases")
+	assert.Equal(t, errLeaseExpired, etcd.Renew(ctx, inst))
+}
@@ -0,0 +1,267 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package register advertises the inbounds of a dispatcher in a service
+// registry, so that YARPC servers can be discovered without a sidecar.
+//
+// A Registrar registers the address of every inbound that reports one,
+// renews the registrations while it runs, and deregisters them when it
+// stops. Start it after the dispatcher, once the inbounds are listening, and
+// stop it before the dispatcher, so that clients stop sending requests before
+// the inbounds close.
+//
+// 	registrar := register.New(dispatcher.Name(), dispatcher.Inbounds(), &register.Consul{})
+// 	if err := dispatcher.Start(); err != nil {
+// 		log.Fatal(err)
+// 	}
+// 	if err := registrar.Start(); err != nil {
+// 		log.Fatal(err)
+// 	}
+// 	defer dispatcher.Stop()
+// 	defer registrar.Stop()
+//
+// Registries are provided for Consul, which expects a heartbeat for a TTL
+// check, etcd, which attaches keys to a lease, and ZooKeeper, which creates
+// ephemeral nodes. The registrations match what the Consul, etcd and
+// ZooKeeper peer list updaters in go.uber.org/yarpc/peer expect.
+//
+// This package is experimental. Breaking changes may be made between minor
+// releases.
+package register
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/multierr"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/pkg/lifecycle"
+	"go.uber.org/yarpc/yarpcerrors"
+	"go.uber.org/zap"
+)
+
+var _ transport.Lifecycle = (*Registrar)(nil)
+
+// addressable is implemented by inbounds that report the address they
+// listen on, like the HTTP and gRPC inbounds.
+type addressable interface {
+	Addr() net.Addr
+}
+
+// Registrar advertises the inbounds of a service in a registry for the
+// duration of its lifecycle.
+type Registrar struct {
+	once     *lifecycle.Once
+	service  string
+	inbounds []transport.Inbound
+	registry Registry
+	opts     options
+
+	mu        sync.Mutex
+	instances []Instance
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// New returns a Registrar that advertises the given inbounds of the named
+// service in the registry.
+func New(service string, inbounds []transport.Inbound, registry Registry, opts ...Option) *Registrar {
+	return &Registrar{
+		once:     lifecycle.NewOnce(),
+		service:  service,
+		inbounds: inbounds,
+		registry: registry,
+		opts:     newOptions(opts),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start registers the address of every inbound that reports one, and starts
+// renewing the registrations. The inbounds must already be listening.
+func (r *Registrar) Start() error {
+	return r.once.Start(r.start)
+}
+
+func (r *Registrar) start() error {
+	instances, err := r.resolve()
+	if err != nil {
+		return err
+	}
+
+	for i, inst := range instances {
+		if err := r.call(r.registry.Register, inst); err != nil {
+			err = fmt.Errorf("failed to register %v: %v", inst.ID, err)
+			for _, registered := range instances[:i] {
+				err = multierr.Append(err, r.call(r.registry.Deregister, registered))
+			}
+			return err
+		}
+	}
+
+	r.mu.Lock()
+	r.instances = instances
+	r.mu.Unlock()
+
+	go r.renew()
+	return nil
+}
+
+// Stop stops renewing the registrations and deregisters every instance.
+func (r *Registrar) Stop() error {
+	return r.once.Stop(r.stopRegistrar)
+}
+
+func (r *Registrar) stopRegistrar() error {
+	close(r.stop)
+	<-r.done
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var errs error
+	for _, inst := range r.instances {
+		if err := r.call(r.registry.Deregister, inst); err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("failed to deregister %v: %v", inst.ID, err))
+		}
+	}
+	r.instances = nil
+	return errs
+}
+
+// IsRunning returns whether the inbounds are registered.
+func (r *Registrar) IsRunning() bool {
+	return r.once.IsRunning()
+}
+
+// Instances returns the instances the registrar advertises.
+func (r *Registrar) Instances() []Instance {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Instance(nil), r.instances...)
+}
+
+// renew renews every registration at the interval until the registrar
+// stops. An instance whose renewal fails is registered again, since its
+// registration may have expired.
+func (r *Registrar) renew() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.opts.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+		}
+
+		for _, inst := range r.Instances() {
+			err := r.call(r.registry.Renew, inst)
+			if err == nil {
+				continue
+			}
+			r.opts.logger.Warn("failed to renew registration, registering again",
+				zap.String("instance", inst.ID), zap.Error(err))
+			if err := r.call(r.registry.Register, inst); err != nil {
+				r.opts.logger.Error("failed to register instance",
+					zap.String("instance", inst.ID), zap.Error(err))
+			}
+		}
+	}
+}
+
+// call calls the registry with a timeout.
+func (r *Registrar) call(f func(context.Context, Instance) error, inst Instance) error {
+	ctx, cancel := context.WithTimeout(context.Background(), r.opts.timeout)
+	defer cancel()
+	return f(ctx, inst)
+}
+
+// resolve returns the instances to advertise for the inbounds that report
+// their address.
+func (r *Registrar) resolve() ([]Instance, error) {
+	var instances []Instance
+	for _, in := range r.inbounds {
+		a, ok := in.(addressable)
+		if !ok {
+			continue
+		}
+		addr := a.Addr()
+		if addr == nil {
+			return nil, yarpcerrors.FailedPreconditionErrorf(
+				"inbound %T is not listening, start the dispatcher before the registrar", in)
+		}
+
+		host, portStr, err := net.SplitHostPort(addr.String())
+		if err != nil {
+			return nil, err
+		}
+		port, err := strconv.Atoi(portStr)
+		if err != nil {
+			return nil, err
+		}
+		if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+			host = r.advertisedHost()
+		}
+
+		name := path.Base(reflect.Indirect(reflect.ValueOf(in)).Type().PkgPath())
+		inst := Instance{
+			Service:   r.service,
+			Transport: name,
+			Host:      host,
+			Port:      port,
+		}
+		inst.ID = fmt.Sprintf("%s-%s-%s", r.service, name, inst.Address())
+		instances = append(instances, inst)
+	}
+
+	if len(instances) == 0 {
+		return nil, yarpcerrors.InvalidArgumentErrorf("no inbounds of %q report an address to register", r.service)
+	}
+	return instances, nil
+}
+
+// advertisedHost returns the host to advertise for inbounds that listen on
+// all interfaces.
+func (r *Registrar) advertisedHost() string {
+	if r.opts.host != "" {
+		return r.opts.host
+	}
+	if addrs, err := net.InterfaceAddrs(); err == nil {
+		for _, addr := range addrs {
+			if ipnet, ok := addr.(*net.IPNet); ok && !ipnet.IP.IsLoopback() && ipnet.IP.To4() != nil {
+				return ipnet.IP.String()
+			}
+		}
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		return hostname
+	}
+	return "localhost"
+}
@@ -0,0 +1,178 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package register
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/yarpc/api/transport"
+	"go.uber.org/yarpc/internal/testtime"
+)
+
+// fakeInbound reports a fixed address.
+type fakeInbound struct {
+	transport.Inbound
+
+	addr net.Addr
+}
+
+func (i *fakeInbound) Addr() net.Addr { return i.addr }
+
+// fakeRegistry records the instances registered with it.
+type fakeRegistry struct {
+	mu         sync.Mutex
+	registered map[string]Instance
+	renewals   int
+	registers  int
+	renewErr   error
+}
+
+func newFakeRegistry() *fakeRegistry {
+	return &fakeRegistry{registered: make(map[string]Instance)}
+}
+
+func (r *fakeRegistry) Register(ctx context.Context, inst Instance) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.registers++
+	r.registered[inst.ID] = inst
+	return nil
+}
+
+func (r *fakeRegistry) Renew(ctx context.Context, inst Instance) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.renewals++
+	return r.renewErr
+}
+
+func (r *fakeRegistry) Deregister(ctx context.Context, inst Instance) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.registered, inst.ID)
+	return nil
+}
+
+func (r *fakeRegistry) ids() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ids := make([]string, 0, len(r.registered))
+	for id := range r.registered {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (r *fakeRegistry) counts() (registers, renewals int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.registers, r.renewals
+}
+
+func tcpAddr(t *testing.T, addr string) net.Addr {
+	a, err := net.ResolveTCPAddr("tcp", addr)
+	require.NoError(t, err)
+	return a
+}
+
+func TestRegistrar(t *testing.T) {
+	registry := newFakeRegistry()
+	r := New("myservice", []transport.Inbound{
+		&fakeInbound{addr: tcpAddr(t, "10.0.0.1:8080")},
+		&fakeInbound{addr: tcpAddr(t, "[::]:8081")},
+	}, registry, Host("10.0.0.2"), Interval(time.Millisecond))
+
+	require.NoError(t, r.Start())
+	assert.Equal(t, []string{
+		"myservice-register-10.0.0.1:8080",
+		"myservice-register-10.0.0.2:8081",
+	}, registry.ids(), "inbounds listening on all interfaces must be advertised with the host")
+
+	insts := r.Instances()
+	require.Len(t, insts, 2)
+	assert.Equal(t, Instance{
+		ID:        "myservice-register-10.0.0.1:8080",
+		Service:   "myservice",
+		Transport: "register",
+		Host:      "10.0.0.1",
+		Port:      8080,
+	}, insts[0])
+
+	testtime.WaitFor(t, "registrations must be renewed", func() bool {
+		_, renewals := registry.counts()
+		return renewals >= 4
+	})
+
+	require.NoError(t, r.Stop())
+	assert.Empty(t, registry.ids(), "instances must be deregistered when stopping")
+	assert.False(t, r.IsRunning())
+}
+
+func TestRegistrarRegistersAgain(t *testing.T) {
+	registry := newFakeRegistry()
+	registry.renewErr = errors.New("lease expired")
+	r := New("myservice", []transport.Inbound{
+		&fakeInbound{addr: tcpAddr(t, "10.0.0.1:8080")},
+	}, registry, Interval(time.Millisecond))
+
+	require.NoError(t, r.Start())
+	defer r.Stop()
+
+	testtime.WaitFor(t, "instances must be registered again when renewals fail", func() bool {
+		registers, _ := registry.counts()
+		return registers >= 3
+	})
+}
+
+func TestRegistrarErrors(t *testing.T) {
+	tests := []struct {
+		desc     string
+		inbounds []transport.Inbound
+		wantErr  string
+	}{
+		{
+			desc:    "no inbounds",
+			wantErr: `no inbounds of "myservice" report an address to register`,
+		},
+		{
+			desc:     "inbound without an address",
+			inbounds: []transport.Inbound{&fakeInbound{}},
+			wantErr:  "is not listening",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			err := New("myservice", tt.inbounds, newFakeRegistry()).Start()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
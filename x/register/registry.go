@@ -0,0 +1,66 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package register
+
+import (
+	"context"
+	"net"
+	"strconv"
+)
+
+// Instance is an address of the local service to advertise in a registry.
+type Instance struct {
+	// ID uniquely identifies the instance in the registry. It is the
+	// service name, transport and address of the instance.
+	ID string
+
+	// Service is the name of the service.
+	Service string
+
+	// Transport is the name of the transport the instance is served on, like
+	// "http" or "grpc".
+	Transport string
+
+	// Host and Port are the advertised address of the instance.
+	Host string
+	Port int
+}
+
+// Address returns the host:port address of the instance.
+func (i Instance) Address() string {
+	return net.JoinHostPort(i.Host, strconv.Itoa(i.Port))
+}
+
+// Registry advertises instances in a service registry.
+//
+// Registrations should expire unless they are renewed, so that instances
+// that crash without deregistering are forgotten.
+type Registry interface {
+	// Register advertises the instance.
+	Register(ctx context.Context, inst Instance) error
+
+	// Renew keeps the registration of the instance alive, with a heartbeat
+	// or by renewing its lease.
+	Renew(ctx context.Context, inst Instance) error
+
+	// Deregister stops advertising the instance.
+	Deregister(ctx context.Context, inst Instance) error
+}
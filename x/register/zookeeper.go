@@ -0,0 +1,103 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package register
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path"
+)
+
+var _ Registry = (*ZooKeeper)(nil)
+
+var errNodeDeleted = errors.New("the node of the instance was deleted")
+
+// ZooKeeperClient creates and deletes ZooKeeper nodes. ZooKeeper clients
+// keep their sessions alive with their own heartbeats, so the client's
+// ephemeral nodes last for as long as its session.
+type ZooKeeperClient interface {
+	// CreateEphemeral creates an ephemeral node with the given data,
+	// creating its parents if they do not exist.
+	CreateEphemeral(path string, data []byte) error
+
+	// Delete deletes the node, if it exists.
+	Delete(path string) error
+
+	// Exists returns whether the node exists.
+	Exists(path string) (bool, error)
+}
+
+// ZooKeeper registers instances as ephemeral nodes under a path, with the
+// JSON data of a Curator service instance, which Curator's service discovery
+// and the ZooKeeper peer list updater understand.
+type ZooKeeper struct {
+	// Client is the client that creates the nodes.
+	Client ZooKeeperClient
+
+	// Path is the parent of the instances' nodes, like /services/myservice.
+	Path string
+}
+
+type curatorInstance struct {
+	Name        string `json:"name"`
+	ID          string `json:"id"`
+	Address     string `json:"address"`
+	Port        int    `json:"port"`
+	ServiceType string `json:"serviceType"`
+}
+
+// Register creates the ephemeral node of the instance.
+func (z *ZooKeeper) Register(ctx context.Context, inst Instance) error {
+	data, err := json.Marshal(curatorInstance{
+		Name:        inst.Service,
+		ID:          inst.ID,
+		Address:     inst.Host,
+		Port:        inst.Port,
+		ServiceType: "DYNAMIC",
+	})
+	if err != nil {
+		return err
+	}
+	return z.Client.CreateEphemeral(z.node(inst), data)
+}
+
+// Renew checks that the node of the instance still exists, since it is
+// deleted if the client's session expires.
+func (z *ZooKeeper) Renew(ctx context.Context, inst Instance) error {
+	ok, err := z.Client.Exists(z.node(inst))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errNodeDeleted
+	}
+	return nil
+}
+
+// Deregister deletes the node of the instance.
+func (z *ZooKeeper) Deregister(ctx context.Context, inst Instance) error {
+	return z.Client.Delete(z.node(inst))
+}
+
+func (z *ZooKeeper) node(inst Instance) string {
+	return path.Join(z.Path, inst.ID)
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package register
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeZooKeeper holds the nodes created through it.
+type fakeZooKeeper struct {
+	nodes map[string][]byte
+}
+
+func (z *fakeZooKeeper) CreateEphemeral(path string, data []byte) error {
+	z.nodes[path] = data
+	return nil
+}
+
+func (z *fakeZooKeeper) Delete(path string) error {
+	delete(z.nodes, path)
+	return nil
+}
+
+func (z *fakeZooKeeper) Exists(path string) (bool, error) {
+	_, ok := z.nodes[path]
+	return ok, nil
+}
+
+func TestZooKeeper(t *testing.T) {
+	client := &fakeZooKeeper{nodes: make(map[string][]byte)}
+	zk := &ZooKeeper{Client: client, Path: "/services/myservice"}
+	inst := Instance{ID: "myservice-http-10.0.0.1:8080", Service: "myservice", Host: "10.0.0.1", Port: 8080}
+
+	ctx := context.Background()
+	require.NoError(t, zk.Register(ctx, inst))
+	data, ok := client.nodes["/services/myservice/myservice-http-10.0.0.1:8080"]
+	require.True(t, ok, "the node of the instance must be created")
+
+	var curator curatorInstance
+	require.NoError(t, json.Unmarshal(data, &curator))
+	assert.Equal(t, curatorInstance{
+		Name:        "myservice",
+		ID:          "myservice-http-10.0.0.1:8080",
+		Address:     "10.0.0.1",
+		Port:        8080,
+		ServiceType: "DYNAMIC",
+	}, curator)
+
+	require.NoError(t, zk.Renew(ctx, inst))
+	require.NoError(t, zk.Deregister(ctx, inst))
+	assert.Empty(t, client.nodes)
+	assert.Equal(t, errNodeDeleted, zk.Renew(ctx, inst), "deleted nodes must fail to renew")
+}
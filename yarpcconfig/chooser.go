@@ -21,10 +21,13 @@
 package yarpcconfig
 
 import (
+	"errors"
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 
+	"github.com/uber-go/mapdecode"
 	"go.uber.org/yarpc/api/peer"
 	"go.uber.org/yarpc/internal/config"
 	peerbind "go.uber.org/yarpc/peer"
@@ -69,6 +72,19 @@ import (
 // robin peer list. The only remaining key is the name of the peer list
 // updater: `peers` which is just a static list of peers.
 //
+// Each static peer may instead be an object with the `address` of the peer
+// and, optionally, its `weight` and `zone`. These are attached to the peer
+// identifier as attributes, which peer lists like weighted-round-robin,
+// weighted-random and zone-aware use to choose among the peers.
+//
+// 	# cfg.RegisterPeerList(weightedroundrobin.Spec())
+// 	weighted-round-robin:
+// 	  peers:
+// 	    - address: 127.0.0.1:8080
+// 	      weight: 3
+// 	      zone: us-east-1a
+// 	    - 127.0.0.1:8081
+//
 // Integration
 //
 // To integrate peer choosers with your transport, embed this struct into your
@@ -258,7 +274,7 @@ func getPeerListInfo(etc config.AttributeMap, kit *Kit) (name string, config con
 //       record: A
 func buildPeerListUpdater(c config.AttributeMap, identify func(string) peer.Identifier, kit *Kit) (peer.Binder, error) {
 	// Special case for explicit list of peers.
-	var peers []string
+	var peers []staticPeer
 	if _, err := c.Pop("peers", &peers); err != nil {
 		return nil, err
 	}
@@ -322,10 +338,82 @@ func buildPeerListUpdater(c config.AttributeMap, identify func(string) peer.Iden
 	return result.(peer.Binder), nil
 }
 
-func identifyAll(identify func(string) peer.Identifier, peers []string) []peer.Identifier {
+// staticPeer is an entry in a static list of peers. It is decoded from
+// either the address of the peer or an object with the address and,
+// optionally, the weight and zone of the peer.
+type staticPeer struct {
+	Address string
+	Weight  int
+	Zone    string
+}
+
+func (p *staticPeer) Decode(into mapdecode.Into) error {
+	var raw interface{}
+	if err := into(&raw); err != nil {
+		return err
+	}
+	if addr, ok := raw.(string); ok {
+		p.Address = addr
+		return nil
+	}
+
+	var attrs config.AttributeMap
+	if err := config.DecodeInto(&attrs, raw); err != nil {
+		return fmt.Errorf("failed to decode peer: %v", err)
+	}
+
+	var err error
+	p.Address, err = attrs.PopString("address")
+	if err != nil {
+		return err
+	}
+	if p.Address == "" {
+		return errors.New(`peer must have an "address"`)
+	}
+
+	hasWeight, err := attrs.Pop("weight", &p.Weight)
+	if err != nil {
+		return err
+	}
+	if hasWeight && p.Weight <= 0 {
+		return fmt.Errorf("weight of peer %q must be greater than 0, got %d", p.Address, p.Weight)
+	}
+
+	p.Zone, err = attrs.PopString("zone")
+	if err != nil {
+		return err
+	}
+
+	if len(attrs) > 0 {
+		keys := attrs.Keys()
+		sort.Strings(keys)
+		return fmt.Errorf("unrecognized attributes in peer %q: %s", p.Address, strings.Join(keys, ", "))
+	}
+	return nil
+}
+
+// attributes returns the attributes of the peer, or nil if it has none.
+func (p staticPeer) attributes() peer.Attributes {
+	var attrs peer.Attributes
+	if p.Weight > 0 {
+		attrs = peer.Attributes{peer.WeightAttribute: strconv.Itoa(p.Weight)}
+	}
+	if p.Zone != "" {
+		if attrs == nil {
+			attrs = make(peer.Attributes, 1)
+		}
+		attrs[peer.ZoneAttribute] = p.Zone
+	}
+	return attrs
+}
+
+func identifyAll(identify func(string) peer.Identifier, peers []staticPeer) []peer.Identifier {
 	pids := make([]peer.Identifier, len(peers))
 	for i, p := range peers {
-		pids[i] = identify(p)
+		pids[i] = identify(p.Address)
+		if attrs := p.attributes(); attrs != nil {
+			pids[i] = peer.WithAttributes(pids[i], attrs)
+		}
 	}
 	return pids
 }
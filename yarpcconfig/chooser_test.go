@@ -42,6 +42,7 @@ import (
 	"go.uber.org/yarpc/peer/hostport"
 	"go.uber.org/yarpc/peer/pendingheap"
 	"go.uber.org/yarpc/peer/roundrobin"
+	"go.uber.org/yarpc/peer/weightedroundrobin"
 	"go.uber.org/yarpc/peer/x/peerheap"
 	"go.uber.org/yarpc/transport/http"
 	"go.uber.org/yarpc/transport/tchannel"
@@ -326,6 +327,49 @@ func TestChooserConfigurator(t *testing.T) {
 				assert.Contains(t, expectedPeers, peer.Identifier(), "chooses one of the provided peers")
 			},
 		},
+		{
+			desc: "use weighted static peers with weighted round robin and exercise choose",
+			given: whitespace.Expand(`
+				outbounds:
+					their-service:
+						unary:
+							fake-transport:
+								weighted-round-robin:
+									peers:
+									- address: 127.0.0.1:8080
+									  weight: 3
+									  zone: us-east-1a
+									- 127.0.0.1:8081
+			`),
+			test: func(t *testing.T, c yarpc.Config) {
+				outbound := c.Outbounds["their-service"]
+				unary := outbound.Unary.(*yarpctest.FakeOutbound)
+				chooser := unary.Chooser().(*peer.BoundChooser)
+				_, ok := chooser.ChooserList().(*weightedroundrobin.List)
+				require.True(t, ok, "use weighted round robin")
+
+				dispatcher := yarpc.NewDispatcher(c)
+				require.NoError(t, dispatcher.Start(), "error starting dispatcher")
+				defer func() {
+					require.NoError(t, dispatcher.Stop(), "error stopping dispatcher")
+				}()
+
+				ctx, cancel := context.WithTimeout(context.Background(), testtime.Second)
+				defer cancel()
+
+				chosen := make(map[string]int)
+				for i := 0; i < 4; i++ {
+					p, onFinish, err := chooser.Choose(ctx, nil)
+					require.NoError(t, err, "error choosing peer")
+					onFinish(nil)
+					chosen[p.Identifier()]++
+				}
+				assert.Equal(t, map[string]int{
+					"127.0.0.1:8080": 3,
+					"127.0.0.1:8081": 1,
+				}, chosen, "peers must be chosen in proportion to their weights")
+			},
+		},
 		{
 			desc: "use round-robin chooser",
 			given: whitespace.Expand(`
@@ -784,6 +828,57 @@ func TestChooserConfigurator(t *testing.T) {
 				`failed to read attribute "peers"`,
 			},
 		},
+		{
+			desc: "static peer without an address",
+			given: whitespace.Expand(`
+				outbounds:
+					their-service:
+						unary:
+							fake-transport:
+								fake-list:
+									peers:
+									- weight: 3
+			`),
+			wantErr: []string{
+				`failed to configure unary outbound for "their-service": `,
+				`failed to read attribute "peers"`,
+				`peer must have an "address"`,
+			},
+		},
+		{
+			desc: "static peer with an invalid weight",
+			given: whitespace.Expand(`
+				outbounds:
+					their-service:
+						unary:
+							fake-transport:
+								fake-list:
+									peers:
+									- address: 127.0.0.1:8080
+									  weight: 0
+			`),
+			wantErr: []string{
+				`failed to configure unary outbound for "their-service": `,
+				`weight of peer "127.0.0.1:8080" must be greater than 0, got 0`,
+			},
+		},
+		{
+			desc: "static peer with extraneous config",
+			given: whitespace.Expand(`
+				outbounds:
+					their-service:
+						unary:
+							fake-transport:
+								fake-list:
+									peers:
+									- address: 127.0.0.1:8080
+									  conspicuously: present
+			`),
+			wantErr: []string{
+				`failed to configure unary outbound for "their-service": `,
+				`unrecognized attributes in peer "127.0.0.1:8080": conspicuously`,
+			},
+		},
 		{
 			desc: "extraneous config in combination with custom updater",
 			given: whitespace.Expand(`
@@ -1038,6 +1133,7 @@ func TestChooserConfigurator(t *testing.T) {
 			configer.MustRegisterPeerList(peerheap.Spec())
 			configer.MustRegisterPeerList(pendingheap.Spec())
 			configer.MustRegisterPeerList(roundrobin.Spec())
+			configer.MustRegisterPeerList(weightedroundrobin.Spec())
 			configer.MustRegisterPeerChooser(invalidPeerChooserSpec())
 			configer.MustRegisterPeerList(invalidPeerListSpec())
 			configer.MustRegisterPeerListUpdater(invalidPeerListUpdaterSpec())
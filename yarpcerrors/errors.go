@@ -68,6 +68,7 @@ type Status struct {
 	code    Code
 	name    string
 	message string
+	details []byte
 }
 
 // WithName returns a new Status with the given name.
@@ -80,8 +81,6 @@ type Status struct {
 //
 // Deprecated: Use only error codes to represent the type of the error.
 func (s *Status) WithName(name string) *Status {
-	// TODO: We plan to add a WithDetails method to add semantic metadata to
-	// Statuses soon.
 	if s == nil {
 		return nil
 	}
@@ -92,6 +91,25 @@ func (s *Status) WithName(name string) *Status {
 		code:    s.code,
 		name:    name,
 		message: s.message,
+		details: s.details,
+	}
+}
+
+// WithDetails returns a new Status with the given details.
+//
+// Details are an encoding-specific representation of the error, like a
+// declared Thrift exception encoded with the Thrift binary protocol, for
+// callers and middleware which need more than the code and message to
+// handle or classify the error.
+func (s *Status) WithDetails(details []byte) *Status {
+	if s == nil {
+		return nil
+	}
+	return &Status{
+		code:    s.code,
+		name:    s.name,
+		message: s.message,
+		details: details,
 	}
 }
 
@@ -122,6 +140,15 @@ func (s *Status) Message() string {
 	return s.message
 }
 
+// Details returns the details of the error for this Status, or nil if it
+// has none.
+func (s *Status) Details() []byte {
+	if s == nil {
+		return nil
+	}
+	return s.details
+}
+
 // Error implements the error interface.
 func (s *Status) Error() string {
 	buffer := bytes.NewBuffer(nil)
@@ -132,6 +132,18 @@ func TestErrorMessage(t *testing.T) {
 	)
 }
 
+func TestErrorDetails(t *testing.T) {
+	status := Newf(CodeUnknown, "great sadness").WithDetails([]byte("details"))
+	assert.Equal(t, []byte("details"), status.Details())
+	assert.Equal(t, CodeUnknown, status.Code())
+	assert.Equal(t, "great sadness", status.Message())
+	assert.Equal(t, []byte("details"), status.WithName("sadness").Details(), "names must keep details")
+
+	assert.Nil(t, Newf(CodeUnknown, "great sadness").Details())
+	assert.Nil(t, FromError(nil).WithDetails([]byte("details")))
+	assert.Nil(t, FromError(nil).Details())
+}
+
 func TestIsErrorWithCode(t *testing.T) {
 	for code, errorConstructor := range _codeToErrorConstructor {
 		t.Run(code.String(), func(t *testing.T) {
@@ -62,7 +62,7 @@ func (t *FakeTransport) NopOption() string {
 
 // RetainPeer returns a fake peer.
 func (t *FakeTransport) RetainPeer(id peer.Identifier, ps peer.Subscriber) (peer.Peer, error) {
-	return &FakePeer{id: id.(hostport.PeerIdentifier)}, nil
+	return &FakePeer{id: hostport.PeerIdentifier(id.Identifier())}, nil
 }
 
 // ReleasePeer does nothing.
@@ -0,0 +1,80 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package grpcinterop
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Client is a reference grpc-go client which sends raw bytes.
+type Client struct {
+	conn *grpc.ClientConn
+}
+
+// NewClient builds a Client connected to the given host:port.
+func NewClient(addr string) (*Client, error) {
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	if err != nil {
+		return nil, err
+	}
+	return &Client{conn: conn}, nil
+}
+
+// Call makes a unary call to the given full method (for example,
+// "/Service/Method") with the given request metadata. It returns the
+// response body and the response headers and trailers, merged.
+func (c *Client) Call(ctx context.Context, fullMethod string, md metadata.MD, body []byte) ([]byte, metadata.MD, error) {
+	var (
+		res     []byte
+		header  metadata.MD
+		trailer metadata.MD
+	)
+	err := c.conn.Invoke(
+		metadata.NewOutgoingContext(ctx, md),
+		fullMethod,
+		body,
+		&res,
+		grpc.CallCustomCodec(rawCodec{}),
+		grpc.Header(&header),
+		grpc.Trailer(&trailer),
+	)
+	return res, metadata.Join(header, trailer), err
+}
+
+// Stream opens a bidirectional stream to the given full method with the
+// given request metadata. Messages sent and received on the stream must be
+// of type []byte and *[]byte respectively.
+func (c *Client) Stream(ctx context.Context, fullMethod string, md metadata.MD) (grpc.ClientStream, error) {
+	return c.conn.NewStream(
+		metadata.NewOutgoingContext(ctx, md),
+		&grpc.StreamDesc{ClientStreams: true, ServerStreams: true},
+		fullMethod,
+		grpc.CallCustomCodec(rawCodec{}),
+	)
+}
+
+// Close closes the underlying connection.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package grpcinterop provides stock grpc-go clients and servers for
+// testing the wire compatibility of gRPC transports.
+//
+// Server is a grpc-go server that accepts any method, echoes every message
+// it receives, and reports what it observed about the request in its
+// response metadata. Client is a thin wrapper around a grpc-go
+// ClientConn which sends raw bytes to any method. Neither depends on YARPC,
+// so they behave exactly as third-party gRPC peers would.
+//
+// 	server, err := grpcinterop.NewServer()
+// 	...
+// 	defer server.Stop()
+// 	outbound := grpc.NewTransport().NewSingleOutbound(server.Addr())
+package grpcinterop
+
+import "fmt"
+
+const (
+	// ErrorCodeKey is a request metadata key which, when set to the numeric
+	// value of a gRPC status code, makes Server fail the call with that
+	// code.
+	ErrorCodeKey = "interop-error-code"
+
+	// ErrorMessageKey is a request metadata key holding the message of the
+	// status returned when ErrorCodeKey is set.
+	ErrorMessageKey = "interop-error-message"
+
+	// DeadlineKey is a response metadata key holding the number of
+	// milliseconds that remained before the deadline of the call when Server
+	// received it. It is absent if the call had no deadline.
+	DeadlineKey = "interop-deadline-ms"
+)
+
+// rawCodec passes bytes to and from the wire without modification.
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v interface{}) ([]byte, error) {
+	b, ok := v.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("expected []byte but got %T", v)
+	}
+	return b, nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v interface{}) error {
+	b, ok := v.(*[]byte)
+	if !ok {
+		return fmt.Errorf("expected *[]byte but got %T", v)
+	}
+	*b = data
+	return nil
+}
+
+func (rawCodec) String() string {
+	return "raw"
+}
@@ -0,0 +1,135 @@
+// Copyright (c) 2018 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package grpcinterop
+
+import (
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Server is a reference grpc-go server.
+//
+// For every call, regardless of method, Server:
+//
+//  - echoes all request metadata except reserved ("rpc-", "grpc-" and
+//    pseudo) headers in its response trailers, along with DeadlineKey;
+//  - fails with the status described by ErrorCodeKey and ErrorMessageKey,
+//    if present, without reading any messages; and otherwise
+//  - echoes every message it receives until the client closes its side of
+//    the stream.
+//
+// Unary and streaming calls are handled alike.
+type Server struct {
+	listener net.Listener
+	server   *grpc.Server
+}
+
+// NewServer starts a new Server listening on a random local port.
+func NewServer() (*Server, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		listener: listener,
+		server: grpc.NewServer(
+			grpc.CustomCodec(rawCodec{}),
+			grpc.UnknownServiceHandler(handle),
+		),
+	}
+	go func() {
+		// Serve only returns once the server is stopped.
+		_ = s.server.Serve(listener)
+	}()
+	return s, nil
+}
+
+// Addr returns the host:port on which the server is listening.
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Stop stops the server, closing all open connections.
+func (s *Server) Stop() {
+	s.server.Stop()
+}
+
+func handle(_ interface{}, stream grpc.ServerStream) error {
+	ctx := stream.Context()
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	trailer := metadata.MD{}
+	for k, v := range md {
+		if !isReserved(k) {
+			trailer[k] = v
+		}
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		remaining := time.Until(deadline) / time.Millisecond
+		trailer[DeadlineKey] = []string{strconv.FormatInt(int64(remaining), 10)}
+	}
+	stream.SetTrailer(trailer)
+
+	if code := first(md, ErrorCodeKey); code != "" {
+		n, err := strconv.Atoi(code)
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "invalid %s: %q", ErrorCodeKey, code)
+		}
+		return status.Error(codes.Code(n), first(md, ErrorMessageKey))
+	}
+
+	for {
+		var msg []byte
+		if err := stream.RecvMsg(&msg); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if err := stream.SendMsg(msg); err != nil {
+			return err
+		}
+	}
+}
+
+func isReserved(key string) bool {
+	key = strings.ToLower(key)
+	return strings.HasPrefix(key, "rpc-") ||
+		strings.HasPrefix(key, "grpc-") ||
+		strings.HasPrefix(key, ":") ||
+		key == "content-type" ||
+		key == "user-agent"
+}
+
+func first(md metadata.MD, key string) string {
+	if values := md[key]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}